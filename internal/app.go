@@ -232,6 +232,12 @@ func (app *Application) Init() {
 		app.logger.Fatal("Service init failed", zap.Error(err))
 	}
 
+	err = micro.RegisterHandler(app.service.Server(), service.NewExtendedBillingService(app.svc))
+
+	if err != nil {
+		app.logger.Fatal("Extended service init failed", zap.Error(err))
+	}
+
 	app.router = http.NewServeMux()
 	app.initHealth()
 	app.initMetrics()
@@ -369,6 +375,10 @@ func (app *Application) TaskCreateRoyaltyReport() error {
 	return app.svc.CreateRoyaltyReport(context.TODO(), &billingpb.CreateRoyaltyReportRequest{}, &billingpb.CreateRoyaltyReportRequest{})
 }
 
+func (app *Application) TaskCreateScheduledRoyaltyReports() error {
+	return app.svc.CreateScheduledRoyaltyReports(context.TODO())
+}
+
 func (app *Application) TaskAutoAcceptRoyaltyReports() error {
 	return app.svc.AutoAcceptRoyaltyReports(context.TODO(), &billingpb.EmptyRequest{}, &billingpb.EmptyResponse{})
 }
@@ -389,6 +399,108 @@ func (app *Application) TaskFixTaxes() error {
 	return app.svc.FixTaxes(context.TODO())
 }
 
+func (app *Application) TaskRotateKeyCodes() error {
+	return app.svc.RotateKeyCodes(context.TODO())
+}
+
+func (app *Application) TaskReleaseExpiredKeys() error {
+	count, err := app.svc.ReleaseExpiredKeys(context.TODO())
+
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("Expired key reservations released", zap.Int("count", count))
+
+	return nil
+}
+
+func (app *Application) TaskDeliverKeyPreOrders() error {
+	count, err := app.svc.DeliverKeyPreOrders(context.TODO())
+
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("Key pre-orders delivered", zap.Int("count", count))
+
+	return nil
+}
+
+func (app *Application) TaskRefreshPriceTables() error {
+	version, err := app.svc.RefreshPriceTables(context.TODO())
+
+	if err != nil {
+		return err
+	}
+
+	if version != nil {
+		zap.L().Info("Price tables version created", zap.Int32("version", version.Version))
+	}
+
+	return nil
+}
+
+func (app *Application) TaskLoadSanctionsLists() error {
+	count, err := app.svc.LoadSanctionsLists(context.TODO())
+
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("Sanctions lists loaded", zap.Int("count", count))
+
+	return nil
+}
+
+func (app *Application) TaskPurgeCustomerHistory() error {
+	count, err := app.svc.PurgeCustomerHistory(context.TODO())
+
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("Customer history purged", zap.Int64("count", count))
+
+	return nil
+}
+
+func (app *Application) TaskEncryptPersonalData() error {
+	count, err := app.svc.EncryptPersonalData(context.TODO())
+
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("Personal data encrypted", zap.Int("count", count))
+
+	return nil
+}
+
+func (app *Application) TaskImportCardUpdates() error {
+	count, err := app.svc.ImportCardUpdates(context.TODO())
+
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("Card updates imported", zap.Int64("count", count))
+
+	return nil
+}
+
+func (app *Application) TaskMonitorMerchantRisk() error {
+	count, err := app.svc.MonitorMerchantRisk(context.TODO())
+
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("Merchant risk monitoring finished", zap.Int("alerts", count))
+
+	return nil
+}
+
 func (app *Application) KeyDaemonStart() {
 	zap.L().Info("Key daemon started", zap.Int64("RestartInterval", app.cfg.KeyDaemonRestartInterval))
 
@@ -416,3 +528,56 @@ func (app *Application) KeyDaemonStart() {
 		}
 	}()
 }
+
+func (app *Application) KeyImportDaemonStart() {
+	zap.L().Info("Key import daemon started", zap.Int64("Interval", app.cfg.KeyImportDaemonInterval))
+
+	go func() {
+		interval := time.Duration(app.cfg.KeyImportDaemonInterval) * time.Second
+		shutdown := make(chan os.Signal, 1)
+		signal.Notify(shutdown, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+
+		for {
+			select {
+			case <-shutdown:
+				zap.S().Info("Key import daemon stopping")
+				return
+			default:
+				count, err := app.svc.KeyImportDaemonProcess(context.TODO())
+				if err != nil {
+					zap.L().Error("Key import daemon process failed", zap.Error(err))
+				}
+
+				if count > 0 {
+					zap.L().Info("Key import jobs processed", zap.Int("count", count))
+				}
+
+				time.Sleep(interval)
+			}
+		}
+	}()
+}
+
+func (app *Application) KeyMetricsDaemonStart() {
+	zap.L().Info("Key metrics daemon started", zap.Int64("Interval", app.cfg.KeyMetricsInterval))
+
+	go func() {
+		interval := time.Duration(app.cfg.KeyMetricsInterval) * time.Second
+		shutdown := make(chan os.Signal, 1)
+		signal.Notify(shutdown, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+
+		for {
+			select {
+			case <-shutdown:
+				zap.S().Info("Key metrics daemon stopping")
+				return
+			default:
+				if err := app.svc.UpdateKeyMetrics(context.TODO()); err != nil {
+					zap.L().Error("Key metrics update failed", zap.Error(err))
+				}
+
+				time.Sleep(interval)
+			}
+		}
+	}()
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"crypto/rsa"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/kelseyhightower/envconfig"
@@ -11,6 +12,10 @@ import (
 	"time"
 )
 
+const (
+	EnvironmentDevelopment = "dev"
+)
+
 type PaymentSystemConfig struct {
 	CardPayApiUrl        string `envconfig:"CARD_PAY_API_URL" required:"true"`
 	CardPayApiSandboxUrl string `envconfig:"CARD_PAY_API_SANDBOX_URL" required:"true"`
@@ -53,6 +58,36 @@ type EmailTemplates struct {
 	OnboardingCompleted            string `envconfig:"EMAIL_MERCHANT_ONBOARDING_REQUEST_COMPLETE_TEMPLATE" default:"p1_email_merchant_onboarding_request_complete_template"`
 	UserInvite                     string `envconfig:"EMAIL_INVITE_TEMPLATE" default:"code-your-own"`
 	MerchantAgreementSigned        string `envconfig:"EMAIL_MERCHANT_AGREEMENT_SIGNED" default:"p1_agreement_fully_signed"`
+	MerchantBankingChanged         string `envconfig:"EMAIL_MERCHANT_BANKING_CHANGED_TEMPLATE" default:"p1_merchant_banking_changed"`
+	MerchantRiskAlert              string `envconfig:"EMAIL_MERCHANT_RISK_ALERT_TEMPLATE" default:"p1_merchant_risk_alert"`
+	RiskManagerMerchantRiskAlert   string `envconfig:"EMAIL_RISK_MANAGER_MERCHANT_RISK_ALERT_TEMPLATE" default:"p1_risk_manager_merchant_risk_alert"`
+}
+
+// KeyCodeEncryption defines the master keys of the local key management service used for envelope encryption
+// of game activation keys. Master keys are set as comma separated pairs of identifier and base64 encoded 32 bytes key.
+// Master keys are required outside the development environment, the rotate_key_codes task encrypts existing keys
+// stored in plaintext after encryption is enabled.
+type KeyCodeEncryption struct {
+	MasterKeysBase64 map[string]string `envconfig:"KEY_CODE_MASTER_KEYS"`
+	MasterKeyId      string            `envconfig:"KEY_CODE_MASTER_KEY_ID"`
+	// secret for the keyed hash of key code which used to find duplicates of encrypted codes
+	IndexSecret string            `envconfig:"KEY_CODE_INDEX_SECRET"`
+	MasterKeys  map[string][]byte `ignored:"true"`
+}
+
+// PersonalDataEncryption defines keys of the field-level encryption of personal data of customers and orders.
+// Keys are set as comma separated pairs of identifier and base64 encoded 32 bytes key. Previous keys must be kept
+// until the encrypt_personal_data task re-encrypts data by the current key. Encryption is disabled without keys,
+// except bank cards saved to the card vault which are always encrypted and aren't saved without keys.
+type PersonalDataEncryption struct {
+	KeysBase64 map[string]string `envconfig:"PERSONAL_DATA_ENCRYPTION_KEYS"`
+	KeyId      string            `envconfig:"PERSONAL_DATA_ENCRYPTION_KEY_ID"`
+	// secret for blind indexes of encrypted fields used to find customers and orders by email and phone
+	IndexSecret string `envconfig:"PERSONAL_DATA_INDEX_SECRET"`
+	// groups of encrypted fields of customers and orders, any of email, phone, name, ip, user_agent, address
+	// and card_holder
+	Fields []string          `envconfig:"PERSONAL_DATA_ENCRYPTION_FIELDS" default:"email,phone,name,ip,user_agent,address,card_holder"`
+	Keys   map[string][]byte `ignored:"true"`
 }
 
 type Centrifugo struct {
@@ -81,6 +116,7 @@ type Config struct {
 	RoyaltyReportAcceptTimeout int64  `envconfig:"ROYALTY_REPORT_TIMEZONE" default:"432000"`
 	// moved to config for testing purposes, to prevent royalty reports tests crash on mondays before 18:00
 	// must not be changed on normal app running, because it will broke royalty reports calculations
+	// of merchants with default schedule, use merchant royalty schedule to change period of merchant reports
 	RoyaltyReportPeriodEndHour int64 `default:"18"`
 
 	CentrifugoMerchantChannel  string `envconfig:"CENTRIFUGO_MERCHANT_CHANNEL" default:"paysuper:merchant#%s"`
@@ -96,6 +132,8 @@ type Config struct {
 	HelloSignAgreementClientId string `envconfig:"HELLO_SIGN_AGREEMENT_CLIENT_ID" required:"true"`
 
 	KeyDaemonRestartInterval int64 `envconfig:"KEY_DAEMON_RESTART_INTERVAL" default:"60"`
+	KeyImportDaemonInterval  int64 `envconfig:"KEY_IMPORT_DAEMON_INTERVAL" default:"5"`
+	KeyMetricsInterval       int64 `envconfig:"KEY_METRICS_INTERVAL" default:"300"`
 
 	PaylinkMinProducts int `envconfig:"PAYLINK_MIN_PRODUCTS" required:"false" default:"1"`
 	PaylinkMaxProducts int `envconfig:"PAYLINK_MAX_PRODUCTS" required:"false" default:"8"`
@@ -105,10 +143,74 @@ type Config struct {
 	UserInviteTokenSecret  string `envconfig:"USER_INVITE_TOKEN_SECRET" required:"true"`
 	UserInviteTokenTimeout int64  `envconfig:"USER_INVITE_TOKEN_TIMEOUT" default:"48"`
 
+	// cooling-off period in hours after the merchant bank account change when payouts are held
+	MerchantBankingChangeCooldown int64 `envconfig:"MERCHANT_BANKING_CHANGE_COOLDOWN" default:"72"`
+
+	// period in hours when merchants can review changes of recommended prices before the pending version
+	// of price tables is applied automatically
+	PriceTableApplyDelay int64 `envconfig:"PRICE_TABLE_APPLY_DELAY" default:"72"`
+
+	// minimal scores of the payment risk assessment when the payment requires 3-D Secure, is held for review
+	// or is declined
+	RiskScoreThreeDs int32 `envconfig:"RISK_SCORE_THREE_DS" default:"50"`
+	RiskScoreReview  int32 `envconfig:"RISK_SCORE_REVIEW" default:"70"`
+	RiskScoreDecline int32 `envconfig:"RISK_SCORE_DECLINE" default:"100"`
+
+	// secret for the keyed hash of bank card numbers used to find the same card in risk signals, block lists
+	// and saved cards
+	CardFingerprintSecret string `envconfig:"CARD_FINGERPRINT_SECRET"`
+
+	// countries of issuers of bank cards where PSD2 strong customer authentication is required, limits in EUR
+	// of low value and transaction risk analysis exemptions, and maximal risk score of the payment to claim
+	// the transaction risk analysis exemption
+	ScaCountries       []string `envconfig:"SCA_COUNTRIES" default:"AT,BE,BG,CY,CZ,DE,DK,EE,ES,FI,FR,GB,GR,HR,HU,IE,IS,IT,LI,LT,LU,LV,MT,NL,NO,PL,PT,RO,SE,SI,SK"`
+	ScaLowValueLimit   float64  `envconfig:"SCA_LOW_VALUE_LIMIT" default:"30"`
+	ScaTraLimit        float64  `envconfig:"SCA_TRA_LIMIT" default:"100"`
+	ScaTraMaxRiskScore int32    `envconfig:"SCA_TRA_MAX_RISK_SCORE" default:"10"`
+
+	// maximal window of velocity limits in seconds, payment attempts are kept in redis during this time
+	VelocityLimitMaxWindow int64 `envconfig:"VELOCITY_LIMIT_MAX_WINDOW" default:"2592000"`
+
+	// time in seconds to resolve the manual review of the order, and time of the claim of the review by the risk manager
+	OrderReviewSla          int64 `envconfig:"ORDER_REVIEW_SLA" default:"14400"`
+	OrderReviewClaimTimeout int64 `envconfig:"ORDER_REVIEW_CLAIM_TIMEOUT" default:"1800"`
+
+	// directory with files of sanctions lists loaded by the task, and minimal similarity of names from 0 to 1
+	// to report the potential match of the screening
+	SanctionsListsDir           string  `envconfig:"SANCTIONS_LISTS_DIR" default:"./sanctions"`
+	SanctionsScreeningThreshold float64 `envconfig:"SANCTIONS_SCREENING_THRESHOLD" default:"0.88"`
+
+	// retention period in days of the history of ip addresses and user agents of customers
+	CustomerHistoryRetentionDays int64 `envconfig:"CUSTOMER_HISTORY_RETENTION_DAYS" default:"90"`
+
+	// directory with files of the account updater with new expiry dates of saved bank cards
+	CardUpdaterDir string `envconfig:"CARD_UPDATER_DIR" default:"./card_updater"`
+
+	// period in days of the merchant risk monitoring, minimal count of sales of the merchant during the period
+	// to check ratios, thresholds of ratios from 0 to 1 and threshold of the ratio of daily sales to average daily sales
+	MerchantRiskPeriod          int64   `envconfig:"MERCHANT_RISK_PERIOD" default:"30"`
+	MerchantRiskMinSales        int64   `envconfig:"MERCHANT_RISK_MIN_SALES" default:"100"`
+	MerchantRiskChargebackRatio float64 `envconfig:"MERCHANT_RISK_CHARGEBACK_RATIO" default:"0.009"`
+	MerchantRiskRefundRatio     float64 `envconfig:"MERCHANT_RISK_REFUND_RATIO" default:"0.1"`
+	MerchantRiskDeclineRate     float64 `envconfig:"MERCHANT_RISK_DECLINE_RATE" default:"0.5"`
+	MerchantRiskSalesSpike      float64 `envconfig:"MERCHANT_RISK_SALES_SPIKE" default:"3"`
+
+	// actions applied to the merchant exceeding any threshold of the risk monitoring, chargeback ratio when processing
+	// of payments of the merchant is suspended (0 disables the suspension), and the rolling reserve of risky merchants
+	MerchantRiskActions                 []string `envconfig:"MERCHANT_RISK_ACTIONS" default:"high_risk,rolling_reserve,manual_payouts"`
+	MerchantRiskSuspendChargebackRatio  float64  `envconfig:"MERCHANT_RISK_SUSPEND_CHARGEBACK_RATIO" default:"0.018"`
+	MerchantRiskRollingReserveThreshold float64  `envconfig:"MERCHANT_RISK_ROLLING_RESERVE_THRESHOLD" default:"10"`
+	MerchantRiskRollingReserveDays      int32    `envconfig:"MERCHANT_RISK_ROLLING_RESERVE_DAYS" default:"180"`
+
+	// email of risk managers to get alerts of the merchant risk monitoring
+	EmailRiskManagerRecipient string `envconfig:"EMAIL_RISK_MANAGER_RECIPIENT" default:""`
+
 	*PaymentSystemConfig
 	*CustomerTokenConfig
 	*CacheRedis
 	*EmailTemplates
+	*KeyCodeEncryption
+	*PersonalDataEncryption
 
 	CentrifugoPaymentForm *Centrifugo `envconfig:"CENTRIFUGO_PAYMENT_FORM"`
 	CentrifugoDashboard   *Centrifugo `envconfig:"CENTRIFUGO_DASHBOARD"`
@@ -155,6 +257,54 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
+	if cfg.CardFingerprintSecret == "" {
+		return nil, errors.New(pkg.ErrorCardFingerprintNoSecret)
+	}
+
+	cfg.KeyCodeEncryption.MasterKeys = make(map[string][]byte, len(cfg.KeyCodeEncryption.MasterKeysBase64))
+
+	for id, val := range cfg.KeyCodeEncryption.MasterKeysBase64 {
+		cfg.KeyCodeEncryption.MasterKeys[id], err = base64.StdEncoding.DecodeString(val)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.KeyCodeEncryption.MasterKeys) <= 0 && !cfg.IsDevelopment() {
+		return nil, errors.New(pkg.ErrorKeyCodeNoMasterKeys)
+	}
+
+	if len(cfg.KeyCodeEncryption.MasterKeys) > 0 {
+		if _, ok := cfg.KeyCodeEncryption.MasterKeys[cfg.KeyCodeEncryption.MasterKeyId]; !ok {
+			return nil, errors.New(pkg.ErrorKeyCodeMasterKeyNotFound)
+		}
+
+		if cfg.KeyCodeEncryption.IndexSecret == "" {
+			return nil, errors.New(pkg.ErrorKeyCodeNoIndexSecret)
+		}
+	}
+
+	cfg.PersonalDataEncryption.Keys = make(map[string][]byte, len(cfg.PersonalDataEncryption.KeysBase64))
+
+	for id, val := range cfg.PersonalDataEncryption.KeysBase64 {
+		cfg.PersonalDataEncryption.Keys[id], err = base64.StdEncoding.DecodeString(val)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.PersonalDataEncryption.Keys) > 0 {
+		if _, ok := cfg.PersonalDataEncryption.Keys[cfg.PersonalDataEncryption.KeyId]; !ok {
+			return nil, errors.New(pkg.ErrorPersonalDataKeyNotFound)
+		}
+
+		if cfg.PersonalDataEncryption.IndexSecret == "" {
+			return nil, errors.New(pkg.ErrorPersonalDataNoIndexSecret)
+		}
+	}
+
 	cfg.EmailConfirmUrlParsed, err = url.Parse(cfg.GetEmailConfirmUrl())
 
 	if err != nil {
@@ -176,6 +326,10 @@ func NewConfig() (*Config, error) {
 	return cfg, err
 }
 
+func (cfg *Config) IsDevelopment() bool {
+	return cfg.Environment == EnvironmentDevelopment
+}
+
 func (cfg *Config) GetCustomerTokenLength() int {
 	return cfg.CustomerTokenConfig.Length
 }
@@ -1,5 +1,9 @@
 package helper
 
+const (
+	keyCodeMaskVisibleChars = 4
+)
+
 func Contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -8,3 +12,21 @@ func Contains(s []string, e string) bool {
 	}
 	return false
 }
+
+// MaskKeyCode returns the key code with all characters except the last four and dashes replaced by asterisks.
+func MaskKeyCode(code string) string {
+	runes := []rune(code)
+	visible := len(runes) - keyCodeMaskVisibleChars
+
+	if visible < 0 {
+		visible = 0
+	}
+
+	for i := 0; i < visible; i++ {
+		if runes[i] != '-' {
+			runes[i] = '*'
+		}
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,118 @@
+package kms
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	fieldCiphertextPrefix    = "pii:"
+	fieldCiphertextSeparator = ":"
+
+	errorFieldKeysNotLoaded    = "keys of field encryption are not set"
+	errorFieldKeyNotFound      = "key of field encryption not found"
+	errorFieldKeyInvalid       = "key of field encryption must be 32 bytes long"
+	errorFieldKeyIdInvalid     = "identifier of field encryption key must not contain colon"
+	errorFieldCiphertextBroken = "ciphertext of field is broken"
+)
+
+// FieldCipher encrypts values of fields by AES-256-GCM with the current key of the key ring.
+// Values encrypted by previous keys are decrypted while these keys are in the key ring, so keys are rotated
+// by adding the new key to the key ring and re-encrypting stored values.
+type FieldCipher struct {
+	keys        map[string][]byte
+	keyId       string
+	indexSecret []byte
+}
+
+// NewFieldCipher create and return the field cipher with the key ring, the identifier of the current key
+// and the secret of blind indexes.
+func NewFieldCipher(keys map[string][]byte, keyId, indexSecret string) (*FieldCipher, error) {
+	if len(keys) <= 0 {
+		return nil, errors.New(errorFieldKeysNotLoaded)
+	}
+
+	for id, key := range keys {
+		if len(key) != dataKeyLength {
+			return nil, errors.New(errorFieldKeyInvalid)
+		}
+
+		if strings.Contains(id, fieldCiphertextSeparator) {
+			return nil, errors.New(errorFieldKeyIdInvalid)
+		}
+	}
+
+	if _, ok := keys[keyId]; !ok {
+		return nil, errors.New(errorFieldKeyNotFound)
+	}
+
+	return &FieldCipher{keys: keys, keyId: keyId, indexSecret: []byte(indexSecret)}, nil
+}
+
+// Encrypt returns the ciphertext of the value in format pii:<key identifier>:<base64 encoded ciphertext>.
+// Empty values aren't encrypted.
+func (c *FieldCipher) Encrypt(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+
+	ciphertext, err := Encrypt(c.keys[c.keyId], []byte(value))
+
+	if err != nil {
+		return "", err
+	}
+
+	return fieldCiphertextPrefix + c.keyId + fieldCiphertextSeparator +
+		base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt returns the plaintext of the value encrypted by any key of the key ring,
+// values stored before encryption was enabled are returned as is.
+func (c *FieldCipher) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, fieldCiphertextPrefix) {
+		return value, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, fieldCiphertextPrefix), fieldCiphertextSeparator, 2)
+
+	if len(parts) != 2 {
+		return "", errors.New(errorFieldCiphertextBroken)
+	}
+
+	key, ok := c.keys[parts[0]]
+
+	if !ok {
+		return "", errors.New(errorFieldKeyNotFound)
+	}
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := Decrypt(key, ciphertext)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// Index returns the blind index of the value, it's a keyed hash which is used to find encrypted values by equality.
+// The index doesn't depend on encryption keys, so rotation of keys doesn't change indexes.
+func (c *FieldCipher) Index(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, c.indexSecret)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
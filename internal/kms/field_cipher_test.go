@@ -0,0 +1,135 @@
+package kms
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+type FieldCipherTestSuite struct {
+	suite.Suite
+	keys map[string][]byte
+}
+
+func Test_FieldCipher(t *testing.T) {
+	suite.Run(t, new(FieldCipherTestSuite))
+}
+
+func (suite *FieldCipherTestSuite) SetupTest() {
+	suite.keys = map[string][]byte{
+		"1": bytes.Repeat([]byte{1}, dataKeyLength),
+		"2": bytes.Repeat([]byte{2}, dataKeyLength),
+	}
+}
+
+func (suite *FieldCipherTestSuite) TestFieldCipher_NewFieldCipher_Error() {
+	_, err := NewFieldCipher(map[string][]byte{}, "1", "secret")
+	assert.EqualError(suite.T(), err, errorFieldKeysNotLoaded)
+
+	_, err = NewFieldCipher(suite.keys, "3", "secret")
+	assert.EqualError(suite.T(), err, errorFieldKeyNotFound)
+
+	_, err = NewFieldCipher(map[string][]byte{"1": []byte("short")}, "1", "secret")
+	assert.EqualError(suite.T(), err, errorFieldKeyInvalid)
+
+	_, err = NewFieldCipher(map[string][]byte{"1:1": suite.keys["1"]}, "1:1", "secret")
+	assert.EqualError(suite.T(), err, errorFieldKeyIdInvalid)
+}
+
+func (suite *FieldCipherTestSuite) TestFieldCipher_EncryptDecrypt_Ok() {
+	c, err := NewFieldCipher(suite.keys, "1", "secret")
+	assert.NoError(suite.T(), err)
+
+	encrypted, err := c.Encrypt("test@unit.test")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(encrypted, "pii:1:"))
+	assert.NotContains(suite.T(), encrypted, "test@unit.test")
+
+	// encryption isn't deterministic
+	encrypted1, err := c.Encrypt("test@unit.test")
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), encrypted, encrypted1)
+
+	decrypted, err := c.Decrypt(encrypted)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "test@unit.test", decrypted)
+}
+
+func (suite *FieldCipherTestSuite) TestFieldCipher_EncryptDecrypt_EmptyAndPlaintext() {
+	c, err := NewFieldCipher(suite.keys, "1", "secret")
+	assert.NoError(suite.T(), err)
+
+	encrypted, err := c.Encrypt("")
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), encrypted)
+
+	decrypted, err := c.Decrypt("test@unit.test")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "test@unit.test", decrypted)
+}
+
+func (suite *FieldCipherTestSuite) TestFieldCipher_Decrypt_KeyRotation() {
+	c, err := NewFieldCipher(suite.keys, "1", "secret")
+	assert.NoError(suite.T(), err)
+
+	encrypted, err := c.Encrypt("+79001234567")
+	assert.NoError(suite.T(), err)
+
+	// value encrypted by the previous key can be decrypted after key rotation
+	c, err = NewFieldCipher(suite.keys, "2", "secret")
+	assert.NoError(suite.T(), err)
+
+	decrypted, err := c.Decrypt(encrypted)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "+79001234567", decrypted)
+
+	reencrypted, err := c.Encrypt(decrypted)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(reencrypted, "pii:2:"))
+
+	// value can't be decrypted after the key was removed from the key ring
+	c, err = NewFieldCipher(map[string][]byte{"2": suite.keys["2"]}, "2", "secret")
+	assert.NoError(suite.T(), err)
+
+	_, err = c.Decrypt(encrypted)
+	assert.EqualError(suite.T(), err, errorFieldKeyNotFound)
+}
+
+func (suite *FieldCipherTestSuite) TestFieldCipher_Decrypt_Error() {
+	c, err := NewFieldCipher(suite.keys, "1", "secret")
+	assert.NoError(suite.T(), err)
+
+	_, err = c.Decrypt("pii:1")
+	assert.EqualError(suite.T(), err, errorFieldCiphertextBroken)
+
+	_, err = c.Decrypt("pii:1:!!!")
+	assert.Error(suite.T(), err)
+
+	encrypted, err := c.Encrypt("test@unit.test")
+	assert.NoError(suite.T(), err)
+
+	_, err = c.Decrypt(encrypted[:len(encrypted)-2])
+	assert.Error(suite.T(), err)
+}
+
+func (suite *FieldCipherTestSuite) TestFieldCipher_Index_Ok() {
+	c, err := NewFieldCipher(suite.keys, "1", "secret")
+	assert.NoError(suite.T(), err)
+
+	index := c.Index("test@unit.test")
+	assert.Len(suite.T(), index, 64)
+	assert.Equal(suite.T(), index, c.Index("test@unit.test"))
+	assert.NotEqual(suite.T(), index, c.Index("test1@unit.test"))
+	assert.Empty(suite.T(), c.Index(""))
+
+	// index doesn't depend on the current key
+	c, err = NewFieldCipher(suite.keys, "2", "secret")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), index, c.Index("test@unit.test"))
+
+	c, err = NewFieldCipher(suite.keys, "2", "secret1")
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), index, c.Index("test@unit.test"))
+}
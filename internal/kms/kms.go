@@ -0,0 +1,150 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+const (
+	dataKeyLength = 32
+
+	errorMasterKeyNotFound   = "master key not found"
+	errorMasterKeyInvalid    = "master key must be 32 bytes long"
+	errorCiphertextTooShort  = "ciphertext too short"
+	errorDataKeyLengthWrong  = "data key must be 32 bytes long"
+	errorMasterKeysNotLoaded = "master keys are not set"
+)
+
+// KmsInterface is abstraction layer for the key management service which keeps master keys
+// and encrypts data keys used for envelope encryption.
+type KmsInterface interface {
+	// GenerateDataKey returns a new data key both in plaintext and encrypted by the current master key.
+	GenerateDataKey(ctx context.Context) (*DataKey, error)
+
+	// DecryptDataKey decrypts the data key encrypted by the master key with the specified identifier.
+	DecryptDataKey(ctx context.Context, masterKeyId string, encrypted []byte) ([]byte, error)
+
+	// GetMasterKeyId returns identifier of the master key used to encrypt new data keys.
+	GetMasterKeyId() string
+}
+
+// DataKey is a key for encrypting data. Only encrypted data key may be stored,
+// plaintext data key must be kept in memory only for the time of encryption or decryption.
+type DataKey struct {
+	MasterKeyId string
+	Plaintext   []byte
+	Encrypted   []byte
+}
+
+// Local is a stand-in for the external key management service which keeps master keys in memory.
+type Local struct {
+	masterKeys  map[string][]byte
+	masterKeyId string
+}
+
+// NewLocalKms create and return the local key management service with master keys from configuration.
+func NewLocalKms(masterKeys map[string][]byte, masterKeyId string) (*Local, error) {
+	if len(masterKeys) <= 0 {
+		return nil, errors.New(errorMasterKeysNotLoaded)
+	}
+
+	for _, key := range masterKeys {
+		if len(key) != dataKeyLength {
+			return nil, errors.New(errorMasterKeyInvalid)
+		}
+	}
+
+	if _, ok := masterKeys[masterKeyId]; !ok {
+		return nil, errors.New(errorMasterKeyNotFound)
+	}
+
+	return &Local{masterKeys: masterKeys, masterKeyId: masterKeyId}, nil
+}
+
+func (k *Local) GenerateDataKey(_ context.Context) (*DataKey, error) {
+	plaintext := make([]byte, dataKeyLength)
+
+	if _, err := io.ReadFull(rand.Reader, plaintext); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := Encrypt(k.masterKeys[k.masterKeyId], plaintext)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataKey{MasterKeyId: k.masterKeyId, Plaintext: plaintext, Encrypted: encrypted}, nil
+}
+
+func (k *Local) DecryptDataKey(_ context.Context, masterKeyId string, encrypted []byte) ([]byte, error) {
+	masterKey, ok := k.masterKeys[masterKeyId]
+
+	if !ok {
+		return nil, errors.New(errorMasterKeyNotFound)
+	}
+
+	plaintext, err := Decrypt(masterKey, encrypted)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plaintext) != dataKeyLength {
+		return nil, errors.New(errorDataKeyLengthWrong)
+	}
+
+	return plaintext, nil
+}
+
+func (k *Local) GetMasterKeyId() string {
+	return k.masterKeyId
+}
+
+// Encrypt encrypts the plaintext by AES-256-GCM, random nonce is prepended to the result.
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGcm(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt decrypts the ciphertext created by Encrypt.
+func Decrypt(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGcm(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New(errorCiphertextTooShort)
+	}
+
+	nonce := ciphertext[:gcm.NonceSize()]
+
+	return gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil)
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,96 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type KmsTestSuite struct {
+	suite.Suite
+	masterKeys map[string][]byte
+}
+
+func Test_Kms(t *testing.T) {
+	suite.Run(t, new(KmsTestSuite))
+}
+
+func (suite *KmsTestSuite) SetupTest() {
+	suite.masterKeys = map[string][]byte{
+		"1": bytes.Repeat([]byte{1}, dataKeyLength),
+		"2": bytes.Repeat([]byte{2}, dataKeyLength),
+	}
+}
+
+func (suite *KmsTestSuite) TestKms_NewLocalKms_Ok() {
+	k, err := NewLocalKms(suite.masterKeys, "2")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "2", k.GetMasterKeyId())
+}
+
+func (suite *KmsTestSuite) TestKms_NewLocalKms_Error() {
+	_, err := NewLocalKms(map[string][]byte{}, "1")
+	assert.EqualError(suite.T(), err, errorMasterKeysNotLoaded)
+
+	_, err = NewLocalKms(suite.masterKeys, "3")
+	assert.EqualError(suite.T(), err, errorMasterKeyNotFound)
+
+	_, err = NewLocalKms(map[string][]byte{"1": []byte("short")}, "1")
+	assert.EqualError(suite.T(), err, errorMasterKeyInvalid)
+}
+
+func (suite *KmsTestSuite) TestKms_GenerateDataKey_Ok() {
+	k, err := NewLocalKms(suite.masterKeys, "1")
+	assert.NoError(suite.T(), err)
+
+	dataKey, err := k.GenerateDataKey(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "1", dataKey.MasterKeyId)
+	assert.Len(suite.T(), dataKey.Plaintext, dataKeyLength)
+	assert.NotEqual(suite.T(), dataKey.Plaintext, dataKey.Encrypted)
+
+	// data key encrypted by the previous master key can be decrypted after master key rotation
+	k, err = NewLocalKms(suite.masterKeys, "2")
+	assert.NoError(suite.T(), err)
+
+	plaintext, err := k.DecryptDataKey(context.TODO(), dataKey.MasterKeyId, dataKey.Encrypted)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), dataKey.Plaintext, plaintext)
+}
+
+func (suite *KmsTestSuite) TestKms_DecryptDataKey_Error() {
+	k, err := NewLocalKms(suite.masterKeys, "1")
+	assert.NoError(suite.T(), err)
+
+	dataKey, err := k.GenerateDataKey(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	_, err = k.DecryptDataKey(context.TODO(), "3", dataKey.Encrypted)
+	assert.EqualError(suite.T(), err, errorMasterKeyNotFound)
+
+	_, err = k.DecryptDataKey(context.TODO(), "2", dataKey.Encrypted)
+	assert.Error(suite.T(), err)
+
+	_, err = k.DecryptDataKey(context.TODO(), "1", []byte("short"))
+	assert.EqualError(suite.T(), err, errorCiphertextTooShort)
+}
+
+func (suite *KmsTestSuite) TestKms_EncryptDecrypt_Ok() {
+	key := suite.masterKeys["1"]
+
+	c1, err := Encrypt(key, []byte("AAAA-BBBB-CCCC"))
+	assert.NoError(suite.T(), err)
+	c2, err := Encrypt(key, []byte("AAAA-BBBB-CCCC"))
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), c1, c2)
+
+	plaintext, err := Decrypt(key, c1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "AAAA-BBBB-CCCC", string(plaintext))
+
+	c1[len(c1)-1] ^= 1
+	_, err = Decrypt(key, c1)
+	assert.Error(suite.T(), err)
+}
@@ -5,6 +5,7 @@ package mocks
 import billingpb "github.com/paysuper/paysuper-proto/go/billingpb"
 import context "context"
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 
 // CustomerRepositoryInterface is an autogenerated mock type for the CustomerRepositoryInterface type
 type CustomerRepositoryInterface struct {
@@ -34,6 +35,52 @@ func (_m *CustomerRepositoryInterface) Find(_a0 context.Context, _a1 string, _a2
 	return r0, r1
 }
 
+// FindAfter provides a mock function with given fields: ctx, afterId, limit
+func (_m *CustomerRepositoryInterface) FindAfter(ctx context.Context, afterId string, limit int64) ([]*billingpb.Customer, error) {
+	ret := _m.Called(ctx, afterId, limit)
+
+	var r0 []*billingpb.Customer
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) []*billingpb.Customer); ok {
+		r0 = rf(ctx, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.Customer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, afterId, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByEmailOrPhone provides a mock function with given fields: ctx, email, phone
+func (_m *CustomerRepositoryInterface) FindByEmailOrPhone(ctx context.Context, email string, phone string) ([]*billingpb.Customer, error) {
+	ret := _m.Called(ctx, email, phone)
+
+	var r0 []*billingpb.Customer
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*billingpb.Customer); ok {
+		r0 = rf(ctx, email, phone)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.Customer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, email, phone)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetById provides a mock function with given fields: _a0, _a1
 func (_m *CustomerRepositoryInterface) GetById(_a0 context.Context, _a1 string) (*billingpb.Customer, error) {
 	ret := _m.Called(_a0, _a1)
@@ -71,6 +118,27 @@ func (_m *CustomerRepositoryInterface) Insert(_a0 context.Context, _a1 *billingp
 	return r0
 }
 
+// PurgeHistory provides a mock function with given fields: ctx, before
+func (_m *CustomerRepositoryInterface) PurgeHistory(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: _a0, _a1
 func (_m *CustomerRepositoryInterface) Update(_a0 context.Context, _a1 *billingpb.Customer) error {
 	ret := _m.Called(_a0, _a1)
@@ -5,12 +5,37 @@ package mocks
 import billingpb "github.com/paysuper/paysuper-proto/go/billingpb"
 import context "context"
 import mock "github.com/stretchr/testify/mock"
+import pkg "github.com/paysuper/paysuper-billing-server/pkg"
+import time "time"
 
 // KeyRepositoryInterface is an autogenerated mock type for the KeyRepositoryInterface type
 type KeyRepositoryInterface struct {
 	mock.Mock
 }
 
+// AllocateById provides a mock function with given fields: _a0, _a1
+func (_m *KeyRepositoryInterface) AllocateById(_a0 context.Context, _a1 string) (*billingpb.Key, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *billingpb.Key
+	if rf, ok := ret.Get(0).(func(context.Context, string) *billingpb.Key); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.Key)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CancelById provides a mock function with given fields: _a0, _a1
 func (_m *KeyRepositoryInterface) CancelById(_a0 context.Context, _a1 string) (*billingpb.Key, error) {
 	ret := _m.Called(_a0, _a1)
@@ -34,6 +59,75 @@ func (_m *KeyRepositoryInterface) CancelById(_a0 context.Context, _a1 string) (*
 	return r0, r1
 }
 
+// CancelExpiredById provides a mock function with given fields: _a0, _a1
+func (_m *KeyRepositoryInterface) CancelExpiredById(_a0 context.Context, _a1 string) (*billingpb.Key, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *billingpb.Key
+	if rf, ok := ret.Get(0).(func(context.Context, string) *billingpb.Key); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.Key)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangeState provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *KeyRepositoryInterface) ChangeState(_a0 context.Context, _a1 string, _a2 string, _a3 string) (*billingpb.Key, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *billingpb.Key
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *billingpb.Key); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.Key)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountByState provides a mock function with given fields: _a0
+func (_m *KeyRepositoryInterface) CountByState(_a0 context.Context) ([]*pkg.KeyStateCount, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*pkg.KeyStateCount
+	if rf, ok := ret.Get(0).(func(context.Context) []*pkg.KeyStateCount); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*pkg.KeyStateCount)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CountKeysByProductPlatform provides a mock function with given fields: _a0, _a1, _a2
 func (_m *KeyRepositoryInterface) CountKeysByProductPlatform(_a0 context.Context, _a1 string, _a2 string) (int64, error) {
 	ret := _m.Called(_a0, _a1, _a2)
@@ -55,6 +149,96 @@ func (_m *KeyRepositoryInterface) CountKeysByProductPlatform(_a0 context.Context
 	return r0, r1
 }
 
+// Find provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5
+func (_m *KeyRepositoryInterface) Find(_a0 context.Context, _a1 string, _a2 string, _a3 string, _a4 int64, _a5 int64) ([]*billingpb.Key, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5)
+
+	var r0 []*billingpb.Key
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64, int64) []*billingpb.Key); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.Key)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int64, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4, _a5)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByCodes provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *KeyRepositoryInterface) FindByCodes(_a0 context.Context, _a1 string, _a2 []string, _a3 []string) ([]*billingpb.Key, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []*billingpb.Key
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, []string) []*billingpb.Key); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.Key)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, []string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindCount provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *KeyRepositoryInterface) FindCount(_a0 context.Context, _a1 string, _a2 string, _a3 string) (int64, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) int64); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindForReencryption provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *KeyRepositoryInterface) FindForReencryption(_a0 context.Context, _a1 []string, _a2 string, _a3 int64) ([]*billingpb.Key, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []*billingpb.Key
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string, int64) []*billingpb.Key); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.Key)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string, string, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindUnfinished provides a mock function with given fields: _a0
 func (_m *KeyRepositoryInterface) FindUnfinished(_a0 context.Context) ([]*billingpb.Key, error) {
 	ret := _m.Called(_a0)
@@ -138,6 +322,38 @@ func (_m *KeyRepositoryInterface) Insert(_a0 context.Context, _a1 *billingpb.Key
 	return r0
 }
 
+// MultipleInsert provides a mock function with given fields: _a0, _a1
+func (_m *KeyRepositoryInterface) MultipleInsert(_a0 context.Context, _a1 []*billingpb.Key) ([]int, []int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []int
+	if rf, ok := ret.Get(0).(func(context.Context, []*billingpb.Key) []int); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	var r1 []int
+	if rf, ok := ret.Get(1).(func(context.Context, []*billingpb.Key) []int); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]int)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, []*billingpb.Key) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // ReserveKey provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
 func (_m *KeyRepositoryInterface) ReserveKey(_a0 context.Context, _a1 string, _a2 string, _a3 string, _a4 int32) (*billingpb.Key, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
@@ -160,3 +376,40 @@ func (_m *KeyRepositoryInterface) ReserveKey(_a0 context.Context, _a1 string, _a
 
 	return r0, r1
 }
+
+// ShortenReservation provides a mock function with given fields: _a0, _a1, _a2
+func (_m *KeyRepositoryInterface) ShortenReservation(_a0 context.Context, _a1 string, _a2 time.Time) (*billingpb.Key, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *billingpb.Key
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) *billingpb.Key); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.Key)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCode provides a mock function with given fields: _a0, _a1
+func (_m *KeyRepositoryInterface) UpdateCode(_a0 context.Context, _a1 *billingpb.Key) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *billingpb.Key) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
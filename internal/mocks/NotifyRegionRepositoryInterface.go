@@ -11,6 +11,20 @@ type NotifyRegionRepositoryInterface struct {
 	mock.Mock
 }
 
+// DeleteByEmail provides a mock function with given fields: ctx, email
+func (_m *NotifyRegionRepositoryInterface) DeleteByEmail(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // FindByEmail provides a mock function with given fields: _a0, _a1
 func (_m *NotifyRegionRepositoryInterface) FindByEmail(_a0 context.Context, _a1 string) ([]*billingpb.NotifyUserNewRegion, error) {
 	ret := _m.Called(_a0, _a1)
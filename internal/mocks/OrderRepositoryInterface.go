@@ -5,12 +5,60 @@ package mocks
 import billingpb "github.com/paysuper/paysuper-proto/go/billingpb"
 import context "context"
 import mock "github.com/stretchr/testify/mock"
+import pkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+import time "time"
 
 // OrderRepositoryInterface is an autogenerated mock type for the OrderRepositoryInterface type
 type OrderRepositoryInterface struct {
 	mock.Mock
 }
 
+// FindAfter provides a mock function with given fields: ctx, afterId, limit
+func (_m *OrderRepositoryInterface) FindAfter(ctx context.Context, afterId string, limit int64) ([]*billingpb.Order, error) {
+	ret := _m.Called(ctx, afterId, limit)
+
+	var r0 []*billingpb.Order
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) []*billingpb.Order); ok {
+		r0 = rf(ctx, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, afterId, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByUser provides a mock function with given fields: ctx, customerIds, email, phone
+func (_m *OrderRepositoryInterface) FindByUser(ctx context.Context, customerIds []string, email string, phone string) ([]*billingpb.Order, error) {
+	ret := _m.Called(ctx, customerIds, email, phone)
+
+	var r0 []*billingpb.Order
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string, string) []*billingpb.Order); ok {
+		r0 = rf(ctx, customerIds, email, phone)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string, string, string) error); ok {
+		r1 = rf(ctx, customerIds, email, phone)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetById provides a mock function with given fields: _a0, _a1
 func (_m *OrderRepositoryInterface) GetById(_a0 context.Context, _a1 string) (*billingpb.Order, error) {
 	ret := _m.Called(_a0, _a1)
@@ -103,6 +151,29 @@ func (_m *OrderRepositoryInterface) GetByUuid(_a0 context.Context, _a1 string) (
 	return r0, r1
 }
 
+// GetMerchantRiskDeclines provides a mock function with given fields: ctx, from, to
+func (_m *OrderRepositoryInterface) GetMerchantRiskDeclines(ctx context.Context, from time.Time, to time.Time) ([]*pkg.MerchantRiskDeclineQueryResItem, error) {
+	ret := _m.Called(ctx, from, to)
+
+	var r0 []*pkg.MerchantRiskDeclineQueryResItem
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) []*pkg.MerchantRiskDeclineQueryResItem); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*pkg.MerchantRiskDeclineQueryResItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: _a0, _a1
 func (_m *OrderRepositoryInterface) Insert(_a0 context.Context, _a1 *billingpb.Order) error {
 	ret := _m.Called(_a0, _a1)
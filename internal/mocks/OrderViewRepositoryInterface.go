@@ -4,10 +4,9 @@ package mocks
 
 import billingpb "github.com/paysuper/paysuper-proto/go/billingpb"
 import context "context"
+import primitive "go.mongodb.org/mongo-driver/bson/primitive"
 import mock "github.com/stretchr/testify/mock"
 import pkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
-import primitive "go.mongodb.org/mongo-driver/bson/primitive"
-
 import time "time"
 
 // OrderViewRepositoryInterface is an autogenerated mock type for the OrderViewRepositoryInterface type
@@ -59,22 +58,22 @@ func (_m *OrderViewRepositoryInterface) GetById(_a0 context.Context, _a1 string)
 	return r0, r1
 }
 
-// GetOrderBy provides a mock function with given fields: ctx, id, uuid, merchantId, receiver
-func (_m *OrderViewRepositoryInterface) GetOrderBy(ctx context.Context, id string, uuid string, merchantId string, receiver interface{}) (interface{}, error) {
-	ret := _m.Called(ctx, id, uuid, merchantId, receiver)
+// GetMerchantRiskSales provides a mock function with given fields: ctx, from, to, dayFrom
+func (_m *OrderViewRepositoryInterface) GetMerchantRiskSales(ctx context.Context, from time.Time, to time.Time, dayFrom time.Time) ([]*pkg.MerchantRiskSalesQueryResItem, error) {
+	ret := _m.Called(ctx, from, to, dayFrom)
 
-	var r0 interface{}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, interface{}) interface{}); ok {
-		r0 = rf(ctx, id, uuid, merchantId, receiver)
+	var r0 []*pkg.MerchantRiskSalesQueryResItem
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, time.Time) []*pkg.MerchantRiskSalesQueryResItem); ok {
+		r0 = rf(ctx, from, to, dayFrom)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(interface{})
+			r0 = ret.Get(0).([]*pkg.MerchantRiskSalesQueryResItem)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, interface{}) error); ok {
-		r1 = rf(ctx, id, uuid, merchantId, receiver)
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, from, to, dayFrom)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -197,6 +196,29 @@ func (_m *OrderViewRepositoryInterface) GetPaylinkStatByUtm(ctx context.Context,
 	return r0, r1
 }
 
+// GetPrivateOrderBy provides a mock function with given fields: ctx, id, uuid, merchantId
+func (_m *OrderViewRepositoryInterface) GetPrivateOrderBy(ctx context.Context, id string, uuid string, merchantId string) (*billingpb.OrderViewPrivate, error) {
+	ret := _m.Called(ctx, id, uuid, merchantId)
+
+	var r0 *billingpb.OrderViewPrivate
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *billingpb.OrderViewPrivate); ok {
+		r0 = rf(ctx, id, uuid, merchantId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.OrderViewPrivate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, id, uuid, merchantId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetPublicByOrderId provides a mock function with given fields: ctx, merchantId
 func (_m *OrderViewRepositoryInterface) GetPublicByOrderId(ctx context.Context, merchantId string) (*billingpb.OrderViewPublic, error) {
 	ret := _m.Called(ctx, merchantId)
@@ -220,13 +242,36 @@ func (_m *OrderViewRepositoryInterface) GetPublicByOrderId(ctx context.Context,
 	return r0, r1
 }
 
-// GetRoyaltyForMerchants provides a mock function with given fields: _a0, _a1, _a2, _a3
-func (_m *OrderViewRepositoryInterface) GetRoyaltyForMerchants(_a0 context.Context, _a1 []string, _a2 time.Time, _a3 time.Time) ([]*pkg.RoyaltyReportMerchant, error) {
-	ret := _m.Called(_a0, _a1, _a2, _a3)
+// GetPublicOrderBy provides a mock function with given fields: ctx, id, uuid, merchantId
+func (_m *OrderViewRepositoryInterface) GetPublicOrderBy(ctx context.Context, id string, uuid string, merchantId string) (*billingpb.OrderViewPublic, error) {
+	ret := _m.Called(ctx, id, uuid, merchantId)
+
+	var r0 *billingpb.OrderViewPublic
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *billingpb.OrderViewPublic); ok {
+		r0 = rf(ctx, id, uuid, merchantId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.OrderViewPublic)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, id, uuid, merchantId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRoyaltyForMerchants provides a mock function with given fields: ctx, statuses, from, to, merchantIds, excludeMerchantIds
+func (_m *OrderViewRepositoryInterface) GetRoyaltyForMerchants(ctx context.Context, statuses []string, from time.Time, to time.Time, merchantIds []string, excludeMerchantIds []string) ([]*pkg.RoyaltyReportMerchant, error) {
+	ret := _m.Called(ctx, statuses, from, to, merchantIds, excludeMerchantIds)
 
 	var r0 []*pkg.RoyaltyReportMerchant
-	if rf, ok := ret.Get(0).(func(context.Context, []string, time.Time, time.Time) []*pkg.RoyaltyReportMerchant); ok {
-		r0 = rf(_a0, _a1, _a2, _a3)
+	if rf, ok := ret.Get(0).(func(context.Context, []string, time.Time, time.Time, []string, []string) []*pkg.RoyaltyReportMerchant); ok {
+		r0 = rf(ctx, statuses, from, to, merchantIds, excludeMerchantIds)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*pkg.RoyaltyReportMerchant)
@@ -234,8 +279,8 @@ func (_m *OrderViewRepositoryInterface) GetRoyaltyForMerchants(_a0 context.Conte
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, []string, time.Time, time.Time) error); ok {
-		r1 = rf(_a0, _a1, _a2, _a3)
+	if rf, ok := ret.Get(1).(func(context.Context, []string, time.Time, time.Time, []string, []string) error); ok {
+		r1 = rf(ctx, statuses, from, to, merchantIds, excludeMerchantIds)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -11,6 +11,34 @@ type PaymentSystem struct {
 	mock.Mock
 }
 
+// CancelPayment provides a mock function with given fields: order
+func (_m *PaymentSystem) CancelPayment(order *billingpb.Order) error {
+	ret := _m.Called(order)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*billingpb.Order) error); ok {
+		r0 = rf(order)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CapturePayment provides a mock function with given fields: order
+func (_m *PaymentSystem) CapturePayment(order *billingpb.Order) error {
+	ret := _m.Called(order)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*billingpb.Order) error); ok {
+		r0 = rf(order)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreatePayment provides a mock function with given fields: order, successUrl, failUrl, requisites
 func (_m *PaymentSystem) CreatePayment(order *billingpb.Order, successUrl string, failUrl string, requisites map[string]string) (string, error) {
 	ret := _m.Called(order, successUrl, failUrl, requisites)
@@ -55,6 +55,29 @@ func (_m *PayoutRepositoryInterface) FindCount(_a0 context.Context, _a1 string,
 	return r0, r1
 }
 
+// FindByOperatingCompany provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PayoutRepositoryInterface) FindByOperatingCompany(_a0 context.Context, _a1 string, _a2 string, _a3 []string) ([]*billingpb.PayoutDocument, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []*billingpb.PayoutDocument
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) []*billingpb.PayoutDocument); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.PayoutDocument)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetBalanceAmount provides a mock function with given fields: _a0, _a1, _a2
 func (_m *PayoutRepositoryInterface) GetBalanceAmount(_a0 context.Context, _a1 string, _a2 string) (float64, error) {
 	ret := _m.Called(_a0, _a1, _a2)
@@ -47,3 +47,17 @@ func (_m *PriceTableServiceInterface) Insert(_a0 context.Context, _a1 *billingpb
 
 	return r0
 }
+
+// Upsert provides a mock function with given fields: _a0, _a1
+func (_m *PriceTableServiceInterface) Upsert(_a0 context.Context, _a1 *billingpb.PriceTable) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *billingpb.PriceTable) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -32,6 +32,29 @@ func (_m *RefundRepositoryInterface) CountByOrderUuid(_a0 context.Context, _a1 s
 	return r0, r1
 }
 
+// FindByOrderIds provides a mock function with given fields: ctx, orderIds
+func (_m *RefundRepositoryInterface) FindByOrderIds(ctx context.Context, orderIds []string) ([]*billingpb.Refund, error) {
+	ret := _m.Called(ctx, orderIds)
+
+	var r0 []*billingpb.Refund
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*billingpb.Refund); ok {
+		r0 = rf(ctx, orderIds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*billingpb.Refund)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, orderIds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByOrderUuid provides a mock function with given fields: _a0, _a1, _a2, _a3
 func (_m *RefundRepositoryInterface) FindByOrderUuid(_a0 context.Context, _a1 string, _a2 int64, _a3 int64) ([]*billingpb.Refund, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
@@ -194,6 +194,29 @@ func (_m *RoyaltyReportRepositoryInterface) GetByPeriod(_a0 context.Context, _a1
 	return r0, r1
 }
 
+// GetLastByMerchant provides a mock function with given fields: ctx, merchantId
+func (_m *RoyaltyReportRepositoryInterface) GetLastByMerchant(ctx context.Context, merchantId string) (*billingpb.RoyaltyReport, error) {
+	ret := _m.Called(ctx, merchantId)
+
+	var r0 *billingpb.RoyaltyReport
+	if rf, ok := ret.Get(0).(func(context.Context, string) *billingpb.RoyaltyReport); ok {
+		r0 = rf(ctx, merchantId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*billingpb.RoyaltyReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, merchantId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNonPayoutReports provides a mock function with given fields: ctx, merchantId, currency
 func (_m *RoyaltyReportRepositoryInterface) GetNonPayoutReports(ctx context.Context, merchantId string, currency string) ([]*billingpb.RoyaltyReport, error) {
 	ret := _m.Called(ctx, merchantId, currency)
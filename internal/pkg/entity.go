@@ -108,3 +108,24 @@ type TotalTransactionsAndArpuReports struct {
 	TotalTransactions *billingpb.DashboardMainReportTotalTransactions `bson:"total_transactions"`
 	Arpu              *billingpb.DashboardAmountItemWithChart         `bson:"arpu"`
 }
+
+type PaylinkFunnelQueryResItem struct {
+	VariantId string `bson:"variant_id"`
+	Step      string `bson:"step"`
+	Count     int64  `bson:"count"`
+}
+
+type MerchantRiskSalesQueryResItem struct {
+	Id               primitive.ObjectID `bson:"_id"`
+	SalesCount       int64              `bson:"sales_count"`
+	RefundCount      int64              `bson:"refund_count"`
+	ChargebackCount  int64              `bson:"chargeback_count"`
+	SalesAmount      float64            `bson:"sales_amount"`
+	DailySalesAmount float64            `bson:"daily_sales_amount"`
+}
+
+type MerchantRiskDeclineQueryResItem struct {
+	Id           primitive.ObjectID `bson:"_id"`
+	AttemptCount int64              `bson:"attempt_count"`
+	DeclineCount int64              `bson:"decline_count"`
+}
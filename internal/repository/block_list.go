@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionBlockList = "block_list"
+)
+
+type blockListRepository repository
+
+// NewBlockListRepository create and return an object for working with the block list repository.
+// The returned object implements the BlockListRepositoryInterface interface.
+func NewBlockListRepository(db mongodb.SourceInterface) BlockListRepositoryInterface {
+	s := &blockListRepository{db: db, mapper: models.NewBlockListEntryMapper()}
+	return s
+}
+
+func (r *blockListRepository) Upsert(ctx context.Context, entry *pkg.BlockListEntry) error {
+	mgo, err := r.mapper.MapObjectToMgo(entry)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, entry),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoBlockListEntry).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionBlockList).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBlockList),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	entry.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *blockListRepository) GetById(ctx context.Context, id string) (*pkg.BlockListEntry, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBlockList),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid, "deleted": false}
+	mgo := &models.MgoBlockListEntry{}
+	err = r.db.Collection(collectionBlockList).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBlockList),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.BlockListEntry), nil
+}
+
+func (r *blockListRepository) FindActive(
+	ctx context.Context,
+	merchantId string,
+	values map[string]string,
+) ([]*pkg.BlockListEntry, error) {
+	conditions := []bson.M{
+		{"field": pkg.BlockListFieldBin},
+		{"field": pkg.BlockListFieldIp, "value": primitive.Regex{Pattern: "/"}},
+	}
+
+	for field, value := range values {
+		if value == "" {
+			continue
+		}
+
+		conditions = append(conditions, bson.M{"field": field, "value": value})
+	}
+
+	query := bson.M{
+		"merchant_id": bson.M{"$in": []string{"", merchantId}},
+		"deleted":     false,
+		"$and": []bson.M{
+			{"$or": conditions},
+			{"$or": []bson.M{{"expires_at": nil}, {"expires_at": bson.M{"$gt": time.Now()}}}},
+		},
+	}
+
+	return r.find(ctx, query, options.Find())
+}
+
+func (r *blockListRepository) IncrementHits(ctx context.Context, ids []string) error {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionBlockList),
+				zap.String(pkg.ErrorDatabaseFieldQuery, id),
+			)
+			return err
+		}
+
+		oids = append(oids, oid)
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": oids}}
+	update := bson.M{
+		"$inc": bson.M{"hit_count": 1},
+		"$set": bson.M{"last_hit_at": time.Now()},
+	}
+	_, err := r.db.Collection(collectionBlockList).UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBlockList),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, update),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *blockListRepository) Find(
+	ctx context.Context,
+	merchantId, listType, field string,
+	offset, limit int64,
+) ([]*pkg.BlockListEntry, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	return r.find(ctx, r.getListQuery(merchantId, listType, field), opts)
+}
+
+func (r *blockListRepository) FindCount(ctx context.Context, merchantId, listType, field string) (int64, error) {
+	query := r.getListQuery(merchantId, listType, field)
+	count, err := r.db.Collection(collectionBlockList).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBlockList),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *blockListRepository) getListQuery(merchantId, listType, field string) bson.M {
+	query := bson.M{"deleted": false}
+
+	if merchantId != "" {
+		query["merchant_id"] = merchantId
+	}
+
+	if listType != "" {
+		query["type"] = listType
+	}
+
+	if field != "" {
+		query["field"] = field
+	}
+
+	return query
+}
+
+func (r *blockListRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]*pkg.BlockListEntry, error) {
+	cursor, err := r.db.Collection(collectionBlockList).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBlockList),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoBlockListEntry
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBlockList),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.BlockListEntry, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.BlockListEntry)
+	}
+
+	return objs, nil
+}
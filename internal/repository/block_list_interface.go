@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// BlockListRepositoryInterface is abstraction layer for working with block lists and allow lists of payments
+// and representation in database.
+type BlockListRepositoryInterface interface {
+	// Upsert adds or updates the entry of the list.
+	Upsert(ctx context.Context, entry *pkg.BlockListEntry) error
+
+	// GetById returns the entry by unique identity, deleted entries aren't returned.
+	GetById(ctx context.Context, id string) (*pkg.BlockListEntry, error)
+
+	// FindActive returns not expired entries applied to payments of the merchant, matched by the values
+	// of the payment (the field of the entry is the key of the map). Global entries are included,
+	// all BIN ranges and IP networks are returned for checking them by the caller.
+	FindActive(ctx context.Context, merchantId string, values map[string]string) ([]*pkg.BlockListEntry, error)
+
+	// IncrementHits increases the count of hits of entries and sets the time of the last hit.
+	IncrementHits(ctx context.Context, ids []string) error
+
+	// Find returns not deleted entries filtered by the merchant, the type and the field if they're passed.
+	Find(ctx context.Context, merchantId, listType, field string, offset, limit int64) ([]*pkg.BlockListEntry, error)
+
+	// FindCount returns count of not deleted entries filtered by the merchant, the type and the field
+	// if they're passed.
+	FindCount(ctx context.Context, merchantId, listType, field string) (int64, error)
+}
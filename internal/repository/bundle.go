@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionBundle = "bundle"
+)
+
+type bundleRepository repository
+
+// NewBundleRepository create and return an object for working with the bundle repository.
+// The returned object implements the BundleRepositoryInterface interface.
+func NewBundleRepository(db mongodb.SourceInterface) BundleRepositoryInterface {
+	s := &bundleRepository{db: db, mapper: models.NewBundleMapper()}
+	return s
+}
+
+func (r *bundleRepository) Upsert(ctx context.Context, bundle *pkg.Bundle) error {
+	mgo, err := r.mapper.MapObjectToMgo(bundle)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, bundle),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoBundle).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionBundle).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	bundle.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *bundleRepository) GetById(ctx context.Context, id string) (*pkg.Bundle, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid, "deleted": false}
+	mgo := &models.MgoBundle{}
+	err = r.db.Collection(collectionBundle).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.Bundle), nil
+}
+
+func (r *bundleRepository) CountByProjectIdSku(ctx context.Context, projectId, sku string) (int64, error) {
+	projectOid, err := primitive.ObjectIDFromHex(projectId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.String(pkg.ErrorDatabaseFieldQuery, projectId),
+		)
+		return int64(0), err
+	}
+
+	query := bson.M{
+		"project_id": projectOid,
+		"sku":        sku,
+		"deleted":    false,
+	}
+	count, err := r.db.Collection(collectionBundle).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *bundleRepository) FindByIdsProjectId(ctx context.Context, ids []string, projectId string) ([]*pkg.Bundle, error) {
+	var items []primitive.ObjectID
+
+	for _, id := range ids {
+		// ids of products and key products of mixed carts are passed together with ids of bundles
+		oid, err := primitive.ObjectIDFromHex(id)
+
+		if err != nil {
+			continue
+		}
+
+		items = append(items, oid)
+	}
+
+	projectOid, err := primitive.ObjectIDFromHex(projectId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.String(pkg.ErrorDatabaseFieldQuery, projectId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{
+		"_id":        bson.M{"$in": items},
+		"enabled":    true,
+		"deleted":    false,
+		"project_id": projectOid,
+	}
+
+	return r.find(ctx, query, options.Find())
+}
+
+func (r *bundleRepository) Find(ctx context.Context, merchantId, projectId string, offset, limit int64) ([]*pkg.Bundle, error) {
+	query, err := r.getListQuery(merchantId, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *bundleRepository) FindCount(ctx context.Context, merchantId, projectId string) (int64, error) {
+	query, err := r.getListQuery(merchantId, projectId)
+
+	if err != nil {
+		return int64(0), err
+	}
+
+	count, err := r.db.Collection(collectionBundle).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *bundleRepository) getListQuery(merchantId, projectId string) (bson.M, error) {
+	merchantOid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"merchant_id": merchantOid, "deleted": false}
+
+	if projectId != "" {
+		query["project_id"], err = primitive.ObjectIDFromHex(projectId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+				zap.String(pkg.ErrorDatabaseFieldQuery, projectId),
+			)
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+func (r *bundleRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]*pkg.Bundle, error) {
+	cursor, err := r.db.Collection(collectionBundle).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoBundle
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionBundle),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.Bundle, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.Bundle)
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// BundleRepositoryInterface is abstraction layer for working with bundles of products and key products
+// and representation in database.
+type BundleRepositoryInterface interface {
+	// Upsert adds or updates the bundle.
+	Upsert(ctx context.Context, bundle *pkg.Bundle) error
+
+	// GetById returns the bundle by unique identity, deleted bundles aren't returned.
+	GetById(ctx context.Context, id string) (*pkg.Bundle, error)
+
+	// CountByProjectIdSku returns count of not deleted bundles of the project with the sku.
+	CountByProjectIdSku(ctx context.Context, projectId, sku string) (int64, error)
+
+	// FindByIdsProjectId returns enabled bundles of the project by the list of identities,
+	// identities of other objects are ignored.
+	FindByIdsProjectId(ctx context.Context, ids []string, projectId string) ([]*pkg.Bundle, error)
+
+	// Find returns bundles of the merchant, filtered by the project if it's passed.
+	Find(ctx context.Context, merchantId, projectId string, offset, limit int64) ([]*pkg.Bundle, error)
+
+	// FindCount returns count of bundles of the merchant, filtered by the project if it's passed.
+	FindCount(ctx context.Context, merchantId, projectId string) (int64, error)
+}
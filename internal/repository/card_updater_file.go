@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionCardUpdaterFile = "card_updater_file"
+)
+
+type cardUpdaterFileRepository repository
+
+// NewCardUpdaterFileRepository create and return an object for working with the card updater file repository.
+// The returned object implements the CardUpdaterFileRepositoryInterface interface.
+func NewCardUpdaterFileRepository(db mongodb.SourceInterface) CardUpdaterFileRepositoryInterface {
+	s := &cardUpdaterFileRepository{db: db, mapper: models.NewCardUpdaterFileMapper()}
+	return s
+}
+
+func (r *cardUpdaterFileRepository) Insert(ctx context.Context, file *pkg.CardUpdaterFile) error {
+	mgo, err := r.mapper.MapObjectToMgo(file)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, file),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionCardUpdaterFile).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardUpdaterFile),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	file.Id = mgo.(*models.MgoCardUpdaterFile).Id.Hex()
+
+	return nil
+}
+
+func (r *cardUpdaterFileRepository) GetByName(ctx context.Context, name string) (*pkg.CardUpdaterFile, error) {
+	mgo := &models.MgoCardUpdaterFile{}
+	query := bson.M{"name": name}
+	err := r.db.Collection(collectionCardUpdaterFile).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		// files which weren't imported yet aren't found
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardUpdaterFile),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.CardUpdaterFile), nil
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// CardUpdaterFileRepositoryInterface is abstraction layer for working with imported files of the account updater
+// and representation in database.
+type CardUpdaterFileRepositoryInterface interface {
+	// Insert adds the imported file into the collection.
+	Insert(ctx context.Context, file *pkg.CardUpdaterFile) error
+
+	// GetByName returns the imported file by the name.
+	GetByName(ctx context.Context, name string) (*pkg.CardUpdaterFile, error)
+}
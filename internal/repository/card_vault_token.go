@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionCardVaultToken = "card_vault_token"
+)
+
+type cardVaultTokenRepository repository
+
+// NewCardVaultTokenRepository create and return an object for working with the card vault repository.
+// The returned object implements the CardVaultTokenRepositoryInterface interface.
+func NewCardVaultTokenRepository(db mongodb.SourceInterface) CardVaultTokenRepositoryInterface {
+	s := &cardVaultTokenRepository{db: db, mapper: models.NewCardVaultTokenMapper()}
+	return s
+}
+
+func (r *cardVaultTokenRepository) Upsert(ctx context.Context, token *pkg.CardVaultToken) error {
+	if token.Id == "" && token.Fingerprint != "" {
+		existing, err := r.findOne(ctx, bson.M{"customer_id": token.CustomerId, "fingerprint": token.Fingerprint})
+
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+
+		// the same card saved again replaces the previous one and keeps its identifier and default flag
+		if existing != nil {
+			token.Id = existing.Id
+			token.IsDefault = token.IsDefault || existing.IsDefault
+			token.CreatedAt = existing.CreatedAt
+		}
+	}
+
+	token.UpdatedAt = ptypes.TimestampNow()
+	mgo, err := r.mapper.MapObjectToMgo(token)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, token),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoCardVaultToken).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionCardVaultToken).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	token.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *cardVaultTokenRepository) GetById(ctx context.Context, id string) (*pkg.CardVaultToken, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *cardVaultTokenRepository) FindByCustomerIds(
+	ctx context.Context,
+	customerIds []string,
+) ([]*pkg.CardVaultToken, error) {
+	query := bson.M{"customer_id": bson.M{"$in": customerIds}}
+	opts := options.Find().SetSort(bson.D{{"is_default", -1}, {"updated_at", -1}})
+	cursor, err := r.db.Collection(collectionCardVaultToken).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoCardVaultToken
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.CardVaultToken, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.CardVaultToken)
+	}
+
+	return objs, nil
+}
+
+func (r *cardVaultTokenRepository) SetDefault(ctx context.Context, customerIds []string, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return err
+	}
+
+	query := bson.M{"_id": oid, "customer_id": bson.M{"$in": customerIds}}
+	update := bson.M{"$set": bson.M{"is_default": true, "updated_at": time.Now()}}
+	res, err := r.db.Collection(collectionCardVaultToken).UpdateOne(ctx, query, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldOperationUpdate, update),
+		)
+		return err
+	}
+
+	if res.MatchedCount <= 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	query = bson.M{"_id": bson.M{"$ne": oid}, "customer_id": bson.M{"$in": customerIds}, "is_default": true}
+	update = bson.M{"$set": bson.M{"is_default": false, "updated_at": time.Now()}}
+	_, err = r.db.Collection(collectionCardVaultToken).UpdateMany(ctx, query, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldOperationUpdate, update),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *cardVaultTokenRepository) Delete(ctx context.Context, customerIds []string, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return err
+	}
+
+	query := bson.M{"_id": oid, "customer_id": bson.M{"$in": customerIds}}
+	res, err := r.db.Collection(collectionCardVaultToken).DeleteOne(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationDelete),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	if res.DeletedCount <= 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *cardVaultTokenRepository) UpdateExpireByFingerprint(
+	ctx context.Context,
+	fingerprint, month, year string,
+	updatedAt time.Time,
+) (int64, error) {
+	query := bson.M{"fingerprint": fingerprint}
+	update := bson.M{
+		"$set": bson.M{
+			"expire_month":      month,
+			"expire_year":       year,
+			"expire_updated_at": updatedAt,
+			"updated_at":        time.Now(),
+		},
+	}
+	res, err := r.db.Collection(collectionCardVaultToken).UpdateMany(ctx, query, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldOperationUpdate, update),
+		)
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+func (r *cardVaultTokenRepository) findOne(ctx context.Context, query bson.M) (*pkg.CardVaultToken, error) {
+	mgo := &models.MgoCardVaultToken{}
+	err := r.db.Collection(collectionCardVaultToken).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		// the card not found isn't an error of the database
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionCardVaultToken),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.CardVaultToken), nil
+}
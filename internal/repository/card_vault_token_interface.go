@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"time"
+)
+
+// CardVaultTokenRepositoryInterface is abstraction layer for working with bank cards saved by customers
+// and representation in database.
+type CardVaultTokenRepositoryInterface interface {
+	// Upsert adds the card of the customer or updates the card with the same fingerprint.
+	Upsert(ctx context.Context, token *pkg.CardVaultToken) error
+
+	// GetById returns the card by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.CardVaultToken, error)
+
+	// FindByCustomerIds returns cards saved by the customer with any of passed identifiers,
+	// the default card is the first.
+	FindByCustomerIds(ctx context.Context, customerIds []string) ([]*pkg.CardVaultToken, error)
+
+	// SetDefault marks the card as default and unmarks other cards of the customer.
+	// Returns mongo.ErrNoDocuments if the card isn't saved by the customer.
+	SetDefault(ctx context.Context, customerIds []string, id string) error
+
+	// Delete removes the card of the customer.
+	// Returns mongo.ErrNoDocuments if the card isn't saved by the customer.
+	Delete(ctx context.Context, customerIds []string, id string) error
+
+	// UpdateExpireByFingerprint sets the new expiry of all cards with the fingerprint
+	// and returns a number of updated cards.
+	UpdateExpireByFingerprint(ctx context.Context, fingerprint, month, year string, updatedAt time.Time) (int64, error)
+}
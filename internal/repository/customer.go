@@ -8,8 +8,10 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
 )
 
 const (
@@ -174,11 +176,11 @@ func (r customerRepository) Find(ctx context.Context, merchantId string, user *b
 	if user.Email != nil && user.Email.Value != "" {
 		subQueryItem = bson.M{
 			"identity": bson.M{
-				"$elemMatch": bson.M{
-					"type":        pkg.UserIdentityTypeEmail,
-					"merchant_id": merchantOid,
-					"value":       user.Email.Value,
-				},
+				"$elemMatch": getCustomerIdentityValueQuery(
+					bson.M{"type": pkg.UserIdentityTypeEmail, "merchant_id": merchantOid},
+					pkg.PersonalDataFieldEmail,
+					user.Email.Value,
+				),
 			},
 		}
 		subQuery = append(subQuery, subQueryItem)
@@ -187,11 +189,11 @@ func (r customerRepository) Find(ctx context.Context, merchantId string, user *b
 	if user.Phone != nil && user.Phone.Value != "" {
 		subQueryItem = bson.M{
 			"identity": bson.M{
-				"$elemMatch": bson.M{
-					"type":        pkg.UserIdentityTypePhone,
-					"merchant_id": merchantOid,
-					"value":       user.Phone.Value,
-				},
+				"$elemMatch": getCustomerIdentityValueQuery(
+					bson.M{"type": pkg.UserIdentityTypePhone, "merchant_id": merchantOid},
+					pkg.PersonalDataFieldPhone,
+					user.Phone.Value,
+				),
 			},
 		}
 		subQuery = append(subQuery, subQueryItem)
@@ -235,3 +237,211 @@ func (r customerRepository) Find(ctx context.Context, merchantId string, user *b
 
 	return obj.(*billingpb.Customer), nil
 }
+
+func (r customerRepository) FindByEmailOrPhone(ctx context.Context, email, phone string) ([]*billingpb.Customer, error) {
+	var subQuery []bson.M
+
+	if email != "" {
+		subQuery = append(
+			subQuery,
+			bson.M{"email": email},
+			bson.M{"identity": bson.M{"$elemMatch": bson.M{"type": pkg.UserIdentityTypeEmail, "value": email}}},
+		)
+
+		if index := models.GetFieldIndex(pkg.PersonalDataFieldEmail, email); index != "" {
+			subQuery = append(
+				subQuery,
+				bson.M{"email_index": index},
+				bson.M{"identity": bson.M{"$elemMatch": bson.M{"type": pkg.UserIdentityTypeEmail, "value_index": index}}},
+			)
+		}
+	}
+
+	if phone != "" {
+		subQuery = append(
+			subQuery,
+			bson.M{"phone": phone},
+			bson.M{"identity": bson.M{"$elemMatch": bson.M{"type": pkg.UserIdentityTypePhone, "value": phone}}},
+		)
+
+		if index := models.GetFieldIndex(pkg.PersonalDataFieldPhone, phone); index != "" {
+			subQuery = append(
+				subQuery,
+				bson.M{"phone_index": index},
+				bson.M{"identity": bson.M{"$elemMatch": bson.M{"type": pkg.UserIdentityTypePhone, "value_index": index}}},
+			)
+		}
+	}
+
+	if len(subQuery) <= 0 {
+		return []*billingpb.Customer{}, nil
+	}
+
+	query := bson.M{"$or": subQuery}
+	cursor, err := r.db.Collection(collectionCustomer).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCustomer),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoCustomer
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCustomer),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*billingpb.Customer, len(list))
+
+	for i, mgo := range list {
+		obj, err := r.mapper.MapMgoToObject(mgo)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+			)
+			return nil, err
+		}
+
+		objs[i] = obj.(*billingpb.Customer)
+	}
+
+	return objs, nil
+}
+
+func (r customerRepository) PurgeHistory(ctx context.Context, before time.Time) (int64, error) {
+	filter := bson.M{"ip_history.created_at": bson.M{"$lt": before}}
+	update := bson.M{"$pull": bson.M{"ip_history": bson.M{"created_at": bson.M{"$lt": before}}}}
+	res, err := r.db.Collection(collectionCustomer).UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCustomer),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, update),
+		)
+		return int64(0), err
+	}
+
+	count := res.ModifiedCount
+
+	filter = bson.M{
+		"updated_at": bson.M{"$lt": before},
+		"$or": []bson.M{
+			{"ip": bson.M{"$ne": nil}},
+			{"user_agent": bson.M{"$ne": ""}},
+		},
+	}
+	update = bson.M{"$set": bson.M{"ip": nil, "user_agent": ""}}
+	res, err = r.db.Collection(collectionCustomer).UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCustomer),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, update),
+		)
+		return int64(0), err
+	}
+
+	return count + res.ModifiedCount, nil
+}
+
+func (r customerRepository) FindAfter(ctx context.Context, afterId string, limit int64) ([]*billingpb.Customer, error) {
+	query := bson.M{}
+
+	if afterId != "" {
+		oid, err := primitive.ObjectIDFromHex(afterId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionCustomer),
+				zap.String(pkg.ErrorDatabaseFieldQuery, afterId),
+			)
+			return nil, err
+		}
+
+		query["_id"] = bson.M{"$gt": oid}
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(limit)
+	cursor, err := r.db.Collection(collectionCustomer).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCustomer),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoCustomer
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionCustomer),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	result := make([]*billingpb.Customer, len(list))
+
+	for i, item := range list {
+		obj, err := r.mapper.MapMgoToObject(item)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, item),
+			)
+			return nil, err
+		}
+
+		result[i] = obj.(*billingpb.Customer)
+	}
+
+	return result, nil
+}
+
+// getCustomerIdentityValueQuery adds the condition by value of the identity to the query,
+// encrypted values are matched by the blind index and values stored as plaintext are matched as is
+func getCustomerIdentityValueQuery(query bson.M, field, value string) bson.M {
+	index := models.GetFieldIndex(field, value)
+
+	if index == "" {
+		query["value"] = value
+		return query
+	}
+
+	query["$or"] = []bson.M{{"value": value}, {"value_index": index}}
+	return query
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"time"
 )
 
 // CustomerRepositoryInterface is abstraction layer for working with customer and representation in database.
@@ -18,4 +19,16 @@ type CustomerRepositoryInterface interface {
 
 	// Find return customer by merchant id and token user (user id or email or phone).
 	Find(context.Context, string, *billingpb.TokenUser) (*billingpb.Customer, error)
+
+	// FindByEmailOrPhone returns customers of all merchants having the email or the phone
+	// in the profile or in identities. Empty values are ignored.
+	FindByEmailOrPhone(ctx context.Context, email, phone string) ([]*billingpb.Customer, error)
+
+	// PurgeHistory removes the history of ip addresses created before the date, and current ip address and user agent
+	// of customers who were not updated since the date. It returns a number of purged records.
+	PurgeHistory(ctx context.Context, before time.Time) (int64, error)
+
+	// FindAfter returns a limited list of customers following the customer with the identifier sorted by identifier.
+	// All customers are returned from the beginning if the identifier is empty.
+	FindAfter(ctx context.Context, afterId string, limit int64) ([]*billingpb.Customer, error)
 }
@@ -2,18 +2,24 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
 	"time"
 )
 
-const collectionKey = "key"
+const (
+	collectionKey = "key"
+
+	mongoErrorCodeDuplicateKey = 11000
+)
 
 type keyRepository repository
 
@@ -44,6 +50,65 @@ func (r *keyRepository) Insert(ctx context.Context, key *billingpb.Key) error {
 	return nil
 }
 
+func (r *keyRepository) MultipleInsert(ctx context.Context, keys []*billingpb.Key) ([]int, []int, error) {
+	m := make([]interface{}, len(keys))
+
+	for i, key := range keys {
+		mgo, err := r.mapper.MapObjectToMgo(key)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, key),
+			)
+			return nil, nil, err
+		}
+
+		m[i] = mgo
+	}
+
+	opts := options.InsertMany().SetOrdered(false)
+	_, err := r.db.Collection(collectionKey).InsertMany(ctx, m, opts)
+
+	if err == nil {
+		return nil, nil, nil
+	}
+
+	exception, ok := err.(mongo.BulkWriteException)
+
+	if !ok || exception.WriteConcernError != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Int("count", len(keys)),
+		)
+		return nil, nil, err
+	}
+
+	var duplicates, failed []int
+
+	for _, writeError := range exception.WriteErrors {
+		if writeError.Code == mongoErrorCodeDuplicateKey {
+			duplicates = append(duplicates, writeError.Index)
+			continue
+		}
+
+		failed = append(failed, writeError.Index)
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.String("error", writeError.Message),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.String("key_id", keys[writeError.Index].Id),
+		)
+	}
+
+	return duplicates, failed, nil
+}
+
 func (r *keyRepository) GetById(ctx context.Context, id string) (*billingpb.Key, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 
@@ -116,6 +181,7 @@ func (r *keyRepository) ReserveKey(ctx context.Context, keyProductId, platformId
 		"key_product_id": oid,
 		"platform_id":    platformId,
 		"order_id":       nil,
+		"state":          bson.M{"$exists": false},
 	}
 	update := bson.M{
 		"$set": bson.M{
@@ -263,6 +329,7 @@ func (r *keyRepository) CountKeysByProductPlatform(ctx context.Context, keyProdu
 		"key_product_id": oid,
 		"platform_id":    platformId,
 		"order_id":       nil,
+		"state":          bson.M{"$exists": false},
 	}
 
 	count, err := r.db.Collection(collectionKey).CountDocuments(ctx, query)
@@ -331,3 +398,511 @@ func (r *keyRepository) FindUnfinished(ctx context.Context) ([]*billingpb.Key, e
 
 	return result, nil
 }
+
+func (r *keyRepository) FindForReencryption(
+	ctx context.Context,
+	dataKeyIds []string,
+	afterId string,
+	limit int64,
+) ([]*billingpb.Key, error) {
+	query := bson.M{"data_key_id": bson.M{"$nin": dataKeyIds}}
+
+	if afterId != "" {
+		oid, err := primitive.ObjectIDFromHex(afterId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+				zap.String(pkg.ErrorDatabaseFieldQuery, afterId),
+			)
+			return nil, err
+		}
+
+		query["_id"] = bson.M{"$gt": oid}
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(limit)
+	cursor, err := r.db.Collection(collectionKey).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var keys []*models.MgoKey
+	err = cursor.All(ctx, &keys)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	result := make([]*billingpb.Key, len(keys))
+
+	for i, key := range keys {
+		obj, err := r.mapper.MapMgoToObject(key)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, key),
+			)
+			return nil, err
+		}
+
+		result[i] = obj.(*billingpb.Key)
+	}
+
+	return result, nil
+}
+
+func (r *keyRepository) UpdateCode(ctx context.Context, key *billingpb.Key) error {
+	mgo, err := r.mapper.MapObjectToMgo(key)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, key),
+		)
+		return err
+	}
+
+	mgoKey := mgo.(*models.MgoKey)
+	query := bson.M{"_id": mgoKey.Id}
+	update := bson.M{
+		"$set": bson.M{
+			"code":        mgoKey.Code,
+			"data_key_id": mgoKey.DataKeyId,
+			"code_index":  mgoKey.CodeIndex,
+		},
+	}
+	_, err = r.db.Collection(collectionKey).UpdateOne(ctx, query, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldOperationUpdate, update),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *keyRepository) FindByCodes(
+	ctx context.Context,
+	platformId string,
+	indexes, codes []string,
+) ([]*billingpb.Key, error) {
+	query := bson.M{
+		"platform_id": platformId,
+		"$or": []bson.M{
+			{"code_index": bson.M{"$in": indexes}},
+			{"code": bson.M{"$in": codes}},
+		},
+	}
+
+	return r.find(ctx, query, options.Find())
+}
+
+func (r *keyRepository) Find(
+	ctx context.Context,
+	keyProductId, platformId, state string,
+	offset, limit int64,
+) ([]*billingpb.Key, error) {
+	query, err := r.getStateQuery(keyProductId, platformId, state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"_id": 1}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *keyRepository) FindCount(ctx context.Context, keyProductId, platformId, state string) (int64, error) {
+	query, err := r.getStateQuery(keyProductId, platformId, state)
+
+	if err != nil {
+		return int64(0), err
+	}
+
+	count, err := r.db.Collection(collectionKey).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *keyRepository) ChangeState(ctx context.Context, keyProductId, id, state string) (*billingpb.Key, error) {
+	if state != pkg.KeyStateRevoked && state != pkg.KeyStateExpired {
+		return nil, errors.New(pkg.ErrorKeyStateUnknown)
+	}
+
+	query, err := r.getStateQuery(keyProductId, "", pkg.KeyStateAvailable)
+
+	if err != nil {
+		return nil, err
+	}
+
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query["_id"] = oid
+	update := bson.M{
+		"$set": bson.M{
+			"state":       state,
+			"reserved_to": time.Time{},
+		},
+	}
+	mgo := &models.MgoKey{}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.db.Collection(collectionKey).FindOneAndUpdate(ctx, query, update, opts).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldOperationUpdate, update),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*billingpb.Key), nil
+}
+
+func (r *keyRepository) CancelExpiredById(ctx context.Context, id string) (*billingpb.Key, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{
+		"_id": oid,
+		"reserved_to": bson.M{
+			"$gt":  time.Time{},
+			"$lte": time.Now().UTC(),
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"reserved_to": time.Time{},
+			"order_id":    nil,
+		},
+	}
+
+	// the key is returned with the order it was reserved for
+	return r.findOneAndUpdate(ctx, query, update, options.Before)
+}
+
+func (r *keyRepository) ShortenReservation(ctx context.Context, id string, reservedTo time.Time) (*billingpb.Key, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{
+		"_id":         oid,
+		"redeemed_at": time.Time{},
+		"reserved_to": bson.M{"$gt": reservedTo.UTC()},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"reserved_to": reservedTo.UTC(),
+		},
+	}
+
+	return r.findOneAndUpdate(ctx, query, update, options.After)
+}
+
+func (r *keyRepository) AllocateById(ctx context.Context, id string) (*billingpb.Key, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	// the key without the end of reservation isn't released by expiration of reservations
+	query := bson.M{
+		"_id":         oid,
+		"order_id":    bson.M{"$ne": nil},
+		"redeemed_at": time.Time{},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"reserved_to": time.Time{},
+		},
+	}
+
+	return r.findOneAndUpdate(ctx, query, update, options.After)
+}
+
+func (r *keyRepository) CountByState(ctx context.Context) ([]*pkg.KeyStateCount, error) {
+	state := bson.M{
+		"$switch": bson.M{
+			"branches": []bson.M{
+				{
+					"case": bson.M{"$ne": []interface{}{bson.M{"$ifNull": []interface{}{"$state", ""}}, ""}},
+					"then": "$state",
+				},
+				{
+					"case": bson.M{"$gt": []interface{}{"$redeemed_at", time.Time{}}},
+					"then": pkg.KeyStateRedeemed,
+				},
+				{
+					"case": bson.M{"$ne": []interface{}{bson.M{"$ifNull": []interface{}{"$order_id", nil}}, nil}},
+					"then": pkg.KeyStateReserved,
+				},
+			},
+			"default": pkg.KeyStateAvailable,
+		},
+	}
+	pipeline := []bson.M{
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"key_product_id": "$key_product_id",
+					"platform_id":    "$platform_id",
+					"state":          state,
+				},
+				"count": bson.M{"$sum": 1},
+			},
+		},
+	}
+	cursor, err := r.db.Collection(collectionKey).Aggregate(ctx, pipeline)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, pipeline),
+		)
+		return nil, err
+	}
+
+	var items []*struct {
+		Id struct {
+			KeyProductId primitive.ObjectID `bson:"key_product_id"`
+			PlatformId   string             `bson:"platform_id"`
+			State        string             `bson:"state"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, pipeline),
+		)
+		return nil, err
+	}
+
+	result := make([]*pkg.KeyStateCount, len(items))
+
+	for i, item := range items {
+		result[i] = &pkg.KeyStateCount{
+			KeyProductId: item.Id.KeyProductId.Hex(),
+			PlatformId:   item.Id.PlatformId,
+			State:        item.Id.State,
+			Count:        item.Count,
+		}
+	}
+
+	return result, nil
+}
+
+func (r *keyRepository) getStateQuery(keyProductId, platformId, state string) (bson.M, error) {
+	oid, err := primitive.ObjectIDFromHex(keyProductId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.String(pkg.ErrorDatabaseFieldQuery, keyProductId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"key_product_id": oid}
+
+	if platformId != "" {
+		query["platform_id"] = platformId
+	}
+
+	switch state {
+	case pkg.KeyStateAvailable:
+		query["order_id"] = nil
+		query["state"] = bson.M{"$exists": false}
+	case pkg.KeyStateReserved:
+		query["order_id"] = bson.M{"$ne": nil}
+		query["redeemed_at"] = time.Time{}
+		query["state"] = bson.M{"$exists": false}
+	case pkg.KeyStateRedeemed:
+		query["redeemed_at"] = bson.M{"$gt": time.Time{}}
+	case pkg.KeyStateRevoked, pkg.KeyStateExpired:
+		query["state"] = state
+	default:
+		return nil, errors.New(pkg.ErrorKeyStateUnknown)
+	}
+
+	return query, nil
+}
+
+func (r *keyRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]*billingpb.Key, error) {
+	cursor, err := r.db.Collection(collectionKey).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var keys []*models.MgoKey
+	err = cursor.All(ctx, &keys)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	result := make([]*billingpb.Key, len(keys))
+
+	for i, key := range keys {
+		obj, err := r.mapper.MapMgoToObject(key)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, key),
+			)
+			return nil, err
+		}
+
+		result[i] = obj.(*billingpb.Key)
+	}
+
+	return result, nil
+}
+
+func (r *keyRepository) findOneAndUpdate(
+	ctx context.Context,
+	query, update bson.M,
+	returnDocument options.ReturnDocument,
+) (*billingpb.Key, error) {
+	mgo := &models.MgoKey{}
+	opts := options.FindOneAndUpdate().SetReturnDocument(returnDocument)
+	err := r.db.Collection(collectionKey).FindOneAndUpdate(ctx, query, update, opts).Decode(mgo)
+
+	if err != nil {
+		// the key doesn't match conditions of the update, it's not an error of the database
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionKey),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+				zap.Any(pkg.ErrorDatabaseFieldOperationUpdate, update),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*billingpb.Key), nil
+}
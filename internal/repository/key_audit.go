@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionKeyAudit = "key_audit"
+)
+
+type keyAuditRepository repository
+
+// NewKeyAuditRepository create and return an object for working with the key audit repository.
+// The returned object implements the KeyAuditRepositoryInterface interface.
+func NewKeyAuditRepository(db mongodb.SourceInterface) KeyAuditRepositoryInterface {
+	s := &keyAuditRepository{db: db, mapper: models.NewKeyAuditMapper()}
+	return s
+}
+
+func (r *keyAuditRepository) Insert(ctx context.Context, audit *pkg.KeyAudit) error {
+	mgo, err := r.mapper.MapObjectToMgo(audit)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, audit),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionKeyAudit).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyAudit),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	audit.Id = mgo.(*models.MgoKeyAudit).Id.Hex()
+
+	return nil
+}
+
+func (r *keyAuditRepository) MultipleInsert(ctx context.Context, audits []*pkg.KeyAudit) error {
+	m := make([]interface{}, len(audits))
+
+	for i, v := range audits {
+		mgo, err := r.mapper.MapObjectToMgo(v)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, v),
+			)
+			return err
+		}
+
+		m[i] = mgo
+	}
+
+	_, err := r.db.Collection(collectionKeyAudit).InsertMany(ctx, m)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyAudit),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+		)
+		return err
+	}
+
+	for i, v := range audits {
+		v.Id = m[i].(*models.MgoKeyAudit).Id.Hex()
+	}
+
+	return nil
+}
+
+func (r *keyAuditRepository) Find(
+	ctx context.Context,
+	keyProductId, keyId string,
+	offset, limit int64,
+) ([]*pkg.KeyAudit, error) {
+	keyProductOid, err := primitive.ObjectIDFromHex(keyProductId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyAudit),
+			zap.String(pkg.ErrorDatabaseFieldQuery, keyProductId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"key_product_id": keyProductOid}
+
+	if keyId != "" {
+		keyOid, err := primitive.ObjectIDFromHex(keyId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyAudit),
+				zap.String(pkg.ErrorDatabaseFieldQuery, keyId),
+			)
+			return nil, err
+		}
+
+		query["key_id"] = keyOid
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.db.Collection(collectionKeyAudit).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyAudit),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoKeyAudit
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyAudit),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.KeyAudit, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.KeyAudit)
+	}
+
+	return objs, nil
+}
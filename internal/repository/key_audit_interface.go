@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// KeyAuditRepositoryInterface is abstraction layer for working with audit of game activation keys state changes
+// and representation in database.
+type KeyAuditRepositoryInterface interface {
+	// Insert adds the key audit record to the collection.
+	Insert(ctx context.Context, audit *pkg.KeyAudit) error
+
+	// MultipleInsert adds the multiple key audit records to the collection.
+	MultipleInsert(ctx context.Context, audits []*pkg.KeyAudit) error
+
+	// Find returns audit records of the key product ordered from newest to oldest.
+	// Records may be filtered by the key if key identifier is not empty.
+	Find(ctx context.Context, keyProductId, keyId string, offset, limit int64) ([]*pkg.KeyAudit, error)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionKeyImportChunk = "key_import_chunk"
+)
+
+type keyImportChunkRepository repository
+
+// NewKeyImportChunkRepository create and return an object for working with the key import chunk repository.
+// The returned object implements the KeyImportChunkRepositoryInterface interface.
+func NewKeyImportChunkRepository(db mongodb.SourceInterface) KeyImportChunkRepositoryInterface {
+	s := &keyImportChunkRepository{db: db, mapper: models.NewKeyImportChunkMapper()}
+	return s
+}
+
+func (r *keyImportChunkRepository) Upsert(ctx context.Context, chunk *pkg.KeyImportChunk) error {
+	mgo, err := r.mapper.MapObjectToMgo(chunk)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, chunk),
+		)
+		return err
+	}
+
+	m := mgo.(*models.MgoKeyImportChunk)
+	filter := bson.M{"job_id": m.JobId, "number": m.Number}
+	update := bson.M{
+		"$set": bson.M{
+			"data_key_id": m.DataKeyId,
+			"data":        m.Data,
+			"created_at":  m.CreatedAt,
+		},
+		"$setOnInsert": bson.M{"_id": m.Id},
+	}
+	opts := options.Update().SetUpsert(true)
+	_, err = r.db.Collection(collectionKeyImportChunk).UpdateOne(ctx, filter, update, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportChunk),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *keyImportChunkRepository) GetByJobIdNumber(
+	ctx context.Context,
+	jobId string,
+	number int32,
+) (*pkg.KeyImportChunk, error) {
+	oid, err := primitive.ObjectIDFromHex(jobId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportChunk),
+			zap.String(pkg.ErrorDatabaseFieldQuery, jobId),
+		)
+		return nil, err
+	}
+
+	mgo := &models.MgoKeyImportChunk{}
+	query := bson.M{"job_id": oid, "number": number}
+	err = r.db.Collection(collectionKeyImportChunk).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportChunk),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.KeyImportChunk), nil
+}
+
+func (r *keyImportChunkRepository) CountByJobId(ctx context.Context, jobId string) (int64, error) {
+	oid, err := primitive.ObjectIDFromHex(jobId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportChunk),
+			zap.String(pkg.ErrorDatabaseFieldQuery, jobId),
+		)
+		return 0, err
+	}
+
+	query := bson.M{"job_id": oid}
+	count, err := r.db.Collection(collectionKeyImportChunk).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportChunk),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationCount),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *keyImportChunkRepository) DeleteByJobId(ctx context.Context, jobId string) error {
+	oid, err := primitive.ObjectIDFromHex(jobId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportChunk),
+			zap.String(pkg.ErrorDatabaseFieldQuery, jobId),
+		)
+		return err
+	}
+
+	query := bson.M{"job_id": oid}
+	_, err = r.db.Collection(collectionKeyImportChunk).DeleteMany(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportChunk),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationDelete),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
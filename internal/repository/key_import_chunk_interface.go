@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// KeyImportChunkRepositoryInterface is abstraction layer for working with uploaded chunks of key import files
+// and representation in database.
+type KeyImportChunkRepositoryInterface interface {
+	// Upsert adds the chunk of the import job or replaces the previously uploaded chunk with the same number.
+	Upsert(ctx context.Context, chunk *pkg.KeyImportChunk) error
+
+	// GetByJobIdNumber returns the chunk of the import job by number.
+	GetByJobIdNumber(ctx context.Context, jobId string, number int32) (*pkg.KeyImportChunk, error)
+
+	// CountByJobId returns count of uploaded chunks of the import job.
+	CountByJobId(ctx context.Context, jobId string) (int64, error)
+
+	// DeleteByJobId removes all chunks of the import job.
+	DeleteByJobId(ctx context.Context, jobId string) error
+}
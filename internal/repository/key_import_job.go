@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionKeyImportJob = "key_import_job"
+)
+
+type keyImportJobRepository repository
+
+// NewKeyImportJobRepository create and return an object for working with the key import job repository.
+// The returned object implements the KeyImportJobRepositoryInterface interface.
+func NewKeyImportJobRepository(db mongodb.SourceInterface) KeyImportJobRepositoryInterface {
+	s := &keyImportJobRepository{db: db, mapper: models.NewKeyImportJobMapper()}
+	return s
+}
+
+func (r *keyImportJobRepository) Insert(ctx context.Context, job *pkg.KeyImportJob) error {
+	mgo, err := r.mapper.MapObjectToMgo(job)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, job),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionKeyImportJob).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportJob),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	job.Id = mgo.(*models.MgoKeyImportJob).Id.Hex()
+
+	return nil
+}
+
+func (r *keyImportJobRepository) Update(ctx context.Context, job *pkg.KeyImportJob) error {
+	oid, err := primitive.ObjectIDFromHex(job.Id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportJob),
+			zap.String(pkg.ErrorDatabaseFieldQuery, job.Id),
+		)
+		return err
+	}
+
+	mgo, err := r.mapper.MapObjectToMgo(job)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, job),
+		)
+		return err
+	}
+
+	filter := bson.M{"_id": oid}
+	_, err = r.db.Collection(collectionKeyImportJob).ReplaceOne(ctx, filter, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportJob),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *keyImportJobRepository) GetById(ctx context.Context, id string) (*pkg.KeyImportJob, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportJob),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	mgo := &models.MgoKeyImportJob{}
+	query := bson.M{"_id": oid}
+	err = r.db.Collection(collectionKeyImportJob).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportJob),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return r.mapMgoToObject(mgo)
+}
+
+func (r *keyImportJobRepository) LockNext(ctx context.Context, lockedUntil time.Time) (*pkg.KeyImportJob, error) {
+	mgo := &models.MgoKeyImportJob{}
+	query := bson.M{
+		"status": bson.M{"$in": []string{pkg.KeyImportJobStatusQueued, pkg.KeyImportJobStatusProcessing}},
+		"$or": []bson.M{
+			{"locked_until": nil},
+			{"locked_until": bson.M{"$lt": time.Now()}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       pkg.KeyImportJobStatusProcessing,
+			"locked_until": lockedUntil,
+			"updated_at":   time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.M{"created_at": 1}).
+		SetReturnDocument(options.After)
+	err := r.db.Collection(collectionKeyImportJob).FindOneAndUpdate(ctx, query, update, opts).Decode(mgo)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyImportJob),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+				zap.Any(pkg.ErrorDatabaseFieldOperationUpdate, update),
+			)
+		}
+
+		return nil, err
+	}
+
+	return r.mapMgoToObject(mgo)
+}
+
+func (r *keyImportJobRepository) mapMgoToObject(mgo *models.MgoKeyImportJob) (*pkg.KeyImportJob, error) {
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.KeyImportJob), nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"time"
+)
+
+// KeyImportJobRepositoryInterface is abstraction layer for working with asynchronous imports of keys
+// and representation in database.
+type KeyImportJobRepositoryInterface interface {
+	// Insert adds the key import job to the collection.
+	Insert(ctx context.Context, job *pkg.KeyImportJob) error
+
+	// Update updates the key import job in the collection.
+	Update(ctx context.Context, job *pkg.KeyImportJob) error
+
+	// GetById returns the key import job by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.KeyImportJob, error)
+
+	// LockNext returns the oldest queued job or the processing job with expired lock and locks it for processing
+	// until the specified time.
+	LockNext(ctx context.Context, lockedUntil time.Time) (*pkg.KeyImportJob, error)
+}
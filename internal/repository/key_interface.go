@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"time"
 )
 
 // KeyRepositoryInterface is abstraction layer for working with key and representation in database.
@@ -10,6 +12,11 @@ type KeyRepositoryInterface interface {
 	// Insert add the key to the collection.
 	Insert(context.Context, *billingpb.Key) error
 
+	// MultipleInsert adds the multiple keys to the collection, insertion isn't stopped by failed keys.
+	// Returns positions of keys rejected as duplicates of the codes already in stock and positions of keys
+	// failed by other reasons.
+	MultipleInsert(context.Context, []*billingpb.Key) ([]int, []int, error)
+
 	// GetById returns the key by unique identity.
 	GetById(context.Context, string) (*billingpb.Key, error)
 
@@ -19,6 +26,19 @@ type KeyRepositoryInterface interface {
 	// CancelById cancels the key reserve.
 	CancelById(context.Context, string) (*billingpb.Key, error)
 
+	// CancelExpiredById cancels the key reserve if the time of reservation is over and returns the key as it was
+	// before the cancellation.
+	// Returns mongo.ErrNoDocuments if the key was already redeemed, released or reserved again.
+	CancelExpiredById(context.Context, string) (*billingpb.Key, error)
+
+	// ShortenReservation moves the end of the key reserve to the specified time if the reserve ends later.
+	// Returns mongo.ErrNoDocuments if the key isn't reserved until a later time.
+	ShortenReservation(context.Context, string, time.Time) (*billingpb.Key, error)
+
+	// AllocateById keeps the reserved key for the order without time limit until the key is redeemed or cancelled.
+	// Returns mongo.ErrNoDocuments if the key isn't reserved.
+	AllocateById(context.Context, string) (*billingpb.Key, error)
+
 	// FinishRedeemById marks the reserved key as successfully used.
 	FinishRedeemById(context.Context, string) (*billingpb.Key, error)
 
@@ -27,4 +47,28 @@ type KeyRepositoryInterface interface {
 
 	// FindUnfinished returns a list of keys that have not been revoked or used.
 	FindUnfinished(context.Context) ([]*billingpb.Key, error)
+
+	// FindForReencryption returns a limited list of keys with codes not encrypted by any of the specified data keys.
+	// Keys are ordered by identity and started after the key with the specified identity.
+	FindForReencryption(context.Context, []string, string, int64) ([]*billingpb.Key, error)
+
+	// UpdateCode updates the code of the key.
+	UpdateCode(context.Context, *billingpb.Key) error
+
+	// FindByCodes returns keys of the platform with the specified hashes of codes
+	// or with the specified plaintext codes stored before encryption was enabled.
+	FindByCodes(context.Context, string, []string, []string) ([]*billingpb.Key, error)
+
+	// Find returns keys of the key product in the specified state with pagination.
+	// Keys of all platforms are returned if the platform identifier is empty.
+	Find(context.Context, string, string, string, int64, int64) ([]*billingpb.Key, error)
+
+	// FindCount returns count of keys of the key product in the specified state.
+	FindCount(context.Context, string, string, string) (int64, error)
+
+	// ChangeState withdraws the available key of the key product from sale by changing state to revoked or expired.
+	ChangeState(context.Context, string, string, string) (*billingpb.Key, error)
+
+	// CountByState returns counts of keys grouped by key product, platform and state.
+	CountByState(context.Context) ([]*pkg.KeyStateCount, error)
 }
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionKeyPreOrder = "key_pre_order"
+)
+
+type keyPreOrderRepository repository
+
+// NewKeyPreOrderRepository create and return an object for working with the key pre-order repository.
+// The returned object implements the KeyPreOrderRepositoryInterface interface.
+func NewKeyPreOrderRepository(db mongodb.SourceInterface) KeyPreOrderRepositoryInterface {
+	s := &keyPreOrderRepository{db: db, mapper: models.NewKeyPreOrderMapper()}
+	return s
+}
+
+func (r *keyPreOrderRepository) Insert(ctx context.Context, preOrder *pkg.KeyPreOrder) error {
+	mgo, err := r.mapper.MapObjectToMgo(preOrder)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, preOrder),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionKeyPreOrder).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyPreOrder),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	preOrder.Id = mgo.(*models.MgoKeyPreOrder).Id.Hex()
+
+	return nil
+}
+
+func (r *keyPreOrderRepository) Update(ctx context.Context, preOrder *pkg.KeyPreOrder) error {
+	oid, err := primitive.ObjectIDFromHex(preOrder.Id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyPreOrder),
+			zap.String(pkg.ErrorDatabaseFieldQuery, preOrder.Id),
+		)
+		return err
+	}
+
+	mgo, err := r.mapper.MapObjectToMgo(preOrder)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, preOrder),
+		)
+		return err
+	}
+
+	filter := bson.M{"_id": oid}
+	_, err = r.db.Collection(collectionKeyPreOrder).ReplaceOne(ctx, filter, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyPreOrder),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *keyPreOrderRepository) FindPendingReleased(
+	ctx context.Context,
+	releasedTo time.Time,
+	limit int64,
+) ([]*pkg.KeyPreOrder, error) {
+	query := bson.M{
+		"status":     pkg.KeyPreOrderStatusPending,
+		"release_at": bson.M{"$lte": releasedTo},
+	}
+	opts := options.Find().
+		SetSort(bson.M{"release_at": 1}).
+		SetLimit(limit)
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *keyPreOrderRepository) FindPendingByKeyProductId(
+	ctx context.Context,
+	keyProductId string,
+) ([]*pkg.KeyPreOrder, error) {
+	oid, err := primitive.ObjectIDFromHex(keyProductId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyPreOrder),
+			zap.String(pkg.ErrorDatabaseFieldQuery, keyProductId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"key_product_id": oid, "status": pkg.KeyPreOrderStatusPending}
+
+	return r.find(ctx, query, options.Find())
+}
+
+func (r *keyPreOrderRepository) FindByOrderId(ctx context.Context, orderId string) ([]*pkg.KeyPreOrder, error) {
+	oid, err := primitive.ObjectIDFromHex(orderId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyPreOrder),
+			zap.String(pkg.ErrorDatabaseFieldQuery, orderId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"order_id": oid}
+
+	return r.find(ctx, query, options.Find())
+}
+
+func (r *keyPreOrderRepository) Find(
+	ctx context.Context,
+	keyProductId, status string,
+	offset, limit int64,
+) ([]*pkg.KeyPreOrder, error) {
+	oid, err := primitive.ObjectIDFromHex(keyProductId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyPreOrder),
+			zap.String(pkg.ErrorDatabaseFieldQuery, keyProductId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"key_product_id": oid}
+
+	if status != "" {
+		query["status"] = status
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *keyPreOrderRepository) find(
+	ctx context.Context,
+	query bson.M,
+	opts *options.FindOptions,
+) ([]*pkg.KeyPreOrder, error) {
+	cursor, err := r.db.Collection(collectionKeyPreOrder).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyPreOrder),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoKeyPreOrder
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyPreOrder),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	result := make([]*pkg.KeyPreOrder, len(list))
+
+	for i, v := range list {
+		obj, err := r.mapper.MapMgoToObject(v)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, v),
+			)
+			return nil, err
+		}
+
+		result[i] = obj.(*pkg.KeyPreOrder)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"time"
+)
+
+// KeyPreOrderRepositoryInterface is abstraction layer for working with keys of pre-orders
+// and representation in database.
+type KeyPreOrderRepositoryInterface interface {
+	// Insert adds the pre-order to the collection.
+	Insert(ctx context.Context, preOrder *pkg.KeyPreOrder) error
+
+	// Update updates the pre-order in the collection.
+	Update(ctx context.Context, preOrder *pkg.KeyPreOrder) error
+
+	// FindPendingReleased returns a limited list of pending pre-orders released before the specified time
+	// ordered by the release date.
+	FindPendingReleased(ctx context.Context, releasedTo time.Time, limit int64) ([]*pkg.KeyPreOrder, error)
+
+	// FindPendingByKeyProductId returns pending pre-orders of the key product.
+	FindPendingByKeyProductId(ctx context.Context, keyProductId string) ([]*pkg.KeyPreOrder, error)
+
+	// FindByOrderId returns pre-orders of the order.
+	FindByOrderId(ctx context.Context, orderId string) ([]*pkg.KeyPreOrder, error)
+
+	// Find returns pre-orders of the key product in the specified status with pagination.
+	// Pre-orders in all statuses are returned if the status is empty.
+	Find(ctx context.Context, keyProductId, status string, offset, limit int64) ([]*pkg.KeyPreOrder, error)
+}
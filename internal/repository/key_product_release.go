@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionKeyProductRelease = "key_product_release"
+)
+
+type keyProductReleaseRepository repository
+
+// NewKeyProductReleaseRepository create and return an object for working with the key product release repository.
+// The returned object implements the KeyProductReleaseRepositoryInterface interface.
+func NewKeyProductReleaseRepository(db mongodb.SourceInterface) KeyProductReleaseRepositoryInterface {
+	s := &keyProductReleaseRepository{db: db, mapper: models.NewKeyProductReleaseMapper()}
+	return s
+}
+
+func (r *keyProductReleaseRepository) Upsert(ctx context.Context, release *pkg.KeyProductRelease) error {
+	mgo, err := r.mapper.MapObjectToMgo(release)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, release),
+		)
+		return err
+	}
+
+	filter := bson.M{"key_product_id": mgo.(*models.MgoKeyProductRelease).KeyProductId}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionKeyProductRelease).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyProductRelease),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	release.Id = mgo.(*models.MgoKeyProductRelease).Id.Hex()
+
+	return nil
+}
+
+func (r *keyProductReleaseRepository) GetByKeyProductId(
+	ctx context.Context,
+	keyProductId string,
+) (*pkg.KeyProductRelease, error) {
+	oid, err := primitive.ObjectIDFromHex(keyProductId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyProductRelease),
+			zap.String(pkg.ErrorDatabaseFieldQuery, keyProductId),
+		)
+		return nil, err
+	}
+
+	mgo := &models.MgoKeyProductRelease{}
+	query := bson.M{"key_product_id": oid}
+	err = r.db.Collection(collectionKeyProductRelease).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		// key products without the release date are available for delivery
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyProductRelease),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.KeyProductRelease), nil
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// KeyProductReleaseRepositoryInterface is abstraction layer for working with release dates of key products
+// and representation in database.
+type KeyProductReleaseRepositoryInterface interface {
+	// Upsert adds or replaces the release date of the key product.
+	Upsert(ctx context.Context, release *pkg.KeyProductRelease) error
+
+	// GetByKeyProductId returns the release date of the key product.
+	GetByKeyProductId(ctx context.Context, keyProductId string) (*pkg.KeyProductRelease, error)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionKeyReservationPolicy = "key_reservation_policy"
+)
+
+type keyReservationPolicyRepository repository
+
+// NewKeyReservationPolicyRepository create and return an object for working with the key reservation policy repository.
+// The returned object implements the KeyReservationPolicyRepositoryInterface interface.
+func NewKeyReservationPolicyRepository(db mongodb.SourceInterface) KeyReservationPolicyRepositoryInterface {
+	s := &keyReservationPolicyRepository{db: db, mapper: models.NewKeyReservationPolicyMapper()}
+	return s
+}
+
+func (r *keyReservationPolicyRepository) Upsert(ctx context.Context, policy *pkg.KeyReservationPolicy) error {
+	mgo, err := r.mapper.MapObjectToMgo(policy)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, policy),
+		)
+		return err
+	}
+
+	filter := bson.M{"key_product_id": mgo.(*models.MgoKeyReservationPolicy).KeyProductId}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionKeyReservationPolicy).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyReservationPolicy),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	policy.Id = mgo.(*models.MgoKeyReservationPolicy).Id.Hex()
+
+	return nil
+}
+
+func (r *keyReservationPolicyRepository) GetByKeyProductId(
+	ctx context.Context,
+	keyProductId string,
+) (*pkg.KeyReservationPolicy, error) {
+	oid, err := primitive.ObjectIDFromHex(keyProductId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyReservationPolicy),
+			zap.String(pkg.ErrorDatabaseFieldQuery, keyProductId),
+		)
+		return nil, err
+	}
+
+	mgo := &models.MgoKeyReservationPolicy{}
+	query := bson.M{"key_product_id": oid}
+	err = r.db.Collection(collectionKeyReservationPolicy).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		// most of key products use the default policy
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyReservationPolicy),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.KeyReservationPolicy), nil
+}
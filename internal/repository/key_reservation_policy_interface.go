@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// KeyReservationPolicyRepositoryInterface is abstraction layer for working with reservation policies of key products
+// and representation in database.
+type KeyReservationPolicyRepositoryInterface interface {
+	// Upsert adds or replaces the reservation policy of the key product.
+	Upsert(ctx context.Context, policy *pkg.KeyReservationPolicy) error
+
+	// GetByKeyProductId returns the reservation policy of the key product.
+	GetByKeyProductId(ctx context.Context, keyProductId string) (*pkg.KeyReservationPolicy, error)
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionKeyStockThreshold = "key_stock_threshold"
+)
+
+type keyStockThresholdRepository repository
+
+// NewKeyStockThresholdRepository create and return an object for working with the key stock threshold repository.
+// The returned object implements the KeyStockThresholdRepositoryInterface interface.
+func NewKeyStockThresholdRepository(db mongodb.SourceInterface) KeyStockThresholdRepositoryInterface {
+	s := &keyStockThresholdRepository{db: db, mapper: models.NewKeyStockThresholdMapper()}
+	return s
+}
+
+func (r *keyStockThresholdRepository) Upsert(ctx context.Context, threshold *pkg.KeyStockThreshold) error {
+	mgo, err := r.mapper.MapObjectToMgo(threshold)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, threshold),
+		)
+		return err
+	}
+
+	filter := bson.M{
+		"key_product_id": mgo.(*models.MgoKeyStockThreshold).KeyProductId,
+		"platform_id":    mgo.(*models.MgoKeyStockThreshold).PlatformId,
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionKeyStockThreshold).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyStockThreshold),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	threshold.Id = mgo.(*models.MgoKeyStockThreshold).Id.Hex()
+
+	return nil
+}
+
+func (r *keyStockThresholdRepository) GetByKeyProductIdPlatformId(
+	ctx context.Context,
+	keyProductId, platformId string,
+) (*pkg.KeyStockThreshold, error) {
+	oid, err := primitive.ObjectIDFromHex(keyProductId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyStockThreshold),
+			zap.String(pkg.ErrorDatabaseFieldQuery, keyProductId),
+		)
+		return nil, err
+	}
+
+	mgo := &models.MgoKeyStockThreshold{}
+	query := bson.M{"key_product_id": oid, "platform_id": platformId}
+	err = r.db.Collection(collectionKeyStockThreshold).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyStockThreshold),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.KeyStockThreshold), nil
+}
+
+func (r *keyStockThresholdRepository) FindByKeyProductId(
+	ctx context.Context,
+	keyProductId string,
+) ([]*pkg.KeyStockThreshold, error) {
+	oid, err := primitive.ObjectIDFromHex(keyProductId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyStockThreshold),
+			zap.String(pkg.ErrorDatabaseFieldQuery, keyProductId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"key_product_id": oid}
+	cursor, err := r.db.Collection(collectionKeyStockThreshold).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyStockThreshold),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoKeyStockThreshold
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionKeyStockThreshold),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.KeyStockThreshold, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.KeyStockThreshold)
+	}
+
+	return objs, nil
+}
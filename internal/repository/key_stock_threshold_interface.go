@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// KeyStockThresholdRepositoryInterface is abstraction layer for working with low-stock thresholds of keys
+// and representation in database.
+type KeyStockThresholdRepositoryInterface interface {
+	// Upsert adds or replaces the threshold of the key product on the platform.
+	Upsert(ctx context.Context, threshold *pkg.KeyStockThreshold) error
+
+	// GetByKeyProductIdPlatformId returns the threshold of the key product on the platform.
+	GetByKeyProductIdPlatformId(ctx context.Context, keyProductId, platformId string) (*pkg.KeyStockThreshold, error)
+
+	// FindByKeyProductId returns thresholds of the key product on all platforms.
+	FindByKeyProductId(ctx context.Context, keyProductId string) ([]*pkg.KeyStockThreshold, error)
+}
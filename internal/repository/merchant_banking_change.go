@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionMerchantBankingChange = "merchant_banking_change"
+)
+
+type merchantBankingChangeRepository repository
+
+// NewMerchantBankingChangeRepository create and return an object for working with the merchant banking change repository.
+// The returned object implements the MerchantBankingChangeRepositoryInterface interface.
+func NewMerchantBankingChangeRepository(db mongodb.SourceInterface) MerchantBankingChangeRepositoryInterface {
+	s := &merchantBankingChangeRepository{db: db, mapper: models.NewMerchantBankingChangeMapper()}
+	return s
+}
+
+func (r *merchantBankingChangeRepository) Insert(ctx context.Context, change *pkg.MerchantBankingChange) error {
+	mgo, err := r.mapper.MapObjectToMgo(change)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, change),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionMerchantBankingChange).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	change.Id = mgo.(*models.MgoMerchantBankingChange).Id.Hex()
+
+	return nil
+}
+
+func (r *merchantBankingChangeRepository) Update(ctx context.Context, change *pkg.MerchantBankingChange) error {
+	oid, err := primitive.ObjectIDFromHex(change.Id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.String(pkg.ErrorDatabaseFieldQuery, change.Id),
+		)
+		return err
+	}
+
+	mgo, err := r.mapper.MapObjectToMgo(change)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, change),
+		)
+		return err
+	}
+
+	filter := bson.M{"_id": oid}
+	_, err = r.db.Collection(collectionMerchantBankingChange).ReplaceOne(ctx, filter, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *merchantBankingChangeRepository) GetById(ctx context.Context, id string) (*pkg.MerchantBankingChange, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *merchantBankingChangeRepository) GetLastByMerchantId(
+	ctx context.Context,
+	merchantId string,
+) (*pkg.MerchantBankingChange, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"merchant_id": oid})
+}
+
+func (r *merchantBankingChangeRepository) FindByMerchantId(
+	ctx context.Context,
+	merchantId string,
+) ([]*pkg.MerchantBankingChange, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"merchant_id": oid}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.db.Collection(collectionMerchantBankingChange).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var mgoChanges []*models.MgoMerchantBankingChange
+	err = cursor.All(ctx, &mgoChanges)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.MerchantBankingChange, len(mgoChanges))
+
+	for i, obj := range mgoChanges {
+		v, err := r.mapper.MapMgoToObject(obj)
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+		objs[i] = v.(*pkg.MerchantBankingChange)
+	}
+
+	return objs, nil
+}
+
+func (r *merchantBankingChangeRepository) findOne(ctx context.Context, query bson.M) (*pkg.MerchantBankingChange, error) {
+	mgo := &models.MgoMerchantBankingChange{}
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	err := r.db.Collection(collectionMerchantBankingChange).FindOne(ctx, query, opts).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantBankingChange),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.MerchantBankingChange), nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// MerchantBankingChangeRepositoryInterface is abstraction layer for working with history of merchant bank account
+// changes and representation in database.
+type MerchantBankingChangeRepositoryInterface interface {
+	// Insert adds the merchant banking change to the collection.
+	Insert(ctx context.Context, change *pkg.MerchantBankingChange) error
+
+	// Update updates the merchant banking change in the collection.
+	Update(ctx context.Context, change *pkg.MerchantBankingChange) error
+
+	// GetById returns the merchant banking change by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.MerchantBankingChange, error)
+
+	// GetLastByMerchantId returns the latest banking change of the merchant.
+	GetLastByMerchantId(ctx context.Context, merchantId string) (*pkg.MerchantBankingChange, error)
+
+	// FindByMerchantId returns history of banking changes of the merchant, newest first.
+	FindByMerchantId(ctx context.Context, merchantId string) ([]*pkg.MerchantBankingChange, error)
+}
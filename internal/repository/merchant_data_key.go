@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionMerchantDataKey = "merchant_data_key"
+)
+
+type merchantDataKeyRepository repository
+
+// NewMerchantDataKeyRepository create and return an object for working with the merchant data key repository.
+// The returned object implements the MerchantDataKeyRepositoryInterface interface.
+func NewMerchantDataKeyRepository(db mongodb.SourceInterface) MerchantDataKeyRepositoryInterface {
+	s := &merchantDataKeyRepository{db: db, mapper: models.NewMerchantDataKeyMapper()}
+	return s
+}
+
+func (r *merchantDataKeyRepository) Insert(ctx context.Context, dataKey *pkg.MerchantDataKey) error {
+	mgo, err := r.mapper.MapObjectToMgo(dataKey)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, dataKey),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionMerchantDataKey).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantDataKey),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	dataKey.Id = mgo.(*models.MgoMerchantDataKey).Id.Hex()
+
+	return nil
+}
+
+func (r *merchantDataKeyRepository) GetById(ctx context.Context, id string) (*pkg.MerchantDataKey, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantDataKey),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *merchantDataKeyRepository) GetLastByMerchantId(
+	ctx context.Context,
+	merchantId string,
+) (*pkg.MerchantDataKey, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantDataKey),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"merchant_id": oid})
+}
+
+func (r *merchantDataKeyRepository) findOne(ctx context.Context, query bson.M) (*pkg.MerchantDataKey, error) {
+	mgo := &models.MgoMerchantDataKey{}
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	err := r.db.Collection(collectionMerchantDataKey).FindOne(ctx, query, opts).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantDataKey),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.MerchantDataKey), nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// MerchantDataKeyRepositoryInterface is abstraction layer for working with data keys used to encrypt
+// game activation keys of merchants and representation in database.
+type MerchantDataKeyRepositoryInterface interface {
+	// Insert adds the merchant data key to the collection.
+	Insert(ctx context.Context, dataKey *pkg.MerchantDataKey) error
+
+	// GetById returns the merchant data key by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.MerchantDataKey, error)
+
+	// GetLastByMerchantId returns the latest data key of the merchant which used to encrypt new key codes.
+	GetLastByMerchantId(ctx context.Context, merchantId string) (*pkg.MerchantDataKey, error)
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionMerchantRiskReview = "merchant_risk_review"
+)
+
+type merchantRiskReviewRepository repository
+
+// NewMerchantRiskReviewRepository create and return an object for working with the merchant risk review repository.
+// The returned object implements the MerchantRiskReviewRepositoryInterface interface.
+func NewMerchantRiskReviewRepository(db mongodb.SourceInterface) MerchantRiskReviewRepositoryInterface {
+	s := &merchantRiskReviewRepository{db: db, mapper: models.NewMerchantRiskReviewMapper()}
+	return s
+}
+
+func (r *merchantRiskReviewRepository) Upsert(ctx context.Context, review *pkg.MerchantRiskReview) error {
+	mgo, err := r.mapper.MapObjectToMgo(review)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, review),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoMerchantRiskReview).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionMerchantRiskReview).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRiskReview),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	review.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *merchantRiskReviewRepository) GetById(ctx context.Context, id string) (*pkg.MerchantRiskReview, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRiskReview),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid}, nil)
+}
+
+func (r *merchantRiskReviewRepository) GetUnresolvedByMerchantId(
+	ctx context.Context,
+	merchantId string,
+) (*pkg.MerchantRiskReview, error) {
+	query, err := r.getListQuery(merchantId, []string{pkg.MerchantRiskReviewStatusOpen, pkg.MerchantRiskReviewStatusSuspended})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.findOne(ctx, query, options.FindOne().SetSort(bson.M{"created_at": -1}))
+}
+
+func (r *merchantRiskReviewRepository) Find(
+	ctx context.Context,
+	merchantId string,
+	statuses []string,
+	offset, limit int64,
+) ([]*pkg.MerchantRiskReview, error) {
+	query, err := r.getListQuery(merchantId, statuses)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.db.Collection(collectionMerchantRiskReview).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRiskReview),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoMerchantRiskReview
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRiskReview),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.MerchantRiskReview, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.MerchantRiskReview)
+	}
+
+	return objs, nil
+}
+
+func (r *merchantRiskReviewRepository) FindCount(ctx context.Context, merchantId string, statuses []string) (int64, error) {
+	query, err := r.getListQuery(merchantId, statuses)
+
+	if err != nil {
+		return int64(0), err
+	}
+
+	count, err := r.db.Collection(collectionMerchantRiskReview).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRiskReview),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *merchantRiskReviewRepository) getListQuery(merchantId string, statuses []string) (bson.M, error) {
+	query := bson.M{}
+
+	if merchantId != "" {
+		oid, err := primitive.ObjectIDFromHex(merchantId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRiskReview),
+				zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+			)
+			return nil, err
+		}
+
+		query["merchant_id"] = oid
+	}
+
+	if len(statuses) > 0 {
+		query["status"] = bson.M{"$in": statuses}
+	}
+
+	return query, nil
+}
+
+func (r *merchantRiskReviewRepository) findOne(
+	ctx context.Context,
+	query bson.M,
+	opts *options.FindOneOptions,
+) (*pkg.MerchantRiskReview, error) {
+	if opts == nil {
+		opts = options.FindOne()
+	}
+
+	mgo := &models.MgoMerchantRiskReview{}
+	err := r.db.Collection(collectionMerchantRiskReview).FindOne(ctx, query, opts).Decode(mgo)
+
+	if err != nil {
+		// the merchant without unresolved reviews isn't an error of the database
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRiskReview),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.MerchantRiskReview), nil
+}
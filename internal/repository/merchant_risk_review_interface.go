@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// MerchantRiskReviewRepositoryInterface is abstraction layer for working with reviews of the merchant risk monitoring
+// and representation in database.
+type MerchantRiskReviewRepositoryInterface interface {
+	// Upsert adds or updates the review.
+	Upsert(ctx context.Context, review *pkg.MerchantRiskReview) error
+
+	// GetById returns the review by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.MerchantRiskReview, error)
+
+	// GetUnresolvedByMerchantId returns the latest open or suspended review of the merchant.
+	// Returns mongo.ErrNoDocuments if the merchant doesn't have unresolved reviews.
+	GetUnresolvedByMerchantId(ctx context.Context, merchantId string) (*pkg.MerchantRiskReview, error)
+
+	// Find returns reviews filtered by the merchant and the statuses if they're passed, the latest review is the first.
+	Find(ctx context.Context, merchantId string, statuses []string, offset, limit int64) ([]*pkg.MerchantRiskReview, error)
+
+	// FindCount returns count of reviews filtered by the merchant and the statuses if they're passed.
+	FindCount(ctx context.Context, merchantId string, statuses []string) (int64, error)
+}
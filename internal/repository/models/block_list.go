@@ -0,0 +1,144 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type blockListEntryMapper struct{}
+
+func NewBlockListEntryMapper() Mapper {
+	return &blockListEntryMapper{}
+}
+
+type MgoBlockListEntry struct {
+	Id         primitive.ObjectID `bson:"_id" faker:"objectId"`
+	MerchantId string             `bson:"merchant_id"`
+	Type       string             `bson:"type"`
+	Field      string             `bson:"field"`
+	Value      string             `bson:"value"`
+	Reason     string             `bson:"reason"`
+	ExpiresAt  *time.Time         `bson:"expires_at"`
+	CreatedBy  string             `bson:"created_by"`
+	HitCount   int64              `bson:"hit_count"`
+	LastHitAt  *time.Time         `bson:"last_hit_at"`
+	Deleted    bool               `bson:"deleted"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+}
+
+func (m *blockListEntryMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.BlockListEntry)
+
+	out := &MgoBlockListEntry{
+		MerchantId: in.MerchantId,
+		Type:       in.Type,
+		Field:      in.Field,
+		Value:      in.Value,
+		Reason:     in.Reason,
+		CreatedBy:  in.CreatedBy,
+		HitCount:   in.HitCount,
+		Deleted:    in.Deleted,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	if in.ExpiresAt != nil {
+		t, err := ptypes.Timestamp(in.ExpiresAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ExpiresAt = &t
+	}
+
+	if in.LastHitAt != nil {
+		t, err := ptypes.Timestamp(in.LastHitAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.LastHitAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *blockListEntryMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoBlockListEntry)
+
+	out := &pkg.BlockListEntry{
+		Id:         in.Id.Hex(),
+		MerchantId: in.MerchantId,
+		Type:       in.Type,
+		Field:      in.Field,
+		Value:      in.Value,
+		Reason:     in.Reason,
+		CreatedBy:  in.CreatedBy,
+		HitCount:   in.HitCount,
+		Deleted:    in.Deleted,
+	}
+
+	if in.ExpiresAt != nil {
+		out.ExpiresAt, err = ptypes.TimestampProto(*in.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if in.LastHitAt != nil {
+		out.LastHitAt, err = ptypes.TimestampProto(*in.LastHitAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type BlockListEntryTestSuite struct {
+	suite.Suite
+	mapper blockListEntryMapper
+}
+
+func TestBlockListEntryTestSuite(t *testing.T) {
+	suite.Run(t, new(BlockListEntryTestSuite))
+}
+
+func (suite *BlockListEntryTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *BlockListEntryTestSuite) Test_BlockListEntry_NewBlockListEntryMapper() {
+	mapper := NewBlockListEntryMapper()
+	assert.IsType(suite.T(), &blockListEntryMapper{}, mapper)
+}
+
+func (suite *BlockListEntryTestSuite) Test_BlockListEntry_MapObjectToMgo_Ok() {
+	original := &pkg.BlockListEntry{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		Type:       pkg.BlockListTypeBlock,
+		Field:      pkg.BlockListFieldEmail,
+		Value:      "fraud@unit.test",
+		Reason:     "chargeback",
+		ExpiresAt:  ptypes.TimestampNow(),
+		CreatedBy:  primitive.NewObjectID().Hex(),
+		HitCount:   2,
+		LastHitAt:  ptypes.TimestampNow(),
+		CreatedAt:  ptypes.TimestampNow(),
+		UpdatedAt:  ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.BlockListEntry))
+}
+
+func (suite *BlockListEntryTestSuite) Test_BlockListEntry_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.BlockListEntry{}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoBlockListEntry).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoBlockListEntry).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoBlockListEntry).UpdatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoBlockListEntry).ExpiresAt)
+	assert.Nil(suite.T(), mgo.(*MgoBlockListEntry).LastHitAt)
+}
+
+func (suite *BlockListEntryTestSuite) Test_BlockListEntry_MapObjectToMgo_Error_Id() {
+	original := &pkg.BlockListEntry{
+		Id: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *BlockListEntryTestSuite) Test_BlockListEntry_MapObjectToMgo_Error_Dates() {
+	original := &pkg.BlockListEntry{
+		ExpiresAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = &pkg.BlockListEntry{
+		LastHitAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = &pkg.BlockListEntry{
+		CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = &pkg.BlockListEntry{
+		UpdatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *BlockListEntryTestSuite) Test_BlockListEntry_MapMgoToObject_Ok() {
+	original := &MgoBlockListEntry{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *BlockListEntryTestSuite) Test_BlockListEntry_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoBlockListEntry{ExpiresAt: &invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoBlockListEntry{LastHitAt: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoBlockListEntry{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoBlockListEntry{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
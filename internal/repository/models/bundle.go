@@ -0,0 +1,143 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type bundleMapper struct{}
+
+func NewBundleMapper() Mapper {
+	return &bundleMapper{}
+}
+
+type MgoBundle struct {
+	Id          primitive.ObjectID        `bson:"_id" faker:"objectId"`
+	Object      string                    `bson:"object"`
+	Sku         string                    `bson:"sku"`
+	Name        map[string]string         `bson:"name"`
+	Description map[string]string         `bson:"description"`
+	MerchantId  primitive.ObjectID        `bson:"merchant_id" faker:"objectId"`
+	ProjectId   primitive.ObjectID        `bson:"project_id" faker:"objectId"`
+	Products    []string                  `bson:"products"`
+	KeyProducts []string                  `bson:"key_products"`
+	Prices      []*billingpb.ProductPrice `bson:"prices"`
+	Images      []string                  `bson:"images,omitempty"`
+	Enabled     bool                      `bson:"enabled"`
+	Deleted     bool                      `bson:"deleted"`
+	CreatedAt   time.Time                 `bson:"created_at"`
+	UpdatedAt   time.Time                 `bson:"updated_at"`
+}
+
+func (m *bundleMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.Bundle)
+
+	out := &MgoBundle{
+		Object:      in.Object,
+		Sku:         in.Sku,
+		Name:        in.Name,
+		Description: in.Description,
+		Products:    in.Products,
+		KeyProducts: in.KeyProducts,
+		Images:      in.Images,
+		Enabled:     in.Enabled,
+		Deleted:     in.Deleted,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	projectOid, err := primitive.ObjectIDFromHex(in.ProjectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.ProjectId = projectOid
+
+	for _, price := range in.Prices {
+		out.Prices = append(out.Prices, &billingpb.ProductPrice{
+			Currency: price.Currency,
+			Region:   price.Region,
+			Amount:   tools.FormatAmount(price.Amount),
+		})
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *bundleMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoBundle)
+
+	out := &pkg.Bundle{
+		Id:          in.Id.Hex(),
+		Object:      in.Object,
+		Sku:         in.Sku,
+		Name:        in.Name,
+		Description: in.Description,
+		MerchantId:  in.MerchantId.Hex(),
+		ProjectId:   in.ProjectId.Hex(),
+		Products:    in.Products,
+		KeyProducts: in.KeyProducts,
+		Prices:      in.Prices,
+		Images:      in.Images,
+		Enabled:     in.Enabled,
+		Deleted:     in.Deleted,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type BundleTestSuite struct {
+	suite.Suite
+	mapper bundleMapper
+}
+
+func TestBundleTestSuite(t *testing.T) {
+	suite.Run(t, new(BundleTestSuite))
+}
+
+func (suite *BundleTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *BundleTestSuite) Test_Bundle_NewBundleMapper() {
+	mapper := NewBundleMapper()
+	assert.IsType(suite.T(), &bundleMapper{}, mapper)
+}
+
+func (suite *BundleTestSuite) Test_Bundle_MapObjectToMgo_Ok() {
+	original := &pkg.Bundle{
+		Id:          primitive.NewObjectID().Hex(),
+		Object:      pkg.BundleObject,
+		Sku:         "starter_pack",
+		Name:        map[string]string{"en": "Starter pack"},
+		Description: map[string]string{"en": "Game and bonus items"},
+		MerchantId:  primitive.NewObjectID().Hex(),
+		ProjectId:   primitive.NewObjectID().Hex(),
+		Products:    []string{primitive.NewObjectID().Hex()},
+		KeyProducts: []string{primitive.NewObjectID().Hex()},
+		Prices:      []*billingpb.ProductPrice{{Amount: 19.99, Currency: "USD", Region: "USD"}},
+		Images:      []string{"/image.png"},
+		Enabled:     true,
+		CreatedAt:   ptypes.TimestampNow(),
+		UpdatedAt:   ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.Bundle))
+}
+
+func (suite *BundleTestSuite) Test_Bundle_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.Bundle{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoBundle).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoBundle).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoBundle).UpdatedAt.IsZero())
+}
+
+func (suite *BundleTestSuite) Test_Bundle_MapObjectToMgo_Error_Id() {
+	original := &pkg.Bundle{
+		Id:         "test",
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *BundleTestSuite) Test_Bundle_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.Bundle{
+		MerchantId: "test",
+		ProjectId:  primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *BundleTestSuite) Test_Bundle_MapObjectToMgo_Error_ProjectId() {
+	original := &pkg.Bundle{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *BundleTestSuite) Test_Bundle_MapObjectToMgo_Error_Dates() {
+	original := &pkg.Bundle{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+		CreatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.CreatedAt = nil
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *BundleTestSuite) Test_Bundle_MapMgoToObject_Ok() {
+	original := &MgoBundle{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *BundleTestSuite) Test_Bundle_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoBundle{CreatedAt: invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoBundle{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type cardUpdaterFileMapper struct{}
+
+func NewCardUpdaterFileMapper() Mapper {
+	return &cardUpdaterFileMapper{}
+}
+
+type MgoCardUpdaterFile struct {
+	Id        primitive.ObjectID `bson:"_id" faker:"objectId"`
+	Name      string             `bson:"name"`
+	Count     int64              `bson:"count"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (m *cardUpdaterFileMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.CardUpdaterFile)
+
+	out := &MgoCardUpdaterFile{
+		Name:  in.Name,
+		Count: in.Count,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *cardUpdaterFileMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoCardUpdaterFile)
+
+	out := &pkg.CardUpdaterFile{
+		Id:    in.Id.Hex(),
+		Name:  in.Name,
+		Count: in.Count,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
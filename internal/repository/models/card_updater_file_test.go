@@ -0,0 +1,86 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type CardUpdaterFileTestSuite struct {
+	suite.Suite
+	mapper cardUpdaterFileMapper
+}
+
+func TestCardUpdaterFileTestSuite(t *testing.T) {
+	suite.Run(t, new(CardUpdaterFileTestSuite))
+}
+
+func (suite *CardUpdaterFileTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *CardUpdaterFileTestSuite) Test_CardUpdaterFile_NewCardUpdaterFileMapper() {
+	mapper := NewCardUpdaterFileMapper()
+	assert.IsType(suite.T(), &cardUpdaterFileMapper{}, mapper)
+}
+
+func (suite *CardUpdaterFileTestSuite) Test_CardUpdaterFile_MapObjectToMgo_Ok() {
+	original := &pkg.CardUpdaterFile{
+		Id:        primitive.NewObjectID().Hex(),
+		Name:      "updates.csv",
+		Count:     10,
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.CardUpdaterFile))
+}
+
+func (suite *CardUpdaterFileTestSuite) Test_CardUpdaterFile_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(&pkg.CardUpdaterFile{Name: "updates.csv"})
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoCardUpdaterFile).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoCardUpdaterFile).CreatedAt.IsZero())
+}
+
+func (suite *CardUpdaterFileTestSuite) Test_CardUpdaterFile_MapObjectToMgo_Error_Id() {
+	_, err := suite.mapper.MapObjectToMgo(&pkg.CardUpdaterFile{Id: "test"})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CardUpdaterFileTestSuite) Test_CardUpdaterFile_MapObjectToMgo_Error_Dates() {
+	original := &pkg.CardUpdaterFile{CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CardUpdaterFileTestSuite) Test_CardUpdaterFile_MapMgoToObject_Ok() {
+	original := &MgoCardUpdaterFile{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *CardUpdaterFileTestSuite) Test_CardUpdaterFile_MapMgoToObject_Error_Dates() {
+	original := &MgoCardUpdaterFile{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
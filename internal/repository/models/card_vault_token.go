@@ -0,0 +1,165 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type cardVaultTokenMapper struct{}
+
+func NewCardVaultTokenMapper() Mapper {
+	return &cardVaultTokenMapper{}
+}
+
+type MgoCardVaultToken struct {
+	Id              primitive.ObjectID `bson:"_id" faker:"objectId"`
+	CustomerId      string             `bson:"customer_id"`
+	ProjectId       primitive.ObjectID `bson:"project_id" faker:"objectId"`
+	MerchantId      primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	Fingerprint     string             `bson:"fingerprint"`
+	PanReference    string             `bson:"pan_reference"`
+	MaskedPan       string             `bson:"masked_pan"`
+	CardHolder      string             `bson:"card_holder"`
+	ExpireMonth     string             `bson:"expire_month"`
+	ExpireYear      string             `bson:"expire_year"`
+	IsDefault       bool               `bson:"is_default"`
+	ExpireUpdatedAt *time.Time         `bson:"expire_updated_at"`
+	CreatedAt       time.Time          `bson:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at"`
+}
+
+func (m *cardVaultTokenMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.CardVaultToken)
+
+	out := &MgoCardVaultToken{
+		CustomerId:  in.CustomerId,
+		Fingerprint: in.Fingerprint,
+		MaskedPan:   in.MaskedPan,
+		ExpireMonth: in.ExpireMonth,
+		ExpireYear:  in.ExpireYear,
+		IsDefault:   in.IsDefault,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	projectOid, err := primitive.ObjectIDFromHex(in.ProjectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.ProjectId = projectOid
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	// references and holders of saved cards are always encrypted, the card isn't saved without keys
+	out.PanReference, err = encryptRequiredField(in.PanReference)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.CardHolder, err = encryptRequiredField(in.CardHolder)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ExpireUpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.ExpireUpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ExpireUpdatedAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *cardVaultTokenMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoCardVaultToken)
+
+	out := &pkg.CardVaultToken{
+		Id:          in.Id.Hex(),
+		CustomerId:  in.CustomerId,
+		ProjectId:   in.ProjectId.Hex(),
+		MerchantId:  in.MerchantId.Hex(),
+		Fingerprint: in.Fingerprint,
+		MaskedPan:   in.MaskedPan,
+		ExpireMonth: in.ExpireMonth,
+		ExpireYear:  in.ExpireYear,
+		IsDefault:   in.IsDefault,
+	}
+
+	out.PanReference, err = decryptField(in.PanReference)
+	if err != nil {
+		return nil, err
+	}
+
+	out.CardHolder, err = decryptField(in.CardHolder)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ExpireUpdatedAt != nil {
+		out.ExpireUpdatedAt, err = ptypes.TimestampProto(*in.ExpireUpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type CardVaultTokenTestSuite struct {
+	suite.Suite
+	mapper cardVaultTokenMapper
+}
+
+func TestCardVaultTokenTestSuite(t *testing.T) {
+	suite.Run(t, new(CardVaultTokenTestSuite))
+}
+
+func (suite *CardVaultTokenTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+	SetFieldCipher(&testFieldCipher{}, nil)
+}
+
+func (suite *CardVaultTokenTestSuite) TearDownTest() {
+	SetFieldCipher(nil, nil)
+}
+
+func (suite *CardVaultTokenTestSuite) getObject() *pkg.CardVaultToken {
+	return &pkg.CardVaultToken{
+		CustomerId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_NewCardVaultTokenMapper() {
+	mapper := NewCardVaultTokenMapper()
+	assert.IsType(suite.T(), &cardVaultTokenMapper{}, mapper)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapObjectToMgo_Ok() {
+	original := &pkg.CardVaultToken{
+		Id:              primitive.NewObjectID().Hex(),
+		CustomerId:      primitive.NewObjectID().Hex(),
+		ProjectId:       primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		Fingerprint:     "fingerprint",
+		PanReference:    "recurring_id",
+		MaskedPan:       "400000******0002",
+		CardHolder:      "MR. CARD HOLDER",
+		ExpireMonth:     "12",
+		ExpireYear:      "2030",
+		IsDefault:       true,
+		ExpireUpdatedAt: ptypes.TimestampNow(),
+		CreatedAt:       ptypes.TimestampNow(),
+		UpdatedAt:       ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.CardVaultToken))
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapObjectToMgo_Encrypted() {
+	// saved cards are encrypted regardless of configured groups of fields
+	original := suite.getObject()
+	original.PanReference = "recurring_id"
+	original.CardHolder = "MR. CARD HOLDER"
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.PanReference, mgo.(*MgoCardVaultToken).PanReference)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.CardHolder, mgo.(*MgoCardVaultToken).CardHolder)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original.PanReference, obj.(*pkg.CardVaultToken).PanReference)
+	assert.Equal(suite.T(), original.CardHolder, obj.(*pkg.CardVaultToken).CardHolder)
+
+	SetFieldCipher(&testBrokenFieldCipher{}, nil)
+	_, err = suite.mapper.MapMgoToObject(mgo)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapObjectToMgo_Error_CipherNotSet() {
+	SetFieldCipher(nil, nil)
+
+	original := suite.getObject()
+	original.PanReference = "recurring_id"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Equal(suite.T(), errorFieldCipherNotSet, err)
+
+	original.PanReference = ""
+	original.CardHolder = "MR. CARD HOLDER"
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Equal(suite.T(), errorFieldCipherNotSet, err)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(suite.getObject())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoCardVaultToken).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoCardVaultToken).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoCardVaultToken).UpdatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoCardVaultToken).ExpireUpdatedAt)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapObjectToMgo_Error_Id() {
+	original := suite.getObject()
+	original.Id = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapObjectToMgo_Error_ProjectId() {
+	original := suite.getObject()
+	original.ProjectId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapObjectToMgo_Error_MerchantId() {
+	original := suite.getObject()
+	original.MerchantId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapObjectToMgo_Error_Dates() {
+	original := suite.getObject()
+	original.ExpireUpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapMgoToObject_Ok() {
+	original := &MgoCardVaultToken{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *CardVaultTokenTestSuite) Test_CardVaultToken_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoCardVaultToken{ExpireUpdatedAt: &invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoCardVaultToken{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoCardVaultToken{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
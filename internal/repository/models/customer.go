@@ -2,6 +2,7 @@ package models
 
 import (
 	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
@@ -18,8 +19,10 @@ type MgoCustomer struct {
 	TechEmail             string                           `bson:"tech_email"`
 	ExternalId            string                           `bson:"external_id"`
 	Email                 string                           `bson:"email"`
+	EmailIndex            string                           `bson:"email_index"`
 	EmailVerified         bool                             `bson:"email_verified"`
 	Phone                 string                           `bson:"phone"`
+	PhoneIndex            string                           `bson:"phone_index"`
 	PhoneVerified         bool                             `bson:"phone_verified"`
 	Name                  string                           `bson:"name"`
 	Ip                    []byte                           `bson:"ip"`
@@ -46,6 +49,7 @@ type MgoCustomerIdentity struct {
 	ProjectId  primitive.ObjectID `bson:"project_id" faker:"objectId"`
 	Type       string             `bson:"type"`
 	Value      string             `bson:"value"`
+	ValueIndex string             `bson:"value_index"`
 	Verified   bool               `bson:"verified"`
 	CreatedAt  time.Time          `bson:"created_at"`
 }
@@ -69,26 +73,21 @@ type MgoCustomerStringValueHistory struct {
 }
 
 func (m *customerMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	var err error
 	in := obj.(*billingpb.Customer)
 
 	out := &MgoCustomer{
 		TechEmail:             in.TechEmail,
 		ExternalId:            in.ExternalId,
-		Email:                 in.Email,
+		EmailIndex:            GetFieldIndex(pkg.PersonalDataFieldEmail, in.Email),
 		EmailVerified:         in.EmailVerified,
-		Phone:                 in.Phone,
+		PhoneIndex:            GetFieldIndex(pkg.PersonalDataFieldPhone, in.Phone),
 		PhoneVerified:         in.PhoneVerified,
-		Name:                  in.Name,
-		Ip:                    in.Ip,
 		Locale:                in.Locale,
 		AcceptLanguage:        in.AcceptLanguage,
-		UserAgent:             in.UserAgent,
-		Address:               in.Address,
 		Metadata:              in.Metadata,
 		NotifySale:            in.NotifySale,
-		NotifySaleEmail:       in.NotifySaleEmail,
 		NotifyNewRegion:       in.NotifyNewRegion,
-		NotifyNewRegionEmail:  in.NotifyNewRegionEmail,
 		Identity:              []*MgoCustomerIdentity{},
 		IpHistory:             []*MgoCustomerIpHistory{},
 		AddressHistory:        []*MgoCustomerAddressHistory{},
@@ -96,6 +95,38 @@ func (m *customerMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
 		AcceptLanguageHistory: []*MgoCustomerStringValueHistory{},
 	}
 
+	if out.Email, err = encryptField(pkg.PersonalDataFieldEmail, in.Email); err != nil {
+		return nil, err
+	}
+
+	if out.Phone, err = encryptField(pkg.PersonalDataFieldPhone, in.Phone); err != nil {
+		return nil, err
+	}
+
+	if out.Name, err = encryptField(pkg.PersonalDataFieldName, in.Name); err != nil {
+		return nil, err
+	}
+
+	if out.Ip, err = encryptFieldBytes(pkg.PersonalDataFieldIp, in.Ip); err != nil {
+		return nil, err
+	}
+
+	if out.UserAgent, err = encryptField(pkg.PersonalDataFieldUserAgent, in.UserAgent); err != nil {
+		return nil, err
+	}
+
+	if out.Address, err = encryptAddress(in.Address); err != nil {
+		return nil, err
+	}
+
+	if out.NotifySaleEmail, err = encryptField(pkg.PersonalDataFieldEmail, in.NotifySaleEmail); err != nil {
+		return nil, err
+	}
+
+	if out.NotifyNewRegionEmail, err = encryptField(pkg.PersonalDataFieldEmail, in.NotifyNewRegionEmail); err != nil {
+		return nil, err
+	}
+
 	if len(in.Id) <= 0 {
 		out.Id = primitive.NewObjectID()
 	} else {
@@ -145,31 +176,48 @@ func (m *customerMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
 			return nil, err
 		}
 
+		field := getCustomerIdentityField(v.Type)
 		mgoIdentity := &MgoCustomerIdentity{
 			MerchantId: merchantOid,
 			ProjectId:  projectOid,
 			Type:       v.Type,
-			Value:      v.Value,
+			ValueIndex: GetFieldIndex(field, v.Value),
 			Verified:   v.Verified,
 		}
 
+		if mgoIdentity.Value, err = encryptField(field, v.Value); err != nil {
+			return nil, err
+		}
+
 		mgoIdentity.CreatedAt, _ = ptypes.Timestamp(v.CreatedAt)
 		out.Identity = append(out.Identity, mgoIdentity)
 	}
 
 	for _, v := range in.IpHistory {
-		mgoIdentity := &MgoCustomerIpHistory{Ip: v.Ip}
+		mgoIdentity := &MgoCustomerIpHistory{}
+
+		if mgoIdentity.Ip, err = encryptFieldBytes(pkg.PersonalDataFieldIp, v.Ip); err != nil {
+			return nil, err
+		}
+
 		mgoIdentity.CreatedAt, _ = ptypes.Timestamp(v.CreatedAt)
 		out.IpHistory = append(out.IpHistory, mgoIdentity)
 	}
 
 	for _, v := range in.AddressHistory {
 		mgoIdentity := &MgoCustomerAddressHistory{
-			Country:    v.Country,
-			City:       v.City,
-			PostalCode: v.PostalCode,
-			State:      v.State,
+			Country: v.Country,
+			State:   v.State,
+		}
+
+		if mgoIdentity.City, err = encryptField(pkg.PersonalDataFieldAddress, v.City); err != nil {
+			return nil, err
+		}
+
+		if mgoIdentity.PostalCode, err = encryptField(pkg.PersonalDataFieldAddress, v.PostalCode); err != nil {
+			return nil, err
 		}
+
 		mgoIdentity.CreatedAt, _ = ptypes.Timestamp(v.CreatedAt)
 		out.AddressHistory = append(out.AddressHistory, mgoIdentity)
 	}
@@ -197,21 +245,13 @@ func (m *customerMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
 		Id:                    in.Id.Hex(),
 		TechEmail:             in.TechEmail,
 		ExternalId:            in.ExternalId,
-		Email:                 in.Email,
 		EmailVerified:         in.EmailVerified,
-		Phone:                 in.Phone,
 		PhoneVerified:         in.PhoneVerified,
-		Name:                  in.Name,
-		Ip:                    in.Ip,
 		Locale:                in.Locale,
 		AcceptLanguage:        in.AcceptLanguage,
-		UserAgent:             in.UserAgent,
-		Address:               in.Address,
 		Metadata:              in.Metadata,
 		NotifySale:            in.NotifySale,
-		NotifySaleEmail:       in.NotifySaleEmail,
 		NotifyNewRegion:       in.NotifyNewRegion,
-		NotifyNewRegionEmail:  in.NotifyNewRegionEmail,
 		Identity:              []*billingpb.CustomerIdentity{},
 		IpHistory:             []*billingpb.CustomerIpHistory{},
 		AddressHistory:        []*billingpb.CustomerAddressHistory{},
@@ -219,6 +259,38 @@ func (m *customerMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
 		AcceptLanguageHistory: []*billingpb.CustomerStringValueHistory{},
 	}
 
+	if out.Email, err = decryptField(in.Email); err != nil {
+		return nil, err
+	}
+
+	if out.Phone, err = decryptField(in.Phone); err != nil {
+		return nil, err
+	}
+
+	if out.Name, err = decryptField(in.Name); err != nil {
+		return nil, err
+	}
+
+	if out.Ip, err = decryptFieldBytes(in.Ip); err != nil {
+		return nil, err
+	}
+
+	if out.UserAgent, err = decryptField(in.UserAgent); err != nil {
+		return nil, err
+	}
+
+	if out.Address, err = decryptAddress(in.Address); err != nil {
+		return nil, err
+	}
+
+	if out.NotifySaleEmail, err = decryptField(in.NotifySaleEmail); err != nil {
+		return nil, err
+	}
+
+	if out.NotifyNewRegionEmail, err = decryptField(in.NotifyNewRegionEmail); err != nil {
+		return nil, err
+	}
+
 	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
 
 	if err != nil {
@@ -236,27 +308,42 @@ func (m *customerMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
 			MerchantId: v.MerchantId.Hex(),
 			ProjectId:  v.ProjectId.Hex(),
 			Type:       v.Type,
-			Value:      v.Value,
 			Verified:   v.Verified,
 		}
 
+		if identity.Value, err = decryptField(v.Value); err != nil {
+			return nil, err
+		}
+
 		identity.CreatedAt, _ = ptypes.TimestampProto(v.CreatedAt)
 		out.Identity = append(out.Identity, identity)
 	}
 
 	for _, v := range in.IpHistory {
-		identity := &billingpb.CustomerIpHistory{Ip: v.Ip}
+		identity := &billingpb.CustomerIpHistory{}
+
+		if identity.Ip, err = decryptFieldBytes(v.Ip); err != nil {
+			return nil, err
+		}
+
 		identity.CreatedAt, _ = ptypes.TimestampProto(v.CreatedAt)
 		out.IpHistory = append(out.IpHistory, identity)
 	}
 
 	for _, v := range in.AddressHistory {
 		identity := &billingpb.CustomerAddressHistory{
-			Country:    v.Country,
-			City:       v.City,
-			PostalCode: v.PostalCode,
-			State:      v.State,
+			Country: v.Country,
+			State:   v.State,
+		}
+
+		if identity.City, err = decryptField(v.City); err != nil {
+			return nil, err
+		}
+
+		if identity.PostalCode, err = decryptField(v.PostalCode); err != nil {
+			return nil, err
 		}
+
 		identity.CreatedAt, _ = ptypes.TimestampProto(v.CreatedAt)
 		out.AddressHistory = append(out.AddressHistory, identity)
 	}
@@ -275,3 +362,16 @@ func (m *customerMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
 
 	return out, nil
 }
+
+// getCustomerIdentityField returns the group of personal data fields of the identity value,
+// external identifiers aren't personal data of the customer
+func getCustomerIdentityField(identityType string) string {
+	switch identityType {
+	case pkg.UserIdentityTypeEmail:
+		return pkg.PersonalDataFieldEmail
+	case pkg.UserIdentityTypePhone:
+		return pkg.PersonalDataFieldPhone
+	}
+
+	return ""
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
@@ -10,6 +11,9 @@ import (
 type MgoKey struct {
 	Id           primitive.ObjectID  `bson:"_id" faker:"objectId"`
 	Code         string              `bson:"code"`
+	DataKeyId    string              `bson:"data_key_id,omitempty"`
+	CodeIndex    string              `bson:"code_index,omitempty"`
+	State        string              `bson:"state,omitempty"`
 	KeyProductId primitive.ObjectID  `bson:"key_product_id" faker:"objectId"`
 	PlatformId   string              `bson:"platform_id"`
 	OrderId      *primitive.ObjectID `bson:"order_id" faker:"objectIdPointer"`
@@ -42,6 +46,11 @@ func (k *keyMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
 		Code:         m.Code,
 	}
 
+	if envelope, ok := pkg.ParseKeyCodeEnvelope(m.Code); ok {
+		st.DataKeyId = envelope.DataKeyId
+		st.CodeIndex = envelope.Index
+	}
+
 	if m.OrderId != "" {
 		oid, err := primitive.ObjectIDFromHex(m.OrderId)
 
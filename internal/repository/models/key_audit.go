@@ -0,0 +1,113 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type keyAuditMapper struct{}
+
+func NewKeyAuditMapper() Mapper {
+	return &keyAuditMapper{}
+}
+
+type MgoKeyAudit struct {
+	Id           primitive.ObjectID  `bson:"_id" faker:"objectId"`
+	KeyId        primitive.ObjectID  `bson:"key_id" faker:"objectId"`
+	KeyProductId primitive.ObjectID  `bson:"key_product_id" faker:"objectId"`
+	PlatformId   string              `bson:"platform_id"`
+	OrderId      *primitive.ObjectID `bson:"order_id" faker:"objectIdPointer"`
+	Action       string              `bson:"action"`
+	UserId       string              `bson:"user_id"`
+	Reason       string              `bson:"reason"`
+	CreatedAt    time.Time           `bson:"created_at"`
+}
+
+func (m *keyAuditMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.KeyAudit)
+
+	out := &MgoKeyAudit{
+		PlatformId: in.PlatformId,
+		Action:     in.Action,
+		UserId:     in.UserId,
+		Reason:     in.Reason,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	keyOid, err := primitive.ObjectIDFromHex(in.KeyId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.KeyId = keyOid
+
+	keyProductOid, err := primitive.ObjectIDFromHex(in.KeyProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.KeyProductId = keyProductOid
+
+	if in.OrderId != "" {
+		orderOid, err := primitive.ObjectIDFromHex(in.OrderId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.OrderId = &orderOid
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *keyAuditMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoKeyAudit)
+
+	out := &pkg.KeyAudit{
+		Id:           in.Id.Hex(),
+		KeyId:        in.KeyId.Hex(),
+		KeyProductId: in.KeyProductId.Hex(),
+		PlatformId:   in.PlatformId,
+		Action:       in.Action,
+		UserId:       in.UserId,
+		Reason:       in.Reason,
+	}
+
+	if in.OrderId != nil {
+		out.OrderId = in.OrderId.Hex()
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type KeyAuditTestSuite struct {
+	suite.Suite
+	mapper keyAuditMapper
+}
+
+func TestKeyAuditTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyAuditTestSuite))
+}
+
+func (suite *KeyAuditTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_NewKeyAuditMapper() {
+	mapper := NewKeyAuditMapper()
+	assert.IsType(suite.T(), &keyAuditMapper{}, mapper)
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapObjectToMgo_Ok() {
+	original := &pkg.KeyAudit{
+		Id:           primitive.NewObjectID().Hex(),
+		KeyId:        primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		PlatformId:   "steam",
+		OrderId:      primitive.NewObjectID().Hex(),
+		Action:       pkg.KeyAuditActionRevoked,
+		UserId:       primitive.NewObjectID().Hex(),
+		Reason:       "leaked",
+		CreatedAt:    ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.KeyAudit))
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapObjectToMgo_Ok_EmptyIdAndDate() {
+	original := &pkg.KeyAudit{
+		KeyId:        primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoKeyAudit).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyAudit).CreatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoKeyAudit).OrderId)
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapObjectToMgo_Error_Id() {
+	original := &pkg.KeyAudit{
+		Id:           "test",
+		KeyId:        primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapObjectToMgo_Error_KeyId() {
+	original := &pkg.KeyAudit{
+		KeyId:        "test",
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapObjectToMgo_Error_KeyProductId() {
+	original := &pkg.KeyAudit{
+		KeyId:        primitive.NewObjectID().Hex(),
+		KeyProductId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapObjectToMgo_Error_OrderId() {
+	original := &pkg.KeyAudit{
+		KeyId:        primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		OrderId:      "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapObjectToMgo_Error_CreatedAt() {
+	original := &pkg.KeyAudit{
+		KeyId:        primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		CreatedAt:    &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapMgoToObject_Ok() {
+	original := &MgoKeyAudit{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *KeyAuditTestSuite) Test_KeyAudit_MapMgoToObject_Error_CreatedAt() {
+	original := &MgoKeyAudit{
+		CreatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
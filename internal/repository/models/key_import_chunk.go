@@ -0,0 +1,85 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type keyImportChunkMapper struct{}
+
+func NewKeyImportChunkMapper() Mapper {
+	return &keyImportChunkMapper{}
+}
+
+type MgoKeyImportChunk struct {
+	Id        primitive.ObjectID `bson:"_id" faker:"objectId"`
+	JobId     primitive.ObjectID `bson:"job_id" faker:"objectId"`
+	Number    int32              `bson:"number"`
+	DataKeyId string             `bson:"data_key_id"`
+	Data      []byte             `bson:"data"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (m *keyImportChunkMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.KeyImportChunk)
+
+	out := &MgoKeyImportChunk{
+		Number:    in.Number,
+		DataKeyId: in.DataKeyId,
+		Data:      in.Data,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	jobOid, err := primitive.ObjectIDFromHex(in.JobId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.JobId = jobOid
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *keyImportChunkMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoKeyImportChunk)
+
+	out := &pkg.KeyImportChunk{
+		Id:        in.Id.Hex(),
+		JobId:     in.JobId.Hex(),
+		Number:    in.Number,
+		DataKeyId: in.DataKeyId,
+		Data:      in.Data,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type KeyImportChunkTestSuite struct {
+	suite.Suite
+	mapper keyImportChunkMapper
+}
+
+func TestKeyImportChunkTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyImportChunkTestSuite))
+}
+
+func (suite *KeyImportChunkTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *KeyImportChunkTestSuite) Test_KeyImportChunk_NewKeyImportChunkMapper() {
+	mapper := NewKeyImportChunkMapper()
+	assert.IsType(suite.T(), &keyImportChunkMapper{}, mapper)
+}
+
+func (suite *KeyImportChunkTestSuite) Test_KeyImportChunk_MapObjectToMgo_Ok() {
+	original := &pkg.KeyImportChunk{
+		Id:        primitive.NewObjectID().Hex(),
+		JobId:     primitive.NewObjectID().Hex(),
+		Number:    1,
+		DataKeyId: primitive.NewObjectID().Hex(),
+		Data:      []byte("ciphertext"),
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.KeyImportChunk))
+}
+
+func (suite *KeyImportChunkTestSuite) Test_KeyImportChunk_MapObjectToMgo_Ok_EmptyIdAndDate() {
+	original := &pkg.KeyImportChunk{
+		JobId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoKeyImportChunk).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyImportChunk).CreatedAt.IsZero())
+}
+
+func (suite *KeyImportChunkTestSuite) Test_KeyImportChunk_MapObjectToMgo_Error_Id() {
+	original := &pkg.KeyImportChunk{
+		Id:    "test",
+		JobId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyImportChunkTestSuite) Test_KeyImportChunk_MapObjectToMgo_Error_JobId() {
+	original := &pkg.KeyImportChunk{
+		JobId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyImportChunkTestSuite) Test_KeyImportChunk_MapObjectToMgo_Error_CreatedAt() {
+	original := &pkg.KeyImportChunk{
+		JobId:     primitive.NewObjectID().Hex(),
+		CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyImportChunkTestSuite) Test_KeyImportChunk_MapMgoToObject_Ok() {
+	original := &MgoKeyImportChunk{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *KeyImportChunkTestSuite) Test_KeyImportChunk_MapMgoToObject_Error_CreatedAt() {
+	original := &MgoKeyImportChunk{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
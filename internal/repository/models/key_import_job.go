@@ -0,0 +1,188 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type keyImportJobMapper struct{}
+
+func NewKeyImportJobMapper() Mapper {
+	return &keyImportJobMapper{}
+}
+
+type MgoKeyImportJob struct {
+	Id              primitive.ObjectID `bson:"_id" faker:"objectId"`
+	MerchantId      primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	KeyProductId    primitive.ObjectID `bson:"key_product_id" faker:"objectId"`
+	PlatformId      string             `bson:"platform_id"`
+	Status          string             `bson:"status"`
+	ChunksCount     int32              `bson:"chunks_count"`
+	ChunksUploaded  int32              `bson:"chunks_uploaded"`
+	ChunksProcessed int32              `bson:"chunks_processed"`
+	LinesCount      int32              `bson:"lines_count"`
+	KeysProcessed   int32              `bson:"keys_processed"`
+	DuplicatesCount int32              `bson:"duplicates_count"`
+	FailedCount     int32              `bson:"failed_count"`
+	Error           string             `bson:"error"`
+	LockedUntil     *time.Time         `bson:"locked_until"`
+	CreatedAt       time.Time          `bson:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at"`
+	StartedAt       *time.Time         `bson:"started_at"`
+	FinishedAt      *time.Time         `bson:"finished_at"`
+}
+
+func (m *keyImportJobMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.KeyImportJob)
+
+	out := &MgoKeyImportJob{
+		PlatformId:      in.PlatformId,
+		Status:          in.Status,
+		ChunksCount:     in.ChunksCount,
+		ChunksUploaded:  in.ChunksUploaded,
+		ChunksProcessed: in.ChunksProcessed,
+		LinesCount:      in.LinesCount,
+		KeysProcessed:   in.KeysProcessed,
+		DuplicatesCount: in.DuplicatesCount,
+		FailedCount:     in.FailedCount,
+		Error:           in.Error,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	keyProductOid, err := primitive.ObjectIDFromHex(in.KeyProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.KeyProductId = keyProductOid
+
+	if in.LockedUntil != nil {
+		t, err := ptypes.Timestamp(in.LockedUntil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.LockedUntil = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	if in.StartedAt != nil {
+		t, err := ptypes.Timestamp(in.StartedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.StartedAt = &t
+	}
+
+	if in.FinishedAt != nil {
+		t, err := ptypes.Timestamp(in.FinishedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.FinishedAt = &t
+	}
+
+	return out, nil
+}
+
+func (m *keyImportJobMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoKeyImportJob)
+
+	out := &pkg.KeyImportJob{
+		Id:              in.Id.Hex(),
+		MerchantId:      in.MerchantId.Hex(),
+		KeyProductId:    in.KeyProductId.Hex(),
+		PlatformId:      in.PlatformId,
+		Status:          in.Status,
+		ChunksCount:     in.ChunksCount,
+		ChunksUploaded:  in.ChunksUploaded,
+		ChunksProcessed: in.ChunksProcessed,
+		LinesCount:      in.LinesCount,
+		KeysProcessed:   in.KeysProcessed,
+		DuplicatesCount: in.DuplicatesCount,
+		FailedCount:     in.FailedCount,
+		Error:           in.Error,
+	}
+
+	if in.LockedUntil != nil {
+		out.LockedUntil, err = ptypes.TimestampProto(*in.LockedUntil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.StartedAt != nil {
+		out.StartedAt, err = ptypes.TimestampProto(*in.StartedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if in.FinishedAt != nil {
+		out.FinishedAt, err = ptypes.TimestampProto(*in.FinishedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type KeyImportJobTestSuite struct {
+	suite.Suite
+	mapper keyImportJobMapper
+}
+
+func TestKeyImportJobTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyImportJobTestSuite))
+}
+
+func (suite *KeyImportJobTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_NewKeyImportJobMapper() {
+	mapper := NewKeyImportJobMapper()
+	assert.IsType(suite.T(), &keyImportJobMapper{}, mapper)
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_MapObjectToMgo_Ok() {
+	original := &pkg.KeyImportJob{
+		Id:              primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		KeyProductId:    primitive.NewObjectID().Hex(),
+		PlatformId:      "steam",
+		Status:          pkg.KeyImportJobStatusProcessing,
+		ChunksCount:     3,
+		ChunksUploaded:  3,
+		ChunksProcessed: 1,
+		LinesCount:      1000,
+		KeysProcessed:   990,
+		DuplicatesCount: 8,
+		FailedCount:     2,
+		LockedUntil:     ptypes.TimestampNow(),
+		CreatedAt:       ptypes.TimestampNow(),
+		UpdatedAt:       ptypes.TimestampNow(),
+		StartedAt:       ptypes.TimestampNow(),
+		FinishedAt:      ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.KeyImportJob))
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.KeyImportJob{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoKeyImportJob).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyImportJob).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyImportJob).UpdatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoKeyImportJob).LockedUntil)
+	assert.Nil(suite.T(), mgo.(*MgoKeyImportJob).StartedAt)
+	assert.Nil(suite.T(), mgo.(*MgoKeyImportJob).FinishedAt)
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_MapObjectToMgo_Error_Id() {
+	original := &pkg.KeyImportJob{
+		Id:           "test",
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.KeyImportJob{
+		MerchantId:   "test",
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_MapObjectToMgo_Error_KeyProductId() {
+	original := &pkg.KeyImportJob{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_MapObjectToMgo_Error_Dates() {
+	original := &pkg.KeyImportJob{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		LockedUntil:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.LockedUntil = nil
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.CreatedAt = nil
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.UpdatedAt = nil
+	original.StartedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.StartedAt = nil
+	original.FinishedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_MapMgoToObject_Ok() {
+	original := &MgoKeyImportJob{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *KeyImportJobTestSuite) Test_KeyImportJob_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoKeyImportJob{LockedUntil: &invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyImportJob{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyImportJob{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyImportJob{StartedAt: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyImportJob{FinishedAt: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
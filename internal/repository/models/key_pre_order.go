@@ -0,0 +1,163 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type keyPreOrderMapper struct{}
+
+func NewKeyPreOrderMapper() Mapper {
+	return &keyPreOrderMapper{}
+}
+
+type MgoKeyPreOrder struct {
+	Id           primitive.ObjectID `bson:"_id" faker:"objectId"`
+	OrderId      primitive.ObjectID `bson:"order_id" faker:"objectId"`
+	MerchantId   primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	KeyProductId primitive.ObjectID `bson:"key_product_id" faker:"objectId"`
+	KeyId        primitive.ObjectID `bson:"key_id" faker:"objectId"`
+	PlatformId   string             `bson:"platform_id"`
+	Region       string             `bson:"region"`
+	Status       string             `bson:"status"`
+	ReleaseAt    time.Time          `bson:"release_at"`
+	DeliveredAt  *time.Time         `bson:"delivered_at"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at"`
+}
+
+func (m *keyPreOrderMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.KeyPreOrder)
+
+	out := &MgoKeyPreOrder{
+		PlatformId: in.PlatformId,
+		Region:     in.Region,
+		Status:     in.Status,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	orderOid, err := primitive.ObjectIDFromHex(in.OrderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.OrderId = orderOid
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	keyProductOid, err := primitive.ObjectIDFromHex(in.KeyProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.KeyProductId = keyProductOid
+
+	keyOid, err := primitive.ObjectIDFromHex(in.KeyId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.KeyId = keyOid
+
+	out.ReleaseAt, err = ptypes.Timestamp(in.ReleaseAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if in.DeliveredAt != nil {
+		t, err := ptypes.Timestamp(in.DeliveredAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.DeliveredAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *keyPreOrderMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoKeyPreOrder)
+
+	out := &pkg.KeyPreOrder{
+		Id:           in.Id.Hex(),
+		OrderId:      in.OrderId.Hex(),
+		MerchantId:   in.MerchantId.Hex(),
+		KeyProductId: in.KeyProductId.Hex(),
+		KeyId:        in.KeyId.Hex(),
+		PlatformId:   in.PlatformId,
+		Region:       in.Region,
+		Status:       in.Status,
+	}
+
+	out.ReleaseAt, err = ptypes.TimestampProto(in.ReleaseAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.DeliveredAt != nil {
+		out.DeliveredAt, err = ptypes.TimestampProto(*in.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
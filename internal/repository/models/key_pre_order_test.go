@@ -0,0 +1,166 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type KeyPreOrderTestSuite struct {
+	suite.Suite
+	mapper keyPreOrderMapper
+}
+
+func TestKeyPreOrderTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyPreOrderTestSuite))
+}
+
+func (suite *KeyPreOrderTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *KeyPreOrderTestSuite) getPreOrder() *pkg.KeyPreOrder {
+	return &pkg.KeyPreOrder{
+		OrderId:      primitive.NewObjectID().Hex(),
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		KeyId:        primitive.NewObjectID().Hex(),
+		ReleaseAt:    ptypes.TimestampNow(),
+	}
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_NewKeyPreOrderMapper() {
+	mapper := NewKeyPreOrderMapper()
+	assert.IsType(suite.T(), &keyPreOrderMapper{}, mapper)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapObjectToMgo_Ok() {
+	original := &pkg.KeyPreOrder{
+		Id:           primitive.NewObjectID().Hex(),
+		OrderId:      primitive.NewObjectID().Hex(),
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		KeyId:        primitive.NewObjectID().Hex(),
+		PlatformId:   "steam",
+		Region:       "EUR",
+		Status:       pkg.KeyPreOrderStatusDelivered,
+		ReleaseAt:    ptypes.TimestampNow(),
+		DeliveredAt:  ptypes.TimestampNow(),
+		CreatedAt:    ptypes.TimestampNow(),
+		UpdatedAt:    ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.KeyPreOrder))
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(suite.getPreOrder())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoKeyPreOrder).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyPreOrder).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyPreOrder).UpdatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoKeyPreOrder).DeliveredAt)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapObjectToMgo_Error_Id() {
+	original := suite.getPreOrder()
+	original.Id = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapObjectToMgo_Error_OrderId() {
+	original := suite.getPreOrder()
+	original.OrderId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapObjectToMgo_Error_MerchantId() {
+	original := suite.getPreOrder()
+	original.MerchantId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapObjectToMgo_Error_KeyProductId() {
+	original := suite.getPreOrder()
+	original.KeyProductId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapObjectToMgo_Error_KeyId() {
+	original := suite.getPreOrder()
+	original.KeyId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapObjectToMgo_Error_Dates() {
+	original := suite.getPreOrder()
+	original.ReleaseAt = nil
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.ReleaseAt = ptypes.TimestampNow()
+	original.DeliveredAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.DeliveredAt = nil
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.CreatedAt = nil
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapMgoToObject_Ok() {
+	original := &MgoKeyPreOrder{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *KeyPreOrderTestSuite) Test_KeyPreOrder_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoKeyPreOrder{ReleaseAt: invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyPreOrder{DeliveredAt: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyPreOrder{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyPreOrder{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type keyProductReleaseMapper struct{}
+
+func NewKeyProductReleaseMapper() Mapper {
+	return &keyProductReleaseMapper{}
+}
+
+type MgoKeyProductRelease struct {
+	Id           primitive.ObjectID   `bson:"_id" faker:"objectId"`
+	MerchantId   primitive.ObjectID   `bson:"merchant_id" faker:"objectId"`
+	KeyProductId primitive.ObjectID   `bson:"key_product_id" faker:"objectId"`
+	ReleaseAt    time.Time            `bson:"release_at"`
+	Regions      map[string]time.Time `bson:"regions"`
+	CreatedAt    time.Time            `bson:"created_at"`
+	UpdatedAt    time.Time            `bson:"updated_at"`
+}
+
+func (m *keyProductReleaseMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.KeyProductRelease)
+
+	out := &MgoKeyProductRelease{
+		Regions: make(map[string]time.Time, len(in.Regions)),
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	keyProductOid, err := primitive.ObjectIDFromHex(in.KeyProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.KeyProductId = keyProductOid
+
+	out.ReleaseAt, err = ptypes.Timestamp(in.ReleaseAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for region, releaseAt := range in.Regions {
+		t, err := ptypes.Timestamp(releaseAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.Regions[region] = t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *keyProductReleaseMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoKeyProductRelease)
+
+	out := &pkg.KeyProductRelease{
+		Id:           in.Id.Hex(),
+		MerchantId:   in.MerchantId.Hex(),
+		KeyProductId: in.KeyProductId.Hex(),
+	}
+
+	out.ReleaseAt, err = ptypes.TimestampProto(in.ReleaseAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(in.Regions) > 0 {
+		out.Regions = make(map[string]*timestamp.Timestamp, len(in.Regions))
+
+		for region, releaseAt := range in.Regions {
+			out.Regions[region], err = ptypes.TimestampProto(releaseAt)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
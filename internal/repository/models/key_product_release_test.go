@@ -0,0 +1,154 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type KeyProductReleaseTestSuite struct {
+	suite.Suite
+	mapper keyProductReleaseMapper
+}
+
+func TestKeyProductReleaseTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyProductReleaseTestSuite))
+}
+
+func (suite *KeyProductReleaseTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_NewKeyProductReleaseMapper() {
+	mapper := NewKeyProductReleaseMapper()
+	assert.IsType(suite.T(), &keyProductReleaseMapper{}, mapper)
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_MapObjectToMgo_Ok() {
+	original := &pkg.KeyProductRelease{
+		Id:           primitive.NewObjectID().Hex(),
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		ReleaseAt:    ptypes.TimestampNow(),
+		Regions:      map[string]*timestamp.Timestamp{"EUR": ptypes.TimestampNow()},
+		CreatedAt:    ptypes.TimestampNow(),
+		UpdatedAt:    ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.KeyProductRelease))
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.KeyProductRelease{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		ReleaseAt:    ptypes.TimestampNow(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoKeyProductRelease).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyProductRelease).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyProductRelease).UpdatedAt.IsZero())
+	assert.Empty(suite.T(), mgo.(*MgoKeyProductRelease).Regions)
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_MapObjectToMgo_Error_Id() {
+	original := &pkg.KeyProductRelease{
+		Id:           "test",
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		ReleaseAt:    ptypes.TimestampNow(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.KeyProductRelease{
+		MerchantId:   "test",
+		KeyProductId: primitive.NewObjectID().Hex(),
+		ReleaseAt:    ptypes.TimestampNow(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_MapObjectToMgo_Error_KeyProductId() {
+	original := &pkg.KeyProductRelease{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: "test",
+		ReleaseAt:    ptypes.TimestampNow(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_MapObjectToMgo_Error_Dates() {
+	original := &pkg.KeyProductRelease{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.ReleaseAt = ptypes.TimestampNow()
+	original.Regions = map[string]*timestamp.Timestamp{"EUR": {Seconds: -1, Nanos: -1}}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.Regions = nil
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.CreatedAt = nil
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_MapMgoToObject_Ok() {
+	original := &MgoKeyProductRelease{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *KeyProductReleaseTestSuite) Test_KeyProductRelease_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoKeyProductRelease{ReleaseAt: invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyProductRelease{Regions: map[string]time.Time{"EUR": invalid}}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyProductRelease{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyProductRelease{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type keyReservationPolicyMapper struct{}
+
+func NewKeyReservationPolicyMapper() Mapper {
+	return &keyReservationPolicyMapper{}
+}
+
+type MgoKeyReservationPolicy struct {
+	Id             primitive.ObjectID `bson:"_id" faker:"objectId"`
+	MerchantId     primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	KeyProductId   primitive.ObjectID `bson:"key_product_id" faker:"objectId"`
+	Ttl            int32              `bson:"ttl"`
+	FailedOrderTtl int32              `bson:"failed_order_ttl"`
+	CreatedAt      time.Time          `bson:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at"`
+}
+
+func (m *keyReservationPolicyMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.KeyReservationPolicy)
+
+	out := &MgoKeyReservationPolicy{
+		Ttl:            in.Ttl,
+		FailedOrderTtl: in.FailedOrderTtl,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	keyProductOid, err := primitive.ObjectIDFromHex(in.KeyProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.KeyProductId = keyProductOid
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *keyReservationPolicyMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoKeyReservationPolicy)
+
+	out := &pkg.KeyReservationPolicy{
+		Id:             in.Id.Hex(),
+		MerchantId:     in.MerchantId.Hex(),
+		KeyProductId:   in.KeyProductId.Hex(),
+		Ttl:            in.Ttl,
+		FailedOrderTtl: in.FailedOrderTtl,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
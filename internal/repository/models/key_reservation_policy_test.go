@@ -0,0 +1,132 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type KeyReservationPolicyTestSuite struct {
+	suite.Suite
+	mapper keyReservationPolicyMapper
+}
+
+func TestKeyReservationPolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyReservationPolicyTestSuite))
+}
+
+func (suite *KeyReservationPolicyTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_NewKeyReservationPolicyMapper() {
+	mapper := NewKeyReservationPolicyMapper()
+	assert.IsType(suite.T(), &keyReservationPolicyMapper{}, mapper)
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_MapObjectToMgo_Ok() {
+	original := &pkg.KeyReservationPolicy{
+		Id:             primitive.NewObjectID().Hex(),
+		MerchantId:     primitive.NewObjectID().Hex(),
+		KeyProductId:   primitive.NewObjectID().Hex(),
+		Ttl:            3600,
+		FailedOrderTtl: 600,
+		CreatedAt:      ptypes.TimestampNow(),
+		UpdatedAt:      ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.KeyReservationPolicy))
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.KeyReservationPolicy{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoKeyReservationPolicy).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyReservationPolicy).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyReservationPolicy).UpdatedAt.IsZero())
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_MapObjectToMgo_Error_Id() {
+	original := &pkg.KeyReservationPolicy{
+		Id:           "test",
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.KeyReservationPolicy{
+		MerchantId:   "test",
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_MapObjectToMgo_Error_KeyProductId() {
+	original := &pkg.KeyReservationPolicy{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_MapObjectToMgo_Error_Dates() {
+	original := &pkg.KeyReservationPolicy{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		CreatedAt:    &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.CreatedAt = nil
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_MapMgoToObject_Ok() {
+	original := &MgoKeyReservationPolicy{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *KeyReservationPolicyTestSuite) Test_KeyReservationPolicy_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoKeyReservationPolicy{CreatedAt: invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyReservationPolicy{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
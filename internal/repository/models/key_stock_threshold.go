@@ -0,0 +1,131 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type keyStockThresholdMapper struct{}
+
+func NewKeyStockThresholdMapper() Mapper {
+	return &keyStockThresholdMapper{}
+}
+
+type MgoKeyStockThreshold struct {
+	Id           primitive.ObjectID `bson:"_id" faker:"objectId"`
+	MerchantId   primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	KeyProductId primitive.ObjectID `bson:"key_product_id" faker:"objectId"`
+	PlatformId   string             `bson:"platform_id"`
+	Threshold    int32              `bson:"threshold"`
+	IsNotified   bool               `bson:"is_notified"`
+	NotifiedAt   *time.Time         `bson:"notified_at"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at"`
+}
+
+func (m *keyStockThresholdMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.KeyStockThreshold)
+
+	out := &MgoKeyStockThreshold{
+		PlatformId: in.PlatformId,
+		Threshold:  in.Threshold,
+		IsNotified: in.IsNotified,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	keyProductOid, err := primitive.ObjectIDFromHex(in.KeyProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.KeyProductId = keyProductOid
+
+	if in.NotifiedAt != nil {
+		t, err := ptypes.Timestamp(in.NotifiedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.NotifiedAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *keyStockThresholdMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoKeyStockThreshold)
+
+	out := &pkg.KeyStockThreshold{
+		Id:           in.Id.Hex(),
+		MerchantId:   in.MerchantId.Hex(),
+		KeyProductId: in.KeyProductId.Hex(),
+		PlatformId:   in.PlatformId,
+		Threshold:    in.Threshold,
+		IsNotified:   in.IsNotified,
+	}
+
+	if in.NotifiedAt != nil {
+		out.NotifiedAt, err = ptypes.TimestampProto(*in.NotifiedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
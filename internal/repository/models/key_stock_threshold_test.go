@@ -0,0 +1,144 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type KeyStockThresholdTestSuite struct {
+	suite.Suite
+	mapper keyStockThresholdMapper
+}
+
+func TestKeyStockThresholdTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyStockThresholdTestSuite))
+}
+
+func (suite *KeyStockThresholdTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_NewKeyStockThresholdMapper() {
+	mapper := NewKeyStockThresholdMapper()
+	assert.IsType(suite.T(), &keyStockThresholdMapper{}, mapper)
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_MapObjectToMgo_Ok() {
+	original := &pkg.KeyStockThreshold{
+		Id:           primitive.NewObjectID().Hex(),
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		PlatformId:   "steam",
+		Threshold:    10,
+		IsNotified:   true,
+		NotifiedAt:   ptypes.TimestampNow(),
+		CreatedAt:    ptypes.TimestampNow(),
+		UpdatedAt:    ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.KeyStockThreshold))
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.KeyStockThreshold{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoKeyStockThreshold).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyStockThreshold).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoKeyStockThreshold).UpdatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoKeyStockThreshold).NotifiedAt)
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_MapObjectToMgo_Error_Id() {
+	original := &pkg.KeyStockThreshold{
+		Id:           "test",
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.KeyStockThreshold{
+		MerchantId:   "test",
+		KeyProductId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_MapObjectToMgo_Error_KeyProductId() {
+	original := &pkg.KeyStockThreshold{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_MapObjectToMgo_Error_Dates() {
+	original := &pkg.KeyStockThreshold{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		NotifiedAt:   &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.NotifiedAt = nil
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.CreatedAt = nil
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_MapMgoToObject_Ok() {
+	original := &MgoKeyStockThreshold{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *KeyStockThresholdTestSuite) Test_KeyStockThreshold_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoKeyStockThreshold{NotifiedAt: &invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyStockThreshold{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoKeyStockThreshold{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -5,9 +5,11 @@ import (
 	"github.com/bxcodec/faker"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"testing"
 )
 
@@ -51,6 +53,29 @@ func (suite *KeyTestSuite) Test_MapToMgo_Ok() {
 	assert.JSONEq(suite.T(), string(buf1.Bytes()), string(buf2.Bytes()))
 }
 
+func (suite *KeyTestSuite) Test_MapToMgo_Ok_EncryptedCode() {
+	envelope := &pkg.KeyCodeEnvelope{
+		DataKeyId:  primitive.NewObjectID().Hex(),
+		Index:      "index",
+		Ciphertext: "Y2lwaGVydGV4dA==",
+	}
+	original := &billingpb.Key{
+		Id:           primitive.NewObjectID().Hex(),
+		KeyProductId: primitive.NewObjectID().Hex(),
+		Code:         envelope.String(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original.Code, mgo.(*MgoKey).Code)
+	assert.Equal(suite.T(), envelope.DataKeyId, mgo.(*MgoKey).DataKeyId)
+	assert.Equal(suite.T(), envelope.Index, mgo.(*MgoKey).CodeIndex)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original.Code, obj.(*billingpb.Key).Code)
+}
+
 func (suite *KeyTestSuite) Test_Error_CreatedAt() {
 	original := &billingpb.Key{
 		CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
@@ -73,4 +98,4 @@ func (suite *KeyTestSuite) Test_Error_ReservedTo() {
 	}
 	_, err := suite.mapper.MapObjectToMgo(original)
 	assert.Error(suite.T(), err)
-}
\ No newline at end of file
+}
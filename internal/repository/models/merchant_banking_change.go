@@ -0,0 +1,192 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type merchantBankingChangeMapper struct{}
+
+func NewMerchantBankingChangeMapper() Mapper {
+	return &merchantBankingChangeMapper{}
+}
+
+type MgoMerchantBankingChange struct {
+	Id                        primitive.ObjectID         `bson:"_id" faker:"objectId"`
+	MerchantId                primitive.ObjectID         `bson:"merchant_id" faker:"objectId"`
+	Previous                  *billingpb.MerchantBanking `bson:"previous"`
+	Banking                   *billingpb.MerchantBanking `bson:"banking"`
+	Status                    string                     `bson:"status"`
+	VerificationMethod        string                     `bson:"verification_method"`
+	MicroDeposits             []float64                  `bson:"micro_deposits"`
+	MicroDepositAttempts      int32                      `bson:"micro_deposit_attempts"`
+	ConfirmationCodeHash      string                     `bson:"confirmation_code_hash"`
+	ConfirmationCodeSalt      string                     `bson:"confirmation_code_salt"`
+	ConfirmationCodeAttempts  int32                      `bson:"confirmation_code_attempts"`
+	ConfirmationCodeExpiresAt *time.Time                 `bson:"confirmation_code_expires_at"`
+	IsOwnerConfirmed          bool                       `bson:"is_owner_confirmed"`
+	IsAccountVerified         bool                       `bson:"is_account_verified"`
+	ReviewerId                string                     `bson:"reviewer_id"`
+	RejectReason              string                     `bson:"reject_reason"`
+	UserId                    string                     `bson:"user_id"`
+	Ip                        string                     `bson:"ip"`
+	HoldUntil                 time.Time                  `bson:"hold_until"`
+	VerifiedAt                *time.Time                 `bson:"verified_at"`
+	CreatedAt                 time.Time                  `bson:"created_at"`
+	UpdatedAt                 time.Time                  `bson:"updated_at"`
+}
+
+func (m *merchantBankingChangeMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.MerchantBankingChange)
+
+	out := &MgoMerchantBankingChange{
+		Previous:                 in.Previous,
+		Banking:                  in.Banking,
+		Status:                   in.Status,
+		VerificationMethod:       in.VerificationMethod,
+		MicroDeposits:            in.MicroDeposits,
+		MicroDepositAttempts:     in.MicroDepositAttempts,
+		ConfirmationCodeHash:     in.ConfirmationCodeHash,
+		ConfirmationCodeSalt:     in.ConfirmationCodeSalt,
+		ConfirmationCodeAttempts: in.ConfirmationCodeAttempts,
+		IsOwnerConfirmed:         in.IsOwnerConfirmed,
+		IsAccountVerified:        in.IsAccountVerified,
+		ReviewerId:               in.ReviewerId,
+		RejectReason:             in.RejectReason,
+		UserId:                   in.UserId,
+		Ip:                       in.Ip,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	if in.HoldUntil != nil {
+		t, err := ptypes.Timestamp(in.HoldUntil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.HoldUntil = t
+	}
+
+	if in.ConfirmationCodeExpiresAt != nil {
+		t, err := ptypes.Timestamp(in.ConfirmationCodeExpiresAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ConfirmationCodeExpiresAt = &t
+	}
+
+	if in.VerifiedAt != nil {
+		t, err := ptypes.Timestamp(in.VerifiedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.VerifiedAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *merchantBankingChangeMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoMerchantBankingChange)
+
+	out := &pkg.MerchantBankingChange{
+		Id:                       in.Id.Hex(),
+		MerchantId:               in.MerchantId.Hex(),
+		Previous:                 in.Previous,
+		Banking:                  in.Banking,
+		Status:                   in.Status,
+		VerificationMethod:       in.VerificationMethod,
+		MicroDeposits:            in.MicroDeposits,
+		MicroDepositAttempts:     in.MicroDepositAttempts,
+		ConfirmationCodeHash:     in.ConfirmationCodeHash,
+		ConfirmationCodeSalt:     in.ConfirmationCodeSalt,
+		ConfirmationCodeAttempts: in.ConfirmationCodeAttempts,
+		IsOwnerConfirmed:         in.IsOwnerConfirmed,
+		IsAccountVerified:        in.IsAccountVerified,
+		ReviewerId:               in.ReviewerId,
+		RejectReason:             in.RejectReason,
+		UserId:                   in.UserId,
+		Ip:                       in.Ip,
+	}
+
+	out.HoldUntil, err = ptypes.TimestampProto(in.HoldUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ConfirmationCodeExpiresAt != nil {
+		out.ConfirmationCodeExpiresAt, err = ptypes.TimestampProto(*in.ConfirmationCodeExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if in.VerifiedAt != nil {
+		out.VerifiedAt, err = ptypes.TimestampProto(*in.VerifiedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,230 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type MerchantBankingChangeTestSuite struct {
+	suite.Suite
+	mapper merchantBankingChangeMapper
+}
+
+func TestMerchantBankingChangeTestSuite(t *testing.T) {
+	suite.Run(t, new(MerchantBankingChangeTestSuite))
+}
+
+func (suite *MerchantBankingChangeTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_NewMerchantBankingChangeMapper() {
+	mapper := NewMerchantBankingChangeMapper()
+	assert.IsType(suite.T(), &merchantBankingChangeMapper{}, mapper)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Ok() {
+	original := &pkg.MerchantBankingChange{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		Previous: &billingpb.MerchantBanking{
+			Currency:      "EUR",
+			Name:          "Old bank",
+			AccountNumber: "DE89370400440532013000",
+			Swift:         "COBADEFFXXX",
+		},
+		Banking: &billingpb.MerchantBanking{
+			Currency:      "EUR",
+			Name:          "New bank",
+			AccountNumber: "GB29NWBK60161331926819",
+			Swift:         "NWBKGB2L",
+		},
+		Status:                    pkg.MerchantBankingChangeStatusVerified,
+		VerificationMethod:        pkg.MerchantBankingVerificationMicroDeposit,
+		MicroDeposits:             []float64{0.12, 0.34},
+		MicroDepositAttempts:      1,
+		ConfirmationCodeAttempts:  2,
+		ConfirmationCodeExpiresAt: ptypes.TimestampNow(),
+		IsOwnerConfirmed:          true,
+		IsAccountVerified:         true,
+		UserId:                    primitive.NewObjectID().Hex(),
+		Ip:                        "127.0.0.1",
+		HoldUntil:                 ptypes.TimestampNow(),
+		VerifiedAt:                ptypes.TimestampNow(),
+		CreatedAt:                 ptypes.TimestampNow(),
+		UpdatedAt:                 ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), obj)
+
+	b1, err := json.Marshal(original)
+	assert.NoError(suite.T(), err)
+	b2, err := json.Marshal(obj.(*pkg.MerchantBankingChange))
+	assert.NoError(suite.T(), err)
+	assert.JSONEq(suite.T(), string(b1), string(b2))
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Ok_ConfirmationCodeHash() {
+	original := &pkg.MerchantBankingChange{
+		MerchantId:           primitive.NewObjectID().Hex(),
+		ConfirmationCodeHash: "hash",
+		ConfirmationCodeSalt: "salt",
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "hash", mgo.(*MgoMerchantBankingChange).ConfirmationCodeHash)
+	assert.Equal(suite.T(), "salt", mgo.(*MgoMerchantBankingChange).ConfirmationCodeSalt)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "hash", obj.(*pkg.MerchantBankingChange).ConfirmationCodeHash)
+	assert.Equal(suite.T(), "salt", obj.(*pkg.MerchantBankingChange).ConfirmationCodeSalt)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.MerchantBankingChange{
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+
+	out := mgo.(*MgoMerchantBankingChange)
+	assert.False(suite.T(), out.Id.IsZero())
+	assert.True(suite.T(), out.HoldUntil.IsZero())
+	assert.Nil(suite.T(), out.VerifiedAt)
+	assert.False(suite.T(), out.CreatedAt.IsZero())
+	assert.False(suite.T(), out.UpdatedAt.IsZero())
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Error_Id() {
+	original := &pkg.MerchantBankingChange{
+		Id:         "test",
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.MerchantBankingChange{
+		MerchantId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Error_HoldUntil() {
+	original := &pkg.MerchantBankingChange{
+		MerchantId: primitive.NewObjectID().Hex(),
+		HoldUntil:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Error_ConfirmationCodeExpiresAt() {
+	original := &pkg.MerchantBankingChange{
+		MerchantId:                primitive.NewObjectID().Hex(),
+		ConfirmationCodeExpiresAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Error_VerifiedAt() {
+	original := &pkg.MerchantBankingChange{
+		MerchantId: primitive.NewObjectID().Hex(),
+		VerifiedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Error_CreatedAt() {
+	original := &pkg.MerchantBankingChange{
+		MerchantId: primitive.NewObjectID().Hex(),
+		CreatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapObjectToMgo_Error_UpdatedAt() {
+	original := &pkg.MerchantBankingChange{
+		MerchantId: primitive.NewObjectID().Hex(),
+		UpdatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapMgoToObject_Ok() {
+	original := &MgoMerchantBankingChange{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapMgoToObject_Error_HoldUntil() {
+	original := &MgoMerchantBankingChange{
+		HoldUntil: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapMgoToObject_Error_ConfirmationCodeExpiresAt() {
+	t := time.Time{}.AddDate(-10000, 0, 0)
+	original := &MgoMerchantBankingChange{
+		ConfirmationCodeExpiresAt: &t,
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapMgoToObject_Error_VerifiedAt() {
+	t := time.Time{}.AddDate(-10000, 0, 0)
+	original := &MgoMerchantBankingChange{
+		VerifiedAt: &t,
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapMgoToObject_Error_CreatedAt() {
+	original := &MgoMerchantBankingChange{
+		CreatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantBankingChangeTestSuite) Test_MerchantBankingChange_MapMgoToObject_Error_UpdatedAt() {
+	original := &MgoMerchantBankingChange{
+		UpdatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type merchantDataKeyMapper struct{}
+
+func NewMerchantDataKeyMapper() Mapper {
+	return &merchantDataKeyMapper{}
+}
+
+type MgoMerchantDataKey struct {
+	Id           primitive.ObjectID `bson:"_id" faker:"objectId"`
+	MerchantId   primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	MasterKeyId  string             `bson:"master_key_id"`
+	EncryptedKey []byte             `bson:"encrypted_key"`
+	CreatedAt    time.Time          `bson:"created_at"`
+}
+
+func (m *merchantDataKeyMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.MerchantDataKey)
+
+	out := &MgoMerchantDataKey{
+		MasterKeyId:  in.MasterKeyId,
+		EncryptedKey: in.EncryptedKey,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *merchantDataKeyMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoMerchantDataKey)
+
+	out := &pkg.MerchantDataKey{
+		Id:           in.Id.Hex(),
+		MerchantId:   in.MerchantId.Hex(),
+		MasterKeyId:  in.MasterKeyId,
+		EncryptedKey: in.EncryptedKey,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
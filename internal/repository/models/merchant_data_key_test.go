@@ -0,0 +1,107 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type MerchantDataKeyTestSuite struct {
+	suite.Suite
+	mapper merchantDataKeyMapper
+}
+
+func TestMerchantDataKeyTestSuite(t *testing.T) {
+	suite.Run(t, new(MerchantDataKeyTestSuite))
+}
+
+func (suite *MerchantDataKeyTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *MerchantDataKeyTestSuite) Test_MerchantDataKey_NewMerchantDataKeyMapper() {
+	mapper := NewMerchantDataKeyMapper()
+	assert.IsType(suite.T(), &merchantDataKeyMapper{}, mapper)
+}
+
+func (suite *MerchantDataKeyTestSuite) Test_MerchantDataKey_MapObjectToMgo_Ok() {
+	original := &pkg.MerchantDataKey{
+		Id:           primitive.NewObjectID().Hex(),
+		MerchantId:   primitive.NewObjectID().Hex(),
+		MasterKeyId:  "1",
+		EncryptedKey: []byte("encrypted"),
+		CreatedAt:    ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.MerchantDataKey))
+}
+
+func (suite *MerchantDataKeyTestSuite) Test_MerchantDataKey_MapObjectToMgo_Ok_EmptyIdAndDate() {
+	original := &pkg.MerchantDataKey{
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoMerchantDataKey).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoMerchantDataKey).CreatedAt.IsZero())
+}
+
+func (suite *MerchantDataKeyTestSuite) Test_MerchantDataKey_MapObjectToMgo_Error_Id() {
+	original := &pkg.MerchantDataKey{
+		Id:         "test",
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantDataKeyTestSuite) Test_MerchantDataKey_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.MerchantDataKey{
+		MerchantId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantDataKeyTestSuite) Test_MerchantDataKey_MapObjectToMgo_Error_CreatedAt() {
+	original := &pkg.MerchantDataKey{
+		MerchantId: primitive.NewObjectID().Hex(),
+		CreatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantDataKeyTestSuite) Test_MerchantDataKey_MapMgoToObject_Ok() {
+	original := &MgoMerchantDataKey{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *MerchantDataKeyTestSuite) Test_MerchantDataKey_MapMgoToObject_Error_CreatedAt() {
+	original := &MgoMerchantDataKey{
+		CreatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,262 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type merchantRiskReviewMapper struct{}
+
+func NewMerchantRiskReviewMapper() Mapper {
+	return &merchantRiskReviewMapper{}
+}
+
+type MgoMerchantRiskReview struct {
+	Id             primitive.ObjectID            `bson:"_id" faker:"objectId"`
+	MerchantId     primitive.ObjectID            `bson:"merchant_id" faker:"objectId"`
+	PeriodFrom     time.Time                     `bson:"period_from"`
+	PeriodTo       time.Time                     `bson:"period_to"`
+	Metrics        *MgoMerchantRiskMetrics       `bson:"metrics"`
+	Alerts         []*pkg.MerchantRiskAlert      `bson:"alerts"`
+	Actions        []string                      `bson:"actions"`
+	OperationsType string                        `bson:"operations_type"`
+	Status         string                        `bson:"status"`
+	ResolvedBy     string                        `bson:"resolved_by"`
+	ResolvedAt     *time.Time                    `bson:"resolved_at"`
+	History        []*MgoMerchantRiskReviewEvent `bson:"history"`
+	CreatedAt      time.Time                     `bson:"created_at"`
+	UpdatedAt      time.Time                     `bson:"updated_at"`
+}
+
+type MgoMerchantRiskMetrics struct {
+	SalesCount              int64   `bson:"sales_count"`
+	RefundCount             int64   `bson:"refund_count"`
+	ChargebackCount         int64   `bson:"chargeback_count"`
+	AttemptCount            int64   `bson:"attempt_count"`
+	DeclineCount            int64   `bson:"decline_count"`
+	SalesAmount             float64 `bson:"sales_amount"`
+	DailySalesAmount        float64 `bson:"daily_sales_amount"`
+	AverageDailySalesAmount float64 `bson:"average_daily_sales_amount"`
+	Currency                string  `bson:"currency"`
+	ChargebackRatio         float64 `bson:"chargeback_ratio"`
+	RefundRatio             float64 `bson:"refund_ratio"`
+	DeclineRate             float64 `bson:"decline_rate"`
+	SalesSpike              float64 `bson:"sales_spike"`
+}
+
+type MgoMerchantRiskReviewEvent struct {
+	Status    string    `bson:"status"`
+	Actions   []string  `bson:"actions"`
+	UserId    string    `bson:"user_id"`
+	Comment   string    `bson:"comment"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (m *merchantRiskReviewMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.MerchantRiskReview)
+
+	out := &MgoMerchantRiskReview{
+		Alerts:         in.Alerts,
+		Actions:        in.Actions,
+		OperationsType: in.OperationsType,
+		Status:         in.Status,
+		ResolvedBy:     in.ResolvedBy,
+		History:        []*MgoMerchantRiskReviewEvent{},
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	if in.Metrics != nil {
+		out.Metrics = &MgoMerchantRiskMetrics{
+			SalesCount:              in.Metrics.SalesCount,
+			RefundCount:             in.Metrics.RefundCount,
+			ChargebackCount:         in.Metrics.ChargebackCount,
+			AttemptCount:            in.Metrics.AttemptCount,
+			DeclineCount:            in.Metrics.DeclineCount,
+			SalesAmount:             in.Metrics.SalesAmount,
+			DailySalesAmount:        in.Metrics.DailySalesAmount,
+			AverageDailySalesAmount: in.Metrics.AverageDailySalesAmount,
+			Currency:                in.Metrics.Currency,
+			ChargebackRatio:         in.Metrics.ChargebackRatio,
+			RefundRatio:             in.Metrics.RefundRatio,
+			DeclineRate:             in.Metrics.DeclineRate,
+			SalesSpike:              in.Metrics.SalesSpike,
+		}
+	}
+
+	for _, v := range in.History {
+		event := &MgoMerchantRiskReviewEvent{
+			Status:  v.Status,
+			Actions: v.Actions,
+			UserId:  v.UserId,
+			Comment: v.Comment,
+		}
+
+		if v.CreatedAt != nil {
+			t, err := ptypes.Timestamp(v.CreatedAt)
+
+			if err != nil {
+				return nil, err
+			}
+
+			event.CreatedAt = t
+		} else {
+			event.CreatedAt = time.Now()
+		}
+
+		out.History = append(out.History, event)
+	}
+
+	if in.PeriodFrom != nil {
+		t, err := ptypes.Timestamp(in.PeriodFrom)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.PeriodFrom = t
+	}
+
+	if in.PeriodTo != nil {
+		t, err := ptypes.Timestamp(in.PeriodTo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.PeriodTo = t
+	}
+
+	if in.ResolvedAt != nil {
+		t, err := ptypes.Timestamp(in.ResolvedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ResolvedAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *merchantRiskReviewMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoMerchantRiskReview)
+
+	out := &pkg.MerchantRiskReview{
+		Id:             in.Id.Hex(),
+		MerchantId:     in.MerchantId.Hex(),
+		Alerts:         in.Alerts,
+		Actions:        in.Actions,
+		OperationsType: in.OperationsType,
+		Status:         in.Status,
+		ResolvedBy:     in.ResolvedBy,
+		History:        []*pkg.MerchantRiskReviewEvent{},
+	}
+
+	if in.Metrics != nil {
+		out.Metrics = &pkg.MerchantRiskMetrics{
+			SalesCount:              in.Metrics.SalesCount,
+			RefundCount:             in.Metrics.RefundCount,
+			ChargebackCount:         in.Metrics.ChargebackCount,
+			AttemptCount:            in.Metrics.AttemptCount,
+			DeclineCount:            in.Metrics.DeclineCount,
+			SalesAmount:             in.Metrics.SalesAmount,
+			DailySalesAmount:        in.Metrics.DailySalesAmount,
+			AverageDailySalesAmount: in.Metrics.AverageDailySalesAmount,
+			Currency:                in.Metrics.Currency,
+			ChargebackRatio:         in.Metrics.ChargebackRatio,
+			RefundRatio:             in.Metrics.RefundRatio,
+			DeclineRate:             in.Metrics.DeclineRate,
+			SalesSpike:              in.Metrics.SalesSpike,
+		}
+	}
+
+	for _, v := range in.History {
+		event := &pkg.MerchantRiskReviewEvent{
+			Status:  v.Status,
+			Actions: v.Actions,
+			UserId:  v.UserId,
+			Comment: v.Comment,
+		}
+
+		event.CreatedAt, err = ptypes.TimestampProto(v.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		out.History = append(out.History, event)
+	}
+
+	out.PeriodFrom, err = ptypes.TimestampProto(in.PeriodFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	out.PeriodTo, err = ptypes.TimestampProto(in.PeriodTo)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ResolvedAt != nil {
+		out.ResolvedAt, err = ptypes.TimestampProto(*in.ResolvedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
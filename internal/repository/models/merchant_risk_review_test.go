@@ -0,0 +1,194 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type MerchantRiskReviewTestSuite struct {
+	suite.Suite
+	mapper merchantRiskReviewMapper
+}
+
+func TestMerchantRiskReviewTestSuite(t *testing.T) {
+	suite.Run(t, new(MerchantRiskReviewTestSuite))
+}
+
+func (suite *MerchantRiskReviewTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *MerchantRiskReviewTestSuite) getObject() *pkg.MerchantRiskReview {
+	return &pkg.MerchantRiskReview{
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+}
+
+func (suite *MerchantRiskReviewTestSuite) Test_MerchantRiskReview_NewMerchantRiskReviewMapper() {
+	mapper := NewMerchantRiskReviewMapper()
+	assert.IsType(suite.T(), &merchantRiskReviewMapper{}, mapper)
+}
+
+func (suite *MerchantRiskReviewTestSuite) Test_MerchantRiskReview_MapObjectToMgo_Ok() {
+	original := &pkg.MerchantRiskReview{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		PeriodFrom: ptypes.TimestampNow(),
+		PeriodTo:   ptypes.TimestampNow(),
+		Metrics: &pkg.MerchantRiskMetrics{
+			SalesCount:              200,
+			RefundCount:             10,
+			ChargebackCount:         4,
+			AttemptCount:            250,
+			DeclineCount:            50,
+			SalesAmount:             2000,
+			DailySalesAmount:        300,
+			AverageDailySalesAmount: 58.62,
+			Currency:                "USD",
+			ChargebackRatio:         0.02,
+			RefundRatio:             0.05,
+			DeclineRate:             0.2,
+			SalesSpike:              5.12,
+		},
+		Alerts: []*pkg.MerchantRiskAlert{
+			{Metric: pkg.MerchantRiskMetricChargebackRatio, Value: 0.02, Threshold: 0.009},
+		},
+		Actions:        []string{pkg.MerchantRiskActionManualPayouts, pkg.MerchantRiskActionSuspendProcessing},
+		OperationsType: pkg.MerchantOperationTypeLowRisk,
+		Status:         pkg.MerchantRiskReviewStatusResolved,
+		ResolvedBy:     primitive.NewObjectID().Hex(),
+		ResolvedAt:     ptypes.TimestampNow(),
+		History: []*pkg.MerchantRiskReviewEvent{
+			{
+				Status:    pkg.MerchantRiskReviewStatusSuspended,
+				Actions:   []string{pkg.MerchantRiskActionManualPayouts, pkg.MerchantRiskActionSuspendProcessing},
+				CreatedAt: ptypes.TimestampNow(),
+			},
+			{
+				Status:    pkg.MerchantRiskReviewStatusResolved,
+				UserId:    primitive.NewObjectID().Hex(),
+				Comment:   "fraud attack is stopped",
+				CreatedAt: ptypes.TimestampNow(),
+			},
+		},
+		CreatedAt: ptypes.TimestampNow(),
+		UpdatedAt: ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.MerchantRiskReview))
+}
+
+func (suite *MerchantRiskReviewTestSuite) Test_MerchantRiskReview_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := suite.getObject()
+	original.History = []*pkg.MerchantRiskReviewEvent{{Status: pkg.MerchantRiskReviewStatusOpen}}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoMerchantRiskReview).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoMerchantRiskReview).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoMerchantRiskReview).UpdatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoMerchantRiskReview).History[0].CreatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoMerchantRiskReview).ResolvedAt)
+	assert.Nil(suite.T(), mgo.(*MgoMerchantRiskReview).Metrics)
+}
+
+func (suite *MerchantRiskReviewTestSuite) Test_MerchantRiskReview_MapObjectToMgo_Error_Id() {
+	original := suite.getObject()
+	original.Id = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRiskReviewTestSuite) Test_MerchantRiskReview_MapObjectToMgo_Error_MerchantId() {
+	original := suite.getObject()
+	original.MerchantId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRiskReviewTestSuite) Test_MerchantRiskReview_MapObjectToMgo_Error_Dates() {
+	original := suite.getObject()
+	original.PeriodFrom = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.PeriodTo = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.ResolvedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.History = []*pkg.MerchantRiskReviewEvent{{CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}}}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRiskReviewTestSuite) Test_MerchantRiskReview_MapMgoToObject_Ok() {
+	original := &MgoMerchantRiskReview{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *MerchantRiskReviewTestSuite) Test_MerchantRiskReview_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoMerchantRiskReview{PeriodFrom: invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoMerchantRiskReview{PeriodTo: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoMerchantRiskReview{ResolvedAt: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoMerchantRiskReview{History: []*MgoMerchantRiskReviewEvent{{CreatedAt: invalid}}}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoMerchantRiskReview{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoMerchantRiskReview{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
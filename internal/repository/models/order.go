@@ -3,6 +3,8 @@ package models
 import (
 	"errors"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"github.com/paysuper/paysuper-proto/go/recurringpb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -35,6 +37,8 @@ type MgoOrder struct {
 	RefundedAt                  time.Time                                `bson:"refunded_at"`
 	ReceiptEmail                string                                   `bson:"receipt_email"`
 	ReceiptPhone                string                                   `bson:"receipt_phone"`
+	UserEmailIndex              string                                   `bson:"user_email_index"`
+	UserPhoneIndex              string                                   `bson:"user_phone_index"`
 	ReceiptNumber               string                                   `bson:"receipt_number"`
 	ReceiptUrl                  string                                   `bson:"receipt_url"`
 	AgreementVersion            string                                   `bson:"agreement_version"`
@@ -121,19 +125,14 @@ func (o *orderMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
 		Canceled:             m.PrivateStatus == recurringpb.OrderStatusPaymentSystemCanceled,
 		Cancellation:         m.Cancellation,
 		Refunded:             m.PrivateStatus == recurringpb.OrderStatusRefund,
-		ReceiptEmail:         m.GetReceiptUserEmail(),
-		ReceiptPhone:         m.GetReceiptUserPhone(),
 		ReceiptNumber:        m.ReceiptNumber,
 		ReceiptUrl:           m.ReceiptUrl,
 		AgreementVersion:     m.AgreementVersion,
 		AgreementAccepted:    m.AgreementAccepted,
 		NotifySale:           m.NotifySale,
-		NotifySaleEmail:      m.NotifySaleEmail,
 		Issuer:               m.Issuer,
 		TotalPaymentAmount:   m.TotalPaymentAmount,
 		Currency:             m.Currency,
-		User:                 m.User,
-		BillingAddress:       m.BillingAddress,
 		Tax:                  m.Tax,
 		Items:                []*MgoOrderItem{},
 		Metadata:             m.Metadata,
@@ -164,8 +163,6 @@ func (o *orderMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
 		IsJsonRequest:               m.IsJsonRequest,
 		OrderAmount:                 m.OrderAmount,
 		PaymentMethodPayerAccount:   m.PaymentMethodPayerAccount,
-		PaymentMethodTxnParams:      m.PaymentMethodTxnParams,
-		PaymentRequisites:           m.PaymentRequisites,
 		UserAddressDataRequired:     m.UserAddressDataRequired,
 		Products:                    m.Products,
 		IsNotificationsSent:         m.IsNotificationsSent,
@@ -194,6 +191,39 @@ func (o *orderMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
 		FormMode:                    m.FormMode,
 	}
 
+	if st.ReceiptEmail, err = encryptField(pkg.PersonalDataFieldEmail, m.GetReceiptUserEmail()); err != nil {
+		return nil, err
+	}
+
+	if st.ReceiptPhone, err = encryptField(pkg.PersonalDataFieldPhone, m.GetReceiptUserPhone()); err != nil {
+		return nil, err
+	}
+
+	if st.NotifySaleEmail, err = encryptField(pkg.PersonalDataFieldEmail, m.NotifySaleEmail); err != nil {
+		return nil, err
+	}
+
+	if st.User, err = encryptOrderUser(m.User); err != nil {
+		return nil, err
+	}
+
+	if m.User != nil {
+		st.UserEmailIndex = GetFieldIndex(pkg.PersonalDataFieldEmail, m.User.Email)
+		st.UserPhoneIndex = GetFieldIndex(pkg.PersonalDataFieldPhone, m.User.Phone)
+	}
+
+	if st.BillingAddress, err = encryptAddress(m.BillingAddress); err != nil {
+		return nil, err
+	}
+
+	if st.PaymentMethodTxnParams, err = encryptPaymentParams(m.PaymentMethodTxnParams); err != nil {
+		return nil, err
+	}
+
+	if st.PaymentRequisites, err = encryptPaymentParams(m.PaymentRequisites); err != nil {
+		return nil, err
+	}
+
 	if m.Refund != nil {
 		st.Refund = &MgoOrderNotificationRefund{
 			Amount:        m.Refund.Amount,
@@ -206,6 +236,7 @@ func (o *orderMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
 	}
 
 	for _, v := range m.Items {
+		// activation codes of keys are stored masked, plaintext is sent to the customer and the merchant only
 		item := &MgoOrderItem{
 			Object:      v.Object,
 			Sku:         v.Sku,
@@ -216,7 +247,7 @@ func (o *orderMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
 			Images:      v.Images,
 			Url:         v.Url,
 			Metadata:    v.Metadata,
-			Code:        v.Code,
+			Code:        helper.MaskKeyCode(v.Code),
 			PlatformId:  v.PlatformId,
 		}
 
@@ -543,6 +574,34 @@ func (o *orderMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
 		return nil, err
 	}
 
+	if m.ReceiptEmail, err = decryptField(m.ReceiptEmail); err != nil {
+		return nil, err
+	}
+
+	if m.ReceiptPhone, err = decryptField(m.ReceiptPhone); err != nil {
+		return nil, err
+	}
+
+	if m.NotifySaleEmail, err = decryptField(m.NotifySaleEmail); err != nil {
+		return nil, err
+	}
+
+	if m.User, err = decryptOrderUser(m.User); err != nil {
+		return nil, err
+	}
+
+	if m.BillingAddress, err = decryptAddress(m.BillingAddress); err != nil {
+		return nil, err
+	}
+
+	if m.PaymentMethodTxnParams, err = decryptPaymentParams(m.PaymentMethodTxnParams); err != nil {
+		return nil, err
+	}
+
+	if m.PaymentRequisites, err = decryptPaymentParams(m.PaymentRequisites); err != nil {
+		return nil, err
+	}
+
 	return m, nil
 }
 
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type orderReviewMapper struct{}
+
+func NewOrderReviewMapper() Mapper {
+	return &orderReviewMapper{}
+}
+
+type MgoOrderReview struct {
+	Id               primitive.ObjectID `bson:"_id" faker:"objectId"`
+	OrderId          primitive.ObjectID `bson:"order_id" faker:"objectId"`
+	OrderUuid        string             `bson:"order_uuid"`
+	MerchantId       primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	ProjectId        primitive.ObjectID `bson:"project_id" faker:"objectId"`
+	RiskAssessmentId string             `bson:"risk_assessment_id"`
+	Score            int32              `bson:"score"`
+	Amount           float64            `bson:"amount"`
+	Currency         string             `bson:"currency"`
+	Status           string             `bson:"status"`
+	Captured         bool               `bson:"captured"`
+	ClaimedBy        string             `bson:"claimed_by"`
+	ClaimExpiresAt   *time.Time         `bson:"claim_expires_at"`
+	ResolvedBy       string             `bson:"resolved_by"`
+	ResolvedAt       *time.Time         `bson:"resolved_at"`
+	Comment          string             `bson:"comment"`
+	SlaDueAt         *time.Time         `bson:"sla_due_at"`
+	CreatedAt        time.Time          `bson:"created_at"`
+	UpdatedAt        time.Time          `bson:"updated_at"`
+}
+
+func (m *orderReviewMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.OrderReview)
+
+	out := &MgoOrderReview{
+		OrderUuid:        in.OrderUuid,
+		RiskAssessmentId: in.RiskAssessmentId,
+		Score:            in.Score,
+		Amount:           in.Amount,
+		Currency:         in.Currency,
+		Status:           in.Status,
+		Captured:         in.Captured,
+		ClaimedBy:        in.ClaimedBy,
+		ResolvedBy:       in.ResolvedBy,
+		Comment:          in.Comment,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	orderOid, err := primitive.ObjectIDFromHex(in.OrderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.OrderId = orderOid
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	projectOid, err := primitive.ObjectIDFromHex(in.ProjectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.ProjectId = projectOid
+
+	if in.ClaimExpiresAt != nil {
+		t, err := ptypes.Timestamp(in.ClaimExpiresAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ClaimExpiresAt = &t
+	}
+
+	if in.ResolvedAt != nil {
+		t, err := ptypes.Timestamp(in.ResolvedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ResolvedAt = &t
+	}
+
+	if in.SlaDueAt != nil {
+		t, err := ptypes.Timestamp(in.SlaDueAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.SlaDueAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *orderReviewMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoOrderReview)
+
+	out := &pkg.OrderReview{
+		Id:               in.Id.Hex(),
+		OrderId:          in.OrderId.Hex(),
+		OrderUuid:        in.OrderUuid,
+		MerchantId:       in.MerchantId.Hex(),
+		ProjectId:        in.ProjectId.Hex(),
+		RiskAssessmentId: in.RiskAssessmentId,
+		Score:            in.Score,
+		Amount:           in.Amount,
+		Currency:         in.Currency,
+		Status:           in.Status,
+		Captured:         in.Captured,
+		ClaimedBy:        in.ClaimedBy,
+		ResolvedBy:       in.ResolvedBy,
+		Comment:          in.Comment,
+	}
+
+	if in.ClaimExpiresAt != nil {
+		out.ClaimExpiresAt, err = ptypes.TimestampProto(*in.ClaimExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if in.ResolvedAt != nil {
+		out.ResolvedAt, err = ptypes.TimestampProto(*in.ResolvedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if in.SlaDueAt != nil {
+		out.SlaDueAt, err = ptypes.TimestampProto(*in.SlaDueAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
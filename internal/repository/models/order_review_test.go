@@ -0,0 +1,175 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type OrderReviewTestSuite struct {
+	suite.Suite
+	mapper orderReviewMapper
+}
+
+func TestOrderReviewTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderReviewTestSuite))
+}
+
+func (suite *OrderReviewTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *OrderReviewTestSuite) getObject() *pkg.OrderReview {
+	return &pkg.OrderReview{
+		OrderId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+	}
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_NewOrderReviewMapper() {
+	mapper := NewOrderReviewMapper()
+	assert.IsType(suite.T(), &orderReviewMapper{}, mapper)
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapObjectToMgo_Ok() {
+	original := &pkg.OrderReview{
+		Id:               primitive.NewObjectID().Hex(),
+		OrderId:          primitive.NewObjectID().Hex(),
+		OrderUuid:        "uuid",
+		MerchantId:       primitive.NewObjectID().Hex(),
+		ProjectId:        primitive.NewObjectID().Hex(),
+		RiskAssessmentId: primitive.NewObjectID().Hex(),
+		Score:            80,
+		Amount:           100,
+		Currency:         "USD",
+		Status:           pkg.OrderReviewStatusApproved,
+		Captured:         true,
+		ClaimedBy:        primitive.NewObjectID().Hex(),
+		ClaimExpiresAt:   ptypes.TimestampNow(),
+		ResolvedBy:       primitive.NewObjectID().Hex(),
+		ResolvedAt:       ptypes.TimestampNow(),
+		Comment:          "known customer",
+		SlaDueAt:         ptypes.TimestampNow(),
+		CreatedAt:        ptypes.TimestampNow(),
+		UpdatedAt:        ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.OrderReview))
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(suite.getObject())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoOrderReview).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoOrderReview).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoOrderReview).UpdatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoOrderReview).ClaimExpiresAt)
+	assert.Nil(suite.T(), mgo.(*MgoOrderReview).ResolvedAt)
+	assert.Nil(suite.T(), mgo.(*MgoOrderReview).SlaDueAt)
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapObjectToMgo_Error_Id() {
+	original := suite.getObject()
+	original.Id = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapObjectToMgo_Error_OrderId() {
+	original := suite.getObject()
+	original.OrderId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapObjectToMgo_Error_MerchantId() {
+	original := suite.getObject()
+	original.MerchantId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapObjectToMgo_Error_ProjectId() {
+	original := suite.getObject()
+	original.ProjectId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapObjectToMgo_Error_Dates() {
+	original := suite.getObject()
+	original.ClaimExpiresAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.ResolvedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.SlaDueAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapMgoToObject_Ok() {
+	original := &MgoOrderReview{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *OrderReviewTestSuite) Test_OrderReview_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoOrderReview{ClaimExpiresAt: &invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoOrderReview{ResolvedAt: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoOrderReview{SlaDueAt: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoOrderReview{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoOrderReview{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
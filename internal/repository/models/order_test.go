@@ -5,6 +5,7 @@ import (
 	"github.com/bxcodec/faker"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -46,6 +47,10 @@ func (suite *OrderTestSuite) Test_MapToMgo_Ok() {
 	original.ReceiptEmail = original.User.Email
 	original.ReceiptPhone = original.User.Phone
 
+	for _, item := range original.Items {
+		item.Code = helper.MaskKeyCode(item.Code)
+	}
+
 	mgo, err := suite.mapper.MapObjectToMgo(original)
 	assert.NoError(suite.T(), err)
 	assert.NotEmpty(suite.T(), mgo)
@@ -63,6 +68,18 @@ func (suite *OrderTestSuite) Test_MapToMgo_Ok() {
 	assert.JSONEq(suite.T(), string(buf1.Bytes()), string(buf2.Bytes()))
 }
 
+func (suite *OrderTestSuite) Test_MapToMgo_Ok_ItemCodeMasked() {
+	original := &billingpb.Order{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	original.Items[0].Code = "AAAA-BBBB-CCCC"
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "****-****-CCCC", mgo.(*MgoOrder).Items[0].Code)
+}
+
 func (suite *OrderTestSuite) Test_Error_Items_CreatedAt() {
 	original := &billingpb.Order{
 	}
@@ -216,5 +216,13 @@ func (o *orderViewPrivateMapper) MapMgoToObject(obj interface{}) (interface{}, e
 		return nil, err
 	}
 
+	if m.User, err = decryptOrderUser(m.User); err != nil {
+		return nil, err
+	}
+
+	if m.BillingAddress, err = decryptAddress(m.BillingAddress); err != nil {
+		return nil, err
+	}
+
 	return m, nil
 }
@@ -149,5 +149,13 @@ func (o *orderViewPublicMapper) MapMgoToObject(obj interface{}) (interface{}, er
 		return nil, err
 	}
 
+	if m.User, err = decryptOrderUser(m.User); err != nil {
+		return nil, err
+	}
+
+	if m.BillingAddress, err = decryptAddress(m.BillingAddress); err != nil {
+		return nil, err
+	}
+
 	return m, nil
 }
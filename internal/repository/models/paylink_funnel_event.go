@@ -0,0 +1,88 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type paylinkFunnelEventMapper struct{}
+
+func NewPaylinkFunnelEventMapper() Mapper {
+	return &paylinkFunnelEventMapper{}
+}
+
+type MgoPaylinkFunnelEvent struct {
+	Id        primitive.ObjectID `bson:"_id" faker:"objectId"`
+	PaylinkId primitive.ObjectID `bson:"paylink_id" faker:"objectId"`
+	VariantId string             `bson:"variant_id"`
+	Step      string             `bson:"step"`
+	VisitorId string             `bson:"visitor_id"`
+	OrderId   string             `bson:"order_id"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (m *paylinkFunnelEventMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.PaylinkFunnelEvent)
+
+	out := &MgoPaylinkFunnelEvent{
+		VariantId: in.VariantId,
+		Step:      in.Step,
+		VisitorId: in.VisitorId,
+		OrderId:   in.OrderId,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	paylinkOid, err := primitive.ObjectIDFromHex(in.PaylinkId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.PaylinkId = paylinkOid
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *paylinkFunnelEventMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPaylinkFunnelEvent)
+
+	out := &pkg.PaylinkFunnelEvent{
+		Id:        in.Id.Hex(),
+		PaylinkId: in.PaylinkId.Hex(),
+		VariantId: in.VariantId,
+		Step:      in.Step,
+		VisitorId: in.VisitorId,
+		OrderId:   in.OrderId,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
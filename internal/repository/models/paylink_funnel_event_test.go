@@ -0,0 +1,107 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PaylinkFunnelEventTestSuite struct {
+	suite.Suite
+	mapper paylinkFunnelEventMapper
+}
+
+func TestPaylinkFunnelEventTestSuite(t *testing.T) {
+	suite.Run(t, new(PaylinkFunnelEventTestSuite))
+}
+
+func (suite *PaylinkFunnelEventTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PaylinkFunnelEventTestSuite) Test_PaylinkFunnelEvent_NewPaylinkFunnelEventMapper() {
+	mapper := NewPaylinkFunnelEventMapper()
+	assert.IsType(suite.T(), &paylinkFunnelEventMapper{}, mapper)
+}
+
+func (suite *PaylinkFunnelEventTestSuite) Test_PaylinkFunnelEvent_MapObjectToMgo_Ok() {
+	original := &pkg.PaylinkFunnelEvent{
+		Id:        primitive.NewObjectID().Hex(),
+		PaylinkId: primitive.NewObjectID().Hex(),
+		VariantId: primitive.NewObjectID().Hex(),
+		Step:      pkg.PaylinkFunnelStepFormOpen,
+		VisitorId: "visitor",
+		OrderId:   primitive.NewObjectID().Hex(),
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.PaylinkFunnelEvent))
+}
+
+func (suite *PaylinkFunnelEventTestSuite) Test_PaylinkFunnelEvent_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.PaylinkFunnelEvent{
+		PaylinkId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoPaylinkFunnelEvent).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPaylinkFunnelEvent).CreatedAt.IsZero())
+}
+
+func (suite *PaylinkFunnelEventTestSuite) Test_PaylinkFunnelEvent_MapObjectToMgo_Error_Id() {
+	original := &pkg.PaylinkFunnelEvent{
+		Id:        "test",
+		PaylinkId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PaylinkFunnelEventTestSuite) Test_PaylinkFunnelEvent_MapObjectToMgo_Error_PaylinkId() {
+	original := &pkg.PaylinkFunnelEvent{
+		PaylinkId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PaylinkFunnelEventTestSuite) Test_PaylinkFunnelEvent_MapObjectToMgo_Error_Dates() {
+	original := &pkg.PaylinkFunnelEvent{
+		PaylinkId: primitive.NewObjectID().Hex(),
+		CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PaylinkFunnelEventTestSuite) Test_PaylinkFunnelEvent_MapMgoToObject_Ok() {
+	original := &MgoPaylinkFunnelEvent{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PaylinkFunnelEventTestSuite) Test_PaylinkFunnelEvent_MapMgoToObject_Error_Dates() {
+	original := &MgoPaylinkFunnelEvent{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type paylinkVariantMapper struct{}
+
+func NewPaylinkVariantMapper() Mapper {
+	return &paylinkVariantMapper{}
+}
+
+type MgoPaylinkVariant struct {
+	Id         primitive.ObjectID `bson:"_id" faker:"objectId"`
+	PaylinkId  primitive.ObjectID `bson:"paylink_id" faker:"objectId"`
+	MerchantId primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	Name       string             `bson:"name"`
+	Weight     int32              `bson:"weight"`
+	Products   []string           `bson:"products"`
+	PromoCode  string             `bson:"promo_code"`
+	Deleted    bool               `bson:"deleted"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+}
+
+func (m *paylinkVariantMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.PaylinkVariant)
+
+	out := &MgoPaylinkVariant{
+		Name:      in.Name,
+		Weight:    in.Weight,
+		Products:  in.Products,
+		PromoCode: in.PromoCode,
+		Deleted:   in.Deleted,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	paylinkOid, err := primitive.ObjectIDFromHex(in.PaylinkId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.PaylinkId = paylinkOid
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *paylinkVariantMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPaylinkVariant)
+
+	out := &pkg.PaylinkVariant{
+		Id:         in.Id.Hex(),
+		PaylinkId:  in.PaylinkId.Hex(),
+		MerchantId: in.MerchantId.Hex(),
+		Name:       in.Name,
+		Weight:     in.Weight,
+		Products:   in.Products,
+		PromoCode:  in.PromoCode,
+		Deleted:    in.Deleted,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
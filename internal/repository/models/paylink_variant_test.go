@@ -0,0 +1,132 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PaylinkVariantTestSuite struct {
+	suite.Suite
+	mapper paylinkVariantMapper
+}
+
+func TestPaylinkVariantTestSuite(t *testing.T) {
+	suite.Run(t, new(PaylinkVariantTestSuite))
+}
+
+func (suite *PaylinkVariantTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_NewPaylinkVariantMapper() {
+	mapper := NewPaylinkVariantMapper()
+	assert.IsType(suite.T(), &paylinkVariantMapper{}, mapper)
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_MapObjectToMgo_Ok() {
+	original := &pkg.PaylinkVariant{
+		Id:         primitive.NewObjectID().Hex(),
+		PaylinkId:  primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		Name:       "variant",
+		Weight:     50,
+		Products:   []string{primitive.NewObjectID().Hex()},
+		PromoCode:  "SALE",
+		CreatedAt:  ptypes.TimestampNow(),
+		UpdatedAt:  ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.PaylinkVariant))
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.PaylinkVariant{
+		PaylinkId:  primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoPaylinkVariant).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPaylinkVariant).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPaylinkVariant).UpdatedAt.IsZero())
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_MapObjectToMgo_Error_Id() {
+	original := &pkg.PaylinkVariant{
+		Id:         "test",
+		PaylinkId:  primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_MapObjectToMgo_Error_PaylinkId() {
+	original := &pkg.PaylinkVariant{
+		PaylinkId:  "test",
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.PaylinkVariant{
+		PaylinkId:  primitive.NewObjectID().Hex(),
+		MerchantId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_MapObjectToMgo_Error_Dates() {
+	original := &pkg.PaylinkVariant{
+		PaylinkId:  primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		CreatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.CreatedAt = nil
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_MapMgoToObject_Ok() {
+	original := &MgoPaylinkVariant{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PaylinkVariantTestSuite) Test_PaylinkVariant_MapMgoToObject_Error_Dates() {
+	original := &MgoPaylinkVariant{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoPaylinkVariant{UpdatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
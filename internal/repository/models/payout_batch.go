@@ -0,0 +1,127 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type payoutBatchMapper struct{}
+
+func NewPayoutBatchMapper() Mapper {
+	return &payoutBatchMapper{}
+}
+
+type MgoPayoutBatch struct {
+	Id                 primitive.ObjectID `bson:"_id" faker:"objectId"`
+	OperatingCompanyId string             `bson:"operating_company_id"`
+	Currency           string             `bson:"currency"`
+	Format             string             `bson:"format"`
+	Status             string             `bson:"status"`
+	PayoutDocumentIds  []string           `bson:"payout_document_ids"`
+	TotalAmount        float64            `bson:"total_amount"`
+	DebtorName         string             `bson:"debtor_name"`
+	DebtorAccount      string             `bson:"debtor_account"`
+	DebtorSwift        string             `bson:"debtor_swift"`
+	ExecutionDate      time.Time          `bson:"execution_date"`
+	CreatedAt          time.Time          `bson:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at"`
+}
+
+func (m *payoutBatchMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.PayoutBatch)
+
+	out := &MgoPayoutBatch{
+		OperatingCompanyId: in.OperatingCompanyId,
+		Currency:           in.Currency,
+		Format:             in.Format,
+		Status:             in.Status,
+		PayoutDocumentIds:  in.PayoutDocumentIds,
+		TotalAmount:        in.TotalAmount,
+		DebtorName:         in.DebtorName,
+		DebtorAccount:      in.DebtorAccount,
+		DebtorSwift:        in.DebtorSwift,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	if in.ExecutionDate != nil {
+		t, err := ptypes.Timestamp(in.ExecutionDate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ExecutionDate = t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *payoutBatchMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPayoutBatch)
+
+	out := &pkg.PayoutBatch{
+		Id:                 in.Id.Hex(),
+		OperatingCompanyId: in.OperatingCompanyId,
+		Currency:           in.Currency,
+		Format:             in.Format,
+		Status:             in.Status,
+		PayoutDocumentIds:  in.PayoutDocumentIds,
+		TotalAmount:        in.TotalAmount,
+		DebtorName:         in.DebtorName,
+		DebtorAccount:      in.DebtorAccount,
+		DebtorSwift:        in.DebtorSwift,
+	}
+
+	out.ExecutionDate, err = ptypes.TimestampProto(in.ExecutionDate)
+	if err != nil {
+		return nil, err
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PayoutBatchTestSuite struct {
+	suite.Suite
+	mapper payoutBatchMapper
+}
+
+func TestPayoutBatchTestSuite(t *testing.T) {
+	suite.Run(t, new(PayoutBatchTestSuite))
+}
+
+func (suite *PayoutBatchTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_NewPayoutBatchMapper() {
+	mapper := NewPayoutBatchMapper()
+	assert.IsType(suite.T(), &payoutBatchMapper{}, mapper)
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapObjectToMgo_Ok() {
+	original := &pkg.PayoutBatch{
+		Id:                 primitive.NewObjectID().Hex(),
+		OperatingCompanyId: primitive.NewObjectID().Hex(),
+		Currency:           "EUR",
+		Format:             pkg.PayoutBatchFormatSepa,
+		Status:             pkg.PayoutBatchStatusCreated,
+		PayoutDocumentIds:  []string{primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()},
+		TotalAmount:        1234.56,
+		DebtorName:         "Operating company",
+		DebtorAccount:      "DE89370400440532013000",
+		DebtorSwift:        "COBADEFFXXX",
+		ExecutionDate:      ptypes.TimestampNow(),
+		CreatedAt:          ptypes.TimestampNow(),
+		UpdatedAt:          ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), obj)
+
+	b1, err := json.Marshal(original)
+	assert.NoError(suite.T(), err)
+	b2, err := json.Marshal(obj.(*pkg.PayoutBatch))
+	assert.NoError(suite.T(), err)
+	assert.JSONEq(suite.T(), string(b1), string(b2))
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(&pkg.PayoutBatch{})
+	assert.NoError(suite.T(), err)
+
+	out := mgo.(*MgoPayoutBatch)
+	assert.False(suite.T(), out.Id.IsZero())
+	assert.True(suite.T(), out.ExecutionDate.IsZero())
+	assert.False(suite.T(), out.CreatedAt.IsZero())
+	assert.False(suite.T(), out.UpdatedAt.IsZero())
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapObjectToMgo_Error_Id() {
+	_, err := suite.mapper.MapObjectToMgo(&pkg.PayoutBatch{Id: "test"})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapObjectToMgo_Error_ExecutionDate() {
+	original := &pkg.PayoutBatch{
+		ExecutionDate: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapObjectToMgo_Error_CreatedAt() {
+	original := &pkg.PayoutBatch{
+		CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapObjectToMgo_Error_UpdatedAt() {
+	original := &pkg.PayoutBatch{
+		UpdatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapMgoToObject_Ok() {
+	original := &MgoPayoutBatch{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapMgoToObject_Error_ExecutionDate() {
+	original := &MgoPayoutBatch{
+		ExecutionDate: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapMgoToObject_Error_CreatedAt() {
+	original := &MgoPayoutBatch{
+		CreatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PayoutBatchTestSuite) Test_PayoutBatch_MapMgoToObject_Error_UpdatedAt() {
+	original := &MgoPayoutBatch{
+		UpdatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
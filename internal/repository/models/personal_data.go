@@ -0,0 +1,258 @@
+package models
+
+import (
+	"errors"
+	"github.com/golang/protobuf/proto"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// FieldCipherInterface is abstraction layer for the field-level encryption of personal data in mappers.
+type FieldCipherInterface interface {
+	// Encrypt returns the ciphertext of the value.
+	Encrypt(value string) (string, error)
+
+	// Decrypt returns the plaintext of the value, values which weren't encrypted are returned as is.
+	Decrypt(value string) (string, error)
+
+	// Index returns the blind index of the value used to find encrypted values by equality.
+	Index(value string) string
+}
+
+var (
+	errorFieldCipherNotSet = errors.New("keys of personal data encryption are not set")
+
+	fieldCipher     FieldCipherInterface
+	encryptedFields = make(map[string]bool)
+
+	// payment parameters of orders with personal data and their groups of fields
+	encryptedPaymentParams = map[string]string{
+		billingpb.PaymentCreateFieldHolder: pkg.PersonalDataFieldCardHolder,
+		billingpb.PaymentCreateFieldEmail:  pkg.PersonalDataFieldEmail,
+	}
+)
+
+// SetFieldCipher sets the cipher and groups of personal data fields (PersonalDataField* constants) encrypted by
+// mappers of customers and orders. Fields are stored as plaintext if the cipher is nil.
+func SetFieldCipher(cipher FieldCipherInterface, fields []string) {
+	fieldCipher = cipher
+	encryptedFields = make(map[string]bool, len(fields))
+
+	for _, field := range fields {
+		encryptedFields[field] = true
+	}
+}
+
+// GetFieldIndex returns the blind index of the value of the personal data field,
+// it's empty if the field isn't encrypted.
+func GetFieldIndex(field, value string) string {
+	if fieldCipher == nil || !encryptedFields[field] || value == "" {
+		return ""
+	}
+
+	return fieldCipher.Index(value)
+}
+
+// encryptRequiredField encrypts the value regardless of configured groups of fields,
+// it fails when the cipher isn't set to keep the value out of the storage as plaintext.
+func encryptRequiredField(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+
+	if fieldCipher == nil {
+		return "", errorFieldCipherNotSet
+	}
+
+	return fieldCipher.Encrypt(value)
+}
+
+func encryptField(field, value string) (string, error) {
+	if fieldCipher == nil || !encryptedFields[field] || value == "" {
+		return value, nil
+	}
+
+	return fieldCipher.Encrypt(value)
+}
+
+// decryptField decrypts values of fields regardless of encrypted groups,
+// so values stay readable after the group was excluded from the configuration.
+func decryptField(value string) (string, error) {
+	if fieldCipher == nil || value == "" {
+		return value, nil
+	}
+
+	return fieldCipher.Decrypt(value)
+}
+
+func encryptFieldBytes(field string, value []byte) ([]byte, error) {
+	encrypted, err := encryptField(field, string(value))
+
+	if err != nil || len(value) <= 0 {
+		return value, err
+	}
+
+	return []byte(encrypted), nil
+}
+
+func decryptFieldBytes(value []byte) ([]byte, error) {
+	decrypted, err := decryptField(string(value))
+
+	if err != nil || len(value) <= 0 {
+		return value, err
+	}
+
+	return []byte(decrypted), nil
+}
+
+// encryptAddress returns the copy of the address with encrypted city and postal code,
+// the country and the state are kept as plaintext for reports
+func encryptAddress(address *billingpb.OrderBillingAddress) (*billingpb.OrderBillingAddress, error) {
+	if address == nil {
+		return nil, nil
+	}
+
+	var err error
+	out := proto.Clone(address).(*billingpb.OrderBillingAddress)
+
+	if out.City, err = encryptField(pkg.PersonalDataFieldAddress, address.City); err != nil {
+		return nil, err
+	}
+
+	if out.PostalCode, err = encryptField(pkg.PersonalDataFieldAddress, address.PostalCode); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func decryptAddress(address *billingpb.OrderBillingAddress) (*billingpb.OrderBillingAddress, error) {
+	if address == nil {
+		return nil, nil
+	}
+
+	var err error
+
+	if address.City, err = decryptField(address.City); err != nil {
+		return nil, err
+	}
+
+	if address.PostalCode, err = decryptField(address.PostalCode); err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+// encryptOrderUser returns the copy of the user of the order with encrypted personal data
+func encryptOrderUser(user *billingpb.OrderUser) (*billingpb.OrderUser, error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	var err error
+	out := proto.Clone(user).(*billingpb.OrderUser)
+
+	if out.Email, err = encryptField(pkg.PersonalDataFieldEmail, user.Email); err != nil {
+		return nil, err
+	}
+
+	if out.Phone, err = encryptField(pkg.PersonalDataFieldPhone, user.Phone); err != nil {
+		return nil, err
+	}
+
+	if out.Name, err = encryptField(pkg.PersonalDataFieldName, user.Name); err != nil {
+		return nil, err
+	}
+
+	if out.Ip, err = encryptField(pkg.PersonalDataFieldIp, user.Ip); err != nil {
+		return nil, err
+	}
+
+	if out.NotifyNewRegionEmail, err = encryptField(pkg.PersonalDataFieldEmail, user.NotifyNewRegionEmail); err != nil {
+		return nil, err
+	}
+
+	if out.Address, err = encryptAddress(user.Address); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func decryptOrderUser(user *billingpb.OrderUser) (*billingpb.OrderUser, error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	var err error
+
+	if user.Email, err = decryptField(user.Email); err != nil {
+		return nil, err
+	}
+
+	if user.Phone, err = decryptField(user.Phone); err != nil {
+		return nil, err
+	}
+
+	if user.Name, err = decryptField(user.Name); err != nil {
+		return nil, err
+	}
+
+	if user.Ip, err = decryptField(user.Ip); err != nil {
+		return nil, err
+	}
+
+	if user.NotifyNewRegionEmail, err = decryptField(user.NotifyNewRegionEmail); err != nil {
+		return nil, err
+	}
+
+	if user.Address, err = decryptAddress(user.Address); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// encryptPaymentParams returns the copy of payment parameters of the order with encrypted personal data
+func encryptPaymentParams(params map[string]string) (map[string]string, error) {
+	if params == nil {
+		return nil, nil
+	}
+
+	var err error
+	out := make(map[string]string, len(params))
+
+	for k, v := range params {
+		field, ok := encryptedPaymentParams[k]
+
+		if !ok {
+			out[k] = v
+			continue
+		}
+
+		if out[k], err = encryptField(field, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func decryptPaymentParams(params map[string]string) (map[string]string, error) {
+	var err error
+
+	for k := range encryptedPaymentParams {
+		v, ok := params[k]
+
+		if !ok {
+			continue
+		}
+
+		if params[k], err = decryptField(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return params, nil
+}
@@ -0,0 +1,219 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+const (
+	testFieldCiphertextPrefix = "encrypted:"
+)
+
+type testFieldCipher struct{}
+
+func (c *testFieldCipher) Encrypt(value string) (string, error) {
+	return testFieldCiphertextPrefix + value, nil
+}
+
+func (c *testFieldCipher) Decrypt(value string) (string, error) {
+	return strings.TrimPrefix(value, testFieldCiphertextPrefix), nil
+}
+
+func (c *testFieldCipher) Index(value string) string {
+	return "index:" + value
+}
+
+type testBrokenFieldCipher struct {
+	testFieldCipher
+}
+
+func (c *testBrokenFieldCipher) Decrypt(_ string) (string, error) {
+	return "", errors.New("decryption failed")
+}
+
+type PersonalDataTestSuite struct {
+	suite.Suite
+	customerMapper customerMapper
+	orderMapper    orderMapper
+}
+
+func TestPersonalDataTestSuite(t *testing.T) {
+	suite.Run(t, new(PersonalDataTestSuite))
+}
+
+func (suite *PersonalDataTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+	SetFieldCipher(&testFieldCipher{}, []string{
+		pkg.PersonalDataFieldEmail,
+		pkg.PersonalDataFieldPhone,
+		pkg.PersonalDataFieldName,
+		pkg.PersonalDataFieldIp,
+		pkg.PersonalDataFieldUserAgent,
+		pkg.PersonalDataFieldAddress,
+		pkg.PersonalDataFieldCardHolder,
+	})
+}
+
+func (suite *PersonalDataTestSuite) TearDownTest() {
+	SetFieldCipher(nil, nil)
+}
+
+func (suite *PersonalDataTestSuite) TestPersonalData_GetFieldIndex() {
+	assert.Equal(suite.T(), "index:test@unit.test", GetFieldIndex(pkg.PersonalDataFieldEmail, "test@unit.test"))
+	assert.Empty(suite.T(), GetFieldIndex(pkg.PersonalDataFieldEmail, ""))
+
+	SetFieldCipher(&testFieldCipher{}, []string{pkg.PersonalDataFieldPhone})
+	assert.Empty(suite.T(), GetFieldIndex(pkg.PersonalDataFieldEmail, "test@unit.test"))
+
+	SetFieldCipher(nil, []string{pkg.PersonalDataFieldEmail})
+	assert.Empty(suite.T(), GetFieldIndex(pkg.PersonalDataFieldEmail, "test@unit.test"))
+}
+
+func (suite *PersonalDataTestSuite) TestPersonalData_Customer_Ok() {
+	original := &billingpb.Customer{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	original.Identity[0].Type = pkg.UserIdentityTypeEmail
+	original.Identity[0].Value = original.Email
+
+	mgo, err := suite.customerMapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+
+	customer := mgo.(*MgoCustomer)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.Email, customer.Email)
+	assert.Equal(suite.T(), "index:"+original.Email, customer.EmailIndex)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.Phone, customer.Phone)
+	assert.Equal(suite.T(), "index:"+original.Phone, customer.PhoneIndex)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.Name, customer.Name)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.UserAgent, customer.UserAgent)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.Address.City, customer.Address.City)
+	assert.Equal(suite.T(), original.Address.Country, customer.Address.Country)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.Identity[0].Value, customer.Identity[0].Value)
+	assert.Equal(suite.T(), "index:"+original.Identity[0].Value, customer.Identity[0].ValueIndex)
+
+	obj, err := suite.customerMapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+
+	buf1 := &bytes.Buffer{}
+	buf2 := &bytes.Buffer{}
+	marshaler := &jsonpb.Marshaler{}
+
+	assert.NoError(suite.T(), marshaler.Marshal(buf1, original))
+	assert.NoError(suite.T(), marshaler.Marshal(buf2, obj.(*billingpb.Customer)))
+	assert.JSONEq(suite.T(), string(buf1.Bytes()), string(buf2.Bytes()))
+}
+
+func (suite *PersonalDataTestSuite) TestPersonalData_Customer_FieldNotEncrypted() {
+	SetFieldCipher(&testFieldCipher{}, []string{pkg.PersonalDataFieldEmail})
+
+	original := &billingpb.Customer{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.customerMapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+
+	customer := mgo.(*MgoCustomer)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.Email, customer.Email)
+	assert.Equal(suite.T(), original.Phone, customer.Phone)
+	assert.Empty(suite.T(), customer.PhoneIndex)
+	assert.Equal(suite.T(), original.Name, customer.Name)
+}
+
+func (suite *PersonalDataTestSuite) TestPersonalData_Customer_DecryptError() {
+	original := &billingpb.Customer{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.customerMapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+
+	SetFieldCipher(&testBrokenFieldCipher{}, []string{pkg.PersonalDataFieldEmail})
+
+	_, err = suite.customerMapper.MapMgoToObject(mgo)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PersonalDataTestSuite) TestPersonalData_Order_Ok() {
+	original := &billingpb.Order{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	original.Canceled = false
+	original.PrivateStatus = 0
+	original.Refunded = false
+	original.Object = "order"
+	original.BillingAddress.Country = "US"
+	original.VirtualCurrencyAmount = 0
+	original.Status = "created"
+	original.TestingCase = ""
+	original.CountryCode = "US"
+	original.ReceiptEmail = original.User.Email
+	original.ReceiptPhone = original.User.Phone
+	original.PaymentMethodTxnParams = map[string]string{
+		billingpb.PaymentCreateFieldHolder: "MR. CARD HOLDER",
+		billingpb.PaymentCreateFieldPan:    "400000******0002",
+	}
+	original.PaymentRequisites = map[string]string{
+		billingpb.PaymentCreateFieldHolder: "MR. CARD HOLDER",
+	}
+	clone := proto.Clone(original).(*billingpb.Order)
+
+	mgo, err := suite.orderMapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+
+	// the original order is not changed by encryption
+	assert.True(suite.T(), proto.Equal(clone, original))
+
+	order := mgo.(*MgoOrder)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.User.Email, order.User.Email)
+	assert.Equal(suite.T(), "index:"+original.User.Email, order.UserEmailIndex)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.User.Phone, order.User.Phone)
+	assert.Equal(suite.T(), "index:"+original.User.Phone, order.UserPhoneIndex)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.User.Name, order.User.Name)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.ReceiptEmail, order.ReceiptEmail)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.BillingAddress.PostalCode, order.BillingAddress.PostalCode)
+	assert.Equal(suite.T(), original.BillingAddress.Country, order.BillingAddress.Country)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+"MR. CARD HOLDER", order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldHolder])
+	assert.Equal(suite.T(), "400000******0002", order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldPan])
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+"MR. CARD HOLDER", order.PaymentRequisites[billingpb.PaymentCreateFieldHolder])
+
+	obj, err := suite.orderMapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+
+	buf1 := &bytes.Buffer{}
+	buf2 := &bytes.Buffer{}
+	marshaler := &jsonpb.Marshaler{}
+
+	assert.NoError(suite.T(), marshaler.Marshal(buf1, original))
+	assert.NoError(suite.T(), marshaler.Marshal(buf2, obj.(*billingpb.Order)))
+	assert.JSONEq(suite.T(), string(buf1.Bytes()), string(buf2.Bytes()))
+}
+
+func (suite *PersonalDataTestSuite) TestPersonalData_OrderView_Decrypt() {
+	user := &billingpb.OrderUser{}
+	err := faker.FakeData(user)
+	assert.NoError(suite.T(), err)
+
+	encrypted, err := encryptOrderUser(user)
+	assert.NoError(suite.T(), err)
+
+	view := &MgoOrderViewPublic{}
+	err = faker.FakeData(view)
+	assert.NoError(suite.T(), err)
+	view.User = encrypted
+
+	obj, err := NewOrderViewPublicMapper().MapMgoToObject(view)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), proto.Equal(user, obj.(*billingpb.OrderViewPublic).User))
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type priceHistoryMapper struct{}
+
+func NewPriceHistoryMapper() Mapper {
+	return &priceHistoryMapper{}
+}
+
+type MgoPriceHistory struct {
+	Id          primitive.ObjectID         `bson:"_id" faker:"objectId"`
+	MerchantId  primitive.ObjectID         `bson:"merchant_id" faker:"objectId"`
+	ProductId   primitive.ObjectID         `bson:"product_id" faker:"objectId"`
+	ProductType string                     `bson:"product_type"`
+	Prices      []*billingpb.ProductPrice  `bson:"prices"`
+	Platforms   []*billingpb.PlatformPrice `bson:"platforms"`
+	CreatedAt   time.Time                  `bson:"created_at"`
+}
+
+func (m *priceHistoryMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.PriceHistory)
+
+	out := &MgoPriceHistory{
+		ProductType: in.ProductType,
+		Prices:      in.Prices,
+		Platforms:   in.Platforms,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	productOid, err := primitive.ObjectIDFromHex(in.ProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.ProductId = productOid
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *priceHistoryMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPriceHistory)
+
+	out := &pkg.PriceHistory{
+		Id:          in.Id.Hex(),
+		MerchantId:  in.MerchantId.Hex(),
+		ProductId:   in.ProductId.Hex(),
+		ProductType: in.ProductType,
+		Prices:      in.Prices,
+		Platforms:   in.Platforms,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PriceHistoryTestSuite struct {
+	suite.Suite
+	mapper priceHistoryMapper
+}
+
+func TestPriceHistoryTestSuite(t *testing.T) {
+	suite.Run(t, new(PriceHistoryTestSuite))
+}
+
+func (suite *PriceHistoryTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_NewPriceHistoryMapper() {
+	mapper := NewPriceHistoryMapper()
+	assert.IsType(suite.T(), &priceHistoryMapper{}, mapper)
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_MapObjectToMgo_Ok() {
+	original := &pkg.PriceHistory{
+		Id:          primitive.NewObjectID().Hex(),
+		MerchantId:  primitive.NewObjectID().Hex(),
+		ProductId:   primitive.NewObjectID().Hex(),
+		ProductType: pkg.OrderItemTypeKeyProduct,
+		Platforms: []*billingpb.PlatformPrice{
+			{Id: "steam", Prices: []*billingpb.ProductPrice{{Amount: 19.99, Currency: "USD", Region: "USD"}}},
+		},
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.PriceHistory))
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.PriceHistory{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProductId:  primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoPriceHistory).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPriceHistory).CreatedAt.IsZero())
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_MapObjectToMgo_Error_Id() {
+	original := &pkg.PriceHistory{
+		Id:         "test",
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProductId:  primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.PriceHistory{
+		MerchantId: "test",
+		ProductId:  primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_MapObjectToMgo_Error_ProductId() {
+	original := &pkg.PriceHistory{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProductId:  "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_MapObjectToMgo_Error_Dates() {
+	original := &pkg.PriceHistory{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProductId:  primitive.NewObjectID().Hex(),
+		CreatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_MapMgoToObject_Ok() {
+	original := &MgoPriceHistory{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PriceHistoryTestSuite) Test_PriceHistory_MapMgoToObject_Error_Dates() {
+	original := &MgoPriceHistory{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
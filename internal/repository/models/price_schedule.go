@@ -0,0 +1,152 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type priceScheduleMapper struct{}
+
+func NewPriceScheduleMapper() Mapper {
+	return &priceScheduleMapper{}
+}
+
+type MgoPriceSchedule struct {
+	Id          primitive.ObjectID        `bson:"_id" faker:"objectId"`
+	MerchantId  primitive.ObjectID        `bson:"merchant_id" faker:"objectId"`
+	ProductId   primitive.ObjectID        `bson:"product_id" faker:"objectId"`
+	ProductType string                    `bson:"product_type"`
+	PlatformId  string                    `bson:"platform_id"`
+	Name        string                    `bson:"name"`
+	Prices      []*billingpb.ProductPrice `bson:"prices"`
+	StartAt     time.Time                 `bson:"start_at"`
+	EndAt       time.Time                 `bson:"end_at"`
+	Cancelled   bool                      `bson:"cancelled"`
+	CreatedAt   time.Time                 `bson:"created_at"`
+	UpdatedAt   time.Time                 `bson:"updated_at"`
+}
+
+func (m *priceScheduleMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.PriceSchedule)
+
+	out := &MgoPriceSchedule{
+		ProductType: in.ProductType,
+		PlatformId:  in.PlatformId,
+		Name:        in.Name,
+		Cancelled:   in.Cancelled,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	productOid, err := primitive.ObjectIDFromHex(in.ProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.ProductId = productOid
+
+	for _, price := range in.Prices {
+		out.Prices = append(out.Prices, &billingpb.ProductPrice{
+			Currency: price.Currency,
+			Region:   price.Region,
+			Amount:   tools.FormatAmount(price.Amount),
+		})
+	}
+
+	out.StartAt, err = ptypes.Timestamp(in.StartAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.EndAt, err = ptypes.Timestamp(in.EndAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *priceScheduleMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPriceSchedule)
+
+	out := &pkg.PriceSchedule{
+		Id:          in.Id.Hex(),
+		MerchantId:  in.MerchantId.Hex(),
+		ProductId:   in.ProductId.Hex(),
+		ProductType: in.ProductType,
+		PlatformId:  in.PlatformId,
+		Name:        in.Name,
+		Prices:      in.Prices,
+		Cancelled:   in.Cancelled,
+	}
+
+	out.StartAt, err = ptypes.TimestampProto(in.StartAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.EndAt, err = ptypes.TimestampProto(in.EndAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
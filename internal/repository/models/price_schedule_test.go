@@ -0,0 +1,146 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PriceScheduleTestSuite struct {
+	suite.Suite
+	mapper priceScheduleMapper
+}
+
+func TestPriceScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(PriceScheduleTestSuite))
+}
+
+func (suite *PriceScheduleTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PriceScheduleTestSuite) getSchedule() *pkg.PriceSchedule {
+	return &pkg.PriceSchedule{
+		MerchantId:  primitive.NewObjectID().Hex(),
+		ProductId:   primitive.NewObjectID().Hex(),
+		ProductType: pkg.OrderItemTypeKeyProduct,
+		PlatformId:  "steam",
+		StartAt:     ptypes.TimestampNow(),
+		EndAt:       ptypes.TimestampNow(),
+	}
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_NewPriceScheduleMapper() {
+	mapper := NewPriceScheduleMapper()
+	assert.IsType(suite.T(), &priceScheduleMapper{}, mapper)
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_MapObjectToMgo_Ok() {
+	original := suite.getSchedule()
+	original.Id = primitive.NewObjectID().Hex()
+	original.Name = "Weekend sale"
+	original.Prices = []*billingpb.ProductPrice{{Amount: 9.99, Currency: "USD", Region: "USD"}}
+	original.Cancelled = true
+	original.CreatedAt = ptypes.TimestampNow()
+	original.UpdatedAt = ptypes.TimestampNow()
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.PriceSchedule))
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(suite.getSchedule())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoPriceSchedule).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPriceSchedule).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPriceSchedule).UpdatedAt.IsZero())
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_MapObjectToMgo_Error_Id() {
+	original := suite.getSchedule()
+	original.Id = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_MapObjectToMgo_Error_MerchantId() {
+	original := suite.getSchedule()
+	original.MerchantId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_MapObjectToMgo_Error_ProductId() {
+	original := suite.getSchedule()
+	original.ProductId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_MapObjectToMgo_Error_Dates() {
+	original := suite.getSchedule()
+	original.StartAt = nil
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getSchedule()
+	original.EndAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getSchedule()
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getSchedule()
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_MapMgoToObject_Ok() {
+	original := &MgoPriceSchedule{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PriceScheduleTestSuite) Test_PriceSchedule_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoPriceSchedule{StartAt: invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoPriceSchedule{EndAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoPriceSchedule{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoPriceSchedule{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type priceTableRuleMapper struct{}
+
+func NewPriceTableRuleMapper() Mapper {
+	return &priceTableRuleMapper{}
+}
+
+type MgoPriceTableRule struct {
+	Id                   primitive.ObjectID `bson:"_id" faker:"objectId"`
+	Region               string             `bson:"region"`
+	PurchasingPowerIndex float64            `bson:"purchasing_power_index"`
+	CharmStep            float64            `bson:"charm_step"`
+	CharmEnding          float64            `bson:"charm_ending"`
+	CreatedAt            time.Time          `bson:"created_at"`
+	UpdatedAt            time.Time          `bson:"updated_at"`
+}
+
+func (m *priceTableRuleMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.PriceTableRule)
+
+	out := &MgoPriceTableRule{
+		Region:               in.Region,
+		PurchasingPowerIndex: in.PurchasingPowerIndex,
+		CharmStep:            in.CharmStep,
+		CharmEnding:          in.CharmEnding,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *priceTableRuleMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPriceTableRule)
+
+	out := &pkg.PriceTableRule{
+		Id:                   in.Id.Hex(),
+		Region:               in.Region,
+		PurchasingPowerIndex: in.PurchasingPowerIndex,
+		CharmStep:            in.CharmStep,
+		CharmEnding:          in.CharmEnding,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PriceTableRuleTestSuite struct {
+	suite.Suite
+	mapper priceTableRuleMapper
+}
+
+func TestPriceTableRuleTestSuite(t *testing.T) {
+	suite.Run(t, new(PriceTableRuleTestSuite))
+}
+
+func (suite *PriceTableRuleTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PriceTableRuleTestSuite) Test_PriceTableRule_NewPriceTableRuleMapper() {
+	mapper := NewPriceTableRuleMapper()
+	assert.IsType(suite.T(), &priceTableRuleMapper{}, mapper)
+}
+
+func (suite *PriceTableRuleTestSuite) Test_PriceTableRule_MapObjectToMgo_Ok() {
+	original := &pkg.PriceTableRule{
+		Id:                   primitive.NewObjectID().Hex(),
+		Region:               "RUB",
+		PurchasingPowerIndex: 0.6,
+		CharmStep:            10,
+		CharmEnding:          9,
+		CreatedAt:            ptypes.TimestampNow(),
+		UpdatedAt:            ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.PriceTableRule))
+}
+
+func (suite *PriceTableRuleTestSuite) Test_PriceTableRule_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.PriceTableRule{Region: "RUB"}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoPriceTableRule).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPriceTableRule).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPriceTableRule).UpdatedAt.IsZero())
+}
+
+func (suite *PriceTableRuleTestSuite) Test_PriceTableRule_MapObjectToMgo_Error_Id() {
+	original := &pkg.PriceTableRule{Id: "test"}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceTableRuleTestSuite) Test_PriceTableRule_MapObjectToMgo_Error_Dates() {
+	original := &pkg.PriceTableRule{CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = &pkg.PriceTableRule{UpdatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceTableRuleTestSuite) Test_PriceTableRule_MapMgoToObject_Ok() {
+	original := &MgoPriceTableRule{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PriceTableRuleTestSuite) Test_PriceTableRule_MapMgoToObject_Error_Dates() {
+	original := &MgoPriceTableRule{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoPriceTableRule{CreatedAt: time.Now(), UpdatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
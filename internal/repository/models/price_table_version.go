@@ -0,0 +1,143 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type priceTableVersionMapper struct{}
+
+func NewPriceTableVersionMapper() Mapper {
+	return &priceTableVersionMapper{}
+}
+
+type MgoPriceTableVersion struct {
+	Id        primitive.ObjectID          `bson:"_id" faker:"objectId"`
+	Version   int32                       `bson:"version"`
+	Status    string                      `bson:"status"`
+	Tables    []*MgoPriceTableVersionItem `bson:"tables"`
+	Rates     []*MgoPriceTableVersionRate `bson:"rates"`
+	CreatedAt time.Time                   `bson:"created_at"`
+	AppliedAt *time.Time                  `bson:"applied_at"`
+}
+
+type MgoPriceTableVersionItem struct {
+	Currency string                `bson:"currency"`
+	Ranges   []*MgoPriceTableRange `bson:"range"`
+}
+
+type MgoPriceTableVersionRate struct {
+	Region               string  `bson:"region"`
+	Currency             string  `bson:"currency"`
+	ExchangeRate         float64 `bson:"exchange_rate"`
+	PurchasingPowerIndex float64 `bson:"purchasing_power_index"`
+}
+
+func (m *priceTableVersionMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.PriceTableVersion)
+
+	out := &MgoPriceTableVersion{
+		Version: in.Version,
+		Status:  in.Status,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	for _, table := range in.Tables {
+		item := &MgoPriceTableVersionItem{Currency: table.Currency}
+
+		for _, v := range table.Ranges {
+			item.Ranges = append(item.Ranges, &MgoPriceTableRange{From: v.From, To: v.To, Position: v.Position})
+		}
+
+		out.Tables = append(out.Tables, item)
+	}
+
+	for _, rate := range in.Rates {
+		out.Rates = append(out.Rates, &MgoPriceTableVersionRate{
+			Region:               rate.Region,
+			Currency:             rate.Currency,
+			ExchangeRate:         rate.ExchangeRate,
+			PurchasingPowerIndex: rate.PurchasingPowerIndex,
+		})
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.AppliedAt != nil {
+		t, err := ptypes.Timestamp(in.AppliedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.AppliedAt = &t
+	}
+
+	return out, nil
+}
+
+func (m *priceTableVersionMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPriceTableVersion)
+
+	out := &pkg.PriceTableVersion{
+		Id:      in.Id.Hex(),
+		Version: in.Version,
+		Status:  in.Status,
+	}
+
+	for _, item := range in.Tables {
+		table := &billingpb.PriceTable{Currency: item.Currency}
+
+		for _, v := range item.Ranges {
+			table.Ranges = append(table.Ranges, &billingpb.PriceTableRange{From: v.From, To: v.To, Position: v.Position})
+		}
+
+		out.Tables = append(out.Tables, table)
+	}
+
+	for _, rate := range in.Rates {
+		out.Rates = append(out.Rates, &pkg.PriceTableVersionRate{
+			Region:               rate.Region,
+			Currency:             rate.Currency,
+			ExchangeRate:         rate.ExchangeRate,
+			PurchasingPowerIndex: rate.PurchasingPowerIndex,
+		})
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.AppliedAt != nil {
+		out.AppliedAt, err = ptypes.TimestampProto(*in.AppliedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PriceTableVersionTestSuite struct {
+	suite.Suite
+	mapper priceTableVersionMapper
+}
+
+func TestPriceTableVersionTestSuite(t *testing.T) {
+	suite.Run(t, new(PriceTableVersionTestSuite))
+}
+
+func (suite *PriceTableVersionTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PriceTableVersionTestSuite) Test_PriceTableVersion_NewPriceTableVersionMapper() {
+	mapper := NewPriceTableVersionMapper()
+	assert.IsType(suite.T(), &priceTableVersionMapper{}, mapper)
+}
+
+func (suite *PriceTableVersionTestSuite) Test_PriceTableVersion_MapObjectToMgo_Ok() {
+	original := &pkg.PriceTableVersion{
+		Id:      primitive.NewObjectID().Hex(),
+		Version: 2,
+		Status:  pkg.PriceTableVersionStatusApplied,
+		Tables: []*billingpb.PriceTable{
+			{
+				Currency: "RUB",
+				Ranges: []*billingpb.PriceTableRange{
+					{From: 0, To: 49, Position: 0},
+					{From: 49, To: 99, Position: 1},
+				},
+			},
+		},
+		Rates: []*pkg.PriceTableVersionRate{
+			{Region: "RUB", Currency: "RUB", ExchangeRate: 65, PurchasingPowerIndex: 0.6},
+		},
+		CreatedAt: ptypes.TimestampNow(),
+		AppliedAt: ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.PriceTableVersion))
+}
+
+func (suite *PriceTableVersionTestSuite) Test_PriceTableVersion_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.PriceTableVersion{Status: pkg.PriceTableVersionStatusPending}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoPriceTableVersion).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPriceTableVersion).CreatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoPriceTableVersion).AppliedAt)
+}
+
+func (suite *PriceTableVersionTestSuite) Test_PriceTableVersion_MapObjectToMgo_Error_Id() {
+	original := &pkg.PriceTableVersion{Id: "test"}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceTableVersionTestSuite) Test_PriceTableVersion_MapObjectToMgo_Error_Dates() {
+	original := &pkg.PriceTableVersion{CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = &pkg.PriceTableVersion{AppliedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PriceTableVersionTestSuite) Test_PriceTableVersion_MapMgoToObject_Ok() {
+	original := &MgoPriceTableVersion{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PriceTableVersionTestSuite) Test_PriceTableVersion_MapMgoToObject_Error_Dates() {
+	original := &MgoPriceTableVersion{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	applied := time.Time{}.AddDate(-10000, 0, 0)
+	original = &MgoPriceTableVersion{CreatedAt: time.Now(), AppliedAt: &applied}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type promoMapper struct{}
+
+func NewPromoMapper() Mapper {
+	return &promoMapper{}
+}
+
+type MgoPromo struct {
+	Id                 primitive.ObjectID `bson:"_id" faker:"objectId"`
+	Object             string             `bson:"object"`
+	MerchantId         primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	ProjectId          primitive.ObjectID `bson:"project_id" faker:"objectId"`
+	Code               string             `bson:"code"`
+	Name               string             `bson:"name"`
+	Type               string             `bson:"type"`
+	Percent            float64            `bson:"percent"`
+	Amounts            []*pkg.PromoAmount `bson:"amounts"`
+	BuyQuantity        int32              `bson:"buy_quantity"`
+	FreeQuantity       int32              `bson:"free_quantity"`
+	Products           []string           `bson:"products"`
+	Regions            []string           `bson:"regions"`
+	ValidFrom          *time.Time         `bson:"valid_from"`
+	ValidTo            *time.Time         `bson:"valid_to"`
+	MaxUses            int64              `bson:"max_uses"`
+	MaxUsesPerCustomer int64              `bson:"max_uses_per_customer"`
+	Enabled            bool               `bson:"enabled"`
+	Deleted            bool               `bson:"deleted"`
+	CreatedAt          time.Time          `bson:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at"`
+}
+
+func (m *promoMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.Promo)
+
+	out := &MgoPromo{
+		Object:             in.Object,
+		Code:               in.Code,
+		Name:               in.Name,
+		Type:               in.Type,
+		Percent:            in.Percent,
+		BuyQuantity:        in.BuyQuantity,
+		FreeQuantity:       in.FreeQuantity,
+		Products:           in.Products,
+		Regions:            in.Regions,
+		MaxUses:            in.MaxUses,
+		MaxUsesPerCustomer: in.MaxUsesPerCustomer,
+		Enabled:            in.Enabled,
+		Deleted:            in.Deleted,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	projectOid, err := primitive.ObjectIDFromHex(in.ProjectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.ProjectId = projectOid
+
+	for _, amount := range in.Amounts {
+		out.Amounts = append(out.Amounts, &pkg.PromoAmount{
+			Currency: amount.Currency,
+			Amount:   tools.FormatAmount(amount.Amount),
+		})
+	}
+
+	if in.ValidFrom != nil {
+		t, err := ptypes.Timestamp(in.ValidFrom)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ValidFrom = &t
+	}
+
+	if in.ValidTo != nil {
+		t, err := ptypes.Timestamp(in.ValidTo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ValidTo = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *promoMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPromo)
+
+	out := &pkg.Promo{
+		Id:                 in.Id.Hex(),
+		Object:             in.Object,
+		MerchantId:         in.MerchantId.Hex(),
+		ProjectId:          in.ProjectId.Hex(),
+		Code:               in.Code,
+		Name:               in.Name,
+		Type:               in.Type,
+		Percent:            in.Percent,
+		Amounts:            in.Amounts,
+		BuyQuantity:        in.BuyQuantity,
+		FreeQuantity:       in.FreeQuantity,
+		Products:           in.Products,
+		Regions:            in.Regions,
+		MaxUses:            in.MaxUses,
+		MaxUsesPerCustomer: in.MaxUsesPerCustomer,
+		Enabled:            in.Enabled,
+		Deleted:            in.Deleted,
+	}
+
+	if in.ValidFrom != nil {
+		out.ValidFrom, err = ptypes.TimestampProto(*in.ValidFrom)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if in.ValidTo != nil {
+		out.ValidTo, err = ptypes.TimestampProto(*in.ValidTo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
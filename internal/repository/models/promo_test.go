@@ -0,0 +1,160 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PromoTestSuite struct {
+	suite.Suite
+	mapper promoMapper
+}
+
+func TestPromoTestSuite(t *testing.T) {
+	suite.Run(t, new(PromoTestSuite))
+}
+
+func (suite *PromoTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PromoTestSuite) Test_Promo_NewPromoMapper() {
+	mapper := NewPromoMapper()
+	assert.IsType(suite.T(), &promoMapper{}, mapper)
+}
+
+func (suite *PromoTestSuite) Test_Promo_MapObjectToMgo_Ok() {
+	original := &pkg.Promo{
+		Id:                 primitive.NewObjectID().Hex(),
+		Object:             pkg.PromoObject,
+		MerchantId:         primitive.NewObjectID().Hex(),
+		ProjectId:          primitive.NewObjectID().Hex(),
+		Code:               "SUMMER20",
+		Name:               "Summer sale",
+		Type:               pkg.PromoTypeFixed,
+		Amounts:            []*pkg.PromoAmount{{Currency: "USD", Amount: 5.5}},
+		Products:           []string{primitive.NewObjectID().Hex()},
+		Regions:            []string{"USD"},
+		ValidFrom:          ptypes.TimestampNow(),
+		ValidTo:            ptypes.TimestampNow(),
+		MaxUses:            100,
+		MaxUsesPerCustomer: 1,
+		Enabled:            true,
+		CreatedAt:          ptypes.TimestampNow(),
+		UpdatedAt:          ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.Promo))
+}
+
+func (suite *PromoTestSuite) Test_Promo_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.Promo{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoPromo).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPromo).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPromo).UpdatedAt.IsZero())
+}
+
+func (suite *PromoTestSuite) Test_Promo_MapObjectToMgo_Error_Id() {
+	original := &pkg.Promo{
+		Id:         "test",
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoTestSuite) Test_Promo_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.Promo{
+		MerchantId: "test",
+		ProjectId:  primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoTestSuite) Test_Promo_MapObjectToMgo_Error_ProjectId() {
+	original := &pkg.Promo{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoTestSuite) Test_Promo_MapObjectToMgo_Error_Dates() {
+	original := &pkg.Promo{
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+		CreatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.CreatedAt = nil
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.UpdatedAt = nil
+	original.ValidFrom = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original.ValidFrom = nil
+	original.ValidTo = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoTestSuite) Test_Promo_MapMgoToObject_Ok() {
+	original := &MgoPromo{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PromoTestSuite) Test_Promo_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoPromo{CreatedAt: invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoPromo{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoPromo{ValidFrom: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoPromo{ValidTo: &invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
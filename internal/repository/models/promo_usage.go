@@ -0,0 +1,127 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type promoUsageMapper struct{}
+
+func NewPromoUsageMapper() Mapper {
+	return &promoUsageMapper{}
+}
+
+type MgoPromoUsage struct {
+	Id         primitive.ObjectID `bson:"_id" faker:"objectId"`
+	PromoId    primitive.ObjectID `bson:"promo_id" faker:"objectId"`
+	MerchantId primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	OrderId    primitive.ObjectID `bson:"order_id" faker:"objectId"`
+	CustomerId string             `bson:"customer_id"`
+	Discount   float64            `bson:"discount"`
+	Currency   string             `bson:"currency"`
+	Status     string             `bson:"status"`
+	ExpiresAt  *time.Time         `bson:"expires_at"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
+
+func (m *promoUsageMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.PromoUsage)
+
+	out := &MgoPromoUsage{
+		CustomerId: in.CustomerId,
+		Discount:   tools.FormatAmount(in.Discount),
+		Currency:   in.Currency,
+		Status:     in.Status,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	promoOid, err := primitive.ObjectIDFromHex(in.PromoId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.PromoId = promoOid
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	orderOid, err := primitive.ObjectIDFromHex(in.OrderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.OrderId = orderOid
+
+	if in.ExpiresAt != nil {
+		t, err := ptypes.Timestamp(in.ExpiresAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ExpiresAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *promoUsageMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoPromoUsage)
+
+	out := &pkg.PromoUsage{
+		Id:         in.Id.Hex(),
+		PromoId:    in.PromoId.Hex(),
+		MerchantId: in.MerchantId.Hex(),
+		OrderId:    in.OrderId.Hex(),
+		CustomerId: in.CustomerId,
+		Discount:   in.Discount,
+		Currency:   in.Currency,
+		Status:     in.Status,
+	}
+
+	if in.ExpiresAt != nil {
+		out.ExpiresAt, err = ptypes.TimestampProto(*in.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
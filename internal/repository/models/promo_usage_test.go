@@ -0,0 +1,149 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PromoUsageTestSuite struct {
+	suite.Suite
+	mapper promoUsageMapper
+}
+
+func TestPromoUsageTestSuite(t *testing.T) {
+	suite.Run(t, new(PromoUsageTestSuite))
+}
+
+func (suite *PromoUsageTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_NewPromoUsageMapper() {
+	mapper := NewPromoUsageMapper()
+	assert.IsType(suite.T(), &promoUsageMapper{}, mapper)
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapObjectToMgo_Ok() {
+	original := &pkg.PromoUsage{
+		Id:         primitive.NewObjectID().Hex(),
+		PromoId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		OrderId:    primitive.NewObjectID().Hex(),
+		CustomerId: primitive.NewObjectID().Hex(),
+		Discount:   12.5,
+		Currency:   "USD",
+		Status:     pkg.PromoUsageStatusReserved,
+		ExpiresAt:  ptypes.TimestampNow(),
+		CreatedAt:  ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.PromoUsage))
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.PromoUsage{
+		PromoId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		OrderId:    primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoPromoUsage).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoPromoUsage).CreatedAt.IsZero())
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapObjectToMgo_Error_Id() {
+	original := &pkg.PromoUsage{
+		Id:         "test",
+		PromoId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		OrderId:    primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapObjectToMgo_Error_PromoId() {
+	original := &pkg.PromoUsage{
+		PromoId:    "test",
+		MerchantId: primitive.NewObjectID().Hex(),
+		OrderId:    primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.PromoUsage{
+		PromoId:    primitive.NewObjectID().Hex(),
+		MerchantId: "test",
+		OrderId:    primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapObjectToMgo_Error_OrderId() {
+	original := &pkg.PromoUsage{
+		PromoId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		OrderId:    "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapObjectToMgo_Error_Dates() {
+	original := &pkg.PromoUsage{
+		PromoId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		OrderId:    primitive.NewObjectID().Hex(),
+		CreatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapObjectToMgo_Error_ExpiresAt() {
+	original := &pkg.PromoUsage{
+		PromoId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		OrderId:    primitive.NewObjectID().Hex(),
+		ExpiresAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapMgoToObject_Ok() {
+	original := &MgoPromoUsage{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *PromoUsageTestSuite) Test_PromoUsage_MapMgoToObject_Error_Dates() {
+	original := &MgoPromoUsage{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type riskAssessmentMapper struct{}
+
+func NewRiskAssessmentMapper() Mapper {
+	return &riskAssessmentMapper{}
+}
+
+type MgoRiskAssessment struct {
+	Id              primitive.ObjectID          `bson:"_id" faker:"objectId"`
+	OrderId         primitive.ObjectID          `bson:"order_id" faker:"objectId"`
+	MerchantId      primitive.ObjectID          `bson:"merchant_id" faker:"objectId"`
+	ProjectId       primitive.ObjectID          `bson:"project_id" faker:"objectId"`
+	CustomerId      string                      `bson:"customer_id"`
+	Email           string                      `bson:"email"`
+	EmailIndex      string                      `bson:"email_index"`
+	Ip              string                      `bson:"ip"`
+	IpIndex         string                      `bson:"ip_index"`
+	CardFingerprint string                      `bson:"card_fingerprint"`
+	Amount          float64                     `bson:"amount"`
+	Currency        string                      `bson:"currency"`
+	Signals         []*pkg.RiskAssessmentSignal `bson:"signals"`
+	Rules           []*pkg.RiskAssessmentRule   `bson:"rules"`
+	Score           int32                       `bson:"score"`
+	Decision        string                      `bson:"decision"`
+	Chargeback      bool                        `bson:"chargeback"`
+	CreatedAt       time.Time                   `bson:"created_at"`
+}
+
+func (m *riskAssessmentMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*pkg.RiskAssessment)
+
+	out := &MgoRiskAssessment{
+		CustomerId:      in.CustomerId,
+		EmailIndex:      GetFieldIndex(pkg.PersonalDataFieldEmail, in.Email),
+		IpIndex:         GetFieldIndex(pkg.PersonalDataFieldIp, in.Ip),
+		CardFingerprint: in.CardFingerprint,
+		Amount:          in.Amount,
+		Currency:        in.Currency,
+		Signals:         in.Signals,
+		Rules:           in.Rules,
+		Score:           in.Score,
+		Decision:        in.Decision,
+		Chargeback:      in.Chargeback,
+	}
+
+	if out.Email, err = encryptField(pkg.PersonalDataFieldEmail, in.Email); err != nil {
+		return nil, err
+	}
+
+	if out.Ip, err = encryptField(pkg.PersonalDataFieldIp, in.Ip); err != nil {
+		return nil, err
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	orderOid, err := primitive.ObjectIDFromHex(in.OrderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.OrderId = orderOid
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	projectOid, err := primitive.ObjectIDFromHex(in.ProjectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.ProjectId = projectOid
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *riskAssessmentMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoRiskAssessment)
+
+	out := &pkg.RiskAssessment{
+		Id:              in.Id.Hex(),
+		OrderId:         in.OrderId.Hex(),
+		MerchantId:      in.MerchantId.Hex(),
+		ProjectId:       in.ProjectId.Hex(),
+		CustomerId:      in.CustomerId,
+		CardFingerprint: in.CardFingerprint,
+		Amount:          in.Amount,
+		Currency:        in.Currency,
+		Signals:         in.Signals,
+		Rules:           in.Rules,
+		Score:           in.Score,
+		Decision:        in.Decision,
+		Chargeback:      in.Chargeback,
+	}
+
+	if out.Email, err = decryptField(in.Email); err != nil {
+		return nil, err
+	}
+
+	if out.Ip, err = decryptField(in.Ip); err != nil {
+		return nil, err
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
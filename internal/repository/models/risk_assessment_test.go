@@ -0,0 +1,162 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type RiskAssessmentTestSuite struct {
+	suite.Suite
+	mapper riskAssessmentMapper
+}
+
+func TestRiskAssessmentTestSuite(t *testing.T) {
+	suite.Run(t, new(RiskAssessmentTestSuite))
+}
+
+func (suite *RiskAssessmentTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *RiskAssessmentTestSuite) TearDownTest() {
+	SetFieldCipher(nil, nil)
+}
+
+func (suite *RiskAssessmentTestSuite) getObject() *pkg.RiskAssessment {
+	return &pkg.RiskAssessment{
+		OrderId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+	}
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_NewRiskAssessmentMapper() {
+	mapper := NewRiskAssessmentMapper()
+	assert.IsType(suite.T(), &riskAssessmentMapper{}, mapper)
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapObjectToMgo_Ok() {
+	original := &pkg.RiskAssessment{
+		Id:              primitive.NewObjectID().Hex(),
+		OrderId:         primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		ProjectId:       primitive.NewObjectID().Hex(),
+		CustomerId:      primitive.NewObjectID().Hex(),
+		Email:           "test@unit.test",
+		Ip:              "127.0.0.1",
+		CardFingerprint: "fingerprint",
+		Amount:          100,
+		Currency:        "USD",
+		Signals: []*pkg.RiskAssessmentSignal{
+			{Signal: pkg.RiskSignalCardVelocity, Period: 3600, Value: 2},
+		},
+		Rules: []*pkg.RiskAssessmentRule{
+			{RuleId: primitive.NewObjectID().Hex(), Name: "card velocity", Score: 40},
+		},
+		Score:      40,
+		Decision:   pkg.RiskDecisionAllow,
+		Chargeback: true,
+		CreatedAt:  ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.RiskAssessment))
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapObjectToMgo_Encrypted() {
+	SetFieldCipher(&testFieldCipher{}, []string{pkg.PersonalDataFieldEmail, pkg.PersonalDataFieldIp})
+
+	original := suite.getObject()
+	original.Email = "test@unit.test"
+	original.Ip = "127.0.0.1"
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.Email, mgo.(*MgoRiskAssessment).Email)
+	assert.Equal(suite.T(), "index:"+original.Email, mgo.(*MgoRiskAssessment).EmailIndex)
+	assert.Equal(suite.T(), testFieldCiphertextPrefix+original.Ip, mgo.(*MgoRiskAssessment).Ip)
+	assert.Equal(suite.T(), "index:"+original.Ip, mgo.(*MgoRiskAssessment).IpIndex)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original.Email, obj.(*pkg.RiskAssessment).Email)
+	assert.Equal(suite.T(), original.Ip, obj.(*pkg.RiskAssessment).Ip)
+
+	SetFieldCipher(&testBrokenFieldCipher{}, []string{pkg.PersonalDataFieldEmail})
+	_, err = suite.mapper.MapMgoToObject(mgo)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(suite.getObject())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoRiskAssessment).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoRiskAssessment).CreatedAt.IsZero())
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapObjectToMgo_Error_Id() {
+	original := suite.getObject()
+	original.Id = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapObjectToMgo_Error_OrderId() {
+	original := suite.getObject()
+	original.OrderId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapObjectToMgo_Error_MerchantId() {
+	original := suite.getObject()
+	original.MerchantId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapObjectToMgo_Error_ProjectId() {
+	original := suite.getObject()
+	original.ProjectId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapObjectToMgo_Error_Dates() {
+	original := suite.getObject()
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapMgoToObject_Ok() {
+	original := &MgoRiskAssessment{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *RiskAssessmentTestSuite) Test_RiskAssessment_MapMgoToObject_Error_Dates() {
+	original := &MgoRiskAssessment{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type riskRuleMapper struct{}
+
+func NewRiskRuleMapper() Mapper {
+	return &riskRuleMapper{}
+}
+
+type MgoRiskRule struct {
+	Id         primitive.ObjectID `bson:"_id" faker:"objectId"`
+	Name       string             `bson:"name"`
+	MerchantId string             `bson:"merchant_id"`
+	Signal     string             `bson:"signal"`
+	Operator   string             `bson:"operator"`
+	Value      float64            `bson:"value"`
+	Period     int64              `bson:"period"`
+	Score      int32              `bson:"score"`
+	Decision   string             `bson:"decision"`
+	Enabled    bool               `bson:"enabled"`
+	Deleted    bool               `bson:"deleted"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+}
+
+func (m *riskRuleMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.RiskRule)
+
+	out := &MgoRiskRule{
+		Name:       in.Name,
+		MerchantId: in.MerchantId,
+		Signal:     in.Signal,
+		Operator:   in.Operator,
+		Value:      in.Value,
+		Period:     in.Period,
+		Score:      in.Score,
+		Decision:   in.Decision,
+		Enabled:    in.Enabled,
+		Deleted:    in.Deleted,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *riskRuleMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoRiskRule)
+
+	out := &pkg.RiskRule{
+		Id:         in.Id.Hex(),
+		Name:       in.Name,
+		MerchantId: in.MerchantId,
+		Signal:     in.Signal,
+		Operator:   in.Operator,
+		Value:      in.Value,
+		Period:     in.Period,
+		Score:      in.Score,
+		Decision:   in.Decision,
+		Enabled:    in.Enabled,
+		Deleted:    in.Deleted,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type RiskRuleTestSuite struct {
+	suite.Suite
+	mapper riskRuleMapper
+}
+
+func TestRiskRuleTestSuite(t *testing.T) {
+	suite.Run(t, new(RiskRuleTestSuite))
+}
+
+func (suite *RiskRuleTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *RiskRuleTestSuite) Test_RiskRule_NewRiskRuleMapper() {
+	mapper := NewRiskRuleMapper()
+	assert.IsType(suite.T(), &riskRuleMapper{}, mapper)
+}
+
+func (suite *RiskRuleTestSuite) Test_RiskRule_MapObjectToMgo_Ok() {
+	original := &pkg.RiskRule{
+		Id:         primitive.NewObjectID().Hex(),
+		Name:       "card velocity",
+		MerchantId: primitive.NewObjectID().Hex(),
+		Signal:     pkg.RiskSignalCardVelocity,
+		Operator:   pkg.RiskOperatorGte,
+		Value:      3,
+		Period:     3600,
+		Score:      40,
+		Decision:   pkg.RiskDecisionReview,
+		Enabled:    true,
+		CreatedAt:  ptypes.TimestampNow(),
+		UpdatedAt:  ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.RiskRule))
+}
+
+func (suite *RiskRuleTestSuite) Test_RiskRule_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.RiskRule{}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoRiskRule).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoRiskRule).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoRiskRule).UpdatedAt.IsZero())
+}
+
+func (suite *RiskRuleTestSuite) Test_RiskRule_MapObjectToMgo_Error_Id() {
+	original := &pkg.RiskRule{
+		Id: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RiskRuleTestSuite) Test_RiskRule_MapObjectToMgo_Error_Dates() {
+	original := &pkg.RiskRule{
+		CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = &pkg.RiskRule{
+		UpdatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RiskRuleTestSuite) Test_RiskRule_MapMgoToObject_Ok() {
+	original := &MgoRiskRule{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *RiskRuleTestSuite) Test_RiskRule_MapMgoToObject_Error_Dates() {
+	original := &MgoRiskRule{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoRiskRule{UpdatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,241 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type royaltyReportDisputeMapper struct{}
+
+func NewRoyaltyReportDisputeMapper() Mapper {
+	return &royaltyReportDisputeMapper{}
+}
+
+type MgoRoyaltyReportDispute struct {
+	Id              primitive.ObjectID                `bson:"_id" faker:"objectId"`
+	RoyaltyReportId primitive.ObjectID                `bson:"royalty_report_id" faker:"objectId"`
+	MerchantId      primitive.ObjectID                `bson:"merchant_id" faker:"objectId"`
+	Status          string                            `bson:"status"`
+	Reason          string                            `bson:"reason"`
+	Items           []*MgoRoyaltyReportDisputeItem    `bson:"items"`
+	Messages        []*MgoRoyaltyReportDisputeMessage `bson:"messages"`
+	CreatedAt       time.Time                         `bson:"created_at"`
+	UpdatedAt       time.Time                         `bson:"updated_at"`
+	ResolvedAt      *time.Time                        `bson:"resolved_at"`
+}
+
+type MgoRoyaltyReportDisputeItem struct {
+	Id                primitive.ObjectID `bson:"_id" faker:"objectId"`
+	OrderUuid         string             `bson:"order_uuid"`
+	Product           string             `bson:"product"`
+	Region            string             `bson:"region"`
+	Amount            float64            `bson:"amount"`
+	Reason            string             `bson:"reason"`
+	Status            string             `bson:"status"`
+	AcceptedAmount    float64            `bson:"accepted_amount"`
+	AccountingEntryId string             `bson:"accounting_entry_id"`
+}
+
+type MgoRoyaltyReportDisputeMessage struct {
+	Id        primitive.ObjectID `bson:"_id" faker:"objectId"`
+	Source    string             `bson:"source"`
+	UserId    string             `bson:"user_id"`
+	Message   string             `bson:"message"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (m *royaltyReportDisputeMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.RoyaltyReportDispute)
+
+	out := &MgoRoyaltyReportDispute{
+		Status:   in.Status,
+		Reason:   in.Reason,
+		Items:    []*MgoRoyaltyReportDisputeItem{},
+		Messages: []*MgoRoyaltyReportDisputeMessage{},
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	reportOid, err := primitive.ObjectIDFromHex(in.RoyaltyReportId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.RoyaltyReportId = reportOid
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	for _, v := range in.Items {
+		item := &MgoRoyaltyReportDisputeItem{
+			OrderUuid:         v.OrderUuid,
+			Product:           v.Product,
+			Region:            v.Region,
+			Amount:            v.Amount,
+			Reason:            v.Reason,
+			Status:            v.Status,
+			AcceptedAmount:    v.AcceptedAmount,
+			AccountingEntryId: v.AccountingEntryId,
+		}
+
+		if len(v.Id) <= 0 {
+			item.Id = primitive.NewObjectID()
+		} else {
+			oid, err := primitive.ObjectIDFromHex(v.Id)
+			if err != nil {
+				return nil, err
+			}
+			item.Id = oid
+		}
+
+		out.Items = append(out.Items, item)
+	}
+
+	for _, v := range in.Messages {
+		message := &MgoRoyaltyReportDisputeMessage{
+			Source:  v.Source,
+			UserId:  v.UserId,
+			Message: v.Message,
+		}
+
+		if len(v.Id) <= 0 {
+			message.Id = primitive.NewObjectID()
+		} else {
+			oid, err := primitive.ObjectIDFromHex(v.Id)
+			if err != nil {
+				return nil, err
+			}
+			message.Id = oid
+		}
+
+		if v.CreatedAt != nil {
+			t, err := ptypes.Timestamp(v.CreatedAt)
+
+			if err != nil {
+				return nil, err
+			}
+
+			message.CreatedAt = t
+		} else {
+			message.CreatedAt = time.Now()
+		}
+
+		out.Messages = append(out.Messages, message)
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	if in.ResolvedAt != nil {
+		t, err := ptypes.Timestamp(in.ResolvedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ResolvedAt = &t
+	}
+
+	return out, nil
+}
+
+func (m *royaltyReportDisputeMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoRoyaltyReportDispute)
+
+	out := &pkg.RoyaltyReportDispute{
+		Id:              in.Id.Hex(),
+		RoyaltyReportId: in.RoyaltyReportId.Hex(),
+		MerchantId:      in.MerchantId.Hex(),
+		Status:          in.Status,
+		Reason:          in.Reason,
+		Items:           []*pkg.RoyaltyReportDisputeItem{},
+		Messages:        []*pkg.RoyaltyReportDisputeMessage{},
+	}
+
+	for _, v := range in.Items {
+		out.Items = append(out.Items, &pkg.RoyaltyReportDisputeItem{
+			Id:                v.Id.Hex(),
+			OrderUuid:         v.OrderUuid,
+			Product:           v.Product,
+			Region:            v.Region,
+			Amount:            v.Amount,
+			Reason:            v.Reason,
+			Status:            v.Status,
+			AcceptedAmount:    v.AcceptedAmount,
+			AccountingEntryId: v.AccountingEntryId,
+		})
+	}
+
+	for _, v := range in.Messages {
+		message := &pkg.RoyaltyReportDisputeMessage{
+			Id:      v.Id.Hex(),
+			Source:  v.Source,
+			UserId:  v.UserId,
+			Message: v.Message,
+		}
+
+		message.CreatedAt, err = ptypes.TimestampProto(v.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		out.Messages = append(out.Messages, message)
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ResolvedAt != nil {
+		out.ResolvedAt, err = ptypes.TimestampProto(*in.ResolvedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
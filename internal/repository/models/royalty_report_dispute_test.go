@@ -0,0 +1,246 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type RoyaltyReportDisputeTestSuite struct {
+	suite.Suite
+	mapper royaltyReportDisputeMapper
+}
+
+func TestRoyaltyReportDisputeTestSuite(t *testing.T) {
+	suite.Run(t, new(RoyaltyReportDisputeTestSuite))
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_NewRoyaltyReportDisputeMapper() {
+	mapper := NewRoyaltyReportDisputeMapper()
+	assert.IsType(suite.T(), &royaltyReportDisputeMapper{}, mapper)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Ok() {
+	original := &pkg.RoyaltyReportDispute{
+		Id:              primitive.NewObjectID().Hex(),
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		Status:          pkg.RoyaltyReportDisputeStatusResolved,
+		Reason:          "reason",
+		Items: []*pkg.RoyaltyReportDisputeItem{
+			{
+				Id:                primitive.NewObjectID().Hex(),
+				OrderUuid:         "ce0c24a5-6fcd-4a03-9b29-17e5b4a4ed54",
+				Amount:            10,
+				Reason:            "order reason",
+				Status:            pkg.RoyaltyReportDisputeItemStatusAccepted,
+				AcceptedAmount:    5,
+				AccountingEntryId: primitive.NewObjectID().Hex(),
+			},
+			{
+				Id:      primitive.NewObjectID().Hex(),
+				Product: "product",
+				Region:  "RU",
+				Amount:  -10,
+				Reason:  "product reason",
+				Status:  pkg.RoyaltyReportDisputeItemStatusRejected,
+			},
+		},
+		Messages: []*pkg.RoyaltyReportDisputeMessage{
+			{
+				Id:        primitive.NewObjectID().Hex(),
+				Source:    pkg.RoyaltyReportChangeSourceMerchant,
+				UserId:    primitive.NewObjectID().Hex(),
+				Message:   "message",
+				CreatedAt: ptypes.TimestampNow(),
+			},
+		},
+		CreatedAt:  ptypes.TimestampNow(),
+		UpdatedAt:  ptypes.TimestampNow(),
+		ResolvedAt: ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), obj)
+
+	b1, err := json.Marshal(original)
+	assert.NoError(suite.T(), err)
+	b2, err := json.Marshal(obj.(*pkg.RoyaltyReportDispute))
+	assert.NoError(suite.T(), err)
+	assert.JSONEq(suite.T(), string(b1), string(b2))
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Ok_EmptyIdsAndDates() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		Items:           []*pkg.RoyaltyReportDisputeItem{{OrderUuid: "order"}},
+		Messages:        []*pkg.RoyaltyReportDisputeMessage{{Message: "message"}},
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+
+	out := mgo.(*MgoRoyaltyReportDispute)
+	assert.False(suite.T(), out.Id.IsZero())
+	assert.False(suite.T(), out.Items[0].Id.IsZero())
+	assert.False(suite.T(), out.Messages[0].Id.IsZero())
+	assert.False(suite.T(), out.Messages[0].CreatedAt.IsZero())
+	assert.False(suite.T(), out.CreatedAt.IsZero())
+	assert.False(suite.T(), out.UpdatedAt.IsZero())
+	assert.Nil(suite.T(), out.ResolvedAt)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_Id() {
+	original := &pkg.RoyaltyReportDispute{
+		Id:              "test",
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_RoyaltyReportId() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: "test",
+		MerchantId:      primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_ItemId() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		Items:           []*pkg.RoyaltyReportDisputeItem{{Id: "test"}},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_MessageId() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		Messages:        []*pkg.RoyaltyReportDisputeMessage{{Id: "test"}},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_MessageCreatedAt() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		Messages: []*pkg.RoyaltyReportDisputeMessage{
+			{CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}},
+		},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_CreatedAt() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		CreatedAt:       &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_UpdatedAt() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		UpdatedAt:       &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapObjectToMgo_Error_ResolvedAt() {
+	original := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		ResolvedAt:      &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapMgoToObject_Ok() {
+	original := &MgoRoyaltyReportDispute{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapMgoToObject_Error_MessageCreatedAt() {
+	original := &MgoRoyaltyReportDispute{
+		Messages: []*MgoRoyaltyReportDisputeMessage{
+			{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)},
+		},
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapMgoToObject_Error_CreatedAt() {
+	original := &MgoRoyaltyReportDispute{
+		CreatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapMgoToObject_Error_UpdatedAt() {
+	original := &MgoRoyaltyReportDispute{
+		UpdatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RoyaltyReportDisputeTestSuite) Test_RoyaltyReportDispute_MapMgoToObject_Error_ResolvedAt() {
+	t := time.Time{}.AddDate(-10000, 0, 0)
+	original := &MgoRoyaltyReportDispute{
+		ResolvedAt: &t,
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type merchantRoyaltyScheduleMapper struct{}
+
+func NewMerchantRoyaltyScheduleMapper() Mapper {
+	return &merchantRoyaltyScheduleMapper{}
+}
+
+type MgoMerchantRoyaltySchedule struct {
+	Id         primitive.ObjectID `bson:"_id" faker:"objectId"`
+	MerchantId primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	Period     string             `bson:"period"`
+	AnchorDay  int32              `bson:"anchor_day"`
+	EndHour    int32              `bson:"end_hour"`
+	Timezone   string             `bson:"timezone"`
+	StartsAt   time.Time          `bson:"starts_at"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+}
+
+func (m *merchantRoyaltyScheduleMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.MerchantRoyaltySchedule)
+
+	out := &MgoMerchantRoyaltySchedule{
+		Period:    in.Period,
+		AnchorDay: in.AnchorDay,
+		EndHour:   in.EndHour,
+		Timezone:  in.Timezone,
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	if in.StartsAt != nil {
+		t, err := ptypes.Timestamp(in.StartsAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.StartsAt = t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *merchantRoyaltyScheduleMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoMerchantRoyaltySchedule)
+
+	out := &pkg.MerchantRoyaltySchedule{
+		Id:         in.Id.Hex(),
+		MerchantId: in.MerchantId.Hex(),
+		Period:     in.Period,
+		AnchorDay:  in.AnchorDay,
+		EndHour:    in.EndHour,
+		Timezone:   in.Timezone,
+	}
+
+	out.StartsAt, err = ptypes.TimestampProto(in.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
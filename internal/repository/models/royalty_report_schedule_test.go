@@ -0,0 +1,153 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type MerchantRoyaltyScheduleTestSuite struct {
+	suite.Suite
+	mapper merchantRoyaltyScheduleMapper
+}
+
+func TestMerchantRoyaltyScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(MerchantRoyaltyScheduleTestSuite))
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_NewMerchantRoyaltyScheduleMapper() {
+	mapper := NewMerchantRoyaltyScheduleMapper()
+	assert.IsType(suite.T(), &merchantRoyaltyScheduleMapper{}, mapper)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapObjectToMgo_Ok() {
+	original := &pkg.MerchantRoyaltySchedule{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		Period:     pkg.RoyaltyReportSchedulePeriodMonthly,
+		AnchorDay:  15,
+		EndHour:    18,
+		Timezone:   "Europe/Berlin",
+		StartsAt:   ptypes.TimestampNow(),
+		CreatedAt:  ptypes.TimestampNow(),
+		UpdatedAt:  ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), obj)
+
+	b1, err := json.Marshal(original)
+	assert.NoError(suite.T(), err)
+	b2, err := json.Marshal(obj.(*pkg.MerchantRoyaltySchedule))
+	assert.NoError(suite.T(), err)
+	assert.JSONEq(suite.T(), string(b1), string(b2))
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapObjectToMgo_Ok_EmptyDates() {
+	original := &pkg.MerchantRoyaltySchedule{
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoMerchantRoyaltySchedule).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoMerchantRoyaltySchedule).CreatedAt.IsZero())
+	assert.True(suite.T(), mgo.(*MgoMerchantRoyaltySchedule).StartsAt.IsZero())
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapObjectToMgo_Error_Id() {
+	original := &pkg.MerchantRoyaltySchedule{
+		Id:         "test",
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapObjectToMgo_Error_MerchantId() {
+	original := &pkg.MerchantRoyaltySchedule{
+		MerchantId: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapObjectToMgo_Error_StartsAt() {
+	original := &pkg.MerchantRoyaltySchedule{
+		MerchantId: primitive.NewObjectID().Hex(),
+		StartsAt:   &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapObjectToMgo_Error_CreatedAt() {
+	original := &pkg.MerchantRoyaltySchedule{
+		MerchantId: primitive.NewObjectID().Hex(),
+		CreatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapObjectToMgo_Error_UpdatedAt() {
+	original := &pkg.MerchantRoyaltySchedule{
+		MerchantId: primitive.NewObjectID().Hex(),
+		UpdatedAt:  &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapMgoToObject_Ok() {
+	original := &MgoMerchantRoyaltySchedule{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapMgoToObject_Error_StartsAt() {
+	original := &MgoMerchantRoyaltySchedule{
+		StartsAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapMgoToObject_Error_CreatedAt() {
+	original := &MgoMerchantRoyaltySchedule{
+		CreatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MerchantRoyaltyScheduleTestSuite) Test_MerchantRoyaltySchedule_MapMgoToObject_Error_UpdatedAt() {
+	original := &MgoMerchantRoyaltySchedule{
+		UpdatedAt: time.Time{}.AddDate(-10000, 0, 0),
+	}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
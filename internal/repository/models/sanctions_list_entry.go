@@ -0,0 +1,90 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type sanctionsListEntryMapper struct{}
+
+func NewSanctionsListEntryMapper() Mapper {
+	return &sanctionsListEntryMapper{}
+}
+
+type MgoSanctionsListEntry struct {
+	Id         primitive.ObjectID `bson:"_id" faker:"objectId"`
+	Source     string             `bson:"source"`
+	ExternalId string             `bson:"external_id"`
+	Type       string             `bson:"type"`
+	Name       string             `bson:"name"`
+	Aliases    []string           `bson:"aliases"`
+	Countries  []string           `bson:"countries"`
+	Keys       []string           `bson:"keys"`
+	Version    int64              `bson:"version"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
+
+func (m *sanctionsListEntryMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.SanctionsListEntry)
+
+	out := &MgoSanctionsListEntry{
+		Source:     in.Source,
+		ExternalId: in.ExternalId,
+		Type:       in.Type,
+		Name:       in.Name,
+		Aliases:    in.Aliases,
+		Countries:  in.Countries,
+		Keys:       in.Keys,
+		Version:    in.Version,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *sanctionsListEntryMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoSanctionsListEntry)
+
+	out := &pkg.SanctionsListEntry{
+		Id:         in.Id.Hex(),
+		Source:     in.Source,
+		ExternalId: in.ExternalId,
+		Type:       in.Type,
+		Name:       in.Name,
+		Aliases:    in.Aliases,
+		Countries:  in.Countries,
+		Keys:       in.Keys,
+		Version:    in.Version,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type SanctionsListEntryTestSuite struct {
+	suite.Suite
+	mapper sanctionsListEntryMapper
+}
+
+func TestSanctionsListEntryTestSuite(t *testing.T) {
+	suite.Run(t, new(SanctionsListEntryTestSuite))
+}
+
+func (suite *SanctionsListEntryTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *SanctionsListEntryTestSuite) Test_SanctionsListEntry_NewSanctionsListEntryMapper() {
+	mapper := NewSanctionsListEntryMapper()
+	assert.IsType(suite.T(), &sanctionsListEntryMapper{}, mapper)
+}
+
+func (suite *SanctionsListEntryTestSuite) Test_SanctionsListEntry_MapObjectToMgo_Ok() {
+	original := &pkg.SanctionsListEntry{
+		Id:         primitive.NewObjectID().Hex(),
+		Source:     pkg.SanctionsListSourceOfac,
+		ExternalId: "12345",
+		Type:       pkg.SanctionsListEntryTypeEntity,
+		Name:       "Bad Company",
+		Aliases:    []string{"Bad Co"},
+		Countries:  []string{"IR"},
+		Keys:       []string{"bad", "com"},
+		Version:    time.Now().Unix(),
+		CreatedAt:  ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.SanctionsListEntry))
+}
+
+func (suite *SanctionsListEntryTestSuite) Test_SanctionsListEntry_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(&pkg.SanctionsListEntry{})
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoSanctionsListEntry).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoSanctionsListEntry).CreatedAt.IsZero())
+}
+
+func (suite *SanctionsListEntryTestSuite) Test_SanctionsListEntry_MapObjectToMgo_Error_Id() {
+	_, err := suite.mapper.MapObjectToMgo(&pkg.SanctionsListEntry{Id: "test"})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SanctionsListEntryTestSuite) Test_SanctionsListEntry_MapObjectToMgo_Error_Dates() {
+	original := &pkg.SanctionsListEntry{CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SanctionsListEntryTestSuite) Test_SanctionsListEntry_MapMgoToObject_Ok() {
+	original := &MgoSanctionsListEntry{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *SanctionsListEntryTestSuite) Test_SanctionsListEntry_MapMgoToObject_Error_Dates() {
+	original := &MgoSanctionsListEntry{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
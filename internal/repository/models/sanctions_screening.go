@@ -0,0 +1,174 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type sanctionsScreeningMapper struct{}
+
+func NewSanctionsScreeningMapper() Mapper {
+	return &sanctionsScreeningMapper{}
+}
+
+type MgoSanctionsScreening struct {
+	Id         primitive.ObjectID               `bson:"_id" faker:"objectId"`
+	MerchantId primitive.ObjectID               `bson:"merchant_id" faker:"objectId"`
+	Trigger    string                           `bson:"trigger"`
+	Subjects   []*pkg.SanctionsScreeningSubject `bson:"subjects"`
+	Matches    []*pkg.SanctionsScreeningMatch   `bson:"matches"`
+	Status     string                           `bson:"status"`
+	ResolvedBy string                           `bson:"resolved_by"`
+	ResolvedAt *time.Time                       `bson:"resolved_at"`
+	History    []*MgoSanctionsScreeningEvent    `bson:"history"`
+	CreatedAt  time.Time                        `bson:"created_at"`
+	UpdatedAt  time.Time                        `bson:"updated_at"`
+}
+
+type MgoSanctionsScreeningEvent struct {
+	Status    string    `bson:"status"`
+	UserId    string    `bson:"user_id"`
+	Comment   string    `bson:"comment"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (m *sanctionsScreeningMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.SanctionsScreening)
+
+	out := &MgoSanctionsScreening{
+		Trigger:    in.Trigger,
+		Subjects:   in.Subjects,
+		Matches:    in.Matches,
+		Status:     in.Status,
+		ResolvedBy: in.ResolvedBy,
+		History:    []*MgoSanctionsScreeningEvent{},
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	for _, v := range in.History {
+		event := &MgoSanctionsScreeningEvent{
+			Status:  v.Status,
+			UserId:  v.UserId,
+			Comment: v.Comment,
+		}
+
+		if v.CreatedAt != nil {
+			t, err := ptypes.Timestamp(v.CreatedAt)
+
+			if err != nil {
+				return nil, err
+			}
+
+			event.CreatedAt = t
+		} else {
+			event.CreatedAt = time.Now()
+		}
+
+		out.History = append(out.History, event)
+	}
+
+	if in.ResolvedAt != nil {
+		t, err := ptypes.Timestamp(in.ResolvedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.ResolvedAt = &t
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *sanctionsScreeningMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoSanctionsScreening)
+
+	out := &pkg.SanctionsScreening{
+		Id:         in.Id.Hex(),
+		MerchantId: in.MerchantId.Hex(),
+		Trigger:    in.Trigger,
+		Subjects:   in.Subjects,
+		Matches:    in.Matches,
+		Status:     in.Status,
+		ResolvedBy: in.ResolvedBy,
+		History:    []*pkg.SanctionsScreeningEvent{},
+	}
+
+	for _, v := range in.History {
+		event := &pkg.SanctionsScreeningEvent{
+			Status:  v.Status,
+			UserId:  v.UserId,
+			Comment: v.Comment,
+		}
+
+		event.CreatedAt, err = ptypes.TimestampProto(v.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		out.History = append(out.History, event)
+	}
+
+	if in.ResolvedAt != nil {
+		out.ResolvedAt, err = ptypes.TimestampProto(*in.ResolvedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
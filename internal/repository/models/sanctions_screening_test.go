@@ -0,0 +1,164 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type SanctionsScreeningTestSuite struct {
+	suite.Suite
+	mapper sanctionsScreeningMapper
+}
+
+func TestSanctionsScreeningTestSuite(t *testing.T) {
+	suite.Run(t, new(SanctionsScreeningTestSuite))
+}
+
+func (suite *SanctionsScreeningTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *SanctionsScreeningTestSuite) getObject() *pkg.SanctionsScreening {
+	return &pkg.SanctionsScreening{
+		MerchantId: primitive.NewObjectID().Hex(),
+	}
+}
+
+func (suite *SanctionsScreeningTestSuite) Test_SanctionsScreening_NewSanctionsScreeningMapper() {
+	mapper := NewSanctionsScreeningMapper()
+	assert.IsType(suite.T(), &sanctionsScreeningMapper{}, mapper)
+}
+
+func (suite *SanctionsScreeningTestSuite) Test_SanctionsScreening_MapObjectToMgo_Ok() {
+	original := &pkg.SanctionsScreening{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		Trigger:    pkg.SanctionsScreeningTriggerPayout,
+		Subjects: []*pkg.SanctionsScreeningSubject{
+			{Role: pkg.SanctionsScreeningSubjectCompany, Name: "Bad Company", Country: "IR"},
+		},
+		Matches: []*pkg.SanctionsScreeningMatch{
+			{
+				SubjectRole:    pkg.SanctionsScreeningSubjectCompany,
+				SubjectName:    "Bad Company",
+				Source:         pkg.SanctionsListSourceOfac,
+				ExternalId:     "12345",
+				EntryName:      "Bad Co",
+				EntryCountries: []string{"IR"},
+				Score:          0.93,
+			},
+		},
+		Status:     pkg.SanctionsScreeningStatusCleared,
+		ResolvedBy: primitive.NewObjectID().Hex(),
+		ResolvedAt: ptypes.TimestampNow(),
+		History: []*pkg.SanctionsScreeningEvent{
+			{Status: pkg.SanctionsScreeningStatusPotentialMatch, CreatedAt: ptypes.TimestampNow()},
+			{
+				Status:    pkg.SanctionsScreeningStatusCleared,
+				UserId:    primitive.NewObjectID().Hex(),
+				Comment:   "another company",
+				CreatedAt: ptypes.TimestampNow(),
+			},
+		},
+		CreatedAt: ptypes.TimestampNow(),
+		UpdatedAt: ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.SanctionsScreening))
+}
+
+func (suite *SanctionsScreeningTestSuite) Test_SanctionsScreening_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := suite.getObject()
+	original.History = []*pkg.SanctionsScreeningEvent{{Status: pkg.SanctionsScreeningStatusClear}}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoSanctionsScreening).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoSanctionsScreening).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoSanctionsScreening).UpdatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoSanctionsScreening).History[0].CreatedAt.IsZero())
+	assert.Nil(suite.T(), mgo.(*MgoSanctionsScreening).ResolvedAt)
+}
+
+func (suite *SanctionsScreeningTestSuite) Test_SanctionsScreening_MapObjectToMgo_Error_Id() {
+	original := suite.getObject()
+	original.Id = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SanctionsScreeningTestSuite) Test_SanctionsScreening_MapObjectToMgo_Error_MerchantId() {
+	original := suite.getObject()
+	original.MerchantId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SanctionsScreeningTestSuite) Test_SanctionsScreening_MapObjectToMgo_Error_Dates() {
+	original := suite.getObject()
+	original.ResolvedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.History = []*pkg.SanctionsScreeningEvent{{CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1}}}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = suite.getObject()
+	original.UpdatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SanctionsScreeningTestSuite) Test_SanctionsScreening_MapMgoToObject_Ok() {
+	original := &MgoSanctionsScreening{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *SanctionsScreeningTestSuite) Test_SanctionsScreening_MapMgoToObject_Error_Dates() {
+	invalid := time.Time{}.AddDate(-10000, 0, 0)
+
+	original := &MgoSanctionsScreening{ResolvedAt: &invalid}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoSanctionsScreening{History: []*MgoSanctionsScreeningEvent{{CreatedAt: invalid}}}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoSanctionsScreening{CreatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoSanctionsScreening{UpdatedAt: invalid}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type velocityLimitMapper struct{}
+
+func NewVelocityLimitMapper() Mapper {
+	return &velocityLimitMapper{}
+}
+
+type MgoVelocityLimit struct {
+	Id              primitive.ObjectID `bson:"_id" faker:"objectId"`
+	MerchantId      string             `bson:"merchant_id"`
+	PaymentMethodId string             `bson:"payment_method_id"`
+	Key             string             `bson:"key"`
+	Window          int64              `bson:"window"`
+	MaxCount        int64              `bson:"max_count"`
+	MaxAmount       float64            `bson:"max_amount"`
+	Currency        string             `bson:"currency"`
+	Enabled         bool               `bson:"enabled"`
+	Deleted         bool               `bson:"deleted"`
+	CreatedAt       time.Time          `bson:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at"`
+}
+
+func (m *velocityLimitMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.VelocityLimit)
+
+	out := &MgoVelocityLimit{
+		MerchantId:      in.MerchantId,
+		PaymentMethodId: in.PaymentMethodId,
+		Key:             in.Key,
+		Window:          in.Window,
+		MaxCount:        in.MaxCount,
+		MaxAmount:       in.MaxAmount,
+		Currency:        in.Currency,
+		Enabled:         in.Enabled,
+		Deleted:         in.Deleted,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	if in.UpdatedAt != nil {
+		t, err := ptypes.Timestamp(in.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.UpdatedAt = t
+	} else {
+		out.UpdatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *velocityLimitMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoVelocityLimit)
+
+	out := &pkg.VelocityLimit{
+		Id:              in.Id.Hex(),
+		MerchantId:      in.MerchantId,
+		PaymentMethodId: in.PaymentMethodId,
+		Key:             in.Key,
+		Window:          in.Window,
+		MaxCount:        in.MaxCount,
+		MaxAmount:       in.MaxAmount,
+		Currency:        in.Currency,
+		Enabled:         in.Enabled,
+		Deleted:         in.Deleted,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedAt, err = ptypes.TimestampProto(in.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type velocityLimitEventMapper struct{}
+
+func NewVelocityLimitEventMapper() Mapper {
+	return &velocityLimitEventMapper{}
+}
+
+type MgoVelocityLimitEvent struct {
+	Id              primitive.ObjectID `bson:"_id" faker:"objectId"`
+	LimitId         primitive.ObjectID `bson:"limit_id" faker:"objectId"`
+	MerchantId      primitive.ObjectID `bson:"merchant_id" faker:"objectId"`
+	ProjectId       primitive.ObjectID `bson:"project_id" faker:"objectId"`
+	OrderId         primitive.ObjectID `bson:"order_id" faker:"objectId"`
+	PaymentMethodId string             `bson:"payment_method_id"`
+	Key             string             `bson:"key"`
+	Stage           string             `bson:"stage"`
+	Window          int64              `bson:"window"`
+	Count           int64              `bson:"count"`
+	Amount          float64            `bson:"amount"`
+	MaxCount        int64              `bson:"max_count"`
+	MaxAmount       float64            `bson:"max_amount"`
+	Currency        string             `bson:"currency"`
+	CreatedAt       time.Time          `bson:"created_at"`
+}
+
+func (m *velocityLimitEventMapper) MapObjectToMgo(obj interface{}) (interface{}, error) {
+	in := obj.(*pkg.VelocityLimitEvent)
+
+	out := &MgoVelocityLimitEvent{
+		PaymentMethodId: in.PaymentMethodId,
+		Key:             in.Key,
+		Stage:           in.Stage,
+		Window:          in.Window,
+		Count:           in.Count,
+		Amount:          in.Amount,
+		MaxCount:        in.MaxCount,
+		MaxAmount:       in.MaxAmount,
+		Currency:        in.Currency,
+	}
+
+	if len(in.Id) <= 0 {
+		out.Id = primitive.NewObjectID()
+	} else {
+		oid, err := primitive.ObjectIDFromHex(in.Id)
+		if err != nil {
+			return nil, err
+		}
+		out.Id = oid
+	}
+
+	limitOid, err := primitive.ObjectIDFromHex(in.LimitId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.LimitId = limitOid
+
+	merchantOid, err := primitive.ObjectIDFromHex(in.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.MerchantId = merchantOid
+
+	projectOid, err := primitive.ObjectIDFromHex(in.ProjectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.ProjectId = projectOid
+
+	orderOid, err := primitive.ObjectIDFromHex(in.OrderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.OrderId = orderOid
+
+	if in.CreatedAt != nil {
+		t, err := ptypes.Timestamp(in.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.CreatedAt = t
+	} else {
+		out.CreatedAt = time.Now()
+	}
+
+	return out, nil
+}
+
+func (m *velocityLimitEventMapper) MapMgoToObject(obj interface{}) (interface{}, error) {
+	var err error
+	in := obj.(*MgoVelocityLimitEvent)
+
+	out := &pkg.VelocityLimitEvent{
+		Id:              in.Id.Hex(),
+		LimitId:         in.LimitId.Hex(),
+		MerchantId:      in.MerchantId.Hex(),
+		ProjectId:       in.ProjectId.Hex(),
+		OrderId:         in.OrderId.Hex(),
+		PaymentMethodId: in.PaymentMethodId,
+		Key:             in.Key,
+		Stage:           in.Stage,
+		Window:          in.Window,
+		Count:           in.Count,
+		Amount:          in.Amount,
+		MaxCount:        in.MaxCount,
+		MaxAmount:       in.MaxAmount,
+		Currency:        in.Currency,
+	}
+
+	out.CreatedAt, err = ptypes.TimestampProto(in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
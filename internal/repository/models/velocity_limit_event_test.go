@@ -0,0 +1,137 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type VelocityLimitEventTestSuite struct {
+	suite.Suite
+	mapper velocityLimitEventMapper
+}
+
+func TestVelocityLimitEventTestSuite(t *testing.T) {
+	suite.Run(t, new(VelocityLimitEventTestSuite))
+}
+
+func (suite *VelocityLimitEventTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *VelocityLimitEventTestSuite) getObject() *pkg.VelocityLimitEvent {
+	return &pkg.VelocityLimitEvent{
+		LimitId:    primitive.NewObjectID().Hex(),
+		MerchantId: primitive.NewObjectID().Hex(),
+		ProjectId:  primitive.NewObjectID().Hex(),
+		OrderId:    primitive.NewObjectID().Hex(),
+	}
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_NewVelocityLimitEventMapper() {
+	mapper := NewVelocityLimitEventMapper()
+	assert.IsType(suite.T(), &velocityLimitEventMapper{}, mapper)
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapObjectToMgo_Ok() {
+	original := &pkg.VelocityLimitEvent{
+		Id:              primitive.NewObjectID().Hex(),
+		LimitId:         primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		ProjectId:       primitive.NewObjectID().Hex(),
+		OrderId:         primitive.NewObjectID().Hex(),
+		PaymentMethodId: primitive.NewObjectID().Hex(),
+		Key:             pkg.VelocityLimitKeyCustomer,
+		Stage:           pkg.VelocityLimitStagePayment,
+		Window:          3600,
+		Count:           4,
+		Amount:          400,
+		MaxCount:        3,
+		MaxAmount:       1000,
+		Currency:        "USD",
+		CreatedAt:       ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.VelocityLimitEvent))
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	mgo, err := suite.mapper.MapObjectToMgo(suite.getObject())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoVelocityLimitEvent).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoVelocityLimitEvent).CreatedAt.IsZero())
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapObjectToMgo_Error_Id() {
+	original := suite.getObject()
+	original.Id = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapObjectToMgo_Error_OrderId() {
+	original := suite.getObject()
+	original.OrderId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapObjectToMgo_Error_MerchantId() {
+	original := suite.getObject()
+	original.MerchantId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapObjectToMgo_Error_ProjectId() {
+	original := suite.getObject()
+	original.ProjectId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapObjectToMgo_Error_LimitId() {
+	original := suite.getObject()
+	original.LimitId = "test"
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapObjectToMgo_Error_Dates() {
+	original := suite.getObject()
+	original.CreatedAt = &timestamp.Timestamp{Seconds: -1, Nanos: -1}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapMgoToObject_Ok() {
+	original := &MgoVelocityLimitEvent{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *VelocityLimitEventTestSuite) Test_VelocityLimitEvent_MapMgoToObject_Error_Dates() {
+	original := &MgoVelocityLimitEvent{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"github.com/bxcodec/faker"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type VelocityLimitTestSuite struct {
+	suite.Suite
+	mapper velocityLimitMapper
+}
+
+func TestVelocityLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(VelocityLimitTestSuite))
+}
+
+func (suite *VelocityLimitTestSuite) SetupTest() {
+	InitFakeCustomProviders()
+}
+
+func (suite *VelocityLimitTestSuite) Test_VelocityLimit_NewVelocityLimitMapper() {
+	mapper := NewVelocityLimitMapper()
+	assert.IsType(suite.T(), &velocityLimitMapper{}, mapper)
+}
+
+func (suite *VelocityLimitTestSuite) Test_VelocityLimit_MapObjectToMgo_Ok() {
+	original := &pkg.VelocityLimit{
+		Id:              primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		PaymentMethodId: primitive.NewObjectID().Hex(),
+		Key:             pkg.VelocityLimitKeyCard,
+		Window:          3600,
+		MaxCount:        3,
+		MaxAmount:       1000,
+		Currency:        "USD",
+		Enabled:         true,
+		CreatedAt:       ptypes.TimestampNow(),
+		UpdatedAt:       ptypes.TimestampNow(),
+	}
+
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), mgo)
+
+	obj, err := suite.mapper.MapMgoToObject(mgo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), original, obj.(*pkg.VelocityLimit))
+}
+
+func (suite *VelocityLimitTestSuite) Test_VelocityLimit_MapObjectToMgo_Ok_EmptyIdAndDates() {
+	original := &pkg.VelocityLimit{}
+	mgo, err := suite.mapper.MapObjectToMgo(original)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), mgo.(*MgoVelocityLimit).Id.IsZero())
+	assert.False(suite.T(), mgo.(*MgoVelocityLimit).CreatedAt.IsZero())
+	assert.False(suite.T(), mgo.(*MgoVelocityLimit).UpdatedAt.IsZero())
+}
+
+func (suite *VelocityLimitTestSuite) Test_VelocityLimit_MapObjectToMgo_Error_Id() {
+	original := &pkg.VelocityLimit{
+		Id: "test",
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VelocityLimitTestSuite) Test_VelocityLimit_MapObjectToMgo_Error_Dates() {
+	original := &pkg.VelocityLimit{
+		CreatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err := suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+
+	original = &pkg.VelocityLimit{
+		UpdatedAt: &timestamp.Timestamp{Seconds: -1, Nanos: -1},
+	}
+	_, err = suite.mapper.MapObjectToMgo(original)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VelocityLimitTestSuite) Test_VelocityLimit_MapMgoToObject_Ok() {
+	original := &MgoVelocityLimit{}
+	err := faker.FakeData(original)
+	assert.NoError(suite.T(), err)
+
+	obj, err := suite.mapper.MapMgoToObject(original)
+	assert.NoError(suite.T(), err)
+
+	mgo, err := suite.mapper.MapObjectToMgo(obj)
+	assert.NoError(suite.T(), err)
+
+	assert.ObjectsAreEqualValues(original, mgo)
+}
+
+func (suite *VelocityLimitTestSuite) Test_VelocityLimit_MapMgoToObject_Error_Dates() {
+	original := &MgoVelocityLimit{CreatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err := suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+
+	original = &MgoVelocityLimit{UpdatedAt: time.Time{}.AddDate(-10000, 0, 0)}
+	_, err = suite.mapper.MapMgoToObject(original)
+	assert.Error(suite.T(), err)
+}
@@ -69,3 +69,21 @@ func (r notifyRegionRepository) FindByEmail(ctx context.Context, email string) (
 
 	return list, nil
 }
+
+func (r notifyRegionRepository) DeleteByEmail(ctx context.Context, email string) error {
+	query := bson.M{"email": email}
+	_, err := r.db.Collection(collectionNotifyNewRegion).DeleteMany(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionNotifyNewRegion),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationDelete),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
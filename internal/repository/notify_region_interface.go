@@ -12,4 +12,7 @@ type NotifyRegionRepositoryInterface interface {
 
 	// FindByEmail returns the notify new region by email.
 	FindByEmail(context.Context, string) ([]*billingpb.NotifyUserNewRegion, error)
+
+	// DeleteByEmail removes all notify new region by email.
+	DeleteByEmail(ctx context.Context, email string) error
 }
@@ -69,3 +69,21 @@ func (r notifySalesRepository) FindByEmail(ctx context.Context, email string) ([
 
 	return list, nil
 }
+
+func (r notifySalesRepository) DeleteByEmail(ctx context.Context, email string) error {
+	query := bson.M{"email": email}
+	_, err := r.db.Collection(collectionNotifySales).DeleteMany(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionNotifySales),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationDelete),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
@@ -12,4 +12,7 @@ type NotifySalesRepositoryInterface interface {
 
 	// FindByEmail returns the notify sales by email.
 	FindByEmail(context.Context, string) ([]*billingpb.NotifyUserSales, error)
+
+	// DeleteByEmail removes all notify sales by email.
+	DeleteByEmail(ctx context.Context, email string) error
 }
@@ -3,11 +3,14 @@ package repository
 import (
 	"context"
 	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	pkg2 "github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
 	"time"
@@ -232,6 +235,83 @@ func (h *orderRepository) GetByProjectOrderId(ctx context.Context, projectId, pr
 	return obj.(*billingpb.Order), nil
 }
 
+func (h *orderRepository) FindByUser(
+	ctx context.Context,
+	customerIds []string,
+	email, phone string,
+) ([]*billingpb.Order, error) {
+	var subQuery []bson.M
+
+	if len(customerIds) > 0 {
+		subQuery = append(subQuery, bson.M{"user.id": bson.M{"$in": customerIds}})
+	}
+
+	if email != "" {
+		subQuery = append(subQuery, bson.M{"user.email": email})
+
+		if index := models.GetFieldIndex(pkg.PersonalDataFieldEmail, email); index != "" {
+			subQuery = append(subQuery, bson.M{"user_email_index": index})
+		}
+	}
+
+	if phone != "" {
+		subQuery = append(subQuery, bson.M{"user.phone": phone})
+
+		if index := models.GetFieldIndex(pkg.PersonalDataFieldPhone, phone); index != "" {
+			subQuery = append(subQuery, bson.M{"user_phone_index": index})
+		}
+	}
+
+	if len(subQuery) <= 0 {
+		return []*billingpb.Order{}, nil
+	}
+
+	query := bson.M{"$or": subQuery}
+	cursor, err := h.db.Collection(CollectionOrder).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrder),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoOrder
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrder),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	orders := make([]*billingpb.Order, len(list))
+
+	for i, mgo := range list {
+		obj, err := h.mapper.MapMgoToObject(mgo)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+			)
+			return nil, err
+		}
+
+		orders[i] = obj.(*billingpb.Order)
+	}
+
+	return orders, nil
+}
+
 func (h *orderRepository) UpdateOrderView(ctx context.Context, ids []string) error {
 	defer helper.TimeTrack(time.Now(), "updateOrderView")
 
@@ -3263,6 +3343,8 @@ func (h *orderRepository) UpdateOrderView(ctx context.Context, ids []string) err
 				"amount_before_vat":    "$private_amount",
 				"currency":             1,
 				"user":                 1,
+				"user_email_index":     1,
+				"user_phone_index":     1,
 				"billing_address":      1,
 				"payment_method":       1,
 				"country_code":         1,
@@ -3406,3 +3488,128 @@ func (h *orderRepository) UpdateOrderView(ctx context.Context, ids []string) err
 
 	return nil
 }
+
+func (h *orderRepository) FindAfter(ctx context.Context, afterId string, limit int64) ([]*billingpb.Order, error) {
+	query := bson.M{}
+
+	if afterId != "" {
+		oid, err := primitive.ObjectIDFromHex(afterId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrder),
+				zap.String(pkg.ErrorDatabaseFieldQuery, afterId),
+			)
+			return nil, err
+		}
+
+		query["_id"] = bson.M{"$gt": oid}
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(limit)
+	cursor, err := h.db.Collection(CollectionOrder).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrder),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoOrder
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrder),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	result := make([]*billingpb.Order, len(list))
+
+	for i, item := range list {
+		obj, err := h.mapper.MapMgoToObject(item)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, item),
+			)
+			return nil, err
+		}
+
+		result[i] = obj.(*billingpb.Order)
+	}
+
+	return result, nil
+}
+
+func (h *orderRepository) GetMerchantRiskDeclines(
+	ctx context.Context,
+	from, to time.Time,
+) ([]*pkg2.MerchantRiskDeclineQueryResItem, error) {
+	var items []*pkg2.MerchantRiskDeclineQueryResItem
+
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"created_at":    bson.M{"$gte": from, "$lte": to},
+				"type":          pkg.OrderTypeOrder,
+				"is_production": true,
+				"status": bson.M{"$in": []string{
+					recurringpb.OrderPublicStatusProcessed,
+					recurringpb.OrderPublicStatusRefunded,
+					recurringpb.OrderPublicStatusChargeback,
+					recurringpb.OrderPublicStatusRejected,
+				}},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":           "$project.merchant_id",
+				"attempt_count": bson.M{"$sum": 1},
+				"decline_count": bson.M{"$sum": bson.M{"$cond": []interface{}{
+					bson.M{"$eq": []interface{}{"$status", recurringpb.OrderPublicStatusRejected}},
+					1,
+					0,
+				}}},
+			},
+		},
+	}
+
+	cursor, err := h.db.Collection(CollectionOrder).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrder),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrder),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
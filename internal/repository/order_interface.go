@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"time"
 )
 
 // OrderRepositoryInterface is abstraction layer for working with order and representation in database.
@@ -27,4 +29,16 @@ type OrderRepositoryInterface interface {
 
 	// UpdateOrderView updates orders into order view.
 	UpdateOrderView(context.Context, []string) error
+
+	// FindByUser returns orders of customers by their identifiers, or orders having the email or the phone of the user.
+	// Empty values are ignored.
+	FindByUser(ctx context.Context, customerIds []string, email, phone string) ([]*billingpb.Order, error)
+
+	// FindAfter returns a limited list of orders following the order with the identifier sorted by identifier.
+	// All orders are returned from the beginning if the identifier is empty.
+	FindAfter(ctx context.Context, afterId string, limit int64) ([]*billingpb.Order, error)
+
+	// GetMerchantRiskDeclines returns counts of completed and declined payment attempts of merchants created
+	// in the period. Declined payments don't have accounting entries and aren't present in the view of orders.
+	GetMerchantRiskDeclines(ctx context.Context, from, to time.Time) ([]*pkg.MerchantRiskDeclineQueryResItem, error)
 }
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionOrderReview = "order_review"
+)
+
+type orderReviewRepository repository
+
+// NewOrderReviewRepository create and return an object for working with the order review repository.
+// The returned object implements the OrderReviewRepositoryInterface interface.
+func NewOrderReviewRepository(db mongodb.SourceInterface) OrderReviewRepositoryInterface {
+	s := &orderReviewRepository{db: db, mapper: models.NewOrderReviewMapper()}
+	return s
+}
+
+func (r *orderReviewRepository) Upsert(ctx context.Context, review *pkg.OrderReview) error {
+	mgo, err := r.mapper.MapObjectToMgo(review)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, review),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoOrderReview).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionOrderReview).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	review.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *orderReviewRepository) GetById(ctx context.Context, id string) (*pkg.OrderReview, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *orderReviewRepository) GetPendingByOrderId(ctx context.Context, orderId string) (*pkg.OrderReview, error) {
+	oid, err := primitive.ObjectIDFromHex(orderId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.String(pkg.ErrorDatabaseFieldQuery, orderId),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"order_id": oid, "status": pkg.OrderReviewStatusPending})
+}
+
+func (r *orderReviewRepository) Claim(
+	ctx context.Context,
+	id, userId string,
+	expiresAt time.Time,
+) (*pkg.OrderReview, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{
+		"_id":    oid,
+		"status": pkg.OrderReviewStatusPending,
+		"$or": []bson.M{
+			{"claimed_by": bson.M{"$in": []string{"", userId}}},
+			{"claim_expires_at": bson.M{"$lt": time.Now()}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"claimed_by":       userId,
+			"claim_expires_at": expiresAt,
+			"updated_at":       time.Now(),
+		},
+	}
+
+	mgo := &models.MgoOrderReview{}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.db.Collection(collectionOrderReview).FindOneAndUpdate(ctx, query, update, opts).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			zap.Any(pkg.ErrorDatabaseFieldOperationUpdate, update),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.OrderReview), nil
+}
+
+func (r *orderReviewRepository) Find(
+	ctx context.Context,
+	merchantId, status string,
+	overdue bool,
+	offset, limit int64,
+) ([]*pkg.OrderReview, error) {
+	query, err := r.getListQuery(merchantId, status, overdue)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the review with the closest deadline is the first
+	opts := options.Find().
+		SetSort(bson.M{"sla_due_at": 1}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.db.Collection(collectionOrderReview).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoOrderReview
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.OrderReview, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.OrderReview)
+	}
+
+	return objs, nil
+}
+
+func (r *orderReviewRepository) FindCount(
+	ctx context.Context,
+	merchantId, status string,
+	overdue bool,
+) (int64, error) {
+	query, err := r.getListQuery(merchantId, status, overdue)
+
+	if err != nil {
+		return int64(0), err
+	}
+
+	count, err := r.db.Collection(collectionOrderReview).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *orderReviewRepository) getListQuery(merchantId, status string, overdue bool) (bson.M, error) {
+	query := bson.M{}
+
+	if merchantId != "" {
+		oid, err := primitive.ObjectIDFromHex(merchantId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+				zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+			)
+			return nil, err
+		}
+
+		query["merchant_id"] = oid
+	}
+
+	if status != "" {
+		query["status"] = status
+	}
+
+	if overdue {
+		query["status"] = pkg.OrderReviewStatusPending
+		query["sla_due_at"] = bson.M{"$lt": time.Now()}
+	}
+
+	return query, nil
+}
+
+func (r *orderReviewRepository) findOne(ctx context.Context, query bson.M) (*pkg.OrderReview, error) {
+	mgo := &models.MgoOrderReview{}
+	err := r.db.Collection(collectionOrderReview).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionOrderReview),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.OrderReview), nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"time"
+)
+
+// OrderReviewRepositoryInterface is abstraction layer for working with manual reviews of risky orders
+// and representation in database.
+type OrderReviewRepositoryInterface interface {
+	// Upsert adds or updates the review.
+	Upsert(ctx context.Context, review *pkg.OrderReview) error
+
+	// GetById returns the review by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.OrderReview, error)
+
+	// GetPendingByOrderId returns the not resolved review of the order.
+	GetPendingByOrderId(ctx context.Context, orderId string) (*pkg.OrderReview, error)
+
+	// Claim assigns the pending review to the user until the passed time. The review is claimed when it isn't
+	// claimed by another user or the claim of another user is expired.
+	Claim(ctx context.Context, id, userId string, expiresAt time.Time) (*pkg.OrderReview, error)
+
+	// Find returns reviews filtered by the merchant and the status if they're passed, pending reviews with
+	// the expired deadline are returned only when overdue is set.
+	Find(ctx context.Context, merchantId, status string, overdue bool, offset, limit int64) ([]*pkg.OrderReview, error)
+
+	// FindCount returns count of reviews filtered by the same conditions as Find.
+	FindCount(ctx context.Context, merchantId, status string, overdue bool) (int64, error)
+}
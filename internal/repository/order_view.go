@@ -398,7 +398,6 @@ func (r *orderViewRepository) royaltySummaryItemPrecise(item *billingpb.RoyaltyR
 	item.PayoutAmount = tools.ToPrecise(item.PayoutAmount)
 }
 
-
 func (r *orderViewRepository) GetPrivateOrderBy(
 	ctx context.Context,
 	id, uuid, merchantId string,
@@ -903,18 +902,44 @@ func (r *orderViewRepository) GetTurnoverSummary(
 }
 
 func (r *orderViewRepository) GetRoyaltyForMerchants(
-	ctx context.Context, statuses []string, from time.Time, to time.Time,
+	ctx context.Context, statuses []string, from, to time.Time, merchantIds, excludeMerchantIds []string,
 ) ([]*pkg2.RoyaltyReportMerchant, error) {
 	var merchants []*pkg2.RoyaltyReportMerchant
 
+	match := bson.M{
+		"pm_order_close_date": bson.M{"$gte": from, "$lte": to},
+		"status":              bson.M{"$in": statuses},
+		"is_production":       true,
+	}
+
+	if len(merchantIds) > 0 || len(excludeMerchantIds) > 0 {
+		merchantQuery := bson.M{}
+
+		if len(merchantIds) > 0 {
+			oids, err := r.getMerchantObjectIds(merchantIds)
+
+			if err != nil {
+				return nil, err
+			}
+
+			merchantQuery["$in"] = oids
+		}
+
+		if len(excludeMerchantIds) > 0 {
+			oids, err := r.getMerchantObjectIds(excludeMerchantIds)
+
+			if err != nil {
+				return nil, err
+			}
+
+			merchantQuery["$nin"] = oids
+		}
+
+		match["project.merchant_id"] = merchantQuery
+	}
+
 	query := []bson.M{
-		{
-			"$match": bson.M{
-				"pm_order_close_date": bson.M{"$gte": from, "$lte": to},
-				"status":              bson.M{"$in": statuses},
-				"is_production":       true,
-			},
-		},
+		{"$match": match},
 		{"$project": bson.M{"project.merchant_id": true}},
 		{"$group": bson.M{"_id": "$project.merchant_id"}},
 	}
@@ -946,6 +971,112 @@ func (r *orderViewRepository) GetRoyaltyForMerchants(
 	return merchants, nil
 }
 
+func (r *orderViewRepository) GetMerchantRiskSales(
+	ctx context.Context, from, to, dayFrom time.Time,
+) ([]*pkg2.MerchantRiskSalesQueryResItem, error) {
+	var items []*pkg2.MerchantRiskSalesQueryResItem
+
+	isSale := bson.M{"$eq": []interface{}{"$type", pkg.OrderTypeOrder}}
+	isRefund := bson.M{"$eq": []interface{}{"$type", pkg.OrderTypeRefund}}
+
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"created_at":    bson.M{"$gte": from, "$lte": to},
+				"is_production": true,
+				"status": bson.M{"$in": []string{
+					recurringpb.OrderPublicStatusProcessed,
+					recurringpb.OrderPublicStatusRefunded,
+					recurringpb.OrderPublicStatusChargeback,
+				}},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":         "$project.merchant_id",
+				"sales_count": bson.M{"$sum": bson.M{"$cond": []interface{}{isSale, 1, 0}}},
+				"refund_count": bson.M{"$sum": bson.M{"$cond": []interface{}{
+					bson.M{"$and": []interface{}{
+						isRefund,
+						bson.M{"$eq": []interface{}{"$status", recurringpb.OrderPublicStatusRefunded}},
+					}},
+					1,
+					0,
+				}}},
+				"chargeback_count": bson.M{"$sum": bson.M{"$cond": []interface{}{
+					bson.M{"$and": []interface{}{
+						isRefund,
+						bson.M{"$eq": []interface{}{"$status", recurringpb.OrderPublicStatusChargeback}},
+					}},
+					1,
+					0,
+				}}},
+				"sales_amount": bson.M{"$sum": bson.M{"$cond": []interface{}{
+					isSale,
+					"$payment_gross_revenue.amount",
+					0,
+				}}},
+				"daily_sales_amount": bson.M{"$sum": bson.M{"$cond": []interface{}{
+					bson.M{"$and": []interface{}{
+						isSale,
+						bson.M{"$gte": []interface{}{"$created_at", dayFrom}},
+					}},
+					"$payment_gross_revenue.amount",
+					0,
+				}}},
+			},
+		},
+	}
+
+	cursor, err := r.db.Collection(CollectionOrderView).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrderView),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &items)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrderView),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *orderViewRepository) getMerchantObjectIds(merchantIds []string) ([]primitive.ObjectID, error) {
+	oids := make([]primitive.ObjectID, len(merchantIds))
+
+	for i, merchantId := range merchantIds {
+		oid, err := primitive.ObjectIDFromHex(merchantId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, CollectionOrderView),
+				zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+			)
+			return nil, err
+		}
+
+		oids[i] = oid
+	}
+
+	return oids, nil
+}
+
 func (r *orderViewRepository) GetById(ctx context.Context, id string) (*billingpb.OrderViewPublic, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 
@@ -56,5 +56,11 @@ type OrderViewRepositoryInterface interface {
 	GetTurnoverSummary(context.Context, string, string, string, time.Time, time.Time) ([]*pkg.TurnoverQueryResItem, error)
 
 	// GetRoyaltyForMerchants returns orders for merchants royal report by statuses and dates.
-	GetRoyaltyForMerchants(context.Context, []string, time.Time, time.Time) ([]*pkg.RoyaltyReportMerchant, error)
+	// The search can be limited to the passed merchants or can skip the excluded merchants, for example merchants
+	// with own royalty report schedule.
+	GetRoyaltyForMerchants(ctx context.Context, statuses []string, from, to time.Time, merchantIds, excludeMerchantIds []string) ([]*pkg.RoyaltyReportMerchant, error)
+
+	// GetMerchantRiskSales returns counts of sales, refunds and chargebacks and amounts of sales of merchants
+	// created in the period, the amount of daily sales is the amount of sales created after dayFrom.
+	GetMerchantRiskSales(ctx context.Context, from, to, dayFrom time.Time) ([]*pkg.MerchantRiskSalesQueryResItem, error)
 }
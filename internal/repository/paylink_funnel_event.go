@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	pkg2 "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionPaylinkFunnelEvent = "paylink_funnel_event"
+)
+
+type paylinkFunnelEventRepository repository
+
+// NewPaylinkFunnelEventRepository create and return an object for working with the paylink funnel event repository.
+// The returned object implements the PaylinkFunnelEventRepositoryInterface interface.
+func NewPaylinkFunnelEventRepository(db mongodb.SourceInterface) PaylinkFunnelEventRepositoryInterface {
+	s := &paylinkFunnelEventRepository{db: db, mapper: models.NewPaylinkFunnelEventMapper()}
+	return s
+}
+
+func (r *paylinkFunnelEventRepository) Insert(ctx context.Context, event *pkg.PaylinkFunnelEvent) error {
+	mgo, err := r.mapper.MapObjectToMgo(event)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, event),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionPaylinkFunnelEvent).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPaylinkFunnelEvent),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	event.Id = mgo.(*models.MgoPaylinkFunnelEvent).Id.Hex()
+
+	return nil
+}
+
+func (r *paylinkFunnelEventRepository) GetStat(
+	ctx context.Context,
+	paylinkId string,
+	from, to int64,
+) ([]*pkg2.PaylinkFunnelQueryResItem, error) {
+	oid, err := primitive.ObjectIDFromHex(paylinkId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPaylinkFunnelEvent),
+			zap.String(pkg.ErrorDatabaseFieldQuery, paylinkId),
+		)
+		return nil, err
+	}
+
+	match := bson.M{"paylink_id": oid}
+
+	if from > 0 || to > 0 {
+		date := bson.M{}
+		if from > 0 {
+			date["$gte"] = time.Unix(from, 0)
+		}
+		if to > 0 {
+			date["$lte"] = time.Unix(to, 0)
+		}
+		match["created_at"] = date
+	}
+
+	// events of the same order or the same visitor are counted once on each step
+	key := bson.M{
+		"$cond": bson.A{
+			bson.M{"$ne": bson.A{"$order_id", ""}},
+			"$order_id",
+			bson.M{
+				"$cond": bson.A{
+					bson.M{"$ne": bson.A{"$visitor_id", ""}},
+					"$visitor_id",
+					"$_id",
+				},
+			},
+		},
+	}
+
+	query := []bson.M{
+		{
+			"$match": match,
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{"variant_id": "$variant_id", "step": "$step", "key": key},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":   bson.M{"variant_id": "$_id.variant_id", "step": "$_id.step"},
+				"count": bson.M{"$sum": 1},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":        0,
+				"variant_id": "$_id.variant_id",
+				"step":       "$_id.step",
+				"count":      1,
+			},
+		},
+	}
+
+	cursor, err := r.db.Collection(collectionPaylinkFunnelEvent).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPaylinkFunnelEvent),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*pkg2.PaylinkFunnelQueryResItem
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPaylinkFunnelEvent),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *paylinkFunnelEventRepository) Find(
+	ctx context.Context,
+	orderIds, visitorIds []string,
+) ([]*pkg.PaylinkFunnelEvent, error) {
+	var subQuery []bson.M
+
+	if len(orderIds) > 0 {
+		subQuery = append(subQuery, bson.M{"order_id": bson.M{"$in": orderIds}})
+	}
+
+	if len(visitorIds) > 0 {
+		subQuery = append(subQuery, bson.M{"visitor_id": bson.M{"$in": visitorIds}})
+	}
+
+	if len(subQuery) <= 0 {
+		return []*pkg.PaylinkFunnelEvent{}, nil
+	}
+
+	query := bson.M{"$or": subQuery}
+	cursor, err := r.db.Collection(collectionPaylinkFunnelEvent).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPaylinkFunnelEvent),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoPaylinkFunnelEvent
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPaylinkFunnelEvent),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.PaylinkFunnelEvent, len(list))
+
+	for i, mgo := range list {
+		obj, err := r.mapper.MapMgoToObject(mgo)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+			)
+			return nil, err
+		}
+
+		objs[i] = obj.(*pkg.PaylinkFunnelEvent)
+	}
+
+	return objs, nil
+}
+
+func (r *paylinkFunnelEventRepository) ReplaceVisitorId(ctx context.Context, visitorId, newVisitorId string) error {
+	filter := bson.M{"visitor_id": visitorId}
+	update := bson.M{"$set": bson.M{"visitor_id": newVisitorId}}
+	_, err := r.db.Collection(collectionPaylinkFunnelEvent).UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPaylinkFunnelEvent),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, update),
+		)
+		return err
+	}
+
+	return nil
+}
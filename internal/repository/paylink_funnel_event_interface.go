@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	pkg2 "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// PaylinkFunnelEventRepositoryInterface is abstraction layer for working with funnel events of paylinks
+// and representation in database.
+type PaylinkFunnelEventRepositoryInterface interface {
+	// Insert adds the funnel event to the collection.
+	Insert(ctx context.Context, event *pkg.PaylinkFunnelEvent) error
+
+	// GetStat returns counts of unique visitors and orders of the paylink grouped by variant and funnel step
+	// between dates, dates are ignored if they are zero.
+	GetStat(ctx context.Context, paylinkId string, from, to int64) ([]*pkg2.PaylinkFunnelQueryResItem, error)
+
+	// Find returns funnel events of orders or visitors by their identifiers.
+	Find(ctx context.Context, orderIds, visitorIds []string) ([]*pkg.PaylinkFunnelEvent, error)
+
+	// ReplaceVisitorId replaces the identifier of the visitor in all funnel events.
+	ReplaceVisitorId(ctx context.Context, visitorId, newVisitorId string) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// PaylinkVariantRepositoryInterface is abstraction layer for working with variants of paylinks
+// and representation in database.
+type PaylinkVariantRepositoryInterface interface {
+	// Upsert adds or updates the paylink variant.
+	Upsert(ctx context.Context, variant *pkg.PaylinkVariant) error
+
+	// GetById returns the paylink variant by unique identity, deleted variants aren't returned.
+	GetById(ctx context.Context, id string) (*pkg.PaylinkVariant, error)
+
+	// FindByPaylinkId returns not deleted variants of the paylink sorted by creation date.
+	FindByPaylinkId(ctx context.Context, paylinkId string) ([]*pkg.PaylinkVariant, error)
+}
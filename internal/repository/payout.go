@@ -251,6 +251,7 @@ func (r *payoutRepository) GetBalanceAmount(ctx context.Context, merchantId, cur
 
 	payoutDocumentStatusActive := []string{
 		pkg.PayoutDocumentStatusPending,
+		pkg.PayoutDocumentStatusInBatch,
 		pkg.PayoutDocumentStatusPaid,
 	}
 	query := []bson.M{
@@ -325,6 +326,7 @@ func (r *payoutRepository) GetLast(ctx context.Context, merchantId, currency str
 
 	payoutDocumentStatusActive := []string{
 		pkg.PayoutDocumentStatusPending,
+		pkg.PayoutDocumentStatusInBatch,
 		pkg.PayoutDocumentStatusPaid,
 	}
 	query := bson.M{
@@ -495,6 +497,65 @@ func (r *payoutRepository) Find(
 	return objs, nil
 }
 
+func (r *payoutRepository) FindByOperatingCompany(
+	ctx context.Context,
+	operatingCompanyId, currency string,
+	status []string,
+) ([]*billingpb.PayoutDocument, error) {
+	query := bson.M{"operating_company_id": operatingCompanyId}
+
+	if currency != "" {
+		query["currency"] = currency
+	}
+
+	if len(status) > 0 {
+		query["status"] = bson.M{"$in": status}
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.db.Collection(collectionPayoutDocuments).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutDocuments),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var mgoPayoutDocuments []*models.MgoPayoutDocument
+	err = cursor.All(ctx, &mgoPayoutDocuments)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutDocuments),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*billingpb.PayoutDocument, len(mgoPayoutDocuments))
+
+	for i, obj := range mgoPayoutDocuments {
+		v, err := r.mapper.MapMgoToObject(obj)
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+		objs[i] = v.(*billingpb.PayoutDocument)
+	}
+
+	return objs, nil
+}
+
 func (r *payoutRepository) updateCaches(pd *billingpb.PayoutDocument) (err error) {
 	key1 := fmt.Sprintf(cacheKeyPayoutDocument, pd.Id)
 	key2 := fmt.Sprintf(cacheKeyPayoutDocumentMerchant, pd.Id, pd.MerchantId)
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionPayoutBatch = "payout_batch"
+)
+
+type payoutBatchRepository repository
+
+// NewPayoutBatchRepository create and return an object for working with the payout batch repository.
+// The returned object implements the PayoutBatchRepositoryInterface interface.
+func NewPayoutBatchRepository(db mongodb.SourceInterface) PayoutBatchRepositoryInterface {
+	s := &payoutBatchRepository{db: db, mapper: models.NewPayoutBatchMapper()}
+	return s
+}
+
+func (r *payoutBatchRepository) Insert(ctx context.Context, batch *pkg.PayoutBatch) error {
+	mgo, err := r.mapper.MapObjectToMgo(batch)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, batch),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionPayoutBatch).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	batch.Id = mgo.(*models.MgoPayoutBatch).Id.Hex()
+
+	return nil
+}
+
+func (r *payoutBatchRepository) Update(ctx context.Context, batch *pkg.PayoutBatch) error {
+	oid, err := primitive.ObjectIDFromHex(batch.Id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.String(pkg.ErrorDatabaseFieldQuery, batch.Id),
+		)
+		return err
+	}
+
+	mgo, err := r.mapper.MapObjectToMgo(batch)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, batch),
+		)
+		return err
+	}
+
+	filter := bson.M{"_id": oid}
+	_, err = r.db.Collection(collectionPayoutBatch).ReplaceOne(ctx, filter, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *payoutBatchRepository) GetById(ctx context.Context, id string) (*pkg.PayoutBatch, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *payoutBatchRepository) GetByPayoutDocumentId(
+	ctx context.Context,
+	payoutDocumentId string,
+) (*pkg.PayoutBatch, error) {
+	return r.findOne(ctx, bson.M{"payout_document_ids": payoutDocumentId})
+}
+
+func (r *payoutBatchRepository) findOne(ctx context.Context, query bson.M) (*pkg.PayoutBatch, error) {
+	mgo := &models.MgoPayoutBatch{}
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	err := r.db.Collection(collectionPayoutBatch).FindOne(ctx, query, opts).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPayoutBatch),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.PayoutBatch), nil
+}
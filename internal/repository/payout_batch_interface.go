@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// PayoutBatchRepositoryInterface is abstraction layer for working with payout batches and representation in database.
+type PayoutBatchRepositoryInterface interface {
+	// Insert adds the payout batch to the collection.
+	Insert(ctx context.Context, batch *pkg.PayoutBatch) error
+
+	// Update updates the payout batch in the collection.
+	Update(ctx context.Context, batch *pkg.PayoutBatch) error
+
+	// GetById returns the payout batch by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.PayoutBatch, error)
+
+	// GetByPayoutDocumentId returns the latest payout batch containing the payout document.
+	GetByPayoutDocumentId(ctx context.Context, payoutDocumentId string) (*pkg.PayoutBatch, error)
+}
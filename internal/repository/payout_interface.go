@@ -30,4 +30,8 @@ type PayoutRepositoryInterface interface {
 
 	// FindCount return count of payouts by merchant, statuses and dates from/to.
 	FindCount(context.Context, string, []string, int64, int64) (int64, error)
+
+	// FindByOperatingCompany returns payouts of the operating company by currency and statuses.
+	// Empty currency means payouts in all currencies.
+	FindByOperatingCompany(context.Context, string, string, []string) ([]*billingpb.PayoutDocument, error)
 }
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionPriceHistory = "price_history"
+)
+
+type priceHistoryRepository repository
+
+// NewPriceHistoryRepository create and return an object for working with the price history repository.
+// The returned object implements the PriceHistoryRepositoryInterface interface.
+func NewPriceHistoryRepository(db mongodb.SourceInterface) PriceHistoryRepositoryInterface {
+	s := &priceHistoryRepository{db: db, mapper: models.NewPriceHistoryMapper()}
+	return s
+}
+
+func (r *priceHistoryRepository) Insert(ctx context.Context, history *pkg.PriceHistory) error {
+	mgo, err := r.mapper.MapObjectToMgo(history)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, history),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionPriceHistory).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceHistory),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	history.Id = mgo.(*models.MgoPriceHistory).Id.Hex()
+
+	return nil
+}
+
+func (r *priceHistoryRepository) FindByProductId(ctx context.Context, productId string) ([]*pkg.PriceHistory, error) {
+	productOid, err := primitive.ObjectIDFromHex(productId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceHistory),
+			zap.String(pkg.ErrorDatabaseFieldQuery, productId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"product_id": productOid}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.db.Collection(collectionPriceHistory).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceHistory),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoPriceHistory
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceHistory),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return r.mapList(list)
+}
+
+func (r *priceHistoryRepository) FindLastByProductIds(
+	ctx context.Context,
+	productIds []string,
+	at time.Time,
+) ([]*pkg.PriceHistory, error) {
+	var productOids []primitive.ObjectID
+
+	for _, id := range productIds {
+		oid, err := primitive.ObjectIDFromHex(id)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceHistory),
+				zap.String(pkg.ErrorDatabaseFieldQuery, id),
+			)
+			return nil, err
+		}
+
+		productOids = append(productOids, oid)
+	}
+
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"product_id": bson.M{"$in": productOids},
+				"created_at": bson.M{"$lte": at},
+			},
+		},
+		{
+			"$sort": bson.M{"created_at": -1},
+		},
+		{
+			"$group": bson.M{
+				"_id":  "$product_id",
+				"last": bson.M{"$first": "$$ROOT"},
+			},
+		},
+		{
+			"$replaceRoot": bson.M{"newRoot": "$last"},
+		},
+	}
+
+	cursor, err := r.db.Collection(collectionPriceHistory).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceHistory),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoPriceHistory
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceHistory),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	return r.mapList(list)
+}
+
+func (r *priceHistoryRepository) mapList(list []*models.MgoPriceHistory) ([]*pkg.PriceHistory, error) {
+	objs := make([]*pkg.PriceHistory, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.PriceHistory)
+	}
+
+	return objs, nil
+}
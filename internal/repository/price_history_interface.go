@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"time"
+)
+
+// PriceHistoryRepositoryInterface is abstraction layer for working with history of prices of products
+// and key products and representation in database.
+type PriceHistoryRepositoryInterface interface {
+	// Insert adds the snapshot of prices.
+	Insert(ctx context.Context, history *pkg.PriceHistory) error
+
+	// FindByProductId returns snapshots of prices of the product sorted from the newest.
+	FindByProductId(ctx context.Context, productId string) ([]*pkg.PriceHistory, error)
+
+	// FindLastByProductIds returns the latest snapshot of prices saved before the time for each product.
+	FindLastByProductIds(ctx context.Context, productIds []string, at time.Time) ([]*pkg.PriceHistory, error)
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionPriceSchedule = "price_schedule"
+)
+
+type priceScheduleRepository repository
+
+// NewPriceScheduleRepository create and return an object for working with the price schedule repository.
+// The returned object implements the PriceScheduleRepositoryInterface interface.
+func NewPriceScheduleRepository(db mongodb.SourceInterface) PriceScheduleRepositoryInterface {
+	s := &priceScheduleRepository{db: db, mapper: models.NewPriceScheduleMapper()}
+	return s
+}
+
+func (r *priceScheduleRepository) Upsert(ctx context.Context, schedule *pkg.PriceSchedule) error {
+	mgo, err := r.mapper.MapObjectToMgo(schedule)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, schedule),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoPriceSchedule).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionPriceSchedule).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	schedule.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *priceScheduleRepository) GetById(ctx context.Context, id string) (*pkg.PriceSchedule, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid}
+	mgo := &models.MgoPriceSchedule{}
+	err = r.db.Collection(collectionPriceSchedule).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.PriceSchedule), nil
+}
+
+func (r *priceScheduleRepository) FindByProductId(ctx context.Context, productId string) ([]*pkg.PriceSchedule, error) {
+	productOid, err := primitive.ObjectIDFromHex(productId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+			zap.String(pkg.ErrorDatabaseFieldQuery, productId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"product_id": productOid}
+	opts := options.Find().SetSort(bson.M{"start_at": -1})
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *priceScheduleRepository) FindActiveByProductIds(
+	ctx context.Context,
+	productIds []string,
+	at time.Time,
+) ([]*pkg.PriceSchedule, error) {
+	var productOids []primitive.ObjectID
+
+	for _, id := range productIds {
+		oid, err := primitive.ObjectIDFromHex(id)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+				zap.String(pkg.ErrorDatabaseFieldQuery, id),
+			)
+			return nil, err
+		}
+
+		productOids = append(productOids, oid)
+	}
+
+	query := bson.M{
+		"product_id": bson.M{"$in": productOids},
+		"cancelled":  false,
+		"start_at":   bson.M{"$lte": at},
+		"end_at":     bson.M{"$gt": at},
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *priceScheduleRepository) CountOverlapping(
+	ctx context.Context,
+	productId, platformId string,
+	from, to time.Time,
+) (int64, error) {
+	productOid, err := primitive.ObjectIDFromHex(productId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+			zap.String(pkg.ErrorDatabaseFieldQuery, productId),
+		)
+		return int64(0), err
+	}
+
+	query := bson.M{
+		"product_id":  productOid,
+		"platform_id": platformId,
+		"cancelled":   false,
+		"start_at":    bson.M{"$lt": to},
+		"end_at":      bson.M{"$gt": from},
+	}
+	count, err := r.db.Collection(collectionPriceSchedule).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *priceScheduleRepository) find(
+	ctx context.Context,
+	query bson.M,
+	opts *options.FindOptions,
+) ([]*pkg.PriceSchedule, error) {
+	cursor, err := r.db.Collection(collectionPriceSchedule).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoPriceSchedule
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceSchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.PriceSchedule, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.PriceSchedule)
+	}
+
+	return objs, nil
+}
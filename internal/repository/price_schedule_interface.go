@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"time"
+)
+
+// PriceScheduleRepositoryInterface is abstraction layer for working with scheduled price changes of products
+// and key products and representation in database.
+type PriceScheduleRepositoryInterface interface {
+	// Upsert adds or updates the schedule.
+	Upsert(ctx context.Context, schedule *pkg.PriceSchedule) error
+
+	// GetById returns the schedule by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.PriceSchedule, error)
+
+	// FindByProductId returns all schedules of the product including cancelled.
+	FindByProductId(ctx context.Context, productId string) ([]*pkg.PriceSchedule, error)
+
+	// FindActiveByProductIds returns not cancelled schedules of products which are active at the time,
+	// schedules are sorted by creation date.
+	FindActiveByProductIds(ctx context.Context, productIds []string, at time.Time) ([]*pkg.PriceSchedule, error)
+
+	// CountOverlapping returns count of not cancelled schedules of the product and platform which intersect
+	// with the period.
+	CountOverlapping(ctx context.Context, productId, platformId string, from, to time.Time) (int64, error)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
 )
@@ -51,6 +52,38 @@ func (r *priceTableRepository) Insert(ctx context.Context, obj *billingpb.PriceT
 	return nil
 }
 
+func (r *priceTableRepository) Upsert(ctx context.Context, obj *billingpb.PriceTable) error {
+	mgo, err := r.mapper.MapObjectToMgo(obj)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+		)
+		return err
+	}
+
+	filter := bson.M{"currency": obj.Currency}
+	set := bson.M{"$set": bson.M{"range": mgo.(*models.MgoPriceTable).Ranges}}
+	opts := options.Update().SetUpsert(true)
+	_, err = r.db.Collection(collectionPriceTable).UpdateOne(ctx, filter, set, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTable),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+			zap.Any(pkg.ErrorDatabaseFieldSet, set),
+		)
+		return err
+	}
+
+	return nil
+}
+
 func (r priceTableRepository) GetByRegion(ctx context.Context, region string) (*billingpb.PriceTable, error) {
 	var mgo = &models.MgoPriceTable{}
 
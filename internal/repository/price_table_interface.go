@@ -10,6 +10,9 @@ type PriceTableRepositoryInterface interface {
 	// Insert adds the price table to the collection.
 	Insert(context.Context, *billingpb.PriceTable) error
 
+	// Upsert replaces the price table of the region or adds it if the region has no price table.
+	Upsert(context.Context, *billingpb.PriceTable) error
+
 	// GetByRegion returns the price table by region name.
 	GetByRegion(context.Context, string) (*billingpb.PriceTable, error)
 }
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionPriceTableRule = "price_table_rule"
+)
+
+type priceTableRuleRepository repository
+
+// NewPriceTableRuleRepository create and return an object for working with the price table rule repository.
+// The returned object implements the PriceTableRuleRepositoryInterface interface.
+func NewPriceTableRuleRepository(db mongodb.SourceInterface) PriceTableRuleRepositoryInterface {
+	s := &priceTableRuleRepository{db: db, mapper: models.NewPriceTableRuleMapper()}
+	return s
+}
+
+func (r *priceTableRuleRepository) Upsert(ctx context.Context, rule *pkg.PriceTableRule) error {
+	mgo, err := r.mapper.MapObjectToMgo(rule)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, rule),
+		)
+		return err
+	}
+
+	filter := bson.M{"region": rule.Region}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionPriceTableRule).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableRule),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	rule.Id = mgo.(*models.MgoPriceTableRule).Id.Hex()
+
+	return nil
+}
+
+func (r *priceTableRuleRepository) GetByRegion(ctx context.Context, region string) (*pkg.PriceTableRule, error) {
+	query := bson.M{"region": region}
+	mgo := &models.MgoPriceTableRule{}
+	err := r.db.Collection(collectionPriceTableRule).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableRule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.PriceTableRule), nil
+}
+
+func (r *priceTableRuleRepository) GetAll(ctx context.Context) ([]*pkg.PriceTableRule, error) {
+	query := bson.M{}
+	cursor, err := r.db.Collection(collectionPriceTableRule).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableRule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoPriceTableRule
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableRule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.PriceTableRule, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.PriceTableRule)
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// PriceTableRuleRepositoryInterface is abstraction layer for working with rules of calculation of price tables
+// and representation in database.
+type PriceTableRuleRepositoryInterface interface {
+	// Upsert add or update the rule of the region to the collection.
+	Upsert(context.Context, *pkg.PriceTableRule) error
+
+	// GetByRegion returns the rule by region name.
+	GetByRegion(context.Context, string) (*pkg.PriceTableRule, error)
+
+	// GetAll returns rules of all regions.
+	GetAll(context.Context) ([]*pkg.PriceTableRule, error)
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionPriceTableVersion = "price_table_version"
+)
+
+type priceTableVersionRepository repository
+
+// NewPriceTableVersionRepository create and return an object for working with the price table version repository.
+// The returned object implements the PriceTableVersionRepositoryInterface interface.
+func NewPriceTableVersionRepository(db mongodb.SourceInterface) PriceTableVersionRepositoryInterface {
+	s := &priceTableVersionRepository{db: db, mapper: models.NewPriceTableVersionMapper()}
+	return s
+}
+
+func (r *priceTableVersionRepository) Upsert(ctx context.Context, version *pkg.PriceTableVersion) error {
+	mgo, err := r.mapper.MapObjectToMgo(version)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, version),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoPriceTableVersion).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionPriceTableVersion).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableVersion),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	version.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *priceTableVersionRepository) GetById(ctx context.Context, id string) (*pkg.PriceTableVersion, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableVersion),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid}
+	mgo := &models.MgoPriceTableVersion{}
+	err = r.db.Collection(collectionPriceTableVersion).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableVersion),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.PriceTableVersion), nil
+}
+
+func (r *priceTableVersionRepository) Find(
+	ctx context.Context,
+	status string,
+	offset, limit int64,
+) ([]*pkg.PriceTableVersion, error) {
+	query := bson.M{}
+
+	if status != "" {
+		query["status"] = status
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"version": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.db.Collection(collectionPriceTableVersion).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableVersion),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoPriceTableVersion
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPriceTableVersion),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.PriceTableVersion, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.PriceTableVersion)
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// PriceTableVersionRepositoryInterface is abstraction layer for working with versions of price tables
+// and representation in database.
+type PriceTableVersionRepositoryInterface interface {
+	// Upsert add or update the version to the collection.
+	Upsert(context.Context, *pkg.PriceTableVersion) error
+
+	// GetById returns the version by unique identity.
+	GetById(context.Context, string) (*pkg.PriceTableVersion, error)
+
+	// Find returns versions by status sorted by version number in descending order with pagination,
+	// versions of any status are returned if status is empty.
+	Find(ctx context.Context, status string, offset, limit int64) ([]*pkg.PriceTableVersion, error)
+}
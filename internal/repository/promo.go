@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionPromo = "promo"
+)
+
+type promoRepository repository
+
+// NewPromoRepository create and return an object for working with the promo repository.
+// The returned object implements the PromoRepositoryInterface interface.
+func NewPromoRepository(db mongodb.SourceInterface) PromoRepositoryInterface {
+	s := &promoRepository{db: db, mapper: models.NewPromoMapper()}
+	return s
+}
+
+func (r *promoRepository) Upsert(ctx context.Context, promo *pkg.Promo) error {
+	mgo, err := r.mapper.MapObjectToMgo(promo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, promo),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoPromo).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionPromo).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	promo.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *promoRepository) GetById(ctx context.Context, id string) (*pkg.Promo, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid, "deleted": false}
+	mgo := &models.MgoPromo{}
+	err = r.db.Collection(collectionPromo).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.Promo), nil
+}
+
+func (r *promoRepository) GetByCode(ctx context.Context, projectId, code string) (*pkg.Promo, error) {
+	projectOid, err := primitive.ObjectIDFromHex(projectId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.String(pkg.ErrorDatabaseFieldQuery, projectId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{
+		"project_id": projectOid,
+		"code":       code,
+		"enabled":    true,
+		"deleted":    false,
+	}
+	mgo := &models.MgoPromo{}
+	err = r.db.Collection(collectionPromo).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.Promo), nil
+}
+
+func (r *promoRepository) CountByProjectIdCode(ctx context.Context, projectId, code string) (int64, error) {
+	projectOid, err := primitive.ObjectIDFromHex(projectId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.String(pkg.ErrorDatabaseFieldQuery, projectId),
+		)
+		return int64(0), err
+	}
+
+	query := bson.M{
+		"project_id": projectOid,
+		"code":       code,
+		"deleted":    false,
+	}
+	count, err := r.db.Collection(collectionPromo).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *promoRepository) FindCampaigns(ctx context.Context, projectId string) ([]*pkg.Promo, error) {
+	projectOid, err := primitive.ObjectIDFromHex(projectId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.String(pkg.ErrorDatabaseFieldQuery, projectId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{
+		"project_id": projectOid,
+		"code":       "",
+		"enabled":    true,
+		"deleted":    false,
+	}
+
+	return r.find(ctx, query, options.Find())
+}
+
+func (r *promoRepository) Find(ctx context.Context, merchantId, projectId string, offset, limit int64) ([]*pkg.Promo, error) {
+	query, err := r.getListQuery(merchantId, projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	return r.find(ctx, query, opts)
+}
+
+func (r *promoRepository) FindCount(ctx context.Context, merchantId, projectId string) (int64, error) {
+	query, err := r.getListQuery(merchantId, projectId)
+
+	if err != nil {
+		return int64(0), err
+	}
+
+	count, err := r.db.Collection(collectionPromo).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *promoRepository) getListQuery(merchantId, projectId string) (bson.M, error) {
+	merchantOid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"merchant_id": merchantOid, "deleted": false}
+
+	if projectId != "" {
+		query["project_id"], err = primitive.ObjectIDFromHex(projectId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+				zap.String(pkg.ErrorDatabaseFieldQuery, projectId),
+			)
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+func (r *promoRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]*pkg.Promo, error) {
+	cursor, err := r.db.Collection(collectionPromo).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoPromo
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromo),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.Promo, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.Promo)
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// PromoRepositoryInterface is abstraction layer for working with promo codes and discount campaigns
+// and representation in database.
+type PromoRepositoryInterface interface {
+	// Upsert adds or updates the promo.
+	Upsert(ctx context.Context, promo *pkg.Promo) error
+
+	// GetById returns the promo by unique identity, deleted promos aren't returned.
+	GetById(ctx context.Context, id string) (*pkg.Promo, error)
+
+	// GetByCode returns the enabled promo of the project by the code.
+	GetByCode(ctx context.Context, projectId, code string) (*pkg.Promo, error)
+
+	// CountByProjectIdCode returns count of not deleted promos of the project with the code.
+	CountByProjectIdCode(ctx context.Context, projectId, code string) (int64, error)
+
+	// FindCampaigns returns enabled promos of the project without code.
+	FindCampaigns(ctx context.Context, projectId string) ([]*pkg.Promo, error)
+
+	// Find returns promos of the merchant, filtered by the project if it's passed.
+	Find(ctx context.Context, merchantId, projectId string, offset, limit int64) ([]*pkg.Promo, error)
+
+	// FindCount returns count of promos of the merchant, filtered by the project if it's passed.
+	FindCount(ctx context.Context, merchantId, projectId string) (int64, error)
+}
@@ -0,0 +1,505 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionPromoUsage        = "promo_usage"
+	collectionPromoUsageCounter = "promo_usage_counter"
+)
+
+type promoUsageRepository repository
+
+type promoDiscountSummary struct {
+	Id struct {
+		PromoId  primitive.ObjectID `bson:"promo_id"`
+		Currency string             `bson:"currency"`
+	} `bson:"_id"`
+	Code        string  `bson:"code"`
+	Name        string  `bson:"name"`
+	OrdersCount int64   `bson:"orders_count"`
+	Discount    float64 `bson:"discount"`
+}
+
+// NewPromoUsageRepository create and return an object for working with the promo usage repository.
+// The returned object implements the PromoUsageRepositoryInterface interface.
+func NewPromoUsageRepository(db mongodb.SourceInterface) PromoUsageRepositoryInterface {
+	s := &promoUsageRepository{db: db, mapper: models.NewPromoUsageMapper()}
+	return s
+}
+
+func (r *promoUsageRepository) Insert(ctx context.Context, usage *pkg.PromoUsage) error {
+	mgo, err := r.mapper.MapObjectToMgo(usage)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, usage),
+		)
+		return err
+	}
+
+	in := mgo.(*models.MgoPromoUsage)
+	filter := bson.M{"promo_id": in.PromoId, "order_id": in.OrderId}
+	update := bson.M{"$setOnInsert": in}
+	opts := options.Update().SetUpsert(true)
+	_, err = r.db.Collection(collectionPromoUsage).UpdateOne(ctx, filter, update, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	usage.Id = in.Id.Hex()
+
+	return nil
+}
+
+func (r *promoUsageRepository) Reserve(ctx context.Context, usage *pkg.PromoUsage) (bool, error) {
+	mgo, err := r.mapper.MapObjectToMgo(usage)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, usage),
+		)
+		return false, err
+	}
+
+	in := mgo.(*models.MgoPromoUsage)
+	in.Status = pkg.PromoUsageStatusReserved
+	filter := bson.M{"promo_id": in.PromoId, "order_id": in.OrderId, "status": pkg.PromoUsageStatusReserved}
+	update := bson.M{"$set": bson.M{"expires_at": in.ExpiresAt}}
+	res, err := r.db.Collection(collectionPromoUsage).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return false, err
+	}
+
+	if res.MatchedCount > 0 {
+		return false, nil
+	}
+
+	filter = bson.M{"promo_id": in.PromoId, "order_id": in.OrderId}
+	update = bson.M{"$setOnInsert": in}
+	opts := options.Update().SetUpsert(true)
+	res, err = r.db.Collection(collectionPromoUsage).UpdateOne(ctx, filter, update, opts)
+
+	if err != nil {
+		// the usage is inserted by the concurrent request of the same order
+		if isDuplicateKeyError(err) {
+			return false, nil
+		}
+
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return false, err
+	}
+
+	if res.UpsertedCount == 0 {
+		return false, nil
+	}
+
+	usage.Id = in.Id.Hex()
+	usage.Status = in.Status
+
+	return true, nil
+}
+
+func (r *promoUsageRepository) Confirm(ctx context.Context, usage *pkg.PromoUsage) (bool, error) {
+	mgo, err := r.mapper.MapObjectToMgo(usage)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, usage),
+		)
+		return false, err
+	}
+
+	in := mgo.(*models.MgoPromoUsage)
+	filter := bson.M{"promo_id": in.PromoId, "order_id": in.OrderId, "status": pkg.PromoUsageStatusReserved}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     pkg.PromoUsageStatusUsed,
+			"discount":   in.Discount,
+			"currency":   in.Currency,
+			"created_at": in.CreatedAt,
+		},
+		"$unset": bson.M{"expires_at": ""},
+	}
+	res, err := r.db.Collection(collectionPromoUsage).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return false, err
+	}
+
+	if res.MatchedCount == 0 {
+		return false, nil
+	}
+
+	usage.Status = pkg.PromoUsageStatusUsed
+	usage.ExpiresAt = nil
+
+	return true, nil
+}
+
+func (r *promoUsageRepository) DeleteReserved(ctx context.Context, promoId, orderId string) (*pkg.PromoUsage, error) {
+	promoOid, err := primitive.ObjectIDFromHex(promoId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldQuery, promoId),
+		)
+		return nil, err
+	}
+
+	orderOid, err := primitive.ObjectIDFromHex(orderId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldQuery, orderId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"promo_id": promoOid, "order_id": orderOid, "status": pkg.PromoUsageStatusReserved}
+
+	return r.deleteReserved(ctx, query)
+}
+
+func (r *promoUsageRepository) DeleteExpiredReserved(ctx context.Context, promoId string, now time.Time) (*pkg.PromoUsage, error) {
+	promoOid, err := primitive.ObjectIDFromHex(promoId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldQuery, promoId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{
+		"promo_id":   promoOid,
+		"status":     pkg.PromoUsageStatusReserved,
+		"expires_at": bson.M{"$lt": now},
+	}
+
+	return r.deleteReserved(ctx, query)
+}
+
+func (r *promoUsageRepository) IncrementUses(ctx context.Context, promoId, customerId string, max int64) (bool, error) {
+	promoOid, err := primitive.ObjectIDFromHex(promoId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsageCounter),
+			zap.String(pkg.ErrorDatabaseFieldQuery, promoId),
+		)
+		return false, err
+	}
+
+	filter := bson.M{"promo_id": promoOid, "customer_id": customerId}
+
+	if max > 0 {
+		filter["uses"] = bson.M{"$lt": max}
+	}
+
+	update := bson.M{"$inc": bson.M{"uses": 1}}
+	opts := options.Update().SetUpsert(true)
+	_, err = r.db.Collection(collectionPromoUsageCounter).UpdateOne(ctx, filter, update, opts)
+
+	if err != nil {
+		// the counter exists but its value reached the limit, so the filter didn't match it
+		// and the upsert failed on the unique index
+		if isDuplicateKeyError(err) {
+			return false, nil
+		}
+
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsageCounter),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *promoUsageRepository) DecrementUses(ctx context.Context, promoId, customerId string) error {
+	promoOid, err := primitive.ObjectIDFromHex(promoId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsageCounter),
+			zap.String(pkg.ErrorDatabaseFieldQuery, promoId),
+		)
+		return err
+	}
+
+	filter := bson.M{"promo_id": promoOid, "customer_id": customerId, "uses": bson.M{"$gt": 0}}
+	update := bson.M{"$inc": bson.M{"uses": -1}}
+	_, err = r.db.Collection(collectionPromoUsageCounter).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsageCounter),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *promoUsageRepository) CountByPromoId(ctx context.Context, promoId string) (int64, error) {
+	promoOid, err := primitive.ObjectIDFromHex(promoId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldQuery, promoId),
+		)
+		return int64(0), err
+	}
+
+	return r.count(ctx, bson.M{"promo_id": promoOid, "status": bson.M{"$ne": pkg.PromoUsageStatusReserved}})
+}
+
+func (r *promoUsageRepository) CountByPromoIdCustomerId(ctx context.Context, promoId, customerId string) (int64, error) {
+	promoOid, err := primitive.ObjectIDFromHex(promoId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldQuery, promoId),
+		)
+		return int64(0), err
+	}
+
+	query := bson.M{
+		"promo_id":    promoOid,
+		"customer_id": customerId,
+		"status":      bson.M{"$ne": pkg.PromoUsageStatusReserved},
+	}
+
+	return r.count(ctx, query)
+}
+
+func (r *promoUsageRepository) GetDiscountSummary(
+	ctx context.Context,
+	merchantId string,
+	from, to time.Time,
+) ([]*pkg.PromoDiscountSummaryItem, error) {
+	merchantOid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"merchant_id": merchantOid,
+				"status":      bson.M{"$ne": pkg.PromoUsageStatusReserved},
+				"created_at":  bson.M{"$gte": from, "$lte": to},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":          bson.M{"promo_id": "$promo_id", "currency": "$currency"},
+				"orders_count": bson.M{"$sum": 1},
+				"discount":     bson.M{"$sum": "$discount"},
+			},
+		},
+		{
+			"$lookup": bson.M{
+				"from":         collectionPromo,
+				"localField":   "_id.promo_id",
+				"foreignField": "_id",
+				"as":           "promo",
+			},
+		},
+		{
+			"$project": bson.M{
+				"orders_count": 1,
+				"discount":     1,
+				"code":         bson.M{"$arrayElemAt": []interface{}{"$promo.code", 0}},
+				"name":         bson.M{"$arrayElemAt": []interface{}{"$promo.name", 0}},
+			},
+		},
+		{
+			"$sort": bson.M{"name": 1, "_id.currency": 1},
+		},
+	}
+
+	cursor, err := r.db.Collection(collectionPromoUsage).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*promoDiscountSummary
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	items := make([]*pkg.PromoDiscountSummaryItem, len(list))
+
+	for i, v := range list {
+		items[i] = &pkg.PromoDiscountSummaryItem{
+			PromoId:     v.Id.PromoId.Hex(),
+			Code:        v.Code,
+			Name:        v.Name,
+			Currency:    v.Id.Currency,
+			OrdersCount: v.OrdersCount,
+			Discount:    tools.FormatAmount(v.Discount),
+		}
+	}
+
+	return items, nil
+}
+
+func (r *promoUsageRepository) deleteReserved(ctx context.Context, query bson.M) (*pkg.PromoUsage, error) {
+	mgo := &models.MgoPromoUsage{}
+	err := r.db.Collection(collectionPromoUsage).FindOneAndDelete(ctx, query).Decode(mgo)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+				zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationDelete),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.PromoUsage), nil
+}
+
+func (r *promoUsageRepository) count(ctx context.Context, query bson.M) (int64, error) {
+	count, err := r.db.Collection(collectionPromoUsage).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionPromoUsage),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func isDuplicateKeyError(err error) bool {
+	exception, ok := err.(mongo.WriteException)
+
+	if !ok {
+		return false
+	}
+
+	for _, writeError := range exception.WriteErrors {
+		if writeError.Code == mongoErrorCodeDuplicateKey {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"time"
+)
+
+// PromoUsageRepositoryInterface is abstraction layer for working with usages of promos in paid orders
+// and representation in database.
+type PromoUsageRepositoryInterface interface {
+	// Insert adds the usage of the promo, the usage is added once for the order.
+	Insert(ctx context.Context, usage *pkg.PromoUsage) error
+
+	// Reserve adds the reserved usage of the promo for the unpaid order and returns true if it's added.
+	// If the order already has the reserved usage, its expiration time is prolonged and false is returned.
+	Reserve(ctx context.Context, usage *pkg.PromoUsage) (bool, error)
+
+	// Confirm marks the reserved usage of the promo in the order as used and returns false
+	// if the order has no reserved usage.
+	Confirm(ctx context.Context, usage *pkg.PromoUsage) (bool, error)
+
+	// DeleteReserved removes and returns the reserved usage of the promo in the order.
+	DeleteReserved(ctx context.Context, promoId, orderId string) (*pkg.PromoUsage, error)
+
+	// DeleteExpiredReserved removes and returns one of reserved usages of the promo expired before the time.
+	DeleteExpiredReserved(ctx context.Context, promoId string, now time.Time) (*pkg.PromoUsage, error)
+
+	// IncrementUses atomically increments the counter of reserved and paid usages of the promo if it's less
+	// than max and returns false otherwise, zero max means unlimited. The customer id is empty for the total
+	// counter of the promo.
+	IncrementUses(ctx context.Context, promoId, customerId string, max int64) (bool, error)
+
+	// DecrementUses decrements the counter of usages of the promo.
+	DecrementUses(ctx context.Context, promoId, customerId string) error
+
+	// CountByPromoId returns count of paid usages of the promo.
+	CountByPromoId(ctx context.Context, promoId string) (int64, error)
+
+	// CountByPromoIdCustomerId returns count of paid usages of the promo by the customer.
+	CountByPromoIdCustomerId(ctx context.Context, promoId, customerId string) (int64, error)
+
+	// GetDiscountSummary returns totals of discounts of the merchant grouped by promo and currency for the period.
+	GetDiscountSummary(ctx context.Context, merchantId string, from, to time.Time) ([]*pkg.PromoDiscountSummaryItem, error)
+}
@@ -244,3 +244,65 @@ func (h *refundRepository) GetAmountByOrderId(ctx context.Context, orderId strin
 
 	return res.Amount, nil
 }
+
+func (h *refundRepository) FindByOrderIds(ctx context.Context, orderIds []string) ([]*billingpb.Refund, error) {
+	oids := make([]primitive.ObjectID, len(orderIds))
+
+	for i, id := range orderIds {
+		oid, err := primitive.ObjectIDFromHex(id)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRefund),
+				zap.String(pkg.ErrorDatabaseFieldQuery, id),
+			)
+			return nil, err
+		}
+
+		oids[i] = oid
+	}
+
+	query := bson.M{"original_order.id": bson.M{"$in": oids}}
+	cursor, err := h.db.Collection(CollectionRefund).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRefund),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var mgoRefunds []*models.MgoRefund
+	err = cursor.All(ctx, &mgoRefunds)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRefund),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	refunds := make([]*billingpb.Refund, len(mgoRefunds))
+	for i, mgo := range mgoRefunds {
+		obj, err := h.mapper.MapMgoToObject(mgo)
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+			)
+			return nil, err
+		}
+		refunds[i] = obj.(*billingpb.Refund)
+	}
+
+	return refunds, nil
+}
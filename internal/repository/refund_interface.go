@@ -24,4 +24,7 @@ type RefundRepositoryInterface interface {
 
 	// GetAmountByOrderId returns the amount of refunds produced by order ID.
 	GetAmountByOrderId(context.Context, string) (float64, error)
+
+	// FindByOrderIds returns all refunds of purchase orders by their identifiers.
+	FindByOrderIds(ctx context.Context, orderIds []string) ([]*billingpb.Refund, error)
 }
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+	"time"
+)
+
+const (
+	collectionRiskAssessment = "risk_assessment"
+)
+
+var (
+	// keys of the count of risk assessments and groups of personal data fields of keys stored encrypted
+	riskAssessmentCountKeys = map[string]string{
+		"card_fingerprint": "",
+		"customer_id":      "",
+		"ip":               pkg.PersonalDataFieldIp,
+		"email":            pkg.PersonalDataFieldEmail,
+	}
+
+	errorRiskAssessmentCountKeyInvalid = errors.New("invalid key for count of risk assessments")
+)
+
+type riskAssessmentRepository repository
+
+// NewRiskAssessmentRepository create and return an object for working with the risk assessment repository.
+// The returned object implements the RiskAssessmentRepositoryInterface interface.
+func NewRiskAssessmentRepository(db mongodb.SourceInterface) RiskAssessmentRepositoryInterface {
+	s := &riskAssessmentRepository{db: db, mapper: models.NewRiskAssessmentMapper()}
+	return s
+}
+
+func (r *riskAssessmentRepository) Insert(ctx context.Context, assessment *pkg.RiskAssessment) error {
+	mgo, err := r.mapper.MapObjectToMgo(assessment)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, assessment),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionRiskAssessment).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	assessment.Id = mgo.(*models.MgoRiskAssessment).Id.Hex()
+
+	return nil
+}
+
+func (r *riskAssessmentRepository) FindByOrderId(ctx context.Context, orderId string) ([]*pkg.RiskAssessment, error) {
+	oid, err := primitive.ObjectIDFromHex(orderId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.String(pkg.ErrorDatabaseFieldQuery, orderId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"order_id": oid}
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.db.Collection(collectionRiskAssessment).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoRiskAssessment
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.RiskAssessment, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.RiskAssessment)
+	}
+
+	return objs, nil
+}
+
+func (r *riskAssessmentRepository) CountByKey(ctx context.Context, key, value string, from time.Time) (int64, error) {
+	field, ok := riskAssessmentCountKeys[key]
+
+	if !ok {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(errorRiskAssessmentCountKeyInvalid),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.String(pkg.ErrorDatabaseFieldQuery, key),
+		)
+		return int64(0), errorRiskAssessmentCountKeyInvalid
+	}
+
+	query := getRiskAssessmentValueQuery(key, field, value)
+	query["created_at"] = bson.M{"$gte": from}
+	count, err := r.db.Collection(collectionRiskAssessment).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationCount),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *riskAssessmentRepository) GetAverageAmount(
+	ctx context.Context,
+	projectId, currency string,
+	from time.Time,
+) (float64, error) {
+	var res struct {
+		Amount float64 `bson:"amount"`
+	}
+
+	oid, err := primitive.ObjectIDFromHex(projectId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.String(pkg.ErrorDatabaseFieldQuery, projectId),
+		)
+		return 0, err
+	}
+
+	query := []bson.M{
+		{
+			"$match": bson.M{
+				"project_id": oid,
+				"currency":   currency,
+				"created_at": bson.M{"$gte": from},
+			},
+		},
+		{"$group": bson.M{"_id": "$project_id", "amount": bson.M{"$avg": "$amount"}}},
+	}
+
+	cursor, err := r.db.Collection(collectionRiskAssessment).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return 0, err
+	}
+
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		err = cursor.Decode(&res)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorQueryCursorExecutionFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+			return 0, err
+		}
+	}
+
+	return res.Amount, nil
+}
+
+func (r *riskAssessmentRepository) CountChargebacks(
+	ctx context.Context,
+	customerId, email, cardFingerprint string,
+) (int64, error) {
+	var or []bson.M
+
+	if customerId != "" {
+		or = append(or, bson.M{"customer_id": customerId})
+	}
+
+	if email != "" {
+		or = append(or, getRiskAssessmentValueQuery("email", pkg.PersonalDataFieldEmail, email))
+	}
+
+	if cardFingerprint != "" {
+		or = append(or, bson.M{"card_fingerprint": cardFingerprint})
+	}
+
+	if len(or) <= 0 {
+		return int64(0), nil
+	}
+
+	query := []bson.M{
+		{"$match": bson.M{"chargeback": true, "$or": or}},
+		{"$group": bson.M{"_id": "$order_id"}},
+		{"$count": "count"},
+	}
+
+	var res struct {
+		Count int64 `bson:"count"`
+	}
+
+	cursor, err := r.db.Collection(collectionRiskAssessment).Aggregate(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		err = cursor.Decode(&res)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorQueryCursorExecutionFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+			)
+			return int64(0), err
+		}
+	}
+
+	return res.Count, nil
+}
+
+func (r *riskAssessmentRepository) MarkChargeback(ctx context.Context, orderId string) error {
+	oid, err := primitive.ObjectIDFromHex(orderId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.String(pkg.ErrorDatabaseFieldQuery, orderId),
+		)
+		return err
+	}
+
+	filter := bson.M{"order_id": oid}
+	update := bson.M{"$set": bson.M{"chargeback": true}}
+	_, err = r.db.Collection(collectionRiskAssessment).UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskAssessment),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, filter),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// getRiskAssessmentValueQuery returns the condition by value of the key, encrypted values are matched
+// by the blind index and values stored as plaintext are matched as is
+func getRiskAssessmentValueQuery(key, field, value string) bson.M {
+	index := models.GetFieldIndex(field, value)
+
+	if index == "" {
+		return bson.M{key: value}
+	}
+
+	return bson.M{"$or": []bson.M{{key: value}, {key + "_index": index}}}
+}
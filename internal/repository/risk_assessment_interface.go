@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"time"
+)
+
+// RiskAssessmentRepositoryInterface is abstraction layer for working with risk assessments of payments
+// and representation in database.
+type RiskAssessmentRepositoryInterface interface {
+	// Insert adds the risk assessment into the collection.
+	Insert(ctx context.Context, assessment *pkg.RiskAssessment) error
+
+	// FindByOrderId returns assessments of the order sorted by creation date.
+	FindByOrderId(ctx context.Context, orderId string) ([]*pkg.RiskAssessment, error)
+
+	// CountByKey returns count of assessments created since the date with the value of the key.
+	// The key is one of card_fingerprint, customer_id, ip or email.
+	CountByKey(ctx context.Context, key, value string, from time.Time) (int64, error)
+
+	// GetAverageAmount returns an average amount of assessments of the project in the currency created since the date.
+	GetAverageAmount(ctx context.Context, projectId, currency string, from time.Time) (float64, error)
+
+	// CountChargebacks returns count of orders with chargebacks paid by the customer, the email or the card.
+	CountChargebacks(ctx context.Context, customerId, email, cardFingerprint string) (int64, error)
+
+	// MarkChargeback marks assessments of the order as received the chargeback.
+	MarkChargeback(ctx context.Context, orderId string) error
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionRiskRule = "risk_rule"
+)
+
+type riskRuleRepository repository
+
+// NewRiskRuleRepository create and return an object for working with the risk rule repository.
+// The returned object implements the RiskRuleRepositoryInterface interface.
+func NewRiskRuleRepository(db mongodb.SourceInterface) RiskRuleRepositoryInterface {
+	s := &riskRuleRepository{db: db, mapper: models.NewRiskRuleMapper()}
+	return s
+}
+
+func (r *riskRuleRepository) Upsert(ctx context.Context, rule *pkg.RiskRule) error {
+	mgo, err := r.mapper.MapObjectToMgo(rule)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, rule),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoRiskRule).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionRiskRule).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskRule),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	rule.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *riskRuleRepository) GetById(ctx context.Context, id string) (*pkg.RiskRule, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskRule),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid, "deleted": false}
+	mgo := &models.MgoRiskRule{}
+	err = r.db.Collection(collectionRiskRule).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskRule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.RiskRule), nil
+}
+
+func (r *riskRuleRepository) FindEnabled(ctx context.Context, merchantId string) ([]*pkg.RiskRule, error) {
+	query := bson.M{
+		"merchant_id": bson.M{"$in": []string{"", merchantId}},
+		"enabled":     true,
+		"deleted":     false,
+	}
+
+	return r.find(ctx, query, options.Find().SetSort(bson.M{"created_at": 1}))
+}
+
+func (r *riskRuleRepository) Find(ctx context.Context, merchantId string, offset, limit int64) ([]*pkg.RiskRule, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	return r.find(ctx, r.getListQuery(merchantId), opts)
+}
+
+func (r *riskRuleRepository) FindCount(ctx context.Context, merchantId string) (int64, error) {
+	query := r.getListQuery(merchantId)
+	count, err := r.db.Collection(collectionRiskRule).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskRule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *riskRuleRepository) getListQuery(merchantId string) bson.M {
+	query := bson.M{"deleted": false}
+
+	if merchantId != "" {
+		query["merchant_id"] = merchantId
+	}
+
+	return query
+}
+
+func (r *riskRuleRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]*pkg.RiskRule, error) {
+	cursor, err := r.db.Collection(collectionRiskRule).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskRule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoRiskRule
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRiskRule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.RiskRule, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.RiskRule)
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// RiskRuleRepositoryInterface is abstraction layer for working with rules of the payment risk engine
+// and representation in database.
+type RiskRuleRepositoryInterface interface {
+	// Upsert adds or updates the risk rule.
+	Upsert(ctx context.Context, rule *pkg.RiskRule) error
+
+	// GetById returns the risk rule by unique identity, deleted rules aren't returned.
+	GetById(ctx context.Context, id string) (*pkg.RiskRule, error)
+
+	// FindEnabled returns enabled global rules and enabled rules of the merchant.
+	FindEnabled(ctx context.Context, merchantId string) ([]*pkg.RiskRule, error)
+
+	// Find returns not deleted rules, filtered by the merchant if it's passed.
+	Find(ctx context.Context, merchantId string, offset, limit int64) ([]*pkg.RiskRule, error)
+
+	// FindCount returns count of not deleted rules, filtered by the merchant if it's passed.
+	FindCount(ctx context.Context, merchantId string) (int64, error)
+}
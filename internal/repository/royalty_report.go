@@ -13,6 +13,7 @@ import (
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
@@ -624,6 +625,55 @@ func (r *royaltyReportRepository) GetReportExists(
 	return obj.(*billingpb.RoyaltyReport)
 }
 
+func (r *royaltyReportRepository) GetLastByMerchant(
+	ctx context.Context,
+	merchantId string,
+) (*billingpb.RoyaltyReport, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRoyaltyReport),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	var mgo = models.MgoRoyaltyReport{}
+	query := bson.M{"merchant_id": oid}
+	sorts := bson.M{"period_to": -1}
+	opts := options.FindOne().SetSort(sorts)
+	err = r.db.Collection(CollectionRoyaltyReport).FindOne(ctx, query, opts).Decode(&mgo)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				pkg.ErrorDatabaseQueryFailed,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, CollectionRoyaltyReport),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+				zap.Any(pkg.ErrorDatabaseFieldSorts, sorts),
+			)
+		}
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(&mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*billingpb.RoyaltyReport), nil
+}
+
 func (r *royaltyReportRepository) Insert(ctx context.Context, rr *billingpb.RoyaltyReport, ip, source string) (err error) {
 	mgo, err := r.mapper.MapObjectToMgo(rr)
 
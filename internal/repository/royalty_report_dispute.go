@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionRoyaltyReportDispute = "royalty_report_dispute"
+)
+
+type royaltyReportDisputeRepository repository
+
+// NewRoyaltyReportDisputeRepository create and return an object for working with the royalty report dispute
+// repository. The returned object implements the RoyaltyReportDisputeRepositoryInterface interface.
+func NewRoyaltyReportDisputeRepository(db mongodb.SourceInterface) RoyaltyReportDisputeRepositoryInterface {
+	s := &royaltyReportDisputeRepository{db: db, mapper: models.NewRoyaltyReportDisputeMapper()}
+	return s
+}
+
+func (r *royaltyReportDisputeRepository) Insert(ctx context.Context, dispute *pkg.RoyaltyReportDispute) error {
+	mgo, err := r.mapper.MapObjectToMgo(dispute)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, dispute),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionRoyaltyReportDispute).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	dispute.Id = mgo.(*models.MgoRoyaltyReportDispute).Id.Hex()
+
+	return nil
+}
+
+func (r *royaltyReportDisputeRepository) Update(ctx context.Context, dispute *pkg.RoyaltyReportDispute) error {
+	oid, err := primitive.ObjectIDFromHex(dispute.Id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldQuery, dispute.Id),
+		)
+		return err
+	}
+
+	mgo, err := r.mapper.MapObjectToMgo(dispute)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, dispute),
+		)
+		return err
+	}
+
+	filter := bson.M{"_id": oid}
+	_, err = r.db.Collection(collectionRoyaltyReportDispute).ReplaceOne(ctx, filter, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpdate),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *royaltyReportDisputeRepository) GetLastByRoyaltyReportId(
+	ctx context.Context,
+	reportId string,
+) (*pkg.RoyaltyReportDispute, error) {
+	oid, err := primitive.ObjectIDFromHex(reportId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.String(pkg.ErrorDatabaseFieldQuery, reportId),
+		)
+		return nil, err
+	}
+
+	mgo := &models.MgoRoyaltyReportDispute{}
+	query := bson.M{"royalty_report_id": oid}
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	err = r.db.Collection(collectionRoyaltyReportDispute).FindOne(ctx, query, opts).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionRoyaltyReportDispute),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.RoyaltyReportDispute), nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// RoyaltyReportDisputeRepositoryInterface is abstraction layer for working with royalty report disputes
+// and representation in database.
+type RoyaltyReportDisputeRepositoryInterface interface {
+	// Insert adds the royalty report dispute to the collection.
+	Insert(ctx context.Context, dispute *pkg.RoyaltyReportDispute) error
+
+	// Update updates the royalty report dispute in the collection.
+	Update(ctx context.Context, dispute *pkg.RoyaltyReportDispute) error
+
+	// GetLastByRoyaltyReportId returns the latest dispute of the royalty report.
+	GetLastByRoyaltyReportId(ctx context.Context, reportId string) (*pkg.RoyaltyReportDispute, error)
+}
@@ -33,6 +33,9 @@ type RoyaltyReportRepositoryInterface interface {
 	// GetReportExists returns exists a royalty reports by merchant id, currency and dates from/to.
 	GetReportExists(ctx context.Context, merchantId, currency string, from, to time.Time) (report *billingpb.RoyaltyReport)
 
+	// GetLastByMerchant returns the royalty report of merchant with the latest end of period.
+	GetLastByMerchant(ctx context.Context, merchantId string) (*billingpb.RoyaltyReport, error)
+
 	// GetAll returns the all royalty reports.
 	GetAll(ctx context.Context) ([]*billingpb.RoyaltyReport, error)
 
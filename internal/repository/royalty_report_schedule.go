@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionMerchantRoyaltySchedule = "merchant_royalty_schedule"
+)
+
+type merchantRoyaltyScheduleRepository repository
+
+// NewMerchantRoyaltyScheduleRepository create and return an object for working with the merchant royalty report
+// schedule repository. The returned object implements the MerchantRoyaltyScheduleRepositoryInterface interface.
+func NewMerchantRoyaltyScheduleRepository(db mongodb.SourceInterface) MerchantRoyaltyScheduleRepositoryInterface {
+	s := &merchantRoyaltyScheduleRepository{db: db, mapper: models.NewMerchantRoyaltyScheduleMapper()}
+	return s
+}
+
+func (r *merchantRoyaltyScheduleRepository) Upsert(ctx context.Context, schedule *pkg.MerchantRoyaltySchedule) error {
+	mgo, err := r.mapper.MapObjectToMgo(schedule)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, schedule),
+		)
+		return err
+	}
+
+	filter := bson.M{"merchant_id": mgo.(*models.MgoMerchantRoyaltySchedule).MerchantId}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionMerchantRoyaltySchedule).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRoyaltySchedule),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *merchantRoyaltyScheduleRepository) GetByMerchantId(
+	ctx context.Context,
+	merchantId string,
+) (*pkg.MerchantRoyaltySchedule, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRoyaltySchedule),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	mgo := &models.MgoMerchantRoyaltySchedule{}
+	query := bson.M{"merchant_id": oid}
+	err = r.db.Collection(collectionMerchantRoyaltySchedule).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRoyaltySchedule),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.MerchantRoyaltySchedule), nil
+}
+
+func (r *merchantRoyaltyScheduleRepository) GetAll(ctx context.Context) ([]*pkg.MerchantRoyaltySchedule, error) {
+	cursor, err := r.db.Collection(collectionMerchantRoyaltySchedule).Find(ctx, bson.M{})
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRoyaltySchedule),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoMerchantRoyaltySchedule
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionMerchantRoyaltySchedule),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.MerchantRoyaltySchedule, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+		objs[i] = v.(*pkg.MerchantRoyaltySchedule)
+	}
+
+	return objs, nil
+}
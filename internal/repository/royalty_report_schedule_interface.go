@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// MerchantRoyaltyScheduleRepositoryInterface is abstraction layer for working with merchant royalty report schedules
+// and representation in database.
+type MerchantRoyaltyScheduleRepositoryInterface interface {
+	// Upsert adds or replaces the royalty report schedule of the merchant.
+	Upsert(ctx context.Context, schedule *pkg.MerchantRoyaltySchedule) error
+
+	// GetByMerchantId returns the royalty report schedule by merchant identifier.
+	GetByMerchantId(ctx context.Context, merchantId string) (*pkg.MerchantRoyaltySchedule, error)
+
+	// GetAll returns all merchants royalty report schedules.
+	GetAll(ctx context.Context) ([]*pkg.MerchantRoyaltySchedule, error)
+}
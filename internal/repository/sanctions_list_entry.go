@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionSanctionsListEntry = "sanctions_list_entry"
+)
+
+type sanctionsListEntryRepository repository
+
+// NewSanctionsListEntryRepository create and return an object for working with the sanctions list entry repository.
+// The returned object implements the SanctionsListEntryRepositoryInterface interface.
+func NewSanctionsListEntryRepository(db mongodb.SourceInterface) SanctionsListEntryRepositoryInterface {
+	s := &sanctionsListEntryRepository{db: db, mapper: models.NewSanctionsListEntryMapper()}
+	return s
+}
+
+func (r *sanctionsListEntryRepository) MultipleInsert(ctx context.Context, entries []*pkg.SanctionsListEntry) error {
+	c := make([]interface{}, len(entries))
+
+	for i, v := range entries {
+		mgo, err := r.mapper.MapObjectToMgo(v)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, v),
+			)
+			return err
+		}
+
+		c[i] = mgo
+	}
+
+	_, err := r.db.Collection(collectionSanctionsListEntry).InsertMany(ctx, c)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsListEntry),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Int("count", len(c)),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *sanctionsListEntryRepository) DeletePreviousVersions(ctx context.Context, source string, version int64) error {
+	query := bson.M{"source": source, "version": bson.M{"$lt": version}}
+	_, err := r.db.Collection(collectionSanctionsListEntry).DeleteMany(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsListEntry),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationDelete),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *sanctionsListEntryRepository) FindByKeys(ctx context.Context, keys []string) ([]*pkg.SanctionsListEntry, error) {
+	query := bson.M{"keys": bson.M{"$in": keys}}
+	cursor, err := r.db.Collection(collectionSanctionsListEntry).Find(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsListEntry),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoSanctionsListEntry
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsListEntry),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.SanctionsListEntry, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.SanctionsListEntry)
+	}
+
+	return objs, nil
+}
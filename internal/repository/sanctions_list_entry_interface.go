@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// SanctionsListEntryRepositoryInterface is abstraction layer for working with entries of sanctions lists
+// and representation in database.
+type SanctionsListEntryRepositoryInterface interface {
+	// MultipleInsert adds entries of the list in a single request.
+	MultipleInsert(ctx context.Context, entries []*pkg.SanctionsListEntry) error
+
+	// DeletePreviousVersions removes entries of the source loaded before the passed version.
+	DeletePreviousVersions(ctx context.Context, source string, version int64) error
+
+	// FindByKeys returns entries which have at least one of passed keys.
+	FindByKeys(ctx context.Context, keys []string) ([]*pkg.SanctionsListEntry, error)
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionSanctionsScreening = "sanctions_screening"
+)
+
+type sanctionsScreeningRepository repository
+
+// NewSanctionsScreeningRepository create and return an object for working with the sanctions screening repository.
+// The returned object implements the SanctionsScreeningRepositoryInterface interface.
+func NewSanctionsScreeningRepository(db mongodb.SourceInterface) SanctionsScreeningRepositoryInterface {
+	s := &sanctionsScreeningRepository{db: db, mapper: models.NewSanctionsScreeningMapper()}
+	return s
+}
+
+func (r *sanctionsScreeningRepository) Upsert(ctx context.Context, screening *pkg.SanctionsScreening) error {
+	mgo, err := r.mapper.MapObjectToMgo(screening)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, screening),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoSanctionsScreening).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionSanctionsScreening).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsScreening),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	screening.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *sanctionsScreeningRepository) GetById(ctx context.Context, id string) (*pkg.SanctionsScreening, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsScreening),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid}
+	mgo := &models.MgoSanctionsScreening{}
+	err = r.db.Collection(collectionSanctionsScreening).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsScreening),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.SanctionsScreening), nil
+}
+
+func (r *sanctionsScreeningRepository) Find(
+	ctx context.Context,
+	merchantId string,
+	statuses []string,
+	offset, limit int64,
+) ([]*pkg.SanctionsScreening, error) {
+	query, err := r.getListQuery(merchantId, statuses)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.db.Collection(collectionSanctionsScreening).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsScreening),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoSanctionsScreening
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsScreening),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.SanctionsScreening, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.SanctionsScreening)
+	}
+
+	return objs, nil
+}
+
+func (r *sanctionsScreeningRepository) FindCount(ctx context.Context, merchantId string, statuses []string) (int64, error) {
+	query, err := r.getListQuery(merchantId, statuses)
+
+	if err != nil {
+		return int64(0), err
+	}
+
+	count, err := r.db.Collection(collectionSanctionsScreening).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsScreening),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *sanctionsScreeningRepository) getListQuery(merchantId string, statuses []string) (bson.M, error) {
+	query := bson.M{}
+
+	if merchantId != "" {
+		oid, err := primitive.ObjectIDFromHex(merchantId)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseInvalidObjectId,
+				zap.Error(err),
+				zap.String(pkg.ErrorDatabaseFieldCollection, collectionSanctionsScreening),
+				zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+			)
+			return nil, err
+		}
+
+		query["merchant_id"] = oid
+	}
+
+	if len(statuses) > 0 {
+		query["status"] = bson.M{"$in": statuses}
+	}
+
+	return query, nil
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// SanctionsScreeningRepositoryInterface is abstraction layer for working with sanctions screenings of merchants
+// and representation in database.
+type SanctionsScreeningRepositoryInterface interface {
+	// Upsert adds or updates the screening.
+	Upsert(ctx context.Context, screening *pkg.SanctionsScreening) error
+
+	// GetById returns the screening by unique identity.
+	GetById(ctx context.Context, id string) (*pkg.SanctionsScreening, error)
+
+	// Find returns screenings filtered by the merchant and the statuses if they're passed, the latest screening
+	// is the first.
+	Find(ctx context.Context, merchantId string, statuses []string, offset, limit int64) ([]*pkg.SanctionsScreening, error)
+
+	// FindCount returns count of screenings filtered by the merchant and the statuses if they're passed.
+	FindCount(ctx context.Context, merchantId string, statuses []string) (int64, error)
+}
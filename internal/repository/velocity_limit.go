@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionVelocityLimit = "velocity_limit"
+)
+
+type velocityLimitRepository repository
+
+// NewVelocityLimitRepository create and return an object for working with the velocity limit repository.
+// The returned object implements the VelocityLimitRepositoryInterface interface.
+func NewVelocityLimitRepository(db mongodb.SourceInterface) VelocityLimitRepositoryInterface {
+	s := &velocityLimitRepository{db: db, mapper: models.NewVelocityLimitMapper()}
+	return s
+}
+
+func (r *velocityLimitRepository) Upsert(ctx context.Context, limit *pkg.VelocityLimit) error {
+	mgo, err := r.mapper.MapObjectToMgo(limit)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, limit),
+		)
+		return err
+	}
+
+	oid := mgo.(*models.MgoVelocityLimit).Id
+	filter := bson.M{"_id": oid}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.db.Collection(collectionVelocityLimit).ReplaceOne(ctx, filter, mgo, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimit),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationUpsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	limit.Id = oid.Hex()
+
+	return nil
+}
+
+func (r *velocityLimitRepository) GetById(ctx context.Context, id string) (*pkg.VelocityLimit, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimit),
+			zap.String(pkg.ErrorDatabaseFieldQuery, id),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"_id": oid, "deleted": false}
+	mgo := &models.MgoVelocityLimit{}
+	err = r.db.Collection(collectionVelocityLimit).FindOne(ctx, query).Decode(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimit),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	obj, err := r.mapper.MapMgoToObject(mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, mgo),
+		)
+		return nil, err
+	}
+
+	return obj.(*pkg.VelocityLimit), nil
+}
+
+func (r *velocityLimitRepository) FindEnabled(
+	ctx context.Context,
+	merchantId, paymentMethodId string,
+) ([]*pkg.VelocityLimit, error) {
+	query := bson.M{
+		"merchant_id":       bson.M{"$in": []string{"", merchantId}},
+		"payment_method_id": bson.M{"$in": []string{"", paymentMethodId}},
+		"enabled":           true,
+		"deleted":           false,
+	}
+
+	return r.find(ctx, query, options.Find().SetSort(bson.M{"created_at": 1}))
+}
+
+func (r *velocityLimitRepository) Find(ctx context.Context, merchantId string, offset, limit int64) ([]*pkg.VelocityLimit, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	return r.find(ctx, r.getListQuery(merchantId), opts)
+}
+
+func (r *velocityLimitRepository) FindCount(ctx context.Context, merchantId string) (int64, error) {
+	query := r.getListQuery(merchantId)
+	count, err := r.db.Collection(collectionVelocityLimit).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimit),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
+
+func (r *velocityLimitRepository) getListQuery(merchantId string) bson.M {
+	query := bson.M{"deleted": false}
+
+	if merchantId != "" {
+		query["merchant_id"] = merchantId
+	}
+
+	return query
+}
+
+func (r *velocityLimitRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]*pkg.VelocityLimit, error) {
+	cursor, err := r.db.Collection(collectionVelocityLimit).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimit),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoVelocityLimit
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimit),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.VelocityLimit, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.VelocityLimit)
+	}
+
+	return objs, nil
+}
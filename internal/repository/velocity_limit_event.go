@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	mongodb "gopkg.in/paysuper/paysuper-database-mongo.v2"
+)
+
+const (
+	collectionVelocityLimitEvent = "velocity_limit_event"
+)
+
+type velocityLimitEventRepository repository
+
+// NewVelocityLimitEventRepository create and return an object for working with the velocity limit event repository.
+// The returned object implements the VelocityLimitEventRepositoryInterface interface.
+func NewVelocityLimitEventRepository(db mongodb.SourceInterface) VelocityLimitEventRepositoryInterface {
+	s := &velocityLimitEventRepository{db: db, mapper: models.NewVelocityLimitEventMapper()}
+	return s
+}
+
+func (r *velocityLimitEventRepository) Insert(ctx context.Context, event *pkg.VelocityLimitEvent) error {
+	mgo, err := r.mapper.MapObjectToMgo(event)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseMapModelFailed,
+			zap.Error(err),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, event),
+		)
+		return err
+	}
+
+	_, err = r.db.Collection(collectionVelocityLimitEvent).InsertOne(ctx, mgo)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimitEvent),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationInsert),
+			zap.Any(pkg.ErrorDatabaseFieldDocument, mgo),
+		)
+		return err
+	}
+
+	event.Id = mgo.(*models.MgoVelocityLimitEvent).Id.Hex()
+
+	return nil
+}
+
+func (r *velocityLimitEventRepository) FindByMerchantId(
+	ctx context.Context,
+	merchantId string,
+	offset, limit int64,
+) ([]*pkg.VelocityLimitEvent, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimitEvent),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return nil, err
+	}
+
+	query := bson.M{"merchant_id": oid}
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(offset).
+		SetLimit(limit)
+	cursor, err := r.db.Collection(collectionVelocityLimitEvent).Find(ctx, query, opts)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimitEvent),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	var list []*models.MgoVelocityLimitEvent
+	err = cursor.All(ctx, &list)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorQueryCursorExecutionFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimitEvent),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return nil, err
+	}
+
+	objs := make([]*pkg.VelocityLimitEvent, len(list))
+
+	for i, obj := range list {
+		v, err := r.mapper.MapMgoToObject(obj)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorDatabaseMapModelFailed,
+				zap.Error(err),
+				zap.Any(pkg.ErrorDatabaseFieldQuery, obj),
+			)
+			return nil, err
+		}
+
+		objs[i] = v.(*pkg.VelocityLimitEvent)
+	}
+
+	return objs, nil
+}
+
+func (r *velocityLimitEventRepository) FindCountByMerchantId(ctx context.Context, merchantId string) (int64, error) {
+	oid, err := primitive.ObjectIDFromHex(merchantId)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseInvalidObjectId,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimitEvent),
+			zap.String(pkg.ErrorDatabaseFieldQuery, merchantId),
+		)
+		return int64(0), err
+	}
+
+	query := bson.M{"merchant_id": oid}
+	count, err := r.db.Collection(collectionVelocityLimitEvent).CountDocuments(ctx, query)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldCollection, collectionVelocityLimitEvent),
+			zap.String(pkg.ErrorDatabaseFieldOperation, pkg.ErrorDatabaseFieldOperationCount),
+			zap.Any(pkg.ErrorDatabaseFieldQuery, query),
+		)
+		return int64(0), err
+	}
+
+	return count, nil
+}
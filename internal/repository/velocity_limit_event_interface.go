@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// VelocityLimitEventRepositoryInterface is abstraction layer for working with breaches of velocity limits
+// and representation in database.
+type VelocityLimitEventRepositoryInterface interface {
+	// Insert adds the event into the collection.
+	Insert(ctx context.Context, event *pkg.VelocityLimitEvent) error
+
+	// FindByMerchantId returns events of the merchant sorted by creation date in descending order.
+	FindByMerchantId(ctx context.Context, merchantId string, offset, limit int64) ([]*pkg.VelocityLimitEvent, error)
+
+	// FindCountByMerchantId returns count of events of the merchant.
+	FindCountByMerchantId(ctx context.Context, merchantId string) (int64, error)
+}
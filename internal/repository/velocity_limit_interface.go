@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+)
+
+// VelocityLimitRepositoryInterface is abstraction layer for working with velocity limits of payments
+// and representation in database.
+type VelocityLimitRepositoryInterface interface {
+	// Upsert adds or updates the velocity limit.
+	Upsert(ctx context.Context, limit *pkg.VelocityLimit) error
+
+	// GetById returns the velocity limit by unique identity, deleted limits aren't returned.
+	GetById(ctx context.Context, id string) (*pkg.VelocityLimit, error)
+
+	// FindEnabled returns enabled limits applied to payments of the merchant with the payment method.
+	// Global limits and limits for all payment methods are included.
+	FindEnabled(ctx context.Context, merchantId, paymentMethodId string) ([]*pkg.VelocityLimit, error)
+
+	// Find returns not deleted limits, filtered by the merchant if it's passed.
+	Find(ctx context.Context, merchantId string, offset, limit int64) ([]*pkg.VelocityLimit, error)
+
+	// FindCount returns count of not deleted limits, filtered by the merchant if it's passed.
+	FindCount(ctx context.Context, merchantId string) (int64, error)
+}
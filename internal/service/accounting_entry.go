@@ -1154,7 +1154,7 @@ func (h *accountingEntry) newEntry(entryType string) *billingpb.AccountingEntry
 		country = h.country.IsoCodeA2
 	}
 
-	return &billingpb.AccountingEntry{
+	entry := &billingpb.AccountingEntry{
 		Id:                 primitive.NewObjectID().Hex(),
 		Object:             pkg.ObjectTypeBalanceTransaction,
 		Type:               entryType,
@@ -1166,6 +1166,14 @@ func (h *accountingEntry) newEntry(entryType string) *billingpb.AccountingEntry
 		Currency:           currency,
 		OperatingCompanyId: operatingCompanyId,
 	}
+
+	// entries of chargebacks of payments authenticated by 3-D Secure are marked for the dispute with the issuer
+	if h.refund != nil && h.refund.IsChargeback && h.order != nil &&
+		h.order.PrivateMetadata[pkg.OrderPrivateMetadataLiabilityShift] == "1" {
+		entry.Reason = pkg.AccountingEntryReasonLiabilityShift
+	}
+
+	return entry
 }
 
 func (h *accountingEntry) getPaymentChannelCostSystem() (*billingpb.PaymentChannelCostSystem, error) {
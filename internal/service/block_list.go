@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// blockListHitSeparator separates hits of the order stored in private metadata, each hit is in
+	// format type:field:entry_id.
+	blockListHitSeparator = ","
+)
+
+var (
+	errorBlockListEntryNotFound     = newBillingServerErrorMsg("bl000001", "block list entry not found")
+	errorBlockListTypeInvalid       = newBillingServerErrorMsg("bl000002", "block list type is invalid")
+	errorBlockListFieldInvalid      = newBillingServerErrorMsg("bl000003", "block list field is invalid")
+	errorBlockListValueInvalid      = newBillingServerErrorMsg("bl000004", "block list value is invalid for the field")
+	errorBlockListExpiresAtInvalid  = newBillingServerErrorMsg("bl000005", "block list entry expiry must be in the future")
+	errorBlockListUnknown           = newBillingServerErrorMsg("bl000006", "unknown error with block list")
+	errorBlockListPaymentBlocked    = newBillingServerErrorMsg("bl000007", "payment is blocked")
+	errorBlockListMerchantForbidden = newBillingServerErrorMsg("bl000008", "block list entry belongs to another merchant")
+
+	blockListTypes = map[string]bool{
+		pkg.BlockListTypeBlock: true,
+		pkg.BlockListTypeAllow: true,
+	}
+
+	blockListCardFingerprintRegex = regexp.MustCompile("^[0-9a-f]{64}$")
+	blockListCardNumberRegex      = regexp.MustCompile("^[0-9]{12,19}$")
+	blockListBinRegex             = regexp.MustCompile("^[0-9]{6,8}$")
+	blockListEmailRegex           = regexp.MustCompile("^[^@\\s]+@[^@\\s]+\\.[^@\\s]+$")
+	blockListEmailDomainRegex     = regexp.MustCompile("^[^@\\s]+\\.[^@\\s]+$")
+)
+
+// CreateOrUpdateBlockListEntry creates or modifies the entry of the block list or the allow list
+func (s *Service) CreateOrUpdateBlockListEntry(
+	ctx context.Context,
+	req *pkg.CreateOrUpdateBlockListEntryRequest,
+	res *pkg.BlockListEntryResponse,
+) error {
+	entry := &pkg.BlockListEntry{
+		Id:        primitive.NewObjectID().Hex(),
+		CreatedBy: req.UserId,
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	if req.Id != "" {
+		var err error
+		entry, err = s.blockListRepository.GetById(ctx, req.Id)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = errorBlockListEntryNotFound
+			return nil
+		}
+
+		if entry.MerchantId != req.MerchantId {
+			res.Status = billingpb.ResponseStatusForbidden
+			res.Message = errorBlockListMerchantForbidden
+			return nil
+		}
+	}
+
+	if !blockListTypes[req.Type] {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorBlockListTypeInvalid
+		return nil
+	}
+
+	value, msg := s.normalizeBlockListValue(req.Field, req.Value)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = msg
+		return nil
+	}
+
+	entry.ExpiresAt = nil
+
+	if req.ExpiresAt != 0 {
+		if req.ExpiresAt <= time.Now().Unix() {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = errorBlockListExpiresAtInvalid
+			return nil
+		}
+
+		entry.ExpiresAt = &timestamp.Timestamp{Seconds: req.ExpiresAt}
+	}
+
+	if req.MerchantId != "" {
+		if _, err := s.merchantRepository.GetById(ctx, req.MerchantId); err != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = merchantErrorNotFound
+			return nil
+		}
+	}
+
+	entry.MerchantId = req.MerchantId
+	entry.Type = req.Type
+	entry.Field = req.Field
+	entry.Value = value
+	entry.Reason = req.Reason
+	entry.UpdatedAt = ptypes.TimestampNow()
+
+	if err := s.blockListRepository.Upsert(ctx, entry); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorBlockListUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = entry
+
+	return nil
+}
+
+// ListBlockListEntries returns entries of block lists and allow lists, filtered by the merchant,
+// the type and the field if they're passed
+func (s *Service) ListBlockListEntries(
+	ctx context.Context,
+	req *pkg.ListBlockListEntriesRequest,
+	res *pkg.ListBlockListEntriesResponse,
+) error {
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	count, err := s.blockListRepository.FindCount(ctx, req.MerchantId, req.Type, req.Field)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorBlockListUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count <= 0 {
+		return nil
+	}
+
+	res.Items, err = s.blockListRepository.Find(ctx, req.MerchantId, req.Type, req.Field, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorBlockListUnknown
+		res.Count = 0
+		return nil
+	}
+
+	return nil
+}
+
+// DeleteBlockListEntry marks the entry of the block list or the allow list as deleted
+func (s *Service) DeleteBlockListEntry(
+	ctx context.Context,
+	req *pkg.DeleteBlockListEntryRequest,
+	res *billingpb.EmptyResponseWithStatus,
+) error {
+	entry, err := s.blockListRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorBlockListEntryNotFound
+		return nil
+	}
+
+	if entry.MerchantId != req.MerchantId {
+		res.Status = billingpb.ResponseStatusForbidden
+		res.Message = errorBlockListMerchantForbidden
+		return nil
+	}
+
+	entry.Deleted = true
+	entry.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.blockListRepository.Upsert(ctx, entry); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorBlockListUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// checkBlockLists checks the order against block lists and allow lists and records hits on the order.
+// It returns true when the order is matched by the allow list, allowed orders skip block lists, velocity limits
+// and decisions of the risk engine. Lists aren't enforced when the storage is unavailable.
+func (s *Service) checkBlockLists(
+	ctx context.Context,
+	order *billingpb.Order,
+	cardFingerprint, pan string,
+) (bool, *billingpb.ResponseErrorMessage) {
+	values := s.getBlockListValues(order, cardFingerprint)
+	entries, err := s.blockListRepository.FindActive(ctx, order.GetMerchantId(), values)
+
+	if err != nil {
+		zap.L().Error("Failed to get block list entries", zap.Error(err), zap.String("order_id", order.Id))
+		return false, nil
+	}
+
+	var hits []*pkg.BlockListEntry
+	allowed, blocked := false, false
+
+	for _, entry := range entries {
+		if !matchBlockListEntry(entry, values, pan) {
+			continue
+		}
+
+		hits = append(hits, entry)
+
+		if entry.Type == pkg.BlockListTypeAllow {
+			allowed = true
+		} else {
+			blocked = true
+		}
+	}
+
+	if len(hits) <= 0 {
+		return false, nil
+	}
+
+	s.recordBlockListHits(ctx, order, hits)
+
+	if !allowed && blocked {
+		return false, errorBlockListPaymentBlocked
+	}
+
+	return allowed, nil
+}
+
+func (s *Service) recordBlockListHits(ctx context.Context, order *billingpb.Order, hits []*pkg.BlockListEntry) {
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	var recorded []string
+	known := make(map[string]bool)
+
+	if v := order.PrivateMetadata[pkg.OrderPrivateMetadataBlockListHits]; v != "" {
+		recorded = strings.Split(v, blockListHitSeparator)
+	}
+
+	for _, hit := range recorded {
+		known[hit] = true
+	}
+
+	ids := make([]string, 0, len(hits))
+
+	for _, entry := range hits {
+		ids = append(ids, entry.Id)
+		hit := entry.Type + ":" + entry.Field + ":" + entry.Id
+
+		if known[hit] {
+			continue
+		}
+
+		known[hit] = true
+		recorded = append(recorded, hit)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataBlockListHits] = strings.Join(recorded, blockListHitSeparator)
+
+	if err := s.blockListRepository.IncrementHits(ctx, ids); err != nil {
+		zap.L().Error("Failed to increment hits of block list entries", zap.Error(err), zap.Strings("ids", ids))
+	}
+}
+
+// getBlockListValues returns values of block list fields of the order, the bank card is known
+// on the payment only.
+func (s *Service) getBlockListValues(order *billingpb.Order, cardFingerprint string) map[string]string {
+	values := map[string]string{
+		pkg.BlockListFieldCard:   cardFingerprint,
+		pkg.BlockListFieldCookie: order.PrivateMetadata[pkg.OrderPrivateMetadataBrowserCookieId],
+	}
+
+	if order.User != nil {
+		email := strings.ToLower(order.User.Email)
+		values[pkg.BlockListFieldCustomer] = order.User.Id
+		values[pkg.BlockListFieldIp] = order.User.Ip
+		values[pkg.BlockListFieldEmail] = email
+
+		if i := strings.LastIndex(email, "@"); i >= 0 {
+			values[pkg.BlockListFieldEmailDomain] = email[i+1:]
+		}
+	}
+
+	return values
+}
+
+// getBlockListPan returns the card number used to match BIN ranges, the masked number of the order
+// is used when the full number isn't passed.
+func getBlockListPan(order *billingpb.Order, data map[string]string) string {
+	if order.PaymentMethod == nil || !order.PaymentMethod.IsBankCard() {
+		return ""
+	}
+
+	if pan := data[billingpb.PaymentCreateFieldPan]; pan != "" && !strings.Contains(pan, "*") {
+		return pan
+	}
+
+	return order.PaymentRequisites[billingpb.PaymentCreateFieldPan]
+}
+
+func matchBlockListEntry(entry *pkg.BlockListEntry, values map[string]string, pan string) bool {
+	switch entry.Field {
+	case pkg.BlockListFieldBin:
+		return matchBlockListBin(entry.Value, pan)
+	case pkg.BlockListFieldIp:
+		if !strings.Contains(entry.Value, "/") {
+			return entry.Value == values[pkg.BlockListFieldIp]
+		}
+
+		_, network, err := net.ParseCIDR(entry.Value)
+		ip := net.ParseIP(values[pkg.BlockListFieldIp])
+
+		return err == nil && ip != nil && network.Contains(ip)
+	default:
+		value := values[entry.Field]
+		return value != "" && value == entry.Value
+	}
+}
+
+// matchBlockListBin checks the card number against the BIN or the range of BINs separated by dash,
+// bounds of the range are inclusive.
+func matchBlockListBin(bin, pan string) bool {
+	from, to := bin, bin
+
+	if i := strings.Index(bin, "-"); i > 0 {
+		from, to = bin[:i], bin[i+1:]
+	}
+
+	if len(pan) < len(from) {
+		return false
+	}
+
+	prefix := pan[:len(from)]
+
+	if !blockListBinRegex.MatchString(prefix) {
+		return false
+	}
+
+	return prefix >= from && prefix <= to
+}
+
+// normalizeBlockListValue validates the value of the entry and converts it to the form stored in the list.
+// Card numbers are replaced by fingerprints to keep them out of the storage.
+func (s *Service) normalizeBlockListValue(field, value string) (string, *billingpb.ResponseErrorMessage) {
+	value = strings.TrimSpace(value)
+
+	switch field {
+	case pkg.BlockListFieldCard:
+		value = strings.ToLower(strings.Replace(value, " ", "", -1))
+
+		if blockListCardNumberRegex.MatchString(value) {
+			return s.getCardFingerprint(value), nil
+		}
+
+		if blockListCardFingerprintRegex.MatchString(value) {
+			return value, nil
+		}
+	case pkg.BlockListFieldBin:
+		parts := strings.Split(value, "-")
+
+		if len(parts) == 1 && blockListBinRegex.MatchString(parts[0]) {
+			return value, nil
+		}
+
+		if len(parts) == 2 && blockListBinRegex.MatchString(parts[0]) &&
+			len(parts[0]) == len(parts[1]) && blockListBinRegex.MatchString(parts[1]) && parts[0] <= parts[1] {
+			return value, nil
+		}
+	case pkg.BlockListFieldEmail:
+		value = strings.ToLower(value)
+
+		if blockListEmailRegex.MatchString(value) {
+			return value, nil
+		}
+	case pkg.BlockListFieldEmailDomain:
+		value = strings.TrimPrefix(strings.ToLower(value), "@")
+
+		if blockListEmailDomainRegex.MatchString(value) {
+			return value, nil
+		}
+	case pkg.BlockListFieldIp:
+		if ip := net.ParseIP(value); ip != nil {
+			return ip.String(), nil
+		}
+
+		if _, network, err := net.ParseCIDR(value); err == nil {
+			return network.String(), nil
+		}
+	case pkg.BlockListFieldCustomer, pkg.BlockListFieldCookie:
+		if value != "" {
+			return value, nil
+		}
+	default:
+		return "", errorBlockListFieldInvalid
+	}
+
+	return "", errorBlockListValueInvalid
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+	"time"
+)
+
+type BlockListTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant *billingpb.Merchant
+	project  *billingpb.Project
+}
+
+func Test_BlockList(t *testing.T) {
+	suite.Run(t, new(BlockListTestSuite))
+}
+
+func (suite *BlockListTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, _, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *BlockListTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *BlockListTestSuite) helperCreateBlockListEntry(req *pkg.CreateOrUpdateBlockListEntryRequest) *pkg.BlockListEntry {
+	res := &pkg.BlockListEntryResponse{}
+	err := suite.service.CreateOrUpdateBlockListEntry(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	return res.Item
+}
+
+func (suite *BlockListTestSuite) getOrder() *billingpb.Order {
+	return &billingpb.Order{
+		Id:          primitive.NewObjectID().Hex(),
+		Project:     &billingpb.ProjectOrder{Id: suite.project.Id, MerchantId: suite.merchant.Id},
+		OrderAmount: 100,
+		Currency:    "USD",
+		User: &billingpb.OrderUser{
+			Id:    "customer",
+			Email: "Fraud@Unit.Test",
+			Ip:    "10.1.2.3",
+		},
+		PrivateMetadata: map[string]string{pkg.OrderPrivateMetadataBrowserCookieId: "cookie"},
+	}
+}
+
+func (suite *BlockListTestSuite) TestBlockList_CreateOrUpdateBlockListEntry_Ok() {
+	entry := suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:   pkg.BlockListTypeBlock,
+		Field:  pkg.BlockListFieldCard,
+		Value:  "4000 0000 0000 0002",
+		Reason: "stolen card",
+		UserId: "user",
+	})
+	assert.NotEmpty(suite.T(), entry.Id)
+	assert.Empty(suite.T(), entry.MerchantId)
+	assert.Nil(suite.T(), entry.ExpiresAt)
+	assert.Equal(suite.T(), "user", entry.CreatedBy)
+	assert.Equal(suite.T(), suite.service.getCardFingerprint("4000000000000002"), entry.Value)
+
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	res := &pkg.BlockListEntryResponse{}
+	err := suite.service.CreateOrUpdateBlockListEntry(context.TODO(), &pkg.CreateOrUpdateBlockListEntryRequest{
+		Id:        entry.Id,
+		Type:      pkg.BlockListTypeAllow,
+		Field:     pkg.BlockListFieldEmailDomain,
+		Value:     "@Unit.Test",
+		ExpiresAt: expiresAt,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), entry.Id, res.Item.Id)
+	assert.Equal(suite.T(), "unit.test", res.Item.Value)
+	assert.Equal(suite.T(), expiresAt, res.Item.ExpiresAt.Seconds)
+	assert.Equal(suite.T(), "user", res.Item.CreatedBy)
+}
+
+func (suite *BlockListTestSuite) TestBlockList_CreateOrUpdateBlockListEntry_Error() {
+	res := &pkg.BlockListEntryResponse{}
+	err := suite.service.CreateOrUpdateBlockListEntry(context.TODO(), &pkg.CreateOrUpdateBlockListEntryRequest{
+		Id:    primitive.NewObjectID().Hex(),
+		Type:  pkg.BlockListTypeBlock,
+		Field: pkg.BlockListFieldEmail,
+		Value: "fraud@unit.test",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorBlockListEntryNotFound, res.Message)
+
+	res = &pkg.BlockListEntryResponse{}
+	err = suite.service.CreateOrUpdateBlockListEntry(context.TODO(), &pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:  "unknown",
+		Field: pkg.BlockListFieldEmail,
+		Value: "fraud@unit.test",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorBlockListTypeInvalid, res.Message)
+
+	res = &pkg.BlockListEntryResponse{}
+	err = suite.service.CreateOrUpdateBlockListEntry(context.TODO(), &pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:  pkg.BlockListTypeBlock,
+		Field: "unknown",
+		Value: "fraud@unit.test",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorBlockListFieldInvalid, res.Message)
+
+	res = &pkg.BlockListEntryResponse{}
+	err = suite.service.CreateOrUpdateBlockListEntry(context.TODO(), &pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:  pkg.BlockListTypeBlock,
+		Field: pkg.BlockListFieldIp,
+		Value: "10.0.0.0/33",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorBlockListValueInvalid, res.Message)
+
+	res = &pkg.BlockListEntryResponse{}
+	err = suite.service.CreateOrUpdateBlockListEntry(context.TODO(), &pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:      pkg.BlockListTypeBlock,
+		Field:     pkg.BlockListFieldCustomer,
+		Value:     "customer",
+		ExpiresAt: time.Now().Add(-time.Hour).Unix(),
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorBlockListExpiresAtInvalid, res.Message)
+
+	res = &pkg.BlockListEntryResponse{}
+	err = suite.service.CreateOrUpdateBlockListEntry(context.TODO(), &pkg.CreateOrUpdateBlockListEntryRequest{
+		MerchantId: primitive.NewObjectID().Hex(),
+		Type:       pkg.BlockListTypeBlock,
+		Field:      pkg.BlockListFieldCustomer,
+		Value:      "customer",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), merchantErrorNotFound, res.Message)
+
+	entry := suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:  pkg.BlockListTypeBlock,
+		Field: pkg.BlockListFieldCustomer,
+		Value: "customer",
+	})
+
+	res = &pkg.BlockListEntryResponse{}
+	err = suite.service.CreateOrUpdateBlockListEntry(context.TODO(), &pkg.CreateOrUpdateBlockListEntryRequest{
+		Id:         entry.Id,
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.BlockListTypeAllow,
+		Field:      pkg.BlockListFieldCustomer,
+		Value:      "customer",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusForbidden, res.Status)
+	assert.Equal(suite.T(), errorBlockListMerchantForbidden, res.Message)
+}
+
+func (suite *BlockListTestSuite) TestBlockList_ListAndDeleteBlockListEntries_Ok() {
+	suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:  pkg.BlockListTypeBlock,
+		Field: pkg.BlockListFieldIp,
+		Value: "10.0.0.0/8",
+	})
+	entry := suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.BlockListTypeAllow,
+		Field:      pkg.BlockListFieldEmail,
+		Value:      "good@unit.test",
+	})
+
+	res := &pkg.ListBlockListEntriesResponse{}
+	err := suite.service.ListBlockListEntries(context.TODO(), &pkg.ListBlockListEntriesRequest{}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.EqualValues(suite.T(), 2, res.Count)
+	assert.Len(suite.T(), res.Items, 2)
+
+	res = &pkg.ListBlockListEntriesResponse{}
+	err = suite.service.ListBlockListEntries(context.TODO(), &pkg.ListBlockListEntriesRequest{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.BlockListTypeAllow,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, res.Count)
+	assert.Equal(suite.T(), entry.Id, res.Items[0].Id)
+
+	deleteRes := &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.DeleteBlockListEntry(context.TODO(), &pkg.DeleteBlockListEntryRequest{Id: entry.Id}, deleteRes)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusForbidden, deleteRes.Status)
+	assert.Equal(suite.T(), errorBlockListMerchantForbidden, deleteRes.Message)
+
+	deleteRes = &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.DeleteBlockListEntry(
+		context.TODO(),
+		&pkg.DeleteBlockListEntryRequest{Id: entry.Id, MerchantId: suite.merchant.Id},
+		deleteRes,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, deleteRes.Status)
+
+	deleteRes = &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.DeleteBlockListEntry(
+		context.TODO(),
+		&pkg.DeleteBlockListEntryRequest{Id: entry.Id, MerchantId: suite.merchant.Id},
+		deleteRes,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, deleteRes.Status)
+	assert.Equal(suite.T(), errorBlockListEntryNotFound, deleteRes.Message)
+
+	res = &pkg.ListBlockListEntriesResponse{}
+	err = suite.service.ListBlockListEntries(context.TODO(), &pkg.ListBlockListEntriesRequest{}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, res.Count)
+}
+
+func (suite *BlockListTestSuite) TestBlockList_CheckBlockLists_Blocked() {
+	order := suite.getOrder()
+	allowed, msg := suite.service.checkBlockLists(context.TODO(), order, "", "")
+	assert.False(suite.T(), allowed)
+	assert.Nil(suite.T(), msg)
+	assert.Empty(suite.T(), order.PrivateMetadata[pkg.OrderPrivateMetadataBlockListHits])
+
+	ipEntry := suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:  pkg.BlockListTypeBlock,
+		Field: pkg.BlockListFieldIp,
+		Value: "10.0.0.0/8",
+	})
+	cookieEntry := suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.BlockListTypeBlock,
+		Field:      pkg.BlockListFieldCookie,
+		Value:      "cookie",
+	})
+
+	allowed, msg = suite.service.checkBlockLists(context.TODO(), order, "", "")
+	assert.False(suite.T(), allowed)
+	assert.Equal(suite.T(), errorBlockListPaymentBlocked, msg)
+
+	hits := strings.Split(order.PrivateMetadata[pkg.OrderPrivateMetadataBlockListHits], blockListHitSeparator)
+	assert.Len(suite.T(), hits, 2)
+	assert.Contains(suite.T(), hits, "block:ip:"+ipEntry.Id)
+	assert.Contains(suite.T(), hits, "block:cookie:"+cookieEntry.Id)
+
+	_, msg = suite.service.checkBlockLists(context.TODO(), order, "", "")
+	assert.Equal(suite.T(), errorBlockListPaymentBlocked, msg)
+	assert.Len(suite.T(), strings.Split(order.PrivateMetadata[pkg.OrderPrivateMetadataBlockListHits], blockListHitSeparator), 2)
+
+	entry, err := suite.service.blockListRepository.GetById(context.TODO(), ipEntry.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 2, entry.HitCount)
+	assert.NotNil(suite.T(), entry.LastHitAt)
+}
+
+func (suite *BlockListTestSuite) TestBlockList_CheckBlockLists_Allowed() {
+	suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		Type:  pkg.BlockListTypeBlock,
+		Field: pkg.BlockListFieldBin,
+		Value: "400000-400099",
+	})
+
+	order := suite.getOrder()
+	_, msg := suite.service.checkBlockLists(context.TODO(), order, "", "400050******0002")
+	assert.Equal(suite.T(), errorBlockListPaymentBlocked, msg)
+
+	suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.BlockListTypeAllow,
+		Field:      pkg.BlockListFieldEmail,
+		Value:      "fraud@unit.test",
+	})
+
+	order = suite.getOrder()
+	allowed, msg := suite.service.checkBlockLists(context.TODO(), order, "", "400050******0002")
+	assert.True(suite.T(), allowed)
+	assert.Nil(suite.T(), msg)
+	assert.Len(suite.T(), strings.Split(order.PrivateMetadata[pkg.OrderPrivateMetadataBlockListHits], blockListHitSeparator), 2)
+}
+
+func (suite *BlockListTestSuite) TestBlockList_CheckBlockLists_OtherMerchant() {
+	suite.helperCreateBlockListEntry(&pkg.CreateOrUpdateBlockListEntryRequest{
+		MerchantId: suite.merchant.Id,
+		Type:       pkg.BlockListTypeBlock,
+		Field:      pkg.BlockListFieldCustomer,
+		Value:      "customer",
+	})
+
+	order := suite.getOrder()
+	order.Project.MerchantId = primitive.NewObjectID().Hex()
+	allowed, msg := suite.service.checkBlockLists(context.TODO(), order, "", "")
+	assert.False(suite.T(), allowed)
+	assert.Nil(suite.T(), msg)
+}
+
+func (suite *BlockListTestSuite) TestBlockList_MatchBlockListBin() {
+	assert.True(suite.T(), matchBlockListBin("400000", "4000001234567890"))
+	assert.True(suite.T(), matchBlockListBin("400000-400099", "400099******0002"))
+	assert.True(suite.T(), matchBlockListBin("40000000-40000010", "4000000512345678"))
+	assert.False(suite.T(), matchBlockListBin("400000-400099", "400100******0002"))
+	assert.False(suite.T(), matchBlockListBin("40000000", "400000******0002"))
+	assert.False(suite.T(), matchBlockListBin("400000", ""))
+}
+
+func (suite *BlockListTestSuite) TestBlockList_NormalizeBlockListValue() {
+	value, msg := suite.service.normalizeBlockListValue(pkg.BlockListFieldIp, "10.1.2.3/8")
+	assert.Nil(suite.T(), msg)
+	assert.Equal(suite.T(), "10.0.0.0/8", value)
+
+	value, msg = suite.service.normalizeBlockListValue(pkg.BlockListFieldEmail, " Fraud@Unit.Test ")
+	assert.Nil(suite.T(), msg)
+	assert.Equal(suite.T(), "fraud@unit.test", value)
+
+	_, msg = suite.service.normalizeBlockListValue(pkg.BlockListFieldBin, "400099-400000")
+	assert.Equal(suite.T(), errorBlockListValueInvalid, msg)
+
+	_, msg = suite.service.normalizeBlockListValue(pkg.BlockListFieldCard, "4000")
+	assert.Equal(suite.T(), errorBlockListValueInvalid, msg)
+
+	_, msg = suite.service.normalizeBlockListValue(pkg.BlockListFieldCookie, "")
+	assert.Equal(suite.T(), errorBlockListValueInvalid, msg)
+}
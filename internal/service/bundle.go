@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var (
+	bundleErrorUnknown            = newBillingServerErrorMsg("bn000001", "unknown error with bundle")
+	bundleErrorNotFound           = newBillingServerErrorMsg("bn000002", "bundle not found")
+	bundleErrorMerchantMismatch   = newBillingServerErrorMsg("bn000003", "merchant id mismatch")
+	bundleErrorProjectMismatch    = newBillingServerErrorMsg("bn000004", "project id mismatch")
+	bundleErrorSkuMismatch        = newBillingServerErrorMsg("bn000005", "sku mismatch")
+	bundleErrorDuplicate          = newBillingServerErrorMsg("bn000006", "bundle with same sku already exists")
+	bundleErrorNameNotProvided    = newBillingServerErrorMsg("bn000007", "name must be set for default language")
+	bundleErrorItemsEmpty         = newBillingServerErrorMsg("bn000008", "bundle must contain at least two products or key products")
+	bundleErrorItemsInvalid       = newBillingServerErrorMsg("bn000009", "some products of bundle are invalid or inactive")
+	bundleErrorPriceDefaultRegion = newBillingServerErrorMsg("bn000010", "bundle must have price in default currency of merchant")
+	bundleErrorPriceInvalid       = newBillingServerErrorMsg("bn000011", "bundle price is invalid")
+)
+
+func (s *Service) CreateOrUpdateBundle(
+	ctx context.Context,
+	req *pkg.CreateOrUpdateBundleRequest,
+	res *pkg.BundleResponse,
+) error {
+	var (
+		isNew  = len(req.Id) == 0
+		now    = ptypes.TimestampNow()
+		bundle = &pkg.Bundle{}
+	)
+
+	project, err := s.project.GetById(ctx, req.ProjectId)
+
+	if err != nil || project.MerchantId != req.MerchantId {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = bundleErrorProjectMismatch
+		return nil
+	}
+
+	if isNew {
+		bundle.Object = pkg.BundleObject
+		bundle.MerchantId = req.MerchantId
+		bundle.ProjectId = req.ProjectId
+		bundle.Sku = req.Sku
+		bundle.CreatedAt = now
+	} else {
+		var msg *billingpb.ResponseErrorMessage
+		bundle, msg = s.getMerchantBundle(ctx, req.MerchantId, req.Id)
+
+		if msg != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = msg
+			return nil
+		}
+
+		if req.Sku != "" && req.Sku != bundle.Sku {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = bundleErrorSkuMismatch
+			return nil
+		}
+
+		if req.ProjectId != bundle.ProjectId {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = bundleErrorProjectMismatch
+			return nil
+		}
+	}
+
+	if _, ok := req.Name[DefaultLanguage]; !ok {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = bundleErrorNameNotProvided
+		return nil
+	}
+
+	if len(req.Products)+len(req.KeyProducts) < 2 {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = bundleErrorItemsEmpty
+		return nil
+	}
+
+	if msg := s.validateBundleItems(ctx, req); msg != nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = msg
+		return nil
+	}
+
+	if msg := s.validateBundlePrices(ctx, req); msg != nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = msg
+		return nil
+	}
+
+	count, err := s.bundleRepository.CountByProjectIdSku(ctx, bundle.ProjectId, bundle.Sku)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = bundleErrorUnknown
+		return nil
+	}
+
+	allowed := int64(1)
+
+	if isNew {
+		allowed = 0
+	}
+
+	if count > allowed {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = bundleErrorDuplicate
+		return nil
+	}
+
+	bundle.Name = req.Name
+	bundle.Description = req.Description
+	bundle.Products = req.Products
+	bundle.KeyProducts = req.KeyProducts
+	bundle.Prices = req.Prices
+	bundle.Images = req.Images
+	bundle.Enabled = req.Enabled
+	bundle.UpdatedAt = now
+
+	if err = s.bundleRepository.Upsert(ctx, bundle); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = bundleErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = bundle
+
+	return nil
+}
+
+func (s *Service) GetBundle(ctx context.Context, req *pkg.GetBundleRequest, res *pkg.BundleResponse) error {
+	bundle, msg := s.getMerchantBundle(ctx, req.MerchantId, req.Id)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = bundle
+
+	return nil
+}
+
+func (s *Service) ListBundles(ctx context.Context, req *pkg.ListBundlesRequest, res *pkg.ListBundlesResponse) error {
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	count, err := s.bundleRepository.FindCount(ctx, req.MerchantId, req.ProjectId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = bundleErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count == 0 || req.Offset > count {
+		return nil
+	}
+
+	res.Items, err = s.bundleRepository.Find(ctx, req.MerchantId, req.ProjectId, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = bundleErrorUnknown
+		return nil
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteBundle(
+	ctx context.Context,
+	req *pkg.GetBundleRequest,
+	res *billingpb.EmptyResponseWithStatus,
+) error {
+	bundle, msg := s.getMerchantBundle(ctx, req.MerchantId, req.Id)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	bundle.Deleted = true
+	bundle.UpdatedAt = ptypes.TimestampNow()
+
+	if err := s.bundleRepository.Upsert(ctx, bundle); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = bundleErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) getMerchantBundle(
+	ctx context.Context,
+	merchantId, bundleId string,
+) (*pkg.Bundle, *billingpb.ResponseErrorMessage) {
+	bundle, err := s.bundleRepository.GetById(ctx, bundleId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, bundleErrorNotFound
+		}
+
+		return nil, bundleErrorUnknown
+	}
+
+	if bundle.MerchantId != merchantId {
+		return nil, bundleErrorMerchantMismatch
+	}
+
+	return bundle, nil
+}
+
+// validateBundleItems checks that products and key products of the bundle are enabled products of the project.
+func (s *Service) validateBundleItems(
+	ctx context.Context,
+	req *pkg.CreateOrUpdateBundleRequest,
+) *billingpb.ResponseErrorMessage {
+	if len(req.Products) > 0 {
+		if _, err := s.GetOrderProducts(ctx, req.ProjectId, req.Products); err != nil {
+			return bundleErrorItemsInvalid
+		}
+	}
+
+	if len(req.KeyProducts) > 0 {
+		keyProducts, err := s.keyProductRepository.FindByIdsProjectId(ctx, req.KeyProducts, req.ProjectId)
+
+		if err != nil || len(keyProducts) != len(req.KeyProducts) {
+			return bundleErrorItemsInvalid
+		}
+	}
+
+	return nil
+}
+
+// validateBundlePrices checks that prices of the bundle are set in currencies of price regions and the price
+// in the default currency of the merchant exists.
+func (s *Service) validateBundlePrices(
+	ctx context.Context,
+	req *pkg.CreateOrUpdateBundleRequest,
+) *billingpb.ResponseErrorMessage {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		return merchantErrorNotFound
+	}
+
+	defaultRegion := merchant.GetProcessingDefaultCurrency()
+	hasDefaultPrice := false
+
+	for _, price := range req.Prices {
+		if price.Amount <= 0 {
+			return bundleErrorPriceInvalid
+		}
+
+		group, err := s.priceGroupRepository.GetByRegion(ctx, price.Region)
+
+		if err != nil || group.Currency != price.Currency {
+			zap.L().Error(
+				bundleErrorPriceInvalid.Message,
+				zap.String("region", price.Region),
+				zap.String("currency", price.Currency),
+			)
+			return bundleErrorPriceInvalid
+		}
+
+		if price.Region == defaultRegion {
+			hasDefaultPrice = true
+		}
+	}
+
+	if !hasDefaultPrice {
+		return bundleErrorPriceDefaultRegion
+	}
+
+	return nil
+}
+
+// getBundlePrice returns the price of the bundle in the price group.
+func getBundlePrice(bundle *pkg.Bundle, group *billingpb.PriceGroup) (float64, error) {
+	for _, price := range bundle.Prices {
+		if price.Region == group.Region && price.Currency == group.Currency {
+			return price.Amount, nil
+		}
+	}
+
+	return 0, orderErrorNoProductsCommonCurrency
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type BundleTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant    *billingpb.Merchant
+	project     *billingpb.Project
+	products    []*billingpb.Product
+	keyProducts []*billingpb.KeyProduct
+}
+
+func Test_Bundle(t *testing.T) {
+	suite.Run(t, new(BundleTestSuite))
+}
+
+func (suite *BundleTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, _, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+	suite.products = CreateProductsForProject(suite.Suite, suite.service, suite.project, 2)
+	suite.keyProducts = CreateKeyProductsForProject(suite.Suite, suite.service, suite.project, 1)
+}
+
+func (suite *BundleTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *BundleTestSuite) getBundleRequest() *pkg.CreateOrUpdateBundleRequest {
+	return &pkg.CreateOrUpdateBundleRequest{
+		MerchantId:  suite.merchant.Id,
+		ProjectId:   suite.project.Id,
+		Sku:         "starter_pack",
+		Name:        map[string]string{"en": "Starter pack"},
+		Description: map[string]string{"en": "Game with bonus items"},
+		Products:    []string{suite.products[0].Id},
+		KeyProducts: []string{suite.keyProducts[0].Id},
+		Prices:      []*billingpb.ProductPrice{{Amount: 50, Currency: "USD", Region: "USD"}},
+		Enabled:     true,
+	}
+}
+
+func (suite *BundleTestSuite) helperCreateBundle() *pkg.Bundle {
+	res := &pkg.BundleResponse{}
+	err := suite.service.CreateOrUpdateBundle(context.TODO(), suite.getBundleRequest(), res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	return res.Item
+}
+
+func (suite *BundleTestSuite) TestBundle_CreateOrUpdateBundle_Ok() {
+	bundle := suite.helperCreateBundle()
+	assert.NotEmpty(suite.T(), bundle.Id)
+	assert.Equal(suite.T(), pkg.BundleObject, bundle.Object)
+	assert.Equal(suite.T(), []string{suite.products[0].Id}, bundle.Products)
+	assert.Equal(suite.T(), []string{suite.keyProducts[0].Id}, bundle.KeyProducts)
+
+	req := suite.getBundleRequest()
+	req.Id = bundle.Id
+	req.Products = append(req.Products, suite.products[1].Id)
+	res := &pkg.BundleResponse{}
+	err := suite.service.CreateOrUpdateBundle(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), bundle.Id, res.Item.Id)
+	assert.Len(suite.T(), res.Item.Products, 2)
+}
+
+func (suite *BundleTestSuite) TestBundle_CreateOrUpdateBundle_Error_ItemsEmpty() {
+	req := suite.getBundleRequest()
+	req.KeyProducts = nil
+	res := &pkg.BundleResponse{}
+	err := suite.service.CreateOrUpdateBundle(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), bundleErrorItemsEmpty, res.Message)
+}
+
+func (suite *BundleTestSuite) TestBundle_CreateOrUpdateBundle_Error_ItemsInvalid() {
+	req := suite.getBundleRequest()
+	req.KeyProducts = []string{primitive.NewObjectID().Hex()}
+	res := &pkg.BundleResponse{}
+	err := suite.service.CreateOrUpdateBundle(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), bundleErrorItemsInvalid, res.Message)
+}
+
+func (suite *BundleTestSuite) TestBundle_CreateOrUpdateBundle_Error_PriceDefaultRegion() {
+	req := suite.getBundleRequest()
+	req.Prices = []*billingpb.ProductPrice{{Amount: 45, Currency: "EUR", Region: "EUR"}}
+	res := &pkg.BundleResponse{}
+	err := suite.service.CreateOrUpdateBundle(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), bundleErrorPriceDefaultRegion, res.Message)
+}
+
+func (suite *BundleTestSuite) TestBundle_CreateOrUpdateBundle_Error_PriceInvalid() {
+	req := suite.getBundleRequest()
+	req.Prices = append(req.Prices, &billingpb.ProductPrice{Amount: 45, Currency: "USD", Region: "EUR"})
+	res := &pkg.BundleResponse{}
+	err := suite.service.CreateOrUpdateBundle(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), bundleErrorPriceInvalid, res.Message)
+}
+
+func (suite *BundleTestSuite) TestBundle_CreateOrUpdateBundle_Error_Duplicate() {
+	suite.helperCreateBundle()
+
+	res := &pkg.BundleResponse{}
+	err := suite.service.CreateOrUpdateBundle(context.TODO(), suite.getBundleRequest(), res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), bundleErrorDuplicate, res.Message)
+}
+
+func (suite *BundleTestSuite) TestBundle_GetBundle_Error_NotFound() {
+	req := &pkg.GetBundleRequest{Id: primitive.NewObjectID().Hex(), MerchantId: suite.merchant.Id}
+	res := &pkg.BundleResponse{}
+	err := suite.service.GetBundle(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), bundleErrorNotFound, res.Message)
+}
+
+func (suite *BundleTestSuite) TestBundle_GetBundle_Error_MerchantMismatch() {
+	bundle := suite.helperCreateBundle()
+
+	req := &pkg.GetBundleRequest{Id: bundle.Id, MerchantId: primitive.NewObjectID().Hex()}
+	res := &pkg.BundleResponse{}
+	err := suite.service.GetBundle(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), bundleErrorMerchantMismatch, res.Message)
+}
+
+func (suite *BundleTestSuite) TestBundle_ListBundles_Ok() {
+	bundle := suite.helperCreateBundle()
+
+	req := &pkg.ListBundlesRequest{MerchantId: suite.merchant.Id, ProjectId: suite.project.Id}
+	res := &pkg.ListBundlesResponse{}
+	err := suite.service.ListBundles(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.EqualValues(suite.T(), 1, res.Count)
+	assert.Len(suite.T(), res.Items, 1)
+	assert.Equal(suite.T(), bundle.Id, res.Items[0].Id)
+}
+
+func (suite *BundleTestSuite) TestBundle_DeleteBundle_Ok() {
+	bundle := suite.helperCreateBundle()
+
+	req := &pkg.GetBundleRequest{Id: bundle.Id, MerchantId: suite.merchant.Id}
+	res := &billingpb.EmptyResponseWithStatus{}
+	err := suite.service.DeleteBundle(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	res1 := &pkg.BundleResponse{}
+	err = suite.service.GetBundle(context.TODO(), req, res1)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res1.Status)
+}
+
+func (suite *BundleTestSuite) TestBundle_ProcessCartItems_Ok() {
+	bundle := suite.helperCreateBundle()
+	ids := []string{suite.products[1].Id, bundle.Id}
+
+	amount, priceGroup, items, platforms, err := suite.service.processCartItems(
+		context.TODO(), suite.project.Id, ids, nil, DefaultLanguage, "", time.Now(),
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "USD", priceGroup.Currency)
+	assert.Len(suite.T(), platforms, 1)
+	assert.Len(suite.T(), items, 3)
+	assert.EqualValues(suite.T(), 124, amount)
+
+	assert.Equal(suite.T(), suite.products[1].Id, items[0].Id)
+	assert.Equal(suite.T(), pkg.OrderItemTypeProduct, items[0].Metadata[pkg.OrderItemMetadataType])
+	assert.Empty(suite.T(), items[0].Metadata[pkg.OrderItemMetadataBundleId])
+
+	bundleAmount := float64(0)
+
+	for _, item := range items[1:] {
+		assert.Equal(suite.T(), bundle.Id, item.Metadata[pkg.OrderItemMetadataBundleId])
+		assert.Equal(suite.T(), "Starter pack", item.Metadata[pkg.OrderItemMetadataBundleName])
+		bundleAmount += item.Amount
+	}
+
+	assert.EqualValues(suite.T(), 50, bundleAmount)
+}
+
+func (suite *BundleTestSuite) TestBundle_ProcessCartItems_Error_Duplicated() {
+	ids := []string{suite.products[0].Id, suite.products[0].Id}
+
+	_, _, _, _, err := suite.service.processCartItems(
+		context.TODO(), suite.project.Id, ids, nil, DefaultLanguage, "", time.Now(),
+	)
+	assert.Equal(suite.T(), orderErrorCartItemsDuplicated, err)
+}
+
+func (suite *BundleTestSuite) TestBundle_AllocateBundleAmount_Ok() {
+	items := []*billingpb.OrderItem{{Amount: 10}, {Amount: 10}, {Amount: 10}}
+	suite.service.allocateBundleAmount(20, "USD", items)
+
+	assert.EqualValues(suite.T(), 6.67, items[0].Amount)
+	assert.EqualValues(suite.T(), 6.67, items[1].Amount)
+	assert.EqualValues(suite.T(), 6.66, items[2].Amount)
+}
+
+func (suite *BundleTestSuite) TestBundle_GetOrderKeyProductIds_Mixed() {
+	keyProductId := primitive.NewObjectID().Hex()
+	order := &billingpb.Order{
+		ProductType: pkg.OrderTypeMixed,
+		Products:    []string{primitive.NewObjectID().Hex()},
+		Items: []*billingpb.OrderItem{
+			{Id: primitive.NewObjectID().Hex(), Metadata: map[string]string{pkg.OrderItemMetadataType: pkg.OrderItemTypeProduct}},
+			{Id: keyProductId, Metadata: map[string]string{pkg.OrderItemMetadataType: pkg.OrderItemTypeKeyProduct}},
+		},
+	}
+
+	assert.Equal(suite.T(), []string{keyProductId}, getOrderKeyProductIds(order))
+	assert.True(suite.T(), orderHasKeyProducts(order))
+}
+
+func (suite *BundleTestSuite) TestBundle_GetOrderReceiptItems_Ok() {
+	bundleId := primitive.NewObjectID().Hex()
+	meta := map[string]string{
+		pkg.OrderItemMetadataBundleId:   bundleId,
+		pkg.OrderItemMetadataBundleName: "Starter pack",
+	}
+	items := []*billingpb.OrderItem{
+		{Name: "Bonus", Amount: 10, Currency: "USD"},
+		{Name: "Game", Amount: 30, Currency: "USD", Metadata: meta},
+		{Name: "Skin", Amount: 20, Currency: "USD", Metadata: meta},
+	}
+
+	result := getOrderReceiptItems(items)
+	assert.Len(suite.T(), result, 2)
+	assert.Equal(suite.T(), "Bonus", result[0].Name)
+	assert.Equal(suite.T(), bundleId, result[1].Id)
+	assert.Equal(suite.T(), "Starter pack (Game, Skin)", result[1].Name)
+	assert.EqualValues(suite.T(), 50, result[1].Amount)
+}
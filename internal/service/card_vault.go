@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cardUpdaterFileExtension = ".csv"
+)
+
+var (
+	cardVaultErrorTokenNotFound = newBillingServerErrorMsg("cv000001", "saved card not found")
+	cardVaultErrorUnknown       = newBillingServerErrorMsg("cv000002", "unknown error")
+)
+
+// ListCardVaultTokens returns bank cards saved by the customer from the browser cookie,
+// cards saved by the customer and by the virtual customer are returned both.
+func (s *Service) ListCardVaultTokens(
+	ctx context.Context,
+	req *pkg.ListCardVaultTokensRequest,
+	res *pkg.ListCardVaultTokensResponse,
+) error {
+	customerIds, status, msg := s.getCookieCardVaultCustomerIds(ctx, req.Cookie)
+
+	if msg != nil {
+		res.Status = status
+		res.Message = msg
+		return nil
+	}
+
+	tokens, err := s.cardVaultTokenRepository.FindByCustomerIds(ctx, customerIds)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = cardVaultErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Items = tokens
+
+	return nil
+}
+
+// SetDefaultCardVaultToken marks the saved card as default card of the customer, the default card
+// is the first in the payment form.
+func (s *Service) SetDefaultCardVaultToken(
+	ctx context.Context,
+	req *pkg.CardVaultTokenRequest,
+	res *pkg.CardVaultTokenResponse,
+) error {
+	customerIds, status, msg := s.getCookieCardVaultCustomerIds(ctx, req.Cookie)
+
+	if msg != nil {
+		res.Status = status
+		res.Message = msg
+		return nil
+	}
+
+	if _, err := primitive.ObjectIDFromHex(req.Id); err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = cardVaultErrorTokenNotFound
+		return nil
+	}
+
+	if err := s.cardVaultTokenRepository.SetDefault(ctx, customerIds, req.Id); err != nil {
+		res.Status, res.Message = getCardVaultErrorResponse(err)
+		return nil
+	}
+
+	token, err := s.cardVaultTokenRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status, res.Message = getCardVaultErrorResponse(err)
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = token
+
+	return nil
+}
+
+// DeleteCardVaultToken removes the saved card of the customer.
+func (s *Service) DeleteCardVaultToken(
+	ctx context.Context,
+	req *pkg.CardVaultTokenRequest,
+	res *billingpb.EmptyResponseWithStatus,
+) error {
+	customerIds, status, msg := s.getCookieCardVaultCustomerIds(ctx, req.Cookie)
+
+	if msg != nil {
+		res.Status = status
+		res.Message = msg
+		return nil
+	}
+
+	if _, err := primitive.ObjectIDFromHex(req.Id); err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = cardVaultErrorTokenNotFound
+		return nil
+	}
+
+	if err := s.cardVaultTokenRepository.Delete(ctx, customerIds, req.Id); err != nil {
+		res.Status, res.Message = getCardVaultErrorResponse(err)
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// ImportCardUpdates updates expiry dates of saved bank cards from files of the account updater in the local
+// directory. Files have columns pan, expire_month and expire_year, the card number is used to calculate
+// the fingerprint of saved cards and isn't stored. Names of imported files are recorded to skip files imported
+// earlier and imported files are removed. It returns a number of updated cards.
+func (s *Service) ImportCardUpdates(ctx context.Context) (int64, error) {
+	files, err := ioutil.ReadDir(s.cfg.CardUpdaterDir)
+
+	if err != nil {
+		zap.L().Error("Card updater directory reading failed", zap.Error(err), zap.String("dir", s.cfg.CardUpdaterDir))
+		return 0, err
+	}
+
+	count := int64(0)
+
+	for _, file := range files {
+		if file.IsDir() || strings.ToLower(filepath.Ext(file.Name())) != cardUpdaterFileExtension {
+			continue
+		}
+
+		path := filepath.Join(s.cfg.CardUpdaterDir, file.Name())
+		_, err = s.cardUpdaterFileRepository.GetByName(ctx, file.Name())
+
+		if err == nil {
+			zap.L().Warn("Card updater file was imported earlier", zap.String("file", path))
+			s.removeCardUpdatesFile(path)
+			continue
+		}
+
+		if err != mongo.ErrNoDocuments {
+			return count, err
+		}
+
+		n, err := s.importCardUpdatesFile(ctx, path)
+
+		if err != nil {
+			return count, err
+		}
+
+		count += n
+		imported := &pkg.CardUpdaterFile{
+			Name:      file.Name(),
+			Count:     n,
+			CreatedAt: ptypes.TimestampNow(),
+		}
+
+		if err = s.cardUpdaterFileRepository.Insert(ctx, imported); err != nil {
+			return count, err
+		}
+
+		s.removeCardUpdatesFile(path)
+	}
+
+	return count, nil
+}
+
+// removeCardUpdatesFile removes the imported file with card numbers, the file isn't imported again
+// when it wasn't removed.
+func (s *Service) removeCardUpdatesFile(path string) {
+	if err := os.Remove(path); err != nil {
+		zap.L().Error("Card updater file removing failed", zap.Error(err), zap.String("file", path))
+	}
+}
+
+func (s *Service) importCardUpdatesFile(ctx context.Context, path string) (int64, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		zap.L().Error("Card updater file opening failed", zap.Error(err), zap.String("file", path))
+		return 0, err
+	}
+
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		zap.L().Error("Card updater file parsing failed", zap.Error(err), zap.String("file", path))
+		return 0, err
+	}
+
+	columns := make(map[string]int)
+
+	for i, v := range header {
+		columns[strings.ToLower(strings.TrimSpace(v))] = i
+	}
+
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	count := int64(0)
+	updatedAt := time.Now()
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			zap.L().Error("Card updater file parsing failed", zap.Error(err), zap.String("file", path))
+			return count, err
+		}
+
+		validator := &bankCardValidator{
+			Pan:   value(record, "pan"),
+			Month: value(record, "expire_month"),
+			Year:  value(record, "expire_year"),
+		}
+
+		if len(validator.Year) < 3 {
+			validator.Year = strconv.Itoa(time.Now().UTC().Year())[:2] + validator.Year
+		}
+
+		if err = validator.validateExpire(); err == nil && !validator.validateNumber() {
+			err = bankCardPanIsInvalid
+		}
+
+		// the card number isn't logged, the line of the file identifies the skipped update
+		if err != nil {
+			zap.L().Warn("Card update skipped", zap.Error(err), zap.String("file", path), zap.Int("line", line))
+			continue
+		}
+
+		n, err := s.cardVaultTokenRepository.UpdateExpireByFingerprint(
+			ctx,
+			s.getCardFingerprint(validator.Pan),
+			validator.Month,
+			validator.Year,
+			updatedAt,
+		)
+
+		if err != nil {
+			return count, err
+		}
+
+		count += n
+	}
+
+	zap.L().Info("Card updater file imported", zap.String("file", path), zap.Int64("count", count))
+
+	return count, nil
+}
+
+// saveCardVaultToken saves the bank card of the paid order by the recurring identifier of the card
+// in the payment system. The card saved by the customer earlier is updated.
+func (s *Service) saveCardVaultToken(ctx context.Context, order *billingpb.Order, recurringId string) error {
+	token := &pkg.CardVaultToken{
+		CustomerId:   order.User.Id,
+		ProjectId:    order.Project.Id,
+		MerchantId:   order.Project.MerchantId,
+		Fingerprint:  order.PrivateMetadata[pkg.OrderPrivateMetadataCardFingerprint],
+		PanReference: recurringId,
+		MaskedPan:    order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldPan],
+		CardHolder:   order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldHolder],
+		ExpireMonth:  order.PaymentRequisites[billingpb.PaymentCreateFieldMonth],
+		ExpireYear:   order.PaymentRequisites[billingpb.PaymentCreateFieldYear],
+		CreatedAt:    ptypes.TimestampNow(),
+	}
+
+	// the fingerprint of the saved card used for the payment is kept
+	if id := order.PrivateMetadata[pkg.OrderPrivateMetadataCardVaultTokenId]; id != "" {
+		stored, err := s.cardVaultTokenRepository.GetById(ctx, id)
+
+		if err == nil && stored.CustomerId == token.CustomerId {
+			token.Fingerprint = stored.Fingerprint
+		}
+	}
+
+	tokens, err := s.cardVaultTokenRepository.FindByCustomerIds(ctx, []string{token.CustomerId})
+
+	if err != nil {
+		return err
+	}
+
+	// the first saved card of the customer is default
+	token.IsDefault = len(tokens) <= 0
+
+	return s.cardVaultTokenRepository.Upsert(ctx, token)
+}
+
+// setOrderCardFingerprint records the fingerprint of the bank card of the payment, it's used to find duplicates
+// when the card is saved after the payment.
+func setOrderCardFingerprint(order *billingpb.Order, cardFingerprint string) {
+	if cardFingerprint == "" {
+		return
+	}
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataCardFingerprint] = cardFingerprint
+}
+
+// setOrderCardVaultToken fills requisites of the payment by the saved card.
+func setOrderCardVaultToken(order *billingpb.Order, token *pkg.CardVaultToken) {
+	order.PaymentRequisites[billingpb.PaymentCreateFieldPan] = token.MaskedPan
+	order.PaymentRequisites[billingpb.PaymentCreateFieldMonth] = token.ExpireMonth
+	order.PaymentRequisites[billingpb.PaymentCreateFieldYear] = token.ExpireYear
+	order.PaymentRequisites[billingpb.PaymentCreateFieldHolder] = token.CardHolder
+	order.PaymentRequisites[billingpb.PaymentCreateFieldRecurringId] = token.PanReference
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataCardVaultTokenId] = token.Id
+
+	if token.Fingerprint != "" {
+		order.PrivateMetadata[pkg.OrderPrivateMetadataCardFingerprint] = token.Fingerprint
+	}
+}
+
+// getOrderCardVaultToken returns the saved card of the customer of the order. It returns nil without error
+// when the card isn't found in the vault, such cards are saved in the recurring service before the vault.
+func (s *Service) getOrderCardVaultToken(
+	ctx context.Context,
+	order *billingpb.Order,
+	id string,
+) (*pkg.CardVaultToken, error) {
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, nil
+	}
+
+	token, err := s.cardVaultTokenRepository.GetById(ctx, id)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+
+		return nil, orderGetSavedCardError
+	}
+
+	if !helper.Contains(getOrderCardVaultCustomerIds(order), token.CustomerId) {
+		zap.L().Error(
+			"Alarm: user try use not own bank card for payment",
+			zap.String("user_id", order.User.Id),
+			zap.String("card_id", id),
+		)
+		return nil, orderErrorRecurringCardNotOwnToUser
+	}
+
+	return token, nil
+}
+
+// getOrderCardVaultCustomerIds returns identifiers of the customer of the order, cards are saved by the virtual
+// customer until the customer is identified.
+func getOrderCardVaultCustomerIds(order *billingpb.Order) []string {
+	var customerIds []string
+
+	if order.User != nil && order.User.Id != "" {
+		customerIds = append(customerIds, order.User.Id)
+	}
+
+	if id := order.PrivateMetadata[pkg.OrderPrivateMetadataBrowserCookieId]; id != "" && !helper.Contains(customerIds, id) {
+		customerIds = append(customerIds, id)
+	}
+
+	return customerIds
+}
+
+func (s *Service) getCookieCardVaultCustomerIds(
+	ctx context.Context,
+	cookie string,
+) ([]string, int32, *billingpb.ResponseErrorMessage) {
+	customer, err := s.decryptBrowserCookie(cookie)
+
+	if err != nil {
+		return nil, billingpb.ResponseStatusBadData, recurringErrorIncorrectCookie
+	}
+
+	var customerIds []string
+
+	if customer.CustomerId != "" {
+		if _, err = s.getCustomerById(ctx, customer.CustomerId); err != nil {
+			return nil, billingpb.ResponseStatusNotFound, recurringCustomerNotFound
+		}
+
+		customerIds = append(customerIds, customer.CustomerId)
+	}
+
+	if customer.VirtualCustomerId != "" {
+		customerIds = append(customerIds, customer.VirtualCustomerId)
+	}
+
+	if len(customerIds) <= 0 {
+		return nil, billingpb.ResponseStatusNotFound, recurringCustomerNotFound
+	}
+
+	return customerIds, billingpb.ResponseStatusOk, nil
+}
+
+func getCardVaultErrorResponse(err error) (int32, *billingpb.ResponseErrorMessage) {
+	if err == mongo.ErrNoDocuments {
+		return billingpb.ResponseStatusNotFound, cardVaultErrorTokenNotFound
+	}
+
+	return billingpb.ResponseStatusSystemError, cardVaultErrorUnknown
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/kms"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type CardVaultTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant   *billingpb.Merchant
+	project    *billingpb.Project
+	pmBankCard *billingpb.PaymentMethod
+}
+
+func Test_CardVault(t *testing.T) {
+	suite.Run(t, new(CardVaultTestSuite))
+}
+
+func (suite *CardVaultTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+	suite.service.rep = mocks.NewRepositoryServiceEmpty()
+
+	suite.merchant, suite.project, suite.pmBankCard, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+
+	fieldCipher, err := kms.NewFieldCipher(map[string][]byte{"1": bytes.Repeat([]byte{1}, 32)}, "1", "secret")
+	assert.NoError(suite.T(), err)
+	models.SetFieldCipher(fieldCipher, nil)
+}
+
+func (suite *CardVaultTestSuite) TearDownTest() {
+	models.SetFieldCipher(nil, nil)
+
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *CardVaultTestSuite) helperCreateToken(customerId, fingerprint string) *pkg.CardVaultToken {
+	token := &pkg.CardVaultToken{
+		CustomerId:   customerId,
+		ProjectId:    suite.project.Id,
+		MerchantId:   suite.merchant.Id,
+		Fingerprint:  fingerprint,
+		PanReference: primitive.NewObjectID().Hex(),
+		MaskedPan:    "400000******0002",
+		CardHolder:   "MR. CARD HOLDER",
+		ExpireMonth:  "02",
+		ExpireYear:   "2030",
+	}
+	err := suite.service.cardVaultTokenRepository.Upsert(context.TODO(), token)
+	assert.NoError(suite.T(), err)
+
+	return token
+}
+
+func (suite *CardVaultTestSuite) helperGetCookie(customerId, virtualCustomerId string) string {
+	cookie, err := suite.service.generateBrowserCookie(&BrowserCookieCustomer{
+		CustomerId:        customerId,
+		VirtualCustomerId: virtualCustomerId,
+		Ip:                "127.0.0.1",
+	})
+	assert.NoError(suite.T(), err)
+
+	return cookie
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_SaveCardVaultToken_Ok() {
+	order := HelperCreateAndPayOrder(suite.Suite, suite.service, 100, "RUB", "RU", suite.project, suite.pmBankCard)
+	assert.Equal(suite.T(), suite.service.getCardFingerprint("4000000000000002"), order.PrivateMetadata[pkg.OrderPrivateMetadataCardFingerprint])
+
+	err := suite.service.saveCardVaultToken(context.TODO(), order, "recurring_id_1")
+	assert.NoError(suite.T(), err)
+
+	tokens, err := suite.service.cardVaultTokenRepository.FindByCustomerIds(context.TODO(), []string{order.User.Id})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), tokens, 1)
+	assert.True(suite.T(), tokens[0].IsDefault)
+	assert.Equal(suite.T(), "recurring_id_1", tokens[0].PanReference)
+	assert.Equal(suite.T(), order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldPan], tokens[0].MaskedPan)
+	assert.Equal(suite.T(), order.PaymentRequisites[billingpb.PaymentCreateFieldYear], tokens[0].ExpireYear)
+
+	// the same card saved again isn't duplicated
+	err = suite.service.saveCardVaultToken(context.TODO(), order, "recurring_id_2")
+	assert.NoError(suite.T(), err)
+
+	tokens1, err := suite.service.cardVaultTokenRepository.FindByCustomerIds(context.TODO(), []string{order.User.Id})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), tokens1, 1)
+	assert.Equal(suite.T(), tokens[0].Id, tokens1[0].Id)
+	assert.True(suite.T(), tokens1[0].IsDefault)
+	assert.Equal(suite.T(), "recurring_id_2", tokens1[0].PanReference)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_SaveCardVaultToken_Error_EncryptionDisabled() {
+	order := HelperCreateAndPayOrder(suite.Suite, suite.service, 100, "RUB", "RU", suite.project, suite.pmBankCard)
+	models.SetFieldCipher(nil, nil)
+
+	err := suite.service.saveCardVaultToken(context.TODO(), order, "recurring_id_1")
+	assert.Error(suite.T(), err)
+
+	tokens, err := suite.service.cardVaultTokenRepository.FindByCustomerIds(context.TODO(), []string{order.User.Id})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), tokens)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_ListCardVaultTokens_Ok() {
+	virtualCustomerId := primitive.NewObjectID().Hex()
+	token1 := suite.helperCreateToken(virtualCustomerId, "fingerprint1")
+	token2 := suite.helperCreateToken(virtualCustomerId, "fingerprint2")
+	suite.helperCreateToken(primitive.NewObjectID().Hex(), "fingerprint1")
+
+	err := suite.service.cardVaultTokenRepository.SetDefault(context.TODO(), []string{virtualCustomerId}, token2.Id)
+	assert.NoError(suite.T(), err)
+
+	req := &pkg.ListCardVaultTokensRequest{Cookie: suite.helperGetCookie("", virtualCustomerId)}
+	res := &pkg.ListCardVaultTokensResponse{}
+	err = suite.service.ListCardVaultTokens(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.Items, 2)
+	assert.Equal(suite.T(), token2.Id, res.Items[0].Id)
+	assert.True(suite.T(), res.Items[0].IsDefault)
+	assert.Equal(suite.T(), token1.Id, res.Items[1].Id)
+	assert.False(suite.T(), res.Items[1].IsDefault)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_ListCardVaultTokens_IncorrectCookie_Error() {
+	req := &pkg.ListCardVaultTokensRequest{Cookie: primitive.NewObjectID().Hex()}
+	res := &pkg.ListCardVaultTokensResponse{}
+	err := suite.service.ListCardVaultTokens(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), recurringErrorIncorrectCookie, res.Message)
+
+	req.Cookie = suite.helperGetCookie("", "")
+	err = suite.service.ListCardVaultTokens(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), recurringCustomerNotFound, res.Message)
+
+	req.Cookie = suite.helperGetCookie(primitive.NewObjectID().Hex(), "")
+	err = suite.service.ListCardVaultTokens(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), recurringCustomerNotFound, res.Message)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_SetDefaultCardVaultToken_Ok() {
+	virtualCustomerId := primitive.NewObjectID().Hex()
+	token1 := suite.helperCreateToken(virtualCustomerId, "fingerprint1")
+	token2 := suite.helperCreateToken(virtualCustomerId, "fingerprint2")
+	other := suite.helperCreateToken(primitive.NewObjectID().Hex(), "fingerprint1")
+
+	req := &pkg.CardVaultTokenRequest{Id: token1.Id, Cookie: suite.helperGetCookie("", virtualCustomerId)}
+	res := &pkg.CardVaultTokenResponse{}
+	err := suite.service.SetDefaultCardVaultToken(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.True(suite.T(), res.Item.IsDefault)
+
+	req.Id = token2.Id
+	err = suite.service.SetDefaultCardVaultToken(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	token, err := suite.service.cardVaultTokenRepository.GetById(context.TODO(), token1.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), token.IsDefault)
+
+	// card of another customer can't be changed
+	req.Id = other.Id
+	err = suite.service.SetDefaultCardVaultToken(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), cardVaultErrorTokenNotFound, res.Message)
+
+	req.Id = "unknown"
+	err = suite.service.SetDefaultCardVaultToken(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_DeleteCardVaultToken_Ok() {
+	customerId := primitive.NewObjectID().Hex()
+	customer := &billingpb.Customer{Id: customerId, TechEmail: customerId + pkg.TechEmailDomain}
+	err := suite.service.customerRepository.Insert(context.TODO(), customer)
+	assert.NoError(suite.T(), err)
+
+	virtualCustomerId := primitive.NewObjectID().Hex()
+	token1 := suite.helperCreateToken(customer.Id, "fingerprint1")
+	token2 := suite.helperCreateToken(virtualCustomerId, "fingerprint2")
+	other := suite.helperCreateToken(primitive.NewObjectID().Hex(), "fingerprint1")
+
+	// cards of the customer and of the virtual customer are deleted by the same cookie
+	cookie := suite.helperGetCookie(customer.Id, virtualCustomerId)
+
+	for _, id := range []string{token1.Id, token2.Id} {
+		req := &pkg.CardVaultTokenRequest{Id: id, Cookie: cookie}
+		res := &billingpb.EmptyResponseWithStatus{}
+		err := suite.service.DeleteCardVaultToken(context.TODO(), req, res)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	}
+
+	req := &pkg.CardVaultTokenRequest{Id: other.Id, Cookie: cookie}
+	res := &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.DeleteCardVaultToken(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), cardVaultErrorTokenNotFound, res.Message)
+
+	tokens, err := suite.service.cardVaultTokenRepository.FindByCustomerIds(context.TODO(), []string{customer.Id, virtualCustomerId})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), tokens)
+
+	_, err = suite.service.cardVaultTokenRepository.GetById(context.TODO(), other.Id)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_ImportCardUpdates_Ok() {
+	token := suite.helperCreateToken(primitive.NewObjectID().Hex(), suite.service.getCardFingerprint("4000000000000002"))
+	other := suite.helperCreateToken(primitive.NewObjectID().Hex(), suite.service.getCardFingerprint("4000000000000077"))
+	year := time.Now().AddDate(3, 0, 0).Format("2006")
+
+	dir, err := ioutil.TempDir("", "card_updater")
+	assert.NoError(suite.T(), err)
+	defer os.RemoveAll(dir)
+
+	suite.service.cfg.CardUpdaterDir = dir
+
+	data := "pan,expire_month,expire_year\n" +
+		"4000000000000002,11," + year + "\n" +
+		"4000000000000077,13," + year + "\n" +
+		"4000000000000001,11," + year + "\n"
+	err = ioutil.WriteFile(filepath.Join(dir, "updates.csv"), []byte(data), 0644)
+	assert.NoError(suite.T(), err)
+	err = ioutil.WriteFile(filepath.Join(dir, "updates.txt"), []byte(data), 0644)
+	assert.NoError(suite.T(), err)
+
+	count, err := suite.service.ImportCardUpdates(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, count)
+
+	token1, err := suite.service.cardVaultTokenRepository.GetById(context.TODO(), token.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "11", token1.ExpireMonth)
+	assert.Equal(suite.T(), year, token1.ExpireYear)
+	assert.NotNil(suite.T(), token1.ExpireUpdatedAt)
+
+	// update with invalid expiry is skipped
+	other1, err := suite.service.cardVaultTokenRepository.GetById(context.TODO(), other.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), other.ExpireMonth, other1.ExpireMonth)
+	assert.Nil(suite.T(), other1.ExpireUpdatedAt)
+
+	// imported file is removed and recorded, files of other types are kept
+	_, err = os.Stat(filepath.Join(dir, "updates.csv"))
+	assert.True(suite.T(), os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "updates.txt"))
+	assert.NoError(suite.T(), err)
+
+	file, err := suite.service.cardUpdaterFileRepository.GetByName(context.TODO(), "updates.csv")
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, file.Count)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_ImportCardUpdates_ImportedFileSkipped() {
+	token := suite.helperCreateToken(primitive.NewObjectID().Hex(), suite.service.getCardFingerprint("4000000000000002"))
+	year := time.Now().AddDate(3, 0, 0).Format("2006")
+
+	dir, err := ioutil.TempDir("", "card_updater")
+	assert.NoError(suite.T(), err)
+	defer os.RemoveAll(dir)
+
+	suite.service.cfg.CardUpdaterDir = dir
+
+	err = ioutil.WriteFile(filepath.Join(dir, "updates.csv"), []byte("pan,expire_month,expire_year\n4000000000000002,11,"+year+"\n"), 0644)
+	assert.NoError(suite.T(), err)
+
+	count, err := suite.service.ImportCardUpdates(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, count)
+
+	// the file delivered again isn't imported
+	err = ioutil.WriteFile(filepath.Join(dir, "updates.csv"), []byte("pan,expire_month,expire_year\n4000000000000002,10,"+year+"\n"), 0644)
+	assert.NoError(suite.T(), err)
+
+	count, err = suite.service.ImportCardUpdates(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), count)
+
+	_, err = os.Stat(filepath.Join(dir, "updates.csv"))
+	assert.True(suite.T(), os.IsNotExist(err))
+
+	token1, err := suite.service.cardVaultTokenRepository.GetById(context.TODO(), token.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "11", token1.ExpireMonth)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_ImportCardUpdates_DirNotFound_Error() {
+	suite.service.cfg.CardUpdaterDir = "/unknown/card_updater/dir"
+
+	_, err := suite.service.ImportCardUpdates(context.TODO())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_GetOrderCardVaultToken() {
+	virtualCustomerId := primitive.NewObjectID().Hex()
+	order := &billingpb.Order{
+		User:            &billingpb.OrderUser{Id: primitive.NewObjectID().Hex()},
+		PrivateMetadata: map[string]string{pkg.OrderPrivateMetadataBrowserCookieId: virtualCustomerId},
+	}
+	token1 := suite.helperCreateToken(order.User.Id, "fingerprint1")
+	token2 := suite.helperCreateToken(virtualCustomerId, "fingerprint2")
+	other := suite.helperCreateToken(primitive.NewObjectID().Hex(), "fingerprint1")
+
+	for _, id := range []string{token1.Id, token2.Id} {
+		token, err := suite.service.getOrderCardVaultToken(context.TODO(), order, id)
+		assert.NoError(suite.T(), err)
+		assert.NotNil(suite.T(), token)
+		assert.Equal(suite.T(), id, token.Id)
+	}
+
+	_, err := suite.service.getOrderCardVaultToken(context.TODO(), order, other.Id)
+	assert.Equal(suite.T(), orderErrorRecurringCardNotOwnToUser, err)
+
+	// cards of the recurring service aren't found in the vault
+	token, err := suite.service.getOrderCardVaultToken(context.TODO(), order, primitive.NewObjectID().Hex())
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), token)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_ProcessPaymentMethodsData_Ok() {
+	order := &billingpb.Order{
+		Id:      primitive.NewObjectID().Hex(),
+		User:    &billingpb.OrderUser{Id: primitive.NewObjectID().Hex()},
+		Project: &billingpb.ProjectOrder{Id: suite.project.Id},
+	}
+	token := suite.helperCreateToken(order.User.Id, "fingerprint1")
+
+	processor := &PaymentFormProcessor{service: suite.service, order: order}
+	pm := &billingpb.PaymentFormPaymentMethod{
+		Id:    suite.pmBankCard.Id,
+		Group: suite.pmBankCard.Group,
+	}
+
+	err := processor.processPaymentMethodsData(context.TODO(), pm)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), pm.HasSavedCards)
+	assert.Len(suite.T(), pm.SavedCards, 1)
+	assert.Equal(suite.T(), token.Id, pm.SavedCards[0].Id)
+	assert.Equal(suite.T(), token.MaskedPan, pm.SavedCards[0].Pan)
+	assert.Equal(suite.T(), token.ExpireYear, pm.SavedCards[0].Expire.Year)
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_SetOrderCardVaultToken_KeepsFingerprint() {
+	token := suite.helperCreateToken(primitive.NewObjectID().Hex(), suite.service.getCardFingerprint("4000000000000002"))
+	order := &billingpb.Order{PaymentRequisites: map[string]string{}}
+
+	setOrderCardVaultToken(order, token)
+	assert.Equal(suite.T(), token.Id, order.PrivateMetadata[pkg.OrderPrivateMetadataCardVaultTokenId])
+	assert.Equal(suite.T(), token.Fingerprint, order.PrivateMetadata[pkg.OrderPrivateMetadataCardFingerprint])
+	assert.Equal(suite.T(), token.Fingerprint, suite.service.getRiskCardFingerprint(order, map[string]string{}))
+}
+
+func (suite *CardVaultTestSuite) TestCardVault_SaveCardVaultToken_WithoutFingerprint() {
+	order := HelperCreateAndPayOrder(suite.Suite, suite.service, 100, "RUB", "RU", suite.project, suite.pmBankCard)
+	delete(order.PrivateMetadata, pkg.OrderPrivateMetadataCardFingerprint)
+
+	// cards with the same masked number aren't merged without the fingerprint of the full number
+	assert.NoError(suite.T(), suite.service.saveCardVaultToken(context.TODO(), order, "recurring_id_1"))
+	assert.NoError(suite.T(), suite.service.saveCardVaultToken(context.TODO(), order, "recurring_id_2"))
+
+	tokens, err := suite.service.cardVaultTokenRepository.FindByCustomerIds(context.TODO(), []string{order.User.Id})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), tokens, 2)
+	assert.Empty(suite.T(), tokens[0].Fingerprint)
+}
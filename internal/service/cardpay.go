@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/paysuper/paysuper-billing-server/pkg"
@@ -34,6 +35,20 @@ const (
 
 	cardPayDateFormat          = "2006-01-02T15:04:05Z"
 	cardPayInitiatorCardholder = "cit"
+	cardPayInitiatorMerchant   = "mit"
+
+	// cardPayThreeDsChallengeMandated requests the 3-D Secure challenge of the cardholder regardless of the issuer decision.
+	cardPayThreeDsChallengeMandated = "04"
+	// cardPayThreeDsNoChallengeRequested requests the frictionless 3-D Secure authentication, the issuer may still
+	// require the challenge.
+	cardPayThreeDsNoChallengeRequested = "02"
+
+	cardPayScaExemptionLowValue = "LOW_VALUE"
+	cardPayScaExemptionTra      = "TRA"
+
+	cardPayOperationChangeStatus = "CHANGE_STATUS"
+	cardPayStatusToComplete      = "COMPLETE"
+	cardPayStatusToReverse       = "REVERSE"
 
 	cardPayMaxItemNameLength        = 50
 	cardPayMaxItemDescriptionLength = 200
@@ -89,19 +104,46 @@ type CardPayRecurringDataFiling struct {
 }
 
 type CardPayPaymentData struct {
-	Currency   string  `json:"currency"`
-	Amount     float64 `json:"amount"`
-	Descriptor string  `json:"dynamic_descriptor"`
-	Note       string  `json:"note"`
+	Currency                  string  `json:"currency"`
+	Amount                    float64 `json:"amount"`
+	Descriptor                string  `json:"dynamic_descriptor"`
+	Note                      string  `json:"note"`
+	ThreeDsChallengeIndicator string  `json:"three_ds_challenge_indicator,omitempty"`
+	ScaExemption              string  `json:"sca_exemption,omitempty"`
+	Preauth                   bool    `json:"preauth,omitempty"`
 }
 
 type CardPayRecurringData struct {
-	Currency   string                      `json:"currency"`
-	Amount     float64                     `json:"amount"`
-	Filing     *CardPayRecurringDataFiling `json:"filing,omitempty"`
-	Descriptor string                      `json:"dynamic_descriptor"`
-	Note       string                      `json:"note"`
-	Initiator  string                      `json:"initiator"`
+	Currency                  string                      `json:"currency"`
+	Amount                    float64                     `json:"amount"`
+	Filing                    *CardPayRecurringDataFiling `json:"filing,omitempty"`
+	Descriptor                string                      `json:"dynamic_descriptor"`
+	Note                      string                      `json:"note"`
+	Initiator                 string                      `json:"initiator"`
+	ThreeDsChallengeIndicator string                      `json:"three_ds_challenge_indicator,omitempty"`
+	ScaExemption              string                      `json:"sca_exemption,omitempty"`
+	Preauth                   bool                        `json:"preauth,omitempty"`
+}
+
+type CardPayChangeStatusData struct {
+	StatusTo string `json:"status_to"`
+}
+
+type CardPayChangeStatusRequest struct {
+	Request     *CardPayRequest          `json:"request"`
+	Operation   string                   `json:"operation"`
+	PaymentData *CardPayChangeStatusData `json:"payment_data"`
+}
+
+type CardPayChangeStatusResponseData struct {
+	Id         string `json:"id"`
+	Status     string `json:"status"`
+	IsExecuted bool   `json:"is_executed"`
+	Details    string `json:"details"`
+}
+
+type CardPayChangeStatusResponse struct {
+	PaymentData *CardPayChangeStatusResponseData `json:"payment_data"`
 }
 
 type CardPayCustomer struct {
@@ -387,7 +429,11 @@ func (h *cardPay) ProcessPayment(order *billingpb.Order, message proto.Message,
 		return err
 	}
 
-	if !req.IsPaymentAllowedStatus() {
+	// the authorization is the final status of the payment until the manual review of the order is resolved
+	isAuthorized := req.GetStatus() == billingpb.CardPayPaymentResponseStatusAuthorized &&
+		order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus] == pkg.OrderReviewStatusPending
+
+	if !isAuthorized && !req.IsPaymentAllowedStatus() {
 		return newBillingServerResponseError(pkg.StatusErrorValidation, paymentSystemErrorRequestStatusIsInvalid)
 	}
 
@@ -450,7 +496,11 @@ func (h *cardPay) ProcessPayment(order *billingpb.Order, message proto.Message,
 		order.IsRefundAllowed = order.PaymentMethod.RefundAllowed
 		break
 	default:
-		return newBillingServerResponseError(pkg.StatusTemporary, paymentSystemErrorRequestTemporarySkipped)
+		if !isAuthorized {
+			return newBillingServerResponseError(pkg.StatusTemporary, paymentSystemErrorRequestTemporarySkipped)
+		}
+
+		order.PrivateStatus = recurringpb.OrderStatusPaymentSystemCreate
 	}
 
 	if status == billingpb.CardPayPaymentResponseStatusDeclined || status == billingpb.CardPayPaymentResponseStatusCancelled {
@@ -476,6 +526,14 @@ func (h *cardPay) ProcessPayment(order *billingpb.Order, message proto.Message,
 	return nil
 }
 
+func (h *cardPay) CapturePayment(order *billingpb.Order) error {
+	return h.changePaymentStatus(order, cardPayStatusToComplete)
+}
+
+func (h *cardPay) CancelPayment(order *billingpb.Order) error {
+	return h.changePaymentStatus(order, cardPayStatusToReverse)
+}
+
 func (h *cardPay) IsRecurringCallback(request proto.Message) bool {
 	req := request.(*billingpb.CardPayPaymentCallback)
 	return req.PaymentMethod == recurringpb.PaymentSystemGroupAliasBankCard && req.IsRecurring()
@@ -609,7 +667,114 @@ func (h *cardPay) refresh(order *billingpb.Order) error {
 	return nil
 }
 
-func (h *cardPay) getUrl(apiUrl, action string) (string, error) {
+// changePaymentStatus completes or reverses the authorized payment of the order.
+func (h *cardPay) changePaymentStatus(order *billingpb.Order, statusTo string) error {
+	err := h.auth(order)
+
+	if err != nil {
+		return paymentSystemErrorChangeStatusFailed
+	}
+
+	u, err := h.getUrl(order.GetPaymentSystemApiUrl(), pkg.PaymentSystemActionChangeStatus, order.Transaction)
+
+	if err != nil {
+		return err
+	}
+
+	data := &CardPayChangeStatusRequest{
+		Request: &CardPayRequest{
+			Id:   order.Id,
+			Time: time.Now().UTC().Format(cardPayDateFormat),
+		},
+		Operation:   cardPayOperationChangeStatus,
+		PaymentData: &CardPayChangeStatusData{StatusTo: statusTo},
+	}
+
+	b, err := json.Marshal(data)
+
+	if err != nil {
+		zap.L().Error(
+			"marshal change payment status request failed",
+			zap.Error(err),
+			zap.String(pkg.LogFieldHandler, billingpb.PaymentSystemHandlerCardPay),
+			zap.Any(pkg.LogFieldRequest, data),
+			zap.String("order_id", order.Id),
+		)
+		return paymentSystemErrorChangeStatusFailed
+	}
+
+	method := pkg.CardPayPaths[pkg.PaymentSystemActionChangeStatus].Method
+	req, err := http.NewRequest(method, u, bytes.NewBuffer(b))
+
+	if err != nil {
+		zap.L().Error(
+			"create change payment status request failed",
+			zap.Error(err),
+			zap.String("method", method),
+			zap.String("url", u),
+			zap.String(pkg.LogFieldHandler, billingpb.PaymentSystemHandlerCardPay),
+			zap.ByteString(pkg.LogFieldRequest, b),
+			zap.String("order_id", order.Id),
+		)
+		return paymentSystemErrorChangeStatusFailed
+	}
+
+	token := h.getToken(order)
+	auth := strings.Title(token.TokenType) + " " + token.AccessToken
+
+	req.Header.Add(HeaderContentType, MIMEApplicationJSON)
+	req.Header.Add(HeaderAuthorization, auth)
+
+	resp, err := h.httpClient.Do(req)
+
+	if err != nil || resp.StatusCode != http.StatusOK {
+		zap.L().Error(
+			"change payment status request failed",
+			zap.Error(err),
+			zap.String(pkg.LogFieldHandler, billingpb.PaymentSystemHandlerCardPay),
+			zap.Any(pkg.LogFieldRequest, data),
+			zap.String("order_id", order.Id),
+		)
+		return paymentSystemErrorChangeStatusFailed
+	}
+
+	b, err = ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		zap.L().Error(
+			"change payment status response body can't be read",
+			zap.Error(err),
+			zap.String(pkg.LogFieldHandler, billingpb.PaymentSystemHandlerCardPay),
+			zap.Any(pkg.LogFieldRequest, data),
+			zap.String("order_id", order.Id),
+		)
+		return paymentSystemErrorChangeStatusFailed
+	}
+
+	rsp := &CardPayChangeStatusResponse{}
+	err = json.Unmarshal(b, &rsp)
+
+	if err != nil {
+		zap.L().Error(
+			"change payment status response contain invalid json",
+			zap.Error(err),
+			zap.String(pkg.LogFieldHandler, billingpb.PaymentSystemHandlerCardPay),
+			zap.Any(pkg.LogFieldRequest, data),
+			zap.ByteString(pkg.LogFieldResponse, b),
+			zap.String("order_id", order.Id),
+		)
+		return paymentSystemErrorChangeStatusFailed
+	}
+
+	if rsp.PaymentData == nil || !rsp.PaymentData.IsExecuted {
+		return paymentSystemErrorChangeStatusRejected
+	}
+
+	return nil
+}
+
+// getUrl returns the url of the action, args are used as values of placeholders of the action path.
+func (h *cardPay) getUrl(apiUrl, action string, args ...interface{}) (string, error) {
 	u, err := url.ParseRequestURI(apiUrl)
 
 	if err != nil {
@@ -623,6 +788,10 @@ func (h *cardPay) getUrl(apiUrl, action string) (string, error) {
 
 	u.Path = pkg.CardPayPaths[action].Path
 
+	if len(args) > 0 {
+		u.Path = fmt.Sprintf(u.Path, args...)
+	}
+
 	return u.String(), nil
 }
 
@@ -724,12 +893,23 @@ func (h *cardPay) getCardPayOrder(
 	storeData, okStoreData := requisites[billingpb.PaymentCreateFieldStoreData]
 	recurringId, okRecurringId := requisites[billingpb.PaymentCreateFieldRecurringId]
 
+	// payments held by the manual review are authorized only, bank cards support the authorization without capture
+	preauth := order.PaymentMethod.IsBankCard() && requisites[pkg.PaymentCreateFieldPreauth] == "1"
+	threeDsChallengeIndicator, scaExemption := h.getScaData(requisites)
+
 	if order.PaymentMethod.IsBankCard() && (okStoreData && storeData == "1") ||
 		(okRecurringId && recurringId != "") {
 		cardPayOrder.RecurringData = &CardPayRecurringData{
-			Currency:  order.ChargeCurrency,
-			Amount:    order.ChargeAmount,
-			Initiator: cardPayInitiatorCardholder,
+			Currency:                  order.ChargeCurrency,
+			Amount:                    order.ChargeAmount,
+			Initiator:                 cardPayInitiatorCardholder,
+			ThreeDsChallengeIndicator: threeDsChallengeIndicator,
+			ScaExemption:              scaExemption,
+			Preauth:                   preauth,
+		}
+
+		if requisites[pkg.PaymentCreateFieldScaDecision] == pkg.ScaDecisionExemptionMit {
+			cardPayOrder.RecurringData.Initiator = cardPayInitiatorMerchant
 		}
 
 		if okRecurringId == true && recurringId != "" {
@@ -741,8 +921,11 @@ func (h *cardPay) getCardPayOrder(
 		}
 	} else {
 		cardPayOrder.PaymentData = &CardPayPaymentData{
-			Currency: order.ChargeCurrency,
-			Amount:   order.ChargeAmount,
+			Currency:                  order.ChargeCurrency,
+			Amount:                    order.ChargeAmount,
+			ThreeDsChallengeIndicator: threeDsChallengeIndicator,
+			ScaExemption:              scaExemption,
+			Preauth:                   preauth,
 		}
 	}
 
@@ -770,6 +953,23 @@ func (h *cardPay) getCardPayOrder(
 	return cardPayOrder, nil
 }
 
+// getScaData returns the 3-D Secure challenge indicator and the exemption from strong customer authentication
+// by the decision of the billing server passed in requisites of the payment.
+func (h *cardPay) getScaData(requisites map[string]string) (string, string) {
+	switch requisites[pkg.PaymentCreateFieldScaDecision] {
+	case pkg.ScaDecisionChallenge:
+		return cardPayThreeDsChallengeMandated, ""
+	case pkg.ScaDecisionFrictionless:
+		return cardPayThreeDsNoChallengeRequested, ""
+	case pkg.ScaDecisionExemptionLowValue:
+		return "", cardPayScaExemptionLowValue
+	case pkg.ScaDecisionExemptionTra:
+		return "", cardPayScaExemptionTra
+	}
+
+	return "", ""
+}
+
 func (h *cardPay) geBankCardCardPayOrder(cpo *CardPayOrder, requisites map[string]string) {
 	expire := requisites[billingpb.PaymentCreateFieldMonth] + "/" + requisites[billingpb.PaymentCreateFieldYear]
 
@@ -5,6 +5,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/paysuper/paysuper-billing-server/internal/config"
 	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"github.com/paysuper/paysuper-proto/go/recurringpb"
 	"github.com/stretchr/testify/assert"
@@ -126,6 +127,38 @@ func (suite *CardPayTestSuite) TestCardPay_GetCardPayOrder_Ok() {
 	assert.Equal(suite.T(), res.ReturnUrls.DeclineUrl, suite.cfg.GetRedirectUrlFail(nil))
 }
 
+func (suite *CardPayTestSuite) TestCardPay_GetCardPayOrder_ScaDecision() {
+	requisites := make(map[string]string)
+
+	for k, v := range bankCardRequisites {
+		requisites[k] = v
+	}
+
+	requisites[pkg.PaymentCreateFieldScaDecision] = pkg.ScaDecisionChallenge
+	res, err := suite.typedHandler.getCardPayOrder(orderSimpleBankCard, "", "", requisites)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), cardPayThreeDsChallengeMandated, res.PaymentData.ThreeDsChallengeIndicator)
+	assert.Empty(suite.T(), res.PaymentData.ScaExemption)
+
+	requisites[pkg.PaymentCreateFieldScaDecision] = pkg.ScaDecisionFrictionless
+	res, err = suite.typedHandler.getCardPayOrder(orderSimpleBankCard, "", "", requisites)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), cardPayThreeDsNoChallengeRequested, res.PaymentData.ThreeDsChallengeIndicator)
+
+	requisites[pkg.PaymentCreateFieldScaDecision] = pkg.ScaDecisionExemptionTra
+	res, err = suite.typedHandler.getCardPayOrder(orderSimpleBankCard, "", "", requisites)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), res.PaymentData.ThreeDsChallengeIndicator)
+	assert.Equal(suite.T(), cardPayScaExemptionTra, res.PaymentData.ScaExemption)
+
+	requisites[pkg.PaymentCreateFieldScaDecision] = pkg.ScaDecisionExemptionMit
+	requisites[billingpb.PaymentCreateFieldRecurringId] = "0987654321"
+	res, err = suite.typedHandler.getCardPayOrder(orderSimpleBankCard, "", "", requisites)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), cardPayInitiatorMerchant, res.RecurringData.Initiator)
+	assert.Empty(suite.T(), res.RecurringData.ScaExemption)
+}
+
 func (suite *CardPayTestSuite) TestCardPay_CreatePayment_Mock_Ok() {
 	suite.typedHandler.httpClient = mocks.NewCardPayHttpClientStatusOk()
 	url, err := suite.handler.CreatePayment(
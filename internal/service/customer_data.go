@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+var (
+	errorCustomerDataRequestEmpty = newBillingServerErrorMsg("cd000001", "customer identifier, email or phone is required")
+	errorCustomerDataNotFound     = newBillingServerErrorMsg("cd000002", "personal data of the customer not found")
+	errorCustomerDataUnknown      = newBillingServerErrorMsg("cd000003", "unknown error with personal data of the customer")
+)
+
+// ExportCustomerData returns all personal data linked to the customer identifier, email or phone: profiles of
+// the customer, orders, refunds, paylink visits and subscriptions to notifications.
+func (s *Service) ExportCustomerData(
+	ctx context.Context,
+	req *pkg.CustomerDataRequest,
+	res *pkg.ExportCustomerDataResponse,
+) error {
+	data, err := s.findCustomerData(ctx, req)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorCustomerDataUnknown
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			res.Message = e
+
+			if e == errorCustomerDataNotFound {
+				res.Status = billingpb.ResponseStatusNotFound
+			} else {
+				res.Status = billingpb.ResponseStatusBadData
+			}
+		}
+
+		return nil
+	}
+
+	zap.L().Info(
+		"Customer data exported",
+		zap.String("customer_id", req.CustomerId),
+		zap.String("user_id", req.UserId),
+		zap.Int("customers", len(data.Customers)),
+		zap.Int("orders", len(data.Orders)),
+	)
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = data
+
+	return nil
+}
+
+// EraseCustomerData pseudonymises personal data linked to the customer identifier, email or phone.
+// Orders and refunds are kept with amounts, taxes and the country of the billing address, because they are
+// accounting and tax records. Visitors of paylinks get new random identifiers to keep statistics of paylinks,
+// and subscriptions to notifications are removed.
+func (s *Service) EraseCustomerData(
+	ctx context.Context,
+	req *pkg.CustomerDataRequest,
+	res *pkg.EraseCustomerDataResponse,
+) error {
+	data, err := s.findCustomerData(ctx, req)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorCustomerDataUnknown
+
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			res.Message = e
+
+			if e == errorCustomerDataNotFound {
+				res.Status = billingpb.ResponseStatusNotFound
+			} else {
+				res.Status = billingpb.ResponseStatusBadData
+			}
+		}
+
+		return nil
+	}
+
+	result := &pkg.CustomerDataErasure{
+		CustomerIds: []string{},
+		OrderIds:    []string{},
+	}
+
+	for _, customer := range data.Customers {
+		s.pseudonymiseCustomer(customer)
+
+		if err = s.customerRepository.Update(ctx, customer); err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errorCustomerDataUnknown
+			return nil
+		}
+
+		result.CustomerIds = append(result.CustomerIds, customer.Id)
+	}
+
+	for _, order := range data.Orders {
+		s.pseudonymiseOrder(order)
+
+		if err = s.orderRepository.Update(ctx, order); err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errorCustomerDataUnknown
+			return nil
+		}
+
+		result.OrderIds = append(result.OrderIds, order.Id)
+	}
+
+	if len(result.OrderIds) > 0 {
+		if err = s.orderRepository.UpdateOrderView(ctx, result.OrderIds); err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errorCustomerDataUnknown
+			return nil
+		}
+	}
+
+	for _, visitorId := range s.getCustomerDataVisitorIds(data) {
+		if err = s.paylinkFunnelEventRepository.ReplaceVisitorId(ctx, visitorId, primitive.NewObjectID().Hex()); err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errorCustomerDataUnknown
+			return nil
+		}
+
+		result.PaylinkVisitors++
+	}
+
+	for _, email := range s.getCustomerDataEmails(req, data) {
+		if err = s.notifySalesRepository.DeleteByEmail(ctx, email); err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errorCustomerDataUnknown
+			return nil
+		}
+
+		if err = s.notifyRegionRepository.DeleteByEmail(ctx, email); err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errorCustomerDataUnknown
+			return nil
+		}
+	}
+
+	result.NotifySubscriptions = int64(len(data.NotifySales) + len(data.NotifyNewRegions))
+
+	zap.L().Info(
+		"Customer data erased",
+		zap.String("user_id", req.UserId),
+		zap.Strings("customer_ids", result.CustomerIds),
+		zap.Strings("order_ids", result.OrderIds),
+	)
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = result
+
+	return nil
+}
+
+// PurgeCustomerHistory removes ip addresses and user agents of customers older than the retention period
+func (s *Service) PurgeCustomerHistory(ctx context.Context) (int64, error) {
+	before := time.Now().AddDate(0, 0, -int(s.cfg.CustomerHistoryRetentionDays))
+	return s.customerRepository.PurgeHistory(ctx, before)
+}
+
+func (s *Service) findCustomerData(ctx context.Context, req *pkg.CustomerDataRequest) (*pkg.CustomerDataExport, error) {
+	if req.CustomerId == "" && req.Email == "" && req.Phone == "" {
+		return nil, errorCustomerDataRequestEmpty
+	}
+
+	customers, err := s.customerRepository.FindByEmailOrPhone(ctx, req.Email, req.Phone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var customerIds []string
+
+	for _, customer := range customers {
+		customerIds = append(customerIds, customer.Id)
+	}
+
+	if req.CustomerId != "" && !helper.Contains(customerIds, req.CustomerId) {
+		customer, err := s.customerRepository.GetById(ctx, req.CustomerId)
+
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		if customer != nil {
+			customers = append(customers, customer)
+			customerIds = append(customerIds, customer.Id)
+		}
+	}
+
+	data := &pkg.CustomerDataExport{
+		Customers:        customers,
+		Refunds:          []*billingpb.Refund{},
+		PaylinkVisits:    []*pkg.PaylinkFunnelEvent{},
+		NotifySales:      []*billingpb.NotifyUserSales{},
+		NotifyNewRegions: []*billingpb.NotifyUserNewRegion{},
+	}
+
+	data.Orders, err = s.orderRepository.FindByUser(ctx, customerIds, req.Email, req.Phone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIds, visitorIds []string
+
+	for _, order := range data.Orders {
+		orderIds = append(orderIds, order.Id)
+
+		if cookieId := order.PrivateMetadata[pkg.OrderPrivateMetadataBrowserCookieId]; cookieId != "" &&
+			!helper.Contains(visitorIds, cookieId) {
+			visitorIds = append(visitorIds, cookieId)
+		}
+	}
+
+	if len(orderIds) > 0 {
+		data.Refunds, err = s.refundRepository.FindByOrderIds(ctx, orderIds)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// visitors of paylinks are found by orders at first, and then all visits of these visitors are found
+		events, err := s.paylinkFunnelEventRepository.Find(ctx, orderIds, visitorIds)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data.PaylinkVisits = events
+		count := len(visitorIds)
+
+		for _, event := range events {
+			if event.VisitorId != "" && !helper.Contains(visitorIds, event.VisitorId) {
+				visitorIds = append(visitorIds, event.VisitorId)
+			}
+		}
+
+		if len(visitorIds) > count {
+			data.PaylinkVisits, err = s.paylinkFunnelEventRepository.Find(ctx, orderIds, visitorIds)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, email := range s.getCustomerDataEmails(req, data) {
+		notifySales, err := s.notifySalesRepository.FindByEmail(ctx, email)
+
+		if err != nil {
+			return nil, err
+		}
+
+		notifyNewRegions, err := s.notifyRegionRepository.FindByEmail(ctx, email)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data.NotifySales = append(data.NotifySales, notifySales...)
+		data.NotifyNewRegions = append(data.NotifyNewRegions, notifyNewRegions...)
+	}
+
+	if len(data.Customers) <= 0 && len(data.Orders) <= 0 && len(data.NotifySales) <= 0 &&
+		len(data.NotifyNewRegions) <= 0 {
+		return nil, errorCustomerDataNotFound
+	}
+
+	return data, nil
+}
+
+func (s *Service) getCustomerDataEmails(req *pkg.CustomerDataRequest, data *pkg.CustomerDataExport) []string {
+	emails := []string{req.Email}
+
+	for _, customer := range data.Customers {
+		emails = append(emails, customer.Email, customer.NotifySaleEmail, customer.NotifyNewRegionEmail)
+	}
+
+	for _, order := range data.Orders {
+		emails = append(emails, order.ReceiptEmail, order.NotifySaleEmail)
+
+		if order.User != nil {
+			emails = append(emails, order.User.Email, order.User.NotifyNewRegionEmail)
+		}
+	}
+
+	var result []string
+
+	for _, email := range emails {
+		if email != "" && !helper.Contains(result, email) {
+			result = append(result, email)
+		}
+	}
+
+	return result
+}
+
+func (s *Service) getCustomerDataVisitorIds(data *pkg.CustomerDataExport) []string {
+	var result []string
+
+	for _, event := range data.PaylinkVisits {
+		if event.VisitorId != "" && !helper.Contains(result, event.VisitorId) {
+			result = append(result, event.VisitorId)
+		}
+	}
+
+	return result
+}
+
+// pseudonymiseCustomer removes personal data from the profile of the customer, the technical email and identifiers
+// of merchants and projects of identities are kept to link the customer with orders
+func (s *Service) pseudonymiseCustomer(customer *billingpb.Customer) {
+	customer.ExternalId = ""
+	customer.Email = ""
+	customer.EmailVerified = false
+	customer.Phone = ""
+	customer.PhoneVerified = false
+	customer.Name = ""
+	customer.Ip = nil
+	customer.UserAgent = ""
+	customer.AcceptLanguage = ""
+	customer.Metadata = nil
+	customer.NotifySale = false
+	customer.NotifySaleEmail = ""
+	customer.NotifyNewRegion = false
+	customer.NotifyNewRegionEmail = ""
+	customer.IpHistory = []*billingpb.CustomerIpHistory{}
+	customer.AddressHistory = []*billingpb.CustomerAddressHistory{}
+	customer.LocaleHistory = []*billingpb.CustomerStringValueHistory{}
+	customer.AcceptLanguageHistory = []*billingpb.CustomerStringValueHistory{}
+	customer.UpdatedAt = ptypes.TimestampNow()
+
+	if customer.Address != nil {
+		customer.Address = &billingpb.OrderBillingAddress{Country: customer.Address.Country}
+	}
+
+	for _, identity := range customer.Identity {
+		identity.Value = ""
+	}
+}
+
+// pseudonymiseOrder removes personal data of the user from the order. The country, the state and the postal code
+// of addresses are kept because they define the tax of the order.
+func (s *Service) pseudonymiseOrder(order *billingpb.Order) {
+	order.ReceiptEmail = ""
+	order.ReceiptPhone = ""
+	order.NotifySaleEmail = ""
+	order.ProjectAccount = ""
+	order.PaymentMethodPayerAccount = ""
+
+	if order.User != nil {
+		order.User.ExternalId = ""
+		order.User.Name = ""
+		order.User.Email = ""
+		order.User.EmailVerified = false
+		order.User.Phone = ""
+		order.User.PhoneVerified = false
+		order.User.Ip = ""
+		order.User.Metadata = nil
+		order.User.NotifyNewRegionEmail = ""
+		order.User.Address = s.pseudonymiseOrderAddress(order.User.Address)
+	}
+
+	order.BillingAddress = s.pseudonymiseOrderAddress(order.BillingAddress)
+
+	delete(order.PaymentRequisites, billingpb.PaymentCreateFieldHolder)
+	delete(order.PaymentRequisites, billingpb.PaymentCreateFieldEmail)
+	delete(order.PrivateMetadata, pkg.OrderPrivateMetadataBrowserCookieId)
+}
+
+func (s *Service) pseudonymiseOrderAddress(address *billingpb.OrderBillingAddress) *billingpb.OrderBillingAddress {
+	if address == nil {
+		return nil
+	}
+
+	return &billingpb.OrderBillingAddress{
+		Country:    address.Country,
+		State:      address.State,
+		PostalCode: address.PostalCode,
+	}
+}
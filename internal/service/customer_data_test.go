@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net"
+	"testing"
+	"time"
+)
+
+type CustomerDataTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant   *billingpb.Merchant
+	project    *billingpb.Project
+	pmBankCard *billingpb.PaymentMethod
+}
+
+func Test_CustomerData(t *testing.T) {
+	suite.Run(t, new(CustomerDataTestSuite))
+}
+
+func (suite *CustomerDataTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, suite.pmBankCard, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *CustomerDataTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *CustomerDataTestSuite) helperCreateCustomerData() (*billingpb.Customer, *billingpb.Order) {
+	customerId := primitive.NewObjectID().Hex()
+	customer := &billingpb.Customer{
+		Id:             customerId,
+		TechEmail:      customerId + pkg.TechEmailDomain,
+		ExternalId:     "external_user",
+		Email:          "gdpr@unit.test",
+		EmailVerified:  true,
+		Phone:          "+79001234567",
+		Name:           "Unit Test",
+		Ip:             net.ParseIP("127.0.0.1"),
+		UserAgent:      "Mozilla/5.0",
+		AcceptLanguage: "ru-RU",
+		Locale:         "ru",
+		Address: &billingpb.OrderBillingAddress{
+			Country:    "RU",
+			City:       "St.Petersburg",
+			PostalCode: "190000",
+		},
+		Identity: []*billingpb.CustomerIdentity{
+			{
+				MerchantId: suite.merchant.Id,
+				ProjectId:  suite.project.Id,
+				Type:       pkg.UserIdentityTypeEmail,
+				Value:      "gdpr@unit.test",
+				Verified:   true,
+				CreatedAt:  ptypes.TimestampNow(),
+			},
+		},
+		IpHistory: []*billingpb.CustomerIpHistory{
+			{Ip: net.ParseIP("127.0.0.2"), CreatedAt: ptypes.TimestampNow()},
+		},
+		NotifySale:      true,
+		NotifySaleEmail: "gdpr@unit.test",
+	}
+	err := suite.service.customerRepository.Insert(context.TODO(), customer)
+	assert.NoError(suite.T(), err)
+
+	order := HelperCreateAndPayOrder(suite.Suite, suite.service, 100, "RUB", "RU", suite.project, suite.pmBankCard)
+	order.User.Id = customer.Id
+	order.User.Email = customer.Email
+	order.User.Name = customer.Name
+	order.User.Address.City = "St.Petersburg"
+	order.PrivateMetadata[pkg.OrderPrivateMetadataBrowserCookieId] = "cookie"
+	err = suite.service.orderRepository.Update(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+
+	events := []*pkg.PaylinkFunnelEvent{
+		{PaylinkId: primitive.NewObjectID().Hex(), Step: pkg.PaylinkFunnelStepVisit, VisitorId: "cookie"},
+		{PaylinkId: primitive.NewObjectID().Hex(), Step: pkg.PaylinkFunnelStepSuccess, OrderId: order.Id},
+		{PaylinkId: primitive.NewObjectID().Hex(), Step: pkg.PaylinkFunnelStepVisit, VisitorId: "another_visitor"},
+	}
+
+	for _, event := range events {
+		err = suite.service.paylinkFunnelEventRepository.Insert(context.TODO(), event)
+		assert.NoError(suite.T(), err)
+	}
+
+	notifySales := &billingpb.NotifyUserSales{Email: customer.Email, OrderId: order.Id, UserId: customer.Id}
+	err = suite.service.notifySalesRepository.Insert(context.TODO(), notifySales)
+	assert.NoError(suite.T(), err)
+
+	return customer, order
+}
+
+func (suite *CustomerDataTestSuite) TestCustomerData_ExportCustomerData_Ok() {
+	customer, order := suite.helperCreateCustomerData()
+
+	res := &pkg.ExportCustomerDataResponse{}
+	err := suite.service.ExportCustomerData(context.TODO(), &pkg.CustomerDataRequest{Email: customer.Email}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.Item.Customers, 1)
+	assert.Equal(suite.T(), customer.Id, res.Item.Customers[0].Id)
+	assert.Len(suite.T(), res.Item.Orders, 1)
+	assert.Equal(suite.T(), order.Id, res.Item.Orders[0].Id)
+	assert.Empty(suite.T(), res.Item.Refunds)
+	assert.Len(suite.T(), res.Item.PaylinkVisits, 2)
+	assert.Len(suite.T(), res.Item.NotifySales, 1)
+
+	// the customer is found by identifier too
+	res = &pkg.ExportCustomerDataResponse{}
+	err = suite.service.ExportCustomerData(context.TODO(), &pkg.CustomerDataRequest{CustomerId: customer.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.Item.Customers, 1)
+	assert.Len(suite.T(), res.Item.Orders, 1)
+}
+
+func (suite *CustomerDataTestSuite) TestCustomerData_ExportCustomerData_RequestEmpty_Error() {
+	res := &pkg.ExportCustomerDataResponse{}
+	err := suite.service.ExportCustomerData(context.TODO(), &pkg.CustomerDataRequest{UserId: "user"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorCustomerDataRequestEmpty, res.Message)
+	assert.Nil(suite.T(), res.Item)
+}
+
+func (suite *CustomerDataTestSuite) TestCustomerData_ExportCustomerData_NotFound_Error() {
+	res := &pkg.ExportCustomerDataResponse{}
+	req := &pkg.CustomerDataRequest{CustomerId: primitive.NewObjectID().Hex(), Email: "unknown@unit.test"}
+	err := suite.service.ExportCustomerData(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorCustomerDataNotFound, res.Message)
+}
+
+func (suite *CustomerDataTestSuite) TestCustomerData_EraseCustomerData_Ok() {
+	customer, order := suite.helperCreateCustomerData()
+
+	res := &pkg.EraseCustomerDataResponse{}
+	req := &pkg.CustomerDataRequest{Email: customer.Email, UserId: "user"}
+	err := suite.service.EraseCustomerData(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), []string{customer.Id}, res.Item.CustomerIds)
+	assert.Equal(suite.T(), []string{order.Id}, res.Item.OrderIds)
+	assert.EqualValues(suite.T(), 1, res.Item.PaylinkVisitors)
+	assert.EqualValues(suite.T(), 1, res.Item.NotifySubscriptions)
+
+	customer1, err := suite.service.customerRepository.GetById(context.TODO(), customer.Id)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), customer1.Email)
+	assert.Empty(suite.T(), customer1.Phone)
+	assert.Empty(suite.T(), customer1.Name)
+	assert.Empty(suite.T(), customer1.ExternalId)
+	assert.Empty(suite.T(), customer1.Ip)
+	assert.Empty(suite.T(), customer1.UserAgent)
+	assert.Empty(suite.T(), customer1.IpHistory)
+	assert.Empty(suite.T(), customer1.NotifySaleEmail)
+	assert.Equal(suite.T(), customer.TechEmail, customer1.TechEmail)
+	assert.Equal(suite.T(), "RU", customer1.Address.Country)
+	assert.Empty(suite.T(), customer1.Address.City)
+	assert.Len(suite.T(), customer1.Identity, 1)
+	assert.Empty(suite.T(), customer1.Identity[0].Value)
+
+	order1, err := suite.service.orderRepository.GetById(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), customer.Id, order1.User.Id)
+	assert.Empty(suite.T(), order1.User.Email)
+	assert.Empty(suite.T(), order1.User.Name)
+	assert.Empty(suite.T(), order1.User.Ip)
+	assert.Empty(suite.T(), order1.User.Address.City)
+	assert.Equal(suite.T(), "RU", order1.User.Address.Country)
+	assert.Empty(suite.T(), order1.PaymentRequisites[billingpb.PaymentCreateFieldHolder])
+	assert.Empty(suite.T(), order1.PrivateMetadata[pkg.OrderPrivateMetadataBrowserCookieId])
+	assert.Equal(suite.T(), order.TotalPaymentAmount, order1.TotalPaymentAmount)
+	assert.Equal(suite.T(), order.Tax, order1.Tax)
+	assert.Equal(suite.T(), order.PrivateStatus, order1.PrivateStatus)
+
+	events, err := suite.service.paylinkFunnelEventRepository.Find(context.TODO(), nil, []string{"cookie"})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), events)
+
+	events, err = suite.service.paylinkFunnelEventRepository.Find(context.TODO(), nil, []string{"another_visitor"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), events, 1)
+
+	notifySales, err := suite.service.notifySalesRepository.FindByEmail(context.TODO(), customer.Email)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), notifySales)
+
+	// personal data aren't found anymore
+	res1 := &pkg.ExportCustomerDataResponse{}
+	err = suite.service.ExportCustomerData(context.TODO(), req, res1)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res1.Status)
+}
+
+func (suite *CustomerDataTestSuite) TestCustomerData_EraseCustomerData_NotFound_Error() {
+	res := &pkg.EraseCustomerDataResponse{}
+	err := suite.service.EraseCustomerData(context.TODO(), &pkg.CustomerDataRequest{Phone: "+70000000000"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorCustomerDataNotFound, res.Message)
+	assert.Nil(suite.T(), res.Item)
+}
+
+func (suite *CustomerDataTestSuite) TestCustomerData_PurgeCustomerHistory_Ok() {
+	suite.service.cfg.CustomerHistoryRetentionDays = 30
+
+	old, _ := ptypes.TimestampProto(time.Now().AddDate(0, 0, -31))
+	recent, _ := ptypes.TimestampProto(time.Now().AddDate(0, 0, -1))
+
+	customer := &billingpb.Customer{
+		Id:        primitive.NewObjectID().Hex(),
+		Ip:        net.ParseIP("127.0.0.1"),
+		UserAgent: "Mozilla/5.0",
+		IpHistory: []*billingpb.CustomerIpHistory{
+			{Ip: net.ParseIP("127.0.0.2"), CreatedAt: old},
+			{Ip: net.ParseIP("127.0.0.3"), CreatedAt: recent},
+		},
+		CreatedAt: old,
+		UpdatedAt: old,
+	}
+	err := suite.service.customerRepository.Insert(context.TODO(), customer)
+	assert.NoError(suite.T(), err)
+
+	customer2 := &billingpb.Customer{
+		Id:        primitive.NewObjectID().Hex(),
+		Ip:        net.ParseIP("127.0.0.4"),
+		UserAgent: "Mozilla/5.0",
+		CreatedAt: old,
+		UpdatedAt: recent,
+	}
+	err = suite.service.customerRepository.Insert(context.TODO(), customer2)
+	assert.NoError(suite.T(), err)
+
+	count, err := suite.service.PurgeCustomerHistory(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 2, count)
+
+	customer, err = suite.service.customerRepository.GetById(context.TODO(), customer.Id)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), customer.Ip)
+	assert.Empty(suite.T(), customer.UserAgent)
+	assert.Len(suite.T(), customer.IpHistory, 1)
+	assert.Equal(suite.T(), "127.0.0.3", net.IP(customer.IpHistory[0].Ip).String())
+
+	// customers updated during the retention period keep current ip address and user agent
+	customer2, err = suite.service.customerRepository.GetById(context.TODO(), customer2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "127.0.0.4", net.IP(customer2.Ip).String())
+	assert.Equal(suite.T(), "Mozilla/5.0", customer2.UserAgent)
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// ExtendedBillingService is RPC handler of the billing service methods which requests or responses
+// are not described in the billing protocol and are declared in the pkg package. Messages of these methods
+// aren't protobuf messages, so clients must call them with JSON codec (application/json content type).
+type ExtendedBillingService struct {
+	svc *Service
+}
+
+func NewExtendedBillingService(svc *Service) *ExtendedBillingService {
+	return &ExtendedBillingService{svc: svc}
+}
+
+func (h *ExtendedBillingService) CreateOrUpdateBlockListEntry(ctx context.Context, req *pkg.CreateOrUpdateBlockListEntryRequest, rsp *pkg.BlockListEntryResponse) error {
+	return h.svc.CreateOrUpdateBlockListEntry(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListBlockListEntries(ctx context.Context, req *pkg.ListBlockListEntriesRequest, rsp *pkg.ListBlockListEntriesResponse) error {
+	return h.svc.ListBlockListEntries(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) DeleteBlockListEntry(ctx context.Context, req *pkg.DeleteBlockListEntryRequest, rsp *billingpb.EmptyResponseWithStatus) error {
+	return h.svc.DeleteBlockListEntry(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreateOrUpdateBundle(ctx context.Context, req *pkg.CreateOrUpdateBundleRequest, rsp *pkg.BundleResponse) error {
+	return h.svc.CreateOrUpdateBundle(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetBundle(ctx context.Context, req *pkg.GetBundleRequest, rsp *pkg.BundleResponse) error {
+	return h.svc.GetBundle(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListBundles(ctx context.Context, req *pkg.ListBundlesRequest, rsp *pkg.ListBundlesResponse) error {
+	return h.svc.ListBundles(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) DeleteBundle(ctx context.Context, req *pkg.GetBundleRequest, rsp *billingpb.EmptyResponseWithStatus) error {
+	return h.svc.DeleteBundle(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListCardVaultTokens(ctx context.Context, req *pkg.ListCardVaultTokensRequest, rsp *pkg.ListCardVaultTokensResponse) error {
+	return h.svc.ListCardVaultTokens(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) SetDefaultCardVaultToken(ctx context.Context, req *pkg.CardVaultTokenRequest, rsp *pkg.CardVaultTokenResponse) error {
+	return h.svc.SetDefaultCardVaultToken(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) DeleteCardVaultToken(ctx context.Context, req *pkg.CardVaultTokenRequest, rsp *billingpb.EmptyResponseWithStatus) error {
+	return h.svc.DeleteCardVaultToken(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ExportCustomerData(ctx context.Context, req *pkg.CustomerDataRequest, rsp *pkg.ExportCustomerDataResponse) error {
+	return h.svc.ExportCustomerData(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) EraseCustomerData(ctx context.Context, req *pkg.CustomerDataRequest, rsp *pkg.EraseCustomerDataResponse) error {
+	return h.svc.EraseCustomerData(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) UploadKeysFileWithSummary(ctx context.Context, req *billingpb.PlatformKeysFileRequest, rsp *pkg.UploadKeysFileResponse) error {
+	return h.svc.UploadKeysFileWithSummary(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreateKeyImportJob(ctx context.Context, req *pkg.CreateKeyImportJobRequest, rsp *pkg.KeyImportJobResponse) error {
+	return h.svc.CreateKeyImportJob(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) UploadKeyImportChunk(ctx context.Context, req *pkg.UploadKeyImportChunkRequest, rsp *pkg.KeyImportJobResponse) error {
+	return h.svc.UploadKeyImportChunk(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CommitKeyImportJob(ctx context.Context, req *pkg.CommitKeyImportJobRequest, rsp *pkg.KeyImportJobResponse) error {
+	return h.svc.CommitKeyImportJob(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetKeyImportJob(ctx context.Context, req *pkg.GetKeyImportJobRequest, rsp *pkg.KeyImportJobResponse) error {
+	return h.svc.GetKeyImportJob(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetKeys(ctx context.Context, req *pkg.GetKeysRequest, rsp *pkg.GetKeysResponse) error {
+	return h.svc.GetKeys(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) RevokeKeys(ctx context.Context, req *pkg.ChangeKeysStateRequest, rsp *pkg.ChangeKeysStateResponse) error {
+	return h.svc.RevokeKeys(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ExpireKeys(ctx context.Context, req *pkg.ChangeKeysStateRequest, rsp *pkg.ChangeKeysStateResponse) error {
+	return h.svc.ExpireKeys(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) SetKeyStockThreshold(ctx context.Context, req *pkg.SetKeyStockThresholdRequest, rsp *pkg.KeyStockThresholdResponse) error {
+	return h.svc.SetKeyStockThreshold(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetKeyStockThresholds(ctx context.Context, req *pkg.GetKeyStockThresholdsRequest, rsp *pkg.GetKeyStockThresholdsResponse) error {
+	return h.svc.GetKeyStockThresholds(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetKeyAudit(ctx context.Context, req *pkg.GetKeyAuditRequest, rsp *pkg.GetKeyAuditResponse) error {
+	return h.svc.GetKeyAudit(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) SetKeyProductRelease(ctx context.Context, req *pkg.SetKeyProductReleaseRequest, rsp *pkg.KeyProductReleaseResponse) error {
+	return h.svc.SetKeyProductRelease(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetKeyProductRelease(ctx context.Context, req *pkg.GetKeyProductReleaseRequest, rsp *pkg.KeyProductReleaseResponse) error {
+	return h.svc.GetKeyProductRelease(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetKeyPreOrders(ctx context.Context, req *pkg.GetKeyPreOrdersRequest, rsp *pkg.GetKeyPreOrdersResponse) error {
+	return h.svc.GetKeyPreOrders(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) SetKeyReservationPolicy(ctx context.Context, req *pkg.SetKeyReservationPolicyRequest, rsp *pkg.KeyReservationPolicyResponse) error {
+	return h.svc.SetKeyReservationPolicy(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetKeyReservationPolicy(ctx context.Context, req *pkg.GetKeyReservationPolicyRequest, rsp *pkg.KeyReservationPolicyResponse) error {
+	return h.svc.GetKeyReservationPolicy(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ChangeMerchantBanking(ctx context.Context, req *pkg.ChangeMerchantBankingRequest, rsp *pkg.MerchantBankingChangeResponse) error {
+	return h.svc.ChangeMerchantBanking(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ConfirmMerchantBankingChange(ctx context.Context, req *pkg.ConfirmMerchantBankingChangeRequest, rsp *pkg.MerchantBankingChangeResponse) error {
+	return h.svc.ConfirmMerchantBankingChange(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) VerifyMerchantBankingMicroDeposits(ctx context.Context, req *pkg.VerifyMerchantBankingMicroDepositsRequest, rsp *pkg.MerchantBankingChangeResponse) error {
+	return h.svc.VerifyMerchantBankingMicroDeposits(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ReviewMerchantBankingChange(ctx context.Context, req *pkg.ReviewMerchantBankingChangeRequest, rsp *pkg.MerchantBankingChangeResponse) error {
+	return h.svc.ReviewMerchantBankingChange(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetMerchantBankingChanges(ctx context.Context, req *pkg.GetMerchantBankingChangesRequest, rsp *pkg.GetMerchantBankingChangesResponse) error {
+	return h.svc.GetMerchantBankingChanges(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListMerchantRiskReviews(ctx context.Context, req *pkg.ListMerchantRiskReviewsRequest, rsp *pkg.ListMerchantRiskReviewsResponse) error {
+	return h.svc.ListMerchantRiskReviews(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ResolveMerchantRiskReview(ctx context.Context, req *pkg.ResolveMerchantRiskReviewRequest, rsp *pkg.MerchantRiskReviewResponse) error {
+	return h.svc.ResolveMerchantRiskReview(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetMerchantStatement(ctx context.Context, req *pkg.GetMerchantStatementRequest, rsp *pkg.GetMerchantStatementResponse) error {
+	return h.svc.GetMerchantStatement(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreateMerchantStatementFile(ctx context.Context, req *pkg.CreateMerchantStatementFileRequest, rsp *billingpb.ResponseError) error {
+	return h.svc.CreateMerchantStatementFile(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetOrderItemsBreakdown(ctx context.Context, req *pkg.GetOrderItemsBreakdownRequest, rsp *pkg.GetOrderItemsBreakdownResponse) error {
+	return h.svc.GetOrderItemsBreakdown(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListOrderReviews(ctx context.Context, req *pkg.ListOrderReviewsRequest, rsp *pkg.ListOrderReviewsResponse) error {
+	return h.svc.ListOrderReviews(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ClaimOrderReview(ctx context.Context, req *pkg.OrderReviewRequest, rsp *pkg.OrderReviewResponse) error {
+	return h.svc.ClaimOrderReview(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ApproveOrderReview(ctx context.Context, req *pkg.OrderReviewRequest, rsp *pkg.OrderReviewResponse) error {
+	return h.svc.ApproveOrderReview(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) DeclineOrderReview(ctx context.Context, req *pkg.OrderReviewRequest, rsp *pkg.OrderReviewResponse) error {
+	return h.svc.DeclineOrderReview(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreateOrUpdatePaylinkVariant(ctx context.Context, req *pkg.CreateOrUpdatePaylinkVariantRequest, rsp *pkg.PaylinkVariantResponse) error {
+	return h.svc.CreateOrUpdatePaylinkVariant(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetPaylinkVariants(ctx context.Context, req *pkg.GetPaylinkVariantsRequest, rsp *pkg.GetPaylinkVariantsResponse) error {
+	return h.svc.GetPaylinkVariants(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) DeletePaylinkVariant(ctx context.Context, req *pkg.DeletePaylinkVariantRequest, rsp *billingpb.EmptyResponseWithStatus) error {
+	return h.svc.DeletePaylinkVariant(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) AssignPaylinkVariant(ctx context.Context, req *pkg.AssignPaylinkVariantRequest, rsp *pkg.PaylinkVariantResponse) error {
+	return h.svc.AssignPaylinkVariant(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetPaylinkFunnelStat(ctx context.Context, req *billingpb.GetPaylinkStatCommonRequest, rsp *pkg.GetPaylinkFunnelStatResponse) error {
+	return h.svc.GetPaylinkFunnelStat(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreatePayoutBatches(ctx context.Context, req *pkg.CreatePayoutBatchesRequest, rsp *pkg.CreatePayoutBatchesResponse) error {
+	return h.svc.CreatePayoutBatches(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetPayoutBatch(ctx context.Context, req *pkg.GetPayoutBatchRequest, rsp *pkg.PayoutBatchResponse) error {
+	return h.svc.GetPayoutBatch(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetPayoutBatchFile(ctx context.Context, req *pkg.GetPayoutBatchRequest, rsp *pkg.PayoutBatchFileResponse) error {
+	return h.svc.GetPayoutBatchFile(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ImportPayoutBatchStatus(ctx context.Context, req *pkg.ImportPayoutBatchStatusRequest, rsp *pkg.ImportPayoutBatchStatusResponse) error {
+	return h.svc.ImportPayoutBatchStatus(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreatePriceSchedule(ctx context.Context, req *pkg.CreatePriceScheduleRequest, rsp *pkg.PriceScheduleResponse) error {
+	return h.svc.CreatePriceSchedule(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CancelPriceSchedule(ctx context.Context, req *pkg.CancelPriceScheduleRequest, rsp *pkg.PriceScheduleResponse) error {
+	return h.svc.CancelPriceSchedule(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetProductPriceHistory(ctx context.Context, req *pkg.GetProductPriceHistoryRequest, rsp *pkg.GetProductPriceHistoryResponse) error {
+	return h.svc.GetProductPriceHistory(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetEffectiveProductPrices(ctx context.Context, req *pkg.GetEffectivePricesRequest, rsp *pkg.GetEffectivePricesResponse) error {
+	return h.svc.GetEffectiveProductPrices(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) SetPriceTableRule(ctx context.Context, req *pkg.SetPriceTableRuleRequest, rsp *pkg.PriceTableRuleResponse) error {
+	return h.svc.SetPriceTableRule(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetPriceTableVersions(ctx context.Context, req *pkg.GetPriceTableVersionsRequest, rsp *pkg.GetPriceTableVersionsResponse) error {
+	return h.svc.GetPriceTableVersions(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ApplyPriceTableVersion(ctx context.Context, req *pkg.ApplyPriceTableVersionRequest, rsp *pkg.PriceTableVersionResponse) error {
+	return h.svc.ApplyPriceTableVersion(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetRecommendedPriceDiff(ctx context.Context, req *pkg.GetRecommendedPriceDiffRequest, rsp *pkg.GetRecommendedPriceDiffResponse) error {
+	return h.svc.GetRecommendedPriceDiff(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreateOrUpdatePromo(ctx context.Context, req *pkg.CreateOrUpdatePromoRequest, rsp *pkg.PromoResponse) error {
+	return h.svc.CreateOrUpdatePromo(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetPromo(ctx context.Context, req *pkg.GetPromoRequest, rsp *pkg.PromoResponse) error {
+	return h.svc.GetPromo(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListPromos(ctx context.Context, req *pkg.ListPromosRequest, rsp *pkg.ListPromosResponse) error {
+	return h.svc.ListPromos(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) DeletePromo(ctx context.Context, req *pkg.GetPromoRequest, rsp *billingpb.EmptyResponseWithStatus) error {
+	return h.svc.DeletePromo(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) PaymentFormPromoCodeChanged(ctx context.Context, req *pkg.PaymentFormPromoCodeRequest, rsp *billingpb.PaymentFormDataChangeResponse) error {
+	return h.svc.PaymentFormPromoCodeChanged(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetRoyaltyReportDiscounts(ctx context.Context, req *pkg.GetRoyaltyReportDiscountsRequest, rsp *pkg.GetRoyaltyReportDiscountsResponse) error {
+	return h.svc.GetRoyaltyReportDiscounts(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreateOrUpdateRiskRule(ctx context.Context, req *pkg.CreateOrUpdateRiskRuleRequest, rsp *pkg.RiskRuleResponse) error {
+	return h.svc.CreateOrUpdateRiskRule(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListRiskRules(ctx context.Context, req *pkg.ListRiskRulesRequest, rsp *pkg.ListRiskRulesResponse) error {
+	return h.svc.ListRiskRules(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) DeleteRiskRule(ctx context.Context, req *pkg.RiskRuleRequest, rsp *billingpb.EmptyResponseWithStatus) error {
+	return h.svc.DeleteRiskRule(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetOrderRiskAssessments(ctx context.Context, req *pkg.GetOrderRiskAssessmentsRequest, rsp *pkg.GetOrderRiskAssessmentsResponse) error {
+	return h.svc.GetOrderRiskAssessments(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) OpenRoyaltyReportDispute(ctx context.Context, req *pkg.OpenRoyaltyReportDisputeRequest, rsp *pkg.RoyaltyReportDisputeResponse) error {
+	return h.svc.OpenRoyaltyReportDispute(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) AddRoyaltyReportDisputeMessage(ctx context.Context, req *pkg.AddRoyaltyReportDisputeMessageRequest, rsp *pkg.RoyaltyReportDisputeResponse) error {
+	return h.svc.AddRoyaltyReportDisputeMessage(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ResolveRoyaltyReportDispute(ctx context.Context, req *pkg.ResolveRoyaltyReportDisputeRequest, rsp *pkg.RoyaltyReportDisputeResponse) error {
+	return h.svc.ResolveRoyaltyReportDispute(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetRoyaltyReportDispute(ctx context.Context, req *pkg.GetRoyaltyReportDisputeRequest, rsp *pkg.RoyaltyReportDisputeResponse) error {
+	return h.svc.GetRoyaltyReportDispute(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) SetMerchantRoyaltySchedule(ctx context.Context, req *pkg.SetMerchantRoyaltyScheduleRequest, rsp *pkg.MerchantRoyaltyScheduleResponse) error {
+	return h.svc.SetMerchantRoyaltySchedule(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) GetMerchantRoyaltySchedule(ctx context.Context, req *pkg.GetMerchantRoyaltyScheduleRequest, rsp *pkg.MerchantRoyaltyScheduleResponse) error {
+	return h.svc.GetMerchantRoyaltySchedule(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListSanctionsScreenings(ctx context.Context, req *pkg.ListSanctionsScreeningsRequest, rsp *pkg.ListSanctionsScreeningsResponse) error {
+	return h.svc.ListSanctionsScreenings(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ScreenMerchant(ctx context.Context, req *pkg.ScreenMerchantRequest, rsp *pkg.SanctionsScreeningResponse) error {
+	return h.svc.ScreenMerchant(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ResolveSanctionsScreening(ctx context.Context, req *pkg.ResolveSanctionsScreeningRequest, rsp *pkg.SanctionsScreeningResponse) error {
+	return h.svc.ResolveSanctionsScreening(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) CreateOrUpdateVelocityLimit(ctx context.Context, req *pkg.CreateOrUpdateVelocityLimitRequest, rsp *pkg.VelocityLimitResponse) error {
+	return h.svc.CreateOrUpdateVelocityLimit(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListVelocityLimits(ctx context.Context, req *pkg.ListVelocityLimitsRequest, rsp *pkg.ListVelocityLimitsResponse) error {
+	return h.svc.ListVelocityLimits(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) DeleteVelocityLimit(ctx context.Context, req *pkg.VelocityLimitRequest, rsp *billingpb.EmptyResponseWithStatus) error {
+	return h.svc.DeleteVelocityLimit(ctx, req, rsp)
+}
+
+func (h *ExtendedBillingService) ListVelocityLimitEvents(ctx context.Context, req *pkg.ListVelocityLimitEventsRequest, rsp *pkg.ListVelocityLimitEventsResponse) error {
+	return h.svc.ListVelocityLimitEvents(ctx, req, rsp)
+}
@@ -4,10 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/errors"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
+	"strings"
 )
 
 func (s *Service) UploadKeysFile(
@@ -15,7 +18,31 @@ func (s *Service) UploadKeysFile(
 	req *billingpb.PlatformKeysFileRequest,
 	res *billingpb.PlatformKeysFileResponse,
 ) error {
-	scanner := bufio.NewScanner(bytes.NewReader(req.File))
+	rsp := &pkg.UploadKeysFileResponse{}
+	err := s.UploadKeysFileWithSummary(ctx, req, rsp)
+
+	if err != nil {
+		return err
+	}
+
+	res.Status = rsp.Status
+	res.Message = rsp.Message
+
+	if rsp.Item != nil {
+		res.TotalCount = rsp.Item.TotalCount
+		res.KeysProcessed = rsp.Item.KeysProcessed
+	}
+
+	return nil
+}
+
+// UploadKeysFileWithSummary validates lines of the uploaded file and stores valid keys, invalid lines and keys
+// already uploaded before are returned in the summary with reasons of rejection.
+func (s *Service) UploadKeysFileWithSummary(
+	ctx context.Context,
+	req *billingpb.PlatformKeysFileRequest,
+	res *pkg.UploadKeysFileResponse,
+) error {
 	count, err := s.keyRepository.CountKeysByProductPlatform(ctx, req.KeyProductId, req.PlatformId)
 
 	if err != nil {
@@ -25,35 +52,126 @@ func (s *Service) UploadKeysFile(
 		return nil
 	}
 
-	res.TotalCount = int32(count)
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(req.File))
 
-	// Process key by line
 	for scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+
+	// tell about errors
+	if err = scanner.Err(); err != nil {
+		zap.S().Errorf(errors.KeyErrorFileProcess.Message, "err", err.Error())
+		res.Message = errors.KeyErrorFileProcess
+		res.Status = billingpb.ResponseStatusBadData
+		return nil
+	}
+
+	summary := &pkg.KeysUploadSummary{
+		TotalCount: int32(count),
+		LinesCount: int32(len(lines)),
+		Rejected:   []*pkg.KeysUploadRejectedLine{},
+	}
+
+	if len(lines) <= 0 {
+		res.Status = billingpb.ResponseStatusOk
+		res.Item = summary
+		return nil
+	}
+
+	keyProduct, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	c, err := s.getMerchantKeyCodeCipher(ctx, keyProduct.MerchantId)
+
+	if err != nil {
+		zap.S().Errorf(errors.KeyErrorEncrypt.Message, "err", err.Error(), "keyProductId", req.KeyProductId)
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorEncrypt
+		return nil
+	}
+
+	indexes := make([]string, len(lines))
+	reasons := make([]string, len(lines))
+	unique := make(map[string]string)
+
+	for i, code := range lines {
+		if code == "" {
+			reasons[i] = pkg.KeyUploadRejectReasonEmpty
+			continue
+		}
+
+		if len(code) > keyCodeMaxLength {
+			reasons[i] = pkg.KeyUploadRejectReasonTooLong
+			continue
+		}
+
+		indexes[i] = s.getKeyCodeIndex(code)
+
+		if _, ok := unique[indexes[i]]; ok {
+			reasons[i] = pkg.KeyUploadRejectReasonDuplicateInFile
+			continue
+		}
+
+		unique[indexes[i]] = code
+	}
+
+	existing, err := s.getExistingKeyCodeIndexes(ctx, req.PlatformId, unique)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorFileProcess
+		return nil
+	}
+
+	var audits []*pkg.KeyAudit
+
+	// Process key by line
+	for i, code := range lines {
+		if reasons[i] == "" && existing[indexes[i]] {
+			reasons[i] = pkg.KeyUploadRejectReasonDuplicateInStock
+		}
+
+		if reasons[i] != "" {
+			summary.Rejected = append(summary.Rejected, &pkg.KeysUploadRejectedLine{Line: int32(i + 1), Reason: reasons[i]})
+			continue
+		}
+
 		key := &billingpb.Key{
 			Id:           primitive.NewObjectID().Hex(),
-			Code:         scanner.Text(),
 			KeyProductId: req.KeyProductId,
 			PlatformId:   req.PlatformId,
 		}
+		key.Code, err = c.encrypt(code, indexes[i])
+
+		if err != nil {
+			zap.S().Errorf(errors.KeyErrorEncrypt.Message, "err", err.Error(), "keyProductId", req.KeyProductId)
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errors.KeyErrorEncrypt
+			return nil
+		}
 
 		if err := s.keyRepository.Insert(ctx, key); err != nil {
-			zap.S().Errorf(errors.KeyErrorFailedToInsert.Message, "err", err, "key", key)
+			zap.S().Errorf(errors.KeyErrorFailedToInsert.Message, "err", err, "keyId", key.Id)
+			summary.Rejected = append(summary.Rejected, &pkg.KeysUploadRejectedLine{Line: int32(i + 1), Reason: pkg.KeyUploadRejectReasonInsertFailed})
 			continue
 		}
 
-		res.TotalCount++
-		res.KeysProcessed++
+		summary.TotalCount++
+		summary.KeysProcessed++
+		audits = append(audits, newKeyAudit(key, pkg.KeyAuditActionUploaded, "", ""))
 	}
 
-	// tell about errors
-	if err = scanner.Err(); err != nil {
-		zap.S().Errorf(errors.KeyErrorFileProcess.Message, "err", err.Error())
-		res.Message = errors.KeyErrorFileProcess
-		res.Status = billingpb.ResponseStatusBadData
-		return nil
-	}
+	s.addKeyAudits(ctx, audits)
+	s.checkKeyStockThreshold(ctx, req.KeyProductId, req.PlatformId)
 
 	res.Status = billingpb.ResponseStatusOk
+	res.Item = summary
 
 	return nil
 }
@@ -119,7 +237,8 @@ func (s *Service) ReserveKeyForOrder(
 	res *billingpb.PlatformKeyReserveResponse,
 ) error {
 	zap.S().Infow("[ReserveKeyForOrder] called", "order_id", req.OrderId, "platform_id", req.PlatformId, "KeyProductId", req.KeyProductId)
-	key, err := s.keyRepository.ReserveKey(ctx, req.KeyProductId, req.PlatformId, req.OrderId, req.Ttl)
+	ttl := s.getKeyReservationTtl(ctx, req.KeyProductId, req.Ttl)
+	key, err := s.keyRepository.ReserveKey(ctx, req.KeyProductId, req.PlatformId, req.OrderId, ttl)
 
 	if err != nil {
 		res.Status = billingpb.ResponseStatusBadData
@@ -129,6 +248,10 @@ func (s *Service) ReserveKeyForOrder(
 
 	zap.S().Infow("[ReserveKeyForOrder] reserved key", "req.order_id", req.OrderId, "key.order_id", key.OrderId, "key.id", key.Id, "key.RedeemedAt", key.RedeemedAt, "key.KeyProductId", key.KeyProductId)
 
+	s.enqueueKeyReservation(key)
+	s.addKeyAudit(ctx, newKeyAudit(key, pkg.KeyAuditActionReserved, "", ""))
+	s.checkKeyStockThreshold(ctx, key.KeyProductId, key.PlatformId)
+
 	res.KeyId = key.Id
 	res.Status = billingpb.ResponseStatusOk
 
@@ -148,6 +271,19 @@ func (s *Service) FinishRedeemKeyForOrder(
 		return nil
 	}
 
+	s.dequeueKeyReservation(key.Id)
+
+	key.Code, err = s.decryptKeyCode(ctx, key.Code)
+
+	if err != nil {
+		zap.S().Errorf(errors.KeyErrorDecrypt.Message, "err", err.Error(), "keyId", req.KeyId)
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorDecrypt
+		return nil
+	}
+
+	s.addKeyAudit(ctx, newKeyAudit(key, pkg.KeyAuditActionRedeemed, "", ""))
+
 	res.Key = key
 	res.Status = billingpb.ResponseStatusOk
 
@@ -159,7 +295,7 @@ func (s *Service) CancelRedeemKeyForOrder(
 	req *billingpb.KeyForOrderRequest,
 	res *billingpb.EmptyResponseWithStatus,
 ) error {
-	_, err := s.keyRepository.CancelById(ctx, req.KeyId)
+	key, err := s.keyRepository.CancelById(ctx, req.KeyId)
 
 	if err != nil {
 		res.Status = billingpb.ResponseStatusSystemError
@@ -167,27 +303,49 @@ func (s *Service) CancelRedeemKeyForOrder(
 		return nil
 	}
 
+	s.dequeueKeyReservation(key.Id)
+
+	s.addKeyAudit(ctx, newKeyAudit(key, pkg.KeyAuditActionReleased, "", ""))
+	s.checkKeyStockThreshold(ctx, key.KeyProductId, key.PlatformId)
+
 	res.Status = billingpb.ResponseStatusOk
 
 	return nil
 }
 
+// KeyDaemonProcess releases keys with the expired reservation taken from the reservation queue.
 func (s *Service) KeyDaemonProcess(ctx context.Context) (int, error) {
 	counter := 0
-	keys, err := s.keyRepository.FindUnfinished(ctx)
+	ids, err := s.getExpiredKeyReservations()
 
 	if err != nil {
 		return counter, err
 	}
 
-	for _, key := range keys {
-		_, err = s.keyRepository.CancelById(ctx, key.Id)
+	var audits []*pkg.KeyAudit
+	stocks := make(map[string]*billingpb.Key)
+
+	for _, id := range ids {
+		key, err := s.keyRepository.CancelExpiredById(ctx, id)
 
 		if err != nil {
+			// the key was redeemed, released or reserved until a later time
+			if err == mongo.ErrNoDocuments {
+				s.dequeueKeyReservation(id)
+			}
 			continue
 		}
 
+		s.dequeueKeyReservation(id)
 		counter++
+		audits = append(audits, newKeyAudit(key, pkg.KeyAuditActionReleased, "", keyReleaseReasonReservationExpired))
+		stocks[key.KeyProductId+key.PlatformId] = key
+	}
+
+	s.addKeyAudits(ctx, audits)
+
+	for _, key := range stocks {
+		s.checkKeyStockThreshold(ctx, key.KeyProductId, key.PlatformId)
 	}
 
 	return counter, nil
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/kms"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var (
+	errorKeyCodeEncryptionDisabled = errors.New("key codes encryption is disabled, master keys are not set")
+)
+
+const (
+	keyCodeReencryptionBatchSize = 500
+)
+
+type keyCodeCipher struct {
+	dataKeyId  string
+	merchantId string
+	key        []byte
+}
+
+// getDataKeyId returns identifier of the data key, it's empty if key codes encryption is disabled.
+func (c *keyCodeCipher) getDataKeyId() string {
+	if c == nil {
+		return ""
+	}
+
+	return c.dataKeyId
+}
+
+// encrypt returns the envelope of the encrypted code, the code is returned as is if key codes encryption is disabled.
+func (c *keyCodeCipher) encrypt(code, index string) (string, error) {
+	if c == nil {
+		return code, nil
+	}
+
+	ciphertext, err := kms.Encrypt(c.key, []byte(code))
+
+	if err != nil {
+		return "", err
+	}
+
+	envelope := &pkg.KeyCodeEnvelope{
+		DataKeyId:  c.dataKeyId,
+		Index:      index,
+		Ciphertext: base64.RawURLEncoding.EncodeToString(ciphertext),
+	}
+
+	return envelope.String(), nil
+}
+
+func (c *keyCodeCipher) decrypt(envelope *pkg.KeyCodeEnvelope) (string, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(envelope.Ciphertext)
+
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := kms.Decrypt(c.key, ciphertext)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func (c *keyCodeCipher) encryptData(data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+
+	return kms.Encrypt(c.key, data)
+}
+
+func (c *keyCodeCipher) decryptData(data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+
+	return kms.Decrypt(c.key, data)
+}
+
+// getMerchantKeyCodeCipher returns cipher with the latest data key of the merchant.
+// New data key will be created if merchant hasn't data key yet or the master key was rotated.
+// Nil cipher is returned if key codes encryption is disabled, codes are stored in plaintext then.
+func (s *Service) getMerchantKeyCodeCipher(ctx context.Context, merchantId string) (*keyCodeCipher, error) {
+	if s.kms == nil {
+		return nil, nil
+	}
+
+	dataKey, err := s.merchantDataKeyRepository.GetLastByMerchantId(ctx, merchantId)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	if dataKey == nil || dataKey.MasterKeyId != s.kms.GetMasterKeyId() {
+		return s.newMerchantKeyCodeCipher(ctx, merchantId)
+	}
+
+	return s.getKeyCodeCipher(ctx, dataKey)
+}
+
+func (s *Service) newMerchantKeyCodeCipher(ctx context.Context, merchantId string) (*keyCodeCipher, error) {
+	generated, err := s.kms.GenerateDataKey(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := &pkg.MerchantDataKey{
+		Id:           primitive.NewObjectID().Hex(),
+		MerchantId:   merchantId,
+		MasterKeyId:  generated.MasterKeyId,
+		EncryptedKey: generated.Encrypted,
+		CreatedAt:    ptypes.TimestampNow(),
+	}
+
+	if err = s.merchantDataKeyRepository.Insert(ctx, dataKey); err != nil {
+		return nil, err
+	}
+
+	return &keyCodeCipher{dataKeyId: dataKey.Id, merchantId: merchantId, key: generated.Plaintext}, nil
+}
+
+func (s *Service) getKeyCodeCipher(ctx context.Context, dataKey *pkg.MerchantDataKey) (*keyCodeCipher, error) {
+	if s.kms == nil {
+		return nil, errorKeyCodeEncryptionDisabled
+	}
+
+	key, err := s.kms.DecryptDataKey(ctx, dataKey.MasterKeyId, dataKey.EncryptedKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyCodeCipher{dataKeyId: dataKey.Id, merchantId: dataKey.MerchantId, key: key}, nil
+}
+
+func (s *Service) getKeyCodeCipherByDataKeyId(ctx context.Context, dataKeyId string) (*keyCodeCipher, error) {
+	dataKey, err := s.merchantDataKeyRepository.GetById(ctx, dataKeyId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getKeyCodeCipher(ctx, dataKey)
+}
+
+// getKeyCodeIndex returns keyed hash of the key code, hash is the same for all merchants
+// to keep key codes unique on platform like it was with plaintext codes.
+func (s *Service) getKeyCodeIndex(code string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.KeyCodeEncryption.IndexSecret))
+	mac.Write([]byte(code))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// decryptKeyCode returns plaintext of the key code, codes which weren't encrypted yet are returned as is.
+func (s *Service) decryptKeyCode(ctx context.Context, code string) (string, error) {
+	envelope, ok := pkg.ParseKeyCodeEnvelope(code)
+
+	if !ok {
+		return code, nil
+	}
+
+	c, err := s.getKeyCodeCipherByDataKeyId(ctx, envelope.DataKeyId)
+
+	if err != nil {
+		return "", err
+	}
+
+	return c.decrypt(envelope)
+}
+
+// RotateKeyCodes re-encrypts codes of all keys by new data keys of merchants.
+// Plaintext codes stored before encryption was enabled are encrypted too.
+func (s *Service) RotateKeyCodes(ctx context.Context) error {
+	if s.kms == nil {
+		return errorKeyCodeEncryptionDisabled
+	}
+
+	ciphers := make(map[string]*keyCodeCipher)
+	dataKeysMerchants := make(map[string]string)
+	keyProductsMerchants := make(map[string]string)
+	dataKeyIds := make([]string, 0)
+
+	afterId := ""
+	processed := 0
+	failed := 0
+
+	for {
+		keys, err := s.keyRepository.FindForReencryption(ctx, dataKeyIds, afterId, keyCodeReencryptionBatchSize)
+
+		if err != nil {
+			return err
+		}
+
+		if len(keys) <= 0 {
+			break
+		}
+
+		for _, key := range keys {
+			afterId = key.Id
+			merchantId, err := s.getKeyMerchantId(ctx, key, dataKeysMerchants, keyProductsMerchants)
+
+			if err != nil {
+				zap.L().Error("Unable to get merchant of key", zap.Error(err), zap.String("key_id", key.Id))
+				failed++
+				continue
+			}
+
+			c, ok := ciphers[merchantId]
+
+			if !ok {
+				c, err = s.newMerchantKeyCodeCipher(ctx, merchantId)
+
+				if err != nil {
+					return err
+				}
+
+				ciphers[merchantId] = c
+				dataKeyIds = append(dataKeyIds, c.getDataKeyId())
+			}
+
+			code, err := s.decryptKeyCode(ctx, key.Code)
+
+			if err != nil {
+				zap.L().Error("Unable to decrypt key code", zap.Error(err), zap.String("key_id", key.Id))
+				failed++
+				continue
+			}
+
+			key.Code, err = c.encrypt(code, s.getKeyCodeIndex(code))
+
+			if err != nil {
+				zap.L().Error("Unable to encrypt key code", zap.Error(err), zap.String("key_id", key.Id))
+				failed++
+				continue
+			}
+
+			if err = s.keyRepository.UpdateCode(ctx, key); err != nil {
+				zap.L().Error("Unable to update key code", zap.Error(err), zap.String("key_id", key.Id))
+				failed++
+				continue
+			}
+
+			processed++
+		}
+	}
+
+	zap.L().Info(
+		"Key codes rotation finished",
+		zap.Int("processed", processed),
+		zap.Int("failed", failed),
+		zap.Int("merchants", len(ciphers)),
+	)
+
+	if failed > 0 {
+		return fmt.Errorf("key codes of %d keys weren't rotated and left on the previous keys", failed)
+	}
+
+	return nil
+}
+
+func (s *Service) getKeyMerchantId(
+	ctx context.Context,
+	key *billingpb.Key,
+	dataKeysMerchants, keyProductsMerchants map[string]string,
+) (string, error) {
+	if envelope, ok := pkg.ParseKeyCodeEnvelope(key.Code); ok {
+		if merchantId, ok := dataKeysMerchants[envelope.DataKeyId]; ok {
+			return merchantId, nil
+		}
+
+		dataKey, err := s.merchantDataKeyRepository.GetById(ctx, envelope.DataKeyId)
+
+		if err != nil {
+			return "", err
+		}
+
+		dataKeysMerchants[envelope.DataKeyId] = dataKey.MerchantId
+
+		return dataKey.MerchantId, nil
+	}
+
+	if merchantId, ok := keyProductsMerchants[key.KeyProductId]; ok {
+		return merchantId, nil
+	}
+
+	keyProduct, err := s.keyProductRepository.GetById(ctx, key.KeyProductId)
+
+	if err != nil {
+		return "", err
+	}
+
+	keyProductsMerchants[key.KeyProductId] = keyProduct.MerchantId
+
+	return keyProduct.MerchantId, nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	errors2 "github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+func (suite *KeyTestSuite) helperCreateKeyProduct() *billingpb.KeyProduct {
+	keyProduct := &billingpb.KeyProduct{
+		Id:              primitive.NewObjectID().Hex(),
+		Object:          "product",
+		Sku:             "ru_double_yeti",
+		Name:            map[string]string{"en": "Double yeti"},
+		DefaultCurrency: "USD",
+		MerchantId:      primitive.NewObjectID().Hex(),
+		ProjectId:       primitive.NewObjectID().Hex(),
+	}
+	assert.NoError(suite.T(), suite.service.keyProductRepository.Upsert(context.TODO(), keyProduct))
+
+	return keyProduct
+}
+
+func (suite *KeyTestSuite) helperUploadKeys(keyProduct *billingpb.KeyProduct, codes ...string) *billingpb.PlatformKeysFileResponse {
+	req := &billingpb.PlatformKeysFileRequest{
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		MerchantId:   keyProduct.MerchantId,
+		File:         []byte(strings.Join(codes, "\n")),
+	}
+	res := &billingpb.PlatformKeysFileResponse{}
+
+	err := suite.service.UploadKeysFile(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	return res
+}
+
+func (suite *KeyTestSuite) TestKey_UploadKeysFile_EncryptsCodes() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF")
+	assert.Equal(suite.T(), int32(2), res.KeysProcessed)
+
+	key, err := suite.service.keyRepository.ReserveKey(context.TODO(), keyProduct.Id, "steam", primitive.NewObjectID().Hex(), 10)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(key.Code, pkg.KeyCodeEncryptedPrefix))
+	assert.NotContains(suite.T(), key.Code, "AAAA-BBBB-CCCC")
+	assert.NotContains(suite.T(), key.Code, "DDDD-EEEE-FFFF")
+
+	dataKey, err := suite.service.merchantDataKeyRepository.GetLastByMerchantId(context.TODO(), keyProduct.MerchantId)
+	assert.NoError(suite.T(), err)
+	envelope, ok := pkg.ParseKeyCodeEnvelope(key.Code)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), dataKey.Id, envelope.DataKeyId)
+}
+
+func (suite *KeyTestSuite) TestKey_UploadKeysFile_Duplicate() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	assert.Equal(suite.T(), int32(1), res.KeysProcessed)
+
+	res = suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF")
+	assert.Equal(suite.T(), int32(1), res.KeysProcessed)
+	assert.Equal(suite.T(), int32(2), res.TotalCount)
+}
+
+func (suite *KeyTestSuite) TestKey_UploadKeysFile_Error_KeyProductNotFound() {
+	req := &billingpb.PlatformKeysFileRequest{
+		KeyProductId: primitive.NewObjectID().Hex(),
+		PlatformId:   "steam",
+		File:         []byte("AAAA-BBBB-CCCC"),
+	}
+	res := &billingpb.PlatformKeysFileResponse{}
+
+	err := suite.service.UploadKeysFile(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), keyProductNotFound, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_FinishRedeemKeyForOrder_DecryptsCode() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+
+	reserveRes := &billingpb.PlatformKeyReserveResponse{}
+	err := suite.service.ReserveKeyForOrder(context.TODO(), &billingpb.PlatformKeyReserveRequest{
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		OrderId:      primitive.NewObjectID().Hex(),
+		Ttl:          10,
+	}, reserveRes)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, reserveRes.Status)
+
+	res := &billingpb.GetKeyForOrderRequestResponse{}
+	err = suite.service.FinishRedeemKeyForOrder(context.TODO(), &billingpb.KeyForOrderRequest{KeyId: reserveRes.KeyId}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), "AAAA-BBBB-CCCC", res.Key.Code)
+
+	// key code is returned in plaintext only at redemption
+	getRes := &billingpb.GetKeyForOrderRequestResponse{}
+	err = suite.service.GetKeyByID(context.TODO(), &billingpb.KeyForOrderRequest{KeyId: reserveRes.KeyId}, getRes)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(getRes.Key.Code, pkg.KeyCodeEncryptedPrefix))
+}
+
+func (suite *KeyTestSuite) TestKey_FinishRedeemKeyForOrder_Error_Decrypt() {
+	req := &billingpb.KeyForOrderRequest{
+		KeyId: primitive.NewObjectID().Hex(),
+	}
+	res := billingpb.GetKeyForOrderRequestResponse{}
+	envelope := &pkg.KeyCodeEnvelope{DataKeyId: primitive.NewObjectID().Hex(), Index: "index", Ciphertext: "ciphertext"}
+	key := &billingpb.Key{
+		Id:   req.KeyId,
+		Code: envelope.String(),
+	}
+
+	kr := &mocks.KeyRepositoryInterface{}
+	kr.On("FinishRedeemById", mock2.Anything, req.KeyId).Return(key, nil)
+	suite.service.keyRepository = kr
+
+	dkr := &mocks.MerchantDataKeyRepositoryInterface{}
+	dkr.On("GetById", mock2.Anything, envelope.DataKeyId).Return(nil, errors.New("not found"))
+	suite.service.merchantDataKeyRepository = dkr
+
+	err := suite.service.FinishRedeemKeyForOrder(context.TODO(), req, &res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusSystemError, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorDecrypt, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_DecryptKeyCode_Plaintext() {
+	code, err := suite.service.decryptKeyCode(context.TODO(), "AAAA-BBBB-CCCC")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "AAAA-BBBB-CCCC", code)
+}
+
+func (suite *KeyTestSuite) TestKey_RotateKeyCodes_Ok() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+
+	// key stored as plaintext before encryption was enabled
+	legacy := &billingpb.Key{
+		Id:           primitive.NewObjectID().Hex(),
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "gog",
+		Code:         "DDDD-EEEE-FFFF",
+	}
+	assert.NoError(suite.T(), suite.service.keyRepository.Insert(context.TODO(), legacy))
+
+	dataKey, err := suite.service.merchantDataKeyRepository.GetLastByMerchantId(context.TODO(), keyProduct.MerchantId)
+	assert.NoError(suite.T(), err)
+
+	assert.NoError(suite.T(), suite.service.RotateKeyCodes(context.TODO()))
+
+	rotated, err := suite.service.merchantDataKeyRepository.GetLastByMerchantId(context.TODO(), keyProduct.MerchantId)
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), dataKey.Id, rotated.Id)
+
+	for platformId, expected := range map[string]string{"steam": "AAAA-BBBB-CCCC", "gog": "DDDD-EEEE-FFFF"} {
+		key, err := suite.service.keyRepository.ReserveKey(context.TODO(), keyProduct.Id, platformId, primitive.NewObjectID().Hex(), 10)
+		assert.NoError(suite.T(), err)
+
+		envelope, ok := pkg.ParseKeyCodeEnvelope(key.Code)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), rotated.Id, envelope.DataKeyId)
+
+		code, err := suite.service.decryptKeyCode(context.TODO(), key.Code)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), expected, code)
+	}
+
+	// codes encrypted by the latest data keys are not processed again
+	keys, err := suite.service.keyRepository.FindForReencryption(context.TODO(), []string{rotated.Id}, "", 10)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), keys)
+}
+
+func (suite *KeyTestSuite) TestKey_UploadKeysFile_Duplicate_LegacyPlaintext() {
+	keyProduct := suite.helperCreateKeyProduct()
+
+	// key stored as plaintext before encryption was enabled
+	legacy := &billingpb.Key{
+		Id:           primitive.NewObjectID().Hex(),
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		Code:         "AAAA-BBBB-CCCC",
+	}
+	assert.NoError(suite.T(), suite.service.keyRepository.Insert(context.TODO(), legacy))
+
+	res := suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF")
+	assert.Equal(suite.T(), int32(1), res.KeysProcessed)
+}
+
+func (suite *KeyTestSuite) TestKey_UploadKeysFile_EncryptionDisabled() {
+	suite.service.kms = nil
+
+	keyProduct := suite.helperCreateKeyProduct()
+	res := suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	assert.Equal(suite.T(), int32(1), res.KeysProcessed)
+
+	key, err := suite.service.keyRepository.ReserveKey(context.TODO(), keyProduct.Id, "steam", primitive.NewObjectID().Hex(), 10)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "AAAA-BBBB-CCCC", key.Code)
+
+	res = suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	assert.Equal(suite.T(), int32(0), res.KeysProcessed)
+
+	assert.Equal(suite.T(), errorKeyCodeEncryptionDisabled, suite.service.RotateKeyCodes(context.TODO()))
+}
+
+func (suite *KeyTestSuite) TestKey_RotateKeyCodes_Error_UpdateCode() {
+	keyProduct := suite.helperCreateKeyProduct()
+	key := &billingpb.Key{
+		Id:           primitive.NewObjectID().Hex(),
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		Code:         "AAAA-BBBB-CCCC",
+	}
+
+	kr := &mocks.KeyRepositoryInterface{}
+	kr.On("FindForReencryption", mock2.Anything, mock2.Anything, "", mock2.Anything).
+		Return([]*billingpb.Key{key}, nil)
+	kr.On("FindForReencryption", mock2.Anything, mock2.Anything, key.Id, mock2.Anything).
+		Return([]*billingpb.Key{}, nil)
+	kr.On("UpdateCode", mock2.Anything, mock2.Anything).Return(errors.New("update failed"))
+	suite.service.keyRepository = kr
+
+	err := suite.service.RotateKeyCodes(context.TODO())
+	assert.Error(suite.T(), err)
+	kr.AssertCalled(suite.T(), "UpdateCode", mock2.Anything, mock2.Anything)
+}
+
+func (suite *KeyTestSuite) TestKey_SendMailWithCode_KeepsCodeForNotification() {
+	keyProductId := primitive.NewObjectID().Hex()
+	order := &billingpb.Order{
+		Id:           primitive.NewObjectID().Hex(),
+		ReceiptEmail: "test@unit.test",
+		Items:        []*billingpb.OrderItem{{Id: keyProductId, Name: "Double yeti"}},
+	}
+	key := &billingpb.Key{Id: primitive.NewObjectID().Hex(), KeyProductId: keyProductId, Code: "AAAA-BBBB-CCCC"}
+
+	suite.service.sendMailWithCode(context.TODO(), order, key)
+	assert.Equal(suite.T(), "AAAA-BBBB-CCCC", order.Items[0].Code)
+}
+
+func (suite *KeyTestSuite) TestKey_MaskKeyCode() {
+	assert.Equal(suite.T(), "****-****-CCCC", helper.MaskKeyCode("AAAA-BBBB-CCCC"))
+	assert.Equal(suite.T(), "ABC", helper.MaskKeyCode("ABC"))
+}
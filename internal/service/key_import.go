@@ -0,0 +1,514 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	keyImportChunkMaxSize     = 2 * 1024 * 1024
+	keyImportInsertBatchSize  = 1000
+	keyImportLockTtl          = 10 * time.Minute
+	keyImportProgressCode     = "ki00001"
+	keyImportProgressMessage  = "key import progress"
+	keyImportErrorChunkFailed = "unable to read chunk of the file"
+)
+
+func (s *Service) CreateKeyImportJob(
+	ctx context.Context,
+	req *pkg.CreateKeyImportJobRequest,
+	res *pkg.KeyImportJobResponse,
+) error {
+	_, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if req.PlatformId == "" {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorPlatform
+		return nil
+	}
+
+	job := &pkg.KeyImportJob{
+		Id:           primitive.NewObjectID().Hex(),
+		MerchantId:   req.MerchantId,
+		KeyProductId: req.KeyProductId,
+		PlatformId:   req.PlatformId,
+		Status:       pkg.KeyImportJobStatusUploading,
+		CreatedAt:    ptypes.TimestampNow(),
+		UpdatedAt:    ptypes.TimestampNow(),
+	}
+
+	if err := s.keyImportJobRepository.Insert(ctx, job); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = job
+
+	return nil
+}
+
+// UploadKeyImportChunk saves the chunk of the file with keys, chunks may be uploaded in any order
+// and repeated upload of the chunk replaces the previous one.
+func (s *Service) UploadKeyImportChunk(
+	ctx context.Context,
+	req *pkg.UploadKeyImportChunkRequest,
+	res *pkg.KeyImportJobResponse,
+) error {
+	job, msg := s.getMerchantKeyImportJob(ctx, req.MerchantId, req.JobId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if job.Status != pkg.KeyImportJobStatusUploading {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorImportJobStatus
+		return nil
+	}
+
+	if req.Number <= 0 {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorImportChunkNumber
+		return nil
+	}
+
+	if len(req.Data) <= 0 || len(req.Data) > keyImportChunkMaxSize {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorImportChunkSize
+		return nil
+	}
+
+	c, err := s.getMerchantKeyCodeCipher(ctx, job.MerchantId)
+
+	if err == nil {
+		req.Data, err = c.encryptData(req.Data)
+	}
+
+	if err != nil {
+		zap.L().Error(errors.KeyErrorEncrypt.Message, zap.Error(err), zap.String("job_id", job.Id))
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorEncrypt
+		return nil
+	}
+
+	chunk := &pkg.KeyImportChunk{
+		JobId:     job.Id,
+		Number:    req.Number,
+		DataKeyId: c.getDataKeyId(),
+		Data:      req.Data,
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	if err = s.keyImportChunkRepository.Upsert(ctx, chunk); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	count, err := s.keyImportChunkRepository.CountByJobId(ctx, job.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	job.ChunksUploaded = int32(count)
+	job.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.keyImportJobRepository.Update(ctx, job); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = job
+
+	return nil
+}
+
+// CommitKeyImportJob finishes the upload of the file and queues the job for processing.
+func (s *Service) CommitKeyImportJob(
+	ctx context.Context,
+	req *pkg.CommitKeyImportJobRequest,
+	res *pkg.KeyImportJobResponse,
+) error {
+	job, msg := s.getMerchantKeyImportJob(ctx, req.MerchantId, req.JobId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if job.Status != pkg.KeyImportJobStatusUploading {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorImportJobStatus
+		return nil
+	}
+
+	if req.ChunksCount <= 0 || req.ChunksCount != job.ChunksUploaded {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorImportChunksMissing
+		return nil
+	}
+
+	// chunks are numbered from one, so the last chunk exists only if there are no gaps in numbering
+	if _, err := s.keyImportChunkRepository.GetByJobIdNumber(ctx, job.Id, req.ChunksCount); err != nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorImportChunksMissing
+		return nil
+	}
+
+	job.Status = pkg.KeyImportJobStatusQueued
+	job.ChunksCount = req.ChunksCount
+	job.UpdatedAt = ptypes.TimestampNow()
+
+	if err := s.keyImportJobRepository.Update(ctx, job); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	s.publishKeyImportProgress(ctx, job)
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = job
+
+	return nil
+}
+
+func (s *Service) GetKeyImportJob(
+	ctx context.Context,
+	req *pkg.GetKeyImportJobRequest,
+	res *pkg.KeyImportJobResponse,
+) error {
+	job, msg := s.getMerchantKeyImportJob(ctx, req.MerchantId, req.JobId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = job
+
+	return nil
+}
+
+// KeyImportDaemonProcess processes all queued key import jobs and resumes jobs which processing was interrupted.
+// Returns count of processed jobs.
+func (s *Service) KeyImportDaemonProcess(ctx context.Context) (int, error) {
+	counter := 0
+
+	for {
+		job, err := s.keyImportJobRepository.LockNext(ctx, time.Now().Add(keyImportLockTtl))
+
+		if err == mongo.ErrNoDocuments {
+			return counter, nil
+		}
+
+		if err != nil {
+			return counter, err
+		}
+
+		if err = s.processKeyImportJob(ctx, job); err != nil {
+			return counter, err
+		}
+
+		counter++
+	}
+}
+
+func (s *Service) processKeyImportJob(ctx context.Context, job *pkg.KeyImportJob) error {
+	if job.StartedAt == nil {
+		job.StartedAt = ptypes.TimestampNow()
+	}
+
+	c, err := s.getMerchantKeyCodeCipher(ctx, job.MerchantId)
+
+	if err != nil {
+		zap.L().Error(errors.KeyErrorEncrypt.Message, zap.Error(err), zap.String("job_id", job.Id))
+		return s.finishKeyImportJob(ctx, job, errors.KeyErrorEncrypt.Message)
+	}
+
+	ciphers := map[string]*keyCodeCipher{c.getDataKeyId(): c}
+	tail, err := s.getKeyImportTail(ctx, job, ciphers)
+
+	if err != nil {
+		return s.finishKeyImportJob(ctx, job, keyImportErrorChunkFailed)
+	}
+
+	for number := job.ChunksProcessed + 1; number <= job.ChunksCount; number++ {
+		data, err := s.getKeyImportChunkData(ctx, job.Id, number, ciphers)
+
+		if err != nil {
+			return s.finishKeyImportJob(ctx, job, keyImportErrorChunkFailed)
+		}
+
+		data = append(tail, data...)
+		tail = nil
+
+		// the last line of the chunk may be continued in the next chunk
+		if number < job.ChunksCount {
+			index := bytes.LastIndexByte(data, '\n')
+			tail = append([]byte{}, data[index+1:]...)
+			data = data[:index+1]
+		}
+
+		s.importKeys(ctx, job, c, data)
+
+		job.ChunksProcessed = number
+		job.LockedUntil, _ = ptypes.TimestampProto(time.Now().Add(keyImportLockTtl))
+		job.UpdatedAt = ptypes.TimestampNow()
+
+		if err = s.keyImportJobRepository.Update(ctx, job); err != nil {
+			return err
+		}
+
+		s.publishKeyImportProgress(ctx, job)
+	}
+
+	return s.finishKeyImportJob(ctx, job, "")
+}
+
+// getKeyImportTail returns the beginning of the line which was started in already processed chunks
+// and is continued in the next chunk.
+func (s *Service) getKeyImportTail(
+	ctx context.Context,
+	job *pkg.KeyImportJob,
+	ciphers map[string]*keyCodeCipher,
+) ([]byte, error) {
+	var tail []byte
+
+	for number := job.ChunksProcessed; number > 0; number-- {
+		data, err := s.getKeyImportChunkData(ctx, job.Id, number, ciphers)
+
+		if err != nil {
+			return nil, err
+		}
+
+		index := bytes.LastIndexByte(data, '\n')
+		tail = append(append([]byte{}, data[index+1:]...), tail...)
+
+		if index >= 0 {
+			break
+		}
+	}
+
+	return tail, nil
+}
+
+func (s *Service) getKeyImportChunkData(
+	ctx context.Context,
+	jobId string,
+	number int32,
+	ciphers map[string]*keyCodeCipher,
+) ([]byte, error) {
+	chunk, err := s.keyImportChunkRepository.GetByJobIdNumber(ctx, jobId, number)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// chunk was stored before key codes encryption was enabled
+	if chunk.DataKeyId == "" {
+		return chunk.Data, nil
+	}
+
+	c, ok := ciphers[chunk.DataKeyId]
+
+	if !ok {
+		c, err = s.getKeyCodeCipherByDataKeyId(ctx, chunk.DataKeyId)
+
+		if err != nil {
+			zap.L().Error(errors.KeyErrorDecrypt.Message, zap.Error(err), zap.String("job_id", jobId))
+			return nil, err
+		}
+
+		ciphers[chunk.DataKeyId] = c
+	}
+
+	data, err := c.decryptData(chunk.Data)
+
+	if err != nil {
+		zap.L().Error(errors.KeyErrorDecrypt.Message, zap.Error(err), zap.String("job_id", jobId))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// importKeys inserts keys from the lines of the file and updates counters of the job.
+func (s *Service) importKeys(ctx context.Context, job *pkg.KeyImportJob, c *keyCodeCipher, data []byte) {
+	var codes, indexes []string
+	unique := make(map[string]string)
+
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		code := string(bytes.TrimSpace(line))
+
+		if code == "" {
+			continue
+		}
+
+		job.LinesCount++
+
+		if len(code) > keyCodeMaxLength {
+			job.FailedCount++
+			continue
+		}
+
+		index := s.getKeyCodeIndex(code)
+
+		if _, ok := unique[index]; ok {
+			job.DuplicatesCount++
+			continue
+		}
+
+		unique[index] = code
+		codes = append(codes, code)
+		indexes = append(indexes, index)
+	}
+
+	existing, err := s.getExistingKeyCodeIndexes(ctx, job.PlatformId, unique)
+
+	if err != nil {
+		job.FailedCount += int32(len(codes))
+		return
+	}
+
+	keys := make([]*billingpb.Key, 0, keyImportInsertBatchSize)
+
+	for i, code := range codes {
+		if existing[indexes[i]] {
+			job.DuplicatesCount++
+			continue
+		}
+
+		key := &billingpb.Key{
+			Id:           primitive.NewObjectID().Hex(),
+			KeyProductId: job.KeyProductId,
+			PlatformId:   job.PlatformId,
+		}
+		key.Code, err = c.encrypt(code, indexes[i])
+
+		if err != nil {
+			zap.L().Error(errors.KeyErrorEncrypt.Message, zap.Error(err), zap.String("job_id", job.Id))
+			job.FailedCount++
+			continue
+		}
+
+		keys = append(keys, key)
+
+		if len(keys) >= keyImportInsertBatchSize {
+			s.insertImportedKeys(ctx, job, keys)
+			keys = keys[:0]
+		}
+	}
+
+	if len(keys) > 0 {
+		s.insertImportedKeys(ctx, job, keys)
+	}
+}
+
+func (s *Service) insertImportedKeys(ctx context.Context, job *pkg.KeyImportJob, keys []*billingpb.Key) {
+	duplicates, failed, err := s.keyRepository.MultipleInsert(ctx, keys)
+
+	if err != nil {
+		job.FailedCount += int32(len(keys))
+		return
+	}
+
+	job.DuplicatesCount += int32(len(duplicates))
+	job.FailedCount += int32(len(failed))
+
+	rejected := make(map[int]bool)
+
+	for _, i := range append(duplicates, failed...) {
+		rejected[i] = true
+	}
+
+	audits := make([]*pkg.KeyAudit, 0, len(keys)-len(rejected))
+
+	for i, key := range keys {
+		if rejected[i] {
+			continue
+		}
+
+		audits = append(audits, newKeyAudit(key, pkg.KeyAuditActionUploaded, "", ""))
+	}
+
+	job.KeysProcessed += int32(len(audits))
+	s.addKeyAudits(ctx, audits)
+}
+
+func (s *Service) finishKeyImportJob(ctx context.Context, job *pkg.KeyImportJob, reason string) error {
+	job.Status = pkg.KeyImportJobStatusCompleted
+
+	if reason != "" {
+		job.Status = pkg.KeyImportJobStatusFailed
+		job.Error = reason
+	}
+
+	job.LockedUntil = nil
+	job.FinishedAt = ptypes.TimestampNow()
+	job.UpdatedAt = ptypes.TimestampNow()
+
+	if err := s.keyImportJobRepository.Update(ctx, job); err != nil {
+		return err
+	}
+
+	if err := s.keyImportChunkRepository.DeleteByJobId(ctx, job.Id); err != nil {
+		zap.L().Error("Delete chunks of key import failed", zap.Error(err), zap.String("job_id", job.Id))
+	}
+
+	s.checkKeyStockThreshold(ctx, job.KeyProductId, job.PlatformId)
+	s.publishKeyImportProgress(ctx, job)
+
+	return nil
+}
+
+func (s *Service) getMerchantKeyImportJob(
+	ctx context.Context,
+	merchantId, jobId string,
+) (*pkg.KeyImportJob, *billingpb.ResponseErrorMessage) {
+	job, err := s.keyImportJobRepository.GetById(ctx, jobId)
+
+	if err != nil || job.MerchantId != merchantId {
+		return nil, errors.KeyErrorImportJobNotFound
+	}
+
+	return job, nil
+}
+
+func (s *Service) publishKeyImportProgress(ctx context.Context, job *pkg.KeyImportJob) {
+	msg := map[string]interface{}{"id": job.Id, "code": keyImportProgressCode, "message": keyImportProgressMessage, "item": job}
+
+	if err := s.centrifugoDashboard.Publish(ctx, s.getMerchantCentrifugoChannel(job.MerchantId), msg); err != nil {
+		zap.L().Error(
+			"[Centrifugo] Send merchant notification about key import progress failed",
+			zap.Error(err),
+			zap.String("job_id", job.Id),
+		)
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	errors2 "github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+func (suite *KeyTestSuite) helperMockCentrifugo() *mocks.CentrifugoInterface {
+	centrifugoMock := &mocks.CentrifugoInterface{}
+	centrifugoMock.On("Publish", mock2.Anything, mock2.Anything, mock2.Anything).Return(nil)
+	suite.service.centrifugoDashboard = centrifugoMock
+
+	return centrifugoMock
+}
+
+func (suite *KeyTestSuite) helperCreateKeyImportJob(keyProduct *billingpb.KeyProduct, chunks ...string) *pkg.KeyImportJob {
+	res := &pkg.KeyImportJobResponse{}
+	err := suite.service.CreateKeyImportJob(context.TODO(), &pkg.CreateKeyImportJobRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.KeyImportJobStatusUploading, res.Item.Status)
+
+	// chunks are uploaded in reverse order to check that order of upload doesn't matter
+	for i := len(chunks) - 1; i >= 0; i-- {
+		err = suite.service.UploadKeyImportChunk(context.TODO(), &pkg.UploadKeyImportChunkRequest{
+			MerchantId: keyProduct.MerchantId,
+			JobId:      res.Item.Id,
+			Number:     int32(i + 1),
+			Data:       []byte(chunks[i]),
+		}, res)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	}
+
+	assert.Equal(suite.T(), int32(len(chunks)), res.Item.ChunksUploaded)
+
+	return res.Item
+}
+
+func (suite *KeyTestSuite) helperCommitKeyImportJob(job *pkg.KeyImportJob) *pkg.KeyImportJobResponse {
+	res := &pkg.KeyImportJobResponse{}
+	err := suite.service.CommitKeyImportJob(context.TODO(), &pkg.CommitKeyImportJobRequest{
+		MerchantId:  job.MerchantId,
+		JobId:       job.Id,
+		ChunksCount: job.ChunksUploaded,
+	}, res)
+	assert.NoError(suite.T(), err)
+
+	return res
+}
+
+func (suite *KeyTestSuite) TestKey_KeyImport_Ok() {
+	centrifugoMock := suite.helperMockCentrifugo()
+
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+
+	job := suite.helperCreateKeyImportJob(
+		keyProduct,
+		"DDDD-EEEE-FFFF\n\nAAAA-BBBB-CCCC\nGGGG-",
+		"HHHH-IIII\n"+strings.Repeat("A", keyCodeMaxLength+1)+"\nDDDD-EEEE-FFFF\n",
+		"JJJJ-KKKK-LLLL",
+	)
+
+	// chunks are stored encrypted
+	chunk, err := suite.service.keyImportChunkRepository.GetByJobIdNumber(context.TODO(), job.Id, 1)
+	assert.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), string(chunk.Data), "DDDD-EEEE-FFFF")
+
+	res := suite.helperCommitKeyImportJob(job)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.KeyImportJobStatusQueued, res.Item.Status)
+	assert.Equal(suite.T(), int32(3), res.Item.ChunksCount)
+
+	count, err := suite.service.KeyImportDaemonProcess(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	getRes := &pkg.KeyImportJobResponse{}
+	err = suite.service.GetKeyImportJob(context.TODO(), &pkg.GetKeyImportJobRequest{
+		MerchantId: keyProduct.MerchantId,
+		JobId:      job.Id,
+	}, getRes)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, getRes.Status)
+	assert.Equal(suite.T(), pkg.KeyImportJobStatusCompleted, getRes.Item.Status)
+	assert.Equal(suite.T(), int32(3), getRes.Item.ChunksProcessed)
+	assert.Equal(suite.T(), int32(6), getRes.Item.LinesCount)
+	assert.Equal(suite.T(), int32(3), getRes.Item.KeysProcessed)
+	assert.Equal(suite.T(), int32(2), getRes.Item.DuplicatesCount)
+	assert.Equal(suite.T(), int32(1), getRes.Item.FailedCount)
+	assert.NotNil(suite.T(), getRes.Item.StartedAt)
+	assert.NotNil(suite.T(), getRes.Item.FinishedAt)
+
+	available, err := suite.service.keyRepository.CountKeysByProductPlatform(context.TODO(), keyProduct.Id, "steam")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(4), available)
+
+	chunks, err := suite.service.keyImportChunkRepository.CountByJobId(context.TODO(), job.Id)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), chunks)
+
+	// progress is published after commit, after each chunk and on finish
+	centrifugoMock.AssertNumberOfCalls(suite.T(), "Publish", 5)
+
+	count, err = suite.service.KeyImportDaemonProcess(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), count)
+}
+
+func (suite *KeyTestSuite) TestKey_KeyImport_Resume() {
+	suite.helperMockCentrifugo()
+
+	keyProduct := suite.helperCreateKeyProduct()
+	job := suite.helperCreateKeyImportJob(keyProduct, "AAAA-BBBB-CCCC\nDDDD-", "EEEE-FFFF\nGGGG-HHHH-IIII")
+	res := suite.helperCommitKeyImportJob(job)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	// processing of the job was interrupted after the first chunk
+	job = res.Item
+	job.Status = pkg.KeyImportJobStatusProcessing
+	job.ChunksProcessed = 1
+	job.LockedUntil, _ = ptypes.TimestampProto(time.Now().Add(-time.Minute))
+	assert.NoError(suite.T(), suite.service.keyImportJobRepository.Update(context.TODO(), job))
+
+	count, err := suite.service.KeyImportDaemonProcess(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	job, err = suite.service.keyImportJobRepository.GetById(context.TODO(), job.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.KeyImportJobStatusCompleted, job.Status)
+	assert.Equal(suite.T(), int32(2), job.KeysProcessed)
+
+	// keys of the first chunk aren't imported, the line started in the first chunk is finished in the second one
+	var codes []string
+
+	for i := 0; i < 2; i++ {
+		key, err := suite.service.keyRepository.ReserveKey(context.TODO(), keyProduct.Id, "steam", primitive.NewObjectID().Hex(), 10)
+		assert.NoError(suite.T(), err)
+
+		code, err := suite.service.decryptKeyCode(context.TODO(), key.Code)
+		assert.NoError(suite.T(), err)
+		codes = append(codes, code)
+	}
+
+	assert.ElementsMatch(suite.T(), []string{"DDDD-EEEE-FFFF", "GGGG-HHHH-IIII"}, codes)
+}
+
+func (suite *KeyTestSuite) TestKey_KeyImport_LockedJobIsSkipped() {
+	suite.helperMockCentrifugo()
+
+	keyProduct := suite.helperCreateKeyProduct()
+	job := suite.helperCreateKeyImportJob(keyProduct, "AAAA-BBBB-CCCC")
+	res := suite.helperCommitKeyImportJob(job)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	job = res.Item
+	job.Status = pkg.KeyImportJobStatusProcessing
+	job.LockedUntil, _ = ptypes.TimestampProto(time.Now().Add(time.Minute))
+	assert.NoError(suite.T(), suite.service.keyImportJobRepository.Update(context.TODO(), job))
+
+	count, err := suite.service.KeyImportDaemonProcess(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), count)
+}
+
+func (suite *KeyTestSuite) TestKey_CommitKeyImportJob_Error_ChunksMissing() {
+	keyProduct := suite.helperCreateKeyProduct()
+	job := suite.helperCreateKeyImportJob(keyProduct, "AAAA-BBBB-CCCC\n")
+
+	res := &pkg.KeyImportJobResponse{}
+	err := suite.service.CommitKeyImportJob(context.TODO(), &pkg.CommitKeyImportJobRequest{
+		MerchantId:  keyProduct.MerchantId,
+		JobId:       job.Id,
+		ChunksCount: 2,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorImportChunksMissing, res.Message)
+
+	// gap in numbering of chunks
+	err = suite.service.UploadKeyImportChunk(context.TODO(), &pkg.UploadKeyImportChunkRequest{
+		MerchantId: keyProduct.MerchantId,
+		JobId:      job.Id,
+		Number:     3,
+		Data:       []byte("DDDD-EEEE-FFFF"),
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	err = suite.service.CommitKeyImportJob(context.TODO(), &pkg.CommitKeyImportJobRequest{
+		MerchantId:  keyProduct.MerchantId,
+		JobId:       job.Id,
+		ChunksCount: 2,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorImportChunksMissing, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_UploadKeyImportChunk_Error() {
+	suite.helperMockCentrifugo()
+
+	keyProduct := suite.helperCreateKeyProduct()
+	job := suite.helperCreateKeyImportJob(keyProduct)
+
+	req := &pkg.UploadKeyImportChunkRequest{
+		MerchantId: keyProduct.MerchantId,
+		JobId:      job.Id,
+		Number:     0,
+		Data:       []byte("AAAA-BBBB-CCCC"),
+	}
+	res := &pkg.KeyImportJobResponse{}
+	err := suite.service.UploadKeyImportChunk(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorImportChunkNumber, res.Message)
+
+	req.Number = 1
+	req.Data = nil
+	err = suite.service.UploadKeyImportChunk(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorImportChunkSize, res.Message)
+
+	req.Data = []byte("AAAA-BBBB-CCCC")
+	req.MerchantId = primitive.NewObjectID().Hex()
+	err = suite.service.UploadKeyImportChunk(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorImportJobNotFound, res.Message)
+
+	req.MerchantId = keyProduct.MerchantId
+	err = suite.service.UploadKeyImportChunk(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	commitRes := suite.helperCommitKeyImportJob(res.Item)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, commitRes.Status)
+
+	err = suite.service.UploadKeyImportChunk(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorImportJobStatus, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_CreateKeyImportJob_Error_Platform() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.KeyImportJobResponse{}
+	err := suite.service.CreateKeyImportJob(context.TODO(), &pkg.CreateKeyImportJobRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorPlatform, res.Message)
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.uber.org/zap"
+)
+
+const (
+	keyCodeMaxLength        = 256
+	keyCodeIndexesBatchSize = 1000
+
+	keyReleaseReasonReservationExpired = "reservation expired"
+
+	keyStockLowMessage = "Stock of keys for the product \"%s\" on the platform \"%s\" is running low: %d keys left."
+)
+
+func (s *Service) GetKeys(
+	ctx context.Context,
+	req *pkg.GetKeysRequest,
+	res *pkg.GetKeysResponse,
+) error {
+	_, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if req.State == "" {
+		req.State = pkg.KeyStateAvailable
+	}
+
+	if !isKeyStateValid(req.State) {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorStateInvalid
+		return nil
+	}
+
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	count, err := s.keyRepository.FindCount(ctx, req.KeyProductId, req.PlatformId, req.State)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	keys, err := s.keyRepository.Find(ctx, req.KeyProductId, req.PlatformId, req.State, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	// codes are returned only to the customer at redemption
+	for _, key := range keys {
+		key.Code = ""
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+	res.Items = keys
+
+	return nil
+}
+
+func (s *Service) RevokeKeys(
+	ctx context.Context,
+	req *pkg.ChangeKeysStateRequest,
+	res *pkg.ChangeKeysStateResponse,
+) error {
+	return s.changeKeysState(ctx, req, res, pkg.KeyStateRevoked, pkg.KeyAuditActionRevoked)
+}
+
+func (s *Service) ExpireKeys(
+	ctx context.Context,
+	req *pkg.ChangeKeysStateRequest,
+	res *pkg.ChangeKeysStateResponse,
+) error {
+	return s.changeKeysState(ctx, req, res, pkg.KeyStateExpired, pkg.KeyAuditActionExpired)
+}
+
+func (s *Service) SetKeyStockThreshold(
+	ctx context.Context,
+	req *pkg.SetKeyStockThresholdRequest,
+	res *pkg.KeyStockThresholdResponse,
+) error {
+	keyProduct, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if req.PlatformId == "" {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorPlatform
+		return nil
+	}
+
+	if req.Threshold < 0 {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorThreshold
+		return nil
+	}
+
+	threshold := &pkg.KeyStockThreshold{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		PlatformId:   req.PlatformId,
+		CreatedAt:    ptypes.TimestampNow(),
+	}
+	thresholds, err := s.keyStockThresholdRepository.FindByKeyProductId(ctx, keyProduct.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	for _, v := range thresholds {
+		if v.PlatformId == req.PlatformId {
+			threshold = v
+			break
+		}
+	}
+
+	// notification will be sent again if stock is already low for the new threshold
+	threshold.Threshold = req.Threshold
+	threshold.IsNotified = false
+	threshold.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.keyStockThresholdRepository.Upsert(ctx, threshold); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	s.checkKeyStockThreshold(ctx, keyProduct.Id, req.PlatformId)
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = threshold
+
+	return nil
+}
+
+func (s *Service) GetKeyStockThresholds(
+	ctx context.Context,
+	req *pkg.GetKeyStockThresholdsRequest,
+	res *pkg.GetKeyStockThresholdsResponse,
+) error {
+	_, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	thresholds, err := s.keyStockThresholdRepository.FindByKeyProductId(ctx, req.KeyProductId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Items = thresholds
+
+	return nil
+}
+
+func (s *Service) GetKeyAudit(
+	ctx context.Context,
+	req *pkg.GetKeyAuditRequest,
+	res *pkg.GetKeyAuditResponse,
+) error {
+	_, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	audits, err := s.keyAuditRepository.Find(ctx, req.KeyProductId, req.KeyId, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Items = audits
+
+	return nil
+}
+
+// changeKeysState withdraws available keys from sale, reserved and redeemed keys are skipped.
+func (s *Service) changeKeysState(
+	ctx context.Context,
+	req *pkg.ChangeKeysStateRequest,
+	res *pkg.ChangeKeysStateResponse,
+	state, action string,
+) error {
+	_, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	keyIds := req.KeyIds
+
+	if len(keyIds) <= 0 {
+		if req.PlatformId == "" {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = errors.KeyErrorNotSpecified
+			return nil
+		}
+
+		keys, err := s.keyRepository.Find(ctx, req.KeyProductId, req.PlatformId, pkg.KeyStateAvailable, 0, 0)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errors.KeyErrorChangeState
+			return nil
+		}
+
+		for _, key := range keys {
+			keyIds = append(keyIds, key.Id)
+		}
+	}
+
+	var audits []*pkg.KeyAudit
+	platforms := make(map[string]bool)
+
+	for _, id := range keyIds {
+		key, err := s.keyRepository.ChangeState(ctx, req.KeyProductId, id, state)
+
+		if err != nil {
+			res.Skipped = append(res.Skipped, id)
+			continue
+		}
+
+		res.Count++
+		audits = append(audits, newKeyAudit(key, action, req.UserId, req.Reason))
+		platforms[key.PlatformId] = true
+	}
+
+	s.addKeyAudits(ctx, audits)
+
+	for platformId := range platforms {
+		s.checkKeyStockThreshold(ctx, req.KeyProductId, platformId)
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) getMerchantKeyProduct(
+	ctx context.Context,
+	merchantId, keyProductId string,
+) (*billingpb.KeyProduct, *billingpb.ResponseErrorMessage) {
+	keyProduct, err := s.keyProductRepository.GetById(ctx, keyProductId)
+
+	if err != nil {
+		return nil, keyProductNotFound
+	}
+
+	if keyProduct.MerchantId != merchantId {
+		return nil, keyProductMerchantMismatch
+	}
+
+	return keyProduct, nil
+}
+
+// getExistingKeyCodeIndexes returns hashes of codes which are already uploaded for the platform.
+// Codes is the map of hashes to plaintext codes, plaintext is used to find keys stored before encryption was enabled.
+func (s *Service) getExistingKeyCodeIndexes(
+	ctx context.Context,
+	platformId string,
+	codes map[string]string,
+) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	indexes := make([]string, 0, keyCodeIndexesBatchSize)
+	plaintexts := make([]string, 0, keyCodeIndexesBatchSize)
+
+	find := func() error {
+		keys, err := s.keyRepository.FindByCodes(ctx, platformId, indexes, plaintexts)
+
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if envelope, ok := pkg.ParseKeyCodeEnvelope(key.Code); ok {
+				existing[envelope.Index] = true
+			} else {
+				existing[s.getKeyCodeIndex(key.Code)] = true
+			}
+		}
+
+		indexes = indexes[:0]
+		plaintexts = plaintexts[:0]
+
+		return nil
+	}
+
+	for index, code := range codes {
+		indexes = append(indexes, index)
+		plaintexts = append(plaintexts, code)
+
+		if len(indexes) < keyCodeIndexesBatchSize {
+			continue
+		}
+
+		if err := find(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(indexes) > 0 {
+		if err := find(); err != nil {
+			return nil, err
+		}
+	}
+
+	return existing, nil
+}
+
+// checkKeyStockThreshold notifies the merchant when count of available keys falls to the threshold.
+func (s *Service) checkKeyStockThreshold(ctx context.Context, keyProductId, platformId string) {
+	thresholds, err := s.keyStockThresholdRepository.FindByKeyProductId(ctx, keyProductId)
+
+	if err != nil {
+		return
+	}
+
+	var threshold *pkg.KeyStockThreshold
+
+	for _, v := range thresholds {
+		if v.PlatformId == platformId {
+			threshold = v
+			break
+		}
+	}
+
+	if threshold == nil || threshold.Threshold <= 0 {
+		return
+	}
+
+	count, err := s.keyRepository.CountKeysByProductPlatform(ctx, keyProductId, platformId)
+
+	if err != nil {
+		return
+	}
+
+	isLow := count <= int64(threshold.Threshold)
+
+	if isLow == threshold.IsNotified {
+		return
+	}
+
+	threshold.IsNotified = isLow
+	threshold.UpdatedAt = ptypes.TimestampNow()
+
+	if isLow {
+		threshold.NotifiedAt = ptypes.TimestampNow()
+		name := keyProductId
+		keyProduct, err := s.keyProductRepository.GetById(ctx, keyProductId)
+
+		if err == nil {
+			if name, err = keyProduct.GetLocalizedName(DefaultLanguage); err != nil {
+				name = keyProduct.Sku
+			}
+		}
+
+		msg := fmt.Sprintf(keyStockLowMessage, name, platformId, count)
+
+		if _, err = s.addNotification(ctx, msg, threshold.MerchantId, "", nil); err != nil {
+			zap.L().Error(
+				"Add low stock of keys notification failed",
+				zap.Error(err),
+				zap.String("key_product_id", keyProductId),
+				zap.String("platform_id", platformId),
+			)
+		}
+	}
+
+	if err = s.keyStockThresholdRepository.Upsert(ctx, threshold); err != nil {
+		zap.L().Error(
+			"Update threshold of keys stock failed",
+			zap.Error(err),
+			zap.String("key_product_id", keyProductId),
+			zap.String("platform_id", platformId),
+		)
+	}
+}
+
+func (s *Service) addKeyAudit(ctx context.Context, audit *pkg.KeyAudit) {
+	if err := s.keyAuditRepository.Insert(ctx, audit); err != nil {
+		zap.L().Error("Add key audit failed", zap.Error(err), zap.String("key_id", audit.KeyId))
+	}
+}
+
+func (s *Service) addKeyAudits(ctx context.Context, audits []*pkg.KeyAudit) {
+	if len(audits) <= 0 {
+		return
+	}
+
+	if err := s.keyAuditRepository.MultipleInsert(ctx, audits); err != nil {
+		zap.L().Error("Add keys audit failed", zap.Error(err), zap.Int("count", len(audits)))
+	}
+}
+
+func newKeyAudit(key *billingpb.Key, action, userId, reason string) *pkg.KeyAudit {
+	return &pkg.KeyAudit{
+		KeyId:        key.Id,
+		KeyProductId: key.KeyProductId,
+		PlatformId:   key.PlatformId,
+		OrderId:      key.OrderId,
+		Action:       action,
+		UserId:       userId,
+		Reason:       reason,
+		CreatedAt:    ptypes.TimestampNow(),
+	}
+}
+
+func isKeyStateValid(state string) bool {
+	switch state {
+	case pkg.KeyStateAvailable, pkg.KeyStateReserved, pkg.KeyStateRedeemed, pkg.KeyStateRevoked, pkg.KeyStateExpired:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	errors2 "github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+func (suite *KeyTestSuite) TestKey_UploadKeysFileWithSummary_RejectedLines() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+
+	lines := []string{
+		"DDDD-EEEE-FFFF",
+		"",
+		strings.Repeat("A", keyCodeMaxLength+1),
+		" DDDD-EEEE-FFFF ",
+		"AAAA-BBBB-CCCC",
+		"GGGG-HHHH-IIII",
+	}
+	req := &billingpb.PlatformKeysFileRequest{
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		MerchantId:   keyProduct.MerchantId,
+		File:         []byte(strings.Join(lines, "\n")),
+	}
+	res := &pkg.UploadKeysFileResponse{}
+
+	err := suite.service.UploadKeysFileWithSummary(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), int32(6), res.Item.LinesCount)
+	assert.Equal(suite.T(), int32(2), res.Item.KeysProcessed)
+	assert.Equal(suite.T(), int32(3), res.Item.TotalCount)
+	assert.Equal(suite.T(), []*pkg.KeysUploadRejectedLine{
+		{Line: 2, Reason: pkg.KeyUploadRejectReasonEmpty},
+		{Line: 3, Reason: pkg.KeyUploadRejectReasonTooLong},
+		{Line: 4, Reason: pkg.KeyUploadRejectReasonDuplicateInFile},
+		{Line: 5, Reason: pkg.KeyUploadRejectReasonDuplicateInStock},
+	}, res.Item.Rejected)
+
+	audits, err := suite.service.keyAuditRepository.Find(context.TODO(), keyProduct.Id, "", 0, 10)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), audits, 3)
+
+	for _, v := range audits {
+		assert.Equal(suite.T(), pkg.KeyAuditActionUploaded, v.Action)
+	}
+}
+
+func (suite *KeyTestSuite) TestKey_UploadKeysFileWithSummary_Error_MerchantMismatch() {
+	keyProduct := suite.helperCreateKeyProduct()
+	req := &billingpb.PlatformKeysFileRequest{
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		MerchantId:   primitive.NewObjectID().Hex(),
+		File:         []byte("AAAA-BBBB-CCCC"),
+	}
+	res := &pkg.UploadKeysFileResponse{}
+
+	err := suite.service.UploadKeysFileWithSummary(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), keyProductMerchantMismatch, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_RevokeKeys_Ok() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF", "GGGG-HHHH-IIII")
+
+	reserved, err := suite.service.keyRepository.ReserveKey(context.TODO(), keyProduct.Id, "steam", primitive.NewObjectID().Hex(), 10)
+	assert.NoError(suite.T(), err)
+
+	available, err := suite.service.keyRepository.Find(context.TODO(), keyProduct.Id, "steam", pkg.KeyStateAvailable, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), available, 2)
+
+	req := &pkg.ChangeKeysStateRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		KeyIds:       []string{available[0].Id, reserved.Id},
+		UserId:       primitive.NewObjectID().Hex(),
+		Reason:       "leaked",
+	}
+	res := &pkg.ChangeKeysStateResponse{}
+	err = suite.service.RevokeKeys(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), int32(1), res.Count)
+	assert.Equal(suite.T(), []string{reserved.Id}, res.Skipped)
+
+	count, err := suite.service.keyRepository.CountKeysByProductPlatform(context.TODO(), keyProduct.Id, "steam")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), count)
+
+	audits, err := suite.service.keyAuditRepository.Find(context.TODO(), keyProduct.Id, available[0].Id, 0, 10)
+	assert.NoError(suite.T(), err)
+
+	var revoked *pkg.KeyAudit
+
+	for _, v := range audits {
+		if v.Action == pkg.KeyAuditActionRevoked {
+			revoked = v
+		}
+	}
+
+	assert.NotNil(suite.T(), revoked)
+	assert.Equal(suite.T(), req.UserId, revoked.UserId)
+	assert.Equal(suite.T(), req.Reason, revoked.Reason)
+
+	// revoked key is never reserved
+	_, err = suite.service.keyRepository.ReserveKey(context.TODO(), keyProduct.Id, "steam", primitive.NewObjectID().Hex(), 10)
+	assert.NoError(suite.T(), err)
+	_, err = suite.service.keyRepository.ReserveKey(context.TODO(), keyProduct.Id, "steam", primitive.NewObjectID().Hex(), 10)
+	assert.Error(suite.T(), err)
+
+	getRes := &pkg.GetKeysResponse{}
+	err = suite.service.GetKeys(context.TODO(), &pkg.GetKeysRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		State:        pkg.KeyStateRevoked,
+	}, getRes)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, getRes.Status)
+	assert.Equal(suite.T(), int64(1), getRes.Count)
+	assert.Equal(suite.T(), available[0].Id, getRes.Items[0].Id)
+	assert.Empty(suite.T(), getRes.Items[0].Code)
+}
+
+func (suite *KeyTestSuite) TestKey_ExpireKeys_AllPlatformKeys() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF")
+
+	req := &pkg.ChangeKeysStateRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+	}
+	res := &pkg.ChangeKeysStateResponse{}
+	err := suite.service.ExpireKeys(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), int32(2), res.Count)
+
+	count, err := suite.service.keyRepository.FindCount(context.TODO(), keyProduct.Id, "steam", pkg.KeyStateExpired)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), count)
+}
+
+func (suite *KeyTestSuite) TestKey_ExpireKeys_Error_NotSpecified() {
+	keyProduct := suite.helperCreateKeyProduct()
+	req := &pkg.ChangeKeysStateRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+	}
+	res := &pkg.ChangeKeysStateResponse{}
+	err := suite.service.ExpireKeys(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorNotSpecified, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_GetKeys_Error_StateInvalid() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.GetKeysResponse{}
+	err := suite.service.GetKeys(context.TODO(), &pkg.GetKeysRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		State:        "unknown",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorStateInvalid, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_KeyStockThreshold_Notification() {
+	centrifugoMock := &mocks.CentrifugoInterface{}
+	centrifugoMock.On("Publish", mock2.Anything, mock2.Anything, mock2.Anything).Return(nil)
+	suite.service.centrifugoDashboard = centrifugoMock
+
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF", "GGGG-HHHH-IIII")
+
+	res := &pkg.KeyStockThresholdResponse{}
+	err := suite.service.SetKeyStockThreshold(context.TODO(), &pkg.SetKeyStockThresholdRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		Threshold:    1,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.False(suite.T(), res.Item.IsNotified)
+
+	reserve := func() string {
+		rsp := &billingpb.PlatformKeyReserveResponse{}
+		err := suite.service.ReserveKeyForOrder(context.TODO(), &billingpb.PlatformKeyReserveRequest{
+			KeyProductId: keyProduct.Id,
+			PlatformId:   "steam",
+			OrderId:      primitive.NewObjectID().Hex(),
+			Ttl:          10,
+		}, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+		return rsp.KeyId
+	}
+
+	reserve()
+	notifications, err := suite.service.notificationRepository.FindCount(context.TODO(), keyProduct.MerchantId, "", 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), notifications)
+
+	keyId := reserve()
+	reserve()
+	notifications, err = suite.service.notificationRepository.FindCount(context.TODO(), keyProduct.MerchantId, "", 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), notifications)
+
+	threshold, err := suite.service.keyStockThresholdRepository.GetByKeyProductIdPlatformId(context.TODO(), keyProduct.Id, "steam")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), threshold.IsNotified)
+	assert.NotNil(suite.T(), threshold.NotifiedAt)
+
+	// released keys replenish stock above the threshold and notification is enabled again
+	cancelRes := &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.CancelRedeemKeyForOrder(context.TODO(), &billingpb.KeyForOrderRequest{KeyId: keyId}, cancelRes)
+	assert.NoError(suite.T(), err)
+
+	threshold, err = suite.service.keyStockThresholdRepository.GetByKeyProductIdPlatformId(context.TODO(), keyProduct.Id, "steam")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), threshold.IsNotified)
+
+	suite.helperUploadKeys(keyProduct, "JJJJ-KKKK-LLLL")
+	threshold, err = suite.service.keyStockThresholdRepository.GetByKeyProductIdPlatformId(context.TODO(), keyProduct.Id, "steam")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), threshold.IsNotified)
+
+	thresholdsRes := &pkg.GetKeyStockThresholdsResponse{}
+	err = suite.service.GetKeyStockThresholds(context.TODO(), &pkg.GetKeyStockThresholdsRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+	}, thresholdsRes)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, thresholdsRes.Status)
+	assert.Len(suite.T(), thresholdsRes.Items, 1)
+}
+
+func (suite *KeyTestSuite) TestKey_SetKeyStockThreshold_Error_Threshold() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.KeyStockThresholdResponse{}
+	err := suite.service.SetKeyStockThreshold(context.TODO(), &pkg.SetKeyStockThresholdRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		Threshold:    -1,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorThreshold, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_GetKeyAudit_Ok() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+
+	reserveRes := &billingpb.PlatformKeyReserveResponse{}
+	err := suite.service.ReserveKeyForOrder(context.TODO(), &billingpb.PlatformKeyReserveRequest{
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		OrderId:      primitive.NewObjectID().Hex(),
+		Ttl:          10,
+	}, reserveRes)
+	assert.NoError(suite.T(), err)
+
+	finishRes := &billingpb.GetKeyForOrderRequestResponse{}
+	err = suite.service.FinishRedeemKeyForOrder(context.TODO(), &billingpb.KeyForOrderRequest{KeyId: reserveRes.KeyId}, finishRes)
+	assert.NoError(suite.T(), err)
+
+	res := &pkg.GetKeyAuditResponse{}
+	err = suite.service.GetKeyAudit(context.TODO(), &pkg.GetKeyAuditRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		KeyId:        reserveRes.KeyId,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.Items, 3)
+
+	actions := make([]string, len(res.Items))
+
+	for i, v := range res.Items {
+		actions[i] = v.Action
+	}
+
+	assert.ElementsMatch(suite.T(), []string{pkg.KeyAuditActionUploaded, pkg.KeyAuditActionReserved, pkg.KeyAuditActionRedeemed}, actions)
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var keysGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "paysuper",
+		Subsystem: "billing",
+		Name:      "keys",
+		Help:      "Count of keys by key product, platform and state.",
+	},
+	[]string{"key_product_id", "platform_id", "state"},
+)
+
+func init() {
+	prometheus.MustRegister(keysGauge)
+}
+
+// UpdateKeyMetrics refreshes counts of available, reserved, redeemed, revoked and expired keys
+// of every key product on every platform.
+func (s *Service) UpdateKeyMetrics(ctx context.Context) error {
+	counts, err := s.keyRepository.CountByState(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// series of deleted key products and platforms without keys disappear from metrics
+	keysGauge.Reset()
+
+	for _, v := range counts {
+		keysGauge.WithLabelValues(v.KeyProductId, v.PlatformId, v.State).Set(float64(v.Count))
+	}
+
+	return nil
+}
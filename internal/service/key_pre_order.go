@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	keyPreOrdersBatchSize = 100
+)
+
+func (s *Service) SetKeyProductRelease(
+	ctx context.Context,
+	req *pkg.SetKeyProductReleaseRequest,
+	res *pkg.KeyProductReleaseResponse,
+) error {
+	keyProduct, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if req.ReleaseAt == nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = keyProductReleaseDateEmpty
+		return nil
+	}
+
+	for region := range req.Regions {
+		if _, err := s.priceGroupRepository.GetByRegion(ctx, region); err != nil {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = keyProductReleaseRegionNotFound
+			return nil
+		}
+	}
+
+	release, err := s.keyProductReleaseRepository.GetByKeyProductId(ctx, keyProduct.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = keyProductInternalError
+			return nil
+		}
+
+		release = &pkg.KeyProductRelease{
+			MerchantId:   keyProduct.MerchantId,
+			KeyProductId: keyProduct.Id,
+			CreatedAt:    ptypes.TimestampNow(),
+		}
+	}
+
+	release.ReleaseAt = req.ReleaseAt
+	release.Regions = req.Regions
+	release.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.keyProductReleaseRepository.Upsert(ctx, release); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = keyProductInternalError
+		return nil
+	}
+
+	if err = s.rescheduleKeyPreOrders(ctx, release); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = keyProductInternalError
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = release
+
+	return nil
+}
+
+func (s *Service) GetKeyProductRelease(
+	ctx context.Context,
+	req *pkg.GetKeyProductReleaseRequest,
+	res *pkg.KeyProductReleaseResponse,
+) error {
+	keyProduct, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	release, err := s.keyProductReleaseRepository.GetByKeyProductId(ctx, keyProduct.Id)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = keyProductReleaseNotFound
+			return nil
+		}
+
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = keyProductInternalError
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = release
+
+	return nil
+}
+
+func (s *Service) GetKeyPreOrders(
+	ctx context.Context,
+	req *pkg.GetKeyPreOrdersRequest,
+	res *pkg.GetKeyPreOrdersResponse,
+) error {
+	_, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	switch req.Status {
+	case "", pkg.KeyPreOrderStatusPending, pkg.KeyPreOrderStatusDelivered, pkg.KeyPreOrderStatusRefunded:
+	default:
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = keyProductPreOrderStatusInvalid
+		return nil
+	}
+
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	preOrders, err := s.keyPreOrderRepository.Find(ctx, req.KeyProductId, req.Status, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Items = preOrders
+
+	return nil
+}
+
+// DeliverKeyPreOrders delivers keys of pre-orders released in regions of customers by email and notifies merchants
+// about delivery by webhook with decrypted codes of delivered keys in items of the order. Keys of orders refunded
+// before the release are returned to the stock.
+func (s *Service) DeliverKeyPreOrders(ctx context.Context) (int, error) {
+	counter := 0
+	orders := make(map[string]*billingpb.Order)
+	delivered := make(map[string]*billingpb.Order)
+
+	for {
+		preOrders, err := s.keyPreOrderRepository.FindPendingReleased(ctx, time.Now(), keyPreOrdersBatchSize)
+
+		if err != nil {
+			return counter, err
+		}
+
+		processed := 0
+
+		for _, preOrder := range preOrders {
+			order, ok := orders[preOrder.OrderId]
+
+			if !ok {
+				order, err = s.getOrderById(ctx, preOrder.OrderId)
+
+				if err != nil {
+					zap.L().Error("Order of key pre-order not found", zap.Error(err), zap.String("order_id", preOrder.OrderId))
+					continue
+				}
+
+				orders[preOrder.OrderId] = order
+			}
+
+			if err = s.deliverKeyPreOrder(ctx, order, preOrder); err != nil {
+				zap.L().Error("Key pre-order delivery failed", zap.Error(err), zap.String("pre_order_id", preOrder.Id))
+				continue
+			}
+
+			// items of the order keep plaintext codes of delivered keys until the order is saved
+			if order.GetPublicStatus() == recurringpb.OrderPublicStatusProcessed {
+				delivered[order.Id] = order
+			}
+
+			processed++
+		}
+
+		counter += processed
+
+		// pre-orders failed to deliver stay pending and are retried by the next run
+		if len(preOrders) < keyPreOrdersBatchSize || processed == 0 {
+			break
+		}
+	}
+
+	for _, order := range delivered {
+		s.orderNotifyMerchant(ctx, order)
+	}
+
+	return counter, nil
+}
+
+// createKeyPreOrders allocates keys of the paid order which key products aren't released yet in the region
+// of the customer until the release and returns keys to deliver immediately.
+func (s *Service) createKeyPreOrders(ctx context.Context, order *billingpb.Order) []string {
+	var keys []string
+	region := s.getOrderPriceRegion(ctx, order)
+
+	for _, keyId := range order.Keys {
+		key, err := s.keyRepository.GetById(ctx, keyId)
+
+		if err != nil {
+			keys = append(keys, keyId)
+			continue
+		}
+
+		release, err := s.keyProductReleaseRepository.GetByKeyProductId(ctx, key.KeyProductId)
+
+		if err != nil {
+			keys = append(keys, keyId)
+			continue
+		}
+
+		releaseAt, err := getKeyProductReleaseAt(release, region)
+
+		if err != nil || !releaseAt.After(time.Now()) {
+			keys = append(keys, keyId)
+			continue
+		}
+
+		preOrder := &pkg.KeyPreOrder{
+			OrderId:      order.Id,
+			MerchantId:   release.MerchantId,
+			KeyProductId: key.KeyProductId,
+			KeyId:        key.Id,
+			PlatformId:   key.PlatformId,
+			Region:       region,
+			Status:       pkg.KeyPreOrderStatusPending,
+			CreatedAt:    ptypes.TimestampNow(),
+			UpdatedAt:    ptypes.TimestampNow(),
+		}
+		preOrder.ReleaseAt, _ = ptypes.TimestampProto(releaseAt)
+
+		// the key is delivered immediately if the pre-order can't be saved, otherwise the paid key is lost
+		if err = s.keyPreOrderRepository.Insert(ctx, preOrder); err != nil {
+			keys = append(keys, keyId)
+			continue
+		}
+
+		if _, err = s.keyRepository.AllocateById(ctx, keyId); err != nil {
+			zap.L().Error("Allocation of key for pre-order failed", zap.Error(err), zap.String("key_id", keyId))
+		}
+
+		s.dequeueKeyReservation(keyId)
+	}
+
+	return keys
+}
+
+func (s *Service) deliverKeyPreOrder(ctx context.Context, order *billingpb.Order, preOrder *pkg.KeyPreOrder) error {
+	// the order was refunded or charged back before the release
+	if order.GetPublicStatus() != recurringpb.OrderPublicStatusProcessed {
+		return s.refundKeyPreOrder(ctx, preOrder)
+	}
+
+	rsp := &billingpb.GetKeyForOrderRequestResponse{}
+	err := s.FinishRedeemKeyForOrder(ctx, &billingpb.KeyForOrderRequest{KeyId: preOrder.KeyId}, rsp)
+
+	if err != nil {
+		return err
+	}
+
+	if rsp.Status != billingpb.ResponseStatusOk {
+		return rsp.Message
+	}
+
+	s.sendMailWithCode(ctx, order, rsp.Key)
+
+	preOrder.Status = pkg.KeyPreOrderStatusDelivered
+	preOrder.DeliveredAt = ptypes.TimestampNow()
+	preOrder.UpdatedAt = ptypes.TimestampNow()
+
+	return s.keyPreOrderRepository.Update(ctx, preOrder)
+}
+
+// refundKeyPreOrders returns keys of pending pre-orders of the refunded order to the stock.
+func (s *Service) refundKeyPreOrders(ctx context.Context, order *billingpb.Order) error {
+	preOrders, err := s.keyPreOrderRepository.FindByOrderId(ctx, order.Id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, preOrder := range preOrders {
+		if preOrder.Status != pkg.KeyPreOrderStatusPending {
+			continue
+		}
+
+		if err = s.refundKeyPreOrder(ctx, preOrder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) refundKeyPreOrder(ctx context.Context, preOrder *pkg.KeyPreOrder) error {
+	rsp := &billingpb.EmptyResponseWithStatus{}
+	err := s.CancelRedeemKeyForOrder(ctx, &billingpb.KeyForOrderRequest{KeyId: preOrder.KeyId}, rsp)
+
+	if err != nil {
+		return err
+	}
+
+	if rsp.Status != billingpb.ResponseStatusOk {
+		return rsp.Message
+	}
+
+	preOrder.Status = pkg.KeyPreOrderStatusRefunded
+	preOrder.UpdatedAt = ptypes.TimestampNow()
+
+	return s.keyPreOrderRepository.Update(ctx, preOrder)
+}
+
+// isKeyPreOrderRefundAllowed checks that keys of the order weren't pre-ordered or aren't released yet.
+func (s *Service) isKeyPreOrderRefundAllowed(ctx context.Context, order *billingpb.Order) (bool, error) {
+	if !orderHasKeyProducts(order) {
+		return true, nil
+	}
+
+	preOrders, err := s.keyPreOrderRepository.FindByOrderId(ctx, order.Id)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, preOrder := range preOrders {
+		releaseAt, err := ptypes.Timestamp(preOrder.ReleaseAt)
+
+		if err != nil {
+			return false, err
+		}
+
+		if preOrder.Status == pkg.KeyPreOrderStatusDelivered || !releaseAt.After(time.Now()) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// rescheduleKeyPreOrders moves delivery of pending pre-orders of the key product to the new release dates.
+func (s *Service) rescheduleKeyPreOrders(ctx context.Context, release *pkg.KeyProductRelease) error {
+	preOrders, err := s.keyPreOrderRepository.FindPendingByKeyProductId(ctx, release.KeyProductId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, preOrder := range preOrders {
+		releaseAt, err := getKeyProductReleaseAt(release, preOrder.Region)
+
+		if err != nil {
+			return err
+		}
+
+		preOrder.ReleaseAt, err = ptypes.TimestampProto(releaseAt)
+
+		if err != nil {
+			return err
+		}
+
+		preOrder.UpdatedAt = ptypes.TimestampNow()
+
+		if err = s.keyPreOrderRepository.Update(ctx, preOrder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getOrderPriceRegion returns the price region of the customer country or an empty string if it's unknown.
+func (s *Service) getOrderPriceRegion(ctx context.Context, order *billingpb.Order) string {
+	country, err := s.country.GetByIsoCodeA2(ctx, order.GetCountry())
+
+	if err != nil {
+		return ""
+	}
+
+	group, err := s.priceGroupRepository.GetById(ctx, country.PriceGroupId)
+
+	if err != nil {
+		return ""
+	}
+
+	return group.Region
+}
+
+// getKeyProductReleaseAt returns the release date of the key product in the price region.
+func getKeyProductReleaseAt(release *pkg.KeyProductRelease, region string) (time.Time, error) {
+	releaseAt := release.ReleaseAt
+
+	if v, ok := release.Regions[region]; ok {
+		releaseAt = v
+	}
+
+	return ptypes.Timestamp(releaseAt)
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (suite *KeyTestSuite) helperCreatePriceRegions() {
+	groupEur := &billingpb.PriceGroup{Id: primitive.NewObjectID().Hex(), Region: "EUR", Currency: "EUR", IsActive: true}
+	groupUsd := &billingpb.PriceGroup{Id: primitive.NewObjectID().Hex(), Region: "USD", Currency: "USD", IsActive: true}
+	err := suite.service.priceGroupRepository.MultipleInsert(context.TODO(), []*billingpb.PriceGroup{groupEur, groupUsd})
+	assert.NoError(suite.T(), err)
+
+	countries := []*billingpb.Country{
+		{
+			Id:                primitive.NewObjectID().Hex(),
+			IsoCodeA2:         "DE",
+			Region:            "EU",
+			Currency:          "EUR",
+			PaymentsAllowed:   true,
+			ChangeAllowed:     true,
+			VatEnabled:        true,
+			PriceGroupId:      groupEur.Id,
+			VatCurrency:       "EUR",
+			PayerTariffRegion: billingpb.TariffRegionEurope,
+		},
+		{
+			Id:                primitive.NewObjectID().Hex(),
+			IsoCodeA2:         "US",
+			Region:            "North America",
+			Currency:          "USD",
+			PaymentsAllowed:   true,
+			ChangeAllowed:     true,
+			PriceGroupId:      groupUsd.Id,
+			PayerTariffRegion: billingpb.TariffRegionWorldwide,
+		},
+	}
+	assert.NoError(suite.T(), suite.service.country.MultipleInsert(context.TODO(), countries))
+}
+
+func (suite *KeyTestSuite) helperSetKeyProductRelease(
+	keyProduct *billingpb.KeyProduct,
+	releaseAt time.Time,
+	regions map[string]time.Time,
+) {
+	req := &pkg.SetKeyProductReleaseRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		Regions:      make(map[string]*timestamp.Timestamp),
+	}
+	req.ReleaseAt, _ = ptypes.TimestampProto(releaseAt)
+
+	for region, t := range regions {
+		req.Regions[region], _ = ptypes.TimestampProto(t)
+	}
+
+	res := &pkg.KeyProductReleaseResponse{}
+	err := suite.service.SetKeyProductRelease(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+}
+
+func (suite *KeyTestSuite) helperCreateKeyOrder(keyProduct *billingpb.KeyProduct, key *billingpb.Key, country string) *billingpb.Order {
+	order := &billingpb.Order{
+		Id:   key.OrderId,
+		Uuid: primitive.NewObjectID().Hex(),
+		Project: &billingpb.ProjectOrder{
+			Id:         keyProduct.ProjectId,
+			MerchantId: keyProduct.MerchantId,
+		},
+		Items: []*billingpb.OrderItem{
+			{
+				Id:        keyProduct.Id,
+				Name:      "Double yeti",
+				CreatedAt: ptypes.TimestampNow(),
+				UpdatedAt: ptypes.TimestampNow(),
+			},
+		},
+		User: &billingpb.OrderUser{
+			Address: &billingpb.OrderBillingAddress{Country: country},
+		},
+		ProductType:   pkg.OrderType_key,
+		Keys:          []string{key.Id},
+		PlatformId:    "steam",
+		ReceiptEmail:  "test@unit.test",
+		Status:        recurringpb.OrderPublicStatusProcessed,
+		PrivateStatus: recurringpb.OrderStatusProjectComplete,
+		CreatedAt:     ptypes.TimestampNow(),
+		UpdatedAt:     ptypes.TimestampNow(),
+	}
+	assert.NoError(suite.T(), suite.service.orderRepository.Insert(context.TODO(), order))
+
+	return order
+}
+
+func (suite *KeyTestSuite) TestKey_SetKeyProductRelease_Ok() {
+	suite.helperCreatePriceRegions()
+	keyProduct := suite.helperCreateKeyProduct()
+	releaseAt := time.Now().Add(48 * time.Hour)
+	suite.helperSetKeyProductRelease(keyProduct, releaseAt, map[string]time.Time{"EUR": releaseAt.Add(time.Hour)})
+
+	res := &pkg.KeyProductReleaseResponse{}
+	err := suite.service.GetKeyProductRelease(context.TODO(), &pkg.GetKeyProductReleaseRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), releaseAt.Unix(), res.Item.ReleaseAt.Seconds)
+	assert.Equal(suite.T(), releaseAt.Add(time.Hour).Unix(), res.Item.Regions["EUR"].Seconds)
+}
+
+func (suite *KeyTestSuite) TestKey_SetKeyProductRelease_Error_RegionNotFound() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.KeyProductReleaseResponse{}
+	err := suite.service.SetKeyProductRelease(context.TODO(), &pkg.SetKeyProductReleaseRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		ReleaseAt:    ptypes.TimestampNow(),
+		Regions:      map[string]*timestamp.Timestamp{"unknown": ptypes.TimestampNow()},
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), keyProductReleaseRegionNotFound, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_SetKeyProductRelease_Error_DateEmpty() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.KeyProductReleaseResponse{}
+	err := suite.service.SetKeyProductRelease(context.TODO(), &pkg.SetKeyProductReleaseRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), keyProductReleaseDateEmpty, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_GetKeyProductRelease_Error_NotFound() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.KeyProductReleaseResponse{}
+	err := suite.service.GetKeyProductRelease(context.TODO(), &pkg.GetKeyProductReleaseRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), keyProductReleaseNotFound, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_GetKeyPreOrders_Error_Status() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.GetKeyPreOrdersResponse{}
+	err := suite.service.GetKeyPreOrders(context.TODO(), &pkg.GetKeyPreOrdersRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		Status:       "unknown",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), keyProductPreOrderStatusInvalid, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_CreateKeyPreOrders_Ok_NotReleased() {
+	suite.helperCreatePriceRegions()
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(-time.Hour), map[string]time.Time{
+		"EUR": time.Now().Add(48 * time.Hour),
+	})
+	key := suite.helperReserveKey(keyProduct, 10)
+	order := suite.helperCreateKeyOrder(keyProduct, key, "DE")
+
+	keys := suite.service.createKeyPreOrders(context.TODO(), order)
+	assert.Empty(suite.T(), keys)
+
+	key, err := suite.service.keyRepository.GetById(context.TODO(), key.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), order.Id, key.OrderId)
+	reservedTo, err := ptypes.Timestamp(key.ReservedTo)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), reservedTo.IsZero())
+
+	_, err = suite.service.redis.ZScore(keyReservationQueue, key.Id).Result()
+	assert.Equal(suite.T(), redis.Nil, err)
+
+	res := &pkg.GetKeyPreOrdersResponse{}
+	err = suite.service.GetKeyPreOrders(context.TODO(), &pkg.GetKeyPreOrdersRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+		Status:       pkg.KeyPreOrderStatusPending,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.Items, 1)
+	assert.Equal(suite.T(), "EUR", res.Items[0].Region)
+	assert.Equal(suite.T(), key.Id, res.Items[0].KeyId)
+}
+
+func (suite *KeyTestSuite) TestKey_CreateKeyPreOrders_Ok_Released() {
+	suite.helperCreatePriceRegions()
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(-time.Hour), map[string]time.Time{
+		"EUR": time.Now().Add(48 * time.Hour),
+	})
+	key := suite.helperReserveKey(keyProduct, 10)
+	order := suite.helperCreateKeyOrder(keyProduct, key, "US")
+
+	keys := suite.service.createKeyPreOrders(context.TODO(), order)
+	assert.Equal(suite.T(), []string{key.Id}, keys)
+
+	preOrders, err := suite.service.keyPreOrderRepository.FindByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), preOrders)
+}
+
+func (suite *KeyTestSuite) TestKey_DeliverKeyPreOrders_Ok() {
+	suite.helperCreatePriceRegions()
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(48*time.Hour), nil)
+	key := suite.helperReserveKey(keyProduct, 10)
+	order := suite.helperCreateKeyOrder(keyProduct, key, "DE")
+	assert.Empty(suite.T(), suite.service.createKeyPreOrders(context.TODO(), order))
+
+	suite.service.broker = mocks.NewBrokerMockOk()
+
+	count, err := suite.service.DeliverKeyPreOrders(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), count)
+
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(-time.Minute), nil)
+
+	count, err = suite.service.DeliverKeyPreOrders(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	key, err = suite.service.keyRepository.GetById(context.TODO(), key.Id)
+	assert.NoError(suite.T(), err)
+	assert.NotZero(suite.T(), key.RedeemedAt.Seconds)
+
+	preOrders, err := suite.service.keyPreOrderRepository.FindByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), preOrders, 1)
+	assert.Equal(suite.T(), pkg.KeyPreOrderStatusDelivered, preOrders[0].Status)
+	assert.NotNil(suite.T(), preOrders[0].DeliveredAt)
+}
+
+func (suite *KeyTestSuite) TestKey_DeliverKeyPreOrders_WebhookContainsCode() {
+	suite.helperCreatePriceRegions()
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(48*time.Hour), nil)
+	key := suite.helperReserveKey(keyProduct, 10)
+	order := suite.helperCreateKeyOrder(keyProduct, key, "DE")
+	assert.Empty(suite.T(), suite.service.createKeyPreOrders(context.TODO(), order))
+
+	broker := &mocks.BrokerInterface{}
+	broker.On("Publish", recurringpb.PayOneTopicNotifyPaymentName, mock.Anything, mock.Anything).Return(nil)
+	suite.service.broker = broker
+
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(-time.Minute), nil)
+
+	count, err := suite.service.DeliverKeyPreOrders(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	broker.AssertNumberOfCalls(suite.T(), "Publish", 1)
+	notification := broker.Calls[0].Arguments.Get(1).(*billingpb.Order)
+	assert.Equal(suite.T(), order.Id, notification.Id)
+	assert.Len(suite.T(), notification.Items, 1)
+	assert.Equal(suite.T(), "AAAA-BBBB-CCCC", notification.Items[0].Code)
+
+	order, err = suite.service.orderRepository.GetById(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), "AAAA-BBBB-CCCC", order.Items[0].Code)
+}
+
+func (suite *KeyTestSuite) TestKey_IsKeyPreOrderRefundAllowed_Ok() {
+	suite.helperCreatePriceRegions()
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(48*time.Hour), nil)
+	key := suite.helperReserveKey(keyProduct, 10)
+	order := suite.helperCreateKeyOrder(keyProduct, key, "DE")
+	assert.Empty(suite.T(), suite.service.createKeyPreOrders(context.TODO(), order))
+
+	allowed, err := suite.service.isKeyPreOrderRefundAllowed(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), allowed)
+
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(-time.Minute), nil)
+
+	allowed, err = suite.service.isKeyPreOrderRefundAllowed(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), allowed)
+}
+
+func (suite *KeyTestSuite) TestKey_RefundKeyPreOrders_Ok() {
+	suite.helperCreatePriceRegions()
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	suite.helperSetKeyProductRelease(keyProduct, time.Now().Add(48*time.Hour), nil)
+	key := suite.helperReserveKey(keyProduct, 10)
+	order := suite.helperCreateKeyOrder(keyProduct, key, "DE")
+	assert.Empty(suite.T(), suite.service.createKeyPreOrders(context.TODO(), order))
+
+	assert.NoError(suite.T(), suite.service.refundKeyPreOrders(context.TODO(), order))
+
+	key, err := suite.service.keyRepository.GetById(context.TODO(), key.Id)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), key.OrderId)
+
+	preOrders, err := suite.service.keyPreOrderRepository.FindByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), preOrders, 1)
+	assert.Equal(suite.T(), pkg.KeyPreOrderStatusRefunded, preOrders[0].Status)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	"github.com/paysuper/paysuper-proto/go/recurringpb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -40,6 +41,10 @@ var (
 	keyProductPlatformDontHaveDefaultPrice  = newBillingServerErrorMsg("kp000020", "platform don't have price in default currency")
 	keyProductPlatformPriceMismatchCurrency = newBillingServerErrorMsg("kp000021", "platform don't have price with region that mismatch with currency")
 	keyProductNotPublished                  = newBillingServerErrorMsg("kp000023", "key product is not published")
+	keyProductReleaseDateEmpty              = newBillingServerErrorMsg("kp000024", "release date must be set")
+	keyProductReleaseRegionNotFound         = newBillingServerErrorMsg("kp000025", "price region of release date not found")
+	keyProductReleaseNotFound               = newBillingServerErrorMsg("kp000026", "release date of key product not found")
+	keyProductPreOrderStatusInvalid         = newBillingServerErrorMsg("kp000027", "status of pre-order is invalid")
 )
 
 var availablePlatforms = map[string]*billingpb.Platform{
@@ -324,6 +329,14 @@ func (s *Service) CreateOrUpdateKeyProduct(
 		return nil
 	}
 
+	s.savePriceHistory(ctx, &pkg.PriceHistory{
+		MerchantId:  product.MerchantId,
+		ProductId:   product.Id,
+		ProductType: pkg.OrderItemTypeKeyProduct,
+		Platforms:   product.Platforms,
+		CreatedAt:   product.UpdatedAt,
+	})
+
 	res.Product = product
 	return nil
 }
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	// keyReservationQueue is a sorted set of identifiers of reserved keys scored by the end time of reservation.
+	keyReservationQueue          = "key:reservation:queue"
+	keyReservationQueueBatchSize = 1000
+)
+
+func (s *Service) SetKeyReservationPolicy(
+	ctx context.Context,
+	req *pkg.SetKeyReservationPolicyRequest,
+	res *pkg.KeyReservationPolicyResponse,
+) error {
+	keyProduct, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if req.Ttl < 0 || req.FailedOrderTtl < 0 {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errors.KeyErrorReservationTtl
+		return nil
+	}
+
+	policy, err := s.keyReservationPolicyRepository.GetByKeyProductId(ctx, keyProduct.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errors.KeyErrorUnknown
+			return nil
+		}
+
+		policy = &pkg.KeyReservationPolicy{
+			MerchantId:   keyProduct.MerchantId,
+			KeyProductId: keyProduct.Id,
+			CreatedAt:    ptypes.TimestampNow(),
+		}
+	}
+
+	// keys reserved before are released by the time of the previous policy
+	policy.Ttl = req.Ttl
+	policy.FailedOrderTtl = req.FailedOrderTtl
+	policy.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.keyReservationPolicyRepository.Upsert(ctx, policy); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errors.KeyErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = policy
+
+	return nil
+}
+
+func (s *Service) GetKeyReservationPolicy(
+	ctx context.Context,
+	req *pkg.GetKeyReservationPolicyRequest,
+	res *pkg.KeyReservationPolicyResponse,
+) error {
+	keyProduct, msg := s.getMerchantKeyProduct(ctx, req.MerchantId, req.KeyProductId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	policy, err := s.keyReservationPolicyRepository.GetByKeyProductId(ctx, keyProduct.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errors.KeyErrorUnknown
+			return nil
+		}
+
+		// zero times mean the default reservation
+		policy = &pkg.KeyReservationPolicy{
+			MerchantId:   keyProduct.MerchantId,
+			KeyProductId: keyProduct.Id,
+		}
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = policy
+
+	return nil
+}
+
+// ReleaseExpiredKeys releases all keys with the expired reservation found by full scan of keys.
+// The reservation queue releases keys in time, the full scan is a recovery for keys missed by the queue.
+func (s *Service) ReleaseExpiredKeys(ctx context.Context) (int, error) {
+	counter := 0
+	keys, err := s.keyRepository.FindUnfinished(ctx)
+
+	if err != nil {
+		return counter, err
+	}
+
+	var audits []*pkg.KeyAudit
+	stocks := make(map[string]*billingpb.Key)
+
+	for _, key := range keys {
+		_, err = s.keyRepository.CancelById(ctx, key.Id)
+
+		if err != nil {
+			continue
+		}
+
+		counter++
+		audits = append(audits, newKeyAudit(key, pkg.KeyAuditActionReleased, "", keyReleaseReasonReservationExpired))
+		stocks[key.KeyProductId+key.PlatformId] = key
+		s.dequeueKeyReservation(key.Id)
+	}
+
+	s.addKeyAudits(ctx, audits)
+
+	for _, key := range stocks {
+		s.checkKeyStockThreshold(ctx, key.KeyProductId, key.PlatformId)
+	}
+
+	return counter, nil
+}
+
+// getKeyReservationPolicy returns the reservation policy of the key product or nil for the default policy.
+func (s *Service) getKeyReservationPolicy(ctx context.Context, keyProductId string) *pkg.KeyReservationPolicy {
+	policy, err := s.keyReservationPolicyRepository.GetByKeyProductId(ctx, keyProductId)
+
+	if err != nil {
+		return nil
+	}
+
+	return policy
+}
+
+// getKeyReservationTtl returns the time of reservation set for the key product, the requested time is used
+// if the key product hasn't own time.
+func (s *Service) getKeyReservationTtl(ctx context.Context, keyProductId string, ttl int32) int32 {
+	policy := s.getKeyReservationPolicy(ctx, keyProductId)
+
+	if policy != nil && policy.Ttl > 0 {
+		return policy.Ttl
+	}
+
+	if ttl > 0 {
+		return ttl
+	}
+
+	return oneDayTtl
+}
+
+// shortenKeyReservation keeps the key of the failed order reserved for the time set for the key product
+// and releases the key immediately if the time isn't set.
+func (s *Service) shortenKeyReservation(ctx context.Context, keyId string) error {
+	key, err := s.keyRepository.GetById(ctx, keyId)
+
+	if err != nil {
+		return err
+	}
+
+	policy := s.getKeyReservationPolicy(ctx, key.KeyProductId)
+
+	if policy == nil || policy.FailedOrderTtl <= 0 {
+		rsp := &billingpb.EmptyResponseWithStatus{}
+		err = s.CancelRedeemKeyForOrder(ctx, &billingpb.KeyForOrderRequest{KeyId: keyId}, rsp)
+
+		if err == nil && rsp.Status != billingpb.ResponseStatusOk {
+			err = rsp.Message
+		}
+
+		return err
+	}
+
+	reservedTo := time.Now().Add(time.Duration(policy.FailedOrderTtl) * time.Second)
+	key, err = s.keyRepository.ShortenReservation(ctx, keyId, reservedTo)
+
+	if err != nil {
+		// the key is already reserved for a shorter time
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+
+		return err
+	}
+
+	s.enqueueKeyReservation(key)
+
+	return nil
+}
+
+// releaseRejectedOrderKeys releases keys reserved for the order when the payment is rejected before it's sent
+// to the payment system. Keys are reserved again on the next payment attempt of the order.
+func (s *Service) releaseRejectedOrderKeys(ctx context.Context, order *billingpb.Order) {
+	for _, keyId := range order.Keys {
+		if keyId == "" {
+			continue
+		}
+
+		rsp := &billingpb.EmptyResponseWithStatus{}
+		err := s.CancelRedeemKeyForOrder(ctx, &billingpb.KeyForOrderRequest{KeyId: keyId}, rsp)
+
+		if err == nil && rsp.Status != billingpb.ResponseStatusOk {
+			err = rsp.Message
+		}
+
+		// the key is released by the ReleaseExpiredKeys task at the end of reservation
+		if err != nil {
+			zap.L().Error(
+				"Failed to release key of rejected payment",
+				zap.Error(err),
+				zap.String("order_id", order.Id),
+				zap.String("key_id", keyId),
+			)
+		}
+	}
+
+	order.Keys = nil
+}
+
+// enqueueKeyReservation adds the reserved key to the queue for release at the end of reservation.
+// Keys missed by the queue are released by the ReleaseExpiredKeys task.
+func (s *Service) enqueueKeyReservation(key *billingpb.Key) {
+	reservedTo, err := ptypes.Timestamp(key.ReservedTo)
+
+	if err != nil {
+		zap.L().Error("Key reservation time is invalid", zap.Error(err), zap.String("key_id", key.Id))
+		return
+	}
+
+	member := redis.Z{Score: float64(reservedTo.Unix()), Member: key.Id}
+
+	if err = s.redis.ZAdd(keyReservationQueue, member).Err(); err != nil {
+		zap.L().Error("Adding key to reservation queue failed", zap.Error(err), zap.String("key_id", key.Id))
+	}
+}
+
+func (s *Service) dequeueKeyReservation(keyId string) {
+	if err := s.redis.ZRem(keyReservationQueue, keyId).Err(); err != nil {
+		zap.L().Error("Removing key from reservation queue failed", zap.Error(err), zap.String("key_id", keyId))
+	}
+}
+
+// getExpiredKeyReservations returns identifiers of keys with the expired reservation from the queue.
+func (s *Service) getExpiredKeyReservations() ([]string, error) {
+	opt := redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: keyReservationQueueBatchSize,
+	}
+
+	return s.redis.ZRangeByScore(keyReservationQueue, opt).Result()
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	errors2 "github.com/paysuper/paysuper-billing-server/pkg/errors"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (suite *KeyTestSuite) helperReserveKey(keyProduct *billingpb.KeyProduct, ttl int32) *billingpb.Key {
+	res := &billingpb.PlatformKeyReserveResponse{}
+	err := suite.service.ReserveKeyForOrder(context.TODO(), &billingpb.PlatformKeyReserveRequest{
+		KeyProductId: keyProduct.Id,
+		PlatformId:   "steam",
+		OrderId:      primitive.NewObjectID().Hex(),
+		Ttl:          ttl,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	key, err := suite.service.keyRepository.GetById(context.TODO(), res.KeyId)
+	assert.NoError(suite.T(), err)
+
+	return key
+}
+
+func (suite *KeyTestSuite) helperSetKeyReservationPolicy(keyProduct *billingpb.KeyProduct, ttl, failedOrderTtl int32) {
+	res := &pkg.KeyReservationPolicyResponse{}
+	err := suite.service.SetKeyReservationPolicy(context.TODO(), &pkg.SetKeyReservationPolicyRequest{
+		MerchantId:     keyProduct.MerchantId,
+		KeyProductId:   keyProduct.Id,
+		Ttl:            ttl,
+		FailedOrderTtl: failedOrderTtl,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+}
+
+func (suite *KeyTestSuite) helperGetKeyReservationScore(keyId string) float64 {
+	score, err := suite.service.redis.ZScore(keyReservationQueue, keyId).Result()
+	assert.NoError(suite.T(), err)
+
+	return score
+}
+
+func (suite *KeyTestSuite) TestKey_SetKeyReservationPolicy_Ok() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperSetKeyReservationPolicy(keyProduct, 3600, 600)
+
+	res := &pkg.KeyReservationPolicyResponse{}
+	req := &pkg.GetKeyReservationPolicyRequest{MerchantId: keyProduct.MerchantId, KeyProductId: keyProduct.Id}
+	err := suite.service.GetKeyReservationPolicy(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.NotEmpty(suite.T(), res.Item.Id)
+	assert.Equal(suite.T(), int32(3600), res.Item.Ttl)
+	assert.Equal(suite.T(), int32(600), res.Item.FailedOrderTtl)
+
+	// the policy is replaced for the same key product
+	suite.helperSetKeyReservationPolicy(keyProduct, 1800, 0)
+
+	res2 := &pkg.KeyReservationPolicyResponse{}
+	err = suite.service.GetKeyReservationPolicy(context.TODO(), req, res2)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), res.Item.Id, res2.Item.Id)
+	assert.Equal(suite.T(), int32(1800), res2.Item.Ttl)
+	assert.Equal(suite.T(), int32(0), res2.Item.FailedOrderTtl)
+}
+
+func (suite *KeyTestSuite) TestKey_SetKeyReservationPolicy_Error_Ttl() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.KeyReservationPolicyResponse{}
+	err := suite.service.SetKeyReservationPolicy(context.TODO(), &pkg.SetKeyReservationPolicyRequest{
+		MerchantId:     keyProduct.MerchantId,
+		KeyProductId:   keyProduct.Id,
+		Ttl:            60,
+		FailedOrderTtl: -1,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errors2.KeyErrorReservationTtl, res.Message)
+}
+
+func (suite *KeyTestSuite) TestKey_SetKeyReservationPolicy_Error_MerchantMismatch() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.KeyReservationPolicyResponse{}
+	err := suite.service.SetKeyReservationPolicy(context.TODO(), &pkg.SetKeyReservationPolicyRequest{
+		MerchantId:   primitive.NewObjectID().Hex(),
+		KeyProductId: keyProduct.Id,
+		Ttl:          60,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+}
+
+func (suite *KeyTestSuite) TestKey_GetKeyReservationPolicy_Ok_Default() {
+	keyProduct := suite.helperCreateKeyProduct()
+	res := &pkg.KeyReservationPolicyResponse{}
+	err := suite.service.GetKeyReservationPolicy(context.TODO(), &pkg.GetKeyReservationPolicyRequest{
+		MerchantId:   keyProduct.MerchantId,
+		KeyProductId: keyProduct.Id,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Empty(suite.T(), res.Item.Id)
+	assert.Equal(suite.T(), keyProduct.Id, res.Item.KeyProductId)
+	assert.Equal(suite.T(), int32(0), res.Item.Ttl)
+}
+
+func (suite *KeyTestSuite) TestKey_ReserveKeyForOrder_Ok_PolicyTtl() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF")
+
+	key := suite.helperReserveKey(keyProduct, 10)
+	reservedTo, err := ptypes.Timestamp(key.ReservedTo)
+	assert.NoError(suite.T(), err)
+	assert.WithinDuration(suite.T(), time.Now().Add(10*time.Second), reservedTo, 5*time.Second)
+	assert.Equal(suite.T(), float64(reservedTo.Unix()), suite.helperGetKeyReservationScore(key.Id))
+
+	suite.helperSetKeyReservationPolicy(keyProduct, 3600, 0)
+
+	key = suite.helperReserveKey(keyProduct, 10)
+	reservedTo, err = ptypes.Timestamp(key.ReservedTo)
+	assert.NoError(suite.T(), err)
+	assert.WithinDuration(suite.T(), time.Now().Add(time.Hour), reservedTo, 5*time.Second)
+	assert.Equal(suite.T(), float64(reservedTo.Unix()), suite.helperGetKeyReservationScore(key.Id))
+}
+
+func (suite *KeyTestSuite) TestKey_FinishRedeemKeyForOrder_Ok_Dequeued() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	key := suite.helperReserveKey(keyProduct, 10)
+
+	res := &billingpb.GetKeyForOrderRequestResponse{}
+	err := suite.service.FinishRedeemKeyForOrder(context.TODO(), &billingpb.KeyForOrderRequest{KeyId: key.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	_, err = suite.service.redis.ZScore(keyReservationQueue, key.Id).Result()
+	assert.Equal(suite.T(), redis.Nil, err)
+}
+
+func (suite *KeyTestSuite) TestKey_KeyDaemonProcess_Ok_ReleasedByQueue() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF")
+	expired := suite.helperReserveKey(keyProduct, 10)
+	reserved := suite.helperReserveKey(keyProduct, 10)
+
+	_, err := suite.service.keyRepository.ShortenReservation(context.TODO(), expired.Id, time.Now().Add(-time.Second))
+	assert.NoError(suite.T(), err)
+	err = suite.service.redis.ZAdd(keyReservationQueue, redis.Z{Score: 0, Member: expired.Id}).Err()
+	assert.NoError(suite.T(), err)
+
+	count, err := suite.service.KeyDaemonProcess(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	audits, err := suite.service.keyAuditRepository.Find(context.TODO(), keyProduct.Id, expired.Id, 0, 10)
+	assert.NoError(suite.T(), err)
+	released := false
+
+	for _, v := range audits {
+		if v.Action == pkg.KeyAuditActionReleased {
+			released = true
+			assert.Equal(suite.T(), expired.OrderId, v.OrderId)
+			assert.Equal(suite.T(), keyReleaseReasonReservationExpired, v.Reason)
+		}
+	}
+
+	assert.True(suite.T(), released)
+
+	key, err := suite.service.keyRepository.GetById(context.TODO(), expired.Id)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), key.OrderId)
+
+	_, err = suite.service.redis.ZScore(keyReservationQueue, expired.Id).Result()
+	assert.Equal(suite.T(), redis.Nil, err)
+	assert.NotZero(suite.T(), suite.helperGetKeyReservationScore(reserved.Id))
+
+	available, err := suite.service.keyRepository.CountKeysByProductPlatform(context.TODO(), keyProduct.Id, "steam")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), available)
+}
+
+func (suite *KeyTestSuite) TestKey_KeyDaemonProcess_Ok_NotExpired() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	reserved := suite.helperReserveKey(keyProduct, 10)
+
+	// the queue is ahead of the reservation stored in the database
+	err := suite.service.redis.ZAdd(keyReservationQueue, redis.Z{Score: 0, Member: reserved.Id}).Err()
+	assert.NoError(suite.T(), err)
+
+	count, err := suite.service.KeyDaemonProcess(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, count)
+
+	key, err := suite.service.keyRepository.GetById(context.TODO(), reserved.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), reserved.OrderId, key.OrderId)
+
+	_, err = suite.service.redis.ZScore(keyReservationQueue, reserved.Id).Result()
+	assert.Equal(suite.T(), redis.Nil, err)
+}
+
+func (suite *KeyTestSuite) TestKey_ShortenKeyReservation_Ok() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	suite.helperSetKeyReservationPolicy(keyProduct, 3600, 60)
+	reserved := suite.helperReserveKey(keyProduct, 0)
+
+	err := suite.service.shortenKeyReservation(context.TODO(), reserved.Id)
+	assert.NoError(suite.T(), err)
+
+	key, err := suite.service.keyRepository.GetById(context.TODO(), reserved.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), reserved.OrderId, key.OrderId)
+
+	reservedTo, err := ptypes.Timestamp(key.ReservedTo)
+	assert.NoError(suite.T(), err)
+	assert.WithinDuration(suite.T(), time.Now().Add(time.Minute), reservedTo, 5*time.Second)
+	assert.Equal(suite.T(), float64(reservedTo.Unix()), suite.helperGetKeyReservationScore(key.Id))
+
+	// repeated notification doesn't extend the reservation
+	suite.helperSetKeyReservationPolicy(keyProduct, 3600, 600)
+	err = suite.service.shortenKeyReservation(context.TODO(), reserved.Id)
+	assert.NoError(suite.T(), err)
+
+	key2, err := suite.service.keyRepository.GetById(context.TODO(), reserved.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), key.ReservedTo, key2.ReservedTo)
+}
+
+func (suite *KeyTestSuite) TestKey_ShortenKeyReservation_Ok_Released() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	reserved := suite.helperReserveKey(keyProduct, 10)
+
+	err := suite.service.shortenKeyReservation(context.TODO(), reserved.Id)
+	assert.NoError(suite.T(), err)
+
+	key, err := suite.service.keyRepository.GetById(context.TODO(), reserved.Id)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), key.OrderId)
+
+	_, err = suite.service.redis.ZScore(keyReservationQueue, reserved.Id).Result()
+	assert.Equal(suite.T(), redis.Nil, err)
+}
+
+func (suite *KeyTestSuite) TestKey_ReleaseRejectedOrderKeys_Ok() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC")
+	suite.helperSetKeyReservationPolicy(keyProduct, 3600, 600)
+	reserved := suite.helperReserveKey(keyProduct, 0)
+
+	order := &billingpb.Order{Id: reserved.OrderId, Keys: []string{reserved.Id, primitive.NewObjectID().Hex()}}
+	suite.service.releaseRejectedOrderKeys(context.TODO(), order)
+	assert.Empty(suite.T(), order.Keys)
+
+	// the key of the rejected payment is released regardless of the reservation time of failed orders
+	key, err := suite.service.keyRepository.GetById(context.TODO(), reserved.Id)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), key.OrderId)
+
+	_, err = suite.service.redis.ZScore(keyReservationQueue, reserved.Id).Result()
+	assert.Equal(suite.T(), redis.Nil, err)
+}
+
+func (suite *KeyTestSuite) TestKey_UpdateKeyMetrics_Ok() {
+	keyProduct := suite.helperCreateKeyProduct()
+	suite.helperUploadKeys(keyProduct, "AAAA-BBBB-CCCC", "DDDD-EEEE-FFFF", "GGGG-HHHH-IIII")
+	suite.helperReserveKey(keyProduct, 10)
+	redeemed := suite.helperReserveKey(keyProduct, 10)
+
+	res := &billingpb.GetKeyForOrderRequestResponse{}
+	err := suite.service.FinishRedeemKeyForOrder(context.TODO(), &billingpb.KeyForOrderRequest{KeyId: redeemed.Id}, res)
+	assert.NoError(suite.T(), err)
+
+	err = suite.service.UpdateKeyMetrics(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	for _, state := range []string{pkg.KeyStateAvailable, pkg.KeyStateReserved, pkg.KeyStateRedeemed} {
+		gauge := keysGauge.WithLabelValues(keyProduct.Id, "steam", state)
+		assert.Equal(suite.T(), float64(1), testutil.ToFloat64(gauge), state)
+	}
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"github.com/elliotchance/redismock"
+	"github.com/go-redis/redis"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/mongodb"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -71,7 +73,7 @@ func (suite *KeyTestSuite) SetupTest() {
 		mocks.NewRepositoryServiceOk(),
 		mocks.NewTaxServiceOkMock(),
 		nil,
-		nil,
+		redisdb,
 		suite.cache,
 		mocks.NewCurrencyServiceMockOk(),
 		mocks.NewDocumentSignerMockOk(),
@@ -568,9 +570,11 @@ func (suite *KeyTestSuite) TestKey_UploadKeysFile_Error_CountKeysByProductPlatfo
 
 func (suite *KeyTestSuite) TestKey_KeyDaemonProcess_Ok() {
 	keys := []*billingpb.Key{{Id: primitive.NewObjectID().Hex()}}
+	err := suite.service.redis.ZAdd(keyReservationQueue, redis.Z{Score: 0, Member: keys[0].Id}).Err()
+	assert.NoError(suite.T(), err)
+
 	kr := &mocks.KeyRepositoryInterface{}
-	kr.On("FindUnfinished", mock2.Anything).Return(keys, nil)
-	kr.On("CancelById", mock2.Anything, keys[0].Id).Return(&billingpb.Key{}, nil)
+	kr.On("CancelExpiredById", mock2.Anything, keys[0].Id).Return(&billingpb.Key{Id: keys[0].Id}, nil)
 	suite.service.keyRepository = kr
 
 	count, err := suite.service.KeyDaemonProcess(ctx)
@@ -579,16 +583,51 @@ func (suite *KeyTestSuite) TestKey_KeyDaemonProcess_Ok() {
 }
 
 func (suite *KeyTestSuite) TestKey_KeyDaemonProcess_Error_FindUnfinished() {
+	redisCl, ok := suite.service.redis.(*redismock.ClientMock)
+	assert.True(suite.T(), ok)
+	redisCl.On("ZRangeByScore").Return(redis.NewStringSliceResult(nil, errors.New("server not available")))
+
+	count, err := suite.service.KeyDaemonProcess(ctx)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), 0, count)
+}
+
+func (suite *KeyTestSuite) TestKey_KeyDaemonProcess_Error_CancelById() {
+	keys := []*billingpb.Key{{Id: primitive.NewObjectID().Hex()}}
+	err := suite.service.redis.ZAdd(keyReservationQueue, redis.Z{Score: 0, Member: keys[0].Id}).Err()
+	assert.NoError(suite.T(), err)
+
+	kr := &mocks.KeyRepositoryInterface{}
+	kr.On("CancelExpiredById", mock2.Anything, keys[0].Id).Return(nil, errors.New("not found"))
+	suite.service.keyRepository = kr
+
+	count, _ := suite.service.KeyDaemonProcess(context.TODO())
+	assert.Equal(suite.T(), 0, count)
+}
+
+func (suite *KeyTestSuite) TestKey_ReleaseExpiredKeys_Ok() {
+	keys := []*billingpb.Key{{Id: primitive.NewObjectID().Hex()}}
+	kr := &mocks.KeyRepositoryInterface{}
+	kr.On("FindUnfinished", mock2.Anything).Return(keys, nil)
+	kr.On("CancelById", mock2.Anything, keys[0].Id).Return(&billingpb.Key{}, nil)
+	suite.service.keyRepository = kr
+
+	count, err := suite.service.ReleaseExpiredKeys(ctx)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+}
+
+func (suite *KeyTestSuite) TestKey_ReleaseExpiredKeys_Error_FindUnfinished() {
 	kr := &mocks.KeyRepositoryInterface{}
 	kr.On("FindUnfinished", mock2.Anything).Return(nil, errors.New("not found"))
 	suite.service.keyRepository = kr
 
-	count, err := suite.service.KeyDaemonProcess(ctx)
+	count, err := suite.service.ReleaseExpiredKeys(ctx)
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), 0, count)
 }
 
-func (suite *KeyTestSuite) TestKey_KeyDaemonProcess_Error_CancelById() {
+func (suite *KeyTestSuite) TestKey_ReleaseExpiredKeys_Error_CancelById() {
 	keys := []*billingpb.Key{{Id: primitive.NewObjectID().Hex()}}
 
 	kr := &mocks.KeyRepositoryInterface{}
@@ -596,7 +635,7 @@ func (suite *KeyTestSuite) TestKey_KeyDaemonProcess_Error_CancelById() {
 	kr.On("CancelById", mock2.Anything, keys[0].Id).Return(nil, errors.New("not found"))
 	suite.service.keyRepository = kr
 
-	count, _ := suite.service.KeyDaemonProcess(context.TODO())
+	count, _ := suite.service.ReleaseExpiredKeys(context.TODO())
 	assert.Equal(suite.T(), 0, count)
 }
 
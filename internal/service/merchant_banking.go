@@ -0,0 +1,678 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	cryptoRand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/postmarkpb"
+	"github.com/streadway/amqp"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	merchantBankingMicroDepositsCount      = 2
+	merchantBankingMicroDepositMaxAttempts = 3
+	merchantBankingConfirmationCodeLength  = 6
+	merchantBankingConfirmationMaxAttempts = 3
+	merchantBankingConfirmationCodeTtl     = 24 * time.Hour
+	merchantBankingConfirmationSaltLength  = 16
+
+	merchantBankingChangedMessage  = "Bank account details of your company were changed. Payouts are held until the new bank account is verified and the cooling-off period ends."
+	merchantBankingVerifiedMessage = "New bank account of your company was verified."
+	merchantBankingRejectedMessage = "Change of bank account of your company was rejected, previous bank account details are restored."
+)
+
+var (
+	merchantBankingErrorAccountInvalid        = newBillingServerErrorMsg("mb000001", "bank account number is invalid")
+	merchantBankingErrorSwiftInvalid          = newBillingServerErrorMsg("mb000002", "bank swift code is invalid")
+	merchantBankingErrorChangePending         = newBillingServerErrorMsg("mb000003", "previous bank account change is not verified yet")
+	merchantBankingErrorChangeNotFound        = newBillingServerErrorMsg("mb000004", "bank account change not found")
+	merchantBankingErrorChangeNotPending      = newBillingServerErrorMsg("mb000005", "bank account change is already completed")
+	merchantBankingErrorVerificationInvalid   = newBillingServerErrorMsg("mb000006", "bank account verification method is not supported")
+	merchantBankingErrorOwnerOnly             = newBillingServerErrorMsg("mb000007", "bank account change can be confirmed by merchant owner only")
+	merchantBankingErrorCodeInvalid           = newBillingServerErrorMsg("mb000008", "bank account change confirmation code is invalid")
+	merchantBankingErrorMicroDepositsInvalid  = newBillingServerErrorMsg("mb000009", "micro-deposit amounts do not match")
+	merchantBankingErrorMicroDepositsDisabled = newBillingServerErrorMsg("mb000010", "bank account change is not verified by micro-deposits")
+	merchantBankingErrorUnknown               = newBillingServerErrorMsg("mb000011", "unknown error. try request later")
+	merchantBankingErrorCodeExpired           = newBillingServerErrorMsg("mb000012", "bank account change confirmation code is expired")
+)
+
+// ChangeMerchantBanking replaces bank account details of the merchant which finished onboarding.
+// The change must be confirmed by the merchant owner with the code sent by email and the new bank account
+// must be verified by micro-deposits or by admin. Payouts of the merchant are held until the change is verified
+// and the cooling-off period ends.
+func (s *Service) ChangeMerchantBanking(
+	ctx context.Context,
+	req *pkg.ChangeMerchantBankingRequest,
+	rsp *pkg.MerchantBankingChangeResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if req.VerificationMethod == "" {
+		req.VerificationMethod = pkg.MerchantBankingVerificationMicroDeposit
+	}
+
+	if req.VerificationMethod != pkg.MerchantBankingVerificationMicroDeposit &&
+		req.VerificationMethod != pkg.MerchantBankingVerificationAdmin {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantBankingErrorVerificationInvalid
+		return nil
+	}
+
+	if req.Banking == nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantBankingErrorAccountInvalid
+		return nil
+	}
+
+	if req.Banking.Currency == "" && merchant.Banking != nil {
+		req.Banking.Currency = merchant.Banking.Currency
+	}
+
+	if req.Banking.ProcessingDefaultCurrency == "" {
+		req.Banking.ProcessingDefaultCurrency = req.Banking.Currency
+	}
+
+	if msg := validateMerchantBanking(req.Banking); msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+		return nil
+	}
+
+	last, err := s.merchantBankingChangeRepository.GetLastByMerchantId(ctx, merchant.Id)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBankingErrorUnknown
+		return nil
+	}
+
+	if last != nil && last.Status == pkg.MerchantBankingChangeStatusPending {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantBankingErrorChangePending
+		return nil
+	}
+
+	code, err := generateMerchantBankingConfirmationCode()
+
+	if err != nil {
+		zap.L().Error("Generate bank account change confirmation code failed", zap.Error(err))
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBankingErrorUnknown
+		return nil
+	}
+
+	salt, err := generateMerchantBankingConfirmationSalt()
+
+	if err != nil {
+		zap.L().Error("Generate bank account change confirmation code failed", zap.Error(err))
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBankingErrorUnknown
+		return nil
+	}
+
+	holdUntil, _ := ptypes.TimestampProto(time.Now().Add(time.Duration(s.cfg.MerchantBankingChangeCooldown) * time.Hour))
+	codeExpiresAt, _ := ptypes.TimestampProto(time.Now().Add(merchantBankingConfirmationCodeTtl))
+
+	change := &pkg.MerchantBankingChange{
+		MerchantId:                merchant.Id,
+		Previous:                  merchant.Banking,
+		Banking:                   req.Banking,
+		Status:                    pkg.MerchantBankingChangeStatusPending,
+		VerificationMethod:        req.VerificationMethod,
+		ConfirmationCodeHash:      hashMerchantBankingConfirmationCode(code, salt),
+		ConfirmationCodeSalt:      salt,
+		ConfirmationCodeExpiresAt: codeExpiresAt,
+		UserId:                    req.UserId,
+		Ip:                        req.Ip,
+		HoldUntil:                 holdUntil,
+		CreatedAt:                 ptypes.TimestampNow(),
+		UpdatedAt:                 ptypes.TimestampNow(),
+	}
+
+	if req.VerificationMethod == pkg.MerchantBankingVerificationMicroDeposit {
+		change.MicroDeposits, err = generateMerchantBankingMicroDeposits()
+
+		if err != nil {
+			zap.L().Error("Generate bank account micro-deposits failed", zap.Error(err))
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = merchantBankingErrorUnknown
+			return nil
+		}
+	}
+
+	err = s.merchantBankingChangeRepository.Insert(ctx, change)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBankingErrorUnknown
+		return nil
+	}
+
+	merchant.Banking = req.Banking
+	merchant.UpdatedAt = ptypes.TimestampNow()
+	err = s.merchantRepository.Update(ctx, merchant)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantErrorUnknown
+		return nil
+	}
+
+	// potential matches of the screening hold payouts regardless of verification of the bank account
+	if _, err = s.screenMerchant(ctx, merchant, pkg.SanctionsScreeningTriggerBanking, req.UserId); err != nil {
+		zap.L().Error("Sanctions screening of the merchant failed", zap.Error(err), zap.String("merchant_id", merchant.Id))
+	}
+
+	s.sendMerchantBankingChangedEmail(ctx, merchant, change, code)
+	s.addMerchantBankingNotification(ctx, merchant.Id, merchantBankingChangedMessage)
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = getMerchantBankingChangeForMerchant(change)
+
+	return nil
+}
+
+// ConfirmMerchantBankingChange is the second factor of the bank account change,
+// the merchant owner confirms the change with the code from email. The change is rejected when the code
+// is expired or after too many failed attempts.
+func (s *Service) ConfirmMerchantBankingChange(
+	ctx context.Context,
+	req *pkg.ConfirmMerchantBankingChangeRequest,
+	rsp *pkg.MerchantBankingChangeResponse,
+) error {
+	change, msg := s.getPendingMerchantBankingChange(ctx, req.MerchantId, req.ChangeId)
+
+	if msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+		return nil
+	}
+
+	owner, err := s.userRoleRepository.GetMerchantOwner(ctx, change.MerchantId)
+
+	if err != nil || owner.UserId != req.UserId {
+		rsp.Status = billingpb.ResponseStatusForbidden
+		rsp.Message = merchantBankingErrorOwnerOnly
+		return nil
+	}
+
+	expiresAt, err := ptypes.Timestamp(change.ConfirmationCodeExpiresAt)
+
+	if err != nil || time.Now().After(expiresAt) {
+		change.RejectReason = merchantBankingErrorCodeExpired.Message
+
+		if err = s.rejectMerchantBankingChange(ctx, change); err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = merchantBankingErrorUnknown
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantBankingErrorCodeExpired
+		rsp.Item = getMerchantBankingChangeForMerchant(change)
+		return nil
+	}
+
+	hash := hashMerchantBankingConfirmationCode(req.Code, change.ConfirmationCodeSalt)
+
+	if !hmac.Equal([]byte(change.ConfirmationCodeHash), []byte(hash)) {
+		change.ConfirmationCodeAttempts++
+
+		if change.ConfirmationCodeAttempts >= merchantBankingConfirmationMaxAttempts {
+			change.RejectReason = merchantBankingErrorCodeInvalid.Message
+			err = s.rejectMerchantBankingChange(ctx, change)
+		} else {
+			change.UpdatedAt = ptypes.TimestampNow()
+			err = s.merchantBankingChangeRepository.Update(ctx, change)
+		}
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = merchantBankingErrorUnknown
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantBankingErrorCodeInvalid
+		rsp.Item = getMerchantBankingChangeForMerchant(change)
+		return nil
+	}
+
+	change.IsOwnerConfirmed = true
+
+	if err = s.completeMerchantBankingChange(ctx, change); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBankingErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = getMerchantBankingChangeForMerchant(change)
+
+	return nil
+}
+
+// VerifyMerchantBankingMicroDeposits checks amounts of micro-deposits received by the merchant on the new
+// bank account. The change is rejected after too many failed attempts.
+func (s *Service) VerifyMerchantBankingMicroDeposits(
+	ctx context.Context,
+	req *pkg.VerifyMerchantBankingMicroDepositsRequest,
+	rsp *pkg.MerchantBankingChangeResponse,
+) error {
+	change, msg := s.getPendingMerchantBankingChange(ctx, req.MerchantId, req.ChangeId)
+
+	if msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+		return nil
+	}
+
+	if change.VerificationMethod != pkg.MerchantBankingVerificationMicroDeposit {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantBankingErrorMicroDepositsDisabled
+		return nil
+	}
+
+	change.MicroDepositAttempts++
+
+	if !isMerchantBankingMicroDepositsMatch(change.MicroDeposits, req.Amounts) {
+		if change.MicroDepositAttempts >= merchantBankingMicroDepositMaxAttempts {
+			change.RejectReason = merchantBankingErrorMicroDepositsInvalid.Message
+			err := s.rejectMerchantBankingChange(ctx, change)
+
+			if err != nil {
+				rsp.Status = billingpb.ResponseStatusSystemError
+				rsp.Message = merchantBankingErrorUnknown
+				return nil
+			}
+		} else {
+			change.UpdatedAt = ptypes.TimestampNow()
+
+			if err := s.merchantBankingChangeRepository.Update(ctx, change); err != nil {
+				rsp.Status = billingpb.ResponseStatusSystemError
+				rsp.Message = merchantBankingErrorUnknown
+				return nil
+			}
+		}
+
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantBankingErrorMicroDepositsInvalid
+		rsp.Item = getMerchantBankingChangeForMerchant(change)
+		return nil
+	}
+
+	change.IsAccountVerified = true
+
+	if err := s.completeMerchantBankingChange(ctx, change); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBankingErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = getMerchantBankingChangeForMerchant(change)
+
+	return nil
+}
+
+// ReviewMerchantBankingChange allows admin to verify the new bank account or to reject the change.
+// Rejected change restores previous bank account details of the merchant.
+func (s *Service) ReviewMerchantBankingChange(
+	ctx context.Context,
+	req *pkg.ReviewMerchantBankingChangeRequest,
+	rsp *pkg.MerchantBankingChangeResponse,
+) error {
+	change, msg := s.getPendingMerchantBankingChange(ctx, req.MerchantId, req.ChangeId)
+
+	if msg != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = msg
+		return nil
+	}
+
+	change.ReviewerId = req.UserId
+
+	var err error
+
+	if req.IsApproved {
+		change.IsAccountVerified = true
+		err = s.completeMerchantBankingChange(ctx, change)
+	} else {
+		change.RejectReason = req.Reason
+		err = s.rejectMerchantBankingChange(ctx, change)
+	}
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBankingErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = change
+
+	return nil
+}
+
+// GetMerchantBankingChanges returns history of bank account changes of the merchant.
+func (s *Service) GetMerchantBankingChanges(
+	ctx context.Context,
+	req *pkg.GetMerchantBankingChangesRequest,
+	rsp *pkg.GetMerchantBankingChangesResponse,
+) error {
+	changes, err := s.merchantBankingChangeRepository.FindByMerchantId(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantBankingErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Items = changes
+
+	return nil
+}
+
+// isMerchantBankingOnHold returns true if payouts to the merchant must be held because the bank account
+// change is not verified yet or the cooling-off period after the change is not ended.
+func (s *Service) isMerchantBankingOnHold(ctx context.Context, merchantId string) (bool, error) {
+	change, err := s.merchantBankingChangeRepository.GetLastByMerchantId(ctx, merchantId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if change.Status == pkg.MerchantBankingChangeStatusPending {
+		return true, nil
+	}
+
+	if change.Status != pkg.MerchantBankingChangeStatusVerified || change.HoldUntil == nil {
+		return false, nil
+	}
+
+	holdUntil, err := ptypes.Timestamp(change.HoldUntil)
+
+	if err != nil {
+		return false, err
+	}
+
+	return time.Now().Before(holdUntil), nil
+}
+
+// addMerchantBankingHistory records bank account change made on onboarding. Such changes are verified
+// by admin together with other onboarding data so they are saved as verified without cooling-off period.
+func (s *Service) addMerchantBankingHistory(
+	ctx context.Context,
+	merchantId, userId string,
+	previous, banking *billingpb.MerchantBanking,
+) error {
+	change := &pkg.MerchantBankingChange{
+		MerchantId:         merchantId,
+		Previous:           previous,
+		Banking:            banking,
+		Status:             pkg.MerchantBankingChangeStatusVerified,
+		VerificationMethod: pkg.MerchantBankingVerificationAdmin,
+		UserId:             userId,
+		HoldUntil:          ptypes.TimestampNow(),
+		VerifiedAt:         ptypes.TimestampNow(),
+		CreatedAt:          ptypes.TimestampNow(),
+		UpdatedAt:          ptypes.TimestampNow(),
+	}
+
+	return s.merchantBankingChangeRepository.Insert(ctx, change)
+}
+
+func (s *Service) getPendingMerchantBankingChange(
+	ctx context.Context,
+	merchantId, changeId string,
+) (*pkg.MerchantBankingChange, *billingpb.ResponseErrorMessage) {
+	change, err := s.merchantBankingChangeRepository.GetById(ctx, changeId)
+
+	if err != nil || change.MerchantId != merchantId {
+		return nil, merchantBankingErrorChangeNotFound
+	}
+
+	if change.Status != pkg.MerchantBankingChangeStatusPending {
+		return nil, merchantBankingErrorChangeNotPending
+	}
+
+	return change, nil
+}
+
+func (s *Service) completeMerchantBankingChange(ctx context.Context, change *pkg.MerchantBankingChange) error {
+	change.UpdatedAt = ptypes.TimestampNow()
+
+	if change.IsOwnerConfirmed && change.IsAccountVerified {
+		change.Status = pkg.MerchantBankingChangeStatusVerified
+		change.VerifiedAt = ptypes.TimestampNow()
+	}
+
+	err := s.merchantBankingChangeRepository.Update(ctx, change)
+
+	if err != nil {
+		return err
+	}
+
+	if change.Status == pkg.MerchantBankingChangeStatusVerified {
+		s.addMerchantBankingNotification(ctx, change.MerchantId, merchantBankingVerifiedMessage)
+	}
+
+	return nil
+}
+
+func (s *Service) rejectMerchantBankingChange(ctx context.Context, change *pkg.MerchantBankingChange) error {
+	merchant, err := s.merchantRepository.GetById(ctx, change.MerchantId)
+
+	if err != nil {
+		return err
+	}
+
+	change.Status = pkg.MerchantBankingChangeStatusRejected
+	change.UpdatedAt = ptypes.TimestampNow()
+	err = s.merchantBankingChangeRepository.Update(ctx, change)
+
+	if err != nil {
+		return err
+	}
+
+	merchant.Banking = change.Previous
+	merchant.UpdatedAt = ptypes.TimestampNow()
+	err = s.merchantRepository.Update(ctx, merchant)
+
+	if err != nil {
+		return err
+	}
+
+	s.addMerchantBankingNotification(ctx, change.MerchantId, merchantBankingRejectedMessage)
+
+	return nil
+}
+
+func (s *Service) sendMerchantBankingChangedEmail(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	change *pkg.MerchantBankingChange,
+	code string,
+) {
+	owner, err := s.userRoleRepository.GetMerchantOwner(ctx, merchant.Id)
+
+	if err != nil {
+		zap.L().Error("Merchant owner not found", zap.Error(err), zap.String("merchant_id", merchant.Id))
+		return
+	}
+
+	payload := &postmarkpb.Payload{
+		TemplateAlias: s.cfg.EmailTemplates.MerchantBankingChanged,
+		TemplateModel: map[string]string{
+			"merchant_name":       merchant.GetCompany().GetName(),
+			"account_number":      maskMerchantBankingAccount(change.Banking.AccountNumber),
+			"bank_name":           change.Banking.Name,
+			"confirmation_code":   code,
+			"verification_method": change.VerificationMethod,
+			"change_id":           change.Id,
+		},
+		To: owner.Email,
+	}
+
+	err = s.postmarkBroker.Publish(postmarkpb.PostmarkSenderTopicName, payload, amqp.Table{})
+
+	if err != nil {
+		zap.L().Error(
+			"Publication message about merchant bank account change to queue failed",
+			zap.Error(err),
+			zap.String("merchant_id", merchant.Id),
+		)
+	}
+}
+
+func (s *Service) addMerchantBankingNotification(ctx context.Context, merchantId, message string) {
+	_, err := s.addNotification(ctx, message, merchantId, "", nil)
+
+	if err != nil {
+		zap.L().Error("Add merchant bank account notification failed", zap.Error(err), zap.String("merchant_id", merchantId))
+	}
+}
+
+// validateMerchantBanking normalizes bank account details and checks the account number and the format
+// of the swift code.
+func validateMerchantBanking(banking *billingpb.MerchantBanking) *billingpb.ResponseErrorMessage {
+	banking.AccountNumber = normalizeBankAccount(banking.AccountNumber)
+	banking.Swift = normalizeBankAccount(banking.Swift)
+
+	if banking.AccountNumber == "" || !isValidMerchantBankingAccount(banking.AccountNumber) {
+		return merchantBankingErrorAccountInvalid
+	}
+
+	if banking.Swift != "" && !swiftRegex.MatchString(banking.Swift) {
+		return merchantBankingErrorSwiftInvalid
+	}
+
+	return nil
+}
+
+// isValidMerchantBankingAccount checks the account number by IBAN checksum when the account number is IBAN.
+// Local account numbers have no common checksum and are verified by micro-deposits or by admin.
+func isValidMerchantBankingAccount(account string) bool {
+	account = normalizeBankAccount(account)
+
+	if !isIbanLike(account) {
+		return true
+	}
+
+	return isValidIban(account)
+}
+
+// isIbanLike returns true if the account number starts with the country code as IBAN does.
+func isIbanLike(account string) bool {
+	return len(account) > 2 && account[0] >= 'A' && account[0] <= 'Z' && account[1] >= 'A' && account[1] <= 'Z'
+}
+
+func isMerchantBankingMicroDepositsMatch(expected, actual []float64) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	toCents := func(amounts []float64) []int64 {
+		cents := make([]int64, len(amounts))
+
+		for i, v := range amounts {
+			cents[i] = int64(math.Round(v * 100))
+		}
+
+		sort.Slice(cents, func(i, j int) bool { return cents[i] < cents[j] })
+		return cents
+	}
+
+	e, a := toCents(expected), toCents(actual)
+
+	for i := range e {
+		if e[i] != a[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func generateMerchantBankingMicroDeposits() ([]float64, error) {
+	amounts := make([]float64, merchantBankingMicroDepositsCount)
+
+	for i := range amounts {
+		n, err := cryptoRand.Int(cryptoRand.Reader, big.NewInt(99))
+
+		if err != nil {
+			return nil, err
+		}
+
+		amounts[i] = float64(n.Int64()+1) / 100
+	}
+
+	return amounts, nil
+}
+
+func generateMerchantBankingConfirmationCode() (string, error) {
+	max := big.NewInt(int64(math.Pow10(merchantBankingConfirmationCodeLength)))
+	n, err := cryptoRand.Int(cryptoRand.Reader, max)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", merchantBankingConfirmationCodeLength, n.Int64()), nil
+}
+
+func generateMerchantBankingConfirmationSalt() (string, error) {
+	salt := make([]byte, merchantBankingConfirmationSaltLength)
+
+	if _, err := cryptoRand.Read(salt); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(salt), nil
+}
+
+func hashMerchantBankingConfirmationCode(code, salt string) string {
+	hash := hmac.New(sha256.New, []byte(salt))
+	hash.Write([]byte(code))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func maskMerchantBankingAccount(account string) string {
+	if len(account) <= 4 {
+		return account
+	}
+
+	return strings.Repeat("*", len(account)-4) + account[len(account)-4:]
+}
+
+// getMerchantBankingChangeForMerchant hides amounts of micro-deposits which merchant must confirm.
+func getMerchantBankingChangeForMerchant(change *pkg.MerchantBankingChange) *pkg.MerchantBankingChange {
+	out := *change
+	out.MicroDeposits = nil
+
+	return &out
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	merchantBankingTestCode = "123456"
+)
+
+func (suite *PayoutsTestSuite) helperAddMerchantOwner() *billingpb.UserRole {
+	owner := &billingpb.UserRole{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: suite.merchant.Id,
+		Status:     pkg.UserRoleStatusAccepted,
+		Role:       billingpb.RoleMerchantOwner,
+		UserId:     suite.merchant.User.Id,
+		Email:      suite.merchant.User.Email,
+	}
+
+	err := suite.service.userRoleRepository.AddMerchantUser(context.TODO(), owner)
+
+	if err != nil {
+		suite.FailNow("Insert merchant owner test data failed", "%v", err)
+	}
+
+	return owner
+}
+
+func (suite *PayoutsTestSuite) helperChangeMerchantBanking(method string) *pkg.MerchantBankingChange {
+	req := &pkg.ChangeMerchantBankingRequest{
+		MerchantId: suite.merchant.Id,
+		UserId:     suite.merchant.User.Id,
+		Banking: &billingpb.MerchantBanking{
+			Currency:      "EUR",
+			Name:          "New bank",
+			AccountNumber: "GB29 NWBK 6016 1331 9268 19",
+			Swift:         "nwbkgb2l",
+		},
+		VerificationMethod: method,
+		Ip:                 "127.0.0.1",
+	}
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	err := suite.service.ChangeMerchantBanking(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	// confirmation code is sent by email only, replace it to the known one
+	change, err := suite.service.merchantBankingChangeRepository.GetById(context.TODO(), rsp.Item.Id)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), change.ConfirmationCodeSalt)
+	assert.NotNil(suite.T(), change.ConfirmationCodeExpiresAt)
+	change.ConfirmationCodeHash = hashMerchantBankingConfirmationCode(merchantBankingTestCode, change.ConfirmationCodeSalt)
+	err = suite.service.merchantBankingChangeRepository.Update(context.TODO(), change)
+	assert.NoError(suite.T(), err)
+
+	return change
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ChangeMerchantBanking_Ok() {
+	suite.helperAddMerchantOwner()
+	previous := suite.merchant.Banking
+	change := suite.helperChangeMerchantBanking("")
+
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusPending, change.Status)
+	assert.Equal(suite.T(), pkg.MerchantBankingVerificationMicroDeposit, change.VerificationMethod)
+	assert.Len(suite.T(), change.MicroDeposits, merchantBankingMicroDepositsCount)
+	assert.Equal(suite.T(), previous.Name, change.Previous.Name)
+	assert.Equal(suite.T(), "GB29NWBK60161331926819", change.Banking.AccountNumber)
+	assert.Equal(suite.T(), "NWBKGB2L", change.Banking.Swift)
+
+	holdUntil, err := ptypes.Timestamp(change.HoldUntil)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), holdUntil.After(time.Now().Add(time.Duration(suite.service.cfg.MerchantBankingChangeCooldown-1)*time.Hour)))
+
+	merchant, err := suite.service.merchantRepository.GetById(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "GB29NWBK60161331926819", merchant.Banking.AccountNumber)
+
+	isOnHold, err := suite.service.isMerchantBankingOnHold(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), isOnHold)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ChangeMerchantBanking_Error() {
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	req := &pkg.ChangeMerchantBankingRequest{
+		MerchantId: primitive.NewObjectID().Hex(),
+		Banking:    &billingpb.MerchantBanking{AccountNumber: "GB29NWBK60161331926819"},
+	}
+	err := suite.service.ChangeMerchantBanking(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+
+	req.MerchantId = suite.merchant.Id
+	req.VerificationMethod = "unknown"
+	rsp = &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ChangeMerchantBanking(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), merchantBankingErrorVerificationInvalid, rsp.Message)
+
+	req.VerificationMethod = ""
+	req.Banking = &billingpb.MerchantBanking{AccountNumber: "GB29NWBK60161331926818"}
+	rsp = &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ChangeMerchantBanking(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantBankingErrorAccountInvalid, rsp.Message)
+
+	req.Banking = &billingpb.MerchantBanking{AccountNumber: "40702810000000000001", Swift: "TEST"}
+	rsp = &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ChangeMerchantBanking(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), merchantBankingErrorSwiftInvalid, rsp.Message)
+
+	suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationAdmin)
+
+	req.Banking = &billingpb.MerchantBanking{AccountNumber: "40702810000000000001", Swift: "SABRRUMM"}
+	rsp = &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ChangeMerchantBanking(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), merchantBankingErrorChangePending, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ConfirmAndVerifyMerchantBankingChange_Ok() {
+	suite.helperAddMerchantOwner()
+	change := suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationMicroDeposit)
+
+	req := &pkg.ConfirmMerchantBankingChangeRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		UserId:     suite.merchant.User.Id,
+		Code:       merchantBankingTestCode,
+	}
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	err := suite.service.ConfirmMerchantBankingChange(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.True(suite.T(), rsp.Item.IsOwnerConfirmed)
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusPending, rsp.Item.Status)
+	assert.Empty(suite.T(), rsp.Item.MicroDeposits)
+
+	req1 := &pkg.VerifyMerchantBankingMicroDepositsRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		Amounts:    []float64{change.MicroDeposits[1], change.MicroDeposits[0]},
+	}
+	err = suite.service.VerifyMerchantBankingMicroDeposits(context.TODO(), req1, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusVerified, rsp.Item.Status)
+	assert.NotNil(suite.T(), rsp.Item.VerifiedAt)
+
+	// payouts still held until cooling-off period ends
+	isOnHold, err := suite.service.isMerchantBankingOnHold(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), isOnHold)
+
+	change, err = suite.service.merchantBankingChangeRepository.GetById(context.TODO(), change.Id)
+	assert.NoError(suite.T(), err)
+	change.HoldUntil, _ = ptypes.TimestampProto(time.Now().Add(-time.Minute))
+	err = suite.service.merchantBankingChangeRepository.Update(context.TODO(), change)
+	assert.NoError(suite.T(), err)
+
+	isOnHold, err = suite.service.isMerchantBankingOnHold(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), isOnHold)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ConfirmMerchantBankingChange_Error() {
+	suite.helperAddMerchantOwner()
+	change := suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationAdmin)
+
+	req := &pkg.ConfirmMerchantBankingChangeRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   primitive.NewObjectID().Hex(),
+		UserId:     suite.merchant.User.Id,
+		Code:       merchantBankingTestCode,
+	}
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	err := suite.service.ConfirmMerchantBankingChange(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), merchantBankingErrorChangeNotFound, rsp.Message)
+
+	req.ChangeId = change.Id
+	req.UserId = "some_user"
+	rsp = &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ConfirmMerchantBankingChange(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusForbidden, rsp.Status)
+	assert.Equal(suite.T(), merchantBankingErrorOwnerOnly, rsp.Message)
+
+	req.UserId = suite.merchant.User.Id
+	req.Code = "000000"
+	rsp = &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ConfirmMerchantBankingChange(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), merchantBankingErrorCodeInvalid, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ConfirmMerchantBankingChange_RejectedByAttempts() {
+	suite.helperAddMerchantOwner()
+	previous := suite.merchant.Banking
+	change := suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationAdmin)
+
+	req := &pkg.ConfirmMerchantBankingChangeRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		UserId:     suite.merchant.User.Id,
+		Code:       "000000",
+	}
+
+	for i := 0; i < merchantBankingConfirmationMaxAttempts; i++ {
+		rsp := &pkg.MerchantBankingChangeResponse{}
+		err := suite.service.ConfirmMerchantBankingChange(context.TODO(), req, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), merchantBankingErrorCodeInvalid, rsp.Message)
+		assert.EqualValues(suite.T(), i+1, rsp.Item.ConfirmationCodeAttempts)
+	}
+
+	change, err := suite.service.merchantBankingChangeRepository.GetById(context.TODO(), change.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusRejected, change.Status)
+	assert.False(suite.T(), change.IsOwnerConfirmed)
+
+	merchant, err := suite.service.merchantRepository.GetById(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), previous.Name, merchant.Banking.Name)
+
+	req.Code = merchantBankingTestCode
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ConfirmMerchantBankingChange(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), merchantBankingErrorChangeNotPending, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ConfirmMerchantBankingChange_CodeExpired() {
+	suite.helperAddMerchantOwner()
+	change := suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationAdmin)
+
+	var err error
+	change.ConfirmationCodeExpiresAt, err = ptypes.TimestampProto(time.Now().Add(-time.Minute))
+	assert.NoError(suite.T(), err)
+	err = suite.service.merchantBankingChangeRepository.Update(context.TODO(), change)
+	assert.NoError(suite.T(), err)
+
+	req := &pkg.ConfirmMerchantBankingChangeRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		UserId:     suite.merchant.User.Id,
+		Code:       merchantBankingTestCode,
+	}
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ConfirmMerchantBankingChange(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantBankingErrorCodeExpired, rsp.Message)
+
+	change, err = suite.service.merchantBankingChangeRepository.GetById(context.TODO(), change.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusRejected, change.Status)
+	assert.Equal(suite.T(), merchantBankingErrorCodeExpired.Message, change.RejectReason)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_HashMerchantBankingConfirmationCode() {
+	hash := hashMerchantBankingConfirmationCode(merchantBankingTestCode, "salt1")
+	assert.Equal(suite.T(), hash, hashMerchantBankingConfirmationCode(merchantBankingTestCode, "salt1"))
+	assert.NotEqual(suite.T(), hash, hashMerchantBankingConfirmationCode(merchantBankingTestCode, "salt2"))
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_VerifyMerchantBankingMicroDeposits_RejectedByAttempts() {
+	suite.helperAddMerchantOwner()
+	previous := suite.merchant.Banking
+	change := suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationMicroDeposit)
+
+	req := &pkg.VerifyMerchantBankingMicroDepositsRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		Amounts:    []float64{1, 2},
+	}
+
+	for i := 0; i < merchantBankingMicroDepositMaxAttempts; i++ {
+		rsp := &pkg.MerchantBankingChangeResponse{}
+		err := suite.service.VerifyMerchantBankingMicroDeposits(context.TODO(), req, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), merchantBankingErrorMicroDepositsInvalid, rsp.Message)
+		assert.EqualValues(suite.T(), i+1, rsp.Item.MicroDepositAttempts)
+	}
+
+	change, err := suite.service.merchantBankingChangeRepository.GetById(context.TODO(), change.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusRejected, change.Status)
+
+	merchant, err := suite.service.merchantRepository.GetById(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), previous.Name, merchant.Banking.Name)
+	assert.Empty(suite.T(), merchant.Banking.AccountNumber)
+
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.VerifyMerchantBankingMicroDeposits(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), merchantBankingErrorChangeNotPending, rsp.Message)
+
+	isOnHold, err := suite.service.isMerchantBankingOnHold(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), isOnHold)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ReviewMerchantBankingChange_Ok() {
+	suite.helperAddMerchantOwner()
+	change := suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationAdmin)
+
+	req1 := &pkg.VerifyMerchantBankingMicroDepositsRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		Amounts:    []float64{0.01, 0.02},
+	}
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	err := suite.service.VerifyMerchantBankingMicroDeposits(context.TODO(), req1, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), merchantBankingErrorMicroDepositsDisabled, rsp.Message)
+
+	req := &pkg.ReviewMerchantBankingChangeRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		UserId:     primitive.NewObjectID().Hex(),
+		IsApproved: true,
+	}
+	rsp = &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ReviewMerchantBankingChange(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.True(suite.T(), rsp.Item.IsAccountVerified)
+	assert.Equal(suite.T(), req.UserId, rsp.Item.ReviewerId)
+	// owner must confirm the change too
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusPending, rsp.Item.Status)
+
+	req2 := &pkg.ConfirmMerchantBankingChangeRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		UserId:     suite.merchant.User.Id,
+		Code:       merchantBankingTestCode,
+	}
+	rsp = &pkg.MerchantBankingChangeResponse{}
+	err = suite.service.ConfirmMerchantBankingChange(context.TODO(), req2, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusVerified, rsp.Item.Status)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ReviewMerchantBankingChange_Rejected() {
+	suite.helperAddMerchantOwner()
+	change := suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationAdmin)
+
+	req := &pkg.ReviewMerchantBankingChangeRequest{
+		MerchantId: suite.merchant.Id,
+		ChangeId:   change.Id,
+		UserId:     primitive.NewObjectID().Hex(),
+		Reason:     "account holder does not match company name",
+	}
+	rsp := &pkg.MerchantBankingChangeResponse{}
+	err := suite.service.ReviewMerchantBankingChange(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.MerchantBankingChangeStatusRejected, rsp.Item.Status)
+	assert.Equal(suite.T(), req.Reason, rsp.Item.RejectReason)
+
+	merchant, err := suite.service.merchantRepository.GetById(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.merchant.Banking.Name, merchant.Banking.Name)
+
+	req1 := &pkg.GetMerchantBankingChangesRequest{MerchantId: suite.merchant.Id}
+	rsp1 := &pkg.GetMerchantBankingChangesResponse{}
+	err = suite.service.GetMerchantBankingChanges(context.TODO(), req1, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+	assert.Len(suite.T(), rsp1.Items, 1)
+	assert.Equal(suite.T(), change.Id, rsp1.Items[0].Id)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_CreatePayoutDocument_Failed_BankingOnHold() {
+	suite.helperAddMerchantOwner()
+	suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationAdmin)
+	suite.helperInsertRoyaltyReports([]*billingpb.RoyaltyReport{suite.report1, suite.report2})
+
+	req := &billingpb.CreatePayoutDocumentRequest{
+		MerchantId:  suite.merchant.Id,
+		Description: "test payout",
+		Ip:          "127.0.0.1",
+	}
+	res := &billingpb.CreatePayoutDocumentResponse{}
+
+	err := suite.service.CreatePayoutDocument(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorPayoutBankingOnHold, res.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_isValidMerchantBankingAccount() {
+	assert.True(suite.T(), isValidMerchantBankingAccount("GB29 NWBK 6016 1331 9268 19"))
+	assert.True(suite.T(), isValidMerchantBankingAccount("40702810000000000001"))
+	assert.False(suite.T(), isValidMerchantBankingAccount("GB29NWBK60161331926818"))
+	assert.False(suite.T(), isValidMerchantBankingAccount("DE00"))
+}
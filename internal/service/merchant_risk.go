@@ -0,0 +1,540 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/postmarkpb"
+	"github.com/streadway/amqp"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	merchantRiskAlertMessage     = "Payments of your account exceed risk thresholds: %s. Please contact support."
+	merchantRiskSuspendedMessage = "Processing of payments of your account is suspended, payments exceed risk thresholds: %s. Please contact support."
+)
+
+var (
+	errorMerchantRiskReviewNotFound        = newBillingServerErrorMsg("rm000001", "merchant risk review not found")
+	errorMerchantRiskReviewAlreadyResolved = newBillingServerErrorMsg("rm000002", "merchant risk review is already resolved")
+	errorMerchantRiskReviewCommentRequired = newBillingServerErrorMsg("rm000003", "comment is required to resolve merchant risk review")
+	errorMerchantRiskReviewUnknown         = newBillingServerErrorMsg("rm000004", "unknown error with merchant risk review")
+
+	merchantRiskReviewAlertMessage = map[string]string{"code": "rm000005", "message": "merchant exceeds risk thresholds"}
+)
+
+// ListMerchantRiskReviews returns reviews of the merchant risk monitoring, the latest review is the first.
+func (s *Service) ListMerchantRiskReviews(
+	ctx context.Context,
+	req *pkg.ListMerchantRiskReviewsRequest,
+	res *pkg.ListMerchantRiskReviewsResponse,
+) error {
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	var statuses []string
+
+	if req.Status != "" {
+		statuses = []string{req.Status}
+	}
+
+	count, err := s.merchantRiskReviewRepository.FindCount(ctx, req.MerchantId, statuses)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorMerchantRiskReviewUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count <= 0 {
+		return nil
+	}
+
+	res.Items, err = s.merchantRiskReviewRepository.Find(ctx, req.MerchantId, statuses, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorMerchantRiskReviewUnknown
+		res.Count = 0
+		return nil
+	}
+
+	return nil
+}
+
+// ResolveMerchantRiskReview resolves the review by the risk manager. Processing of payments of the merchant
+// is resumed if it was suspended by the review.
+func (s *Service) ResolveMerchantRiskReview(
+	ctx context.Context,
+	req *pkg.ResolveMerchantRiskReviewRequest,
+	res *pkg.MerchantRiskReviewResponse,
+) error {
+	if strings.TrimSpace(req.Comment) == "" {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorMerchantRiskReviewCommentRequired
+		return nil
+	}
+
+	review, err := s.merchantRiskReviewRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorMerchantRiskReviewNotFound
+		return nil
+	}
+
+	if review.Status == pkg.MerchantRiskReviewStatusResolved {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorMerchantRiskReviewAlreadyResolved
+		return nil
+	}
+
+	review.Status = pkg.MerchantRiskReviewStatusResolved
+	review.ResolvedBy = req.UserId
+	review.ResolvedAt = ptypes.TimestampNow()
+	review.UpdatedAt = ptypes.TimestampNow()
+	review.History = append(review.History, &pkg.MerchantRiskReviewEvent{
+		Status:    pkg.MerchantRiskReviewStatusResolved,
+		UserId:    req.UserId,
+		Comment:   req.Comment,
+		CreatedAt: ptypes.TimestampNow(),
+	})
+
+	if err = s.merchantRiskReviewRepository.Upsert(ctx, review); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorMerchantRiskReviewUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = review
+
+	return nil
+}
+
+// MonitorMerchantRisk computes chargeback and refund ratios, decline rates and sales spikes of merchants
+// for the monitoring period and reviews merchants exceeding thresholds. Returns count of reviewed merchants.
+func (s *Service) MonitorMerchantRisk(ctx context.Context) (int, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -int(s.cfg.MerchantRiskPeriod))
+	dayFrom := to.Add(-24 * time.Hour)
+
+	sales, err := s.orderViewRepository.GetMerchantRiskSales(ctx, from, to, dayFrom)
+
+	if err != nil {
+		return 0, err
+	}
+
+	declines, err := s.orderRepository.GetMerchantRiskDeclines(ctx, from, to)
+
+	if err != nil {
+		return 0, err
+	}
+
+	metrics := make(map[string]*pkg.MerchantRiskMetrics)
+	getMetrics := func(merchantId string) *pkg.MerchantRiskMetrics {
+		if _, ok := metrics[merchantId]; !ok {
+			metrics[merchantId] = &pkg.MerchantRiskMetrics{}
+		}
+
+		return metrics[merchantId]
+	}
+
+	for _, item := range sales {
+		m := getMetrics(item.Id.Hex())
+		m.SalesCount = item.SalesCount
+		m.RefundCount = item.RefundCount
+		m.ChargebackCount = item.ChargebackCount
+		m.SalesAmount = item.SalesAmount
+		m.DailySalesAmount = item.DailySalesAmount
+	}
+
+	for _, item := range declines {
+		m := getMetrics(item.Id.Hex())
+		m.AttemptCount = item.AttemptCount
+		m.DeclineCount = item.DeclineCount
+	}
+
+	merchantIds := make([]string, 0, len(metrics))
+
+	for merchantId := range metrics {
+		merchantIds = append(merchantIds, merchantId)
+	}
+
+	sort.Strings(merchantIds)
+	count := 0
+
+	for _, merchantId := range merchantIds {
+		m := metrics[merchantId]
+		setMerchantRiskRatios(m, s.cfg.MerchantRiskPeriod)
+		alerts := s.getMerchantRiskAlerts(m)
+
+		if len(alerts) <= 0 {
+			continue
+		}
+
+		_, err = s.reviewMerchantRisk(ctx, merchantId, from, to, m, alerts)
+
+		if err != nil {
+			zap.L().Error("Merchant risk review failed", zap.Error(err), zap.String("merchant_id", merchantId))
+			continue
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// isMerchantProcessingSuspended checks that processing of payments of the merchant is suspended
+// by the risk monitoring.
+func (s *Service) isMerchantProcessingSuspended(ctx context.Context, merchantId string) (bool, error) {
+	count, err := s.merchantRiskReviewRepository.FindCount(
+		ctx,
+		merchantId,
+		[]string{pkg.MerchantRiskReviewStatusSuspended},
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// reviewMerchantRisk opens the review of the merchant exceeding thresholds or updates the unresolved review
+// of the merchant, applies actions which aren't applied yet and notifies the merchant and risk managers
+// about the new review or new actions.
+func (s *Service) reviewMerchantRisk(
+	ctx context.Context,
+	merchantId string,
+	from, to time.Time,
+	metrics *pkg.MerchantRiskMetrics,
+	alerts []*pkg.MerchantRiskAlert,
+) (*pkg.MerchantRiskReview, error) {
+	merchant, err := s.merchantRepository.GetById(ctx, merchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.merchantRiskReviewRepository.GetUnresolvedByMerchantId(ctx, merchantId)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	isNew := review == nil
+
+	if isNew {
+		review = &pkg.MerchantRiskReview{
+			MerchantId:     merchantId,
+			OperationsType: merchant.MerchantOperationsType,
+			Status:         pkg.MerchantRiskReviewStatusOpen,
+			CreatedAt:      ptypes.TimestampNow(),
+		}
+	}
+
+	metrics.Currency = merchant.GetPayoutCurrency()
+	review.Metrics = metrics
+	review.Alerts = alerts
+	review.UpdatedAt = ptypes.TimestampNow()
+
+	if review.PeriodFrom, err = ptypes.TimestampProto(from); err != nil {
+		return nil, err
+	}
+
+	if review.PeriodTo, err = ptypes.TimestampProto(to); err != nil {
+		return nil, err
+	}
+
+	actions, err := s.applyMerchantRiskActions(ctx, merchant, s.getMerchantRiskActions(review, metrics))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if helper.Contains(actions, pkg.MerchantRiskActionSuspendProcessing) {
+		review.Status = pkg.MerchantRiskReviewStatusSuspended
+	}
+
+	if isNew || len(actions) > 0 {
+		review.Actions = append(review.Actions, actions...)
+		review.History = append(review.History, &pkg.MerchantRiskReviewEvent{
+			Status:    review.Status,
+			Actions:   actions,
+			CreatedAt: ptypes.TimestampNow(),
+		})
+	}
+
+	if err = s.merchantRiskReviewRepository.Upsert(ctx, review); err != nil {
+		return nil, err
+	}
+
+	if isNew || len(actions) > 0 {
+		s.notifyMerchantRisk(ctx, merchant, review, actions)
+	}
+
+	return review, nil
+}
+
+// getMerchantRiskActions returns configured actions which aren't applied by the review yet. Processing
+// of payments is suspended when the chargeback ratio reaches the suspension threshold.
+func (s *Service) getMerchantRiskActions(review *pkg.MerchantRiskReview, metrics *pkg.MerchantRiskMetrics) []string {
+	var actions []string
+
+	for _, action := range s.cfg.MerchantRiskActions {
+		if !helper.Contains(review.Actions, action) && !helper.Contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+
+	suspend := s.cfg.MerchantRiskSuspendChargebackRatio > 0 &&
+		metrics.SalesCount >= s.cfg.MerchantRiskMinSales &&
+		metrics.ChargebackRatio >= s.cfg.MerchantRiskSuspendChargebackRatio
+
+	if suspend &&
+		!helper.Contains(review.Actions, pkg.MerchantRiskActionSuspendProcessing) &&
+		!helper.Contains(actions, pkg.MerchantRiskActionSuspendProcessing) {
+		actions = append(actions, pkg.MerchantRiskActionSuspendProcessing)
+	}
+
+	return actions
+}
+
+// applyMerchantRiskActions changes settings of the merchant and returns actions which have changed them.
+// The suspension of processing is applied by the status of the review.
+func (s *Service) applyMerchantRiskActions(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	actions []string,
+) ([]string, error) {
+	var applied []string
+	isChanged := false
+
+	for _, action := range actions {
+		switch action {
+		case pkg.MerchantRiskActionHighRisk:
+			if merchant.MerchantOperationsType == pkg.MerchantOperationTypeHighRisk {
+				continue
+			}
+
+			mccCode, err := getMccByOperationsType(pkg.MerchantOperationTypeHighRisk)
+
+			if err != nil {
+				return nil, err
+			}
+
+			merchant.MerchantOperationsType = pkg.MerchantOperationTypeHighRisk
+			merchant.MccCode = mccCode
+			isChanged = true
+		case pkg.MerchantRiskActionRollingReserve:
+			if merchant.RollingReserveThreshold >= s.cfg.MerchantRiskRollingReserveThreshold &&
+				merchant.RollingReserveDays >= s.cfg.MerchantRiskRollingReserveDays {
+				continue
+			}
+
+			if merchant.RollingReserveThreshold < s.cfg.MerchantRiskRollingReserveThreshold {
+				merchant.RollingReserveThreshold = s.cfg.MerchantRiskRollingReserveThreshold
+			}
+
+			if merchant.RollingReserveDays < s.cfg.MerchantRiskRollingReserveDays {
+				merchant.RollingReserveDays = s.cfg.MerchantRiskRollingReserveDays
+			}
+
+			isChanged = true
+		case pkg.MerchantRiskActionManualPayouts:
+			// manual payouts are enabled after the update of other settings of the merchant
+			continue
+		case pkg.MerchantRiskActionSuspendProcessing:
+			// processing of payments is suspended by the status of the review
+		default:
+			zap.L().Warn("Unknown action of merchant risk monitoring", zap.String("action", action))
+			continue
+		}
+
+		applied = append(applied, action)
+	}
+
+	if isChanged {
+		merchant.UpdatedAt = ptypes.TimestampNow()
+
+		if err := s.merchantRepository.Update(ctx, merchant); err != nil {
+			return nil, err
+		}
+	}
+
+	if helper.Contains(actions, pkg.MerchantRiskActionManualPayouts) {
+		req := &billingpb.ChangeMerchantManualPayoutsRequest{
+			MerchantId:           merchant.Id,
+			ManualPayoutsEnabled: true,
+		}
+		rsp := &billingpb.ChangeMerchantManualPayoutsResponse{}
+
+		if err := s.ChangeMerchantManualPayouts(ctx, req, rsp); err != nil {
+			return nil, err
+		}
+
+		switch rsp.Status {
+		case billingpb.ResponseStatusOk:
+			merchant.ManualPayoutsEnabled = true
+			applied = append(applied, pkg.MerchantRiskActionManualPayouts)
+		case billingpb.ResponseStatusNotModified:
+			// manual payouts are already enabled for the merchant
+		default:
+			return nil, rsp.Message
+		}
+	}
+
+	return applied, nil
+}
+
+// getMerchantRiskAlerts returns metrics exceeding thresholds, ratios aren't checked for merchants with few sales.
+func (s *Service) getMerchantRiskAlerts(metrics *pkg.MerchantRiskMetrics) []*pkg.MerchantRiskAlert {
+	var alerts []*pkg.MerchantRiskAlert
+
+	check := func(metric string, value, threshold float64) {
+		if threshold > 0 && value > threshold {
+			alerts = append(alerts, &pkg.MerchantRiskAlert{Metric: metric, Value: value, Threshold: threshold})
+		}
+	}
+
+	if metrics.SalesCount >= s.cfg.MerchantRiskMinSales {
+		check(pkg.MerchantRiskMetricChargebackRatio, metrics.ChargebackRatio, s.cfg.MerchantRiskChargebackRatio)
+		check(pkg.MerchantRiskMetricRefundRatio, metrics.RefundRatio, s.cfg.MerchantRiskRefundRatio)
+	}
+
+	if metrics.AttemptCount >= s.cfg.MerchantRiskMinSales {
+		check(pkg.MerchantRiskMetricDeclineRate, metrics.DeclineRate, s.cfg.MerchantRiskDeclineRate)
+	}
+
+	check(pkg.MerchantRiskMetricSalesSpike, metrics.SalesSpike, s.cfg.MerchantRiskSalesSpike)
+
+	return alerts
+}
+
+func (s *Service) notifyMerchantRisk(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	review *pkg.MerchantRiskReview,
+	actions []string,
+) {
+	alertsText := getMerchantRiskAlertsText(review.Alerts)
+	msg := fmt.Sprintf(merchantRiskAlertMessage, alertsText)
+
+	if review.Status == pkg.MerchantRiskReviewStatusSuspended {
+		msg = fmt.Sprintf(merchantRiskSuspendedMessage, alertsText)
+	}
+
+	if _, err := s.addNotification(ctx, msg, merchant.Id, "", nil); err != nil {
+		zap.L().Error(
+			"Add merchant risk alert notification failed",
+			zap.Error(err),
+			zap.String("merchant_id", merchant.Id),
+		)
+	}
+
+	err := s.centrifugoDashboard.Publish(ctx, s.cfg.CentrifugoAdminChannel, merchantRiskReviewAlertMessage)
+
+	if err != nil {
+		zap.L().Error(
+			"Publication message about merchant risk alert to centrifugo failed",
+			zap.Error(err),
+			zap.String("merchant_id", merchant.Id),
+		)
+	}
+
+	model := map[string]string{
+		"merchant_id":   merchant.Id,
+		"merchant_name": merchant.GetCompanyName(),
+		"review_id":     review.Id,
+		"status":        review.Status,
+		"alerts":        alertsText,
+		"actions":       strings.Join(actions, ", "),
+	}
+
+	s.sendMerchantRiskEmail(merchant, s.cfg.EmailTemplates.MerchantRiskAlert, merchant.GetAuthorizedEmail(), model)
+
+	if s.cfg.EmailRiskManagerRecipient != "" {
+		s.sendMerchantRiskEmail(
+			merchant,
+			s.cfg.EmailTemplates.RiskManagerMerchantRiskAlert,
+			s.cfg.EmailRiskManagerRecipient,
+			model,
+		)
+	}
+}
+
+func (s *Service) sendMerchantRiskEmail(
+	merchant *billingpb.Merchant,
+	template, recipientEmail string,
+	model map[string]string,
+) {
+	if recipientEmail == "" {
+		return
+	}
+
+	payload := &postmarkpb.Payload{
+		TemplateAlias: template,
+		TemplateModel: model,
+		To:            recipientEmail,
+	}
+
+	err := s.postmarkBroker.Publish(postmarkpb.PostmarkSenderTopicName, payload, amqp.Table{})
+
+	if err != nil {
+		zap.L().Error(
+			"Publication message about merchant risk alert to queue failed",
+			zap.Error(err),
+			zap.String("merchant_id", merchant.Id),
+			zap.String("template", template),
+		)
+	}
+}
+
+// setMerchantRiskRatios computes ratios of the merchant. The sales spike is a ratio of sales of the last day
+// to average daily sales of previous days of the period, it isn't computed for merchants without previous sales.
+func setMerchantRiskRatios(metrics *pkg.MerchantRiskMetrics, period int64) {
+	if metrics.SalesCount > 0 {
+		metrics.ChargebackRatio = float64(metrics.ChargebackCount) / float64(metrics.SalesCount)
+		metrics.RefundRatio = float64(metrics.RefundCount) / float64(metrics.SalesCount)
+	}
+
+	if metrics.AttemptCount > 0 {
+		metrics.DeclineRate = float64(metrics.DeclineCount) / float64(metrics.AttemptCount)
+	}
+
+	if period > 1 {
+		metrics.AverageDailySalesAmount = (metrics.SalesAmount - metrics.DailySalesAmount) / float64(period-1)
+	}
+
+	if metrics.AverageDailySalesAmount > 0 {
+		metrics.SalesSpike = metrics.DailySalesAmount / metrics.AverageDailySalesAmount
+	}
+}
+
+func getMerchantRiskAlertsText(alerts []*pkg.MerchantRiskAlert) string {
+	texts := make([]string, len(alerts))
+
+	for i, alert := range alerts {
+		if alert.Metric == pkg.MerchantRiskMetricSalesSpike {
+			texts[i] = fmt.Sprintf("%s %.1fx (threshold %.1fx)", alert.Metric, alert.Value, alert.Threshold)
+			continue
+		}
+
+		texts[i] = fmt.Sprintf("%s %.2f%% (threshold %.2f%%)", alert.Metric, alert.Value*100, alert.Threshold*100)
+	}
+
+	return strings.Join(texts, ", ")
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	intPkg "github.com/paysuper/paysuper-billing-server/internal/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+type MerchantRiskTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant *billingpb.Merchant
+	project  *billingpb.Project
+}
+
+func Test_MerchantRisk(t *testing.T) {
+	suite.Run(t, new(MerchantRiskTestSuite))
+}
+
+func (suite *MerchantRiskTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+	suite.service.rep = mocks.NewRepositoryServiceEmpty()
+
+	suite.merchant, suite.project, _, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+
+	centrifugoMock := &mocks.CentrifugoInterface{}
+	centrifugoMock.On("Publish", mock2.Anything, mock2.Anything, mock2.Anything).Return(nil)
+	suite.service.centrifugoDashboard = centrifugoMock
+
+	suite.service.cfg.MerchantRiskActions = []string{
+		pkg.MerchantRiskActionHighRisk,
+		pkg.MerchantRiskActionRollingReserve,
+		pkg.MerchantRiskActionManualPayouts,
+	}
+}
+
+func (suite *MerchantRiskTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *MerchantRiskTestSuite) helperMockStats(
+	sales *intPkg.MerchantRiskSalesQueryResItem,
+	declines *intPkg.MerchantRiskDeclineQueryResItem,
+) {
+	oid, err := primitive.ObjectIDFromHex(suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	var salesItems []*intPkg.MerchantRiskSalesQueryResItem
+	var declineItems []*intPkg.MerchantRiskDeclineQueryResItem
+
+	if sales != nil {
+		sales.Id = oid
+		salesItems = append(salesItems, sales)
+	}
+
+	if declines != nil {
+		declines.Id = oid
+		declineItems = append(declineItems, declines)
+	}
+
+	ovr := &mocks.OrderViewRepositoryInterface{}
+	ovr.On("GetMerchantRiskSales", mock2.Anything, mock2.Anything, mock2.Anything, mock2.Anything).
+		Return(salesItems, nil)
+	suite.service.orderViewRepository = ovr
+
+	or := &mocks.OrderRepositoryInterface{}
+	or.On("GetMerchantRiskDeclines", mock2.Anything, mock2.Anything, mock2.Anything).Return(declineItems, nil)
+	suite.service.orderRepository = or
+}
+
+func (suite *MerchantRiskTestSuite) helperGetReview() *pkg.MerchantRiskReview {
+	review, err := suite.service.merchantRiskReviewRepository.GetUnresolvedByMerchantId(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	return review
+}
+
+func (suite *MerchantRiskTestSuite) TestMerchantRisk_MonitorMerchantRisk_ChargebackRatio_Actions() {
+	notificationsBefore, err := suite.service.notificationRepository.FindCount(context.TODO(), suite.merchant.Id, "", 0)
+	assert.NoError(suite.T(), err)
+
+	suite.helperMockStats(&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 200, ChargebackCount: 3}, nil)
+
+	count, err := suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	review := suite.helperGetReview()
+	assert.Equal(suite.T(), pkg.MerchantRiskReviewStatusOpen, review.Status)
+	assert.Equal(suite.T(), pkg.MerchantOperationTypeLowRisk, review.OperationsType)
+	assert.Equal(suite.T(), suite.merchant.GetPayoutCurrency(), review.Metrics.Currency)
+	assert.InDelta(suite.T(), 0.015, review.Metrics.ChargebackRatio, 0.0001)
+	assert.Len(suite.T(), review.Alerts, 1)
+	assert.Equal(suite.T(), pkg.MerchantRiskMetricChargebackRatio, review.Alerts[0].Metric)
+	assert.Equal(suite.T(), suite.service.cfg.MerchantRiskActions, review.Actions)
+	assert.Len(suite.T(), review.History, 1)
+
+	merchant, err := suite.service.merchantRepository.GetById(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.MerchantOperationTypeHighRisk, merchant.MerchantOperationsType)
+	assert.Equal(suite.T(), billingpb.MccCodeHighRisk, merchant.MccCode)
+	assert.Equal(suite.T(), suite.service.cfg.MerchantRiskRollingReserveThreshold, merchant.RollingReserveThreshold)
+	assert.Equal(suite.T(), suite.service.cfg.MerchantRiskRollingReserveDays, merchant.RollingReserveDays)
+	assert.True(suite.T(), merchant.ManualPayoutsEnabled)
+
+	notifications, err := suite.service.notificationRepository.FindCount(context.TODO(), suite.merchant.Id, "", 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), notificationsBefore+1, notifications)
+
+	suspended, err := suite.service.isMerchantProcessingSuspended(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), suspended)
+}
+
+func (suite *MerchantRiskTestSuite) TestMerchantRisk_MonitorMerchantRisk_UpdatesUnresolvedReview() {
+	notificationsBefore, err := suite.service.notificationRepository.FindCount(context.TODO(), suite.merchant.Id, "", 0)
+	assert.NoError(suite.T(), err)
+
+	suite.helperMockStats(&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 200, RefundCount: 30}, nil)
+
+	_, err = suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+	review1 := suite.helperGetReview()
+
+	suite.helperMockStats(&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 200, RefundCount: 40}, nil)
+
+	count, err := suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	review2 := suite.helperGetReview()
+	assert.Equal(suite.T(), review1.Id, review2.Id)
+	assert.InDelta(suite.T(), 0.2, review2.Metrics.RefundRatio, 0.0001)
+	assert.Len(suite.T(), review2.History, 1)
+
+	reviews, err := suite.service.merchantRiskReviewRepository.FindCount(context.TODO(), suite.merchant.Id, nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), reviews)
+
+	notifications, err := suite.service.notificationRepository.FindCount(context.TODO(), suite.merchant.Id, "", 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), notificationsBefore+1, notifications)
+}
+
+func (suite *MerchantRiskTestSuite) TestMerchantRisk_MonitorMerchantRisk_SuspendProcessing() {
+	orderRepository := suite.service.orderRepository
+	suite.helperMockStats(&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 200, ChargebackCount: 3}, nil)
+
+	_, err := suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.MerchantRiskReviewStatusOpen, suite.helperGetReview().Status)
+
+	suite.helperMockStats(&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 200, ChargebackCount: 5}, nil)
+
+	_, err = suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	review := suite.helperGetReview()
+	assert.Equal(suite.T(), pkg.MerchantRiskReviewStatusSuspended, review.Status)
+	assert.Contains(suite.T(), review.Actions, pkg.MerchantRiskActionSuspendProcessing)
+	assert.Len(suite.T(), review.History, 2)
+	assert.Equal(suite.T(), []string{pkg.MerchantRiskActionSuspendProcessing}, review.History[1].Actions)
+
+	suspended, err := suite.service.isMerchantProcessingSuspended(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), suspended)
+
+	suite.service.orderRepository = orderRepository
+	req := &billingpb.OrderCreateRequest{
+		Type:        pkg.OrderType_simple,
+		ProjectId:   suite.project.Id,
+		Currency:    "RUB",
+		Amount:      100,
+		Account:     "unit test",
+		Description: "unit test",
+		OrderId:     primitive.NewObjectID().Hex(),
+		User: &billingpb.OrderUser{
+			Email: "some_email@unit.com",
+			Ip:    "127.0.0.1",
+		},
+	}
+	rsp := &billingpb.OrderCreateProcessResponse{}
+	err = suite.service.OrderCreateProcess(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), orderErrorMerchantProcessingSuspended, rsp.Message)
+
+	res := &pkg.MerchantRiskReviewResponse{}
+	err = suite.service.ResolveMerchantRiskReview(context.TODO(), &pkg.ResolveMerchantRiskReviewRequest{
+		Id:      review.Id,
+		UserId:  primitive.NewObjectID().Hex(),
+		Comment: "chargebacks of fraud attack are disputed",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.MerchantRiskReviewStatusResolved, res.Item.Status)
+	assert.NotNil(suite.T(), res.Item.ResolvedAt)
+
+	suspended, err = suite.service.isMerchantProcessingSuspended(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), suspended)
+}
+
+func (suite *MerchantRiskTestSuite) TestMerchantRisk_MonitorMerchantRisk_DeclineRateAndSalesSpike() {
+	suite.helperMockStats(
+		&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 150, SalesAmount: 1450, DailySalesAmount: 1000},
+		&intPkg.MerchantRiskDeclineQueryResItem{AttemptCount: 400, DeclineCount: 250},
+	)
+
+	count, err := suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	review := suite.helperGetReview()
+	assert.Len(suite.T(), review.Alerts, 2)
+	assert.Equal(suite.T(), pkg.MerchantRiskMetricDeclineRate, review.Alerts[0].Metric)
+	assert.InDelta(suite.T(), 0.625, review.Alerts[0].Value, 0.0001)
+	assert.Equal(suite.T(), pkg.MerchantRiskMetricSalesSpike, review.Alerts[1].Metric)
+	assert.InDelta(suite.T(), 1000/(450/float64(suite.service.cfg.MerchantRiskPeriod-1)), review.Alerts[1].Value, 0.0001)
+}
+
+func (suite *MerchantRiskTestSuite) TestMerchantRisk_MonitorMerchantRisk_FewSales_NoAlerts() {
+	suite.helperMockStats(
+		&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 10, ChargebackCount: 5, RefundCount: 5},
+		&intPkg.MerchantRiskDeclineQueryResItem{AttemptCount: 20, DeclineCount: 10},
+	)
+
+	count, err := suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, count)
+
+	reviews, err := suite.service.merchantRiskReviewRepository.FindCount(context.TODO(), suite.merchant.Id, nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), reviews)
+
+	merchant, err := suite.service.merchantRepository.GetById(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.MerchantOperationTypeLowRisk, merchant.MerchantOperationsType)
+	assert.False(suite.T(), merchant.ManualPayoutsEnabled)
+}
+
+func (suite *MerchantRiskTestSuite) TestMerchantRisk_ListMerchantRiskReviews_Ok() {
+	suite.helperMockStats(&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 200, ChargebackCount: 3}, nil)
+
+	_, err := suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	res := &pkg.ListMerchantRiskReviewsResponse{}
+	err = suite.service.ListMerchantRiskReviews(context.TODO(), &pkg.ListMerchantRiskReviewsRequest{
+		MerchantId: suite.merchant.Id,
+		Status:     pkg.MerchantRiskReviewStatusOpen,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), int64(1), res.Count)
+	assert.Len(suite.T(), res.Items, 1)
+
+	res = &pkg.ListMerchantRiskReviewsResponse{}
+	err = suite.service.ListMerchantRiskReviews(context.TODO(), &pkg.ListMerchantRiskReviewsRequest{
+		MerchantId: suite.merchant.Id,
+		Status:     pkg.MerchantRiskReviewStatusResolved,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), int64(0), res.Count)
+	assert.Empty(suite.T(), res.Items)
+}
+
+func (suite *MerchantRiskTestSuite) TestMerchantRisk_ResolveMerchantRiskReview_Error() {
+	suite.helperMockStats(&intPkg.MerchantRiskSalesQueryResItem{SalesCount: 200, ChargebackCount: 3}, nil)
+
+	_, err := suite.service.MonitorMerchantRisk(context.TODO())
+	assert.NoError(suite.T(), err)
+	review := suite.helperGetReview()
+
+	res := &pkg.MerchantRiskReviewResponse{}
+	err = suite.service.ResolveMerchantRiskReview(context.TODO(), &pkg.ResolveMerchantRiskReviewRequest{Id: review.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorMerchantRiskReviewCommentRequired, res.Message)
+
+	res = &pkg.MerchantRiskReviewResponse{}
+	err = suite.service.ResolveMerchantRiskReview(context.TODO(), &pkg.ResolveMerchantRiskReviewRequest{
+		Id:      primitive.NewObjectID().Hex(),
+		Comment: "comment",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorMerchantRiskReviewNotFound, res.Message)
+
+	req := &pkg.ResolveMerchantRiskReviewRequest{Id: review.Id, Comment: "comment"}
+	res = &pkg.MerchantRiskReviewResponse{}
+	err = suite.service.ResolveMerchantRiskReview(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	res = &pkg.MerchantRiskReviewResponse{}
+	err = suite.service.ResolveMerchantRiskReview(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorMerchantRiskReviewAlreadyResolved, res.Message)
+}
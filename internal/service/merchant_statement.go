@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+const (
+	merchantStatementParamsFieldMerchantId = "merchant_id"
+	merchantStatementParamsFieldFrom       = "from"
+	merchantStatementParamsFieldTo         = "to"
+)
+
+var (
+	merchantStatementErrorPeriodInvalid   = newBillingServerErrorMsg("ms000001", "merchant statement period is invalid")
+	merchantStatementErrorFileTypeInvalid = newBillingServerErrorMsg("ms000002", "merchant statement file type is not supported")
+	merchantStatementErrorUnknown         = newBillingServerErrorMsg("ms000003", "unknown error. try request later")
+
+	royaltyReportStatusForStatement = []string{
+		billingpb.RoyaltyReportStatusAccepted,
+		billingpb.RoyaltyReportStatusWaitForPayment,
+		billingpb.RoyaltyReportStatusPaid,
+	}
+	payoutDocumentStatusForStatement = []string{
+		pkg.PayoutDocumentStatusPending,
+		pkg.PayoutDocumentStatusInBatch,
+		pkg.PayoutDocumentStatusPaid,
+	}
+
+	// order of statement items with the same date
+	merchantStatementItemTypeOrder = map[string]int{
+		pkg.MerchantStatementItemTypeRoyaltyReport:            0,
+		pkg.MerchantStatementItemTypeCorrection:               1,
+		pkg.MerchantStatementItemTypeRollingReserve:           2,
+		pkg.MerchantStatementItemTypePayout:                   3,
+		pkg.MerchantStatementItemTypeRollingReserveSettlement: 4,
+	}
+)
+
+type merchantStatementItem struct {
+	*pkg.MerchantStatementItem
+	date time.Time
+}
+
+// GetMerchantStatement returns statement of account of the merchant for the period with opening balance,
+// royalty reports, corrections, rolling reserve movements and payout documents ordered by date
+// with running and closing balance in the payout currency of merchant.
+func (s *Service) GetMerchantStatement(
+	ctx context.Context,
+	req *pkg.GetMerchantStatementRequest,
+	rsp *pkg.GetMerchantStatementResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if merchant.GetPayoutCurrency() == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = errorMerchantPayoutCurrencyNotSet
+		return nil
+	}
+
+	from, to := time.Unix(req.From, 0), time.Now()
+
+	if req.To > 0 {
+		to = time.Unix(req.To, 0)
+	}
+
+	if req.From < 0 || from.After(to) {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantStatementErrorPeriodInvalid
+		return nil
+	}
+
+	rsp.Item, err = s.getMerchantStatement(ctx, merchant, from, to)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantStatementErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// CreateMerchantStatementFile requests rendering of the merchant statement of account to PDF or CSV file
+// in the reporting service.
+func (s *Service) CreateMerchantStatementFile(
+	ctx context.Context,
+	req *pkg.CreateMerchantStatementFileRequest,
+	rsp *billingpb.ResponseError,
+) error {
+	if req.FileType != reporterpb.OutputExtensionPdf && req.FileType != reporterpb.OutputExtensionCsv {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantStatementErrorFileTypeInvalid
+		return nil
+	}
+
+	if req.From < 0 || (req.To > 0 && req.From > req.To) {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = merchantStatementErrorPeriodInvalid
+		return nil
+	}
+
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	params, err := json.Marshal(map[string]interface{}{
+		merchantStatementParamsFieldMerchantId: merchant.Id,
+		merchantStatementParamsFieldFrom:       req.From,
+		merchantStatementParamsFieldTo:         req.To,
+	})
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to marshal the params of merchant statement for the reporting service.",
+			zap.Error(err),
+		)
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantStatementErrorUnknown
+		return nil
+	}
+
+	userId := req.UserId
+
+	if userId == "" && merchant.User != nil {
+		userId = merchant.User.Id
+	}
+
+	fileReq := &reporterpb.ReportFile{
+		UserId:           userId,
+		MerchantId:       merchant.Id,
+		ReportType:       pkg.ReportTypeMerchantStatement,
+		FileType:         req.FileType,
+		Params:           params,
+		SendNotification: true,
+	}
+
+	if _, err = s.reporterService.CreateFile(ctx, fileReq); err != nil {
+		zap.L().Error(
+			"Unable to create file in the reporting service for merchant statement.",
+			zap.Error(err),
+		)
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = merchantStatementErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) getMerchantStatement(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	from, to time.Time,
+) (*pkg.MerchantStatement, error) {
+	items, err := s.getMerchantStatementItems(ctx, merchant.Id, merchant.GetPayoutCurrency(), to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &pkg.MerchantStatement{
+		MerchantId: merchant.Id,
+		Currency:   merchant.GetPayoutCurrency(),
+		Items:      []*pkg.MerchantStatementItem{},
+		CreatedAt:  ptypes.TimestampNow(),
+	}
+
+	if statement.From, err = ptypes.TimestampProto(from); err != nil {
+		return nil, err
+	}
+
+	if statement.To, err = ptypes.TimestampProto(to); err != nil {
+		return nil, err
+	}
+
+	// balance is calculated from the first movement of the merchant to get correct opening balance of the period
+	balance := float64(0)
+
+	for _, item := range items {
+		balance = tools.ToPrecise(balance + item.Amount)
+		item.Balance = balance
+
+		if item.date.Before(from) {
+			statement.OpeningBalance = balance
+			continue
+		}
+
+		if item.Amount > 0 {
+			statement.TotalDebit += item.Amount
+		} else {
+			statement.TotalCredit -= item.Amount
+		}
+
+		statement.Items = append(statement.Items, item.MerchantStatementItem)
+	}
+
+	statement.ClosingBalance = balance
+	statement.TotalDebit = tools.ToPrecise(statement.TotalDebit)
+	statement.TotalCredit = tools.ToPrecise(statement.TotalCredit)
+
+	return statement, nil
+}
+
+// getMerchantStatementItems returns all movements of the merchant balance until the specified date.
+// Items are calculated by the same rules as merchant balance: royalty reports and payout documents are counted
+// in active statuses only and rolling reserves created before the payout document are settled with the payout.
+func (s *Service) getMerchantStatementItems(
+	ctx context.Context,
+	merchantId, currency string,
+	to time.Time,
+) ([]*merchantStatementItem, error) {
+	var items []*merchantStatementItem
+
+	reports, err := s.royaltyReportRepository.FindByMerchantStatusDates(
+		ctx, merchantId, royaltyReportStatusForStatement, 0, 0, 0, 0,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, report := range reports {
+		if report.Currency != currency {
+			continue
+		}
+
+		reportItems, err := getMerchantStatementRoyaltyReportItems(report)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, reportItems...)
+	}
+
+	payouts, err := s.payoutRepository.Find(ctx, merchantId, payoutDocumentStatusForStatement, 0, 0, 0, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pd := range payouts {
+		if pd.Currency != currency {
+			continue
+		}
+
+		date, err := ptypes.Timestamp(pd.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, newMerchantStatementItem(
+			pkg.MerchantStatementItemTypePayout,
+			pd.Id,
+			fmt.Sprintf("Payout document %s", pd.Id),
+			-pd.TotalFees,
+			date,
+		))
+	}
+
+	reserves, err := s.accountingRepository.GetRollingReservesForRoyaltyReport(
+		ctx, merchantId, currency, accountingEntriesForRollingReserve, time.Time{}, to,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range reserves {
+		date, err := ptypes.Timestamp(entry.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		amount := -entry.Amount
+		description := "Rolling reserve"
+
+		if entry.Type == pkg.AccountingEntryTypeMerchantRollingReserveRelease {
+			amount = entry.Amount
+			description = "Rolling reserve release"
+		}
+
+		items = append(items, newMerchantStatementItem(
+			pkg.MerchantStatementItemTypeRollingReserve,
+			entry.Id,
+			description,
+			amount,
+			date,
+		))
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].date.Equal(items[j].date) {
+			return merchantStatementItemTypeOrder[items[i].Type] < merchantStatementItemTypeOrder[items[j].Type]
+		}
+
+		return items[i].date.Before(items[j].date)
+	})
+
+	var result []*merchantStatementItem
+	reserve := float64(0)
+
+	for _, item := range items {
+		if item.date.After(to) {
+			break
+		}
+
+		result = append(result, item)
+
+		switch item.Type {
+		case pkg.MerchantStatementItemTypeRollingReserve:
+			reserve += item.Amount
+		case pkg.MerchantStatementItemTypePayout:
+			if tools.ToPrecise(reserve) == 0 {
+				continue
+			}
+
+			result = append(result, newMerchantStatementItem(
+				pkg.MerchantStatementItemTypeRollingReserveSettlement,
+				item.SourceId,
+				fmt.Sprintf("Rolling reserve settled with payout document %s", item.SourceId),
+				-reserve,
+				item.date,
+			))
+			reserve = 0
+		}
+	}
+
+	return result, nil
+}
+
+func getMerchantStatementRoyaltyReportItems(report *billingpb.RoyaltyReport) ([]*merchantStatementItem, error) {
+	// royalty report affects merchant balance after acceptance
+	ts := report.PeriodTo
+
+	if report.AcceptedAt != nil && report.AcceptedAt.Seconds > 0 {
+		ts = report.AcceptedAt
+	}
+
+	date, err := ptypes.Timestamp(ts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	totals := report.Totals
+
+	if totals == nil {
+		totals = &billingpb.RoyaltyReportTotals{}
+	}
+
+	items := []*merchantStatementItem{
+		newMerchantStatementItem(
+			pkg.MerchantStatementItemTypeRoyaltyReport,
+			report.Id,
+			fmt.Sprintf("Royalty report %s", report.Id),
+			totals.PayoutAmount,
+			date,
+		),
+	}
+
+	corrections := float64(0)
+
+	if report.Summary != nil {
+		for _, correction := range report.Summary.Corrections {
+			items = append(items, newMerchantStatementItem(
+				pkg.MerchantStatementItemTypeCorrection,
+				correction.AccountingEntryId,
+				correction.Reason,
+				-correction.Amount,
+				date,
+			))
+			corrections += correction.Amount
+		}
+	}
+
+	// keep statement consistent with the report totals which are used for merchant balance
+	if diff := tools.ToPrecise(totals.CorrectionAmount - corrections); diff != 0 {
+		items = append(items, newMerchantStatementItem(
+			pkg.MerchantStatementItemTypeCorrection,
+			report.Id,
+			fmt.Sprintf("Corrections of royalty report %s", report.Id),
+			-diff,
+			date,
+		))
+	}
+
+	return items, nil
+}
+
+func newMerchantStatementItem(
+	itemType, sourceId, description string,
+	amount float64,
+	date time.Time,
+) *merchantStatementItem {
+	ts, _ := ptypes.TimestampProto(date)
+
+	return &merchantStatementItem{
+		MerchantStatementItem: &pkg.MerchantStatementItem{
+			Type:        itemType,
+			SourceId:    sourceId,
+			Description: description,
+			Date:        ts,
+			Amount:      tools.ToPrecise(amount),
+		},
+		date: date,
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/reporterpb"
+	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (suite *MerchantBalanceTestSuite) createStatementRoyaltyReport(amount, correction float64) {
+	report := &billingpb.RoyaltyReport{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: suite.merchant.Id,
+		Totals: &billingpb.RoyaltyReportTotals{
+			TransactionsCount: 10,
+			PayoutAmount:      amount,
+			CorrectionAmount:  correction,
+		},
+		Summary:        &billingpb.RoyaltyReportSummary{},
+		Status:         billingpb.RoyaltyReportStatusAccepted,
+		CreatedAt:      ptypes.TimestampNow(),
+		PeriodFrom:     ptypes.TimestampNow(),
+		PeriodTo:       ptypes.TimestampNow(),
+		AcceptedAt:     ptypes.TimestampNow(),
+		AcceptExpireAt: ptypes.TimestampNow(),
+		Currency:       suite.merchant.GetPayoutCurrency(),
+	}
+
+	if correction != 0 {
+		report.Summary.Corrections = []*billingpb.RoyaltyReportCorrectionItem{
+			{
+				AccountingEntryId: primitive.NewObjectID().Hex(),
+				Amount:            correction,
+				Reason:            "unit-test",
+				EntryDate:         ptypes.TimestampNow(),
+			},
+		}
+	}
+
+	err := suite.service.royaltyReportRepository.Insert(ctx, report, "", pkg.RoyaltyReportChangeSourceAuto)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *MerchantBalanceTestSuite) createStatementPayout(amount float64, createdAt time.Time) {
+	date, err := ptypes.TimestampProto(createdAt)
+	assert.NoError(suite.T(), err)
+
+	payout := &billingpb.PayoutDocument{
+		Id:          primitive.NewObjectID().Hex(),
+		MerchantId:  suite.merchant.Id,
+		SourceId:    []string{primitive.NewObjectID().Hex()},
+		TotalFees:   amount,
+		Balance:     amount,
+		Currency:    suite.merchant.GetPayoutCurrency(),
+		Status:      pkg.PayoutDocumentStatusPending,
+		Description: "test payout document",
+		Destination: suite.merchant.Banking,
+		CreatedAt:   date,
+		UpdatedAt:   ptypes.TimestampNow(),
+		ArrivalDate: ptypes.TimestampNow(),
+	}
+	err = suite.service.payoutRepository.Insert(ctx, payout, "127.0.0.1", payoutChangeSourceAdmin)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *MerchantBalanceTestSuite) createStatementRollingReserve(entryType string, amount float64, createdAt time.Time) {
+	date, err := ptypes.TimestampProto(createdAt)
+	assert.NoError(suite.T(), err)
+
+	ae := &billingpb.AccountingEntry{
+		Id:     primitive.NewObjectID().Hex(),
+		Type:   entryType,
+		Object: pkg.ObjectTypeBalanceTransaction,
+		Source: &billingpb.AccountingEntrySource{
+			Type: "merchant",
+			Id:   suite.merchant.Id,
+		},
+		MerchantId: suite.merchant.Id,
+		Amount:     amount,
+		Currency:   suite.merchant.GetPayoutCurrency(),
+		CreatedAt:  date,
+	}
+	err = suite.service.accountingRepository.MultipleInsert(ctx, []*billingpb.AccountingEntry{ae})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *MerchantBalanceTestSuite) TestMerchantStatement_GetMerchantStatement_Ok_AgreeWithBalance() {
+	suite.createStatementPayout(1000, time.Now().Add(-480*time.Hour))
+	suite.createStatementRoyaltyReport(1234.5, 10)
+	suite.createStatementRollingReserve(pkg.AccountingEntryTypeMerchantRollingReserveCreate, 150, time.Now())
+	suite.createStatementRollingReserve(pkg.AccountingEntryTypeMerchantRollingReserveRelease, 50, time.Now())
+
+	mb, err := suite.service.updateMerchantBalance(ctx, suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	req := &pkg.GetMerchantStatementRequest{MerchantId: suite.merchant.Id}
+	rsp := &pkg.GetMerchantStatementResponse{}
+	err = suite.service.GetMerchantStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), suite.merchant.GetPayoutCurrency(), rsp.Item.Currency)
+	assert.EqualValues(suite.T(), 0, rsp.Item.OpeningBalance)
+	assert.EqualValues(suite.T(), mb.Total, rsp.Item.ClosingBalance)
+	assert.EqualValues(suite.T(), 124.5, rsp.Item.ClosingBalance)
+	assert.Len(suite.T(), rsp.Item.Items, 5)
+
+	assert.Equal(suite.T(), pkg.MerchantStatementItemTypePayout, rsp.Item.Items[0].Type)
+	assert.EqualValues(suite.T(), -1000, rsp.Item.Items[0].Amount)
+	assert.EqualValues(suite.T(), -1000, rsp.Item.Items[0].Balance)
+	assert.Equal(suite.T(), pkg.MerchantStatementItemTypeRoyaltyReport, rsp.Item.Items[1].Type)
+	assert.EqualValues(suite.T(), 1234.5, rsp.Item.Items[1].Amount)
+	assert.Equal(suite.T(), pkg.MerchantStatementItemTypeCorrection, rsp.Item.Items[2].Type)
+	assert.EqualValues(suite.T(), -10, rsp.Item.Items[2].Amount)
+	assert.Equal(suite.T(), pkg.MerchantStatementItemTypeRollingReserve, rsp.Item.Items[3].Type)
+	assert.Equal(suite.T(), pkg.MerchantStatementItemTypeRollingReserve, rsp.Item.Items[4].Type)
+	assert.EqualValues(suite.T(), rsp.Item.ClosingBalance, rsp.Item.Items[4].Balance)
+
+	assert.EqualValues(suite.T(), 1284.5, rsp.Item.TotalDebit)
+	assert.EqualValues(suite.T(), 1160, rsp.Item.TotalCredit)
+
+	req.From = time.Now().Add(-time.Hour).Unix()
+	err = suite.service.GetMerchantStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), -1000, rsp.Item.OpeningBalance)
+	assert.EqualValues(suite.T(), mb.Total, rsp.Item.ClosingBalance)
+	assert.Len(suite.T(), rsp.Item.Items, 4)
+}
+
+func (suite *MerchantBalanceTestSuite) TestMerchantStatement_GetMerchantStatement_Ok_RollingReserveSettledWithPayout() {
+	suite.createStatementRollingReserve(pkg.AccountingEntryTypeMerchantRollingReserveCreate, 100, time.Now().Add(-500*time.Hour))
+	suite.createStatementPayout(1000, time.Now().Add(-480*time.Hour))
+	suite.createStatementRoyaltyReport(1234.5, 0)
+
+	mb, err := suite.service.updateMerchantBalance(ctx, suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+
+	req := &pkg.GetMerchantStatementRequest{MerchantId: suite.merchant.Id}
+	rsp := &pkg.GetMerchantStatementResponse{}
+	err = suite.service.GetMerchantStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), mb.Total, rsp.Item.ClosingBalance)
+	assert.EqualValues(suite.T(), 234.5, rsp.Item.ClosingBalance)
+	assert.Len(suite.T(), rsp.Item.Items, 4)
+	assert.Equal(suite.T(), pkg.MerchantStatementItemTypeRollingReserveSettlement, rsp.Item.Items[2].Type)
+	assert.EqualValues(suite.T(), 100, rsp.Item.Items[2].Amount)
+
+	// statement for the period before royalty report acceptance
+	req.To = time.Now().Add(-time.Hour).Unix()
+	err = suite.service.GetMerchantStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.EqualValues(suite.T(), -1000, rsp.Item.ClosingBalance)
+	assert.Len(suite.T(), rsp.Item.Items, 3)
+}
+
+func (suite *MerchantBalanceTestSuite) TestMerchantStatement_GetMerchantStatement_Errors() {
+	req := &pkg.GetMerchantStatementRequest{MerchantId: primitive.NewObjectID().Hex()}
+	rsp := &pkg.GetMerchantStatementResponse{}
+	err := suite.service.GetMerchantStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), merchantErrorNotFound, rsp.Message)
+
+	req.MerchantId = suite.merchant2.Id
+	err = suite.service.GetMerchantStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), errorMerchantPayoutCurrencyNotSet, rsp.Message)
+
+	req.MerchantId = suite.merchant.Id
+	req.From = time.Now().Unix()
+	req.To = time.Now().Add(-time.Hour).Unix()
+	err = suite.service.GetMerchantStatement(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantStatementErrorPeriodInvalid, rsp.Message)
+}
+
+func (suite *MerchantBalanceTestSuite) TestMerchantStatement_CreateMerchantStatementFile_Ok() {
+	reporterMock := &reportingMocks.ReporterService{}
+	reporterMock.On("CreateFile", mock.Anything, mock.Anything, mock.Anything).
+		Return(&reporterpb.CreateFileResponse{Status: billingpb.ResponseStatusOk}, nil)
+	suite.service.reporterService = reporterMock
+
+	req := &pkg.CreateMerchantStatementFileRequest{
+		MerchantId: suite.merchant.Id,
+		From:       time.Now().Add(-24 * time.Hour).Unix(),
+		FileType:   reporterpb.OutputExtensionCsv,
+	}
+	rsp := &billingpb.ResponseError{}
+	err := suite.service.CreateMerchantStatementFile(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	fileReq := reporterMock.Calls[0].Arguments.Get(1).(*reporterpb.ReportFile)
+	assert.Equal(suite.T(), pkg.ReportTypeMerchantStatement, fileReq.ReportType)
+	assert.Equal(suite.T(), reporterpb.OutputExtensionCsv, fileReq.FileType)
+	assert.Equal(suite.T(), suite.merchant.Id, fileReq.MerchantId)
+}
+
+func (suite *MerchantBalanceTestSuite) TestMerchantStatement_CreateMerchantStatementFile_Errors() {
+	reporterMock := &reportingMocks.ReporterService{}
+	reporterMock.On("CreateFile", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("some error"))
+	suite.service.reporterService = reporterMock
+
+	req := &pkg.CreateMerchantStatementFileRequest{
+		MerchantId: suite.merchant.Id,
+		FileType:   "xml",
+	}
+	rsp := &billingpb.ResponseError{}
+	err := suite.service.CreateMerchantStatementFile(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), merchantStatementErrorFileTypeInvalid, rsp.Message)
+
+	req.FileType = reporterpb.OutputExtensionPdf
+	err = suite.service.CreateMerchantStatementFile(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusSystemError, rsp.Status)
+	assert.Equal(suite.T(), merchantStatementErrorUnknown, rsp.Message)
+}
@@ -63,6 +63,8 @@ func NewCardPayMock() Gate {
 			},
 			nil,
 		)
+	cpMock.On("CapturePayment", mock.Anything).Return(nil)
+	cpMock.On("CancelPayment", mock.Anything).Return(nil)
 	cpMock.On("IsRecurringCallback", mock.Anything).Return(false)
 	cpMock.On("GetRecurringId", mock.Anything).Return("0987654321")
 	cpMock.On("CreateRefund", mock.Anything, mock.Anything).
@@ -102,6 +104,14 @@ func (m *PaymentSystemMockOk) ProcessPayment(order *billingpb.Order, message pro
 	return nil
 }
 
+func (m *PaymentSystemMockOk) CapturePayment(order *billingpb.Order) error {
+	return nil
+}
+
+func (m *PaymentSystemMockOk) CancelPayment(order *billingpb.Order) error {
+	return nil
+}
+
 func (m *PaymentSystemMockOk) IsRecurringCallback(request proto.Message) bool {
 	return false
 }
@@ -132,6 +142,14 @@ func (m *PaymentSystemMockError) ProcessPayment(order *billingpb.Order, message
 	return nil
 }
 
+func (m *PaymentSystemMockError) CapturePayment(order *billingpb.Order) error {
+	return paymentSystemErrorChangeStatusRejected
+}
+
+func (m *PaymentSystemMockError) CancelPayment(order *billingpb.Order) error {
+	return paymentSystemErrorChangeStatusRejected
+}
+
 func (m *PaymentSystemMockError) IsRecurringCallback(request proto.Message) bool {
 	return false
 }
@@ -214,9 +214,11 @@ func (s *Service) ChangeMerchant(
 	rsp *billingpb.ChangeMerchantResponse,
 ) error {
 	var (
-		merchant      *billingpb.Merchant
-		err           error
-		isNewMerchant bool
+		merchant         *billingpb.Merchant
+		err              error
+		isNewMerchant    bool
+		isBankingChanged bool
+		bankingPrevious  *billingpb.MerchantBanking
 	)
 
 	if req.HasIdentificationFields() {
@@ -248,6 +250,8 @@ func (s *Service) ChangeMerchant(
 		isNewMerchant = true
 	}
 
+	screeningSubjects := getSanctionsScreeningSubjects(merchant)
+
 	if !s.IsChangeDataAllow(merchant, req) {
 		rsp.Status = billingpb.ResponseStatusForbidden
 		rsp.Message = merchantErrorChangeNotAllowed
@@ -283,6 +287,18 @@ func (s *Service) ChangeMerchant(
 
 		if req.Banking.AccountNumber != "" {
 			req.Banking.AccountNumber = strings.Join(strings.Fields(req.Banking.AccountNumber), "")
+
+			if !isValidMerchantBankingAccount(req.Banking.AccountNumber) {
+				rsp.Status = billingpb.ResponseStatusBadData
+				rsp.Message = merchantBankingErrorAccountInvalid
+				return nil
+			}
+		}
+
+		if merchant.Banking.GetAccountNumber() != req.Banking.AccountNumber ||
+			merchant.Banking.GetSwift() != req.Banking.Swift {
+			bankingPrevious = merchant.Banking
+			isBankingChanged = true
 		}
 
 		merchant.Banking = req.Banking
@@ -380,6 +396,27 @@ func (s *Service) ChangeMerchant(
 		return nil
 	}
 
+	if isBankingChanged {
+		err = s.addMerchantBankingHistory(ctx, merchant.Id, req.User.GetId(), bankingPrevious, merchant.Banking)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = merchantErrorUnknown
+
+			return nil
+		}
+	}
+
+	// the merchant is screened when names of the company, owners or the bank are changed,
+	// potential matches hold the status change of the merchant
+	if isSanctionsScreeningSubjectsChanged(screeningSubjects, getSanctionsScreeningSubjects(merchant)) {
+		_, err = s.screenMerchant(ctx, merchant, pkg.SanctionsScreeningTriggerOnboarding, req.User.GetId())
+
+		if err != nil {
+			zap.L().Error("Sanctions screening of the merchant failed", zap.Error(err), zap.String("merchant_id", merchant.Id))
+		}
+	}
+
 	merchant.CentrifugoToken = s.centrifugoDashboard.GetChannelToken(merchant.Id, time.Now().Add(time.Hour*3).Unix())
 
 	rsp.Status = billingpb.ResponseStatusOk
@@ -409,6 +446,24 @@ func (s *Service) ChangeMerchantStatus(
 		return nil
 	}
 
+	if req.Status != billingpb.MerchantStatusRejected && req.Status != billingpb.MerchantStatusDeleted {
+		isOnHold, err := s.isMerchantSanctionsScreeningOnHold(ctx, merchant, pkg.SanctionsScreeningTriggerOnboarding, "", false)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = errorSanctionsScreeningUnknown
+
+			return nil
+		}
+
+		if isOnHold {
+			rsp.Status = billingpb.ResponseStatusForbidden
+			rsp.Message = errorSanctionsScreeningHold
+
+			return nil
+		}
+	}
+
 	statusChange := &billingpb.SystemNotificationStatuses{From: merchant.Status, To: req.Status}
 	message, ok := merchantStatusChangesMessages[req.Status]
 
@@ -464,6 +519,22 @@ func (s *Service) ChangeMerchantData(
 		return nil
 	}
 
+	if merchant.HasMerchantSignature || req.HasMerchantSignature {
+		isOnHold, err := s.isMerchantSanctionsScreeningOnHold(ctx, merchant, pkg.SanctionsScreeningTriggerOnboarding, "", false)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = errorSanctionsScreeningUnknown
+			return nil
+		}
+
+		if isOnHold {
+			rsp.Status = billingpb.ResponseStatusForbidden
+			rsp.Message = errorSanctionsScreeningHold
+			return nil
+		}
+	}
+
 	if !merchant.HasPspSignature && req.HasPspSignature {
 		merchant.HasPspSignature = req.HasPspSignature
 	}
@@ -133,6 +133,8 @@ var (
 	orderErrorWrongPrivateStatus                              = newBillingServerErrorMsg("fm000077", "order has wrong private status and cannot be recreated")
 	orderCountryChangeRestrictedError                         = newBillingServerErrorMsg("fm000078", "change country is not allowed")
 	orderErrorVatPayerUnknown                                 = newBillingServerErrorMsg("fm000079", "vat payer unknown")
+	orderErrorCartItemsDuplicated                             = newBillingServerErrorMsg("fm000080", "cart contains duplicated items")
+	orderErrorMerchantProcessingSuspended                     = newBillingServerErrorMsg("fm000081", "processing of payments of merchant is suspended")
 
 	virtualCurrencyPayoutCurrencyMissed = newBillingServerErrorMsg("vc000001", "virtual currency don't have price in merchant payout currency")
 
@@ -213,6 +215,11 @@ func (s *Service) OrderCreateByPaylink(
 		return nil
 	}
 
+	variant, err := s.getPaylinkVariant(ctx, pl.Id, req.Cookie)
+	if err != nil {
+		return err
+	}
+
 	oReq := &billingpb.OrderCreateRequest{
 		ProjectId: pl.ProjectId,
 		User: &billingpb.OrderUser{
@@ -220,7 +227,7 @@ func (s *Service) OrderCreateByPaylink(
 		},
 		Products: pl.Products,
 		PrivateMetadata: map[string]string{
-			"PaylinkId": pl.Id,
+			pkg.OrderPrivateMetadataPaylinkId: pl.Id,
 		},
 		Type:                pl.ProductsType,
 		IssuerUrl:           req.IssuerUrl,
@@ -233,6 +240,18 @@ func (s *Service) OrderCreateByPaylink(
 		Cookie:              req.Cookie,
 	}
 
+	if variant != nil {
+		oReq.PrivateMetadata[pkg.OrderPrivateMetadataPaylinkVariantId] = variant.Id
+
+		if len(variant.Products) > 0 {
+			oReq.Products = variant.Products
+		}
+
+		if variant.PromoCode != "" {
+			oReq.PrivateMetadata[pkg.OrderPrivateMetadataPromoCode] = variant.PromoCode
+		}
+	}
+
 	err = s.OrderCreateProcess(ctx, oReq, rsp)
 	if err != nil {
 		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
@@ -243,6 +262,10 @@ func (s *Service) OrderCreateByPaylink(
 		return err
 	}
 
+	if rsp.Status == billingpb.ResponseStatusOk && rsp.Item != nil {
+		s.recordPaylinkOrderFunnelEvent(ctx, rsp.Item, pkg.PaylinkFunnelStepFormOpen)
+	}
+
 	return nil
 }
 
@@ -341,7 +364,7 @@ func (s *Service) OrderCreateProcess(
 			return nil
 		}
 		break
-	case pkg.OrderType_product, pkg.OrderType_key:
+	case pkg.OrderType_product, pkg.OrderType_key, pkg.OrderTypeMixed:
 		if req.Amount > float64(0) {
 			rsp.Status = billingpb.ResponseStatusBadData
 			rsp.Message = orderErrorCheckoutWithoutProducts
@@ -463,6 +486,20 @@ func (s *Service) OrderCreateProcess(
 			return err
 		}
 		break
+	case pkg.OrderTypeMixed:
+		if err := processor.processPaylinkCartItems(); err != nil {
+			if pid := req.PrivateMetadata["PaylinkId"]; pid != "" {
+				s.notifyPaylinkError(ctx, pid, err, req, nil)
+			}
+			zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error())
+			if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+				rsp.Status = billingpb.ResponseStatusBadData
+				rsp.Message = e
+				return nil
+			}
+			return err
+		}
+		break
 	}
 
 	if req.OrderId != "" {
@@ -529,6 +566,34 @@ func (s *Service) OrderCreateProcess(
 		return err
 	}
 
+	if req.Cookie != "" {
+		browserCustomer, err := s.decryptBrowserCookie(req.Cookie)
+
+		if err == nil && browserCustomer.VirtualCustomerId != "" {
+			if order.PrivateMetadata == nil {
+				order.PrivateMetadata = make(map[string]string)
+			}
+
+			order.PrivateMetadata[pkg.OrderPrivateMetadataBrowserCookieId] = browserCustomer.VirtualCustomerId
+		}
+	}
+
+	allowed, msg := s.checkBlockLists(ctx, order, "", "")
+
+	if msg != nil {
+		rsp.Status = billingpb.ResponseStatusForbidden
+		rsp.Message = msg
+		return nil
+	}
+
+	if !allowed {
+		if msg = s.checkVelocityLimits(ctx, order, "", pkg.VelocityLimitStageOrderCreate); msg != nil {
+			rsp.Status = billingpb.ResponseStatusForbidden
+			rsp.Message = msg
+			return nil
+		}
+	}
+
 	if err = s.orderRepository.Insert(ctx, order); err != nil {
 		rsp.Status = billingpb.ResponseStatusBadData
 		rsp.Message = orderErrorCanNotCreate
@@ -723,6 +788,8 @@ func (s *Service) PaymentFormJsonDataProcess(
 		break
 	case pkg.OrderType_key:
 		rsp.Item.Platforms, err = s.ProcessOrderKeyProducts(ctx, order)
+	case pkg.OrderTypeMixed:
+		rsp.Item.Platforms, err = s.ProcessOrderCartItems(ctx, order)
 	case pkg.OrderTypeVirtualCurrency:
 		err = s.ProcessOrderVirtualCurrency(ctx, order)
 	}
@@ -777,6 +844,16 @@ func (s *Service) PaymentFormJsonDataProcess(
 		return err
 	}
 
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataSessionCount] = strconv.Itoa(int(browserCustomer.SessionCount))
+
+	if browserCustomer.VirtualCustomerId != "" {
+		order.PrivateMetadata[pkg.OrderPrivateMetadataBrowserCookieId] = browserCustomer.VirtualCustomerId
+	}
+
 	err = s.updateOrder(ctx, order)
 
 	if err != nil {
@@ -909,6 +986,10 @@ func (s *Service) PaymentCreateProcess(
 		if _, err = s.ProcessOrderKeyProducts(ctx, order); err == nil {
 			err = processor.reserveKeysForOrder(ctx, order)
 		}
+	} else if order.ProductType == pkg.OrderTypeMixed {
+		if _, err = s.ProcessOrderCartItems(ctx, order); err == nil {
+			err = processor.reserveKeysForOrder(ctx, order)
+		}
 	} else if order.ProductType == pkg.OrderTypeVirtualCurrency {
 		err = s.ProcessOrderVirtualCurrency(ctx, order)
 	}
@@ -1026,6 +1107,78 @@ func (s *Service) PaymentCreateProcess(
 		return err
 	}
 
+	cardFingerprint := s.getRiskCardFingerprint(order, req.Data)
+	allowed, msg := s.checkBlockLists(ctx, order, cardFingerprint, getBlockListPan(order, req.Data))
+
+	if msg != nil {
+		s.releaseRejectedOrderKeys(ctx, order)
+
+		if err = s.updateOrder(ctx, order); err != nil {
+			zap.L().Error("s.updateOrder Method failed", zap.Error(err), zap.Any("order", order))
+		}
+
+		rsp.Status = billingpb.ResponseStatusForbidden
+		rsp.Message = msg
+		return nil
+	}
+
+	if !allowed {
+		if msg = s.checkVelocityLimits(ctx, order, cardFingerprint, pkg.VelocityLimitStagePayment); msg != nil {
+			s.releaseRejectedOrderKeys(ctx, order)
+
+			if err = s.updateOrder(ctx, order); err != nil {
+				zap.L().Error("s.updateOrder Method failed", zap.Error(err), zap.Any("order", order))
+			}
+
+			rsp.Status = billingpb.ResponseStatusForbidden
+			rsp.Message = msg
+			return nil
+		}
+	}
+
+	s.recordVelocityLimitAttempt(order, cardFingerprint)
+	setOrderCardFingerprint(order, cardFingerprint)
+
+	// payment is not blocked when risk assessment is unavailable
+	assessment, err := s.assessOrderRisk(ctx, order, req.Data)
+
+	if err != nil {
+		zap.L().Error(
+			"Payment risk assessment failed",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+		)
+	} else if !allowed {
+		// decisions of the risk engine aren't applied to payments matched by the allow list
+		switch assessment.Decision {
+		case pkg.RiskDecisionDecline:
+			s.releaseRejectedOrderKeys(ctx, order)
+
+			if err = s.updateOrder(ctx, order); err != nil {
+				zap.L().Error("s.updateOrder Method failed", zap.Error(err), zap.Any("order", order))
+			}
+
+			rsp.Status = billingpb.ResponseStatusForbidden
+			rsp.Message = errorRiskPaymentDeclined
+			return nil
+		case pkg.RiskDecisionReview:
+			if err = s.createOrderReview(ctx, order, assessment); err != nil {
+				zap.L().Error(
+					"Order review creation failed",
+					zap.Error(err),
+					zap.String("order_id", order.Id),
+				)
+			}
+		}
+	}
+
+	s.setOrderScaDecision(ctx, order, req.Data, assessment, allowed)
+
+	// payment of the order under review is authorized only and captured when the review is approved
+	if order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus] == pkg.OrderReviewStatusPending {
+		req.Data[pkg.PaymentCreateFieldPreauth] = "1"
+	}
+
 	err = s.updateOrder(ctx, order)
 
 	if err != nil {
@@ -1045,6 +1198,8 @@ func (s *Service) PaymentCreateProcess(
 		return nil
 	}
 
+	s.recordPaylinkOrderFunnelEvent(ctx, order, pkg.PaylinkFunnelStepPaymentAttempt)
+
 	if !s.hasPaymentCosts(ctx, order) {
 		rsp.Status = billingpb.ResponseStatusBadData
 		rsp.Message = orderErrorCostsRatesNotFound
@@ -1194,6 +1349,11 @@ func (s *Service) PaymentCallbackProcess(
 		break
 	}
 
+	if pErr == nil {
+		s.setOrderLiabilityShift(order)
+		s.processOrderReviewPayment(ctx, order)
+	}
+
 	err = s.updateOrder(ctx, order)
 
 	if err != nil {
@@ -1207,7 +1367,15 @@ func (s *Service) PaymentCallbackProcess(
 	}
 
 	if pErr == nil {
-		if order.PrivateStatus == recurringpb.OrderStatusPaymentSystemComplete {
+		held := s.isOrderHeldByReview(order)
+
+		// authorized payment of the order under review waits for the capture
+		if held && order.PrivateStatus == recurringpb.OrderStatusPaymentSystemCreate {
+			rsp.Status = pkg.StatusOK
+			return nil
+		}
+
+		if order.PrivateStatus == recurringpb.OrderStatusPaymentSystemComplete && !held {
 			err = s.paymentSystemPaymentCallbackComplete(ctx, order)
 
 			if err != nil {
@@ -1236,7 +1404,7 @@ func (s *Service) PaymentCallbackProcess(
 			return err
 		}
 
-		if order.PrivateStatus == recurringpb.OrderStatusPaymentSystemComplete {
+		if order.PrivateStatus == recurringpb.OrderStatusPaymentSystemComplete && !held {
 			s.sendMailWithReceipt(ctx, order)
 		}
 
@@ -1284,6 +1452,8 @@ func (s *Service) PaymentFormLanguageChanged(
 		err = s.ProcessOrderProducts(ctx, order)
 	} else if order.ProductType == pkg.OrderType_key {
 		_, err = s.ProcessOrderKeyProducts(ctx, order)
+	} else if order.ProductType == pkg.OrderTypeMixed {
+		_, err = s.ProcessOrderCartItems(ctx, order)
 	}
 
 	if err != nil {
@@ -1580,6 +1750,8 @@ func (s *Service) ProcessBillingAddress(
 		err = s.ProcessOrderProducts(ctx, order)
 	} else if order.ProductType == pkg.OrderType_key {
 		_, err = s.ProcessOrderKeyProducts(ctx, order)
+	} else if order.ProductType == pkg.OrderTypeMixed {
+		_, err = s.ProcessOrderCartItems(ctx, order)
 	}
 
 	if err != nil {
@@ -1652,27 +1824,13 @@ func (s *Service) ProcessBillingAddress(
 }
 
 func (s *Service) saveRecurringCard(ctx context.Context, order *billingpb.Order, recurringId string) {
-	req := &recurringpb.SavedCardRequest{
-		Token:      order.User.Id,
-		ProjectId:  order.Project.Id,
-		MerchantId: order.Project.MerchantId,
-		MaskedPan:  order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldPan],
-		CardHolder: order.PaymentMethodTxnParams[billingpb.PaymentCreateFieldHolder],
-		Expire: &recurringpb.CardExpire{
-			Month: order.PaymentRequisites[billingpb.PaymentCreateFieldMonth],
-			Year:  order.PaymentRequisites[billingpb.PaymentCreateFieldYear],
-		},
-		RecurringId: recurringId,
-	}
-
-	_, err := s.rep.InsertSavedCard(ctx, req)
+	err := s.saveCardVaultToken(ctx, order, recurringId)
 
 	if err != nil {
 		zap.L().Error(
-			pkg.ErrorGrpcServiceCallFailed,
+			"Failed to save recurring card to card vault",
 			zap.Error(err),
-			zap.String(errorFieldService, recurringpb.PayOneRepositoryServiceName),
-			zap.String(errorFieldMethod, "InsertSavedCard"),
+			zap.String("order_id", order.Id),
 		)
 	} else {
 		order, err := s.orderRepository.GetById(ctx, order.Id)
@@ -1734,7 +1892,20 @@ func (s *Service) updateOrder(ctx context.Context, order *billingpb.Order) error
 
 	zap.S().Debug("[updateOrder] updating order success", "order_id", order.Id, "status_changed", statusChanged, "type", order.ProductType)
 
-	if order.ProductType == pkg.OrderType_key {
+	if statusChanged && ps == recurringpb.OrderPublicStatusProcessed {
+		s.createOrderPromoUsage(ctx, order)
+		s.recordPaylinkOrderFunnelEvent(ctx, order, pkg.PaylinkFunnelStepSuccess)
+	}
+
+	if statusChanged && (ps == recurringpb.OrderPublicStatusCanceled || ps == recurringpb.OrderPublicStatusRejected) {
+		s.releaseOrderPromo(ctx, order, order.PrivateMetadata[pkg.OrderPrivateMetadataPromoId])
+	}
+
+	if statusChanged && ps == recurringpb.OrderPublicStatusChargeback && order.Type == pkg.OrderTypeOrder {
+		s.markRiskChargeback(ctx, order)
+	}
+
+	if orderHasKeyProducts(order) {
 		s.orderNotifyKeyProducts(ctx, order)
 	}
 
@@ -1758,20 +1929,22 @@ func (s *Service) orderNotifyKeyProducts(ctx context.Context, order *billingpb.O
 	case recurringpb.OrderPublicStatusCanceled, recurringpb.OrderPublicStatusRejected:
 		for _, key := range keys {
 			zap.S().Infow("[orderNotifyKeyProducts] trying to cancel reserving key", "order_id", order.Id, "key", key)
-			rsp := &billingpb.EmptyResponseWithStatus{}
-			err = s.CancelRedeemKeyForOrder(ctx, &billingpb.KeyForOrderRequest{KeyId: key}, rsp)
+			err = s.shortenKeyReservation(ctx, key)
 			if err != nil {
-				zap.S().Error("internal error during canceling reservation for key", "err", err, "key", key)
-				continue
-			}
-			if rsp.Status != billingpb.ResponseStatusOk {
-				zap.S().Error("could not cancel reservation for key", "key", key, "message", rsp.Message)
+				zap.S().Error("could not cancel reservation for key", "err", err, "key", key)
 				continue
 			}
 		}
 		order.IsKeyProductNotified = true
 		break
 	case recurringpb.OrderPublicStatusProcessed:
+		// keys of the order under review are delivered when the review is approved
+		if s.isOrderHeldByReview(order) {
+			return
+		}
+
+		// keys of key products which aren't released yet are delivered at the release
+		keys = s.createKeyPreOrders(ctx, order)
 		for _, key := range keys {
 			zap.S().Infow("[orderNotifyKeyProducts] trying to finish reserving key", "order_id", order.Id, "key", key)
 			rsp := &billingpb.GetKeyForOrderRequestResponse{}
@@ -1964,6 +2137,7 @@ func (s *Service) sendMailWithCode(_ context.Context, order *billingpb.Order, ke
 
 	for _, item := range order.Items {
 		if item.Id == key.KeyProductId {
+			// the merchant is notified with plaintext of the code, the code is masked when the order is saved
 			item.Code = key.Code
 			payload := &postmarkpb.Payload{
 				TemplateAlias: s.cfg.EmailTemplates.ActivationGameKey,
@@ -2001,6 +2175,11 @@ func (s *Service) sendMailWithCode(_ context.Context, order *billingpb.Order, ke
 func (s *Service) orderNotifyMerchant(ctx context.Context, order *billingpb.Order) {
 	zap.S().Debug("[orderNotifyMerchant] try to send notify merchant to rmq", "order_id", order.Id, "status", order.GetPublicStatus())
 
+	// merchant is notified about the payment of the order under review when the review is approved
+	if order.GetPublicStatus() == recurringpb.OrderPublicStatusProcessed && s.isOrderHeldByReview(order) {
+		return
+	}
+
 	err := s.broker.Publish(recurringpb.PayOneTopicNotifyPaymentName, order, amqp.Table{"x-retry-count": int32(0)})
 	if err != nil {
 		zap.S().Debug("[orderNotifyMerchant] send notify merchant to rmq failed", "order_id", order.Id)
@@ -2293,6 +2472,16 @@ func (v *OrderCreateRequestProcessor) processProject() error {
 		return orderErrorProjectMerchantInactive
 	}
 
+	suspended, err := v.isMerchantProcessingSuspended(v.ctx, merchant.Id)
+
+	if err != nil {
+		return orderErrorUnknown
+	}
+
+	if suspended {
+		return orderErrorMerchantProcessingSuspended
+	}
+
 	v.checked.project = project
 	v.checked.merchant = merchant
 
@@ -2402,6 +2591,7 @@ func (v *OrderCreateRequestProcessor) processPaylinkKeyProducts() error {
 		v.checked.priceGroup,
 		DefaultLanguage,
 		v.request.PlatformId,
+		time.Now(),
 	)
 
 	if err != nil {
@@ -2432,6 +2622,7 @@ func (v *OrderCreateRequestProcessor) processPaylinkProducts(_ context.Context)
 		v.request.Products,
 		v.checked.priceGroup,
 		DefaultLanguage,
+		time.Now(),
 	)
 
 	v.checked.isBuyForVirtualCurrency = isBuyForVirtual
@@ -2829,6 +3020,33 @@ func (v *PaymentFormProcessor) processPaymentMethodsData(ctx context.Context, pm
 	pm.HasSavedCards = false
 
 	if pm.IsBankCard() == true {
+		pm.SavedCards = []*billingpb.SavedCard{}
+		panReferences := make(map[string]bool)
+		tokens, err := v.service.cardVaultTokenRepository.FindByCustomerIds(ctx, getOrderCardVaultCustomerIds(v.order))
+
+		if err != nil {
+			zap.S().Errorw(
+				"Get saved cards from card vault failed",
+				"error", err,
+				"token", v.order.User.Id,
+				"project_id", v.order.Project.Id,
+				"order_id", v.order.Id,
+			)
+		}
+
+		for _, token := range tokens {
+			d := &billingpb.SavedCard{
+				Id:         token.Id,
+				Pan:        token.MaskedPan,
+				CardHolder: token.CardHolder,
+				Expire:     &billingpb.CardExpire{Month: token.ExpireMonth, Year: token.ExpireYear},
+			}
+
+			pm.SavedCards = append(pm.SavedCards, d)
+			panReferences[token.PanReference] = true
+		}
+
+		// cards saved in the recurring service before the card vault
 		req := &recurringpb.SavedCardRequest{Token: v.order.User.Id}
 		rsp, err := v.service.rep.FindSavedCards(ctx, req)
 
@@ -2841,10 +3059,11 @@ func (v *PaymentFormProcessor) processPaymentMethodsData(ctx context.Context, pm
 				"order_id", v.order.Id,
 			)
 		} else {
-			pm.HasSavedCards = len(rsp.SavedCards) > 0
-			pm.SavedCards = []*billingpb.SavedCard{}
-
 			for _, v := range rsp.SavedCards {
+				if v.RecurringId != "" && panReferences[v.RecurringId] {
+					continue
+				}
+
 				d := &billingpb.SavedCard{
 					Id:         v.Id,
 					Pan:        v.MaskedPan,
@@ -2854,8 +3073,9 @@ func (v *PaymentFormProcessor) processPaymentMethodsData(ctx context.Context, pm
 
 				pm.SavedCards = append(pm.SavedCards, d)
 			}
-
 		}
+
+		pm.HasSavedCards = len(pm.SavedCards) > 0
 	}
 
 	return nil
@@ -2864,8 +3084,9 @@ func (v *PaymentFormProcessor) processPaymentMethodsData(ctx context.Context, pm
 func (v *PaymentCreateProcessor) reserveKeysForOrder(ctx context.Context, order *billingpb.Order) error {
 	if len(order.Keys) == 0 {
 		zap.S().Infow("[ProcessOrderKeyProducts] reserving keys", "order_id", order.Id)
-		keys := make([]string, len(order.Products))
-		for i, productId := range order.Products {
+		keyProductIds := getOrderKeyProductIds(order)
+		keys := make([]string, len(keyProductIds))
+		for i, productId := range keyProductIds {
 			reserveRes := &billingpb.PlatformKeyReserveResponse{}
 			reserveReq := &billingpb.PlatformKeyReserveRequest{
 				PlatformId:   order.PlatformId,
@@ -3105,27 +3326,38 @@ func (v *PaymentCreateProcessor) processPaymentFormData(ctx context.Context) err
 
 	if pm.IsBankCard() == true {
 		if id, ok := v.data[billingpb.PaymentCreateFieldStoredCardId]; ok {
-			storedCard, err := v.service.rep.FindSavedCardById(ctx, &recurringpb.FindByStringValue{Value: id})
+			token, err := v.service.getOrderCardVaultToken(ctx, order, id)
 
 			if err != nil {
-				v.service.logError("Get data about stored card failed", []interface{}{"err", err.Error(), "id", id})
+				return err
 			}
 
-			if storedCard == nil {
-				v.service.logError("Get data about stored card failed", []interface{}{"id", id})
-				return orderGetSavedCardError
-			}
+			if token != nil {
+				setOrderCardVaultToken(order, token)
+			} else {
+				// cards saved in the recurring service before the card vault
+				storedCard, err := v.service.rep.FindSavedCardById(ctx, &recurringpb.FindByStringValue{Value: id})
 
-			if storedCard.Token != order.User.Id {
-				v.service.logError("Alarm: user try use not own bank card for payment", []interface{}{"user_id", order.User.Id, "card_id", id})
-				return orderErrorRecurringCardNotOwnToUser
-			}
+				if err != nil {
+					v.service.logError("Get data about stored card failed", []interface{}{"err", err.Error(), "id", id})
+				}
+
+				if storedCard == nil {
+					v.service.logError("Get data about stored card failed", []interface{}{"id", id})
+					return orderGetSavedCardError
+				}
 
-			order.PaymentRequisites[billingpb.PaymentCreateFieldPan] = storedCard.MaskedPan
-			order.PaymentRequisites[billingpb.PaymentCreateFieldMonth] = storedCard.Expire.Month
-			order.PaymentRequisites[billingpb.PaymentCreateFieldYear] = storedCard.Expire.Year
-			order.PaymentRequisites[billingpb.PaymentCreateFieldHolder] = storedCard.CardHolder
-			order.PaymentRequisites[billingpb.PaymentCreateFieldRecurringId] = storedCard.RecurringId
+				if storedCard.Token != order.User.Id {
+					v.service.logError("Alarm: user try use not own bank card for payment", []interface{}{"user_id", order.User.Id, "card_id", id})
+					return orderErrorRecurringCardNotOwnToUser
+				}
+
+				order.PaymentRequisites[billingpb.PaymentCreateFieldPan] = storedCard.MaskedPan
+				order.PaymentRequisites[billingpb.PaymentCreateFieldMonth] = storedCard.Expire.Month
+				order.PaymentRequisites[billingpb.PaymentCreateFieldYear] = storedCard.Expire.Year
+				order.PaymentRequisites[billingpb.PaymentCreateFieldHolder] = storedCard.CardHolder
+				order.PaymentRequisites[billingpb.PaymentCreateFieldRecurringId] = storedCard.RecurringId
+			}
 		} else {
 			validator := &bankCardValidator{
 				Pan:    v.data[billingpb.PaymentCreateFieldPan],
@@ -3611,6 +3843,7 @@ func (s *Service) ProcessOrderKeyProducts(ctx context.Context, order *billingpb.
 		priceGroup,
 		locale,
 		order.PlatformId,
+		getOrderPricingTime(order),
 	)
 
 	if err != nil {
@@ -3626,6 +3859,10 @@ func (s *Service) ProcessOrderKeyProducts(ctx context.Context, order *billingpb.
 
 	order.Items = items
 
+	if err = s.processOrderPromo(ctx, order); err != nil {
+		return nil, err
+	}
+
 	return platforms, nil
 }
 
@@ -3655,6 +3892,7 @@ func (s *Service) ProcessOrderProducts(ctx context.Context, order *billingpb.Ord
 		order.Products,
 		priceGroup,
 		locale,
+		getOrderPricingTime(order),
 	)
 
 	if err != nil {
@@ -3671,7 +3909,7 @@ func (s *Service) ProcessOrderProducts(ctx context.Context, order *billingpb.Ord
 
 	order.Items = items
 
-	return nil
+	return s.processOrderPromo(ctx, order)
 }
 
 func (s *Service) processAmountForFiatCurrency(
@@ -4095,6 +4333,8 @@ func (s *Service) PaymentFormPlatformChanged(ctx context.Context, req *billingpb
 		err = s.ProcessOrderProducts(ctx, order)
 	} else if order.ProductType == pkg.OrderType_key {
 		_, err = s.ProcessOrderKeyProducts(ctx, order)
+	} else if order.ProductType == pkg.OrderTypeMixed {
+		_, err = s.ProcessOrderCartItems(ctx, order)
 	}
 
 	if err != nil {
@@ -4268,7 +4508,8 @@ func (s *Service) getOrderReceiptObject(ctx context.Context, order *billingpb.Or
 		return nil, orderErrorDuringFormattingDate
 	}
 
-	items := make([]*billingpb.OrderReceiptItem, len(order.Items))
+	orderItems := getOrderReceiptItems(order.Items)
+	items := make([]*billingpb.OrderReceiptItem, len(orderItems))
 
 	currency := order.Currency
 	if order.IsBuyForVirtualCurrency {
@@ -4296,7 +4537,7 @@ func (s *Service) getOrderReceiptObject(ctx context.Context, order *billingpb.Or
 		}
 	}
 
-	for i, item := range order.Items {
+	for i, item := range orderItems {
 		price, err := s.formatter.FormatCurrency(DefaultLanguage, item.Amount, currency)
 
 		// Virtual currency always returns error but formatting with Name
@@ -4697,6 +4938,7 @@ func (s *Service) processProducts(
 	productIds []string,
 	priceGroup *billingpb.PriceGroup,
 	locale string,
+	at time.Time,
 ) (amount float64, usedPriceGroup *billingpb.PriceGroup, items []*billingpb.OrderItem, isBuyForVirtualCurrency bool, err error) {
 	project, err := s.project.GetById(ctx, projectId)
 	if err != nil {
@@ -4712,6 +4954,8 @@ func (s *Service) processProducts(
 		return
 	}
 
+	orderProducts = s.getProductsPricesAt(ctx, orderProducts, at)
+
 	merchant, err := s.merchantRepository.GetById(ctx, project.MerchantId)
 	if err != nil {
 		return
@@ -4756,6 +5000,7 @@ func (s *Service) processKeyProducts(
 	priceGroup *billingpb.PriceGroup,
 	locale string,
 	platformId string,
+	at time.Time,
 ) (amount float64, usedPriceGroup *billingpb.PriceGroup, items []*billingpb.OrderItem, platforms []*billingpb.Platform, err error) {
 
 	project, err := s.project.GetById(ctx, projectId)
@@ -4791,6 +5036,8 @@ func (s *Service) processKeyProducts(
 		platformId = platforms[0].Id
 	}
 
+	orderProducts = s.getKeyProductsPricesAt(ctx, orderProducts, at, platformId)
+
 	merchant, err := s.merchantRepository.GetById(ctx, project.MerchantId)
 	if err != nil {
 		return
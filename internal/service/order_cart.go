@@ -0,0 +1,591 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"time"
+)
+
+// orderCart contains catalog objects of the mixed cart, products and key products of bundles are included.
+type orderCart struct {
+	products    map[string]*billingpb.Product
+	keyProducts map[string]*billingpb.KeyProduct
+	bundles     map[string]*pkg.Bundle
+}
+
+func (v *OrderCreateRequestProcessor) processPaylinkCartItems() error {
+	amount, priceGroup, items, _, err := v.processCartItems(
+		v.ctx,
+		v.checked.project.Id,
+		v.request.Products,
+		v.checked.priceGroup,
+		DefaultLanguage,
+		v.request.PlatformId,
+		time.Now(),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if v.checked.project.CallbackProtocol == billingpb.ProjectCallbackProtocolDefault && len(v.request.TestingCase) == 0 {
+		testing := v.checked.project.WebhookTesting
+
+		if testing == nil {
+			return orderErrorMerchantWebHookTestingNotPassed
+		}
+
+		for _, item := range items {
+			if item.Metadata[pkg.OrderItemMetadataType] == pkg.OrderItemTypeKeyProduct {
+				if !testing.Keys.IsPassed {
+					return orderErrorMerchantWebHookTestingNotPassed
+				}
+				continue
+			}
+
+			if !(testing.Products.IncorrectPayment && testing.Products.CorrectPayment &&
+				testing.Products.ExistingUser && testing.Products.NonExistingUser) {
+				return orderErrorMerchantWebHookTestingNotPassed
+			}
+		}
+	}
+
+	v.checked.priceGroup = priceGroup
+	v.checked.products = v.request.Products
+	v.checked.currency = priceGroup.Currency
+	v.checked.amount = amount
+	v.checked.items = items
+
+	return nil
+}
+
+// ProcessOrderCartItems recalculates the amount and items of the mixed order in the price group of the customer.
+func (s *Service) ProcessOrderCartItems(ctx context.Context, order *billingpb.Order) ([]*billingpb.Platform, error) {
+	if order.ProductType != pkg.OrderTypeMixed {
+		return nil, nil
+	}
+
+	priceGroup, err := s.getOrderPriceGroup(ctx, order)
+
+	if err != nil {
+		zap.L().Error(
+			"ProcessOrderCartItems getOrderPriceGroup failed",
+			zap.Error(err),
+			zap.String("order.Uuid", order.Uuid),
+		)
+		return nil, err
+	}
+
+	locale := DefaultLanguage
+
+	if order.User != nil && order.User.Locale != "" {
+		locale = order.User.Locale
+	}
+
+	amount, priceGroup, items, platforms, err := s.processCartItems(
+		ctx,
+		order.Project.Id,
+		order.Products,
+		priceGroup,
+		locale,
+		order.PlatformId,
+		getOrderPricingTime(order),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	order.Currency = priceGroup.Currency
+	order.OrderAmount = amount
+	order.TotalPaymentAmount = amount
+
+	order.ChargeAmount = order.TotalPaymentAmount
+	order.ChargeCurrency = order.Currency
+
+	order.Items = items
+
+	if err = s.processOrderPromo(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return platforms, nil
+}
+
+// processCartItems calculates the amount and items of the mixed cart which contains products, key products
+// and bundles of the project. The bundle is added to the order as items of its products and key products.
+func (s *Service) processCartItems(
+	ctx context.Context,
+	projectId string,
+	ids []string,
+	priceGroup *billingpb.PriceGroup,
+	locale string,
+	platformId string,
+	at time.Time,
+) (amount float64, usedPriceGroup *billingpb.PriceGroup, items []*billingpb.OrderItem, platforms []*billingpb.Platform, err error) {
+	project, err := s.project.GetById(ctx, projectId)
+
+	if err != nil {
+		return
+	}
+
+	if project.IsDeleted() == true {
+		err = orderErrorProjectInactive
+		return
+	}
+
+	cart, err := s.getOrderCart(ctx, project.Id, ids)
+
+	if err != nil {
+		return
+	}
+
+	if len(cart.keyProducts) > 0 {
+		keyProducts := make([]*billingpb.KeyProduct, 0, len(cart.keyProducts))
+
+		for _, keyProduct := range cart.keyProducts {
+			keyProducts = append(keyProducts, keyProduct)
+		}
+
+		platformIds := s.filterPlatforms(keyProducts)
+
+		if len(platformIds) == 0 {
+			err = orderErrorNoPlatforms
+			return
+		}
+
+		platforms = make([]*billingpb.Platform, len(platformIds))
+
+		for i, v := range platformIds {
+			platforms[i] = availablePlatforms[v]
+		}
+
+		sort.Slice(platforms, func(i, j int) bool {
+			return platforms[i].Order < platforms[j].Order
+		})
+
+		if platformId == "" {
+			platformId = platforms[0].Id
+		}
+	}
+
+	s.applyOrderCartPricesAt(ctx, cart, at, platformId)
+
+	merchant, err := s.merchantRepository.GetById(ctx, project.MerchantId)
+
+	if err != nil {
+		return
+	}
+
+	defaultPriceGroup, err := s.priceGroupRepository.GetByRegion(ctx, merchant.GetProcessingDefaultCurrency())
+
+	if err != nil {
+		return
+	}
+
+	if priceGroup == nil {
+		priceGroup = defaultPriceGroup
+	}
+
+	usedPriceGroup = priceGroup
+	items, err = s.getOrderCartItems(cart, ids, priceGroup, locale, platformId)
+
+	if err == orderErrorNoProductsCommonCurrency && priceGroup.Id != defaultPriceGroup.Id {
+		// try to get order amount in fallback currency
+		usedPriceGroup = defaultPriceGroup
+		items, err = s.getOrderCartItems(cart, ids, defaultPriceGroup, locale, platformId)
+	}
+
+	if err != nil {
+		return
+	}
+
+	for _, item := range items {
+		amount += item.Amount
+	}
+
+	amount = s.FormatAmount(amount, usedPriceGroup.Currency)
+
+	return
+}
+
+// getOrderCart loads catalog objects of the cart. Identities of the cart are searched in bundles, key products
+// and products of the project in that order.
+func (s *Service) getOrderCart(ctx context.Context, projectId string, ids []string) (*orderCart, error) {
+	if len(ids) == 0 {
+		return nil, orderErrorProductsEmpty
+	}
+
+	unique := make(map[string]bool, len(ids))
+
+	for _, id := range ids {
+		if unique[id] {
+			return nil, orderErrorCartItemsDuplicated
+		}
+
+		unique[id] = true
+	}
+
+	cart := &orderCart{
+		products:    make(map[string]*billingpb.Product),
+		keyProducts: make(map[string]*billingpb.KeyProduct),
+		bundles:     make(map[string]*pkg.Bundle),
+	}
+
+	bundles, err := s.bundleRepository.FindByIdsProjectId(ctx, ids, projectId)
+
+	if err != nil {
+		return nil, orderErrorUnknown
+	}
+
+	var keyProductIds, productIds []string
+
+	for _, bundle := range bundles {
+		cart.bundles[bundle.Id] = bundle
+		keyProductIds = append(keyProductIds, bundle.KeyProducts...)
+		productIds = append(productIds, bundle.Products...)
+	}
+
+	for _, id := range ids {
+		if _, ok := cart.bundles[id]; !ok {
+			keyProductIds = append(keyProductIds, id)
+		}
+	}
+
+	keyProducts, err := s.keyProductRepository.FindByIdsProjectId(ctx, keyProductIds, projectId)
+
+	if err != nil {
+		return nil, orderErrorUnknown
+	}
+
+	for _, keyProduct := range keyProducts {
+		cart.keyProducts[keyProduct.Id] = keyProduct
+	}
+
+	for _, bundle := range bundles {
+		for _, id := range bundle.KeyProducts {
+			if _, ok := cart.keyProducts[id]; !ok {
+				return nil, orderErrorProductsInvalid
+			}
+		}
+	}
+
+	for _, id := range ids {
+		_, isBundle := cart.bundles[id]
+		_, isKeyProduct := cart.keyProducts[id]
+
+		if !isBundle && !isKeyProduct {
+			productIds = append(productIds, id)
+		}
+	}
+
+	if len(productIds) > 0 {
+		products, err := s.GetOrderProducts(ctx, projectId, productIds)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, product := range products {
+			cart.products[product.Id] = product
+		}
+	}
+
+	return cart, nil
+}
+
+// applyOrderCartPricesAt replaces products and key products of the cart with their copies with prices
+// effective at the time.
+func (s *Service) applyOrderCartPricesAt(ctx context.Context, cart *orderCart, at time.Time, platformId string) {
+	if len(cart.products) > 0 {
+		products := make([]*billingpb.Product, 0, len(cart.products))
+
+		for _, product := range cart.products {
+			products = append(products, product)
+		}
+
+		for _, product := range s.getProductsPricesAt(ctx, products, at) {
+			cart.products[product.Id] = product
+		}
+	}
+
+	if len(cart.keyProducts) > 0 {
+		keyProducts := make([]*billingpb.KeyProduct, 0, len(cart.keyProducts))
+
+		for _, keyProduct := range cart.keyProducts {
+			keyProducts = append(keyProducts, keyProduct)
+		}
+
+		for _, keyProduct := range s.getKeyProductsPricesAt(ctx, keyProducts, at, platformId) {
+			cart.keyProducts[keyProduct.Id] = keyProduct
+		}
+	}
+}
+
+// getOrderCartItems returns items of the cart with amounts in the price group. The price of the bundle
+// is allocated to its items in proportion to their own prices.
+func (s *Service) getOrderCartItems(
+	cart *orderCart,
+	ids []string,
+	group *billingpb.PriceGroup,
+	locale, platformId string,
+) ([]*billingpb.OrderItem, error) {
+	var items []*billingpb.OrderItem
+
+	for _, id := range ids {
+		bundle, ok := cart.bundles[id]
+
+		if !ok {
+			item, err := s.getOrderCartItem(cart, id, group, locale, platformId)
+
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+			continue
+		}
+
+		amount, err := getBundlePrice(bundle, group)
+
+		if err != nil {
+			return nil, err
+		}
+
+		name, ok := bundle.Name[locale]
+
+		if !ok {
+			name = bundle.Name[DefaultLanguage]
+		}
+
+		var bundleItems []*billingpb.OrderItem
+
+		for _, itemId := range append(append([]string{}, bundle.Products...), bundle.KeyProducts...) {
+			item, err := s.getOrderCartItem(cart, itemId, group, locale, platformId)
+
+			if err != nil {
+				return nil, err
+			}
+
+			item.Metadata[pkg.OrderItemMetadataBundleId] = bundle.Id
+			item.Metadata[pkg.OrderItemMetadataBundleName] = name
+			bundleItems = append(bundleItems, item)
+		}
+
+		s.allocateBundleAmount(amount, group.Currency, bundleItems)
+		items = append(items, bundleItems...)
+	}
+
+	return items, nil
+}
+
+func (s *Service) getOrderCartItem(
+	cart *orderCart,
+	id string,
+	group *billingpb.PriceGroup,
+	locale, platformId string,
+) (*billingpb.OrderItem, error) {
+	var (
+		items    []*billingpb.OrderItem
+		itemType string
+		err      error
+	)
+
+	if product, ok := cart.products[id]; ok {
+		if _, err = product.GetPriceInCurrency(group); err != nil {
+			return nil, orderErrorNoProductsCommonCurrency
+		}
+
+		itemType = pkg.OrderItemTypeProduct
+		items, err = s.GetOrderProductsItems([]*billingpb.Product{product}, locale, group)
+	} else if keyProduct, ok := cart.keyProducts[id]; ok {
+		if _, err = keyProduct.GetPriceInCurrencyAndPlatform(group, platformId); err != nil {
+			return nil, orderErrorNoProductsCommonCurrency
+		}
+
+		itemType = pkg.OrderItemTypeKeyProduct
+		items, err = s.GetOrderKeyProductsItems([]*billingpb.KeyProduct{keyProduct}, locale, group, platformId)
+	} else {
+		return nil, orderErrorProductsInvalid
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	item := items[0]
+	// metadata of the item is copied to keep metadata of the cached product unchanged
+	metadata := make(map[string]string, len(item.Metadata)+3)
+
+	for k, v := range item.Metadata {
+		metadata[k] = v
+	}
+
+	metadata[pkg.OrderItemMetadataType] = itemType
+	item.Metadata = metadata
+
+	return item, nil
+}
+
+// allocateBundleAmount splits the bundle price between items of the bundle in proportion to their own prices,
+// the rounding difference is added to the last item to keep the sum of items equal to the bundle price.
+func (s *Service) allocateBundleAmount(amount float64, currency string, items []*billingpb.OrderItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	total := float64(0)
+
+	for _, item := range items {
+		total += item.Amount
+	}
+
+	allocated := float64(0)
+
+	for i, item := range items {
+		if i == len(items)-1 {
+			item.Amount = s.FormatAmount(amount-allocated, currency)
+			break
+		}
+
+		if total > 0 {
+			item.Amount = s.FormatAmount(amount*item.Amount/total, currency)
+		} else {
+			item.Amount = s.FormatAmount(amount/float64(len(items)), currency)
+		}
+
+		allocated += item.Amount
+	}
+}
+
+// getOrderKeyProductIds returns identities of key products to reserve keys for the order.
+func getOrderKeyProductIds(order *billingpb.Order) []string {
+	switch order.ProductType {
+	case pkg.OrderType_key:
+		return order.Products
+	case pkg.OrderTypeMixed:
+		var ids []string
+
+		for _, item := range order.Items {
+			if item.Metadata[pkg.OrderItemMetadataType] == pkg.OrderItemTypeKeyProduct {
+				ids = append(ids, item.Id)
+			}
+		}
+
+		return ids
+	}
+
+	return nil
+}
+
+func orderHasKeyProducts(order *billingpb.Order) bool {
+	return len(getOrderKeyProductIds(order)) > 0
+}
+
+// GetOrderItemsBreakdown returns shares of items of the order in revenue, taxes and fees of the order.
+func (s *Service) GetOrderItemsBreakdown(
+	ctx context.Context,
+	req *pkg.GetOrderItemsBreakdownRequest,
+	res *pkg.GetOrderItemsBreakdownResponse,
+) error {
+	order, err := s.orderViewRepository.GetPrivateOrderBy(ctx, "", req.OrderId, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = orderErrorNotFound
+		return nil
+	}
+
+	total := float64(0)
+
+	for _, item := range order.Items {
+		total += item.Amount
+	}
+
+	for _, item := range order.Items {
+		share := float64(1) / float64(len(order.Items))
+
+		if total > 0 {
+			share = item.Amount / total
+		}
+
+		itemType := item.Metadata[pkg.OrderItemMetadataType]
+
+		// items of orders with the single product type have no type in metadata
+		if itemType == "" {
+			itemType = pkg.OrderItemTypeProduct
+
+			if item.PlatformId != "" {
+				itemType = pkg.OrderItemTypeKeyProduct
+			}
+		}
+
+		res.Items = append(res.Items, &pkg.OrderItemBreakdown{
+			ItemId:       item.Id,
+			Name:         item.Name,
+			Type:         itemType,
+			BundleId:     item.Metadata[pkg.OrderItemMetadataBundleId],
+			BundleName:   item.Metadata[pkg.OrderItemMetadataBundleName],
+			Amount:       item.Amount,
+			Share:        share,
+			Currency:     order.MerchantPayoutCurrency,
+			GrossRevenue: s.getOrderItemShare(order.GrossRevenue, share),
+			TaxFee:       s.getOrderItemShare(order.TaxFeeTotal, share),
+			FeesTotal:    s.getOrderItemShare(order.FeesTotal, share),
+			NetRevenue:   s.getOrderItemShare(order.NetRevenue, share),
+		})
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) getOrderItemShare(money *billingpb.OrderViewMoney, share float64) float64 {
+	if money == nil {
+		return 0
+	}
+
+	return s.FormatAmount(money.Amount*share, money.Currency)
+}
+
+// getOrderReceiptItems returns items of the order to show in the receipt, items of the bundle are joined
+// to the single item with the bundle price and names of products in the bundle.
+func getOrderReceiptItems(items []*billingpb.OrderItem) []*billingpb.OrderItem {
+	var (
+		result  []*billingpb.OrderItem
+		bundles = make(map[string]*billingpb.OrderItem)
+		names   = make(map[string][]string)
+	)
+
+	for _, item := range items {
+		bundleId := item.Metadata[pkg.OrderItemMetadataBundleId]
+
+		if bundleId == "" {
+			result = append(result, item)
+			continue
+		}
+
+		bundle, ok := bundles[bundleId]
+
+		if !ok {
+			bundle = &billingpb.OrderItem{
+				Id:       bundleId,
+				Object:   pkg.BundleObject,
+				Currency: item.Currency,
+			}
+			bundles[bundleId] = bundle
+			result = append(result, bundle)
+		}
+
+		bundle.Amount += item.Amount
+		names[bundleId] = append(names[bundleId], item.Name)
+		bundle.Name = item.Metadata[pkg.OrderItemMetadataBundleName] + " (" + strings.Join(names[bundleId], ", ") + ")"
+	}
+
+	return result
+}
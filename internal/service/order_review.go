@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	orderReviewDeclineReason = "payment declined by manual review of the order"
+)
+
+var (
+	errorOrderReviewNotFound             = newBillingServerErrorMsg("rv000001", "order review not found")
+	errorOrderReviewAlreadyResolved      = newBillingServerErrorMsg("rv000002", "order review already resolved")
+	errorOrderReviewClaimedByAnotherUser = newBillingServerErrorMsg("rv000003", "order review is claimed by another user")
+	errorOrderReviewNotClaimed           = newBillingServerErrorMsg("rv000004", "order review must be claimed before the resolution")
+	errorOrderReviewPaymentNotAuthorized = newBillingServerErrorMsg("rv000005", "payment of the order under review isn't authorized yet")
+	errorOrderReviewUnknown              = newBillingServerErrorMsg("rv000006", "unknown error with order review")
+)
+
+// ListOrderReviews returns manual reviews of risky orders, reviews with the closest deadline are returned first
+func (s *Service) ListOrderReviews(
+	ctx context.Context,
+	req *pkg.ListOrderReviewsRequest,
+	res *pkg.ListOrderReviewsResponse,
+) error {
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	count, err := s.orderReviewRepository.FindCount(ctx, req.MerchantId, req.Status, req.Overdue)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorOrderReviewUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count <= 0 {
+		return nil
+	}
+
+	res.Items, err = s.orderReviewRepository.Find(ctx, req.MerchantId, req.Status, req.Overdue, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorOrderReviewUnknown
+		res.Count = 0
+		return nil
+	}
+
+	return nil
+}
+
+// ClaimOrderReview assigns the pending review to the risk manager, other users can't resolve the review
+// until the claim is expired
+func (s *Service) ClaimOrderReview(
+	ctx context.Context,
+	req *pkg.OrderReviewRequest,
+	res *pkg.OrderReviewResponse,
+) error {
+	review, err := s.orderReviewRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorOrderReviewNotFound
+		return nil
+	}
+
+	if review.Status != pkg.OrderReviewStatusPending {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorOrderReviewAlreadyResolved
+		return nil
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.cfg.OrderReviewClaimTimeout) * time.Second)
+	review, err = s.orderReviewRepository.Claim(ctx, req.Id, req.UserId, expiresAt)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			res.Status = billingpb.ResponseStatusForbidden
+			res.Message = errorOrderReviewClaimedByAnotherUser
+			return nil
+		}
+
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorOrderReviewUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = review
+
+	return nil
+}
+
+// ApproveOrderReview releases the order held by the review. The authorized payment is captured and continues
+// the normal flow on the callback of the payment system, delivery and notifications of the captured payment
+// are released immediately.
+func (s *Service) ApproveOrderReview(
+	ctx context.Context,
+	req *pkg.OrderReviewRequest,
+	res *pkg.OrderReviewResponse,
+) error {
+	review, order, msg := s.getOrderReviewForResolution(ctx, req)
+
+	if msg != nil {
+		res.Status = msg.Status
+		res.Message = msg.Message
+		return nil
+	}
+
+	captured := order.PrivateStatus == recurringpb.OrderStatusPaymentSystemComplete
+
+	if !captured {
+		if msg := s.changeOrderReviewPaymentStatus(order, true); msg != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = msg
+			return nil
+		}
+	}
+
+	if err := s.resolveOrderReview(ctx, review, order, pkg.OrderReviewStatusApproved, req); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorOrderReviewUnknown
+		return nil
+	}
+
+	if captured {
+		if order.NeedCallbackNotification() {
+			s.orderNotifyMerchant(ctx, order)
+		}
+
+		if err := s.paymentSystemPaymentCallbackComplete(ctx, order); err != nil {
+			zap.L().Error("Payment form notification failed", zap.Error(err), zap.String("order_id", order.Id))
+		}
+
+		s.sendMailWithReceipt(ctx, order)
+	}
+
+	if err := s.updateOrder(ctx, order); err != nil {
+		zap.L().Error("s.updateOrder Method failed", zap.Error(err), zap.Any("order", order))
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorOrderReviewUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = review
+
+	return nil
+}
+
+// DeclineOrderReview rejects the order held by the review. The authorized payment is voided
+// and the captured payment is refunded.
+func (s *Service) DeclineOrderReview(
+	ctx context.Context,
+	req *pkg.OrderReviewRequest,
+	res *pkg.OrderReviewResponse,
+) error {
+	review, order, msg := s.getOrderReviewForResolution(ctx, req)
+
+	if msg != nil {
+		res.Status = msg.Status
+		res.Message = msg.Message
+		return nil
+	}
+
+	if order.PrivateStatus == recurringpb.OrderStatusPaymentSystemComplete {
+		refundReq := &billingpb.CreateRefundRequest{
+			OrderId:    order.Uuid,
+			Amount:     order.ChargeAmount,
+			CreatorId:  req.UserId,
+			Reason:     orderReviewDeclineReason,
+			MerchantId: order.GetMerchantId(),
+		}
+		refundRsp := &billingpb.CreateRefundResponse{}
+
+		if err := s.CreateRefund(ctx, refundReq, refundRsp); err != nil || refundRsp.Status != billingpb.ResponseStatusOk {
+			zap.L().Error(
+				"Refund of the order declined by review failed",
+				zap.Error(err),
+				zap.String("order_id", order.Id),
+				zap.Any("message", refundRsp.Message),
+			)
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = errorOrderReviewUnknown
+
+			if refundRsp.Message != nil {
+				res.Message = refundRsp.Message
+			}
+
+			return nil
+		}
+	} else {
+		if msg := s.changeOrderReviewPaymentStatus(order, false); msg != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = msg
+			return nil
+		}
+
+		order.PrivateStatus = recurringpb.OrderStatusPaymentSystemCanceled
+		order.CanceledAt = ptypes.TimestampNow()
+		order.Cancellation = &billingpb.OrderNotificationCancellation{Reason: orderReviewDeclineReason}
+	}
+
+	if err := s.resolveOrderReview(ctx, review, order, pkg.OrderReviewStatusDeclined, req); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorOrderReviewUnknown
+		return nil
+	}
+
+	if err := s.updateOrder(ctx, order); err != nil {
+		zap.L().Error("s.updateOrder Method failed", zap.Error(err), zap.Any("order", order))
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorOrderReviewUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = review
+
+	return nil
+}
+
+func (s *Service) getOrderReviewForResolution(
+	ctx context.Context,
+	req *pkg.OrderReviewRequest,
+) (*pkg.OrderReview, *billingpb.Order, *billingpb.ResponseError) {
+	review, err := s.orderReviewRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		return nil, nil, newBillingServerResponseError(billingpb.ResponseStatusNotFound, errorOrderReviewNotFound)
+	}
+
+	if review.Status != pkg.OrderReviewStatusPending {
+		return nil, nil, newBillingServerResponseError(billingpb.ResponseStatusBadData, errorOrderReviewAlreadyResolved)
+	}
+
+	if review.ClaimedBy == "" || review.ClaimedBy != req.UserId {
+		return nil, nil, newBillingServerResponseError(billingpb.ResponseStatusForbidden, errorOrderReviewNotClaimed)
+	}
+
+	order, err := s.getOrderById(ctx, review.OrderId)
+
+	if err != nil {
+		return nil, nil, newBillingServerResponseError(billingpb.ResponseStatusNotFound, orderErrorNotFound)
+	}
+
+	if order.PrivateStatus != recurringpb.OrderStatusPaymentSystemComplete && order.Transaction == "" {
+		return nil, nil, newBillingServerResponseError(billingpb.ResponseStatusBadData, errorOrderReviewPaymentNotAuthorized)
+	}
+
+	return review, order, nil
+}
+
+// changeOrderReviewPaymentStatus captures or voids the authorized payment of the order.
+func (s *Service) changeOrderReviewPaymentStatus(order *billingpb.Order, capture bool) *billingpb.ResponseErrorMessage {
+	h, err := s.paymentSystemGateway.getGateway(order.PaymentMethod.Handler)
+
+	if err == nil {
+		if capture {
+			err = h.CapturePayment(order)
+		} else {
+			err = h.CancelPayment(order)
+		}
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	zap.L().Error(
+		"Change status of the payment under review failed",
+		zap.Error(err),
+		zap.String("order_id", order.Id),
+		zap.Bool("capture", capture),
+	)
+
+	if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+		return e
+	}
+
+	return errorOrderReviewUnknown
+}
+
+func (s *Service) resolveOrderReview(
+	ctx context.Context,
+	review *pkg.OrderReview,
+	order *billingpb.Order,
+	status string,
+	req *pkg.OrderReviewRequest,
+) error {
+	review.Status = status
+	review.ResolvedBy = req.UserId
+	review.ResolvedAt = ptypes.TimestampNow()
+	review.Comment = req.Comment
+	review.UpdatedAt = ptypes.TimestampNow()
+
+	if err := s.orderReviewRepository.Upsert(ctx, review); err != nil {
+		return err
+	}
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus] = status
+
+	return nil
+}
+
+// createOrderReview puts the order to the queue of manual reviews, the pending review of the order
+// is reused on the next payment attempt.
+func (s *Service) createOrderReview(
+	ctx context.Context,
+	order *billingpb.Order,
+	assessment *pkg.RiskAssessment,
+) error {
+	review, err := s.orderReviewRepository.GetPendingByOrderId(ctx, order.Id)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	if review == nil {
+		slaDueAt, _ := ptypes.TimestampProto(time.Now().Add(time.Duration(s.cfg.OrderReviewSla) * time.Second))
+		review = &pkg.OrderReview{
+			Id:         primitive.NewObjectID().Hex(),
+			OrderId:    order.Id,
+			OrderUuid:  order.Uuid,
+			MerchantId: order.GetMerchantId(),
+			ProjectId:  order.GetProjectId(),
+			Status:     pkg.OrderReviewStatusPending,
+			SlaDueAt:   slaDueAt,
+			CreatedAt:  ptypes.TimestampNow(),
+		}
+	}
+
+	review.RiskAssessmentId = assessment.Id
+	review.Score = assessment.Score
+	review.Amount = order.ChargeAmount
+	review.Currency = order.ChargeCurrency
+	review.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.orderReviewRepository.Upsert(ctx, review); err != nil {
+		return err
+	}
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataReviewId] = review.Id
+	order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus] = pkg.OrderReviewStatusPending
+
+	return nil
+}
+
+// processOrderReviewPayment updates the pending review of the order by the result of the payment. The review is
+// canceled when the payment is failed.
+func (s *Service) processOrderReviewPayment(ctx context.Context, order *billingpb.Order) {
+	if order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus] != pkg.OrderReviewStatusPending {
+		return
+	}
+
+	review, err := s.orderReviewRepository.GetPendingByOrderId(ctx, order.Id)
+
+	if err != nil {
+		return
+	}
+
+	switch order.PrivateStatus {
+	case recurringpb.OrderStatusPaymentSystemComplete:
+		review.Captured = true
+	case recurringpb.OrderStatusPaymentSystemDeclined, recurringpb.OrderStatusPaymentSystemCanceled:
+		review.Status = pkg.OrderReviewStatusCanceled
+		review.ResolvedAt = ptypes.TimestampNow()
+		order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus] = pkg.OrderReviewStatusCanceled
+	default:
+		return
+	}
+
+	review.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.orderReviewRepository.Upsert(ctx, review); err != nil {
+		zap.L().Error("Failed to update order review", zap.Error(err), zap.String("order_id", order.Id))
+	}
+}
+
+// isOrderHeldByReview checks that delivery of the order and notifications of the merchant wait for the review.
+// Orders declined by the review are never delivered.
+func (s *Service) isOrderHeldByReview(order *billingpb.Order) bool {
+	status := order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus]
+	return status == pkg.OrderReviewStatusPending || status == pkg.OrderReviewStatusDeclined
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+type OrderReviewTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant   *billingpb.Merchant
+	project    *billingpb.Project
+	pmBankCard *billingpb.PaymentMethod
+}
+
+func Test_OrderReview(t *testing.T) {
+	suite.Run(t, new(OrderReviewTestSuite))
+}
+
+func (suite *OrderReviewTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, suite.pmBankCard, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *OrderReviewTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+// helperCreateOrderReview puts the paid order to the queue of manual reviews
+func (suite *OrderReviewTestSuite) helperCreateOrderReview(authorizedOnly bool) (*billingpb.Order, *pkg.OrderReview) {
+	order := HelperCreateAndPayOrder(suite.Suite, suite.service, 100, "RUB", "RU", suite.project, suite.pmBankCard)
+
+	if authorizedOnly {
+		order.PrivateStatus = recurringpb.OrderStatusPaymentSystemCreate
+		order.Status = recurringpb.OrderPublicStatusCreated
+	}
+
+	assessment := &pkg.RiskAssessment{Id: primitive.NewObjectID().Hex(), Score: 70}
+	err := suite.service.createOrderReview(context.TODO(), order, assessment)
+	assert.NoError(suite.T(), err)
+
+	err = suite.service.orderRepository.Update(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+
+	review, err := suite.service.orderReviewRepository.GetPendingByOrderId(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+
+	return order, review
+}
+
+func (suite *OrderReviewTestSuite) helperClaimOrderReview(id, userId string) {
+	res := &pkg.OrderReviewResponse{}
+	err := suite.service.ClaimOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: id, UserId: userId}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_CreateOrderReview_Ok() {
+	order, review := suite.helperCreateOrderReview(false)
+	assert.Equal(suite.T(), order.Id, review.OrderId)
+	assert.Equal(suite.T(), order.Uuid, review.OrderUuid)
+	assert.Equal(suite.T(), suite.merchant.Id, review.MerchantId)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusPending, review.Status)
+	assert.EqualValues(suite.T(), 70, review.Score)
+	assert.NotNil(suite.T(), review.SlaDueAt)
+	assert.Equal(suite.T(), review.Id, order.PrivateMetadata[pkg.OrderPrivateMetadataReviewId])
+	assert.True(suite.T(), suite.service.isOrderHeldByReview(order))
+
+	// the pending review is reused by the next payment attempt
+	assessment := &pkg.RiskAssessment{Id: primitive.NewObjectID().Hex(), Score: 80}
+	err := suite.service.createOrderReview(context.TODO(), order, assessment)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), review.Id, order.PrivateMetadata[pkg.OrderPrivateMetadataReviewId])
+
+	review, err = suite.service.orderReviewRepository.GetById(context.TODO(), review.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 80, review.Score)
+	assert.Equal(suite.T(), assessment.Id, review.RiskAssessmentId)
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_ListOrderReviews_Ok() {
+	suite.helperCreateOrderReview(false)
+
+	res := &pkg.ListOrderReviewsResponse{}
+	err := suite.service.ListOrderReviews(context.TODO(), &pkg.ListOrderReviewsRequest{MerchantId: suite.merchant.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.EqualValues(suite.T(), 1, res.Count)
+	assert.Len(suite.T(), res.Items, 1)
+
+	res = &pkg.ListOrderReviewsResponse{}
+	err = suite.service.ListOrderReviews(context.TODO(), &pkg.ListOrderReviewsRequest{Overdue: true}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.EqualValues(suite.T(), 0, res.Count)
+	assert.Empty(suite.T(), res.Items)
+
+	res = &pkg.ListOrderReviewsResponse{}
+	err = suite.service.ListOrderReviews(context.TODO(), &pkg.ListOrderReviewsRequest{Status: pkg.OrderReviewStatusApproved}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0, res.Count)
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_ClaimOrderReview_Ok() {
+	_, review := suite.helperCreateOrderReview(false)
+
+	res := &pkg.OrderReviewResponse{}
+	err := suite.service.ClaimOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: review.Id, UserId: "user1"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), "user1", res.Item.ClaimedBy)
+	assert.NotNil(suite.T(), res.Item.ClaimExpiresAt)
+
+	res = &pkg.OrderReviewResponse{}
+	err = suite.service.ClaimOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: review.Id, UserId: "user2"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusForbidden, res.Status)
+	assert.Equal(suite.T(), errorOrderReviewClaimedByAnotherUser, res.Message)
+
+	// the claim is prolonged by the same user
+	suite.helperClaimOrderReview(review.Id, "user1")
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_ClaimOrderReview_NotFound() {
+	res := &pkg.OrderReviewResponse{}
+	err := suite.service.ClaimOrderReview(
+		context.TODO(),
+		&pkg.OrderReviewRequest{Id: primitive.NewObjectID().Hex(), UserId: "user1"},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorOrderReviewNotFound, res.Message)
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_ApproveOrderReview_NotClaimed() {
+	_, review := suite.helperCreateOrderReview(false)
+
+	res := &pkg.OrderReviewResponse{}
+	err := suite.service.ApproveOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: review.Id, UserId: "user1"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusForbidden, res.Status)
+	assert.Equal(suite.T(), errorOrderReviewNotClaimed, res.Message)
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_ApproveOrderReview_Captured_Ok() {
+	order, review := suite.helperCreateOrderReview(false)
+	suite.helperClaimOrderReview(review.Id, "user1")
+
+	res := &pkg.OrderReviewResponse{}
+	err := suite.service.ApproveOrderReview(
+		context.TODO(),
+		&pkg.OrderReviewRequest{Id: review.Id, UserId: "user1", Comment: "ok"},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusApproved, res.Item.Status)
+	assert.Equal(suite.T(), "user1", res.Item.ResolvedBy)
+	assert.Equal(suite.T(), "ok", res.Item.Comment)
+	assert.NotNil(suite.T(), res.Item.ResolvedAt)
+
+	order, err = suite.service.getOrderById(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusApproved, order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus])
+	assert.False(suite.T(), suite.service.isOrderHeldByReview(order))
+
+	res = &pkg.OrderReviewResponse{}
+	err = suite.service.ApproveOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: review.Id, UserId: "user1"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorOrderReviewAlreadyResolved, res.Message)
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_ApproveOrderReview_Authorized_Ok() {
+	order, review := suite.helperCreateOrderReview(true)
+	suite.helperClaimOrderReview(review.Id, "user1")
+
+	res := &pkg.OrderReviewResponse{}
+	err := suite.service.ApproveOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: review.Id, UserId: "user1"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	// the order is completed by the callback of the captured payment
+	order, err = suite.service.getOrderById(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), recurringpb.OrderStatusPaymentSystemCreate, order.PrivateStatus)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusApproved, order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus])
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_ApproveOrderReview_NotAuthorized() {
+	order, review := suite.helperCreateOrderReview(true)
+	suite.helperClaimOrderReview(review.Id, "user1")
+
+	order.Transaction = ""
+	err := suite.service.orderRepository.Update(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+
+	res := &pkg.OrderReviewResponse{}
+	err = suite.service.ApproveOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: review.Id, UserId: "user1"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorOrderReviewPaymentNotAuthorized, res.Message)
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_DeclineOrderReview_Authorized_Ok() {
+	order, review := suite.helperCreateOrderReview(true)
+	suite.helperClaimOrderReview(review.Id, "user1")
+
+	res := &pkg.OrderReviewResponse{}
+	err := suite.service.DeclineOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: review.Id, UserId: "user1"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusDeclined, res.Item.Status)
+
+	order, err = suite.service.getOrderById(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), recurringpb.OrderStatusPaymentSystemCanceled, order.PrivateStatus)
+	assert.NotNil(suite.T(), order.CanceledAt)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusDeclined, order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus])
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_DeclineOrderReview_Captured_Ok() {
+	order, review := suite.helperCreateOrderReview(false)
+	suite.helperClaimOrderReview(review.Id, "user1")
+
+	res := &pkg.OrderReviewResponse{}
+	err := suite.service.DeclineOrderReview(context.TODO(), &pkg.OrderReviewRequest{Id: review.Id, UserId: "user1"}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusDeclined, res.Item.Status)
+
+	count, err := suite.service.refundRepository.CountByOrderUuid(context.TODO(), order.Uuid)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, count)
+
+	order, err = suite.service.getOrderById(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), suite.service.isOrderHeldByReview(order))
+}
+
+func (suite *OrderReviewTestSuite) TestOrderReview_ProcessOrderReviewPayment() {
+	order, review := suite.helperCreateOrderReview(false)
+
+	suite.service.processOrderReviewPayment(context.TODO(), order)
+	review, err := suite.service.orderReviewRepository.GetById(context.TODO(), review.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), review.Captured)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusPending, review.Status)
+
+	order.PrivateStatus = recurringpb.OrderStatusPaymentSystemDeclined
+	suite.service.processOrderReviewPayment(context.TODO(), order)
+	review, err = suite.service.orderReviewRepository.GetById(context.TODO(), review.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusCanceled, review.Status)
+	assert.Equal(suite.T(), pkg.OrderReviewStatusCanceled, order.PrivateMetadata[pkg.OrderPrivateMetadataReviewStatus])
+	assert.False(suite.T(), suite.service.isOrderHeldByReview(order))
+}
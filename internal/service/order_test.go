@@ -8049,6 +8049,38 @@ func (suite *OrderTestSuite) TestOrder_OrderCreateByPaylink_Ok() {
 	assert.Equal(suite.T(), rsp.Item.Project.MerchantId, suite.paylink1.MerchantId)
 }
 
+func (suite *OrderTestSuite) TestOrder_OrderCreateByPaylink_Ok_WithVariant() {
+	variant := &pkg.PaylinkVariant{
+		PaylinkId:  suite.paylink1.Id,
+		MerchantId: suite.paylink1.MerchantId,
+		Name:       "one product",
+		Weight:     1,
+		Products:   suite.productIds[:1],
+	}
+	err := suite.service.paylinkVariantRepository.Upsert(context.TODO(), variant)
+	assert.NoError(suite.T(), err)
+
+	req := &billingpb.OrderCreateByPaylink{
+		PaylinkId: suite.paylink1.Id,
+		PayerIp:   "127.0.0.1",
+		Cookie:    "visitor",
+	}
+
+	rsp := &billingpb.OrderCreateProcessResponse{}
+	err = suite.service.OrderCreateByPaylink(context.TODO(), req, rsp)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), variant.Id, rsp.Item.PrivateMetadata[pkg.OrderPrivateMetadataPaylinkVariantId])
+	assert.Len(suite.T(), rsp.Item.Items, 1)
+
+	stat, err := suite.service.paylinkFunnelEventRepository.GetStat(context.TODO(), suite.paylink1.Id, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), stat, 1)
+	assert.Equal(suite.T(), variant.Id, stat[0].VariantId)
+	assert.Equal(suite.T(), pkg.PaylinkFunnelStepFormOpen, stat[0].Step)
+	assert.EqualValues(suite.T(), 1, stat[0].Count)
+}
+
 func (suite *OrderTestSuite) TestOrder_OrderCreateByPaylink_Fail_Expired() {
 	req := &billingpb.OrderCreateByPaylink{
 		PaylinkId:   suite.paylink3.Id,
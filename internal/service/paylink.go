@@ -295,75 +295,16 @@ func (s *Service) CreateOrUpdatePaylink(
 		return nil
 	}
 
-	for _, productId := range req.Products {
-		switch req.ProductsType {
+	status, msg, err := s.checkPaylinkProducts(ctx, pl, req.ProductsType, req.Products)
 
-		case pkg.OrderType_product:
-			product, err := s.productRepository.GetById(ctx, productId)
-			if err != nil {
-				if err.Error() == "product not found" || err == mongo.ErrNoDocuments {
-					res.Status = billingpb.ResponseStatusNotFound
-					res.Message = errorPaylinkProductNotFoundOrInvalidType
-					return nil
-				}
-
-				if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
-					res.Status = billingpb.ResponseStatusBadData
-					res.Message = e
-					return nil
-				}
-				return err
-			}
-
-			if product.MerchantId != pl.MerchantId {
-				res.Status = billingpb.ResponseStatusBadData
-				res.Message = errorPaylinkProductNotBelongToMerchant
-				return nil
-			}
-
-			if product.ProjectId != pl.ProjectId {
-				res.Status = billingpb.ResponseStatusBadData
-				res.Message = errorPaylinkProductNotBelongToProject
-				return nil
-			}
-
-			break
-
-		case pkg.OrderType_key:
-			product, err := s.keyProductRepository.GetById(ctx, productId)
-			if err != nil {
-				if err.Error() == "key_product not found" || err == mongo.ErrNoDocuments {
-					res.Status = billingpb.ResponseStatusNotFound
-					res.Message = errorPaylinkProductNotFoundOrInvalidType
-					return nil
-				}
-
-				if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
-					res.Status = billingpb.ResponseStatusBadData
-					res.Message = e
-					return nil
-				}
-				return err
-			}
-
-			if product.MerchantId != pl.MerchantId {
-				res.Status = billingpb.ResponseStatusBadData
-				res.Message = errorPaylinkProductNotBelongToMerchant
-				return nil
-			}
-
-			if product.ProjectId != pl.ProjectId {
-				res.Status = billingpb.ResponseStatusBadData
-				res.Message = errorPaylinkProductNotBelongToProject
-				return nil
-			}
-			break
+	if err != nil {
+		return err
+	}
 
-		default:
-			res.Status = billingpb.ResponseStatusBadData
-			res.Message = errorPaylinkProductsTypeInvalid
-			return nil
-		}
+	if msg != nil {
+		res.Status = status
+		res.Message = msg
+		return nil
 	}
 
 	pl.ProductsType = req.ProductsType
@@ -566,3 +507,100 @@ func (s *Service) getPaylinkUrl(
 
 	return u.String(), nil
 }
+
+// checkPaylinkProducts checks that products of the paylink or its variant exist, have the type of the paylink
+// and belong to the project of the paylink.
+func (s *Service) checkPaylinkProducts(
+	ctx context.Context,
+	pl *billingpb.Paylink,
+	productsType string,
+	products []string,
+) (int32, *billingpb.ResponseErrorMessage, error) {
+	for _, productId := range products {
+		switch productsType {
+
+		case pkg.OrderType_product:
+			product, err := s.productRepository.GetById(ctx, productId)
+			if err != nil {
+				if err.Error() == "product not found" || err == mongo.ErrNoDocuments {
+					return billingpb.ResponseStatusNotFound, errorPaylinkProductNotFoundOrInvalidType, nil
+				}
+
+				if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+					return billingpb.ResponseStatusBadData, e, nil
+				}
+				return 0, nil, err
+			}
+
+			if product.MerchantId != pl.MerchantId {
+				return billingpb.ResponseStatusBadData, errorPaylinkProductNotBelongToMerchant, nil
+			}
+
+			if product.ProjectId != pl.ProjectId {
+				return billingpb.ResponseStatusBadData, errorPaylinkProductNotBelongToProject, nil
+			}
+
+			break
+
+		case pkg.OrderType_key:
+			product, err := s.keyProductRepository.GetById(ctx, productId)
+			if err != nil {
+				if err.Error() == "key_product not found" || err == mongo.ErrNoDocuments {
+					return billingpb.ResponseStatusNotFound, errorPaylinkProductNotFoundOrInvalidType, nil
+				}
+
+				if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+					return billingpb.ResponseStatusBadData, e, nil
+				}
+				return 0, nil, err
+			}
+
+			if product.MerchantId != pl.MerchantId {
+				return billingpb.ResponseStatusBadData, errorPaylinkProductNotBelongToMerchant, nil
+			}
+
+			if product.ProjectId != pl.ProjectId {
+				return billingpb.ResponseStatusBadData, errorPaylinkProductNotBelongToProject, nil
+			}
+			break
+
+		case pkg.OrderTypeMixed:
+			merchantId, projectId, ok := s.getPaylinkCartItemOwner(ctx, productId)
+			if !ok {
+				return billingpb.ResponseStatusNotFound, errorPaylinkProductNotFoundOrInvalidType, nil
+			}
+
+			if merchantId != pl.MerchantId {
+				return billingpb.ResponseStatusBadData, errorPaylinkProductNotBelongToMerchant, nil
+			}
+
+			if projectId != pl.ProjectId {
+				return billingpb.ResponseStatusBadData, errorPaylinkProductNotBelongToProject, nil
+			}
+			break
+
+		default:
+			return billingpb.ResponseStatusBadData, errorPaylinkProductsTypeInvalid, nil
+		}
+	}
+
+	return billingpb.ResponseStatusOk, nil, nil
+}
+
+// getPaylinkCartItemOwner returns the merchant and the project of the bundle, key product or product
+// of the mixed paylink.
+func (s *Service) getPaylinkCartItemOwner(ctx context.Context, id string) (string, string, bool) {
+	if bundle, err := s.bundleRepository.GetById(ctx, id); err == nil {
+		return bundle.MerchantId, bundle.ProjectId, true
+	}
+
+	if keyProduct, err := s.keyProductRepository.GetById(ctx, id); err == nil {
+		return keyProduct.MerchantId, keyProduct.ProjectId, true
+	}
+
+	if product, err := s.productRepository.GetById(ctx, id); err == nil {
+		return product.MerchantId, product.ProjectId, true
+	}
+
+	return "", "", false
+}
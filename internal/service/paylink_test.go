@@ -11,6 +11,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/internal/config"
 	"github.com/paysuper/paysuper-billing-server/internal/database"
 	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
 	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
@@ -1526,3 +1527,257 @@ func (suite *PaylinkTestSuite) Test_Paylink_GetPaylinkVisits_Ok() {
 	assert.NoError(suite.T(), err)
 	assert.EqualValues(suite.T(), n, 0)
 }
+
+func (suite *PaylinkTestSuite) Test_Paylink_CreateOrUpdatePaylinkVariant_Ok() {
+	req := &pkg.CreateOrUpdatePaylinkVariantRequest{
+		PaylinkId:  suite.paylink1.Id,
+		MerchantId: suite.paylink1.MerchantId,
+		Name:       "one product",
+		Weight:     50,
+		Products:   []string{suite.product1.Id},
+	}
+
+	res := &pkg.PaylinkVariantResponse{}
+	err := suite.service.CreateOrUpdatePaylinkVariant(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.NotEmpty(suite.T(), res.Item.Id)
+	assert.Equal(suite.T(), suite.paylink1.Id, res.Item.PaylinkId)
+	assert.EqualValues(suite.T(), 50, res.Item.Weight)
+
+	req.Id = res.Item.Id
+	req.Weight = 30
+	req.Products = nil
+
+	res = &pkg.PaylinkVariantResponse{}
+	err = suite.service.CreateOrUpdatePaylinkVariant(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), req.Id, res.Item.Id)
+	assert.EqualValues(suite.T(), 30, res.Item.Weight)
+	assert.Empty(suite.T(), res.Item.Products)
+
+	res2 := &pkg.GetPaylinkVariantsResponse{}
+	err = suite.service.GetPaylinkVariants(
+		context.TODO(),
+		&pkg.GetPaylinkVariantsRequest{PaylinkId: suite.paylink1.Id, MerchantId: suite.paylink1.MerchantId},
+		res2,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res2.Status)
+	assert.Len(suite.T(), res2.Items, 1)
+
+	res3 := &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.DeletePaylinkVariant(
+		context.TODO(),
+		&pkg.DeletePaylinkVariantRequest{Id: req.Id, PaylinkId: suite.paylink1.Id, MerchantId: suite.paylink1.MerchantId},
+		res3,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res3.Status)
+
+	res2 = &pkg.GetPaylinkVariantsResponse{}
+	err = suite.service.GetPaylinkVariants(
+		context.TODO(),
+		&pkg.GetPaylinkVariantsRequest{PaylinkId: suite.paylink1.Id, MerchantId: suite.paylink1.MerchantId},
+		res2,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), res2.Items)
+}
+
+func (suite *PaylinkTestSuite) Test_Paylink_CreateOrUpdatePaylinkVariant_Fail() {
+	req := &pkg.CreateOrUpdatePaylinkVariantRequest{
+		PaylinkId:  suite.paylink1.Id,
+		MerchantId: suite.merchant2.Id,
+		Weight:     1,
+	}
+
+	res := &pkg.PaylinkVariantResponse{}
+	err := suite.service.CreateOrUpdatePaylinkVariant(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorPaylinkNotFound, res.Message)
+
+	req.MerchantId = suite.paylink1.MerchantId
+	req.Weight = -1
+
+	res = &pkg.PaylinkVariantResponse{}
+	err = suite.service.CreateOrUpdatePaylinkVariant(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorPaylinkVariantWeightInvalid, res.Message)
+
+	req.Weight = 1
+	req.Products = []string{suite.product3.Id}
+
+	res = &pkg.PaylinkVariantResponse{}
+	err = suite.service.CreateOrUpdatePaylinkVariant(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorPaylinkProductNotBelongToProject, res.Message)
+
+	req.Products = nil
+	req.PromoCode = "unknown"
+
+	res = &pkg.PaylinkVariantResponse{}
+	err = suite.service.CreateOrUpdatePaylinkVariant(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorPaylinkVariantPromoCodeNotFound, res.Message)
+
+	req.PromoCode = ""
+	req.Id = primitive.NewObjectID().Hex()
+
+	res = &pkg.PaylinkVariantResponse{}
+	err = suite.service.CreateOrUpdatePaylinkVariant(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorPaylinkVariantNotFound, res.Message)
+}
+
+func (suite *PaylinkTestSuite) Test_Paylink_SelectPaylinkVariant() {
+	variants := []*pkg.PaylinkVariant{
+		{Id: primitive.NewObjectID().Hex(), Weight: 1},
+		{Id: primitive.NewObjectID().Hex(), Weight: 0},
+		{Id: primitive.NewObjectID().Hex(), Weight: 1},
+	}
+
+	variant := selectPaylinkVariant(variants, suite.paylink1.Id, "visitor")
+	assert.NotNil(suite.T(), variant)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(suite.T(), variant, selectPaylinkVariant(variants, suite.paylink1.Id, "visitor"))
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.NotEqual(suite.T(), variants[1], selectPaylinkVariant(variants, suite.paylink1.Id, ""))
+	}
+
+	assert.Nil(suite.T(), selectPaylinkVariant(variants[1:2], suite.paylink1.Id, "visitor"))
+	assert.Nil(suite.T(), selectPaylinkVariant(nil, suite.paylink1.Id, "visitor"))
+}
+
+func (suite *PaylinkTestSuite) Test_Paylink_AssignPaylinkVariant_Ok() {
+	res := &pkg.PaylinkVariantResponse{}
+	err := suite.service.AssignPaylinkVariant(
+		context.TODO(),
+		&pkg.AssignPaylinkVariantRequest{PaylinkId: suite.paylink1.Id, VisitorId: "visitor"},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Nil(suite.T(), res.Item)
+
+	variant := &pkg.PaylinkVariant{PaylinkId: suite.paylink1.Id, MerchantId: suite.paylink1.MerchantId, Weight: 1}
+	err = suite.service.paylinkVariantRepository.Upsert(context.TODO(), variant)
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < 2; i++ {
+		res = &pkg.PaylinkVariantResponse{}
+		err = suite.service.AssignPaylinkVariant(
+			context.TODO(),
+			&pkg.AssignPaylinkVariantRequest{PaylinkId: suite.paylink1.Id, VisitorId: "visitor"},
+			res,
+		)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+		assert.Equal(suite.T(), variant.Id, res.Item.Id)
+	}
+
+	stat, err := suite.service.paylinkFunnelEventRepository.GetStat(context.TODO(), suite.paylink1.Id, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), stat, 2)
+
+	for _, item := range stat {
+		assert.Equal(suite.T(), pkg.PaylinkFunnelStepVisit, item.Step)
+		assert.EqualValues(suite.T(), 1, item.Count)
+	}
+}
+
+func (suite *PaylinkTestSuite) Test_Paylink_AssignPaylinkVariant_Fail_Expired() {
+	res := &pkg.PaylinkVariantResponse{}
+	err := suite.service.AssignPaylinkVariant(
+		context.TODO(),
+		&pkg.AssignPaylinkVariantRequest{PaylinkId: suite.paylink3.Id, VisitorId: "visitor"},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusGone, res.Status)
+	assert.Equal(suite.T(), errorPaylinkExpired, res.Message)
+}
+
+func (suite *PaylinkTestSuite) Test_Paylink_GetPaylinkFunnelStat_Ok() {
+	control := &pkg.PaylinkVariant{PaylinkId: suite.paylink1.Id, MerchantId: suite.paylink1.MerchantId, Name: "A", Weight: 1}
+	err := suite.service.paylinkVariantRepository.Upsert(context.TODO(), control)
+	assert.NoError(suite.T(), err)
+
+	variant := &pkg.PaylinkVariant{PaylinkId: suite.paylink1.Id, MerchantId: suite.paylink1.MerchantId, Name: "B", Weight: 1}
+	err = suite.service.paylinkVariantRepository.Upsert(context.TODO(), variant)
+	assert.NoError(suite.T(), err)
+
+	counts := map[string][]int{
+		control.Id: {100, 50, 20, 10},
+		variant.Id: {100, 60, 40, 30},
+	}
+
+	for variantId, steps := range counts {
+		for i, count := range steps {
+			for j := 0; j < count; j++ {
+				event := &pkg.PaylinkFunnelEvent{
+					PaylinkId: suite.paylink1.Id,
+					VariantId: variantId,
+					Step:      paylinkFunnelSteps[i],
+				}
+
+				if i == 0 {
+					event.VisitorId = primitive.NewObjectID().Hex()
+				} else {
+					event.OrderId = primitive.NewObjectID().Hex()
+				}
+
+				err = suite.service.paylinkFunnelEventRepository.Insert(context.TODO(), event)
+				assert.NoError(suite.T(), err)
+
+				// repeated payment attempts of the same order are counted once
+				if i == 2 {
+					event.Id = ""
+					err = suite.service.paylinkFunnelEventRepository.Insert(context.TODO(), event)
+					assert.NoError(suite.T(), err)
+				}
+			}
+		}
+	}
+
+	res := &pkg.GetPaylinkFunnelStatResponse{}
+	err = suite.service.GetPaylinkFunnelStat(
+		context.TODO(),
+		&billingpb.GetPaylinkStatCommonRequest{Id: suite.paylink1.Id, MerchantId: suite.paylink1.MerchantId},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.Items, 2)
+
+	assert.Equal(suite.T(), control.Id, res.Items[0].VariantId)
+	assert.True(suite.T(), res.Items[0].IsControl)
+	assert.EqualValues(suite.T(), 10, res.Items[0].Conversion)
+	assert.Len(suite.T(), res.Items[0].Steps, 4)
+	assert.EqualValues(suite.T(), 20, res.Items[0].Steps[2].Count)
+	assert.EqualValues(suite.T(), 40, res.Items[0].Steps[2].Conversion)
+
+	assert.Equal(suite.T(), variant.Id, res.Items[1].VariantId)
+	assert.False(suite.T(), res.Items[1].IsControl)
+	assert.EqualValues(suite.T(), 30, res.Items[1].Conversion)
+	assert.EqualValues(suite.T(), 200, res.Items[1].Uplift)
+	assert.True(suite.T(), res.Items[1].IsSignificant)
+	assert.False(suite.T(), res.Items[1].Steps[1].IsSignificant)
+	assert.True(suite.T(), res.Items[1].Steps[2].IsSignificant)
+}
+
+func (suite *PaylinkTestSuite) Test_Paylink_GetTwoProportionPValue() {
+	assert.EqualValues(suite.T(), 1, getTwoProportionPValue(10, 100, 10, 100))
+	assert.EqualValues(suite.T(), 1, getTwoProportionPValue(0, 0, 10, 100))
+	assert.EqualValues(suite.T(), 1, getTwoProportionPValue(0, 100, 0, 100))
+	assert.True(suite.T(), getTwoProportionPValue(10, 100, 30, 100) < paylinkFunnelSignificanceLevel)
+}
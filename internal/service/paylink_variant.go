@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// paylinkFunnelSignificanceLevel is a maximal p-value of the difference of conversions
+	// which is considered significant.
+	paylinkFunnelSignificanceLevel = 0.05
+)
+
+var (
+	errorPaylinkVariantNotFound          = newBillingServerErrorMsg("pl000011", "paylink variant not found")
+	errorPaylinkVariantWeightInvalid     = newBillingServerErrorMsg("pl000012", "paylink variant weight must not be negative")
+	errorPaylinkVariantPromoCodeNotFound = newBillingServerErrorMsg("pl000013", "promo code of paylink variant not found")
+	errorPaylinkVariantUnknown           = newBillingServerErrorMsg("pl000014", "unknown error with paylink variant")
+
+	paylinkFunnelSteps = []string{
+		pkg.PaylinkFunnelStepVisit,
+		pkg.PaylinkFunnelStepFormOpen,
+		pkg.PaylinkFunnelStepPaymentAttempt,
+		pkg.PaylinkFunnelStepSuccess,
+	}
+)
+
+// CreateOrUpdatePaylinkVariant creates or modifies the variant of the paylink
+func (s *Service) CreateOrUpdatePaylinkVariant(
+	ctx context.Context,
+	req *pkg.CreateOrUpdatePaylinkVariantRequest,
+	res *pkg.PaylinkVariantResponse,
+) error {
+	pl, err := s.paylinkRepository.GetByIdAndMerchant(ctx, req.PaylinkId, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorPaylinkNotFound
+		return nil
+	}
+
+	variant := &pkg.PaylinkVariant{
+		Id:         primitive.NewObjectID().Hex(),
+		PaylinkId:  pl.Id,
+		MerchantId: pl.MerchantId,
+		CreatedAt:  ptypes.TimestampNow(),
+	}
+
+	if req.Id != "" {
+		variant, err = s.paylinkVariantRepository.GetById(ctx, req.Id)
+
+		if err != nil || variant.PaylinkId != pl.Id {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = errorPaylinkVariantNotFound
+			return nil
+		}
+	}
+
+	if req.Weight < 0 {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorPaylinkVariantWeightInvalid
+		return nil
+	}
+
+	if len(req.Products) > 0 {
+		if len(req.Products) < s.cfg.PaylinkMinProducts || len(req.Products) > s.cfg.PaylinkMaxProducts {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = errorPaylinkProductsLengthInvalid
+			return nil
+		}
+
+		status, msg, err := s.checkPaylinkProducts(ctx, pl, pl.ProductsType, req.Products)
+
+		if err != nil {
+			return err
+		}
+
+		if msg != nil {
+			res.Status = status
+			res.Message = msg
+			return nil
+		}
+	}
+
+	code := normalizePromoCode(req.PromoCode)
+
+	if code != "" {
+		if _, err = s.promoRepository.GetByCode(ctx, pl.ProjectId, code); err != nil {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = errorPaylinkVariantPromoCodeNotFound
+			return nil
+		}
+	}
+
+	variant.Name = req.Name
+	variant.Weight = req.Weight
+	variant.Products = req.Products
+	variant.PromoCode = code
+	variant.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.paylinkVariantRepository.Upsert(ctx, variant); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPaylinkVariantUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = variant
+
+	return nil
+}
+
+// GetPaylinkVariants returns variants of the paylink
+func (s *Service) GetPaylinkVariants(
+	ctx context.Context,
+	req *pkg.GetPaylinkVariantsRequest,
+	res *pkg.GetPaylinkVariantsResponse,
+) error {
+	pl, err := s.paylinkRepository.GetByIdAndMerchant(ctx, req.PaylinkId, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorPaylinkNotFound
+		return nil
+	}
+
+	res.Items, err = s.paylinkVariantRepository.FindByPaylinkId(ctx, pl.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPaylinkVariantUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// DeletePaylinkVariant deletes the variant of the paylink, funnel events of the variant are kept for statistics
+func (s *Service) DeletePaylinkVariant(
+	ctx context.Context,
+	req *pkg.DeletePaylinkVariantRequest,
+	res *billingpb.EmptyResponseWithStatus,
+) error {
+	pl, err := s.paylinkRepository.GetByIdAndMerchant(ctx, req.PaylinkId, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorPaylinkNotFound
+		return nil
+	}
+
+	variant, err := s.paylinkVariantRepository.GetById(ctx, req.Id)
+
+	if err != nil || variant.PaylinkId != pl.Id {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorPaylinkVariantNotFound
+		return nil
+	}
+
+	variant.Deleted = true
+	variant.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.paylinkVariantRepository.Upsert(ctx, variant); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPaylinkVariantUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// AssignPaylinkVariant returns the variant of the paylink for the visitor and records the visit to the funnel.
+// The item of the response is empty if the paylink has no variants.
+func (s *Service) AssignPaylinkVariant(
+	ctx context.Context,
+	req *pkg.AssignPaylinkVariantRequest,
+	res *pkg.PaylinkVariantResponse,
+) error {
+	pl, err := s.paylinkRepository.GetById(ctx, req.PaylinkId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorPaylinkNotFound
+		return nil
+	}
+
+	if pl.GetIsExpired() {
+		res.Status = billingpb.ResponseStatusGone
+		res.Message = errorPaylinkExpired
+		return nil
+	}
+
+	variant, err := s.getPaylinkVariant(ctx, pl.Id, req.VisitorId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPaylinkVariantUnknown
+		return nil
+	}
+
+	event := &pkg.PaylinkFunnelEvent{
+		PaylinkId: pl.Id,
+		Step:      pkg.PaylinkFunnelStepVisit,
+		VisitorId: req.VisitorId,
+	}
+
+	if variant != nil {
+		event.VariantId = variant.Id
+	}
+
+	s.recordPaylinkFunnelEvent(ctx, event)
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = variant
+
+	return nil
+}
+
+// GetPaylinkFunnelStat returns conversions of funnel steps of variants of the paylink for the period
+func (s *Service) GetPaylinkFunnelStat(
+	ctx context.Context,
+	req *billingpb.GetPaylinkStatCommonRequest,
+	res *pkg.GetPaylinkFunnelStatResponse,
+) error {
+	pl, err := s.paylinkRepository.GetByIdAndMerchant(ctx, req.Id, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorPaylinkNotFound
+		return nil
+	}
+
+	variants, err := s.paylinkVariantRepository.FindByPaylinkId(ctx, pl.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPaylinkVariantUnknown
+		return nil
+	}
+
+	stat, err := s.paylinkFunnelEventRepository.GetStat(ctx, pl.Id, req.PeriodFrom, req.PeriodTo)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorPaylinkVariantUnknown
+		return nil
+	}
+
+	counts := make(map[string][]*pkg.PaylinkFunnelStepStat)
+
+	for _, item := range stat {
+		counts[item.VariantId] = append(
+			counts[item.VariantId],
+			&pkg.PaylinkFunnelStepStat{Step: item.Step, Count: item.Count},
+		)
+	}
+
+	known := make(map[string]bool)
+
+	for _, variant := range variants {
+		res.Items = append(res.Items, &pkg.PaylinkVariantFunnelStat{
+			VariantId: variant.Id,
+			Name:      variant.Name,
+			Weight:    variant.Weight,
+			Steps:     getPaylinkFunnelSteps(counts[variant.Id]),
+		})
+		known[variant.Id] = true
+	}
+
+	// events of deleted variants and events recorded before variants were created
+	var other []string
+
+	for variantId := range counts {
+		if !known[variantId] {
+			other = append(other, variantId)
+		}
+	}
+
+	sort.Strings(other)
+
+	for _, variantId := range other {
+		res.Items = append(res.Items, &pkg.PaylinkVariantFunnelStat{
+			VariantId: variantId,
+			Steps:     getPaylinkFunnelSteps(counts[variantId]),
+		})
+	}
+
+	if len(res.Items) > 0 {
+		setPaylinkFunnelSignificance(res.Items)
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// getPaylinkVariant returns the variant of the paylink for the visitor, the same visitor always gets
+// the same variant while weights of variants aren't changed. Nil is returned if the paylink has no variants
+// with positive weight.
+func (s *Service) getPaylinkVariant(ctx context.Context, paylinkId, visitorId string) (*pkg.PaylinkVariant, error) {
+	variants, err := s.paylinkVariantRepository.FindByPaylinkId(ctx, paylinkId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return selectPaylinkVariant(variants, paylinkId, visitorId), nil
+}
+
+func selectPaylinkVariant(variants []*pkg.PaylinkVariant, paylinkId, visitorId string) *pkg.PaylinkVariant {
+	total := int64(0)
+
+	for _, variant := range variants {
+		total += int64(variant.Weight)
+	}
+
+	if total <= 0 {
+		return nil
+	}
+
+	var point int64
+
+	if visitorId == "" {
+		point = rand.Int63n(total)
+	} else {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(paylinkId + visitorId))
+		point = int64(h.Sum32()) % total
+	}
+
+	for _, variant := range variants {
+		point -= int64(variant.Weight)
+
+		if point < 0 {
+			return variant
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) recordPaylinkFunnelEvent(ctx context.Context, event *pkg.PaylinkFunnelEvent) {
+	if err := s.paylinkFunnelEventRepository.Insert(ctx, event); err != nil {
+		zap.L().Error(
+			"Failed to record paylink funnel event",
+			zap.Error(err),
+			zap.Any("event", event),
+		)
+	}
+}
+
+// recordPaylinkOrderFunnelEvent records the funnel step for the order created by the paylink.
+func (s *Service) recordPaylinkOrderFunnelEvent(ctx context.Context, order *billingpb.Order, step string) {
+	paylinkId := order.PrivateMetadata[pkg.OrderPrivateMetadataPaylinkId]
+
+	if paylinkId == "" {
+		return
+	}
+
+	s.recordPaylinkFunnelEvent(ctx, &pkg.PaylinkFunnelEvent{
+		PaylinkId: paylinkId,
+		VariantId: order.PrivateMetadata[pkg.OrderPrivateMetadataPaylinkVariantId],
+		Step:      step,
+		OrderId:   order.Id,
+	})
+}
+
+// getPaylinkFunnelSteps returns all steps of the funnel in order and calculates conversions from previous steps.
+func getPaylinkFunnelSteps(counted []*pkg.PaylinkFunnelStepStat) []*pkg.PaylinkFunnelStepStat {
+	steps := make([]*pkg.PaylinkFunnelStepStat, len(paylinkFunnelSteps))
+
+	for i, name := range paylinkFunnelSteps {
+		steps[i] = &pkg.PaylinkFunnelStepStat{Step: name}
+
+		for _, step := range counted {
+			if step.Step == name {
+				steps[i].Count = step.Count
+			}
+		}
+
+		if i > 0 && steps[i-1].Count > 0 {
+			steps[i].Conversion = tools.FormatAmount(float64(steps[i].Count) / float64(steps[i-1].Count) * 100)
+		}
+	}
+
+	return steps
+}
+
+// setPaylinkFunnelSignificance compares conversions of variants with the first variant which is the control.
+func setPaylinkFunnelSignificance(items []*pkg.PaylinkVariantFunnelStat) {
+	control := items[0]
+	control.IsControl = true
+
+	for _, item := range items {
+		visits := item.Steps[0].Count
+		success := item.Steps[len(item.Steps)-1].Count
+
+		if visits > 0 {
+			item.Conversion = tools.FormatAmount(float64(success) / float64(visits) * 100)
+		}
+
+		if item == control {
+			continue
+		}
+
+		if control.Conversion > 0 {
+			item.Uplift = tools.FormatAmount((item.Conversion - control.Conversion) / control.Conversion * 100)
+		}
+
+		item.PValue = getTwoProportionPValue(
+			control.Steps[len(control.Steps)-1].Count,
+			control.Steps[0].Count,
+			success,
+			visits,
+		)
+		item.IsSignificant = item.PValue < paylinkFunnelSignificanceLevel
+
+		for i := 1; i < len(item.Steps); i++ {
+			item.Steps[i].PValue = getTwoProportionPValue(
+				control.Steps[i].Count,
+				control.Steps[i-1].Count,
+				item.Steps[i].Count,
+				item.Steps[i-1].Count,
+			)
+			item.Steps[i].IsSignificant = item.Steps[i].PValue < paylinkFunnelSignificanceLevel
+		}
+	}
+}
+
+// getTwoProportionPValue returns the two-tailed p-value of the z-test of the difference between
+// conversions x1/n1 and x2/n2, 1 is returned if the test can't be performed.
+func getTwoProportionPValue(x1, n1, x2, n2 int64) float64 {
+	if n1 <= 0 || n2 <= 0 {
+		return 1
+	}
+
+	p := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(p * (1 - p) * (1/float64(n1) + 1/float64(n2)))
+
+	if se == 0 {
+		return 1
+	}
+
+	z := (float64(x2)/float64(n2) - float64(x1)/float64(n1)) / se
+
+	return tools.ToFixed(math.Erfc(math.Abs(z)/math.Sqrt2), 4)
+}
@@ -29,6 +29,8 @@ var (
 	paymentSystemErrorRefundRequestAmountOrCurrencyIsInvalid = newBillingServerErrorMsg("ph000012", "amount or currency from request not match with value in refund")
 	paymentSystemErrorRequestTemporarySkipped                = newBillingServerErrorMsg("ph000013", "notification skipped with temporary status")
 	paymentSystemErrorRecurringFailed                        = newBillingServerErrorMsg("ph000014", "recurring payment failed")
+	paymentSystemErrorChangeStatusFailed                     = newBillingServerErrorMsg("ph000015", "payment status can't be changed. try request later")
+	paymentSystemErrorChangeStatusRejected                   = newBillingServerErrorMsg("ph000016", "payment status change request rejected")
 
 	registry = map[string]func() Gate{
 		billingpb.PaymentSystemHandlerCardPay: newCardPayHandler,
@@ -41,6 +43,10 @@ var (
 type Gate interface {
 	CreatePayment(order *billingpb.Order, successUrl, failUrl string, requisites map[string]string) (string, error)
 	ProcessPayment(order *billingpb.Order, message proto.Message, raw, signature string) error
+	// CapturePayment captures the authorized payment, the payment system notifies about the result by the callback.
+	CapturePayment(order *billingpb.Order) error
+	// CancelPayment voids the authorized payment.
+	CancelPayment(order *billingpb.Order) error
 	IsRecurringCallback(request proto.Message) bool
 	GetRecurringId(request proto.Message) string
 	CreateRefund(order *billingpb.Order, refund *billingpb.Refund) error
@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.uber.org/zap"
+	"sort"
+)
+
+const (
+	payoutBatchContentTypeXml = "application/xml"
+	payoutBatchContentTypeCsv = "text/csv"
+)
+
+var (
+	errorPayoutBatchFormatInvalid         = newBillingServerErrorMsg("pb000001", "payout batch format is not supported")
+	errorPayoutBatchDebtorAccountInvalid  = newBillingServerErrorMsg("pb000002", "debtor bank account is invalid for the payout batch format")
+	errorPayoutBatchCurrencyInvalid       = newBillingServerErrorMsg("pb000003", "sepa payout batch can be created for EUR payout documents only")
+	errorPayoutBatchDocumentsNotFound     = newBillingServerErrorMsg("pb000004", "pending payout documents with valid banking details not found")
+	errorPayoutBatchNotFound              = newBillingServerErrorMsg("pb000005", "payout batch not found")
+	errorPayoutBatchStatusFileInvalid     = newBillingServerErrorMsg("pb000006", "bank status file is invalid or has unsupported format")
+	errorPayoutBatchUnknown               = newBillingServerErrorMsg("pb000007", "unknown error. try request later")
+	errorPayoutBatchPayoutDocumentInvalid = newBillingServerErrorMsg("pb000008", "payout document is not in the payout batch")
+)
+
+// CreatePayoutBatches groups pending payout documents of the operating company by currency to payout batches
+// and moves payout documents to in-batch status. Payout documents with incomplete banking details
+// for the batch format and payout documents of merchants with banking or sanctions screening holds
+// are left in pending status.
+func (s *Service) CreatePayoutBatches(
+	ctx context.Context,
+	req *pkg.CreatePayoutBatchesRequest,
+	rsp *pkg.CreatePayoutBatchesResponse,
+) error {
+	if req.Format != pkg.PayoutBatchFormatSepa && req.Format != pkg.PayoutBatchFormatSwift {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = errorPayoutBatchFormatInvalid
+		return nil
+	}
+
+	operatingCompany, err := s.operatingCompanyRepository.GetById(ctx, req.OperatingCompanyId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorOperatingCompanyNotFound
+		return nil
+	}
+
+	debtorAccount := normalizeBankAccount(req.DebtorAccount)
+	debtorSwift := normalizeBankAccount(req.DebtorSwift)
+	isDebtorValid := debtorAccount != "" && swiftRegex.MatchString(debtorSwift)
+
+	if req.Format == pkg.PayoutBatchFormatSepa {
+		isDebtorValid = isValidIban(debtorAccount) && swiftRegex.MatchString(debtorSwift)
+	}
+
+	if !isDebtorValid {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = errorPayoutBatchDebtorAccountInvalid
+		return nil
+	}
+
+	currency := req.Currency
+
+	if req.Format == pkg.PayoutBatchFormatSepa {
+		if currency != "" && currency != sepaCurrency {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = errorPayoutBatchCurrencyInvalid
+			return nil
+		}
+
+		currency = sepaCurrency
+	}
+
+	payouts, err := s.payoutRepository.FindByOperatingCompany(
+		ctx, operatingCompany.Id, currency, []string{pkg.PayoutDocumentStatusPending},
+	)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorPayoutBatchUnknown
+		return nil
+	}
+
+	executionDate, err := ptypes.TimestampProto(getPayoutBatchExecutionDate(req.ExecutionDate))
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = errorPayoutBatchUnknown
+		return nil
+	}
+
+	debtorName := req.DebtorName
+
+	if debtorName == "" {
+		debtorName = operatingCompany.Name
+	}
+
+	groups := make(map[string][]*billingpb.PayoutDocument)
+	holds := make(map[string]bool)
+
+	for _, pd := range payouts {
+		if !validatePayoutBatchDestination(req.Format, pd) {
+			rsp.SkippedPayoutDocumentIds = append(rsp.SkippedPayoutDocumentIds, pd.Id)
+			continue
+		}
+
+		// holds may be opened after the payout document was created
+		isOnHold, ok := holds[pd.MerchantId]
+
+		if !ok {
+			isOnHold, err = s.isPayoutBatchMerchantOnHold(ctx, pd.MerchantId)
+
+			if err != nil {
+				rsp.Status = billingpb.ResponseStatusSystemError
+				rsp.Message = errorPayoutBatchUnknown
+				return nil
+			}
+
+			holds[pd.MerchantId] = isOnHold
+		}
+
+		if isOnHold {
+			rsp.SkippedPayoutDocumentIds = append(rsp.SkippedPayoutDocumentIds, pd.Id)
+			continue
+		}
+
+		groups[pd.Currency] = append(groups[pd.Currency], pd)
+	}
+
+	currencies := make([]string, 0, len(groups))
+
+	for k := range groups {
+		currencies = append(currencies, k)
+	}
+
+	sort.Strings(currencies)
+
+	for _, groupCurrency := range currencies {
+		batch := &pkg.PayoutBatch{
+			OperatingCompanyId: operatingCompany.Id,
+			Currency:           groupCurrency,
+			Format:             req.Format,
+			Status:             pkg.PayoutBatchStatusCreated,
+			PayoutDocumentIds:  []string{},
+			DebtorName:         debtorName,
+			DebtorAccount:      debtorAccount,
+			DebtorSwift:        debtorSwift,
+			ExecutionDate:      executionDate,
+			CreatedAt:          ptypes.TimestampNow(),
+			UpdatedAt:          ptypes.TimestampNow(),
+		}
+
+		for _, pd := range groups[groupCurrency] {
+			res := &billingpb.PayoutDocumentResponse{}
+			err = s.UpdatePayoutDocument(
+				ctx,
+				&billingpb.UpdatePayoutDocumentRequest{
+					PayoutDocumentId: pd.Id,
+					Status:           pkg.PayoutDocumentStatusInBatch,
+					Ip:               req.Ip,
+				},
+				res,
+			)
+
+			if err != nil || res.Status != billingpb.ResponseStatusOk {
+				zap.L().Error(
+					"Unable to move payout document to the payout batch",
+					zap.Error(err),
+					zap.String("payout_document_id", pd.Id),
+					zap.Any("response", res),
+				)
+				rsp.SkippedPayoutDocumentIds = append(rsp.SkippedPayoutDocumentIds, pd.Id)
+				continue
+			}
+
+			batch.PayoutDocumentIds = append(batch.PayoutDocumentIds, pd.Id)
+			batch.TotalAmount += pd.Balance
+		}
+
+		if len(batch.PayoutDocumentIds) <= 0 {
+			continue
+		}
+
+		batch.TotalAmount = tools.ToPrecise(batch.TotalAmount)
+
+		// batches created before the failure are kept and returned in the response
+		if err = s.payoutBatchRepository.Insert(ctx, batch); err != nil {
+			s.revertPayoutBatchDocuments(ctx, batch, req.Ip)
+			rsp.SkippedPayoutDocumentIds = append(rsp.SkippedPayoutDocumentIds, batch.PayoutDocumentIds...)
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = errorPayoutBatchUnknown
+			return nil
+		}
+
+		rsp.Items = append(rsp.Items, batch)
+	}
+
+	if len(rsp.Items) <= 0 {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorPayoutBatchDocumentsNotFound
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) GetPayoutBatch(
+	ctx context.Context,
+	req *pkg.GetPayoutBatchRequest,
+	rsp *pkg.PayoutBatchResponse,
+) error {
+	batch, err := s.payoutBatchRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorPayoutBatchNotFound
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = batch
+
+	return nil
+}
+
+// GetPayoutBatchFile returns bank file of the payout batch: ISO 20022 pain.001 credit transfer XML
+// for SEPA batches or CSV for SWIFT batches.
+func (s *Service) GetPayoutBatchFile(
+	ctx context.Context,
+	req *pkg.GetPayoutBatchRequest,
+	rsp *pkg.PayoutBatchFileResponse,
+) error {
+	batch, err := s.payoutBatchRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = errorPayoutBatchNotFound
+		return nil
+	}
+
+	payouts := make([]*billingpb.PayoutDocument, 0, len(batch.PayoutDocumentIds))
+
+	for _, id := range batch.PayoutDocumentIds {
+		pd, err := s.payoutRepository.GetById(ctx, id)
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = errorPayoutBatchUnknown
+			return nil
+		}
+
+		payouts = append(payouts, pd)
+	}
+
+	rsp.Content, err = newPayoutBatchFile(batch, payouts)
+
+	if err != nil {
+		zap.L().Error(
+			"Unable to generate the payout batch file",
+			zap.Error(err),
+			zap.String("payout_batch_id", batch.Id),
+		)
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = errorPayoutBatchUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Filename = fmt.Sprintf("payout_batch_%s.csv", batch.Id)
+	rsp.ContentType = payoutBatchContentTypeCsv
+
+	if batch.Format == pkg.PayoutBatchFormatSepa {
+		rsp.Filename = fmt.Sprintf("payout_batch_%s.xml", batch.Id)
+		rsp.ContentType = payoutBatchContentTypeXml
+	}
+
+	return nil
+}
+
+// ImportPayoutBatchStatus updates statuses of payout documents in payout batches from the bank status file
+// (pain.002, camt.053 or camt.054). Settled transfers become paid, rejected transfers become failed and transfers
+// returned after settlement move from paid to failed. Status of the whole batch is applied only to payout documents
+// of the batch which are still in-batch and have no own status in the file.
+// Payout batch is marked processed when all of its payout documents left in-batch status.
+func (s *Service) ImportPayoutBatchStatus(
+	ctx context.Context,
+	req *pkg.ImportPayoutBatchStatusRequest,
+	rsp *pkg.ImportPayoutBatchStatusResponse,
+) error {
+	records, err := parsePayoutBatchStatusFile(req.Content)
+
+	if err != nil {
+		zap.L().Error("Unable to parse the bank status file", zap.Error(err))
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = errorPayoutBatchStatusFileInvalid
+		return nil
+	}
+
+	documentRecords := make(map[string]bool)
+
+	for _, record := range records {
+		if record.BatchId == "" {
+			documentRecords[record.PayoutDocumentId] = true
+		}
+	}
+
+	batches := make(map[string]*pkg.PayoutBatch)
+
+	for _, record := range records {
+		if record.BatchId == "" {
+			batch, err := s.payoutBatchRepository.GetByPayoutDocumentId(ctx, record.PayoutDocumentId)
+
+			if err != nil {
+				rsp.Items = append(rsp.Items, &pkg.PayoutBatchStatusImportItem{
+					PayoutDocumentId: record.PayoutDocumentId,
+					Status:           record.Status,
+					FailureCode:      record.FailureCode,
+					FailureMessage:   record.FailureMessage,
+					Error:            errorPayoutBatchPayoutDocumentInvalid.Message,
+				})
+				continue
+			}
+
+			batches[batch.Id] = batch
+			rsp.Items = append(rsp.Items, s.importPayoutDocumentStatus(ctx, record.PayoutDocumentId, record, req.Ip))
+			continue
+		}
+
+		batch, err := s.payoutBatchRepository.GetById(ctx, record.BatchId)
+
+		if err != nil {
+			rsp.Items = append(rsp.Items, &pkg.PayoutBatchStatusImportItem{
+				Status: record.Status,
+				Error:  errorPayoutBatchNotFound.Message,
+			})
+			continue
+		}
+
+		batches[batch.Id] = batch
+
+		for _, id := range batch.PayoutDocumentIds {
+			// status of the single transfer has priority over the status of the whole batch
+			if documentRecords[id] {
+				continue
+			}
+
+			pd, err := s.payoutRepository.GetById(ctx, id)
+
+			if err != nil {
+				rsp.Items = append(rsp.Items, &pkg.PayoutBatchStatusImportItem{
+					PayoutDocumentId: id,
+					Status:           record.Status,
+					Error:            errorPayoutBatchUnknown.Message,
+				})
+				continue
+			}
+
+			if pd.Status != pkg.PayoutDocumentStatusInBatch {
+				continue
+			}
+
+			rsp.Items = append(rsp.Items, s.importPayoutDocumentStatus(ctx, id, record, req.Ip))
+		}
+	}
+
+	for _, batch := range batches {
+		if err = s.updatePayoutBatchStatus(ctx, batch); err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = errorPayoutBatchUnknown
+			return nil
+		}
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) importPayoutDocumentStatus(
+	ctx context.Context,
+	id string,
+	record *payoutBatchStatusRecord,
+	ip string,
+) *pkg.PayoutBatchStatusImportItem {
+	item := &pkg.PayoutBatchStatusImportItem{
+		PayoutDocumentId: id,
+		Status:           record.Status,
+		FailureCode:      record.FailureCode,
+		FailureMessage:   record.FailureMessage,
+	}
+
+	res := &billingpb.PayoutDocumentResponse{}
+	err := s.updatePayoutDocument(
+		ctx,
+		&billingpb.UpdatePayoutDocumentRequest{
+			PayoutDocumentId: id,
+			Status:           record.Status,
+			Transaction:      record.Transaction,
+			FailureCode:      record.FailureCode,
+			FailureMessage:   record.FailureMessage,
+			Ip:               ip,
+		},
+		res,
+		record.IsReturned,
+	)
+
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+
+	if res.Status != billingpb.ResponseStatusOk && res.Status != billingpb.ResponseStatusNotModified {
+		item.Error = res.Message.Message
+	}
+
+	return item
+}
+
+// revertPayoutBatchDocuments returns payout documents of the batch which wasn't saved to pending status
+// to include them to the next payout batch.
+// isPayoutBatchMerchantOnHold returns true if payouts to the merchant are held because of the banking change
+// or unresolved matches of sanctions screening.
+func (s *Service) isPayoutBatchMerchantOnHold(ctx context.Context, merchantId string) (bool, error) {
+	isOnHold, err := s.isMerchantBankingOnHold(ctx, merchantId)
+
+	if err != nil || isOnHold {
+		return isOnHold, err
+	}
+
+	merchant, err := s.merchantRepository.GetById(ctx, merchantId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return s.isMerchantSanctionsScreeningOnHold(ctx, merchant, pkg.SanctionsScreeningTriggerPayout, "", false)
+}
+
+func (s *Service) revertPayoutBatchDocuments(ctx context.Context, batch *pkg.PayoutBatch, ip string) {
+	for _, id := range batch.PayoutDocumentIds {
+		res := &billingpb.PayoutDocumentResponse{}
+		err := s.UpdatePayoutDocument(
+			ctx,
+			&billingpb.UpdatePayoutDocumentRequest{
+				PayoutDocumentId: id,
+				Status:           pkg.PayoutDocumentStatusPending,
+				Ip:               ip,
+			},
+			res,
+		)
+
+		if err != nil || res.Status != billingpb.ResponseStatusOk {
+			zap.L().Error(
+				"Unable to return payout document of the failed payout batch to pending status",
+				zap.Error(err),
+				zap.String("payout_document_id", id),
+				zap.Any("response", res),
+			)
+		}
+	}
+}
+
+func (s *Service) updatePayoutBatchStatus(ctx context.Context, batch *pkg.PayoutBatch) error {
+	if batch.Status == pkg.PayoutBatchStatusProcessed {
+		return nil
+	}
+
+	for _, id := range batch.PayoutDocumentIds {
+		pd, err := s.payoutRepository.GetById(ctx, id)
+
+		if err != nil {
+			return err
+		}
+
+		if pd.Status == pkg.PayoutDocumentStatusInBatch {
+			return nil
+		}
+	}
+
+	batch.Status = pkg.PayoutBatchStatusProcessed
+	batch.UpdatedAt = ptypes.TimestampNow()
+
+	return s.payoutBatchRepository.Update(ctx, batch)
+}
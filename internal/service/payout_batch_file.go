@@ -0,0 +1,563 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	sepaCurrency            = "EUR"
+	sepaNameMaxLength       = 70
+	sepaRemittanceMaxLength = 140
+
+	payoutBatchDateLayout     = "2006-01-02"
+	payoutBatchDateTimeLayout = "2006-01-02T15:04:05"
+
+	// ISO 20022 transaction status codes of pain.002 payment status report
+	payoutBatchTxStatusAcceptedSettlementCompleted = "ACSC"
+	payoutBatchTxStatusAcceptedCreditSettled       = "ACCC"
+	payoutBatchTxStatusRejected                    = "RJCT"
+
+	// ISO 20022 entry codes of camt.053/camt.054 bank statements
+	payoutBatchEntryStatusBooked = "BOOK"
+	payoutBatchEntryDebit        = "DBIT"
+	payoutBatchEntryCredit       = "CRDT"
+)
+
+var (
+	ibanRegex     = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	swiftRegex    = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	sepaTextRegex = regexp.MustCompile(`[^a-zA-Z0-9/\-?:().,'+ ]`)
+
+	errorPayoutBatchStatusFileUnknownFormat = errors.New("bank status file format is not supported")
+
+	payoutBatchSwiftCsvHeader = []string{
+		"payout_document_id",
+		"execution_date",
+		"amount",
+		"currency",
+		"debtor_name",
+		"debtor_account",
+		"debtor_swift",
+		"beneficiary_name",
+		"beneficiary_address",
+		"beneficiary_country",
+		"beneficiary_account",
+		"beneficiary_bank_name",
+		"beneficiary_bank_address",
+		"beneficiary_bank_swift",
+		"correspondent_account",
+		"remittance_information",
+	}
+)
+
+type sepaDocument struct {
+	XMLName            xml.Name                        `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03 Document"`
+	CreditTransferInit *sepaCustomerCreditTransferInit `xml:"CstmrCdtTrfInitn"`
+}
+
+type sepaCustomerCreditTransferInit struct {
+	GroupHeader *sepaGroupHeader `xml:"GrpHdr"`
+	PaymentInfo *sepaPaymentInfo `xml:"PmtInf"`
+}
+
+type sepaGroupHeader struct {
+	MessageId            string     `xml:"MsgId"`
+	CreationDateTime     string     `xml:"CreDtTm"`
+	NumberOfTransactions int        `xml:"NbOfTxs"`
+	ControlSum           string     `xml:"CtrlSum"`
+	InitiatingParty      *sepaParty `xml:"InitgPty"`
+}
+
+type sepaPaymentInfo struct {
+	PaymentInfoId          string                     `xml:"PmtInfId"`
+	PaymentMethod          string                     `xml:"PmtMtd"`
+	BatchBooking           bool                       `xml:"BtchBookg"`
+	NumberOfTransactions   int                        `xml:"NbOfTxs"`
+	ControlSum             string                     `xml:"CtrlSum"`
+	ServiceLevel           string                     `xml:"PmtTpInf>SvcLvl>Cd"`
+	RequestedExecutionDate string                     `xml:"ReqdExctnDt"`
+	Debtor                 *sepaParty                 `xml:"Dbtr"`
+	DebtorAccountIban      string                     `xml:"DbtrAcct>Id>IBAN"`
+	DebtorAgentBic         string                     `xml:"DbtrAgt>FinInstnId>BIC"`
+	ChargeBearer           string                     `xml:"ChrgBr"`
+	Transactions           []*sepaCreditTransferTxInf `xml:"CdtTrfTxInf"`
+}
+
+type sepaParty struct {
+	Name string `xml:"Nm"`
+}
+
+type sepaCreditTransferTxInf struct {
+	EndToEndId         string      `xml:"PmtId>EndToEndId"`
+	Amount             *sepaAmount `xml:"Amt>InstdAmt"`
+	CreditorAgentBic   string      `xml:"CdtrAgt>FinInstnId>BIC"`
+	Creditor           *sepaParty  `xml:"Cdtr"`
+	CreditorAccount    string      `xml:"CdtrAcct>Id>IBAN"`
+	RemittanceUnstruct string      `xml:"RmtInf>Ustrd"`
+}
+
+type sepaAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// bankStatusDocument is a bank status file in one of supported ISO 20022 formats:
+// pain.002 customer payment status report, camt.053 bank to customer statement
+// or camt.054 bank to customer debit credit notification.
+// Namespaces are omitted in tags to accept all versions of the messages.
+type bankStatusDocument struct {
+	PaymentStatusReport *bankPaymentStatusReport `xml:"CstmrPmtStsRpt"`
+	Statement           *bankCashManagement      `xml:"BkToCstmrStmt"`
+	Notification        *bankCashManagement      `xml:"BkToCstmrDbtCdtNtfctn"`
+}
+
+type bankPaymentStatusReport struct {
+	OriginalMessageId   string                       `xml:"OrgnlGrpInfAndSts>OrgnlMsgId"`
+	GroupStatus         string                       `xml:"OrgnlGrpInfAndSts>GrpSts"`
+	GroupStatusReasons  []*bankStatusReason          `xml:"OrgnlGrpInfAndSts>StsRsnInf"`
+	OriginalPaymentInfo []*bankOriginalPaymentStatus `xml:"OrgnlPmtInfAndSts"`
+}
+
+type bankOriginalPaymentStatus struct {
+	Status        string                   `xml:"PmtInfSts"`
+	StatusReasons []*bankStatusReason      `xml:"StsRsnInf"`
+	Transactions  []*bankTransactionStatus `xml:"TxInfAndSts"`
+}
+
+type bankTransactionStatus struct {
+	OriginalEndToEndId string              `xml:"OrgnlEndToEndId"`
+	Status             string              `xml:"TxSts"`
+	StatusReasons      []*bankStatusReason `xml:"StsRsnInf"`
+	AccountServicerRef string              `xml:"AcctSvcrRef"`
+}
+
+type bankStatusReason struct {
+	Code           string   `xml:"Rsn>Cd"`
+	Proprietary    string   `xml:"Rsn>Prtry"`
+	AdditionalInfo []string `xml:"AddtlInf"`
+}
+
+type bankCashManagement struct {
+	Statements    []*bankAccountReport `xml:"Stmt"`
+	Notifications []*bankAccountReport `xml:"Ntfctn"`
+}
+
+type bankAccountReport struct {
+	Entries []*bankEntry `xml:"Ntry"`
+}
+
+type bankEntry struct {
+	CreditDebitIndicator string             `xml:"CdtDbtInd"`
+	ReversalIndicator    bool               `xml:"RvslInd"`
+	Status               *bankEntryStatus   `xml:"Sts"`
+	AccountServicerRef   string             `xml:"AcctSvcrRef"`
+	Transactions         []*bankEntryTxInfo `xml:"NtryDtls>TxDtls"`
+}
+
+// bankEntryStatus supports both plain code (camt.05x.001.02) and code element (camt.05x.001.08) of entry status.
+type bankEntryStatus struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+type bankEntryTxInfo struct {
+	EndToEndId           string   `xml:"Refs>EndToEndId"`
+	AccountServicerRef   string   `xml:"Refs>AcctSvcrRef"`
+	ReturnReasonCode     string   `xml:"RtrInf>Rsn>Cd"`
+	ReturnAdditionalInfo []string `xml:"RtrInf>AddtlInf"`
+}
+
+// payoutBatchStatusRecord is a status of the payout document or the whole batch parsed from the bank status file.
+type payoutBatchStatusRecord struct {
+	// PayoutDocumentId is empty for the status of the whole batch.
+	PayoutDocumentId string
+	// BatchId is set for the status of the whole batch.
+	BatchId        string
+	Status         string
+	Transaction    string
+	FailureCode    string
+	FailureMessage string
+	// IsReturned is set for the transfer returned by the bank of the beneficiary after it was settled.
+	IsReturned bool
+}
+
+func newPayoutBatchFile(batch *pkg.PayoutBatch, payouts []*billingpb.PayoutDocument) ([]byte, error) {
+	if batch.Format == pkg.PayoutBatchFormatSepa {
+		return newSepaCreditTransferFile(batch, payouts)
+	}
+
+	return newSwiftCsvFile(batch, payouts)
+}
+
+func newSepaCreditTransferFile(batch *pkg.PayoutBatch, payouts []*billingpb.PayoutDocument) ([]byte, error) {
+	executionDate, err := ptypes.Timestamp(batch.ExecutionDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	controlSum := formatPayoutBatchAmount(batch.TotalAmount)
+	debtor := &sepaParty{Name: sepaText(batch.DebtorName, sepaNameMaxLength)}
+
+	paymentInfo := &sepaPaymentInfo{
+		PaymentInfoId:          batch.Id,
+		PaymentMethod:          "TRF",
+		BatchBooking:           true,
+		NumberOfTransactions:   len(payouts),
+		ControlSum:             controlSum,
+		ServiceLevel:           "SEPA",
+		RequestedExecutionDate: executionDate.Format(payoutBatchDateLayout),
+		Debtor:                 debtor,
+		DebtorAccountIban:      normalizeBankAccount(batch.DebtorAccount),
+		DebtorAgentBic:         normalizeBankAccount(batch.DebtorSwift),
+		ChargeBearer:           "SLEV",
+	}
+
+	for _, pd := range payouts {
+		paymentInfo.Transactions = append(paymentInfo.Transactions, &sepaCreditTransferTxInf{
+			EndToEndId:         pd.Id,
+			Amount:             &sepaAmount{Currency: pd.Currency, Value: formatPayoutBatchAmount(pd.Balance)},
+			CreditorAgentBic:   normalizeBankAccount(pd.Destination.Swift),
+			Creditor:           &sepaParty{Name: sepaText(getPayoutBeneficiaryName(pd), sepaNameMaxLength)},
+			CreditorAccount:    normalizeBankAccount(pd.Destination.AccountNumber),
+			RemittanceUnstruct: sepaText(getPayoutRemittanceInformation(pd), sepaRemittanceMaxLength),
+		})
+	}
+
+	createdAt, err := ptypes.Timestamp(batch.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &sepaDocument{
+		CreditTransferInit: &sepaCustomerCreditTransferInit{
+			GroupHeader: &sepaGroupHeader{
+				MessageId:            batch.Id,
+				CreationDateTime:     createdAt.UTC().Format(payoutBatchDateTimeLayout),
+				NumberOfTransactions: len(payouts),
+				ControlSum:           controlSum,
+				InitiatingParty:      debtor,
+			},
+			PaymentInfo: paymentInfo,
+		},
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+func newSwiftCsvFile(batch *pkg.PayoutBatch, payouts []*billingpb.PayoutDocument) ([]byte, error) {
+	executionDate, err := ptypes.Timestamp(batch.ExecutionDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	if err = w.Write(payoutBatchSwiftCsvHeader); err != nil {
+		return nil, err
+	}
+
+	for _, pd := range payouts {
+		var country, address string
+
+		if pd.Company != nil {
+			country = pd.Company.Country
+			address = strings.Join(
+				removeEmptyStrings([]string{pd.Company.Address, pd.Company.City, pd.Company.Zip, pd.Company.State}),
+				", ",
+			)
+		}
+
+		err = w.Write([]string{
+			pd.Id,
+			executionDate.Format(payoutBatchDateLayout),
+			formatPayoutBatchAmount(pd.Balance),
+			pd.Currency,
+			batch.DebtorName,
+			normalizeBankAccount(batch.DebtorAccount),
+			normalizeBankAccount(batch.DebtorSwift),
+			getPayoutBeneficiaryName(pd),
+			address,
+			country,
+			normalizeBankAccount(pd.Destination.AccountNumber),
+			pd.Destination.Name,
+			pd.Destination.Address,
+			normalizeBankAccount(pd.Destination.Swift),
+			pd.Destination.CorrespondentAccount,
+			getPayoutRemittanceInformation(pd),
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	if err = w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parsePayoutBatchStatusFile returns statuses of payout documents from the bank status file.
+// Only final statuses are returned: paid for settled transfers and failed for rejected or returned transfers.
+func parsePayoutBatchStatusFile(content []byte) ([]*payoutBatchStatusRecord, error) {
+	doc := &bankStatusDocument{}
+
+	if err := xml.Unmarshal(content, doc); err != nil {
+		return nil, err
+	}
+
+	if doc.PaymentStatusReport != nil {
+		return parsePaymentStatusReport(doc.PaymentStatusReport), nil
+	}
+
+	var reports []*bankAccountReport
+
+	if doc.Statement != nil {
+		reports = append(reports, doc.Statement.Statements...)
+	}
+
+	if doc.Notification != nil {
+		reports = append(reports, doc.Notification.Notifications...)
+	}
+
+	if doc.Statement == nil && doc.Notification == nil {
+		return nil, errorPayoutBatchStatusFileUnknownFormat
+	}
+
+	var records []*payoutBatchStatusRecord
+
+	for _, report := range reports {
+		for _, entry := range report.Entries {
+			records = append(records, parseBankEntry(entry)...)
+		}
+	}
+
+	return records, nil
+}
+
+func parsePaymentStatusReport(report *bankPaymentStatusReport) []*payoutBatchStatusRecord {
+	var records []*payoutBatchStatusRecord
+	hasTransactions := false
+
+	for _, pmtInf := range report.OriginalPaymentInfo {
+		for _, tx := range pmtInf.Transactions {
+			hasTransactions = true
+			record := &payoutBatchStatusRecord{
+				PayoutDocumentId: tx.OriginalEndToEndId,
+				Transaction:      tx.AccountServicerRef,
+			}
+
+			switch tx.Status {
+			case payoutBatchTxStatusAcceptedSettlementCompleted, payoutBatchTxStatusAcceptedCreditSettled:
+				record.Status = pkg.PayoutDocumentStatusPaid
+			case payoutBatchTxStatusRejected:
+				record.Status = pkg.PayoutDocumentStatusFailed
+				record.FailureCode, record.FailureMessage = getBankStatusReason(tx.StatusReasons)
+			default:
+				continue
+			}
+
+			records = append(records, record)
+		}
+	}
+
+	if hasTransactions || report.GroupStatus != payoutBatchTxStatusRejected {
+		return records
+	}
+
+	// whole batch is rejected without the statuses of single transfers
+	record := &payoutBatchStatusRecord{
+		BatchId: report.OriginalMessageId,
+		Status:  pkg.PayoutDocumentStatusFailed,
+	}
+	record.FailureCode, record.FailureMessage = getBankStatusReason(report.GroupStatusReasons)
+
+	return append(records, record)
+}
+
+func parseBankEntry(entry *bankEntry) []*payoutBatchStatusRecord {
+	if entry.Status == nil {
+		return nil
+	}
+
+	status := strings.TrimSpace(entry.Status.Value)
+
+	if entry.Status.Code != "" {
+		status = entry.Status.Code
+	}
+
+	if status != payoutBatchEntryStatusBooked {
+		return nil
+	}
+
+	var records []*payoutBatchStatusRecord
+
+	for _, tx := range entry.Transactions {
+		if tx.EndToEndId == "" {
+			continue
+		}
+
+		record := &payoutBatchStatusRecord{
+			PayoutDocumentId: tx.EndToEndId,
+			Transaction:      tx.AccountServicerRef,
+		}
+
+		if record.Transaction == "" {
+			record.Transaction = entry.AccountServicerRef
+		}
+
+		isReturned := tx.ReturnReasonCode != "" || entry.ReversalIndicator
+
+		switch {
+		case entry.CreditDebitIndicator == payoutBatchEntryDebit && !isReturned:
+			record.Status = pkg.PayoutDocumentStatusPaid
+		case entry.CreditDebitIndicator == payoutBatchEntryCredit && isReturned:
+			record.Status = pkg.PayoutDocumentStatusFailed
+			record.IsReturned = true
+			record.FailureCode = tx.ReturnReasonCode
+			record.FailureMessage = strings.Join(tx.ReturnAdditionalInfo, " ")
+		default:
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func getBankStatusReason(reasons []*bankStatusReason) (string, string) {
+	if len(reasons) <= 0 {
+		return "", ""
+	}
+
+	code := reasons[0].Code
+
+	if code == "" {
+		code = reasons[0].Proprietary
+	}
+
+	return code, strings.Join(reasons[0].AdditionalInfo, " ")
+}
+
+// validatePayoutBatchDestination checks banking details of the payout document required for transfer in the format.
+func validatePayoutBatchDestination(format string, pd *billingpb.PayoutDocument) bool {
+	if pd.Destination == nil || pd.Balance <= 0 {
+		return false
+	}
+
+	account := normalizeBankAccount(pd.Destination.AccountNumber)
+	swift := normalizeBankAccount(pd.Destination.Swift)
+
+	if format == pkg.PayoutBatchFormatSepa {
+		return pd.Currency == sepaCurrency && isValidIban(account) && swiftRegex.MatchString(swift)
+	}
+
+	return account != "" && swiftRegex.MatchString(swift)
+}
+
+func isValidIban(iban string) bool {
+	if !ibanRegex.MatchString(iban) {
+		return false
+	}
+
+	// ISO 13616 check: move first four characters to the end, replace letters with numbers and compute mod 97
+	var sb strings.Builder
+
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteString(fmt.Sprintf("%d", r-'A'+10))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+
+	if !ok {
+		return false
+	}
+
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func normalizeBankAccount(val string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(val), ""))
+}
+
+func formatPayoutBatchAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// sepaText replaces characters not allowed by SEPA character set and truncates the text to the max length.
+func sepaText(val string, max int) string {
+	val = strings.TrimSpace(sepaTextRegex.ReplaceAllString(val, " "))
+
+	if len(val) > max {
+		val = val[:max]
+	}
+
+	return val
+}
+
+func getPayoutBeneficiaryName(pd *billingpb.PayoutDocument) string {
+	if pd.Company != nil && pd.Company.Name != "" {
+		return pd.Company.Name
+	}
+
+	return pd.MerchantId
+}
+
+func getPayoutRemittanceInformation(pd *billingpb.PayoutDocument) string {
+	info := fmt.Sprintf("Payout %s", pd.Id)
+
+	if pd.MerchantAgreementNumber != "" {
+		info += fmt.Sprintf(" agreement %s", pd.MerchantAgreementNumber)
+	}
+
+	return info
+}
+
+func removeEmptyStrings(values []string) []string {
+	var result []string
+
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+func getPayoutBatchExecutionDate(ts int64) time.Time {
+	if ts > 0 {
+		return time.Unix(ts, 0)
+	}
+
+	return time.Now()
+}
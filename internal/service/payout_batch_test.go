@@ -0,0 +1,591 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+const (
+	payoutBatchTestDebtorIban  = "DE89370400440532013000"
+	payoutBatchTestDebtorSwift = "COBADEFFXXX"
+
+	payoutBatchTestPain002 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03">
+  <CstmrPmtStsRpt>
+    <GrpHdr><MsgId>STATUS-1</MsgId><CreDtTm>2020-01-01T10:00:00</CreDtTm></GrpHdr>
+    <OrgnlGrpInfAndSts><OrgnlMsgId>%s</OrgnlMsgId><OrgnlMsgNmId>pain.001.001.03</OrgnlMsgNmId><GrpSts>PART</GrpSts></OrgnlGrpInfAndSts>
+    <OrgnlPmtInfAndSts>
+      <OrgnlPmtInfId>%s</OrgnlPmtInfId>
+      <TxInfAndSts>
+        <OrgnlEndToEndId>%s</OrgnlEndToEndId>
+        <TxSts>ACSC</TxSts>
+        <AcctSvcrRef>BANKREF1</AcctSvcrRef>
+      </TxInfAndSts>
+      <TxInfAndSts>
+        <OrgnlEndToEndId>%s</OrgnlEndToEndId>
+        <TxSts>RJCT</TxSts>
+        <StsRsnInf><Rsn><Cd>AC04</Cd></Rsn><AddtlInf>Closed account number</AddtlInf></StsRsnInf>
+      </TxInfAndSts>
+    </OrgnlPmtInfAndSts>
+  </CstmrPmtStsRpt>
+</Document>`
+
+	payoutBatchTestPain002GroupRejected = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03">
+  <CstmrPmtStsRpt>
+    <OrgnlGrpInfAndSts>
+      <OrgnlMsgId>%s</OrgnlMsgId>
+      <GrpSts>RJCT</GrpSts>
+      <StsRsnInf><Rsn><Cd>AM05</Cd></Rsn><AddtlInf>Duplication</AddtlInf></StsRsnInf>
+    </OrgnlGrpInfAndSts>
+  </CstmrPmtStsRpt>
+</Document>`
+
+	payoutBatchTestCamt054 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.054.001.02">
+  <BkToCstmrDbtCdtNtfctn>
+    <Ntfctn>
+      <Ntry>
+        <Amt Ccy="EUR">100.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <AcctSvcrRef>ENTRYREF1</AcctSvcrRef>
+        <NtryDtls><TxDtls><Refs><EndToEndId>%s</EndToEndId></Refs></TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">200.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>PDNG</Sts>
+        <NtryDtls><TxDtls><Refs><EndToEndId>%s</EndToEndId></Refs></TxDtls></NtryDtls>
+      </Ntry>
+    </Ntfctn>
+  </BkToCstmrDbtCdtNtfctn>
+</Document>`
+
+	payoutBatchTestCamt054Returned = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.054.001.02">
+  <BkToCstmrDbtCdtNtfctn>
+    <Ntfctn>
+      <Ntry>
+        <Amt Ccy="EUR">100.50</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <AcctSvcrRef>RETURNREF1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>%s</EndToEndId></Refs>
+          <RtrInf><Rsn><Cd>AC06</Cd></Rsn><AddtlInf>Blocked account</AddtlInf></RtrInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+    </Ntfctn>
+  </BkToCstmrDbtCdtNtfctn>
+</Document>`
+)
+
+func (suite *PayoutsTestSuite) helperCreatePayoutForBatch(currency, account, swift string, amount float64) *billingpb.PayoutDocument {
+	pd := &billingpb.PayoutDocument{
+		Id:          primitive.NewObjectID().Hex(),
+		MerchantId:  suite.merchant.Id,
+		SourceId:    []string{},
+		TotalFees:   amount,
+		Balance:     amount,
+		Currency:    currency,
+		Status:      pkg.PayoutDocumentStatusPending,
+		Description: "test payout document",
+		Destination: &billingpb.MerchantBanking{
+			Currency:      currency,
+			Name:          "Bank name",
+			AccountNumber: account,
+			Swift:         swift,
+		},
+		Company:                 suite.merchant.Company,
+		MerchantAgreementNumber: "AG-0001",
+		CreatedAt:               ptypes.TimestampNow(),
+		UpdatedAt:               ptypes.TimestampNow(),
+		ArrivalDate:             ptypes.TimestampNow(),
+		OperatingCompanyId:      suite.operatingCompany.Id,
+	}
+	suite.helperInsertPayoutDocuments([]*billingpb.PayoutDocument{pd})
+
+	return pd
+}
+
+func (suite *PayoutsTestSuite) helperCreatePayoutBatch(format string) *pkg.PayoutBatch {
+	req := &pkg.CreatePayoutBatchesRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Format:             format,
+		DebtorAccount:      payoutBatchTestDebtorIban,
+		DebtorSwift:        payoutBatchTestDebtorSwift,
+		Ip:                 "127.0.0.1",
+	}
+	rsp := &pkg.CreatePayoutBatchesResponse{}
+	err := suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 1)
+
+	return rsp.Items[0]
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_CreatePayoutBatches_Ok_Sepa() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29 NWBK 6016 1331 9268 19", "NWBKGB2L", 100.5)
+	pd2 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 200)
+	pd3 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926818", "NWBKGB2L", 300)
+	pd4 := suite.helperCreatePayoutForBatch("RUB", "40702810000000000001", "SABRRUMM", 400)
+
+	req := &pkg.CreatePayoutBatchesRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Format:             pkg.PayoutBatchFormatSepa,
+		DebtorAccount:      payoutBatchTestDebtorIban,
+		DebtorSwift:        payoutBatchTestDebtorSwift,
+		Ip:                 "127.0.0.1",
+	}
+	rsp := &pkg.CreatePayoutBatchesResponse{}
+	err := suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), []string{pd3.Id}, rsp.SkippedPayoutDocumentIds)
+
+	batch := rsp.Items[0]
+	assert.NotEmpty(suite.T(), batch.Id)
+	assert.Equal(suite.T(), "EUR", batch.Currency)
+	assert.Equal(suite.T(), pkg.PayoutBatchStatusCreated, batch.Status)
+	assert.Equal(suite.T(), suite.operatingCompany.Name, batch.DebtorName)
+	assert.EqualValues(suite.T(), 300.5, batch.TotalAmount)
+	assert.ElementsMatch(suite.T(), []string{pd1.Id, pd2.Id}, batch.PayoutDocumentIds)
+
+	for _, id := range []string{pd1.Id, pd2.Id} {
+		pd, err := suite.service.payoutRepository.GetById(context.TODO(), id)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), pkg.PayoutDocumentStatusInBatch, pd.Status)
+	}
+
+	for _, id := range []string{pd3.Id, pd4.Id} {
+		pd, err := suite.service.payoutRepository.GetById(context.TODO(), id)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, pd.Status)
+	}
+
+	rsp1 := &pkg.PayoutBatchResponse{}
+	err = suite.service.GetPayoutBatch(context.TODO(), &pkg.GetPayoutBatchRequest{Id: batch.Id}, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+	assert.Equal(suite.T(), batch.PayoutDocumentIds, rsp1.Item.PayoutDocumentIds)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_CreatePayoutBatches_Ok_SwiftGroupedByCurrency() {
+	suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100)
+	suite.helperCreatePayoutForBatch("RUB", "40702810000000000001", "SABRRUMM", 400)
+	suite.helperCreatePayoutForBatch("RUB", "40702810000000000002", "SABRRUMM", 500)
+	pd := suite.helperCreatePayoutForBatch("RUB", "40702810000000000003", "", 600)
+
+	req := &pkg.CreatePayoutBatchesRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Format:             pkg.PayoutBatchFormatSwift,
+		DebtorName:         "Debtor",
+		DebtorAccount:      "40702810000000000009",
+		DebtorSwift:        "SABRRUMM",
+	}
+	rsp := &pkg.CreatePayoutBatchesResponse{}
+	err := suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Equal(suite.T(), []string{pd.Id}, rsp.SkippedPayoutDocumentIds)
+
+	assert.Equal(suite.T(), "EUR", rsp.Items[0].Currency)
+	assert.Len(suite.T(), rsp.Items[0].PayoutDocumentIds, 1)
+	assert.Equal(suite.T(), "RUB", rsp.Items[1].Currency)
+	assert.Len(suite.T(), rsp.Items[1].PayoutDocumentIds, 2)
+	assert.EqualValues(suite.T(), 900, rsp.Items[1].TotalAmount)
+	assert.Equal(suite.T(), "Debtor", rsp.Items[1].DebtorName)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_CreatePayoutBatches_Errors() {
+	req := &pkg.CreatePayoutBatchesRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Format:             "xml",
+		DebtorAccount:      payoutBatchTestDebtorIban,
+		DebtorSwift:        payoutBatchTestDebtorSwift,
+	}
+	rsp := &pkg.CreatePayoutBatchesResponse{}
+	err := suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), errorPayoutBatchFormatInvalid, rsp.Message)
+
+	req.Format = pkg.PayoutBatchFormatSepa
+	req.OperatingCompanyId = primitive.NewObjectID().Hex()
+	rsp = &pkg.CreatePayoutBatchesResponse{}
+	err = suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorOperatingCompanyNotFound, rsp.Message)
+
+	req.OperatingCompanyId = suite.operatingCompany.Id
+	req.DebtorAccount = "DE89370400440532013001"
+	rsp = &pkg.CreatePayoutBatchesResponse{}
+	err = suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), errorPayoutBatchDebtorAccountInvalid, rsp.Message)
+
+	req.DebtorAccount = payoutBatchTestDebtorIban
+	req.Currency = "USD"
+	rsp = &pkg.CreatePayoutBatchesResponse{}
+	err = suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), errorPayoutBatchCurrencyInvalid, rsp.Message)
+
+	req.Currency = ""
+	rsp = &pkg.CreatePayoutBatchesResponse{}
+	err = suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorPayoutBatchDocumentsNotFound, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_CreatePayoutBatches_Failed_InsertRevertsPayoutDocuments() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100.5)
+
+	batchRep := &mocks.PayoutBatchRepositoryInterface{}
+	batchRep.On("Insert", mock.Anything, mock.Anything).Return(errors.New("some error"))
+	suite.service.payoutBatchRepository = batchRep
+
+	req := &pkg.CreatePayoutBatchesRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Format:             pkg.PayoutBatchFormatSepa,
+		DebtorAccount:      payoutBatchTestDebtorIban,
+		DebtorSwift:        payoutBatchTestDebtorSwift,
+		Ip:                 "127.0.0.1",
+	}
+	rsp := &pkg.CreatePayoutBatchesResponse{}
+	err := suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusSystemError, rsp.Status)
+	assert.Equal(suite.T(), errorPayoutBatchUnknown, rsp.Message)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, pd.Status)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_CreatePayoutBatches_Failed_InsertKeepsCreatedBatches() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100)
+	pd2 := suite.helperCreatePayoutForBatch("RUB", "40702810000000000001", "SABRRUMM", 400)
+
+	batchRep := &mocks.PayoutBatchRepositoryInterface{}
+	batchRep.On("Insert", mock.Anything, mock.Anything).Return(nil).Once()
+	batchRep.On("Insert", mock.Anything, mock.Anything).Return(errors.New("some error"))
+	suite.service.payoutBatchRepository = batchRep
+
+	req := &pkg.CreatePayoutBatchesRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Format:             pkg.PayoutBatchFormatSwift,
+		DebtorAccount:      "40702810000000000009",
+		DebtorSwift:        "SABRRUMM",
+	}
+	rsp := &pkg.CreatePayoutBatchesResponse{}
+	err := suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusSystemError, rsp.Status)
+	assert.Equal(suite.T(), errorPayoutBatchUnknown, rsp.Message)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), []string{pd1.Id}, rsp.Items[0].PayoutDocumentIds)
+	assert.Equal(suite.T(), []string{pd2.Id}, rsp.SkippedPayoutDocumentIds)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusInBatch, pd.Status)
+
+	pd, err = suite.service.payoutRepository.GetById(context.TODO(), pd2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, pd.Status)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_CreatePayoutBatches_Failed_MerchantBankingOnHold() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100)
+	suite.helperAddMerchantOwner()
+	suite.helperChangeMerchantBanking(pkg.MerchantBankingVerificationAdmin)
+
+	req := &pkg.CreatePayoutBatchesRequest{
+		OperatingCompanyId: suite.operatingCompany.Id,
+		Format:             pkg.PayoutBatchFormatSepa,
+		DebtorAccount:      payoutBatchTestDebtorIban,
+		DebtorSwift:        payoutBatchTestDebtorSwift,
+	}
+	rsp := &pkg.CreatePayoutBatchesResponse{}
+	err := suite.service.CreatePayoutBatches(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorPayoutBatchDocumentsNotFound, rsp.Message)
+	assert.Equal(suite.T(), []string{pd1.Id}, rsp.SkippedPayoutDocumentIds)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, pd.Status)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_GetPayoutBatchFile_Ok_Sepa() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100.5)
+	pd2 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 200)
+	batch := suite.helperCreatePayoutBatch(pkg.PayoutBatchFormatSepa)
+
+	rsp := &pkg.PayoutBatchFileResponse{}
+	err := suite.service.GetPayoutBatchFile(context.TODO(), &pkg.GetPayoutBatchRequest{Id: batch.Id}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchContentTypeXml, rsp.ContentType)
+	assert.Equal(suite.T(), "payout_batch_"+batch.Id+".xml", rsp.Filename)
+
+	content := string(rsp.Content)
+	assert.Contains(suite.T(), content, `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">`)
+	assert.Contains(suite.T(), content, "<MsgId>"+batch.Id+"</MsgId>")
+	assert.Contains(suite.T(), content, "<NbOfTxs>2</NbOfTxs>")
+	assert.Contains(suite.T(), content, "<CtrlSum>300.50</CtrlSum>")
+	assert.Contains(suite.T(), content, "<IBAN>"+payoutBatchTestDebtorIban+"</IBAN>")
+	assert.Contains(suite.T(), content, "<EndToEndId>"+pd1.Id+"</EndToEndId>")
+	assert.Contains(suite.T(), content, "<EndToEndId>"+pd2.Id+"</EndToEndId>")
+	assert.Contains(suite.T(), content, `<InstdAmt Ccy="EUR">100.50</InstdAmt>`)
+	assert.Contains(suite.T(), content, "<Nm>Unit test</Nm>")
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_GetPayoutBatchFile_Ok_Swift() {
+	pd := suite.helperCreatePayoutForBatch("RUB", "40702810000000000001", "SABRRUMM", 400)
+	batch := suite.helperCreatePayoutBatch(pkg.PayoutBatchFormatSwift)
+
+	rsp := &pkg.PayoutBatchFileResponse{}
+	err := suite.service.GetPayoutBatchFile(context.TODO(), &pkg.GetPayoutBatchRequest{Id: batch.Id}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), payoutBatchContentTypeCsv, rsp.ContentType)
+
+	rows, err := csv.NewReader(bytes.NewReader(rsp.Content)).ReadAll()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), rows, 2)
+	assert.Equal(suite.T(), payoutBatchSwiftCsvHeader, rows[0])
+	assert.Equal(suite.T(), pd.Id, rows[1][0])
+	assert.Equal(suite.T(), "400.00", rows[1][2])
+	assert.Equal(suite.T(), "RUB", rows[1][3])
+	assert.Equal(suite.T(), "Unit test", rows[1][7])
+	assert.Equal(suite.T(), "St.Petersburg, 190000", rows[1][8])
+	assert.Equal(suite.T(), "SABRRUMM", rows[1][13])
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_GetPayoutBatchFile_Failed_NotFound() {
+	rsp := &pkg.PayoutBatchFileResponse{}
+	err := suite.service.GetPayoutBatchFile(context.TODO(), &pkg.GetPayoutBatchRequest{Id: primitive.NewObjectID().Hex()}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), errorPayoutBatchNotFound, rsp.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ImportPayoutBatchStatus_Ok_PaymentStatusReport() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100.5)
+	pd2 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 200)
+	batch := suite.helperCreatePayoutBatch(pkg.PayoutBatchFormatSepa)
+
+	req := &pkg.ImportPayoutBatchStatusRequest{
+		Content: []byte(fmt.Sprintf(payoutBatchTestPain002, batch.Id, batch.Id, pd1.Id, pd2.Id)),
+		Ip:      "127.0.0.1",
+	}
+	rsp := &pkg.ImportPayoutBatchStatusResponse{}
+	err := suite.service.ImportPayoutBatchStatus(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Empty(suite.T(), rsp.Items[0].Error)
+	assert.Empty(suite.T(), rsp.Items[1].Error)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPaid, pd.Status)
+	assert.Equal(suite.T(), "BANKREF1", pd.Transaction)
+
+	pd, err = suite.service.payoutRepository.GetById(context.TODO(), pd2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusFailed, pd.Status)
+	assert.Equal(suite.T(), "AC04", pd.FailureCode)
+	assert.Equal(suite.T(), "Closed account number", pd.FailureMessage)
+
+	batch, err = suite.service.payoutBatchRepository.GetById(context.TODO(), batch.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutBatchStatusProcessed, batch.Status)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ImportPayoutBatchStatus_Ok_GroupRejected() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100.5)
+	pd2 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 200)
+	batch := suite.helperCreatePayoutBatch(pkg.PayoutBatchFormatSepa)
+
+	req := &pkg.ImportPayoutBatchStatusRequest{
+		Content: []byte(fmt.Sprintf(payoutBatchTestPain002GroupRejected, batch.Id)),
+	}
+	rsp := &pkg.ImportPayoutBatchStatusResponse{}
+	err := suite.service.ImportPayoutBatchStatus(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 2)
+
+	for _, id := range []string{pd1.Id, pd2.Id} {
+		pd, err := suite.service.payoutRepository.GetById(context.TODO(), id)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), pkg.PayoutDocumentStatusFailed, pd.Status)
+		assert.Equal(suite.T(), "AM05", pd.FailureCode)
+	}
+
+	batch, err = suite.service.payoutBatchRepository.GetById(context.TODO(), batch.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutBatchStatusProcessed, batch.Status)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ImportPayoutBatchStatus_Ok_GroupRejectedSkipsProcessedDocuments() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100.5)
+	pd2 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 200)
+	batch := suite.helperCreatePayoutBatch(pkg.PayoutBatchFormatSepa)
+
+	res := &billingpb.PayoutDocumentResponse{}
+	err := suite.service.UpdatePayoutDocument(
+		context.TODO(),
+		&billingpb.UpdatePayoutDocumentRequest{PayoutDocumentId: pd1.Id, Status: pkg.PayoutDocumentStatusPaid},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	req := &pkg.ImportPayoutBatchStatusRequest{
+		Content: []byte(fmt.Sprintf(payoutBatchTestPain002GroupRejected, batch.Id)),
+	}
+	rsp := &pkg.ImportPayoutBatchStatusResponse{}
+	err = suite.service.ImportPayoutBatchStatus(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), pd2.Id, rsp.Items[0].PayoutDocumentId)
+	assert.Empty(suite.T(), rsp.Items[0].Error)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPaid, pd.Status)
+	assert.Empty(suite.T(), pd.FailureCode)
+
+	pd, err = suite.service.payoutRepository.GetById(context.TODO(), pd2.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusFailed, pd.Status)
+	assert.Equal(suite.T(), "AM05", pd.FailureCode)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ImportPayoutBatchStatus_Ok_Returned() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100.5)
+	pd2 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 200)
+	batch := suite.helperCreatePayoutBatch(pkg.PayoutBatchFormatSepa)
+
+	req := &pkg.ImportPayoutBatchStatusRequest{
+		Content: []byte(fmt.Sprintf(payoutBatchTestPain002, batch.Id, batch.Id, pd1.Id, pd2.Id)),
+	}
+	rsp := &pkg.ImportPayoutBatchStatusResponse{}
+	err := suite.service.ImportPayoutBatchStatus(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	req.Content = []byte(fmt.Sprintf(payoutBatchTestCamt054Returned, pd1.Id))
+	rsp = &pkg.ImportPayoutBatchStatusResponse{}
+	err = suite.service.ImportPayoutBatchStatus(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Empty(suite.T(), rsp.Items[0].Error)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusFailed, pd.Status)
+	assert.Equal(suite.T(), "AC06", pd.FailureCode)
+	assert.Equal(suite.T(), "Blocked account", pd.FailureMessage)
+
+	// status of the failed payout document can't be changed manually
+	res := &billingpb.PayoutDocumentResponse{}
+	err = suite.service.UpdatePayoutDocument(
+		context.TODO(),
+		&billingpb.UpdatePayoutDocumentRequest{PayoutDocumentId: pd2.Id, Status: pkg.PayoutDocumentStatusPending},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorPayoutStatusChangeIsForbidden, res.Message)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ImportPayoutBatchStatus_Ok_Notification() {
+	pd1 := suite.helperCreatePayoutForBatch("EUR", "GB29NWBK60161331926819", "NWBKGB2L", 100)
+	pd2 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 200)
+	pd3 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 300)
+	batch := suite.helperCreatePayoutBatch(pkg.PayoutBatchFormatSepa)
+
+	// payout document out of payout batches
+	pd4 := suite.helperCreatePayoutForBatch("EUR", "FR1420041010050500013M02606", "BNPAFRPP", 300)
+
+	content := fmt.Sprintf(payoutBatchTestCamt054, pd1.Id, pd2.Id)
+	content = strings.Replace(content, "</Ntfctn>", fmt.Sprintf(`<Ntry>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <NtryDtls><TxDtls><Refs><EndToEndId>%s</EndToEndId></Refs></TxDtls></NtryDtls>
+      </Ntry></Ntfctn>`, pd4.Id), 1)
+
+	rsp := &pkg.ImportPayoutBatchStatusResponse{}
+	err := suite.service.ImportPayoutBatchStatus(context.TODO(), &pkg.ImportPayoutBatchStatusRequest{Content: []byte(content)}, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Equal(suite.T(), pd1.Id, rsp.Items[0].PayoutDocumentId)
+	assert.Empty(suite.T(), rsp.Items[0].Error)
+	assert.Equal(suite.T(), pd4.Id, rsp.Items[1].PayoutDocumentId)
+	assert.Equal(suite.T(), errorPayoutBatchPayoutDocumentInvalid.Message, rsp.Items[1].Error)
+
+	pd, err := suite.service.payoutRepository.GetById(context.TODO(), pd1.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPaid, pd.Status)
+	assert.Equal(suite.T(), "ENTRYREF1", pd.Transaction)
+
+	for _, id := range []string{pd2.Id, pd3.Id} {
+		pd, err = suite.service.payoutRepository.GetById(context.TODO(), id)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), pkg.PayoutDocumentStatusInBatch, pd.Status)
+	}
+
+	pd, err = suite.service.payoutRepository.GetById(context.TODO(), pd4.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutDocumentStatusPending, pd.Status)
+
+	batch, err = suite.service.payoutBatchRepository.GetById(context.TODO(), batch.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.PayoutBatchStatusCreated, batch.Status)
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_ImportPayoutBatchStatus_Failed_InvalidFile() {
+	for _, content := range []string{"not xml", `<Document><CstmrCdtTrfInitn/></Document>`} {
+		rsp := &pkg.ImportPayoutBatchStatusResponse{}
+		err := suite.service.ImportPayoutBatchStatus(context.TODO(), &pkg.ImportPayoutBatchStatusRequest{Content: []byte(content)}, rsp)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+		assert.Equal(suite.T(), errorPayoutBatchStatusFileInvalid, rsp.Message)
+	}
+}
+
+func (suite *PayoutsTestSuite) TestPayouts_isValidIban() {
+	assert.True(suite.T(), isValidIban("DE89370400440532013000"))
+	assert.True(suite.T(), isValidIban("FR1420041010050500013M02606"))
+	assert.False(suite.T(), isValidIban("DE89370400440532013001"))
+	assert.False(suite.T(), isValidIban("40702810000000000001"))
+	assert.False(suite.T(), isValidIban(""))
+}
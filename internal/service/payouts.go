@@ -42,9 +42,12 @@ var (
 	errorPayoutManualPayoutsDisabled   = newBillingServerErrorMsg("po000015", "manual payouts disabled")
 	errorPayoutAutoPayoutsDisabled     = newBillingServerErrorMsg("po000016", "auto payouts disabled")
 	errorPayoutAutoPayoutsWithErrors   = newBillingServerErrorMsg("po000017", "auto payouts creation finished with errors")
+	errorPayoutBankingOnHold           = newBillingServerErrorMsg("po000018", "payouts are held until the new bank account is verified")
+	errorPayoutSanctionsScreeningHold  = newBillingServerErrorMsg("po000019", "payouts are held until potential matches of sanctions screening are cleared")
 
 	statusForUpdateBalance = map[string]bool{
 		pkg.PayoutDocumentStatusPending: true,
+		pkg.PayoutDocumentStatusInBatch: true,
 		pkg.PayoutDocumentStatusPaid:    true,
 	}
 
@@ -89,6 +92,29 @@ func (s *Service) createPayoutDocument(
 	res *billingpb.CreatePayoutDocumentResponse,
 ) error {
 
+	isOnHold, err := s.isMerchantBankingOnHold(ctx, merchant.Id)
+	if err != nil {
+		return err
+	}
+
+	if isOnHold {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorPayoutBankingOnHold
+		return nil
+	}
+
+	// the merchant is screened before each payout, because sanctions lists may be updated after the onboarding
+	isOnHold, err = s.isMerchantSanctionsScreeningOnHold(ctx, merchant, pkg.SanctionsScreeningTriggerPayout, req.Initiator, true)
+	if err != nil {
+		return err
+	}
+
+	if isOnHold {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorPayoutSanctionsScreeningHold
+		return nil
+	}
+
 	arrivalDate, err := ptypes.TimestampProto(now.EndOfDay().Add(time.Hour * 24 * payoutArrivalInDays))
 	if err != nil {
 		return err
@@ -338,7 +364,8 @@ func (s *Service) AutoCreatePayoutDocuments(
 			continue
 		}
 		if res.Status != billingpb.ResponseStatusOk {
-			if res.Message == errorPayoutAmountInvalid || res.Message == errorPayoutSourcesNotFound {
+			if res.Message == errorPayoutAmountInvalid || res.Message == errorPayoutSourcesNotFound ||
+				res.Message == errorPayoutBankingOnHold {
 				continue
 			}
 			zap.L().Error(
@@ -400,6 +427,17 @@ func (s *Service) UpdatePayoutDocument(
 	ctx context.Context,
 	req *billingpb.UpdatePayoutDocumentRequest,
 	res *billingpb.PayoutDocumentResponse,
+) error {
+	return s.updatePayoutDocument(ctx, req, res, false)
+}
+
+// updatePayoutDocument changes status and transaction details of the payout document. Paid payout document
+// can be moved to failed status only when the transfer is returned by the bank.
+func (s *Service) updatePayoutDocument(
+	ctx context.Context,
+	req *billingpb.UpdatePayoutDocumentRequest,
+	res *billingpb.PayoutDocumentResponse,
+	isReturned bool,
 ) error {
 	pd, err := s.payoutRepository.GetById(ctx, req.PayoutDocumentId)
 	if err != nil {
@@ -422,13 +460,21 @@ func (s *Service) UpdatePayoutDocument(
 	becomeFailed := isReqStatusForBecomeFailed && !isPayoutStatusForBecomeFailed
 
 	if req.Status != "" && pd.Status != req.Status {
-		if pd.Status == pkg.PayoutDocumentStatusPaid || pd.Status == pkg.PayoutDocumentStatusFailed {
+		isReturnedPaid := isReturned && pd.Status == pkg.PayoutDocumentStatusPaid &&
+			req.Status == pkg.PayoutDocumentStatusFailed
+
+		if (pd.Status == pkg.PayoutDocumentStatusPaid || pd.Status == pkg.PayoutDocumentStatusFailed) && !isReturnedPaid {
 			res.Status = billingpb.ResponseStatusBadData
 			res.Message = errorPayoutStatusChangeIsForbidden
 
 			return nil
 		}
 
+		// returned amount is added back to the merchant balance
+		if isReturnedPaid {
+			needBalanceUpdate = true
+		}
+
 		if req.Status == pkg.PayoutDocumentStatusPaid {
 			pd.PaidAt = ptypes.TimestampNow()
 		}
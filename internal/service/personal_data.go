@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"go.uber.org/zap"
+)
+
+const (
+	personalDataEncryptionBatchSize = 500
+)
+
+// EncryptPersonalData re-saves all customers and orders, so their personal data is encrypted by the current key
+// of the field-level encryption. Data stored as plaintext before encryption was enabled is encrypted too,
+// and blind indexes are calculated. It returns a number of processed customers and orders.
+func (s *Service) EncryptPersonalData(ctx context.Context) (int, error) {
+	processed := 0
+	afterId := ""
+
+	for {
+		customers, err := s.customerRepository.FindAfter(ctx, afterId, personalDataEncryptionBatchSize)
+
+		if err != nil {
+			return processed, err
+		}
+
+		if len(customers) <= 0 {
+			break
+		}
+
+		for _, customer := range customers {
+			afterId = customer.Id
+
+			if err = s.customerRepository.Update(ctx, customer); err != nil {
+				zap.L().Error("Unable to encrypt personal data of customer", zap.Error(err), zap.String("customer_id", customer.Id))
+				continue
+			}
+
+			processed++
+		}
+	}
+
+	afterId = ""
+
+	for {
+		orders, err := s.orderRepository.FindAfter(ctx, afterId, personalDataEncryptionBatchSize)
+
+		if err != nil {
+			return processed, err
+		}
+
+		if len(orders) <= 0 {
+			break
+		}
+
+		orderIds := make([]string, 0, len(orders))
+
+		for _, order := range orders {
+			afterId = order.Id
+
+			if err = s.orderRepository.Update(ctx, order); err != nil {
+				zap.L().Error("Unable to encrypt personal data of order", zap.Error(err), zap.String("order_id", order.Id))
+				continue
+			}
+
+			orderIds = append(orderIds, order.Id)
+			processed++
+		}
+
+		if len(orderIds) <= 0 {
+			continue
+		}
+
+		if err = s.orderRepository.UpdateOrderView(ctx, orderIds); err != nil {
+			return processed, err
+		}
+	}
+
+	return processed, nil
+}
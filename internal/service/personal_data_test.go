@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/kms"
+	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+)
+
+type PersonalDataTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant   *billingpb.Merchant
+	project    *billingpb.Project
+	pmBankCard *billingpb.PaymentMethod
+}
+
+func Test_PersonalData(t *testing.T) {
+	suite.Run(t, new(PersonalDataTestSuite))
+}
+
+func (suite *PersonalDataTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, suite.pmBankCard, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *PersonalDataTestSuite) TearDownTest() {
+	models.SetFieldCipher(nil, nil)
+
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *PersonalDataTestSuite) helperSetFieldCipher(keyId string) {
+	keys := map[string][]byte{
+		"1": bytes.Repeat([]byte{1}, 32),
+		"2": bytes.Repeat([]byte{2}, 32),
+	}
+	fieldCipher, err := kms.NewFieldCipher(keys, keyId, "secret")
+	assert.NoError(suite.T(), err)
+
+	models.SetFieldCipher(fieldCipher, []string{
+		pkg.PersonalDataFieldEmail,
+		pkg.PersonalDataFieldPhone,
+		pkg.PersonalDataFieldName,
+		pkg.PersonalDataFieldIp,
+		pkg.PersonalDataFieldUserAgent,
+		pkg.PersonalDataFieldAddress,
+		pkg.PersonalDataFieldCardHolder,
+	})
+}
+
+func (suite *PersonalDataTestSuite) helperCreateCustomer() *billingpb.Customer {
+	customerId := primitive.NewObjectID().Hex()
+	customer := &billingpb.Customer{
+		Id:        customerId,
+		TechEmail: customerId + pkg.TechEmailDomain,
+		Email:     "pii@unit.test",
+		Phone:     "+79001234567",
+		Name:      "Unit Test",
+		Identity: []*billingpb.CustomerIdentity{
+			{
+				MerchantId: suite.merchant.Id,
+				ProjectId:  suite.project.Id,
+				Type:       pkg.UserIdentityTypeEmail,
+				Value:      "pii@unit.test",
+				Verified:   true,
+				CreatedAt:  ptypes.TimestampNow(),
+			},
+		},
+	}
+	err := suite.service.customerRepository.Insert(context.TODO(), customer)
+	assert.NoError(suite.T(), err)
+
+	return customer
+}
+
+func (suite *PersonalDataTestSuite) helperGetMgoCustomer(id string) *models.MgoCustomer {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	mgo := &models.MgoCustomer{}
+	err := suite.service.db.Collection("customer").FindOne(context.TODO(), bson.M{"_id": oid}).Decode(mgo)
+	assert.NoError(suite.T(), err)
+
+	return mgo
+}
+
+func (suite *PersonalDataTestSuite) TestPersonalData_Customer_Encrypted() {
+	suite.helperSetFieldCipher("1")
+	customer := suite.helperCreateCustomer()
+
+	mgo := suite.helperGetMgoCustomer(customer.Id)
+	assert.True(suite.T(), strings.HasPrefix(mgo.Email, "pii:1:"))
+	assert.True(suite.T(), strings.HasPrefix(mgo.Phone, "pii:1:"))
+	assert.True(suite.T(), strings.HasPrefix(mgo.Identity[0].Value, "pii:1:"))
+	assert.NotEmpty(suite.T(), mgo.EmailIndex)
+
+	found, err := suite.service.customerRepository.GetById(context.TODO(), customer.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), customer.Email, found.Email)
+	assert.Equal(suite.T(), customer.Phone, found.Phone)
+	assert.Equal(suite.T(), customer.Name, found.Name)
+
+	user := &billingpb.TokenUser{Email: &billingpb.TokenUserEmailValue{Value: customer.Email}}
+	found, err = suite.service.customerRepository.Find(context.TODO(), suite.merchant.Id, user)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), customer.Id, found.Id)
+
+	customers, err := suite.service.customerRepository.FindByEmailOrPhone(context.TODO(), "", customer.Phone)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), customers, 1)
+}
+
+func (suite *PersonalDataTestSuite) TestPersonalData_EncryptPersonalData_Ok() {
+	customer := suite.helperCreateCustomer()
+	order := HelperCreateAndPayOrder(suite.Suite, suite.service, 100, "RUB", "RU", suite.project, suite.pmBankCard)
+
+	mgo := suite.helperGetMgoCustomer(customer.Id)
+	assert.Equal(suite.T(), customer.Email, mgo.Email)
+	assert.Empty(suite.T(), mgo.EmailIndex)
+
+	suite.helperSetFieldCipher("1")
+
+	count, err := suite.service.EncryptPersonalData(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), count >= 2)
+
+	mgo = suite.helperGetMgoCustomer(customer.Id)
+	assert.True(suite.T(), strings.HasPrefix(mgo.Email, "pii:1:"))
+	assert.NotEmpty(suite.T(), mgo.EmailIndex)
+
+	orders, err := suite.service.orderRepository.FindByUser(context.TODO(), nil, order.User.Email, "")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), orders, 1)
+	assert.Equal(suite.T(), order.User.Email, orders[0].User.Email)
+
+	// data is re-encrypted by the new key after rotation
+	suite.helperSetFieldCipher("2")
+
+	_, err = suite.service.EncryptPersonalData(context.TODO())
+	assert.NoError(suite.T(), err)
+
+	mgo = suite.helperGetMgoCustomer(customer.Id)
+	assert.True(suite.T(), strings.HasPrefix(mgo.Email, "pii:2:"))
+
+	oid, _ := primitive.ObjectIDFromHex(order.Id)
+	mgoOrder := &models.MgoOrder{}
+	err = suite.service.db.Collection(repository.CollectionOrder).FindOne(context.TODO(), bson.M{"_id": oid}).Decode(mgoOrder)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(mgoOrder.User.Email, "pii:2:"))
+
+	found, err := suite.service.orderRepository.GetById(context.TODO(), order.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), order.User.Email, found.User.Email)
+}
@@ -237,6 +237,17 @@ func (s *Service) getRecommendedPriceForRegion(
 		return 0, err
 	}
 
+	return s.getRecommendedPriceByTable(table, region, rng, amount), nil
+}
+
+// getRecommendedPriceByTable returns the price in the region which is placed in the price table of the region
+// at the same position as the amount in the range of the source price table.
+func (s *Service) getRecommendedPriceByTable(
+	table *billingpb.PriceTable,
+	region *billingpb.PriceGroup,
+	rng *billingpb.PriceTableRange,
+	amount float64,
+) float64 {
 	regionRange := &billingpb.PriceTableRange{Position: rng.Position}
 
 	if int(rng.Position) >= len(table.Ranges) {
@@ -260,9 +271,8 @@ func (s *Service) getRecommendedPriceForRegion(
 	}
 
 	price := regionRange.From + (regionRange.To-regionRange.From)*ratio
-	priceFrac := s.calculatePriceWithFraction(region.Fraction, price)
 
-	return priceFrac, nil
+	return s.calculatePriceWithFraction(region.Fraction, price)
 }
 
 func (s *Service) GetRecommendedPriceByConversion(
@@ -0,0 +1,560 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+var (
+	priceScheduleErrorUnknown            = newBillingServerErrorMsg("ps000001", "unknown error with price schedule")
+	priceScheduleErrorNotFound           = newBillingServerErrorMsg("ps000002", "price schedule not found")
+	priceScheduleErrorMerchantMismatch   = newBillingServerErrorMsg("ps000003", "merchant id mismatch")
+	priceScheduleErrorProductNotFound    = newBillingServerErrorMsg("ps000004", "product not found")
+	priceScheduleErrorProductTypeInvalid = newBillingServerErrorMsg("ps000005", "product type is invalid")
+	priceScheduleErrorPlatformInvalid    = newBillingServerErrorMsg("ps000006", "platform is not found in key product")
+	priceScheduleErrorPeriodInvalid      = newBillingServerErrorMsg("ps000007", "end of price schedule must be later than start and now")
+	priceScheduleErrorPricesEmpty        = newBillingServerErrorMsg("ps000008", "price schedule must contain at least one price")
+	priceScheduleErrorPriceInvalid       = newBillingServerErrorMsg("ps000009", "price schedule price is invalid")
+	priceScheduleErrorOverlapping        = newBillingServerErrorMsg("ps000010", "price schedule overlaps with other schedule of the product")
+	priceScheduleErrorAlreadyFinished    = newBillingServerErrorMsg("ps000011", "price schedule already finished or cancelled")
+)
+
+func (s *Service) CreatePriceSchedule(
+	ctx context.Context,
+	req *pkg.CreatePriceScheduleRequest,
+	res *pkg.PriceScheduleResponse,
+) error {
+	platforms, msg := s.getPriceScheduleProduct(ctx, req.MerchantId, req.ProductId, req.ProductType)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if req.ProductType == pkg.OrderItemTypeKeyProduct {
+		if _, ok := platforms[req.PlatformId]; !ok {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = priceScheduleErrorPlatformInvalid
+			return nil
+		}
+	} else {
+		req.PlatformId = ""
+	}
+
+	startAt, err := ptypes.Timestamp(req.StartAt)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = priceScheduleErrorPeriodInvalid
+		return nil
+	}
+
+	endAt, err := ptypes.Timestamp(req.EndAt)
+
+	if err != nil || !endAt.After(startAt) || !endAt.After(time.Now()) {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = priceScheduleErrorPeriodInvalid
+		return nil
+	}
+
+	if msg = s.validatePriceSchedulePrices(ctx, req.Prices); msg != nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = msg
+		return nil
+	}
+
+	count, err := s.priceScheduleRepository.CountOverlapping(ctx, req.ProductId, req.PlatformId, startAt, endAt)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceScheduleErrorUnknown
+		return nil
+	}
+
+	if count > 0 {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = priceScheduleErrorOverlapping
+		return nil
+	}
+
+	now := ptypes.TimestampNow()
+	schedule := &pkg.PriceSchedule{
+		MerchantId:  req.MerchantId,
+		ProductId:   req.ProductId,
+		ProductType: req.ProductType,
+		PlatformId:  req.PlatformId,
+		Name:        req.Name,
+		Prices:      req.Prices,
+		StartAt:     req.StartAt,
+		EndAt:       req.EndAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err = s.priceScheduleRepository.Upsert(ctx, schedule); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceScheduleErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = schedule
+
+	return nil
+}
+
+// CancelPriceSchedule cancels the schedule which is not finished yet. The cancelled schedule which is already
+// started stops to change prices of the product immediately.
+func (s *Service) CancelPriceSchedule(
+	ctx context.Context,
+	req *pkg.CancelPriceScheduleRequest,
+	res *pkg.PriceScheduleResponse,
+) error {
+	schedule, err := s.priceScheduleRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = priceScheduleErrorNotFound
+
+		if err != mongo.ErrNoDocuments {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = priceScheduleErrorUnknown
+		}
+
+		return nil
+	}
+
+	if schedule.MerchantId != req.MerchantId {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = priceScheduleErrorMerchantMismatch
+		return nil
+	}
+
+	endAt, err := ptypes.Timestamp(schedule.EndAt)
+
+	if err != nil || schedule.Cancelled || !endAt.After(time.Now()) {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = priceScheduleErrorAlreadyFinished
+		return nil
+	}
+
+	schedule.Cancelled = true
+	schedule.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.priceScheduleRepository.Upsert(ctx, schedule); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceScheduleErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = schedule
+
+	return nil
+}
+
+func (s *Service) GetProductPriceHistory(
+	ctx context.Context,
+	req *pkg.GetProductPriceHistoryRequest,
+	res *pkg.GetProductPriceHistoryResponse,
+) error {
+	if _, msg := s.getPriceScheduleProduct(ctx, req.MerchantId, req.ProductId, req.ProductType); msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	history, err := s.priceHistoryRepository.FindByProductId(ctx, req.ProductId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceScheduleErrorUnknown
+		return nil
+	}
+
+	schedules, err := s.priceScheduleRepository.FindByProductId(ctx, req.ProductId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceScheduleErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.History = history
+	res.Schedules = schedules
+
+	return nil
+}
+
+// GetEffectiveProductPrices returns prices of the product which were effective at the time, the current time
+// is used if time is not set.
+func (s *Service) GetEffectiveProductPrices(
+	ctx context.Context,
+	req *pkg.GetEffectivePricesRequest,
+	res *pkg.GetEffectivePricesResponse,
+) error {
+	if _, msg := s.getPriceScheduleProduct(ctx, req.MerchantId, req.ProductId, req.ProductType); msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	at := time.Now()
+
+	if req.At != nil {
+		var err error
+		at, err = ptypes.Timestamp(req.At)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = priceScheduleErrorPeriodInvalid
+			return nil
+		}
+	}
+
+	histories, schedules, err := s.getPriceChangesAt(ctx, []string{req.ProductId}, at)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceScheduleErrorUnknown
+		return nil
+	}
+
+	if req.ProductType == pkg.OrderItemTypeKeyProduct {
+		product, err := s.keyProductRepository.GetById(ctx, req.ProductId)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = priceScheduleErrorUnknown
+			return nil
+		}
+
+		product = applyKeyProductPriceChanges(product, histories[product.Id], schedules[product.Id], "")
+		res.Platforms = product.Platforms
+	} else {
+		product, err := s.productRepository.GetById(ctx, req.ProductId)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = priceScheduleErrorUnknown
+			return nil
+		}
+
+		product = applyProductPriceChanges(product, histories[product.Id], schedules[product.Id])
+		res.Prices = product.Prices
+	}
+
+	for _, schedule := range schedules[req.ProductId] {
+		res.ScheduleIds = append(res.ScheduleIds, schedule.Id)
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// getPriceScheduleProduct checks that the product or key product belongs to the merchant and returns
+// identities of platforms of the key product.
+func (s *Service) getPriceScheduleProduct(
+	ctx context.Context,
+	merchantId, productId, productType string,
+) (map[string]bool, *billingpb.ResponseErrorMessage) {
+	switch productType {
+	case pkg.OrderItemTypeProduct:
+		product, err := s.productRepository.GetById(ctx, productId)
+
+		if err != nil || product.Deleted {
+			return nil, priceScheduleErrorProductNotFound
+		}
+
+		if product.MerchantId != merchantId {
+			return nil, priceScheduleErrorMerchantMismatch
+		}
+
+		return nil, nil
+	case pkg.OrderItemTypeKeyProduct:
+		product, err := s.keyProductRepository.GetById(ctx, productId)
+
+		if err != nil || product.Deleted {
+			return nil, priceScheduleErrorProductNotFound
+		}
+
+		if product.MerchantId != merchantId {
+			return nil, priceScheduleErrorMerchantMismatch
+		}
+
+		platforms := make(map[string]bool, len(product.Platforms))
+
+		for _, platform := range product.Platforms {
+			platforms[platform.Id] = true
+		}
+
+		return platforms, nil
+	}
+
+	return nil, priceScheduleErrorProductTypeInvalid
+}
+
+func (s *Service) validatePriceSchedulePrices(
+	ctx context.Context,
+	prices []*billingpb.ProductPrice,
+) *billingpb.ResponseErrorMessage {
+	if len(prices) == 0 {
+		return priceScheduleErrorPricesEmpty
+	}
+
+	for _, price := range prices {
+		if price.Amount <= 0 || price.IsVirtualCurrency {
+			return priceScheduleErrorPriceInvalid
+		}
+
+		group, err := s.priceGroupRepository.GetByRegion(ctx, price.Region)
+
+		if err != nil || group.Currency != price.Currency {
+			zap.L().Error(
+				priceScheduleErrorPriceInvalid.Message,
+				zap.String("region", price.Region),
+				zap.String("currency", price.Currency),
+			)
+			return priceScheduleErrorPriceInvalid
+		}
+	}
+
+	return nil
+}
+
+// savePriceHistory saves the snapshot of prices of the product. Errors are only logged because the product
+// is already saved at the moment.
+func (s *Service) savePriceHistory(ctx context.Context, history *pkg.PriceHistory) {
+	if history.CreatedAt == nil {
+		history.CreatedAt = ptypes.TimestampNow()
+	}
+
+	if err := s.priceHistoryRepository.Insert(ctx, history); err != nil {
+		zap.L().Error(
+			"Unable to save price history of product",
+			zap.Error(err),
+			zap.String("product_id", history.ProductId),
+		)
+	}
+}
+
+// getPriceChangesAt returns the last snapshot of prices before the time and schedules active at the time
+// for each product.
+func (s *Service) getPriceChangesAt(
+	ctx context.Context,
+	productIds []string,
+	at time.Time,
+) (map[string]*pkg.PriceHistory, map[string][]*pkg.PriceSchedule, error) {
+	histories, err := s.priceHistoryRepository.FindLastByProductIds(ctx, productIds, at)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schedules, err := s.priceScheduleRepository.FindActiveByProductIds(ctx, productIds, at)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	historyByProduct := make(map[string]*pkg.PriceHistory, len(histories))
+
+	for _, history := range histories {
+		historyByProduct[history.ProductId] = history
+	}
+
+	schedulesByProduct := make(map[string][]*pkg.PriceSchedule)
+
+	for _, schedule := range schedules {
+		schedulesByProduct[schedule.ProductId] = append(schedulesByProduct[schedule.ProductId], schedule)
+	}
+
+	return historyByProduct, schedulesByProduct, nil
+}
+
+// getOrderPricingTime returns the time prices of the order are resolved at, the order keeps prices which were
+// effective at the moment of its creation.
+func getOrderPricingTime(order *billingpb.Order) time.Time {
+	if order.CreatedAt != nil {
+		if t, err := ptypes.Timestamp(order.CreatedAt); err == nil {
+			return t
+		}
+	}
+
+	return time.Now()
+}
+
+// getProductsPricesAt returns copies of products with prices effective at the time. Products are returned
+// unchanged if prices can't be resolved.
+func (s *Service) getProductsPricesAt(
+	ctx context.Context,
+	products []*billingpb.Product,
+	at time.Time,
+) []*billingpb.Product {
+	ids := make([]string, len(products))
+
+	for i, product := range products {
+		ids[i] = product.Id
+	}
+
+	histories, schedules, err := s.getPriceChangesAt(ctx, ids, at)
+
+	if err != nil {
+		zap.L().Error("Unable to get effective prices of products", zap.Error(err), zap.Strings("product_ids", ids))
+		return products
+	}
+
+	result := make([]*billingpb.Product, len(products))
+
+	for i, product := range products {
+		result[i] = applyProductPriceChanges(product, histories[product.Id], schedules[product.Id])
+	}
+
+	return result
+}
+
+// getKeyProductsPricesAt returns copies of key products with prices effective at the time. Products are
+// returned unchanged if prices can't be resolved.
+func (s *Service) getKeyProductsPricesAt(
+	ctx context.Context,
+	products []*billingpb.KeyProduct,
+	at time.Time,
+	platformId string,
+) []*billingpb.KeyProduct {
+	ids := make([]string, len(products))
+
+	for i, product := range products {
+		ids[i] = product.Id
+	}
+
+	histories, schedules, err := s.getPriceChangesAt(ctx, ids, at)
+
+	if err != nil {
+		zap.L().Error("Unable to get effective prices of key products", zap.Error(err), zap.Strings("product_ids", ids))
+		return products
+	}
+
+	result := make([]*billingpb.KeyProduct, len(products))
+
+	for i, product := range products {
+		result[i] = applyKeyProductPriceChanges(product, histories[product.Id], schedules[product.Id], platformId)
+	}
+
+	return result
+}
+
+// applyProductPriceChanges returns the copy of the product with prices of the snapshot if the product was
+// changed after the snapshot, and with prices of the schedules on top of them.
+func applyProductPriceChanges(
+	product *billingpb.Product,
+	history *pkg.PriceHistory,
+	schedules []*pkg.PriceSchedule,
+) *billingpb.Product {
+	if !isPriceHistoryOutdated(product.UpdatedAt, history) && len(schedules) == 0 {
+		return product
+	}
+
+	// product is cloned to keep the cached product unchanged
+	result := proto.Clone(product).(*billingpb.Product)
+
+	if isPriceHistoryOutdated(product.UpdatedAt, history) {
+		result.Prices = history.Prices
+	}
+
+	for _, schedule := range schedules {
+		result.Prices = mergeSchedulePrices(result.Prices, schedule.Prices)
+		result.Metadata = setPriceScheduleMetadata(result.Metadata, schedule.Id)
+	}
+
+	return result
+}
+
+func applyKeyProductPriceChanges(
+	product *billingpb.KeyProduct,
+	history *pkg.PriceHistory,
+	schedules []*pkg.PriceSchedule,
+	platformId string,
+) *billingpb.KeyProduct {
+	if !isPriceHistoryOutdated(product.UpdatedAt, history) && len(schedules) == 0 {
+		return product
+	}
+
+	result := proto.Clone(product).(*billingpb.KeyProduct)
+
+	if isPriceHistoryOutdated(product.UpdatedAt, history) {
+		for _, platform := range result.Platforms {
+			for _, old := range history.Platforms {
+				if old.Id == platform.Id {
+					platform.Prices = old.Prices
+				}
+			}
+		}
+	}
+
+	for _, schedule := range schedules {
+		for _, platform := range result.Platforms {
+			if platform.Id == schedule.PlatformId {
+				platform.Prices = mergeSchedulePrices(platform.Prices, schedule.Prices)
+			}
+		}
+
+		if schedule.PlatformId == platformId {
+			result.Metadata = setPriceScheduleMetadata(result.Metadata, schedule.Id)
+		}
+	}
+
+	return result
+}
+
+// isPriceHistoryOutdated checks that the product was changed after the snapshot, so prices of the snapshot
+// differ from the current prices of the product.
+func isPriceHistoryOutdated(updatedAt *timestamp.Timestamp, history *pkg.PriceHistory) bool {
+	if history == nil || history.CreatedAt == nil || updatedAt == nil {
+		return false
+	}
+
+	return updatedAt.Seconds > history.CreatedAt.Seconds ||
+		(updatedAt.Seconds == history.CreatedAt.Seconds && updatedAt.Nanos > history.CreatedAt.Nanos)
+}
+
+// mergeSchedulePrices replaces prices in the same region and currency with prices of the schedule.
+func mergeSchedulePrices(prices, schedulePrices []*billingpb.ProductPrice) []*billingpb.ProductPrice {
+	result := append([]*billingpb.ProductPrice{}, prices...)
+
+	for _, price := range schedulePrices {
+		found := false
+
+		for i, v := range result {
+			if v.Region == price.Region && v.Currency == price.Currency && !v.IsVirtualCurrency {
+				result[i] = price
+				found = true
+			}
+		}
+
+		if !found {
+			result = append(result, price)
+		}
+	}
+
+	return result
+}
+
+func setPriceScheduleMetadata(metadata map[string]string, scheduleId string) map[string]string {
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+
+	metadata[pkg.OrderItemMetadataPriceSchedule] = scheduleId
+
+	return metadata
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PriceScheduleTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant   *billingpb.Merchant
+	project    *billingpb.Project
+	product    *billingpb.Product
+	keyProduct *billingpb.KeyProduct
+}
+
+func Test_PriceSchedule(t *testing.T) {
+	suite.Run(t, new(PriceScheduleTestSuite))
+}
+
+func (suite *PriceScheduleTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, _, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+	suite.product = CreateProductsForProject(suite.Suite, suite.service, suite.project, 1)[0]
+	suite.keyProduct = CreateKeyProductsForProject(suite.Suite, suite.service, suite.project, 1)[0]
+}
+
+func (suite *PriceScheduleTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *PriceScheduleTestSuite) getScheduleRequest(from, to time.Time) *pkg.CreatePriceScheduleRequest {
+	startAt, _ := ptypes.TimestampProto(from)
+	endAt, _ := ptypes.TimestampProto(to)
+
+	return &pkg.CreatePriceScheduleRequest{
+		MerchantId:  suite.merchant.Id,
+		ProductId:   suite.product.Id,
+		ProductType: pkg.OrderItemTypeProduct,
+		Name:        "Weekend sale",
+		Prices: []*billingpb.ProductPrice{
+			{Amount: 10, Currency: "USD", Region: "USD"},
+		},
+		StartAt: startAt,
+		EndAt:   endAt,
+	}
+}
+
+func (suite *PriceScheduleTestSuite) helperCreateSchedule(req *pkg.CreatePriceScheduleRequest) *pkg.PriceSchedule {
+	res := &pkg.PriceScheduleResponse{}
+	err := suite.service.CreatePriceSchedule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	return res.Item
+}
+
+func (suite *PriceScheduleTestSuite) getProductAmount(at time.Time) (float64, []*billingpb.OrderItem) {
+	amount, _, items, _, err := suite.service.processProducts(
+		context.TODO(), suite.project.Id, []string{suite.product.Id}, nil, DefaultLanguage, at,
+	)
+	assert.NoError(suite.T(), err)
+
+	return amount, items
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_CreatePriceSchedule_Ok() {
+	now := time.Now()
+	schedule := suite.helperCreateSchedule(suite.getScheduleRequest(now.Add(-time.Hour), now.Add(time.Hour)))
+	assert.NotEmpty(suite.T(), schedule.Id)
+	assert.False(suite.T(), schedule.Cancelled)
+
+	amount, items := suite.getProductAmount(now)
+	assert.EqualValues(suite.T(), 10, amount)
+	assert.Equal(suite.T(), schedule.Id, items[0].Metadata[pkg.OrderItemMetadataPriceSchedule])
+
+	amount, items = suite.getProductAmount(now.Add(2 * time.Hour))
+	assert.EqualValues(suite.T(), 37, amount)
+	assert.NotContains(suite.T(), items[0].Metadata, pkg.OrderItemMetadataPriceSchedule)
+
+	product, err := suite.service.productRepository.GetById(context.TODO(), suite.product.Id)
+	assert.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), product.Metadata, pkg.OrderItemMetadataPriceSchedule)
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_CreatePriceSchedule_KeyProduct_Ok() {
+	now := time.Now()
+	req := suite.getScheduleRequest(now.Add(-time.Hour), now.Add(time.Hour))
+	req.ProductId = suite.keyProduct.Id
+	req.ProductType = pkg.OrderItemTypeKeyProduct
+	req.PlatformId = "steam"
+	schedule := suite.helperCreateSchedule(req)
+
+	amount, _, items, _, err := suite.service.processKeyProducts(
+		context.TODO(), suite.project.Id, []string{suite.keyProduct.Id}, nil, DefaultLanguage, "steam", now,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 10, amount)
+	assert.Equal(suite.T(), schedule.Id, items[0].Metadata[pkg.OrderItemMetadataPriceSchedule])
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_CreatePriceSchedule_Error() {
+	now := time.Now()
+
+	req := suite.getScheduleRequest(now.Add(time.Hour), now)
+	res := &pkg.PriceScheduleResponse{}
+	err := suite.service.CreatePriceSchedule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), priceScheduleErrorPeriodInvalid, res.Message)
+
+	req = suite.getScheduleRequest(now.Add(-2*time.Hour), now.Add(-time.Hour))
+	res = &pkg.PriceScheduleResponse{}
+	err = suite.service.CreatePriceSchedule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), priceScheduleErrorPeriodInvalid, res.Message)
+
+	req = suite.getScheduleRequest(now, now.Add(time.Hour))
+	req.Prices[0].Region = "EUR"
+	res = &pkg.PriceScheduleResponse{}
+	err = suite.service.CreatePriceSchedule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), priceScheduleErrorPriceInvalid, res.Message)
+
+	req = suite.getScheduleRequest(now, now.Add(time.Hour))
+	req.MerchantId = primitive.NewObjectID().Hex()
+	res = &pkg.PriceScheduleResponse{}
+	err = suite.service.CreatePriceSchedule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), priceScheduleErrorMerchantMismatch, res.Message)
+
+	req = suite.getScheduleRequest(now, now.Add(time.Hour))
+	req.ProductId = suite.keyProduct.Id
+	req.ProductType = pkg.OrderItemTypeKeyProduct
+	req.PlatformId = "gog"
+	res = &pkg.PriceScheduleResponse{}
+	err = suite.service.CreatePriceSchedule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), priceScheduleErrorPlatformInvalid, res.Message)
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_CreatePriceSchedule_Overlapping() {
+	now := time.Now()
+	suite.helperCreateSchedule(suite.getScheduleRequest(now, now.Add(2*time.Hour)))
+
+	req := suite.getScheduleRequest(now.Add(time.Hour), now.Add(3*time.Hour))
+	res := &pkg.PriceScheduleResponse{}
+	err := suite.service.CreatePriceSchedule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), priceScheduleErrorOverlapping, res.Message)
+
+	suite.helperCreateSchedule(suite.getScheduleRequest(now.Add(2*time.Hour), now.Add(3*time.Hour)))
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_CancelPriceSchedule_Ok() {
+	now := time.Now()
+	schedule := suite.helperCreateSchedule(suite.getScheduleRequest(now.Add(-time.Hour), now.Add(time.Hour)))
+
+	res := &pkg.PriceScheduleResponse{}
+	err := suite.service.CancelPriceSchedule(
+		context.TODO(),
+		&pkg.CancelPriceScheduleRequest{MerchantId: suite.merchant.Id, Id: schedule.Id},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.True(suite.T(), res.Item.Cancelled)
+
+	amount, _ := suite.getProductAmount(time.Now())
+	assert.EqualValues(suite.T(), 37, amount)
+
+	res = &pkg.PriceScheduleResponse{}
+	err = suite.service.CancelPriceSchedule(
+		context.TODO(),
+		&pkg.CancelPriceScheduleRequest{MerchantId: suite.merchant.Id, Id: schedule.Id},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), priceScheduleErrorAlreadyFinished, res.Message)
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_CancelPriceSchedule_NotFound() {
+	res := &pkg.PriceScheduleResponse{}
+	err := suite.service.CancelPriceSchedule(
+		context.TODO(),
+		&pkg.CancelPriceScheduleRequest{MerchantId: suite.merchant.Id, Id: primitive.NewObjectID().Hex()},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), priceScheduleErrorNotFound, res.Message)
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_UpdateProductPrices_HistoryUsedForOrderTime() {
+	time.Sleep(10 * time.Millisecond)
+	createdAt := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	prices := []*billingpb.ProductPrice{
+		{Amount: 50, Currency: "USD", Region: "USD"},
+		{Amount: 45, Currency: "EUR", Region: "EUR"},
+	}
+	err := suite.service.UpdateProductPrices(
+		context.TODO(),
+		&billingpb.UpdateProductPricesRequest{
+			MerchantId: suite.merchant.Id,
+			ProductId:  suite.product.Id,
+			Prices:     prices,
+		},
+		&billingpb.ResponseError{},
+	)
+	assert.NoError(suite.T(), err)
+
+	amount, _ := suite.getProductAmount(createdAt)
+	assert.EqualValues(suite.T(), 37, amount)
+
+	amount, _ = suite.getProductAmount(time.Now())
+	assert.EqualValues(suite.T(), 50, amount)
+
+	res := &pkg.GetProductPriceHistoryResponse{}
+	err = suite.service.GetProductPriceHistory(
+		context.TODO(),
+		&pkg.GetProductPriceHistoryRequest{
+			MerchantId:  suite.merchant.Id,
+			ProductId:   suite.product.Id,
+			ProductType: pkg.OrderItemTypeProduct,
+		},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.History, 2)
+	assert.Len(suite.T(), res.History[0].Prices, 2)
+	assert.EqualValues(suite.T(), 50, res.History[0].Prices[0].Amount)
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_GetEffectiveProductPrices_Ok() {
+	now := time.Now()
+	schedule := suite.helperCreateSchedule(suite.getScheduleRequest(now.Add(-time.Hour), now.Add(time.Hour)))
+
+	res := &pkg.GetEffectivePricesResponse{}
+	err := suite.service.GetEffectiveProductPrices(
+		context.TODO(),
+		&pkg.GetEffectivePricesRequest{
+			MerchantId:  suite.merchant.Id,
+			ProductId:   suite.product.Id,
+			ProductType: pkg.OrderItemTypeProduct,
+		},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), []string{schedule.Id}, res.ScheduleIds)
+	assert.Len(suite.T(), res.Prices, 3)
+
+	for _, price := range res.Prices {
+		if price.Currency == "USD" {
+			assert.EqualValues(suite.T(), 10, price.Amount)
+		}
+	}
+
+	at, _ := ptypes.TimestampProto(now.Add(2 * time.Hour))
+	res = &pkg.GetEffectivePricesResponse{}
+	err = suite.service.GetEffectiveProductPrices(
+		context.TODO(),
+		&pkg.GetEffectivePricesRequest{
+			MerchantId:  suite.merchant.Id,
+			ProductId:   suite.product.Id,
+			ProductType: pkg.OrderItemTypeProduct,
+			At:          at,
+		},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), res.ScheduleIds)
+}
+
+func (suite *PriceScheduleTestSuite) TestPriceSchedule_MergeSchedulePrices() {
+	prices := []*billingpb.ProductPrice{
+		{Amount: 37, Currency: "USD", Region: "USD"},
+		{Amount: 30, Currency: "EUR", Region: "EUR"},
+	}
+	result := mergeSchedulePrices(prices, []*billingpb.ProductPrice{
+		{Amount: 10, Currency: "USD", Region: "USD"},
+		{Amount: 600, Currency: "RUB", Region: "RUB"},
+	})
+	assert.Len(suite.T(), result, 3)
+	assert.EqualValues(suite.T(), 10, result[0].Amount)
+	assert.EqualValues(suite.T(), 30, result[1].Amount)
+	assert.EqualValues(suite.T(), 600, result[2].Amount)
+	assert.EqualValues(suite.T(), 37, prices[0].Amount)
+}
@@ -2,8 +2,22 @@ package service
 
 import (
 	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
+	tools "github.com/paysuper/paysuper-tools/number"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+var (
+	priceTableErrorUnknown             = newBillingServerErrorMsg("pt000001", "unknown error with price table")
+	priceTableErrorVersionNotFound     = newBillingServerErrorMsg("pt000002", "price table version not found")
+	priceTableErrorVersionNotPending   = newBillingServerErrorMsg("pt000003", "price table version is already applied")
+	priceTableErrorRuleIndexInvalid    = newBillingServerErrorMsg("pt000004", "purchasing power index must be positive")
+	priceTableErrorRuleRoundingInvalid = newBillingServerErrorMsg("pt000005", "charm ending must be positive and less than charm step")
 )
 
 func (s *Service) GetRecommendedPriceTable(
@@ -22,3 +36,544 @@ func (s *Service) GetRecommendedPriceTable(
 
 	return nil
 }
+
+func (s *Service) SetPriceTableRule(
+	ctx context.Context,
+	req *pkg.SetPriceTableRuleRequest,
+	res *pkg.PriceTableRuleResponse,
+) error {
+	if _, err := s.priceGroupRepository.GetByRegion(ctx, req.Region); err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = priceGroupErrorNotFound
+		return nil
+	}
+
+	if req.PurchasingPowerIndex <= 0 {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = priceTableErrorRuleIndexInvalid
+		return nil
+	}
+
+	if req.CharmStep < 0 || req.CharmEnding < 0 || (req.CharmStep > 0 && req.CharmEnding >= req.CharmStep) {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = priceTableErrorRuleRoundingInvalid
+		return nil
+	}
+
+	rule, err := s.priceTableRuleRepository.GetByRegion(ctx, req.Region)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = priceTableErrorUnknown
+			return nil
+		}
+
+		rule = &pkg.PriceTableRule{Region: req.Region, CreatedAt: ptypes.TimestampNow()}
+	}
+
+	rule.PurchasingPowerIndex = req.PurchasingPowerIndex
+	rule.CharmStep = req.CharmStep
+	rule.CharmEnding = req.CharmEnding
+	rule.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.priceTableRuleRepository.Upsert(ctx, rule); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceTableErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = rule
+
+	return nil
+}
+
+func (s *Service) GetPriceTableVersions(
+	ctx context.Context,
+	req *pkg.GetPriceTableVersionsRequest,
+	res *pkg.GetPriceTableVersionsResponse,
+) error {
+	if req.Limit <= 0 {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	versions, err := s.priceTableVersionRepository.Find(ctx, req.Status, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceTableErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Items = versions
+
+	return nil
+}
+
+// ApplyPriceTableVersion replaces price tables used for recommendations with price tables of the pending version
+// without waiting for the end of the review period.
+func (s *Service) ApplyPriceTableVersion(
+	ctx context.Context,
+	req *pkg.ApplyPriceTableVersionRequest,
+	res *pkg.PriceTableVersionResponse,
+) error {
+	version, err := s.priceTableVersionRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = priceTableErrorVersionNotFound
+		return nil
+	}
+
+	if version.Status != pkg.PriceTableVersionStatusPending {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = priceTableErrorVersionNotPending
+		return nil
+	}
+
+	if err = s.applyPriceTableVersion(ctx, version); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceTableErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = version
+
+	return nil
+}
+
+// GetRecommendedPriceDiff returns changes of recommended prices of products of the merchant which will be made
+// after the version of price tables is applied. Only changed prices are returned.
+func (s *Service) GetRecommendedPriceDiff(
+	ctx context.Context,
+	req *pkg.GetRecommendedPriceDiffRequest,
+	res *pkg.GetRecommendedPriceDiffResponse,
+) error {
+	version, msg := s.getPriceTableVersionForDiff(ctx, req.VersionId)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	regions, err := s.priceGroupRepository.GetAll(ctx)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = priceTableErrorUnknown
+		return nil
+	}
+
+	tables := &priceTableDiffTables{
+		service: s,
+		current: make(map[string]*billingpb.PriceTable),
+		next:    make(map[string]*billingpb.PriceTable),
+	}
+
+	for _, table := range version.Tables {
+		tables.next[table.Currency] = table
+	}
+
+	var items []*pkg.RecommendedPriceDiff
+
+	if req.ProductType == pkg.OrderItemTypeKeyProduct {
+		products, err := s.keyProductRepository.Find(ctx, req.MerchantId, req.ProjectId, "", "", "", req.Offset, req.Limit)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = priceTableErrorUnknown
+			return nil
+		}
+
+		for _, product := range products {
+			for _, platform := range product.Platforms {
+				diff := tables.getDiff(ctx, product.DefaultCurrency, platform.Prices, regions)
+
+				for _, item := range diff {
+					item.ProductId = product.Id
+					item.ProductType = pkg.OrderItemTypeKeyProduct
+					item.PlatformId = platform.Id
+				}
+
+				items = append(items, diff...)
+			}
+		}
+	} else {
+		products, err := s.productRepository.Find(ctx, req.MerchantId, req.ProjectId, "", "", 0, req.Offset, req.Limit)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = priceTableErrorUnknown
+			return nil
+		}
+
+		for _, product := range products {
+			diff := tables.getDiff(ctx, product.DefaultCurrency, product.Prices, regions)
+
+			for _, item := range diff {
+				item.ProductId = product.Id
+				item.ProductType = pkg.OrderItemTypeProduct
+			}
+
+			items = append(items, diff...)
+		}
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.VersionId = version.Id
+	res.Items = items
+
+	return nil
+}
+
+// RefreshPriceTables applies the pending version of price tables if the review period of it is over and
+// calculates the new pending version from current exchange rates and purchasing power indices of regions.
+// The new version is not created while the previous one is under review or if price tables are not changed.
+func (s *Service) RefreshPriceTables(ctx context.Context) (*pkg.PriceTableVersion, error) {
+	pending, err := s.priceTableVersionRepository.Find(ctx, pkg.PriceTableVersionStatusPending, 0, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) > 0 {
+		createdAt, err := ptypes.Timestamp(pending[0].CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if time.Since(createdAt) < time.Duration(s.cfg.PriceTableApplyDelay)*time.Hour {
+			return nil, nil
+		}
+
+		if err = s.applyPriceTableVersion(ctx, pending[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	version, err := s.calculatePriceTableVersion(ctx)
+
+	if err != nil || version == nil {
+		return nil, err
+	}
+
+	last, err := s.priceTableVersionRepository.Find(ctx, "", 0, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	version.Version = 1
+
+	if len(last) > 0 {
+		version.Version = last[0].Version + 1
+	}
+
+	if err = s.priceTableVersionRepository.Upsert(ctx, version); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+func (s *Service) applyPriceTableVersion(ctx context.Context, version *pkg.PriceTableVersion) error {
+	for _, table := range version.Tables {
+		if err := s.priceTableRepository.Upsert(ctx, table); err != nil {
+			return err
+		}
+	}
+
+	version.Status = pkg.PriceTableVersionStatusApplied
+	version.AppliedAt = ptypes.TimestampNow()
+
+	return s.priceTableVersionRepository.Upsert(ctx, version)
+}
+
+// calculatePriceTableVersion calculates price tables of all regions from the price table of the base region,
+// nil is returned if calculated price tables are equal to current price tables.
+func (s *Service) calculatePriceTableVersion(ctx context.Context) (*pkg.PriceTableVersion, error) {
+	base, err := s.priceTableRepository.GetByRegion(ctx, pkg.PriceTableBaseRegion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	baseRegion, err := s.priceGroupRepository.GetByRegion(ctx, pkg.PriceTableBaseRegion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := s.priceGroupRepository.GetAll(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.priceTableRuleRepository.GetAll(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rulesByRegion := make(map[string]*pkg.PriceTableRule, len(rules))
+
+	for _, rule := range rules {
+		rulesByRegion[rule.Region] = rule
+	}
+
+	version := &pkg.PriceTableVersion{
+		Status:    pkg.PriceTableVersionStatusPending,
+		CreatedAt: ptypes.TimestampNow(),
+	}
+	isChanged := false
+
+	for _, region := range regions {
+		// price table of the base region is a source for all regions and it's never recalculated
+		if region.Region == pkg.PriceTableBaseRegion {
+			continue
+		}
+
+		rate := float64(1)
+
+		if region.Currency != baseRegion.Currency {
+			rate, err = s.getPriceInCurrencyByAmount(ctx, region.Currency, baseRegion.Currency, 1)
+
+			if err != nil {
+				zap.L().Error(
+					"Unable to get exchange rate for price table",
+					zap.Error(err),
+					zap.String("region", region.Region),
+					zap.String("currency", region.Currency),
+				)
+				return nil, err
+			}
+		}
+
+		rule := rulesByRegion[region.Region]
+		index := float64(1)
+
+		if rule != nil && rule.PurchasingPowerIndex > 0 {
+			index = rule.PurchasingPowerIndex
+		}
+
+		table := s.calculatePriceTable(base, region.Currency, rate*index, rule)
+		table.Currency = region.Region
+		version.Tables = append(version.Tables, table)
+		version.Rates = append(version.Rates, &pkg.PriceTableVersionRate{
+			Region:               region.Region,
+			Currency:             region.Currency,
+			ExchangeRate:         rate,
+			PurchasingPowerIndex: index,
+		})
+
+		current, err := s.priceTableRepository.GetByRegion(ctx, region.Region)
+
+		if err != nil || !isPriceTableRangesEqual(current.Ranges, table.Ranges) {
+			isChanged = true
+		}
+	}
+
+	if !isChanged {
+		return nil, nil
+	}
+
+	return version, nil
+}
+
+// calculatePriceTable converts ranges of the base price table with multiplier and rounds bounds of ranges
+// to charm prices. Bounds of ranges always increase even if rounded values are equal.
+func (s *Service) calculatePriceTable(
+	base *billingpb.PriceTable,
+	currency string,
+	multiplier float64,
+	rule *pkg.PriceTableRule,
+) *billingpb.PriceTable {
+	step := float64(1)
+
+	if rule != nil && rule.CharmStep > 0 {
+		step = rule.CharmStep
+	}
+
+	table := &billingpb.PriceTable{}
+	from := float64(0)
+
+	for _, rng := range base.Ranges {
+		to := s.getCharmPrice(rng.To*multiplier, currency, rule)
+
+		if to <= from {
+			to = s.getCharmPrice(from+step, currency, rule)
+		}
+
+		table.Ranges = append(table.Ranges, &billingpb.PriceTableRange{From: from, To: to, Position: rng.Position})
+		from = to
+	}
+
+	return table
+}
+
+// getCharmPrice rounds the price up to the nearest price with the charm ending of the rule,
+// the price is rounded to the precision of the currency if the region has no rule.
+func (s *Service) getCharmPrice(price float64, currency string, rule *pkg.PriceTableRule) float64 {
+	if rule == nil || rule.CharmStep <= 0 {
+		return s.FormatAmount(price, currency)
+	}
+
+	steps := math.Ceil(tools.ToPrecise((price - rule.CharmEnding) / rule.CharmStep))
+
+	if steps < 0 {
+		steps = 0
+	}
+
+	return s.FormatAmount(steps*rule.CharmStep+rule.CharmEnding, currency)
+}
+
+func (s *Service) getPriceTableVersionForDiff(
+	ctx context.Context,
+	versionId string,
+) (*pkg.PriceTableVersion, *billingpb.ResponseErrorMessage) {
+	if versionId != "" {
+		version, err := s.priceTableVersionRepository.GetById(ctx, versionId)
+
+		if err != nil {
+			return nil, priceTableErrorVersionNotFound
+		}
+
+		return version, nil
+	}
+
+	versions, err := s.priceTableVersionRepository.Find(ctx, pkg.PriceTableVersionStatusPending, 0, 1)
+
+	if err != nil {
+		return nil, priceTableErrorUnknown
+	}
+
+	if len(versions) == 0 {
+		return nil, priceTableErrorVersionNotFound
+	}
+
+	return versions[0], nil
+}
+
+func isPriceTableRangesEqual(a, b []*billingpb.PriceTableRange) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].From != b[i].From || a[i].To != b[i].To || a[i].Position != b[i].Position {
+			return false
+		}
+	}
+
+	return true
+}
+
+// priceTableDiffTables contains current price tables and price tables of the version loaded for calculation
+// of the diff of recommended prices.
+type priceTableDiffTables struct {
+	service *Service
+	current map[string]*billingpb.PriceTable
+	next    map[string]*billingpb.PriceTable
+}
+
+func (t *priceTableDiffTables) getCurrent(ctx context.Context, region string) *billingpb.PriceTable {
+	if table, ok := t.current[region]; ok {
+		return table
+	}
+
+	table, err := t.service.priceTableRepository.GetByRegion(ctx, region)
+
+	if err != nil || len(table.Ranges) == 0 {
+		table = nil
+	}
+
+	t.current[region] = table
+
+	return table
+}
+
+func (t *priceTableDiffTables) getNext(ctx context.Context, region string) *billingpb.PriceTable {
+	if table, ok := t.next[region]; ok {
+		return table
+	}
+
+	return t.getCurrent(ctx, region)
+}
+
+func (t *priceTableDiffTables) getDiff(
+	ctx context.Context,
+	defaultRegion string,
+	prices []*billingpb.ProductPrice,
+	regions []*billingpb.PriceGroup,
+) []*pkg.RecommendedPriceDiff {
+	var amount float64
+
+	for _, price := range prices {
+		if price.Region == defaultRegion && !price.IsVirtualCurrency {
+			amount = price.Amount
+		}
+	}
+
+	currentSource := t.getCurrent(ctx, defaultRegion)
+	nextSource := t.getNext(ctx, defaultRegion)
+
+	if amount <= 0 || currentSource == nil || nextSource == nil {
+		return nil
+	}
+
+	currentRange := t.service.getPriceTableRange(currentSource, amount)
+	nextRange := t.service.getPriceTableRange(nextSource, amount)
+
+	var result []*pkg.RecommendedPriceDiff
+
+	for _, region := range regions {
+		if region.Region == defaultRegion {
+			continue
+		}
+
+		current := t.getCurrent(ctx, region.Region)
+		next := t.getNext(ctx, region.Region)
+
+		if current == nil || next == nil {
+			continue
+		}
+
+		currentPrice := t.service.getRecommendedPriceByTable(current, region, currentRange, amount)
+		nextPrice := t.service.getRecommendedPriceByTable(next, region, nextRange, amount)
+
+		if currentPrice == nextPrice {
+			continue
+		}
+
+		item := &pkg.RecommendedPriceDiff{
+			Region:             region.Region,
+			Currency:           region.Currency,
+			CurrentRecommended: currentPrice,
+			NewRecommended:     nextPrice,
+		}
+
+		if currentPrice > 0 {
+			item.Change = tools.FormatAmount((nextPrice - currentPrice) / currentPrice * 100)
+		}
+
+		for _, price := range prices {
+			if price.Region == region.Region && price.Currency == region.Currency {
+				item.Amount = price.Amount
+			}
+		}
+
+		result = append(result, item)
+	}
+
+	return result
+}
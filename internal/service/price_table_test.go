@@ -5,6 +5,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/internal/config"
 	"github.com/paysuper/paysuper-billing-server/internal/database"
 	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
 	casbinMocks "github.com/paysuper/paysuper-proto/go/casbinpb/mocks"
 	reportingMocks "github.com/paysuper/paysuper-proto/go/reporterpb/mocks"
@@ -127,3 +128,234 @@ func (suite *PriceTableTestSuite) TestPriceTable_GetRecommendedPriceTable_Ok_Emp
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), res.Ranges, 0)
 }
+
+func (suite *PriceTableTestSuite) helperCreatePriceTables() {
+	groups := []*billingpb.PriceGroup{
+		{Id: primitive.NewObjectID().Hex(), Region: "USD", Currency: "USD", IsActive: true},
+		{Id: primitive.NewObjectID().Hex(), Region: "RUB", Currency: "RUB", IsActive: true},
+	}
+
+	for _, group := range groups {
+		assert.NoError(suite.T(), suite.service.priceGroupRepository.Insert(context.TODO(), group))
+	}
+
+	tables := []*billingpb.PriceTable{
+		{
+			Currency: "USD",
+			Ranges: []*billingpb.PriceTableRange{
+				{From: 0, To: 0.99, Position: 0},
+				{From: 0.99, To: 1.99, Position: 1},
+				{From: 1.99, To: 2.99, Position: 2},
+			},
+		},
+		{
+			Currency: "RUB",
+			Ranges: []*billingpb.PriceTableRange{
+				{From: 0, To: 30, Position: 0},
+				{From: 30, To: 61, Position: 1},
+				{From: 61, To: 82, Position: 2},
+			},
+		},
+	}
+
+	for _, table := range tables {
+		assert.NoError(suite.T(), suite.service.priceTableRepository.Insert(context.TODO(), table))
+	}
+
+	res := &pkg.PriceTableRuleResponse{}
+	err := suite.service.SetPriceTableRule(
+		context.TODO(),
+		&pkg.SetPriceTableRuleRequest{Region: "RUB", PurchasingPowerIndex: 0.5, CharmStep: 10, CharmEnding: 9},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+}
+
+func (suite *PriceTableTestSuite) TestPriceTable_GetCharmPrice() {
+	rule := &pkg.PriceTableRule{CharmStep: 1, CharmEnding: 0.99}
+	assert.EqualValues(suite.T(), 3.99, suite.service.getCharmPrice(3.4, "USD", rule))
+	assert.EqualValues(suite.T(), 3.99, suite.service.getCharmPrice(3.99, "USD", rule))
+	assert.EqualValues(suite.T(), 0.99, suite.service.getCharmPrice(0.5, "USD", rule))
+
+	rule = &pkg.PriceTableRule{CharmStep: 10, CharmEnding: 9}
+	assert.EqualValues(suite.T(), 129, suite.service.getCharmPrice(125, "RUB", rule))
+	assert.EqualValues(suite.T(), 9, suite.service.getCharmPrice(3, "RUB", rule))
+
+	assert.EqualValues(suite.T(), 3.46, suite.service.getCharmPrice(3.456, "USD", nil))
+}
+
+func (suite *PriceTableTestSuite) TestPriceTable_CalculatePriceTable_Ok() {
+	base := &billingpb.PriceTable{
+		Currency: "USD",
+		Ranges: []*billingpb.PriceTableRange{
+			{From: 0, To: 0.99, Position: 0},
+			{From: 0.99, To: 1.99, Position: 1},
+			{From: 1.99, To: 2.99, Position: 2},
+		},
+	}
+
+	table := suite.service.calculatePriceTable(base, "RUB", 65, &pkg.PriceTableRule{CharmStep: 10, CharmEnding: 9})
+	assert.Equal(suite.T(), []*billingpb.PriceTableRange{
+		{From: 0, To: 69, Position: 0},
+		{From: 69, To: 139, Position: 1},
+		{From: 139, To: 199, Position: 2},
+	}, table.Ranges)
+
+	table = suite.service.calculatePriceTable(base, "USD", 0.1, &pkg.PriceTableRule{CharmStep: 1, CharmEnding: 0.99})
+	assert.Equal(suite.T(), []*billingpb.PriceTableRange{
+		{From: 0, To: 0.99, Position: 0},
+		{From: 0.99, To: 1.99, Position: 1},
+		{From: 1.99, To: 2.99, Position: 2},
+	}, table.Ranges)
+}
+
+func (suite *PriceTableTestSuite) TestPriceTable_SetPriceTableRule_Error() {
+	suite.helperCreatePriceTables()
+
+	res := &pkg.PriceTableRuleResponse{}
+	err := suite.service.SetPriceTableRule(
+		context.TODO(),
+		&pkg.SetPriceTableRuleRequest{Region: "XXX", PurchasingPowerIndex: 1},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+
+	res = &pkg.PriceTableRuleResponse{}
+	err = suite.service.SetPriceTableRule(
+		context.TODO(),
+		&pkg.SetPriceTableRuleRequest{Region: "RUB", PurchasingPowerIndex: 0},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), priceTableErrorRuleIndexInvalid, res.Message)
+
+	res = &pkg.PriceTableRuleResponse{}
+	err = suite.service.SetPriceTableRule(
+		context.TODO(),
+		&pkg.SetPriceTableRuleRequest{Region: "RUB", PurchasingPowerIndex: 1, CharmStep: 1, CharmEnding: 1},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), priceTableErrorRuleRoundingInvalid, res.Message)
+}
+
+func (suite *PriceTableTestSuite) TestPriceTable_RefreshPriceTables_Ok() {
+	suite.helperCreatePriceTables()
+
+	version, err := suite.service.RefreshPriceTables(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), version)
+	assert.EqualValues(suite.T(), 1, version.Version)
+	assert.Equal(suite.T(), pkg.PriceTableVersionStatusPending, version.Status)
+	assert.Len(suite.T(), version.Tables, 1)
+	assert.Equal(suite.T(), "RUB", version.Tables[0].Currency)
+	assert.EqualValues(suite.T(), 39, version.Tables[0].Ranges[0].To)
+	assert.EqualValues(suite.T(), 69, version.Tables[0].Ranges[1].To)
+	assert.EqualValues(suite.T(), 99, version.Tables[0].Ranges[2].To)
+	assert.Len(suite.T(), version.Rates, 1)
+	assert.EqualValues(suite.T(), 65, version.Rates[0].ExchangeRate)
+	assert.EqualValues(suite.T(), 0.5, version.Rates[0].PurchasingPowerIndex)
+
+	// previous version is under review
+	next, err := suite.service.RefreshPriceTables(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), next)
+
+	table, err := suite.service.priceTableRepository.GetByRegion(context.TODO(), "RUB")
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 30, table.Ranges[0].To)
+
+	res := &pkg.PriceTableVersionResponse{}
+	err = suite.service.ApplyPriceTableVersion(context.TODO(), &pkg.ApplyPriceTableVersionRequest{Id: version.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.PriceTableVersionStatusApplied, res.Item.Status)
+	assert.NotNil(suite.T(), res.Item.AppliedAt)
+
+	table, err = suite.service.priceTableRepository.GetByRegion(context.TODO(), "RUB")
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 39, table.Ranges[0].To)
+
+	res = &pkg.PriceTableVersionResponse{}
+	err = suite.service.ApplyPriceTableVersion(context.TODO(), &pkg.ApplyPriceTableVersionRequest{Id: version.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), priceTableErrorVersionNotPending, res.Message)
+
+	// price tables are not changed after the version applied
+	next, err = suite.service.RefreshPriceTables(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), next)
+}
+
+func (suite *PriceTableTestSuite) TestPriceTable_RefreshPriceTables_AppliedAfterDelay() {
+	suite.helperCreatePriceTables()
+
+	version, err := suite.service.RefreshPriceTables(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), version)
+
+	suite.service.cfg.PriceTableApplyDelay = 0
+	next, err := suite.service.RefreshPriceTables(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), next)
+
+	res := &pkg.GetPriceTableVersionsResponse{}
+	err = suite.service.GetPriceTableVersions(context.TODO(), &pkg.GetPriceTableVersionsRequest{}, res)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), res.Items, 1)
+	assert.Equal(suite.T(), pkg.PriceTableVersionStatusApplied, res.Items[0].Status)
+}
+
+func (suite *PriceTableTestSuite) TestPriceTable_GetRecommendedPriceDiff_Ok() {
+	suite.helperCreatePriceTables()
+
+	product := &billingpb.Product{
+		Id:              primitive.NewObjectID().Hex(),
+		MerchantId:      primitive.NewObjectID().Hex(),
+		ProjectId:       primitive.NewObjectID().Hex(),
+		Sku:             "sku",
+		Name:            map[string]string{"en": "Product"},
+		DefaultCurrency: "USD",
+		Enabled:         true,
+		Prices: []*billingpb.ProductPrice{
+			{Amount: 2.5, Currency: "USD", Region: "USD"},
+			{Amount: 150, Currency: "RUB", Region: "RUB"},
+		},
+	}
+	assert.NoError(suite.T(), suite.service.productRepository.Upsert(context.TODO(), product))
+
+	res := &pkg.GetRecommendedPriceDiffResponse{}
+	err := suite.service.GetRecommendedPriceDiff(
+		context.TODO(),
+		&pkg.GetRecommendedPriceDiffRequest{MerchantId: product.MerchantId},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), priceTableErrorVersionNotFound, res.Message)
+
+	version, err := suite.service.RefreshPriceTables(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), version)
+
+	res = &pkg.GetRecommendedPriceDiffResponse{}
+	err = suite.service.GetRecommendedPriceDiff(
+		context.TODO(),
+		&pkg.GetRecommendedPriceDiffRequest{MerchantId: product.MerchantId},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), version.Id, res.VersionId)
+	assert.Len(suite.T(), res.Items, 1)
+	assert.Equal(suite.T(), product.Id, res.Items[0].ProductId)
+	assert.Equal(suite.T(), pkg.OrderItemTypeProduct, res.Items[0].ProductType)
+	assert.Equal(suite.T(), "RUB", res.Items[0].Region)
+	assert.EqualValues(suite.T(), 150, res.Items[0].Amount)
+	assert.EqualValues(suite.T(), 72, res.Items[0].CurrentRecommended)
+	assert.EqualValues(suite.T(), 84, res.Items[0].NewRecommended)
+	assert.EqualValues(suite.T(), 16.67, res.Items[0].Change)
+}
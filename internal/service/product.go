@@ -103,6 +103,14 @@ func (s *Service) CreateOrUpdateProduct(ctx context.Context, req *billingpb.Prod
 		return productErrorUpsert
 	}
 
+	s.savePriceHistory(ctx, &pkg.PriceHistory{
+		MerchantId:  req.MerchantId,
+		ProductId:   req.Id,
+		ProductType: pkg.OrderItemTypeProduct,
+		Prices:      req.Prices,
+		CreatedAt:   req.UpdatedAt,
+	})
+
 	res.Id = req.Id
 	res.Object = req.Object
 	res.Type = req.Type
@@ -353,10 +361,20 @@ func (s *Service) UpdateProductPrices(ctx context.Context, req *billingpb.Update
 		return productErrorPriceDefaultCurrency
 	}
 
+	product.UpdatedAt = ptypes.TimestampNow()
+
 	if err := s.productRepository.Upsert(ctx, product); err != nil {
 		zap.S().Errorf("Query to create/update product failed", "err", err.Error(), "data", req)
 		return productErrorPricesUpdate
 	}
 
+	s.savePriceHistory(ctx, &pkg.PriceHistory{
+		MerchantId:  product.MerchantId,
+		ProductId:   product.Id,
+		ProductType: pkg.OrderItemTypeProduct,
+		Prices:      product.Prices,
+		CreatedAt:   product.UpdatedAt,
+	})
+
 	return nil
 }
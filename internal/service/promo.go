@@ -0,0 +1,912 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	promoErrorUnknown               = newBillingServerErrorMsg("dc000001", "unknown error with promo")
+	promoErrorNotFound              = newBillingServerErrorMsg("dc000002", "promo not found")
+	promoErrorMerchantMismatch      = newBillingServerErrorMsg("dc000003", "merchant id mismatch")
+	promoErrorProjectMismatch       = newBillingServerErrorMsg("dc000004", "project id mismatch")
+	promoErrorDuplicate             = newBillingServerErrorMsg("dc000005", "promo with same code already exists")
+	promoErrorNameNotProvided       = newBillingServerErrorMsg("dc000006", "promo name must be set")
+	promoErrorTypeInvalid           = newBillingServerErrorMsg("dc000007", "promo type is invalid")
+	promoErrorPercentInvalid        = newBillingServerErrorMsg("dc000008", "discount percent must be greater than 0 and not greater than 100")
+	promoErrorAmountsInvalid        = newBillingServerErrorMsg("dc000009", "discount amounts must be set with currency and positive amount")
+	promoErrorQuantityInvalid       = newBillingServerErrorMsg("dc000010", "buy and free quantities must be greater than 0")
+	promoErrorPeriodInvalid         = newBillingServerErrorMsg("dc000011", "end of promo validity period must be after the start")
+	promoErrorLimitsInvalid         = newBillingServerErrorMsg("dc000012", "promo usage limits must not be negative")
+	promoErrorCodeNotFound          = newBillingServerErrorMsg("dc000013", "promo code not found")
+	promoErrorNotActive             = newBillingServerErrorMsg("dc000014", "promo code is not active")
+	promoErrorRegionNotAllowed      = newBillingServerErrorMsg("dc000015", "promo code is not available in the region")
+	promoErrorUsageLimit            = newBillingServerErrorMsg("dc000016", "promo code usage limit is reached")
+	promoErrorNotApplicable         = newBillingServerErrorMsg("dc000017", "promo code is not applicable to products of the order")
+	promoErrorOrderTypeNotSupported = newBillingServerErrorMsg("dc000018", "promo codes are not supported for the order")
+)
+
+const (
+	// promoUsageReservationTtl is a time after which the reserved usage of the promo of the unpaid order
+	// may be released for other orders.
+	promoUsageReservationTtl = 30 * time.Minute
+)
+
+func (s *Service) CreateOrUpdatePromo(
+	ctx context.Context,
+	req *pkg.CreateOrUpdatePromoRequest,
+	res *pkg.PromoResponse,
+) error {
+	var (
+		isNew = len(req.Id) == 0
+		now   = ptypes.TimestampNow()
+		promo = &pkg.Promo{}
+	)
+
+	project, err := s.project.GetById(ctx, req.ProjectId)
+
+	if err != nil || project.MerchantId != req.MerchantId {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = promoErrorProjectMismatch
+		return nil
+	}
+
+	if isNew {
+		promo.Object = pkg.PromoObject
+		promo.MerchantId = req.MerchantId
+		promo.ProjectId = req.ProjectId
+		promo.CreatedAt = now
+	} else {
+		var msg *billingpb.ResponseErrorMessage
+		promo, msg = s.getMerchantPromo(ctx, req.MerchantId, req.Id)
+
+		if msg != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = msg
+			return nil
+		}
+
+		if req.ProjectId != promo.ProjectId {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = promoErrorProjectMismatch
+			return nil
+		}
+	}
+
+	if msg := validatePromo(req); msg != nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = msg
+		return nil
+	}
+
+	code := normalizePromoCode(req.Code)
+
+	if code != "" && code != promo.Code {
+		count, err := s.promoRepository.CountByProjectIdCode(ctx, promo.ProjectId, code)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusSystemError
+			res.Message = promoErrorUnknown
+			return nil
+		}
+
+		if count > 0 {
+			res.Status = billingpb.ResponseStatusBadData
+			res.Message = promoErrorDuplicate
+			return nil
+		}
+	}
+
+	promo.Code = code
+	promo.Name = req.Name
+	promo.Type = req.Type
+	promo.Percent = req.Percent
+	promo.Amounts = req.Amounts
+	promo.BuyQuantity = req.BuyQuantity
+	promo.FreeQuantity = req.FreeQuantity
+	promo.Products = req.Products
+	promo.Regions = req.Regions
+	promo.ValidFrom = req.ValidFrom
+	promo.ValidTo = req.ValidTo
+	promo.MaxUses = req.MaxUses
+	promo.MaxUsesPerCustomer = req.MaxUsesPerCustomer
+	promo.Enabled = req.Enabled
+	promo.UpdatedAt = now
+
+	if err = s.promoRepository.Upsert(ctx, promo); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = promoErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = promo
+
+	return nil
+}
+
+func (s *Service) GetPromo(ctx context.Context, req *pkg.GetPromoRequest, res *pkg.PromoResponse) error {
+	promo, msg := s.getMerchantPromo(ctx, req.MerchantId, req.Id)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = promo
+
+	return nil
+}
+
+func (s *Service) ListPromos(ctx context.Context, req *pkg.ListPromosRequest, res *pkg.ListPromosResponse) error {
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	count, err := s.promoRepository.FindCount(ctx, req.MerchantId, req.ProjectId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = promoErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count == 0 || req.Offset > count {
+		return nil
+	}
+
+	res.Items, err = s.promoRepository.Find(ctx, req.MerchantId, req.ProjectId, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = promoErrorUnknown
+		return nil
+	}
+
+	return nil
+}
+
+func (s *Service) DeletePromo(
+	ctx context.Context,
+	req *pkg.GetPromoRequest,
+	res *billingpb.EmptyResponseWithStatus,
+) error {
+	promo, msg := s.getMerchantPromo(ctx, req.MerchantId, req.Id)
+
+	if msg != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = msg
+		return nil
+	}
+
+	promo.Deleted = true
+	promo.UpdatedAt = ptypes.TimestampNow()
+
+	if err := s.promoRepository.Upsert(ctx, promo); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = promoErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// PaymentFormPromoCodeChanged applies the promo code entered by the customer in the payment form
+// and recalculates amounts of the order.
+func (s *Service) PaymentFormPromoCodeChanged(
+	ctx context.Context,
+	req *pkg.PaymentFormPromoCodeRequest,
+	rsp *billingpb.PaymentFormDataChangeResponse,
+) error {
+	order, err := s.getOrderByUuidToForm(ctx, req.OrderId)
+
+	if err != nil {
+		zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error())
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	if order.ProductType != pkg.OrderType_product && order.ProductType != pkg.OrderType_key &&
+		order.ProductType != pkg.OrderTypeMixed {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = promoErrorOrderTypeNotSupported
+		return nil
+	}
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	delete(order.PrivateMetadata, pkg.OrderPrivateMetadataPromoCode)
+
+	if code := normalizePromoCode(req.Code); code != "" {
+		order.PrivateMetadata[pkg.OrderPrivateMetadataPromoCode] = code
+	}
+
+	if order.ProductType == pkg.OrderType_product {
+		err = s.ProcessOrderProducts(ctx, order)
+	} else if order.ProductType == pkg.OrderType_key {
+		_, err = s.ProcessOrderKeyProducts(ctx, order)
+	} else {
+		_, err = s.ProcessOrderCartItems(ctx, order)
+	}
+
+	if err != nil {
+		zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error())
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	processor := &OrderCreateRequestProcessor{Service: s, ctx: ctx}
+	err = processor.processOrderVat(order)
+	if err != nil {
+		zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error(), "method", "processOrderVat")
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	err = s.setOrderChargeAmountAndCurrency(ctx, order)
+	if err != nil {
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	err = s.updateOrder(ctx, order)
+
+	if err != nil {
+		zap.S().Errorw(pkg.MethodFinishedWithError, "err", err.Error())
+		if e, ok := err.(*billingpb.ResponseErrorMessage); ok {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = e
+			return nil
+		}
+		return err
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = order.GetPaymentFormDataChangeResult()
+
+	return nil
+}
+
+// GetRoyaltyReportDiscounts returns totals of discounts of promos in orders of the royalty report period.
+func (s *Service) GetRoyaltyReportDiscounts(
+	ctx context.Context,
+	req *pkg.GetRoyaltyReportDiscountsRequest,
+	res *pkg.GetRoyaltyReportDiscountsResponse,
+) error {
+	report, err := s.royaltyReportRepository.GetById(ctx, req.ReportId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = royaltyReportErrorReportNotFound
+			return nil
+		}
+
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	if report.MerchantId != req.MerchantId {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = royaltyReportErrorNotOwnedByMerchant
+		return nil
+	}
+
+	from, _ := ptypes.Timestamp(report.PeriodFrom)
+	to, _ := ptypes.Timestamp(report.PeriodTo)
+
+	res.Items, err = s.promoUsageRepository.GetDiscountSummary(ctx, report.MerchantId, from, to)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = promoErrorUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+func (s *Service) getMerchantPromo(
+	ctx context.Context,
+	merchantId, promoId string,
+) (*pkg.Promo, *billingpb.ResponseErrorMessage) {
+	promo, err := s.promoRepository.GetById(ctx, promoId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, promoErrorNotFound
+		}
+
+		return nil, promoErrorUnknown
+	}
+
+	if promo.MerchantId != merchantId {
+		return nil, promoErrorMerchantMismatch
+	}
+
+	return promo, nil
+}
+
+func validatePromo(req *pkg.CreateOrUpdatePromoRequest) *billingpb.ResponseErrorMessage {
+	if strings.TrimSpace(req.Name) == "" {
+		return promoErrorNameNotProvided
+	}
+
+	switch req.Type {
+	case pkg.PromoTypePercent:
+		if req.Percent <= 0 || req.Percent > 100 {
+			return promoErrorPercentInvalid
+		}
+	case pkg.PromoTypeFixed:
+		if len(req.Amounts) == 0 {
+			return promoErrorAmountsInvalid
+		}
+
+		for _, amount := range req.Amounts {
+			if amount.Currency == "" || amount.Amount <= 0 {
+				return promoErrorAmountsInvalid
+			}
+		}
+	case pkg.PromoTypeBuyXGetY:
+		if req.BuyQuantity <= 0 || req.FreeQuantity <= 0 {
+			return promoErrorQuantityInvalid
+		}
+	default:
+		return promoErrorTypeInvalid
+	}
+
+	if req.ValidFrom != nil && req.ValidTo != nil {
+		from, err := ptypes.Timestamp(req.ValidFrom)
+
+		if err != nil {
+			return promoErrorPeriodInvalid
+		}
+
+		to, err := ptypes.Timestamp(req.ValidTo)
+
+		if err != nil || !to.After(from) {
+			return promoErrorPeriodInvalid
+		}
+	}
+
+	if req.MaxUses < 0 || req.MaxUsesPerCustomer < 0 {
+		return promoErrorLimitsInvalid
+	}
+
+	return nil
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// processOrderPromo applies the promo code entered by the customer or the best automatic campaign of the project
+// to items of the order and recalculates the order amount. The error is returned if the entered code isn't
+// applicable to the order, the customer should remove the code in the payment form to continue.
+// The usage of the promo with usage limits is reserved for the order, the reservation of the previously
+// applied promo is released if the promo of the order is changed.
+func (s *Service) processOrderPromo(ctx context.Context, order *billingpb.Order) error {
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	previousPromoId := order.PrivateMetadata[pkg.OrderPrivateMetadataPromoId]
+	delete(order.PrivateMetadata, pkg.OrderPrivateMetadataPromoId)
+
+	promo, discounts, msg := s.getOrderPromo(ctx, order)
+
+	if promo != nil {
+		if msg = s.reserveOrderPromo(ctx, order, promo); msg != nil {
+			promo = nil
+
+			// the automatic campaign which reached the limit isn't applied without the error
+			if order.PrivateMetadata[pkg.OrderPrivateMetadataPromoCode] == "" {
+				msg = nil
+			}
+		}
+	}
+
+	if previousPromoId != "" && (promo == nil || promo.Id != previousPromoId) {
+		s.releaseOrderPromo(ctx, order, previousPromoId)
+	}
+
+	if msg != nil {
+		return msg
+	}
+
+	if promo != nil {
+		s.applyOrderPromo(order, promo, discounts)
+	}
+
+	return nil
+}
+
+// getOrderPromo returns the promo which should be applied to the order and discounts of items of the order.
+func (s *Service) getOrderPromo(
+	ctx context.Context,
+	order *billingpb.Order,
+) (*pkg.Promo, []float64, *billingpb.ResponseErrorMessage) {
+	if len(order.Items) == 0 {
+		return nil, nil, nil
+	}
+
+	if code := order.PrivateMetadata[pkg.OrderPrivateMetadataPromoCode]; code != "" {
+		return s.getOrderPromoByCode(ctx, order, code)
+	}
+
+	promo, discounts := s.getOrderCampaign(ctx, order)
+
+	return promo, discounts, nil
+}
+
+// getOrderPromoRegion returns the price region which is used for amounts of the order.
+func (s *Service) getOrderPromoRegion(ctx context.Context, order *billingpb.Order) (string, error) {
+	priceGroup, err := s.getOrderPriceGroup(ctx, order)
+
+	if err != nil {
+		return "", err
+	}
+
+	if priceGroup.Currency == order.Currency {
+		return priceGroup.Region, nil
+	}
+
+	// amounts of the order are calculated in the default price group of the merchant
+	return order.Currency, nil
+}
+
+func (s *Service) getOrderPromoByCode(
+	ctx context.Context,
+	order *billingpb.Order,
+	code string,
+) (*pkg.Promo, []float64, *billingpb.ResponseErrorMessage) {
+	promo, err := s.promoRepository.GetByCode(ctx, order.GetProjectId(), normalizePromoCode(code))
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, promoErrorCodeNotFound
+		}
+
+		return nil, nil, promoErrorUnknown
+	}
+
+	discounts, msg := s.checkOrderPromo(ctx, order, promo)
+
+	if msg != nil {
+		return nil, nil, msg
+	}
+
+	return promo, discounts, nil
+}
+
+// getOrderCampaign returns the automatic campaign of the project with the biggest discount for the order.
+func (s *Service) getOrderCampaign(ctx context.Context, order *billingpb.Order) (*pkg.Promo, []float64) {
+	campaigns, err := s.promoRepository.FindCampaigns(ctx, order.GetProjectId())
+
+	if err != nil {
+		return nil, nil
+	}
+
+	var (
+		result    *pkg.Promo
+		discounts []float64
+		max       float64
+	)
+
+	for _, campaign := range campaigns {
+		value, msg := s.checkOrderPromo(ctx, order, campaign)
+
+		if msg != nil {
+			continue
+		}
+
+		if total := sumAmounts(value); total > max {
+			result, discounts, max = campaign, value, total
+		}
+	}
+
+	return result, discounts
+}
+
+// checkOrderPromo checks conditions of the promo for the order and returns discounts of items of the order.
+func (s *Service) checkOrderPromo(
+	ctx context.Context,
+	order *billingpb.Order,
+	promo *pkg.Promo,
+) ([]float64, *billingpb.ResponseErrorMessage) {
+	now := time.Now()
+
+	if promo.ValidFrom != nil {
+		if from, err := ptypes.Timestamp(promo.ValidFrom); err != nil || now.Before(from) {
+			return nil, promoErrorNotActive
+		}
+	}
+
+	if promo.ValidTo != nil {
+		if to, err := ptypes.Timestamp(promo.ValidTo); err != nil || now.After(to) {
+			return nil, promoErrorNotActive
+		}
+	}
+
+	if len(promo.Regions) > 0 {
+		region, err := s.getOrderPromoRegion(ctx, order)
+
+		if err != nil {
+			return nil, promoErrorUnknown
+		}
+
+		if !helper.Contains(promo.Regions, region) {
+			return nil, promoErrorRegionNotAllowed
+		}
+	}
+
+	// usage limits are checked atomically when the usage is reserved for the order
+	if promo.MaxUsesPerCustomer > 0 && (order.User == nil || order.User.Id == "") {
+		return nil, promoErrorUsageLimit
+	}
+
+	discounts := s.getPromoDiscounts(promo, order.Items, order.Currency)
+
+	if sumAmounts(discounts) <= 0 {
+		return nil, promoErrorNotApplicable
+	}
+
+	return discounts, nil
+}
+
+// getPromoDiscounts calculates discounts of the promo for items of the order, items not targeted
+// by the promo have zero discount.
+func (s *Service) getPromoDiscounts(promo *pkg.Promo, items []*billingpb.OrderItem, currency string) []float64 {
+	var (
+		discounts = make([]float64, len(items))
+		targeted  []int
+		total     float64
+	)
+
+	for i, item := range items {
+		if len(promo.Products) > 0 && !helper.Contains(promo.Products, item.Id) &&
+			!helper.Contains(promo.Products, item.Metadata[pkg.OrderItemMetadataBundleId]) {
+			continue
+		}
+
+		targeted = append(targeted, i)
+		total += item.Amount
+	}
+
+	if len(targeted) == 0 || total <= 0 {
+		return discounts
+	}
+
+	switch promo.Type {
+	case pkg.PromoTypePercent:
+		for _, i := range targeted {
+			discounts[i] = s.FormatAmount(items[i].Amount*promo.Percent/100, currency)
+		}
+	case pkg.PromoTypeFixed:
+		amount := float64(0)
+
+		for _, v := range promo.Amounts {
+			if v.Currency == currency {
+				amount = v.Amount
+				break
+			}
+		}
+
+		if amount > total {
+			amount = total
+		}
+
+		allocated := float64(0)
+
+		for k, i := range targeted {
+			if k == len(targeted)-1 {
+				discounts[i] = s.FormatAmount(amount-allocated, currency)
+				break
+			}
+
+			discounts[i] = s.FormatAmount(amount*items[i].Amount/total, currency)
+			allocated += discounts[i]
+		}
+	case pkg.PromoTypeBuyXGetY:
+		group := int(promo.BuyQuantity + promo.FreeQuantity)
+		free := len(targeted) / group * int(promo.FreeQuantity)
+
+		sort.SliceStable(targeted, func(a, b int) bool {
+			return items[targeted[a]].Amount > items[targeted[b]].Amount
+		})
+
+		for _, i := range targeted[len(targeted)-free:] {
+			discounts[i] = items[i].Amount
+		}
+	}
+
+	return discounts
+}
+
+// applyOrderPromo reduces amounts of items of the order by discounts of the promo, original amounts
+// and discounts are kept in metadata of items.
+func (s *Service) applyOrderPromo(order *billingpb.Order, promo *pkg.Promo, discounts []float64) {
+	amount := float64(0)
+
+	for i, item := range order.Items {
+		if discounts[i] > 0 {
+			// metadata of the item is copied to keep metadata of the cached product unchanged
+			metadata := make(map[string]string, len(item.Metadata)+3)
+
+			for k, v := range item.Metadata {
+				metadata[k] = v
+			}
+
+			metadata[pkg.OrderItemMetadataOriginalAmount] = strconv.FormatFloat(item.Amount, 'f', -1, 64)
+			metadata[pkg.OrderItemMetadataDiscount] = strconv.FormatFloat(discounts[i], 'f', -1, 64)
+			metadata[pkg.OrderItemMetadataPromoId] = promo.Id
+			item.Metadata = metadata
+			item.Amount = s.FormatAmount(item.Amount-discounts[i], order.Currency)
+		}
+
+		amount += item.Amount
+	}
+
+	order.OrderAmount = s.FormatAmount(amount, order.Currency)
+	order.TotalPaymentAmount = order.OrderAmount
+	order.ChargeAmount = order.TotalPaymentAmount
+	order.ChargeCurrency = order.Currency
+	order.PrivateMetadata[pkg.OrderPrivateMetadataPromoId] = promo.Id
+}
+
+// getOrderDiscount returns the total discount of items of the order.
+func getOrderDiscount(order *billingpb.Order) float64 {
+	discount := float64(0)
+
+	for _, item := range order.Items {
+		if v, err := strconv.ParseFloat(item.Metadata[pkg.OrderItemMetadataDiscount], 64); err == nil {
+			discount += v
+		}
+	}
+
+	return discount
+}
+
+// reserveOrderPromo reserves the usage of the promo with usage limits for the order. The reservation
+// is made once for the order, repeated calls prolong it. Expired reservations of other orders are released
+// if the limit is reached.
+func (s *Service) reserveOrderPromo(
+	ctx context.Context,
+	order *billingpb.Order,
+	promo *pkg.Promo,
+) *billingpb.ResponseErrorMessage {
+	if promo.MaxUses <= 0 && promo.MaxUsesPerCustomer <= 0 {
+		return nil
+	}
+
+	usage := newOrderPromoUsage(order, promo.Id)
+	usage.Status = pkg.PromoUsageStatusReserved
+	usage.ExpiresAt, _ = ptypes.TimestampProto(time.Now().Add(promoUsageReservationTtl))
+
+	isNew, err := s.promoUsageRepository.Reserve(ctx, usage)
+
+	if err != nil {
+		return promoErrorUnknown
+	}
+
+	if !isNew {
+		return nil
+	}
+
+	msg := s.incrementPromoUses(ctx, promo, usage.CustomerId)
+
+	if msg == promoErrorUsageLimit && s.releaseExpiredPromoUsages(ctx, promo.Id) > 0 {
+		msg = s.incrementPromoUses(ctx, promo, usage.CustomerId)
+	}
+
+	if msg != nil {
+		if _, err = s.promoUsageRepository.DeleteReserved(ctx, promo.Id, order.Id); err != nil {
+			zap.L().Error(
+				"delete reserved usage of promo failed",
+				zap.Error(err),
+				zap.String("order_id", order.Id),
+				zap.String("promo_id", promo.Id),
+			)
+		}
+
+		return msg
+	}
+
+	return nil
+}
+
+// incrementPromoUses increments the total counter and the counter of the customer of usages of the promo
+// within usage limits of the promo.
+func (s *Service) incrementPromoUses(
+	ctx context.Context,
+	promo *pkg.Promo,
+	customerId string,
+) *billingpb.ResponseErrorMessage {
+	ok, err := s.promoUsageRepository.IncrementUses(ctx, promo.Id, "", promo.MaxUses)
+
+	if err != nil {
+		return promoErrorUnknown
+	}
+
+	if !ok {
+		return promoErrorUsageLimit
+	}
+
+	if customerId == "" {
+		return nil
+	}
+
+	ok, err = s.promoUsageRepository.IncrementUses(ctx, promo.Id, customerId, promo.MaxUsesPerCustomer)
+
+	if err == nil && ok {
+		return nil
+	}
+
+	s.decrementPromoUses(ctx, promo.Id, "")
+
+	if err != nil {
+		return promoErrorUnknown
+	}
+
+	return promoErrorUsageLimit
+}
+
+func (s *Service) decrementPromoUses(ctx context.Context, promoId, customerId string) {
+	if err := s.promoUsageRepository.DecrementUses(ctx, promoId, customerId); err != nil {
+		zap.L().Error(
+			"decrement usages of promo failed",
+			zap.Error(err),
+			zap.String("promo_id", promoId),
+			zap.String("customer_id", customerId),
+		)
+	}
+}
+
+// releasePromoUsage decrements counters of usages of the promo for the deleted reservation.
+func (s *Service) releasePromoUsage(ctx context.Context, usage *pkg.PromoUsage) {
+	s.decrementPromoUses(ctx, usage.PromoId, "")
+
+	if usage.CustomerId != "" {
+		s.decrementPromoUses(ctx, usage.PromoId, usage.CustomerId)
+	}
+}
+
+// releaseOrderPromo releases the reserved usage of the promo for the unpaid order.
+func (s *Service) releaseOrderPromo(ctx context.Context, order *billingpb.Order, promoId string) {
+	if promoId == "" {
+		return
+	}
+
+	usage, err := s.promoUsageRepository.DeleteReserved(ctx, promoId, order.Id)
+
+	if err != nil {
+		// the promo without usage limits or the paid order hasn't the reserved usage
+		if err != mongo.ErrNoDocuments {
+			zap.L().Error(
+				"release reserved usage of promo failed",
+				zap.Error(err),
+				zap.String("order_id", order.Id),
+				zap.String("promo_id", promoId),
+			)
+		}
+		return
+	}
+
+	s.releasePromoUsage(ctx, usage)
+}
+
+// releaseExpiredPromoUsages releases expired reservations of the promo of abandoned orders
+// and returns count of released reservations.
+func (s *Service) releaseExpiredPromoUsages(ctx context.Context, promoId string) int {
+	count := 0
+
+	for {
+		usage, err := s.promoUsageRepository.DeleteExpiredReserved(ctx, promoId, time.Now())
+
+		if err != nil {
+			return count
+		}
+
+		s.releasePromoUsage(ctx, usage)
+		count++
+	}
+}
+
+// createOrderPromoUsage saves the usage of the promo applied to the paid order. The reserved usage
+// is confirmed, if the reservation was released before the payment the usage is counted anyway
+// because the discount is already given.
+func (s *Service) createOrderPromoUsage(ctx context.Context, order *billingpb.Order) {
+	promoId := order.PrivateMetadata[pkg.OrderPrivateMetadataPromoId]
+
+	if promoId == "" {
+		return
+	}
+
+	usage := newOrderPromoUsage(order, promoId)
+	usage.Status = pkg.PromoUsageStatusUsed
+	usage.Discount = getOrderDiscount(order)
+	usage.Currency = order.Currency
+	usage.CreatedAt = ptypes.TimestampNow()
+
+	isConfirmed, err := s.promoUsageRepository.Confirm(ctx, usage)
+
+	if err == nil && !isConfirmed {
+		if err = s.promoUsageRepository.Insert(ctx, usage); err == nil {
+			_, err = s.promoUsageRepository.IncrementUses(ctx, promoId, "", 0)
+
+			if err == nil && usage.CustomerId != "" {
+				_, err = s.promoUsageRepository.IncrementUses(ctx, promoId, usage.CustomerId, 0)
+			}
+		}
+	}
+
+	if err != nil {
+		zap.L().Error(
+			"save usage of promo failed",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+			zap.String("promo_id", promoId),
+		)
+	}
+}
+
+func newOrderPromoUsage(order *billingpb.Order, promoId string) *pkg.PromoUsage {
+	usage := &pkg.PromoUsage{
+		PromoId:    promoId,
+		MerchantId: order.GetMerchantId(),
+		OrderId:    order.Id,
+	}
+
+	if order.User != nil {
+		usage.CustomerId = order.User.Id
+	}
+
+	return usage
+}
+
+func sumAmounts(amounts []float64) float64 {
+	sum := float64(0)
+
+	for _, v := range amounts {
+		sum += v
+	}
+
+	return sum
+}
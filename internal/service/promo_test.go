@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type PromoTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant *billingpb.Merchant
+	project  *billingpb.Project
+}
+
+func Test_Promo(t *testing.T) {
+	suite.Run(t, new(PromoTestSuite))
+}
+
+func (suite *PromoTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, _, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *PromoTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *PromoTestSuite) getPromoRequest() *pkg.CreateOrUpdatePromoRequest {
+	return &pkg.CreateOrUpdatePromoRequest{
+		MerchantId: suite.merchant.Id,
+		ProjectId:  suite.project.Id,
+		Code:       " summer20 ",
+		Name:       "Summer sale",
+		Type:       pkg.PromoTypePercent,
+		Percent:    20,
+		Enabled:    true,
+	}
+}
+
+func (suite *PromoTestSuite) helperCreatePromo(req *pkg.CreateOrUpdatePromoRequest) *pkg.Promo {
+	res := &pkg.PromoResponse{}
+	err := suite.service.CreateOrUpdatePromo(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	return res.Item
+}
+
+func (suite *PromoTestSuite) getOrder(code string, amounts ...float64) *billingpb.Order {
+	order := &billingpb.Order{
+		Id:                   primitive.NewObjectID().Hex(),
+		Project:              &billingpb.ProjectOrder{Id: suite.project.Id, MerchantId: suite.merchant.Id},
+		Currency:             "USD",
+		IsCurrencyPredefined: true,
+		User:                 &billingpb.OrderUser{Id: primitive.NewObjectID().Hex()},
+		PrivateMetadata:      map[string]string{},
+	}
+
+	if code != "" {
+		order.PrivateMetadata[pkg.OrderPrivateMetadataPromoCode] = code
+	}
+
+	for _, amount := range amounts {
+		order.Items = append(order.Items, &billingpb.OrderItem{
+			Id:       primitive.NewObjectID().Hex(),
+			Amount:   amount,
+			Currency: "USD",
+		})
+		order.OrderAmount += amount
+	}
+
+	return order
+}
+
+func (suite *PromoTestSuite) TestPromo_CreateOrUpdatePromo_Ok() {
+	promo := suite.helperCreatePromo(suite.getPromoRequest())
+	assert.NotEmpty(suite.T(), promo.Id)
+	assert.Equal(suite.T(), pkg.PromoObject, promo.Object)
+	assert.Equal(suite.T(), "SUMMER20", promo.Code)
+
+	req := suite.getPromoRequest()
+	req.Id = promo.Id
+	req.Percent = 30
+	res := &pkg.PromoResponse{}
+	err := suite.service.CreateOrUpdatePromo(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), promo.Id, res.Item.Id)
+	assert.EqualValues(suite.T(), 30, res.Item.Percent)
+}
+
+func (suite *PromoTestSuite) TestPromo_CreateOrUpdatePromo_Error_Duplicate() {
+	suite.helperCreatePromo(suite.getPromoRequest())
+
+	res := &pkg.PromoResponse{}
+	err := suite.service.CreateOrUpdatePromo(context.TODO(), suite.getPromoRequest(), res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), promoErrorDuplicate, res.Message)
+}
+
+func (suite *PromoTestSuite) TestPromo_CreateOrUpdatePromo_Error_Validation() {
+	req := suite.getPromoRequest()
+	req.Percent = 120
+	res := &pkg.PromoResponse{}
+	err := suite.service.CreateOrUpdatePromo(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), promoErrorPercentInvalid, res.Message)
+
+	req = suite.getPromoRequest()
+	req.Type = "unknown"
+	res = &pkg.PromoResponse{}
+	err = suite.service.CreateOrUpdatePromo(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), promoErrorTypeInvalid, res.Message)
+
+	req = suite.getPromoRequest()
+	req.Type = pkg.PromoTypeFixed
+	res = &pkg.PromoResponse{}
+	err = suite.service.CreateOrUpdatePromo(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), promoErrorAmountsInvalid, res.Message)
+
+	req = suite.getPromoRequest()
+	req.ValidFrom = ptypes.TimestampNow()
+	req.ValidTo, _ = ptypes.TimestampProto(time.Now().Add(-time.Hour))
+	res = &pkg.PromoResponse{}
+	err = suite.service.CreateOrUpdatePromo(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), promoErrorPeriodInvalid, res.Message)
+}
+
+func (suite *PromoTestSuite) TestPromo_DeletePromo_Ok() {
+	promo := suite.helperCreatePromo(suite.getPromoRequest())
+
+	req := &pkg.GetPromoRequest{Id: promo.Id, MerchantId: suite.merchant.Id}
+	res := &billingpb.EmptyResponseWithStatus{}
+	err := suite.service.DeletePromo(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	res1 := &pkg.PromoResponse{}
+	err = suite.service.GetPromo(context.TODO(), req, res1)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res1.Status)
+	assert.Equal(suite.T(), promoErrorNotFound, res1.Message)
+}
+
+func (suite *PromoTestSuite) TestPromo_GetPromoDiscounts_Percent() {
+	order := suite.getOrder("", 10, 25)
+	promo := &pkg.Promo{Type: pkg.PromoTypePercent, Percent: 15, Products: []string{order.Items[1].Id}}
+
+	discounts := suite.service.getPromoDiscounts(promo, order.Items, order.Currency)
+	assert.Equal(suite.T(), []float64{0, 3.75}, discounts)
+}
+
+func (suite *PromoTestSuite) TestPromo_GetPromoDiscounts_Fixed() {
+	order := suite.getOrder("", 10, 20)
+	promo := &pkg.Promo{
+		Type:    pkg.PromoTypeFixed,
+		Amounts: []*pkg.PromoAmount{{Currency: "EUR", Amount: 1}, {Currency: "USD", Amount: 10}},
+	}
+
+	discounts := suite.service.getPromoDiscounts(promo, order.Items, order.Currency)
+	assert.Equal(suite.T(), []float64{3.33, 6.67}, discounts)
+
+	order.Currency = "RUB"
+	discounts = suite.service.getPromoDiscounts(promo, order.Items, order.Currency)
+	assert.Zero(suite.T(), sumAmounts(discounts))
+}
+
+func (suite *PromoTestSuite) TestPromo_GetPromoDiscounts_BuyXGetY() {
+	order := suite.getOrder("", 10, 5, 20, 15, 30)
+	promo := &pkg.Promo{Type: pkg.PromoTypeBuyXGetY, BuyQuantity: 1, FreeQuantity: 1}
+
+	discounts := suite.service.getPromoDiscounts(promo, order.Items, order.Currency)
+	assert.Equal(suite.T(), []float64{10, 5, 0, 0, 0}, discounts)
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Code_Ok() {
+	promo := suite.helperCreatePromo(suite.getPromoRequest())
+	order := suite.getOrder("summer20", 10, 40)
+
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 40, order.OrderAmount)
+	assert.EqualValues(suite.T(), 40, order.TotalPaymentAmount)
+	assert.EqualValues(suite.T(), 8, order.Items[0].Amount)
+	assert.EqualValues(suite.T(), 32, order.Items[1].Amount)
+	assert.Equal(suite.T(), "10", order.Items[0].Metadata[pkg.OrderItemMetadataOriginalAmount])
+	assert.Equal(suite.T(), "2", order.Items[0].Metadata[pkg.OrderItemMetadataDiscount])
+	assert.Equal(suite.T(), promo.Id, order.Items[0].Metadata[pkg.OrderItemMetadataPromoId])
+	assert.Equal(suite.T(), promo.Id, order.PrivateMetadata[pkg.OrderPrivateMetadataPromoId])
+	assert.EqualValues(suite.T(), 10, getOrderDiscount(order))
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Code_Error_NotFound() {
+	order := suite.getOrder("unknown", 10)
+
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.Equal(suite.T(), promoErrorCodeNotFound, err)
+	assert.EqualValues(suite.T(), 10, order.Items[0].Amount)
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Code_Error_NotActive() {
+	req := suite.getPromoRequest()
+	req.ValidFrom, _ = ptypes.TimestampProto(time.Now().Add(time.Hour))
+	suite.helperCreatePromo(req)
+	order := suite.getOrder("SUMMER20", 10)
+
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.Equal(suite.T(), promoErrorNotActive, err)
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Code_Error_Region() {
+	req := suite.getPromoRequest()
+	req.Regions = []string{"EUR"}
+	suite.helperCreatePromo(req)
+	order := suite.getOrder("SUMMER20", 10)
+
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.Equal(suite.T(), promoErrorRegionNotAllowed, err)
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Code_Error_UsageLimit() {
+	req := suite.getPromoRequest()
+	req.MaxUsesPerCustomer = 1
+	promo := suite.helperCreatePromo(req)
+
+	order := suite.getOrder("SUMMER20", 10)
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+	suite.service.createOrderPromoUsage(context.TODO(), order)
+
+	count, err := suite.service.promoUsageRepository.CountByPromoId(context.TODO(), promo.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, count)
+
+	order2 := suite.getOrder("SUMMER20", 10)
+	order2.User = order.User
+	err = suite.service.processOrderPromo(context.TODO(), order2)
+	assert.Equal(suite.T(), promoErrorUsageLimit, err)
+
+	order3 := suite.getOrder("SUMMER20", 10)
+	err = suite.service.processOrderPromo(context.TODO(), order3)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Code_Error_UsageLimitReserved() {
+	req := suite.getPromoRequest()
+	req.MaxUses = 1
+	promo := suite.helperCreatePromo(req)
+
+	order := suite.getOrder("SUMMER20", 10)
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+
+	// the repeated processing of the order keeps its reservation
+	err = suite.service.processOrderPromo(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), promo.Id, order.PrivateMetadata[pkg.OrderPrivateMetadataPromoId])
+
+	order2 := suite.getOrder("SUMMER20", 10)
+	err = suite.service.processOrderPromo(context.TODO(), order2)
+	assert.Equal(suite.T(), promoErrorUsageLimit, err)
+
+	suite.service.releaseOrderPromo(context.TODO(), order, promo.Id)
+
+	err = suite.service.processOrderPromo(context.TODO(), order2)
+	assert.NoError(suite.T(), err)
+	suite.service.createOrderPromoUsage(context.TODO(), order2)
+
+	count, err := suite.service.promoUsageRepository.CountByPromoId(context.TODO(), promo.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, count)
+
+	err = suite.service.processOrderPromo(context.TODO(), order)
+	assert.Equal(suite.T(), promoErrorUsageLimit, err)
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Code_Ok_ExpiredReservationReleased() {
+	req := suite.getPromoRequest()
+	req.MaxUses = 1
+	promo := suite.helperCreatePromo(req)
+
+	order := suite.getOrder("SUMMER20", 10)
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.service.db.Collection("promo_usage").UpdateMany(
+		context.TODO(),
+		bson.M{},
+		bson.M{"$set": bson.M{"expires_at": time.Now().Add(-time.Minute)}},
+	)
+	assert.NoError(suite.T(), err)
+
+	order2 := suite.getOrder("SUMMER20", 10)
+	err = suite.service.processOrderPromo(context.TODO(), order2)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), promo.Id, order2.PrivateMetadata[pkg.OrderPrivateMetadataPromoId])
+
+	// the order paid after the release of its reservation is counted anyway
+	suite.service.createOrderPromoUsage(context.TODO(), order)
+
+	count, err := suite.service.promoUsageRepository.CountByPromoId(context.TODO(), promo.Id)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, count)
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Code_Ok_ChangedCodeReleasesReservation() {
+	req := suite.getPromoRequest()
+	req.MaxUses = 1
+	suite.helperCreatePromo(req)
+
+	order := suite.getOrder("SUMMER20", 10)
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+
+	delete(order.PrivateMetadata, pkg.OrderPrivateMetadataPromoCode)
+	err = suite.service.processOrderPromo(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), order.PrivateMetadata[pkg.OrderPrivateMetadataPromoId])
+
+	order2 := suite.getOrder("SUMMER20", 10)
+	err = suite.service.processOrderPromo(context.TODO(), order2)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PromoTestSuite) TestPromo_ProcessOrderPromo_Campaign_Ok() {
+	req := suite.getPromoRequest()
+	req.Code = ""
+	req.Percent = 10
+	suite.helperCreatePromo(req)
+
+	req = suite.getPromoRequest()
+	req.Code = ""
+	req.Type = pkg.PromoTypeFixed
+	req.Amounts = []*pkg.PromoAmount{{Currency: "USD", Amount: 3}}
+	campaign := suite.helperCreatePromo(req)
+
+	order := suite.getOrder("", 20)
+	err := suite.service.processOrderPromo(context.TODO(), order)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 17, order.OrderAmount)
+	assert.Equal(suite.T(), campaign.Id, order.PrivateMetadata[pkg.OrderPrivateMetadataPromoId])
+}
+
+func (suite *PromoTestSuite) TestPromo_GetDiscountSummary_Ok() {
+	promo := suite.helperCreatePromo(suite.getPromoRequest())
+
+	for i := 0; i < 2; i++ {
+		order := suite.getOrder("SUMMER20", 10)
+		err := suite.service.processOrderPromo(context.TODO(), order)
+		assert.NoError(suite.T(), err)
+		suite.service.createOrderPromoUsage(context.TODO(), order)
+	}
+
+	items, err := suite.service.promoUsageRepository.GetDiscountSummary(
+		context.TODO(),
+		suite.merchant.Id,
+		time.Now().Add(-time.Hour),
+		time.Now().Add(time.Hour),
+	)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), items, 1)
+	assert.Equal(suite.T(), promo.Id, items[0].PromoId)
+	assert.Equal(suite.T(), "SUMMER20", items[0].Code)
+	assert.EqualValues(suite.T(), 2, items[0].OrdersCount)
+	assert.EqualValues(suite.T(), 4, items[0].Discount)
+}
@@ -29,6 +29,7 @@ var (
 	refundErrorNotFound           = newBillingServerErrorMsg("rf000005", "refund with specified data not found")
 	refundErrorOrderNotFound      = newBillingServerErrorMsg("rf000006", "information about payment for refund with specified data not found")
 	refundErrorCostsRatesNotFound = newBillingServerErrorMsg("rf000007", "settings to calculate commissions for refund not found")
+	refundErrorPreOrderReleased   = newBillingServerErrorMsg("rf000008", "refund of pre-order is not allowed after release")
 )
 
 type createRefundChecked struct {
@@ -313,6 +314,10 @@ func (s *Service) ProcessRefundCallback(
 			if err != nil {
 				zap.S().Errorf("Update order data failed", "err", err.Error(), "order", order)
 			}
+
+			if err = s.refundKeyPreOrders(ctx, order); err != nil {
+				zap.L().Error("Refund of key pre-orders failed", zap.Error(err), zap.String("order_id", order.Id))
+			}
 		}
 
 		err = s.onRefundNotify(ctx, refund, order)
@@ -512,6 +517,19 @@ func (p *createRefundProcessor) processOrder() error {
 		return newBillingServerResponseError(billingpb.ResponseStatusBadData, refundErrorNotAllowed)
 	}
 
+	// chargeback is initiated by the bank and can't be rejected
+	if !p.request.IsChargeback {
+		allowed, err := p.service.isKeyPreOrderRefundAllowed(p.ctx, order)
+
+		if err != nil {
+			return newBillingServerResponseError(billingpb.ResponseStatusSystemError, refundErrorUnknown)
+		}
+
+		if !allowed {
+			return newBillingServerResponseError(billingpb.ResponseStatusBadData, refundErrorPreOrderReleased)
+		}
+	}
+
 	p.checked.order = order
 
 	return nil
@@ -217,7 +217,7 @@ func (s *Service) getOrdersList(
 
 		if req.Account != "" {
 			r := primitive.Regex{Pattern: ".*" + req.Account + ".*", Options: "i"}
-			query["$or"] = []bson.M{
+			account := []bson.M{
 				{"user.external_id": r},
 				{"user.phone": r},
 				{"user.email": r},
@@ -225,6 +225,17 @@ func (s *Service) getOrdersList(
 				{"payment_method.crypto_currency.address": bson.M{"$regex": r, "$exists": true}},
 				{"payment_method.wallet.account": bson.M{"$regex": r, "$exists": true}},
 			}
+
+			// encrypted emails and phones are found by exact match only
+			if index := models.GetFieldIndex(pkg.PersonalDataFieldEmail, req.Account); index != "" {
+				account = append(account, bson.M{"user_email_index": index})
+			}
+
+			if index := models.GetFieldIndex(pkg.PersonalDataFieldPhone, req.Account); index != "" {
+				account = append(account, bson.M{"user_phone_index": index})
+			}
+
+			query["$or"] = account
 		}
 
 		pmDates := make(bson.M)
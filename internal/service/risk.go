@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// riskDefaultVelocityPeriod is a period in seconds of velocity signals of rules without period.
+	riskDefaultVelocityPeriod = 86400
+	// riskDefaultAmountPeriod is a period in seconds of the average amount of the project for rules without period.
+	riskDefaultAmountPeriod = 2592000
+)
+
+var (
+	errorRiskRuleNotFound        = newBillingServerErrorMsg("rk000001", "risk rule not found")
+	errorRiskRuleSignalInvalid   = newBillingServerErrorMsg("rk000002", "risk rule signal is invalid")
+	errorRiskRuleOperatorInvalid = newBillingServerErrorMsg("rk000003", "risk rule operator is invalid")
+	errorRiskRuleDecisionInvalid = newBillingServerErrorMsg("rk000004", "risk rule decision is invalid")
+	errorRiskRulePeriodInvalid   = newBillingServerErrorMsg("rk000005", "risk rule period must not be negative")
+	errorRiskPaymentDeclined     = newBillingServerErrorMsg("rk000006", "payment declined by risk assessment")
+	errorRiskAssessmentNotFound  = newBillingServerErrorMsg("rk000007", "risk assessments of order not found")
+	errorRiskUnknown             = newBillingServerErrorMsg("rk000008", "unknown error with risk rule")
+
+	// riskVelocitySignalKeys maps velocity signals to the fields of assessments which are counted.
+	riskVelocitySignalKeys = map[string]string{
+		pkg.RiskSignalCardVelocity:     "card_fingerprint",
+		pkg.RiskSignalCustomerVelocity: "customer_id",
+		pkg.RiskSignalIpVelocity:       "ip",
+		pkg.RiskSignalEmailVelocity:    "email",
+	}
+
+	riskSignals = map[string]bool{
+		pkg.RiskSignalBinIpCountryMismatch: true,
+		pkg.RiskSignalCardVelocity:         true,
+		pkg.RiskSignalCustomerVelocity:     true,
+		pkg.RiskSignalIpVelocity:           true,
+		pkg.RiskSignalEmailVelocity:        true,
+		pkg.RiskSignalAmountRatio:          true,
+		pkg.RiskSignalChargebacks:          true,
+		pkg.RiskSignalSessionCount:         true,
+	}
+
+	riskOperators = map[string]bool{
+		pkg.RiskOperatorGt:  true,
+		pkg.RiskOperatorGte: true,
+		pkg.RiskOperatorLt:  true,
+		pkg.RiskOperatorLte: true,
+		pkg.RiskOperatorEq:  true,
+	}
+
+	// riskDecisionSeverity is used to choose the strongest decision of the score and matched rules.
+	riskDecisionSeverity = map[string]int{
+		pkg.RiskDecisionAllow:   0,
+		pkg.RiskDecisionThreeDs: 1,
+		pkg.RiskDecisionReview:  2,
+		pkg.RiskDecisionDecline: 3,
+	}
+)
+
+// CreateOrUpdateRiskRule creates or modifies the rule of the payment risk engine
+func (s *Service) CreateOrUpdateRiskRule(
+	ctx context.Context,
+	req *pkg.CreateOrUpdateRiskRuleRequest,
+	res *pkg.RiskRuleResponse,
+) error {
+	rule := &pkg.RiskRule{
+		Id:        primitive.NewObjectID().Hex(),
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	if req.Id != "" {
+		var err error
+		rule, err = s.riskRuleRepository.GetById(ctx, req.Id)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = errorRiskRuleNotFound
+			return nil
+		}
+	}
+
+	if _, ok := riskSignals[req.Signal]; !ok {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorRiskRuleSignalInvalid
+		return nil
+	}
+
+	if _, ok := riskOperators[req.Operator]; !ok {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorRiskRuleOperatorInvalid
+		return nil
+	}
+
+	if _, ok := riskDecisionSeverity[req.Decision]; req.Decision != "" && !ok {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorRiskRuleDecisionInvalid
+		return nil
+	}
+
+	if req.Period < 0 {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorRiskRulePeriodInvalid
+		return nil
+	}
+
+	if req.MerchantId != "" {
+		if _, err := s.merchantRepository.GetById(ctx, req.MerchantId); err != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = merchantErrorNotFound
+			return nil
+		}
+	}
+
+	rule.Name = req.Name
+	rule.MerchantId = req.MerchantId
+	rule.Signal = req.Signal
+	rule.Operator = req.Operator
+	rule.Value = req.Value
+	rule.Period = req.Period
+	rule.Score = req.Score
+	rule.Decision = req.Decision
+	rule.Enabled = req.Enabled
+	rule.UpdatedAt = ptypes.TimestampNow()
+
+	if err := s.riskRuleRepository.Upsert(ctx, rule); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorRiskUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = rule
+
+	return nil
+}
+
+// ListRiskRules returns rules of the payment risk engine, filtered by the merchant if it's passed
+func (s *Service) ListRiskRules(
+	ctx context.Context,
+	req *pkg.ListRiskRulesRequest,
+	res *pkg.ListRiskRulesResponse,
+) error {
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	count, err := s.riskRuleRepository.FindCount(ctx, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorRiskUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count <= 0 {
+		return nil
+	}
+
+	res.Items, err = s.riskRuleRepository.Find(ctx, req.MerchantId, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorRiskUnknown
+		res.Count = 0
+		return nil
+	}
+
+	return nil
+}
+
+// DeleteRiskRule marks the rule of the payment risk engine as deleted
+func (s *Service) DeleteRiskRule(
+	ctx context.Context,
+	req *pkg.RiskRuleRequest,
+	res *billingpb.EmptyResponseWithStatus,
+) error {
+	rule, err := s.riskRuleRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorRiskRuleNotFound
+		return nil
+	}
+
+	rule.Deleted = true
+	rule.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.riskRuleRepository.Upsert(ctx, rule); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorRiskUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// GetOrderRiskAssessments returns risk assessments of all payment attempts of the order
+func (s *Service) GetOrderRiskAssessments(
+	ctx context.Context,
+	req *pkg.GetOrderRiskAssessmentsRequest,
+	res *pkg.GetOrderRiskAssessmentsResponse,
+) error {
+	items, err := s.riskAssessmentRepository.FindByOrderId(ctx, req.OrderId)
+
+	if err != nil || len(items) <= 0 {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorRiskAssessmentNotFound
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Items = items
+
+	return nil
+}
+
+// assessOrderRisk evaluates enabled risk rules for the payment attempt of the order, stores the assessment
+// and puts its result to the private metadata of the order. Data is the payment form data of the attempt.
+func (s *Service) assessOrderRisk(
+	ctx context.Context,
+	order *billingpb.Order,
+	data map[string]string,
+) (*pkg.RiskAssessment, error) {
+	assessment := &pkg.RiskAssessment{
+		OrderId:         order.Id,
+		MerchantId:      order.GetMerchantId(),
+		ProjectId:       order.GetProjectId(),
+		Amount:          order.ChargeAmount,
+		Currency:        order.ChargeCurrency,
+		CardFingerprint: s.getRiskCardFingerprint(order, data),
+		CreatedAt:       ptypes.TimestampNow(),
+	}
+
+	if order.User != nil {
+		assessment.CustomerId = order.User.Id
+		assessment.Email = strings.ToLower(order.User.Email)
+		assessment.Ip = order.User.Ip
+	}
+
+	rules, err := s.riskRuleRepository.FindEnabled(ctx, assessment.MerchantId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64)
+	decision := pkg.RiskDecisionAllow
+
+	for _, rule := range rules {
+		period := rule.Period
+
+		if period <= 0 {
+			period = s.getRiskSignalDefaultPeriod(rule.Signal)
+		}
+
+		key := rule.Signal + ":" + strconv.FormatInt(period, 10)
+		value, ok := values[key]
+
+		if !ok {
+			value, err = s.getRiskSignalValue(ctx, order, assessment, rule.Signal, period)
+
+			if err != nil {
+				zap.L().Error(
+					"Failed to calculate risk signal",
+					zap.Error(err),
+					zap.String("order_id", order.Id),
+					zap.String("signal", rule.Signal),
+				)
+				continue
+			}
+
+			values[key] = value
+			assessment.Signals = append(assessment.Signals, &pkg.RiskAssessmentSignal{
+				Signal: rule.Signal,
+				Period: period,
+				Value:  value,
+			})
+		}
+
+		if !compareRiskSignalValue(value, rule.Operator, rule.Value) {
+			continue
+		}
+
+		assessment.Score += rule.Score
+		assessment.Rules = append(assessment.Rules, &pkg.RiskAssessmentRule{
+			RuleId:   rule.Id,
+			Name:     rule.Name,
+			Score:    rule.Score,
+			Decision: rule.Decision,
+		})
+
+		if riskDecisionSeverity[rule.Decision] > riskDecisionSeverity[decision] {
+			decision = rule.Decision
+		}
+	}
+
+	if scoreDecision := s.getRiskScoreDecision(assessment.Score); riskDecisionSeverity[scoreDecision] > riskDecisionSeverity[decision] {
+		decision = scoreDecision
+	}
+
+	assessment.Decision = decision
+
+	if err = s.riskAssessmentRepository.Insert(ctx, assessment); err != nil {
+		return nil, err
+	}
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataRiskAssessmentId] = assessment.Id
+	order.PrivateMetadata[pkg.OrderPrivateMetadataRiskScore] = strconv.Itoa(int(assessment.Score))
+	order.PrivateMetadata[pkg.OrderPrivateMetadataRiskDecision] = assessment.Decision
+
+	return assessment, nil
+}
+
+func (s *Service) getRiskSignalValue(
+	ctx context.Context,
+	order *billingpb.Order,
+	assessment *pkg.RiskAssessment,
+	signal string,
+	period int64,
+) (float64, error) {
+	from := time.Now().Add(-time.Duration(period) * time.Second)
+
+	switch signal {
+	case pkg.RiskSignalBinIpCountryMismatch:
+		binCountry := order.PaymentRequisites[billingpb.PaymentCreateBankCardFieldIssuerCountryIsoCode]
+		ipCountry := order.PaymentIpCountry
+
+		if ipCountry == "" && order.User != nil && order.User.Address != nil {
+			ipCountry = order.User.Address.Country
+		}
+
+		if binCountry != "" && ipCountry != "" && !strings.EqualFold(binCountry, ipCountry) {
+			return 1, nil
+		}
+
+		return 0, nil
+	case pkg.RiskSignalCardVelocity, pkg.RiskSignalCustomerVelocity, pkg.RiskSignalIpVelocity, pkg.RiskSignalEmailVelocity:
+		var value string
+
+		switch signal {
+		case pkg.RiskSignalCardVelocity:
+			value = assessment.CardFingerprint
+		case pkg.RiskSignalCustomerVelocity:
+			value = assessment.CustomerId
+		case pkg.RiskSignalIpVelocity:
+			value = assessment.Ip
+		case pkg.RiskSignalEmailVelocity:
+			value = assessment.Email
+		}
+
+		if value == "" {
+			return 0, nil
+		}
+
+		count, err := s.riskAssessmentRepository.CountByKey(ctx, riskVelocitySignalKeys[signal], value, from)
+
+		return float64(count), err
+	case pkg.RiskSignalAmountRatio:
+		avg, err := s.riskAssessmentRepository.GetAverageAmount(ctx, assessment.ProjectId, assessment.Currency, from)
+
+		if err != nil || avg <= 0 {
+			return 0, err
+		}
+
+		return assessment.Amount / avg, nil
+	case pkg.RiskSignalChargebacks:
+		count, err := s.riskAssessmentRepository.CountChargebacks(
+			ctx,
+			assessment.CustomerId,
+			assessment.Email,
+			assessment.CardFingerprint,
+		)
+
+		return float64(count), err
+	case pkg.RiskSignalSessionCount:
+		count, _ := strconv.ParseFloat(order.PrivateMetadata[pkg.OrderPrivateMetadataSessionCount], 64)
+
+		return count, nil
+	}
+
+	return 0, nil
+}
+
+func (s *Service) getRiskSignalDefaultPeriod(signal string) int64 {
+	if signal == pkg.RiskSignalAmountRatio {
+		return riskDefaultAmountPeriod
+	}
+
+	if _, ok := riskVelocitySignalKeys[signal]; ok {
+		return riskDefaultVelocityPeriod
+	}
+
+	return 0
+}
+
+func (s *Service) getRiskScoreDecision(score int32) string {
+	if s.cfg.RiskScoreDecline > 0 && score >= s.cfg.RiskScoreDecline {
+		return pkg.RiskDecisionDecline
+	}
+
+	if s.cfg.RiskScoreReview > 0 && score >= s.cfg.RiskScoreReview {
+		return pkg.RiskDecisionReview
+	}
+
+	if s.cfg.RiskScoreThreeDs > 0 && score >= s.cfg.RiskScoreThreeDs {
+		return pkg.RiskDecisionThreeDs
+	}
+
+	return pkg.RiskDecisionAllow
+}
+
+// getRiskCardFingerprint returns a hash of the bank card of the payment. The full card number is used
+// when it's passed in the payment form, the saved card keeps the fingerprint calculated when the card was saved.
+// Fingerprint is empty if the card can't be identified, masked numbers are the same for different cards.
+func (s *Service) getRiskCardFingerprint(order *billingpb.Order, data map[string]string) string {
+	if order.PaymentMethod == nil || !order.PaymentMethod.IsBankCard() {
+		return ""
+	}
+
+	pan := data[billingpb.PaymentCreateFieldPan]
+
+	if pan != "" && !strings.Contains(pan, "*") {
+		return s.getCardFingerprint(pan)
+	}
+
+	return order.PrivateMetadata[pkg.OrderPrivateMetadataCardFingerprint]
+}
+
+// getCardFingerprint returns a keyed hash of the card number, it's used to find the same card in risk signals
+// and in saved cards of customers.
+func (s *Service) getCardFingerprint(pan string) string {
+	if pan == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.cfg.CardFingerprintSecret))
+	mac.Write([]byte(pan))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// markRiskChargeback marks risk assessments of the order when the chargeback is received, it is used
+// by the chargebacks signal of next payments.
+func (s *Service) markRiskChargeback(ctx context.Context, order *billingpb.Order) {
+	if err := s.riskAssessmentRepository.MarkChargeback(ctx, order.Id); err != nil {
+		zap.L().Error(
+			"Failed to mark risk assessments of order with chargeback",
+			zap.Error(err),
+			zap.String("order_id", order.Id),
+		)
+	}
+}
+
+func compareRiskSignalValue(value float64, operator string, target float64) bool {
+	switch operator {
+	case pkg.RiskOperatorGt:
+		return value > target
+	case pkg.RiskOperatorGte:
+		return value >= target
+	case pkg.RiskOperatorLt:
+		return value < target
+	case pkg.RiskOperatorLte:
+		return value <= target
+	case pkg.RiskOperatorEq:
+		return value == target
+	}
+
+	return false
+}
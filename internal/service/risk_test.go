@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/recurringpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+type RiskTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant *billingpb.Merchant
+	project  *billingpb.Project
+}
+
+func Test_Risk(t *testing.T) {
+	suite.Run(t, new(RiskTestSuite))
+}
+
+func (suite *RiskTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, _, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *RiskTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *RiskTestSuite) helperCreateRiskRule(req *pkg.CreateOrUpdateRiskRuleRequest) *pkg.RiskRule {
+	res := &pkg.RiskRuleResponse{}
+	err := suite.service.CreateOrUpdateRiskRule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	return res.Item
+}
+
+func (suite *RiskTestSuite) getOrder() *billingpb.Order {
+	return &billingpb.Order{
+		Id:             primitive.NewObjectID().Hex(),
+		Project:        &billingpb.ProjectOrder{Id: suite.project.Id, MerchantId: suite.merchant.Id},
+		ChargeAmount:   100,
+		ChargeCurrency: "USD",
+		User: &billingpb.OrderUser{
+			Id:    primitive.NewObjectID().Hex(),
+			Email: "test@unit.test",
+			Ip:    "127.0.0.1",
+		},
+		PaymentMethod: &billingpb.PaymentMethodOrder{
+			Group:      recurringpb.PaymentSystemGroupAliasBankCard,
+			ExternalId: recurringpb.PaymentSystemGroupAliasBankCard,
+		},
+		PaymentRequisites: map[string]string{
+			billingpb.PaymentCreateFieldPan:                          "400000******0002",
+			billingpb.PaymentCreateFieldMonth:                        "12",
+			billingpb.PaymentCreateFieldYear:                         "2030",
+			billingpb.PaymentCreateBankCardFieldIssuerCountryIsoCode: "RU",
+		},
+		PaymentIpCountry: "RU",
+		PrivateMetadata:  map[string]string{},
+	}
+}
+
+func (suite *RiskTestSuite) TestRisk_CreateOrUpdateRiskRule_Ok() {
+	rule := suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		Name:     "card velocity",
+		Signal:   pkg.RiskSignalCardVelocity,
+		Operator: pkg.RiskOperatorGte,
+		Value:    3,
+		Period:   3600,
+		Score:    40,
+		Enabled:  true,
+	})
+	assert.NotEmpty(suite.T(), rule.Id)
+	assert.Empty(suite.T(), rule.MerchantId)
+
+	res := &pkg.RiskRuleResponse{}
+	err := suite.service.CreateOrUpdateRiskRule(context.TODO(), &pkg.CreateOrUpdateRiskRuleRequest{
+		Id:         rule.Id,
+		Name:       "card velocity",
+		MerchantId: suite.merchant.Id,
+		Signal:     pkg.RiskSignalCardVelocity,
+		Operator:   pkg.RiskOperatorGte,
+		Value:      5,
+		Score:      60,
+		Decision:   pkg.RiskDecisionReview,
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), rule.Id, res.Item.Id)
+	assert.Equal(suite.T(), suite.merchant.Id, res.Item.MerchantId)
+	assert.EqualValues(suite.T(), 5, res.Item.Value)
+	assert.Equal(suite.T(), pkg.RiskDecisionReview, res.Item.Decision)
+	assert.False(suite.T(), res.Item.Enabled)
+}
+
+func (suite *RiskTestSuite) TestRisk_CreateOrUpdateRiskRule_Error() {
+	req := &pkg.CreateOrUpdateRiskRuleRequest{
+		Signal:   "unknown",
+		Operator: pkg.RiskOperatorGt,
+	}
+	res := &pkg.RiskRuleResponse{}
+	err := suite.service.CreateOrUpdateRiskRule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorRiskRuleSignalInvalid, res.Message)
+
+	req.Signal = pkg.RiskSignalChargebacks
+	req.Operator = "unknown"
+	res = &pkg.RiskRuleResponse{}
+	err = suite.service.CreateOrUpdateRiskRule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), errorRiskRuleOperatorInvalid, res.Message)
+
+	req.Operator = pkg.RiskOperatorGt
+	req.Decision = "unknown"
+	res = &pkg.RiskRuleResponse{}
+	err = suite.service.CreateOrUpdateRiskRule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), errorRiskRuleDecisionInvalid, res.Message)
+
+	req.Decision = ""
+	req.Period = -1
+	res = &pkg.RiskRuleResponse{}
+	err = suite.service.CreateOrUpdateRiskRule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), errorRiskRulePeriodInvalid, res.Message)
+
+	req.Period = 0
+	req.MerchantId = primitive.NewObjectID().Hex()
+	res = &pkg.RiskRuleResponse{}
+	err = suite.service.CreateOrUpdateRiskRule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), merchantErrorNotFound, res.Message)
+
+	req.MerchantId = ""
+	req.Id = primitive.NewObjectID().Hex()
+	res = &pkg.RiskRuleResponse{}
+	err = suite.service.CreateOrUpdateRiskRule(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorRiskRuleNotFound, res.Message)
+}
+
+func (suite *RiskTestSuite) TestRisk_ListRiskRules_Ok() {
+	suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		Signal:   pkg.RiskSignalChargebacks,
+		Operator: pkg.RiskOperatorGt,
+		Score:    50,
+	})
+	rule := suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		MerchantId: suite.merchant.Id,
+		Signal:     pkg.RiskSignalSessionCount,
+		Operator:   pkg.RiskOperatorGt,
+		Value:      10,
+		Score:      20,
+	})
+
+	res := &pkg.ListRiskRulesResponse{}
+	err := suite.service.ListRiskRules(context.TODO(), &pkg.ListRiskRulesRequest{}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.EqualValues(suite.T(), 2, res.Count)
+	assert.Len(suite.T(), res.Items, 2)
+
+	res = &pkg.ListRiskRulesResponse{}
+	err = suite.service.ListRiskRules(context.TODO(), &pkg.ListRiskRulesRequest{MerchantId: suite.merchant.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, res.Count)
+	assert.Equal(suite.T(), rule.Id, res.Items[0].Id)
+}
+
+func (suite *RiskTestSuite) TestRisk_DeleteRiskRule_Ok() {
+	rule := suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		Signal:   pkg.RiskSignalChargebacks,
+		Operator: pkg.RiskOperatorGt,
+		Score:    50,
+	})
+
+	res := &billingpb.EmptyResponseWithStatus{}
+	err := suite.service.DeleteRiskRule(context.TODO(), &pkg.RiskRuleRequest{Id: rule.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	res = &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.DeleteRiskRule(context.TODO(), &pkg.RiskRuleRequest{Id: rule.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorRiskRuleNotFound, res.Message)
+}
+
+func (suite *RiskTestSuite) TestRisk_AssessOrderRisk_Ok_WithoutRules() {
+	order := suite.getOrder()
+	data := map[string]string{billingpb.PaymentCreateFieldPan: "4000000000000002"}
+	assessment, err := suite.service.assessOrderRisk(context.TODO(), order, data)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), assessment.Id)
+	assert.EqualValues(suite.T(), 0, assessment.Score)
+	assert.Equal(suite.T(), pkg.RiskDecisionAllow, assessment.Decision)
+	assert.NotEmpty(suite.T(), assessment.CardFingerprint)
+	assert.Equal(suite.T(), assessment.Id, order.PrivateMetadata[pkg.OrderPrivateMetadataRiskAssessmentId])
+	assert.Equal(suite.T(), "0", order.PrivateMetadata[pkg.OrderPrivateMetadataRiskScore])
+	assert.Equal(suite.T(), pkg.RiskDecisionAllow, order.PrivateMetadata[pkg.OrderPrivateMetadataRiskDecision])
+
+	res := &pkg.GetOrderRiskAssessmentsResponse{}
+	err = suite.service.GetOrderRiskAssessments(context.TODO(), &pkg.GetOrderRiskAssessmentsRequest{OrderId: order.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Len(suite.T(), res.Items, 1)
+	assert.Equal(suite.T(), assessment.Id, res.Items[0].Id)
+}
+
+func (suite *RiskTestSuite) TestRisk_AssessOrderRisk_Ok_CardVelocity() {
+	suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		Name:     "card velocity",
+		Signal:   pkg.RiskSignalCardVelocity,
+		Operator: pkg.RiskOperatorGte,
+		Value:    2,
+		Period:   3600,
+		Score:    30,
+		Enabled:  true,
+	})
+
+	data := map[string]string{billingpb.PaymentCreateFieldPan: "4000000000000002"}
+
+	for i := 0; i < 2; i++ {
+		assessment, err := suite.service.assessOrderRisk(context.TODO(), suite.getOrder(), data)
+		assert.NoError(suite.T(), err)
+		assert.EqualValues(suite.T(), 0, assessment.Score)
+	}
+
+	assessment, err := suite.service.assessOrderRisk(context.TODO(), suite.getOrder(), data)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 30, assessment.Score)
+	assert.Equal(suite.T(), pkg.RiskDecisionAllow, assessment.Decision)
+	assert.Len(suite.T(), assessment.Rules, 1)
+	assert.Len(suite.T(), assessment.Signals, 1)
+	assert.EqualValues(suite.T(), 2, assessment.Signals[0].Value)
+
+	order := suite.getOrder()
+	order.PaymentRequisites[billingpb.PaymentCreateFieldPan] = "555555******4444"
+	assessment, err = suite.service.assessOrderRisk(context.TODO(), order, map[string]string{billingpb.PaymentCreateFieldPan: "5555555555554444"})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0, assessment.Score)
+
+	// cards identified by masked numbers only aren't counted together
+	assessment, err = suite.service.assessOrderRisk(context.TODO(), suite.getOrder(), map[string]string{})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0, assessment.Score)
+	assert.Empty(suite.T(), assessment.CardFingerprint)
+}
+
+func (suite *RiskTestSuite) TestRisk_AssessOrderRisk_Ok_ScoreDecisions() {
+	suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		Signal:   pkg.RiskSignalBinIpCountryMismatch,
+		Operator: pkg.RiskOperatorEq,
+		Value:    1,
+		Score:    suite.service.cfg.RiskScoreThreeDs,
+		Enabled:  true,
+	})
+	suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		MerchantId: suite.merchant.Id,
+		Signal:     pkg.RiskSignalSessionCount,
+		Operator:   pkg.RiskOperatorGt,
+		Value:      5,
+		Score:      suite.service.cfg.RiskScoreDecline,
+		Enabled:    true,
+	})
+
+	order := suite.getOrder()
+	assessment, err := suite.service.assessOrderRisk(context.TODO(), order, map[string]string{})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RiskDecisionAllow, assessment.Decision)
+
+	order = suite.getOrder()
+	order.PaymentIpCountry = "US"
+	assessment, err = suite.service.assessOrderRisk(context.TODO(), order, map[string]string{})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.service.cfg.RiskScoreThreeDs, assessment.Score)
+	assert.Equal(suite.T(), pkg.RiskDecisionThreeDs, assessment.Decision)
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataSessionCount] = "10"
+	assessment, err = suite.service.assessOrderRisk(context.TODO(), order, map[string]string{})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RiskDecisionDecline, assessment.Decision)
+	assert.Equal(suite.T(), pkg.RiskDecisionDecline, order.PrivateMetadata[pkg.OrderPrivateMetadataRiskDecision])
+}
+
+func (suite *RiskTestSuite) TestRisk_AssessOrderRisk_Ok_RuleDecision() {
+	suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		Signal:   pkg.RiskSignalAmountRatio,
+		Operator: pkg.RiskOperatorGt,
+		Value:    3,
+		Score:    1,
+		Decision: pkg.RiskDecisionReview,
+		Enabled:  true,
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := suite.service.assessOrderRisk(context.TODO(), suite.getOrder(), map[string]string{})
+		assert.NoError(suite.T(), err)
+	}
+
+	order := suite.getOrder()
+	order.ChargeAmount = 1000
+	assessment, err := suite.service.assessOrderRisk(context.TODO(), order, map[string]string{})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, assessment.Score)
+	assert.Equal(suite.T(), pkg.RiskDecisionReview, assessment.Decision)
+	assert.EqualValues(suite.T(), 10, assessment.Signals[0].Value)
+}
+
+func (suite *RiskTestSuite) TestRisk_AssessOrderRisk_Ok_Chargebacks() {
+	suite.helperCreateRiskRule(&pkg.CreateOrUpdateRiskRuleRequest{
+		Signal:   pkg.RiskSignalChargebacks,
+		Operator: pkg.RiskOperatorGt,
+		Value:    0,
+		Score:    suite.service.cfg.RiskScoreReview,
+		Enabled:  true,
+	})
+
+	order := suite.getOrder()
+	assessment, err := suite.service.assessOrderRisk(context.TODO(), order, map[string]string{})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RiskDecisionAllow, assessment.Decision)
+
+	suite.service.markRiskChargeback(context.TODO(), order)
+
+	order = suite.getOrder()
+	order.PaymentRequisites[billingpb.PaymentCreateFieldPan] = "555555******4444"
+	assessment, err = suite.service.assessOrderRisk(context.TODO(), order, map[string]string{})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RiskDecisionReview, assessment.Decision)
+	assert.EqualValues(suite.T(), 1, assessment.Signals[0].Value)
+}
+
+func (suite *RiskTestSuite) TestRisk_GetRiskCardFingerprint() {
+	order := suite.getOrder()
+
+	// masked number of the card doesn't identify the card
+	assert.Empty(suite.T(), suite.service.getRiskCardFingerprint(order, map[string]string{}))
+	assert.Empty(suite.T(), suite.service.getRiskCardFingerprint(order, map[string]string{billingpb.PaymentCreateFieldPan: "400000******0002"}))
+
+	full := suite.service.getRiskCardFingerprint(order, map[string]string{billingpb.PaymentCreateFieldPan: "4000000000000002"})
+	assert.NotEmpty(suite.T(), full)
+	assert.NotEqual(suite.T(), "4000000000000002", full)
+	assert.Equal(suite.T(), full, suite.service.getRiskCardFingerprint(suite.getOrder(), map[string]string{billingpb.PaymentCreateFieldPan: "4000000000000002"}))
+
+	// fingerprint of the saved card is used for the payment by the saved card
+	order.PrivateMetadata[pkg.OrderPrivateMetadataCardFingerprint] = full
+	assert.Equal(suite.T(), full, suite.service.getRiskCardFingerprint(order, map[string]string{}))
+
+	// fingerprint is keyed by the secret
+	secret := suite.service.cfg.CardFingerprintSecret
+	suite.service.cfg.CardFingerprintSecret = "other"
+	assert.NotEqual(suite.T(), full, suite.service.getCardFingerprint("4000000000000002"))
+	suite.service.cfg.CardFingerprintSecret = secret
+
+	order.PaymentMethod = &billingpb.PaymentMethodOrder{Group: recurringpb.PaymentSystemGroupAliasQiwi}
+	assert.Empty(suite.T(), suite.service.getRiskCardFingerprint(order, map[string]string{}))
+}
+
+func (suite *RiskTestSuite) TestRisk_GetOrderRiskAssessments_NotFound() {
+	res := &pkg.GetOrderRiskAssessmentsResponse{}
+	err := suite.service.GetOrderRiskAssessments(
+		context.TODO(),
+		&pkg.GetOrderRiskAssessmentsRequest{OrderId: primitive.NewObjectID().Hex()},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorRiskAssessmentNotFound, res.Message)
+}
+
+func (suite *RiskTestSuite) TestRisk_CompareRiskSignalValue() {
+	assert.True(suite.T(), compareRiskSignalValue(2, pkg.RiskOperatorGt, 1))
+	assert.False(suite.T(), compareRiskSignalValue(1, pkg.RiskOperatorGt, 1))
+	assert.True(suite.T(), compareRiskSignalValue(1, pkg.RiskOperatorGte, 1))
+	assert.True(suite.T(), compareRiskSignalValue(0, pkg.RiskOperatorLt, 1))
+	assert.True(suite.T(), compareRiskSignalValue(1, pkg.RiskOperatorLte, 1))
+	assert.True(suite.T(), compareRiskSignalValue(1, pkg.RiskOperatorEq, 1))
+	assert.False(suite.T(), compareRiskSignalValue(1, "unknown", 1))
+}
@@ -2,6 +2,7 @@ package service
 
 //1) крон для формирования - 1 раз в неделю (после 18 часов понедельника!)
 //2) крон для проверки не пропущена ли дата - каждый день
+//3) крон для формирования отчетов мерчантов с собственным расписанием - каждый день
 
 import (
 	"context"
@@ -71,13 +72,29 @@ func (s *Service) CreateRoyaltyReport(
 		return royaltyReportErrorTimezoneIncorrect
 	}
 
+	schedules, err := s.royaltyScheduleRepository.GetAll(ctx)
+
+	if err != nil {
+		return err
+	}
+
 	to := now.Monday().In(loc).Add(time.Duration(s.cfg.RoyaltyReportPeriodEndHour) * time.Hour)
-	if to.After(time.Now().In(loc)) {
+	isDefaultPeriodFinished := !to.After(time.Now().In(loc))
+
+	if !isDefaultPeriodFinished && len(schedules) <= 0 {
 		return royaltyReportErrorEndOfPeriodIsInFuture
 	}
 
 	from := to.Add(-time.Duration(s.cfg.RoyaltyReportPeriod) * time.Second).Add(1 * time.Millisecond).In(loc)
 
+	merchantSchedules := make(map[string]*pkg.MerchantRoyaltySchedule, len(schedules))
+	scheduledMerchants := make([]string, len(schedules))
+
+	for i, schedule := range schedules {
+		merchantSchedules[schedule.MerchantId] = schedule
+		scheduledMerchants[i] = schedule.MerchantId
+	}
+
 	var merchants []*pkg2.RoyaltyReportMerchant
 
 	if len(req.Merchants) > 0 {
@@ -91,7 +108,17 @@ func (s *Service) CreateRoyaltyReport(
 			merchants = append(merchants, &pkg2.RoyaltyReportMerchant{Id: oid})
 		}
 	} else {
-		merchants, _ = s.orderViewRepository.GetRoyaltyForMerchants(ctx, orderStatusForRoyaltyReports, from, to)
+		if isDefaultPeriodFinished {
+			merchants, _ = s.orderViewRepository.GetRoyaltyForMerchants(
+				ctx, orderStatusForRoyaltyReports, from, to, nil, scheduledMerchants,
+			)
+		}
+
+		for _, schedule := range schedules {
+			if s.processScheduledRoyaltyReport(ctx, schedule, false) {
+				rsp.Merchants = append(rsp.Merchants, schedule.MerchantId)
+			}
+		}
 	}
 
 	if len(merchants) <= 0 {
@@ -106,6 +133,22 @@ func (s *Service) CreateRoyaltyReport(
 	}
 
 	for _, v := range merchants {
+		if schedule, ok := merchantSchedules[v.Id.Hex()]; ok {
+			if s.processScheduledRoyaltyReport(ctx, schedule, true) {
+				rsp.Merchants = append(rsp.Merchants, v.Id.Hex())
+			}
+			continue
+		}
+
+		if !isDefaultPeriodFinished {
+			zap.L().Error(
+				pkg.ErrorRoyaltyReportGenerationFailed,
+				zap.Error(royaltyReportErrorEndOfPeriodIsInFuture),
+				zap.String(pkg.ErrorRoyaltyReportFieldMerchantId, v.Id.Hex()),
+			)
+			continue
+		}
+
 		err := handler.createMerchantRoyaltyReport(ctx, v.Id)
 
 		if err == nil {
@@ -265,6 +308,14 @@ func (s *Service) MerchantReviewRoyaltyReport(
 			rsp.Status = billingpb.ResponseStatusSystemError
 			rsp.Message = royaltyReportUpdateBalanceError
 
+			return nil
+		}
+	} else {
+		dispute := newRoyaltyReportDispute(report, "", req.DisputeReason, nil)
+
+		if err = s.royaltyDisputeRepository.Insert(ctx, dispute); err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = royaltyReportEntryErrorUnknown
 			return nil
 		}
 	}
@@ -346,6 +397,7 @@ func (s *Service) ChangeRoyaltyReport(
 	}
 
 	hasChanges := false
+	isDisputeClosed := false
 
 	if report.Status == billingpb.RoyaltyReportStatusDispute && req.Correction != nil {
 
@@ -363,59 +415,14 @@ func (s *Service) ChangeRoyaltyReport(
 			return nil
 		}
 
-		from, err := ptypes.Timestamp(report.PeriodFrom)
+		_, err = s.createRoyaltyReportCorrection(ctx, report, req.Correction.Amount, req.Correction.Reason)
 		if err != nil {
-			zap.L().Error("time conversion error", zap.Error(err))
-			rsp.Status = billingpb.ResponseStatusSystemError
-			rsp.Message = royaltyReportEntryErrorUnknown
-			return nil
-		}
-		to, err := ptypes.Timestamp(report.PeriodTo)
-		if err != nil {
-			zap.L().Error("time conversion error", zap.Error(err))
-			rsp.Status = billingpb.ResponseStatusSystemError
-			rsp.Message = royaltyReportEntryErrorUnknown
-			return nil
-		}
-
-		reqAe := &billingpb.CreateAccountingEntryRequest{
-			MerchantId: report.MerchantId,
-			Amount:     req.Correction.Amount,
-			Currency:   report.Currency,
-			Reason:     req.Correction.Reason,
-			Date:       to.Add(-1 * time.Second).Unix(),
-			Type:       pkg.AccountingEntryTypeMerchantRoyaltyCorrection,
-		}
-		resAe := &billingpb.CreateAccountingEntryResponse{}
-		err = s.CreateAccountingEntry(ctx, reqAe, resAe)
-		if err != nil {
-			zap.L().Error("create correction accounting entry failed", zap.Error(err))
-			rsp.Status = billingpb.ResponseStatusSystemError
-			rsp.Message = royaltyReportEntryErrorUnknown
-			return nil
-		}
-		if resAe.Status != billingpb.ResponseStatusOk {
-			zap.L().Error("create correction accounting entry failed")
 			rsp.Status = billingpb.ResponseStatusSystemError
 			rsp.Message = royaltyReportEntryErrorUnknown
 			return nil
 		}
 
-		if report.Totals == nil {
-			report.Totals = &billingpb.RoyaltyReportTotals{}
-		}
-		if report.Summary == nil {
-			report.Summary = &billingpb.RoyaltyReportSummary{}
-		}
-
-		handler := &royaltyHandler{
-			Service: s,
-			from:    from,
-			to:      to,
-		}
-		report.Summary.Corrections, report.Totals.CorrectionAmount, err = handler.getRoyaltyReportCorrections(ctx, report.MerchantId, report.Currency)
-		if err != nil {
-			zap.L().Error("get royalty report corrections error", zap.Error(err))
+		if err = s.refreshRoyaltyReportCorrections(ctx, report); err != nil {
 			rsp.Status = billingpb.ResponseStatusSystemError
 			rsp.Message = royaltyReportEntryErrorUnknown
 			return nil
@@ -427,6 +434,7 @@ func (s *Service) ChangeRoyaltyReport(
 	if req.Status != "" && req.Status != report.Status {
 		if report.Status == billingpb.RoyaltyReportStatusDispute {
 			report.DisputeClosedAt = ptypes.TimestampNow()
+			isDisputeClosed = true
 		}
 
 		if req.Status == billingpb.RoyaltyReportStatusAccepted {
@@ -455,6 +463,10 @@ func (s *Service) ChangeRoyaltyReport(
 		return err
 	}
 
+	if isDisputeClosed {
+		s.closeRoyaltyReportDispute(ctx, report.Id)
+	}
+
 	s.sendRoyaltyReportNotification(ctx, report)
 
 	_, err = s.updateMerchantBalance(ctx, report.MerchantId)
@@ -467,6 +479,76 @@ func (s *Service) ChangeRoyaltyReport(
 	return nil
 }
 
+// createRoyaltyReportCorrection creates merchant_royalty_correction accounting entry which will be included
+// to corrections of the royalty report.
+func (s *Service) createRoyaltyReportCorrection(
+	ctx context.Context,
+	report *billingpb.RoyaltyReport,
+	amount float64,
+	reason string,
+) (*billingpb.AccountingEntry, error) {
+	to, err := ptypes.Timestamp(report.PeriodTo)
+	if err != nil {
+		zap.L().Error("time conversion error", zap.Error(err))
+		return nil, err
+	}
+
+	reqAe := &billingpb.CreateAccountingEntryRequest{
+		MerchantId: report.MerchantId,
+		Amount:     amount,
+		Currency:   report.Currency,
+		Reason:     reason,
+		Date:       to.Add(-1 * time.Second).Unix(),
+		Type:       pkg.AccountingEntryTypeMerchantRoyaltyCorrection,
+	}
+	resAe := &billingpb.CreateAccountingEntryResponse{}
+	err = s.CreateAccountingEntry(ctx, reqAe, resAe)
+	if err != nil {
+		zap.L().Error("create correction accounting entry failed", zap.Error(err))
+		return nil, err
+	}
+	if resAe.Status != billingpb.ResponseStatusOk {
+		zap.L().Error("create correction accounting entry failed", zap.Any("message", resAe.Message))
+		return nil, royaltyReportEntryErrorUnknown
+	}
+
+	return resAe.Item, nil
+}
+
+// refreshRoyaltyReportCorrections recalculates corrections list and corrections total of the royalty report.
+func (s *Service) refreshRoyaltyReportCorrections(ctx context.Context, report *billingpb.RoyaltyReport) error {
+	from, err := ptypes.Timestamp(report.PeriodFrom)
+	if err != nil {
+		zap.L().Error("time conversion error", zap.Error(err))
+		return err
+	}
+	to, err := ptypes.Timestamp(report.PeriodTo)
+	if err != nil {
+		zap.L().Error("time conversion error", zap.Error(err))
+		return err
+	}
+
+	if report.Totals == nil {
+		report.Totals = &billingpb.RoyaltyReportTotals{}
+	}
+	if report.Summary == nil {
+		report.Summary = &billingpb.RoyaltyReportSummary{}
+	}
+
+	handler := &royaltyHandler{
+		Service: s,
+		from:    from,
+		to:      to,
+	}
+	report.Summary.Corrections, report.Totals.CorrectionAmount, err = handler.getRoyaltyReportCorrections(ctx, report.MerchantId, report.Currency)
+	if err != nil {
+		zap.L().Error("get royalty report corrections error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (s *Service) ListRoyaltyReportOrders(
 	ctx context.Context,
 	req *billingpb.ListRoyaltyReportOrdersRequest,
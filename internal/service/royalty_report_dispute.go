@@ -0,0 +1,529 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var (
+	royaltyDisputeErrorNotFound             = newBillingServerErrorMsg("rr00017", "royalty report dispute not found")
+	royaltyDisputeErrorReasonRequired       = newBillingServerErrorMsg("rr00018", "royalty report dispute reason required")
+	royaltyDisputeErrorItemOrderNotFound    = newBillingServerErrorMsg("rr00019", "order of royalty report dispute item not found")
+	royaltyDisputeErrorItemSummaryNotFound  = newBillingServerErrorMsg("rr00020", "product summary line of royalty report dispute item not found")
+	royaltyDisputeErrorItemAmountRequired   = newBillingServerErrorMsg("rr00021", "royalty report dispute item amount required and must be not zero")
+	royaltyDisputeErrorItemReferenceInvalid = newBillingServerErrorMsg("rr00022", "royalty report dispute item must reference order or product summary line")
+	royaltyDisputeErrorAlreadyResolved      = newBillingServerErrorMsg("rr00023", "royalty report dispute already resolved")
+	royaltyDisputeErrorMessageRequired      = newBillingServerErrorMsg("rr00024", "royalty report dispute message required")
+	royaltyDisputeErrorMessageSourceInvalid = newBillingServerErrorMsg("rr00025", "royalty report dispute message source is invalid")
+	royaltyDisputeErrorItemNotFound         = newBillingServerErrorMsg("rr00026", "royalty report dispute item not found")
+)
+
+// OpenRoyaltyReportDispute moves the pending royalty report to dispute status on behalf of the merchant
+// and creates the structured dispute with line items referencing orders or product summary lines of the report.
+func (s *Service) OpenRoyaltyReportDispute(
+	ctx context.Context,
+	req *pkg.OpenRoyaltyReportDisputeRequest,
+	rsp *pkg.RoyaltyReportDisputeResponse,
+) error {
+	report, err := s.getRoyaltyReportForDispute(ctx, req.ReportId, req.MerchantId)
+
+	if err != nil {
+		rsp.Status, rsp.Message = getRoyaltyDisputeErrorStatus(err)
+		return nil
+	}
+
+	if report.Status != billingpb.RoyaltyReportStatusPending {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportErrorReportStatusChangeDenied
+		return nil
+	}
+
+	if req.Reason == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyDisputeErrorReasonRequired
+		return nil
+	}
+
+	for _, item := range req.Items {
+		if err = s.validateRoyaltyDisputeItem(ctx, report, item); err != nil {
+			rsp.Status, rsp.Message = getRoyaltyDisputeErrorStatus(err)
+			return nil
+		}
+	}
+
+	previousReason, previousStartedAt := report.DisputeReason, report.DisputeStartedAt
+
+	report.Status = billingpb.RoyaltyReportStatusDispute
+	report.DisputeReason = req.Reason
+	report.DisputeStartedAt = ptypes.TimestampNow()
+	report.UpdatedAt = ptypes.TimestampNow()
+
+	err = s.royaltyReportRepository.Update(ctx, report, req.Ip, pkg.RoyaltyReportChangeSourceMerchant)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	dispute := newRoyaltyReportDispute(report, req.UserId, req.Reason, req.Items)
+
+	if err = s.royaltyDisputeRepository.Insert(ctx, dispute); err != nil {
+		// report without opened dispute can't be resolved, so it's returned to pending status
+		report.Status = billingpb.RoyaltyReportStatusPending
+		report.DisputeReason = previousReason
+		report.DisputeStartedAt = previousStartedAt
+		report.UpdatedAt = ptypes.TimestampNow()
+
+		if err := s.royaltyReportRepository.Update(ctx, report, req.Ip, pkg.RoyaltyReportChangeSourceMerchant); err != nil {
+			zap.L().Error(
+				"rollback of royalty report status after failed dispute insert failed",
+				zap.Error(err),
+				zap.String("report_id", report.Id),
+			)
+		}
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	if err = s.royaltyReportChangedEmail(ctx, report); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = err.(*billingpb.ResponseErrorMessage)
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = dispute
+
+	return nil
+}
+
+// AddRoyaltyReportDisputeMessage adds message of the merchant or admin to the discussion thread of the opened dispute.
+func (s *Service) AddRoyaltyReportDisputeMessage(
+	ctx context.Context,
+	req *pkg.AddRoyaltyReportDisputeMessageRequest,
+	rsp *pkg.RoyaltyReportDisputeResponse,
+) error {
+	if req.Source != pkg.RoyaltyReportChangeSourceMerchant && req.Source != pkg.RoyaltyReportChangeSourceAdmin {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyDisputeErrorMessageSourceInvalid
+		return nil
+	}
+
+	if req.Message == "" {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyDisputeErrorMessageRequired
+		return nil
+	}
+
+	report, err := s.getRoyaltyReportForDispute(ctx, req.ReportId, req.MerchantId)
+
+	if err != nil {
+		rsp.Status, rsp.Message = getRoyaltyDisputeErrorStatus(err)
+		return nil
+	}
+
+	dispute, err := s.getOpenedRoyaltyReportDispute(ctx, report)
+
+	if err != nil {
+		rsp.Status, rsp.Message = getRoyaltyDisputeErrorStatus(err)
+		return nil
+	}
+
+	dispute.Messages = append(dispute.Messages, &pkg.RoyaltyReportDisputeMessage{
+		Id:        primitive.NewObjectID().Hex(),
+		Source:    req.Source,
+		UserId:    req.UserId,
+		Message:   req.Message,
+		CreatedAt: ptypes.TimestampNow(),
+	})
+	dispute.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.royaltyDisputeRepository.Update(ctx, dispute); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	// the report update is written to the royalty report changes log
+	report.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.royaltyReportRepository.Update(ctx, report, req.Ip, req.Source); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	if req.Source == pkg.RoyaltyReportChangeSourceAdmin {
+		s.sendRoyaltyReportNotification(ctx, report)
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = dispute
+
+	return nil
+}
+
+// ResolveRoyaltyReportDispute closes the opened dispute on behalf of the admin. Accepted items of the dispute
+// are applied as itemised merchant_royalty_correction accounting entries, items without decision are rejected.
+// After that corrections of the royalty report are recalculated and the report is rendered again.
+func (s *Service) ResolveRoyaltyReportDispute(
+	ctx context.Context,
+	req *pkg.ResolveRoyaltyReportDisputeRequest,
+	rsp *pkg.RoyaltyReportDisputeResponse,
+) error {
+	report, err := s.getRoyaltyReportForDispute(ctx, req.ReportId, req.MerchantId)
+
+	if err != nil {
+		rsp.Status, rsp.Message = getRoyaltyDisputeErrorStatus(err)
+		return nil
+	}
+
+	if req.Status == "" {
+		req.Status = billingpb.RoyaltyReportStatusPending
+	}
+
+	if report.Status != billingpb.RoyaltyReportStatusDispute ||
+		req.Status == billingpb.RoyaltyReportStatusDispute || report.ChangesAvailable(req.Status) == false {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportErrorReportStatusChangeDenied
+		return nil
+	}
+
+	dispute, err := s.getOpenedRoyaltyReportDispute(ctx, report)
+
+	if err != nil {
+		rsp.Status, rsp.Message = getRoyaltyDisputeErrorStatus(err)
+		return nil
+	}
+
+	itemIds := make(map[string]bool, len(dispute.Items))
+
+	for _, item := range dispute.Items {
+		itemIds[item.Id] = true
+	}
+
+	resolutions := make(map[string]*pkg.RoyaltyReportDisputeItemResolution, len(req.Items))
+
+	for _, resolution := range req.Items {
+		if !itemIds[resolution.ItemId] {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = royaltyDisputeErrorItemNotFound
+			return nil
+		}
+
+		resolutions[resolution.ItemId] = resolution
+	}
+
+	for _, item := range dispute.Items {
+		// correction of the item was created by the previous attempt of resolution which failed afterwards
+		if item.AccountingEntryId != "" {
+			continue
+		}
+
+		resolution, ok := resolutions[item.Id]
+
+		if !ok || !resolution.IsAccepted {
+			item.Status = pkg.RoyaltyReportDisputeItemStatusRejected
+			continue
+		}
+
+		item.AcceptedAmount = item.Amount
+
+		if resolution.Amount != 0 {
+			item.AcceptedAmount = resolution.Amount
+		}
+
+		entry, err := s.createRoyaltyReportCorrection(ctx, report, item.AcceptedAmount, getRoyaltyDisputeItemReason(item))
+
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = royaltyReportEntryErrorUnknown
+			return nil
+		}
+
+		item.Status = pkg.RoyaltyReportDisputeItemStatusAccepted
+		item.AccountingEntryId = entry.Id
+		dispute.UpdatedAt = ptypes.TimestampNow()
+
+		// correction is saved on the item right away to not create it again on retry of the resolution
+		if err = s.royaltyDisputeRepository.Update(ctx, dispute); err != nil {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = royaltyReportEntryErrorUnknown
+			return nil
+		}
+	}
+
+	if req.Message != "" {
+		dispute.Messages = append(dispute.Messages, &pkg.RoyaltyReportDisputeMessage{
+			Id:        primitive.NewObjectID().Hex(),
+			Source:    pkg.RoyaltyReportChangeSourceAdmin,
+			UserId:    req.UserId,
+			Message:   req.Message,
+			CreatedAt: ptypes.TimestampNow(),
+		})
+	}
+
+	dispute.Status = pkg.RoyaltyReportDisputeStatusResolved
+	dispute.ResolvedAt = ptypes.TimestampNow()
+	dispute.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.royaltyDisputeRepository.Update(ctx, dispute); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	if err = s.refreshRoyaltyReportCorrections(ctx, report); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	if req.Status == billingpb.RoyaltyReportStatusAccepted {
+		report.AcceptedAt = ptypes.TimestampNow()
+	}
+
+	report.Status = req.Status
+	report.DisputeClosedAt = ptypes.TimestampNow()
+	report.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.royaltyReportRepository.Update(ctx, report, req.Ip, pkg.RoyaltyReportChangeSourceAdmin); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	merchant, err := s.merchantRepository.GetById(ctx, report.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportErrorMerchantNotFound
+		return nil
+	}
+
+	if err = s.renderRoyaltyReport(ctx, report, merchant); err != nil {
+		zap.L().Error("re-render royalty report after dispute resolution failed", zap.Error(err))
+	}
+
+	s.sendRoyaltyReportNotification(ctx, report)
+
+	if _, err = s.updateMerchantBalance(ctx, report.MerchantId); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportUpdateBalanceError
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = dispute
+
+	return nil
+}
+
+// GetRoyaltyReportDispute returns the latest dispute of the royalty report with its discussion thread.
+func (s *Service) GetRoyaltyReportDispute(
+	ctx context.Context,
+	req *pkg.GetRoyaltyReportDisputeRequest,
+	rsp *pkg.RoyaltyReportDisputeResponse,
+) error {
+	report, err := s.getRoyaltyReportForDispute(ctx, req.ReportId, req.MerchantId)
+
+	if err != nil {
+		rsp.Status, rsp.Message = getRoyaltyDisputeErrorStatus(err)
+		return nil
+	}
+
+	dispute, err := s.royaltyDisputeRepository.GetLastByRoyaltyReportId(ctx, report.Id)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = royaltyDisputeErrorNotFound
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = dispute
+
+	return nil
+}
+
+// closeRoyaltyReportDispute marks opened dispute of the report as resolved without adjustments.
+// It's used when the dispute is closed by direct change of the royalty report status.
+func (s *Service) closeRoyaltyReportDispute(ctx context.Context, reportId string) {
+	dispute, err := s.royaltyDisputeRepository.GetLastByRoyaltyReportId(ctx, reportId)
+
+	if err != nil || dispute.Status != pkg.RoyaltyReportDisputeStatusOpened {
+		return
+	}
+
+	for _, item := range dispute.Items {
+		if item.Status == pkg.RoyaltyReportDisputeItemStatusPending {
+			item.Status = pkg.RoyaltyReportDisputeItemStatusRejected
+		}
+	}
+
+	dispute.Status = pkg.RoyaltyReportDisputeStatusResolved
+	dispute.ResolvedAt = ptypes.TimestampNow()
+	dispute.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.royaltyDisputeRepository.Update(ctx, dispute); err != nil {
+		zap.L().Error("close royalty report dispute failed", zap.Error(err), zap.String("report_id", reportId))
+	}
+}
+
+func (s *Service) getRoyaltyReportForDispute(
+	ctx context.Context,
+	reportId, merchantId string,
+) (*billingpb.RoyaltyReport, error) {
+	report, err := s.royaltyReportRepository.GetById(ctx, reportId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, royaltyReportErrorReportNotFound
+		}
+
+		return nil, royaltyReportEntryErrorUnknown
+	}
+
+	if report.MerchantId != merchantId {
+		return nil, royaltyReportErrorNotOwnedByMerchant
+	}
+
+	return report, nil
+}
+
+func (s *Service) getOpenedRoyaltyReportDispute(
+	ctx context.Context,
+	report *billingpb.RoyaltyReport,
+) (*pkg.RoyaltyReportDispute, error) {
+	dispute, err := s.royaltyDisputeRepository.GetLastByRoyaltyReportId(ctx, report.Id)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, royaltyDisputeErrorNotFound
+		}
+
+		return nil, royaltyReportEntryErrorUnknown
+	}
+
+	if dispute.Status != pkg.RoyaltyReportDisputeStatusOpened || report.Status != billingpb.RoyaltyReportStatusDispute {
+		return nil, royaltyDisputeErrorAlreadyResolved
+	}
+
+	return dispute, nil
+}
+
+func (s *Service) validateRoyaltyDisputeItem(
+	ctx context.Context,
+	report *billingpb.RoyaltyReport,
+	item *pkg.RoyaltyReportDisputeItem,
+) error {
+	if item.Amount == 0 {
+		return royaltyDisputeErrorItemAmountRequired
+	}
+
+	if item.OrderUuid != "" {
+		order, err := s.orderViewRepository.GetPrivateOrderBy(ctx, "", item.OrderUuid, report.MerchantId)
+
+		if err != nil || order.MerchantPayoutCurrency != report.Currency {
+			return royaltyDisputeErrorItemOrderNotFound
+		}
+
+		return nil
+	}
+
+	if item.Product == "" {
+		return royaltyDisputeErrorItemReferenceInvalid
+	}
+
+	if report.Summary != nil {
+		for _, v := range report.Summary.ProductsItems {
+			if v.Product == item.Product && v.Region == item.Region {
+				return nil
+			}
+		}
+	}
+
+	return royaltyDisputeErrorItemSummaryNotFound
+}
+
+func getRoyaltyDisputeErrorStatus(err error) (int32, *billingpb.ResponseErrorMessage) {
+	e, ok := err.(*billingpb.ResponseErrorMessage)
+
+	if !ok || e == royaltyReportEntryErrorUnknown {
+		return billingpb.ResponseStatusSystemError, royaltyReportEntryErrorUnknown
+	}
+
+	if e == royaltyReportErrorReportNotFound || e == royaltyDisputeErrorNotFound {
+		return billingpb.ResponseStatusNotFound, e
+	}
+
+	return billingpb.ResponseStatusBadData, e
+}
+
+func newRoyaltyReportDispute(
+	report *billingpb.RoyaltyReport,
+	userId, reason string,
+	items []*pkg.RoyaltyReportDisputeItem,
+) *pkg.RoyaltyReportDispute {
+	dispute := &pkg.RoyaltyReportDispute{
+		RoyaltyReportId: report.Id,
+		MerchantId:      report.MerchantId,
+		Status:          pkg.RoyaltyReportDisputeStatusOpened,
+		Reason:          reason,
+		Items:           []*pkg.RoyaltyReportDisputeItem{},
+		Messages: []*pkg.RoyaltyReportDisputeMessage{
+			{
+				Id:        primitive.NewObjectID().Hex(),
+				Source:    pkg.RoyaltyReportChangeSourceMerchant,
+				UserId:    userId,
+				Message:   reason,
+				CreatedAt: ptypes.TimestampNow(),
+			},
+		},
+		CreatedAt: ptypes.TimestampNow(),
+		UpdatedAt: ptypes.TimestampNow(),
+	}
+
+	for _, item := range items {
+		dispute.Items = append(dispute.Items, &pkg.RoyaltyReportDisputeItem{
+			Id:        primitive.NewObjectID().Hex(),
+			OrderUuid: item.OrderUuid,
+			Product:   item.Product,
+			Region:    item.Region,
+			Amount:    item.Amount,
+			Reason:    item.Reason,
+			Status:    pkg.RoyaltyReportDisputeItemStatusPending,
+		})
+	}
+
+	return dispute
+}
+
+func getRoyaltyDisputeItemReason(item *pkg.RoyaltyReportDisputeItem) string {
+	reference := fmt.Sprintf("product %s, region %s", item.Product, item.Region)
+
+	if item.OrderUuid != "" {
+		reference = fmt.Sprintf("order %s", item.OrderUuid)
+	}
+
+	if item.Reason == "" {
+		return fmt.Sprintf("Dispute adjustment (%s)", reference)
+	}
+
+	return fmt.Sprintf("Dispute adjustment (%s): %s", reference, item.Reason)
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/paysuper/paysuper-billing-server/internal/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (suite *RoyaltyReportTestSuite) createRoyaltyReportForDispute() (*billingpb.RoyaltyReport, *billingpb.Order) {
+	order := suite.createOrder(suite.project)
+	err := suite.service.updateOrderView(context.TODO(), []string{})
+	assert.NoError(suite.T(), err)
+
+	req := &billingpb.CreateRoyaltyReportRequest{}
+	rsp := &billingpb.CreateRoyaltyReportRequest{}
+	err = suite.service.CreateRoyaltyReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), rsp.Merchants)
+
+	reports, err := suite.service.royaltyReportRepository.GetAll(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), reports)
+	assert.Equal(suite.T(), billingpb.RoyaltyReportStatusPending, reports[0].Status)
+	assert.NotEmpty(suite.T(), reports[0].Summary.ProductsItems)
+
+	return reports[0], order
+}
+
+func (suite *RoyaltyReportTestSuite) openRoyaltyReportDispute(
+	report *billingpb.RoyaltyReport,
+	order *billingpb.Order,
+) *pkg.RoyaltyReportDispute {
+	req := &pkg.OpenRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		UserId:     primitive.NewObjectID().Hex(),
+		Reason:     "unit-test",
+		Items: []*pkg.RoyaltyReportDisputeItem{
+			{
+				OrderUuid: order.Uuid,
+				Amount:    15,
+				Reason:    "order fee is incorrect",
+			},
+			{
+				Product: report.Summary.ProductsItems[0].Product,
+				Region:  report.Summary.ProductsItems[0].Region,
+				Amount:  -5,
+				Reason:  "product summary is incorrect",
+			},
+		},
+		Ip: "127.0.0.1",
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Empty(suite.T(), rsp.Message)
+
+	return rsp.Item
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_Ok() {
+	report, order := suite.createRoyaltyReportForDispute()
+	dispute := suite.openRoyaltyReportDispute(report, order)
+
+	assert.NotEmpty(suite.T(), dispute.Id)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeStatusOpened, dispute.Status)
+	assert.Len(suite.T(), dispute.Items, 2)
+	assert.Len(suite.T(), dispute.Messages, 1)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeItemStatusPending, dispute.Items[0].Status)
+
+	report, err := suite.service.royaltyReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.RoyaltyReportStatusDispute, report.Status)
+	assert.Equal(suite.T(), "unit-test", report.DisputeReason)
+
+	req := &pkg.GetRoyaltyReportDisputeRequest{ReportId: report.Id, MerchantId: report.MerchantId}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err = suite.service.GetRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), dispute.Id, rsp.Item.Id)
+	assert.Equal(suite.T(), order.Uuid, rsp.Item.Items[0].OrderUuid)
+
+	changes, err := suite.service.royaltyReportRepository.GetRoyaltyHistoryById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), changes, 2)
+	assert.Equal(suite.T(), pkg.RoyaltyReportChangeSourceMerchant, changes[1].Source)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_ValidationErrors() {
+	report, order := suite.createRoyaltyReportForDispute()
+
+	req := &pkg.OpenRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorReasonRequired, rsp.Message)
+
+	req.Reason = "unit-test"
+	req.Items = []*pkg.RoyaltyReportDisputeItem{{OrderUuid: order.Uuid}}
+	err = suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorItemAmountRequired, rsp.Message)
+
+	req.Items = []*pkg.RoyaltyReportDisputeItem{{OrderUuid: "unknown", Amount: 10}}
+	err = suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorItemOrderNotFound, rsp.Message)
+
+	req.Items = []*pkg.RoyaltyReportDisputeItem{{Product: "unknown", Region: "RU", Amount: 10}}
+	err = suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorItemSummaryNotFound, rsp.Message)
+
+	req.Items = []*pkg.RoyaltyReportDisputeItem{{Amount: 10}}
+	err = suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorItemReferenceInvalid, rsp.Message)
+
+	req.MerchantId = primitive.NewObjectID().Hex()
+	err = suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportErrorNotOwnedByMerchant, rsp.Message)
+
+	req.ReportId = primitive.NewObjectID().Hex()
+	err = suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportErrorReportNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_StatusChangeDenied() {
+	report, order := suite.createRoyaltyReportForDispute()
+	suite.openRoyaltyReportDispute(report, order)
+
+	req := &pkg.OpenRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		Reason:     "unit-test",
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportErrorReportStatusChangeDenied, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_OpenRoyaltyReportDispute_InsertError_RollbackReportStatus() {
+	report, order := suite.createRoyaltyReportForDispute()
+
+	disputeRep := &mocks.RoyaltyReportDisputeRepositoryInterface{}
+	disputeRep.On("Insert", mock.Anything, mock.Anything).Return(errors.New("some error"))
+	suite.service.royaltyDisputeRepository = disputeRep
+
+	req := &pkg.OpenRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		Reason:     "unit-test",
+		Items:      []*pkg.RoyaltyReportDisputeItem{{OrderUuid: order.Uuid, Amount: 15}},
+		Ip:         "127.0.0.1",
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.OpenRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusSystemError, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportEntryErrorUnknown, rsp.Message)
+
+	report, err = suite.service.royaltyReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.RoyaltyReportStatusPending, report.Status)
+	assert.Empty(suite.T(), report.DisputeReason)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_AddRoyaltyReportDisputeMessage_Ok() {
+	report, order := suite.createRoyaltyReportForDispute()
+	suite.openRoyaltyReportDispute(report, order)
+
+	req := &pkg.AddRoyaltyReportDisputeMessageRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		UserId:     primitive.NewObjectID().Hex(),
+		Source:     pkg.RoyaltyReportChangeSourceAdmin,
+		Message:    "please provide more details",
+		Ip:         "127.0.0.2",
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.AddRoyaltyReportDisputeMessage(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Len(suite.T(), rsp.Item.Messages, 2)
+	assert.Equal(suite.T(), req.Message, rsp.Item.Messages[1].Message)
+	assert.Equal(suite.T(), pkg.RoyaltyReportChangeSourceAdmin, rsp.Item.Messages[1].Source)
+
+	changes, err := suite.service.royaltyReportRepository.GetRoyaltyHistoryById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), changes, 3)
+	assert.Equal(suite.T(), req.Ip, changes[2].Ip)
+	assert.Equal(suite.T(), pkg.RoyaltyReportChangeSourceAdmin, changes[2].Source)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_AddRoyaltyReportDisputeMessage_Errors() {
+	report, _ := suite.createRoyaltyReportForDispute()
+
+	req := &pkg.AddRoyaltyReportDisputeMessageRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		Source:     "unknown",
+		Message:    "message",
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.AddRoyaltyReportDisputeMessage(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorMessageSourceInvalid, rsp.Message)
+
+	req.Source = pkg.RoyaltyReportChangeSourceMerchant
+	req.Message = ""
+	err = suite.service.AddRoyaltyReportDisputeMessage(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorMessageRequired, rsp.Message)
+
+	req.Message = "message"
+	err = suite.service.AddRoyaltyReportDisputeMessage(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_ResolveRoyaltyReportDispute_Ok() {
+	report, order := suite.createRoyaltyReportForDispute()
+	dispute := suite.openRoyaltyReportDispute(report, order)
+
+	req := &pkg.ResolveRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		UserId:     primitive.NewObjectID().Hex(),
+		Items: []*pkg.RoyaltyReportDisputeItemResolution{
+			{ItemId: dispute.Items[0].Id, IsAccepted: true, Amount: 12},
+		},
+		Message: "order fee corrected",
+		Ip:      "127.0.0.2",
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.ResolveRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Empty(suite.T(), rsp.Message)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeStatusResolved, rsp.Item.Status)
+	assert.NotNil(suite.T(), rsp.Item.ResolvedAt)
+	assert.Len(suite.T(), rsp.Item.Messages, 2)
+
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeItemStatusAccepted, rsp.Item.Items[0].Status)
+	assert.Equal(suite.T(), float64(12), rsp.Item.Items[0].AcceptedAmount)
+	assert.NotEmpty(suite.T(), rsp.Item.Items[0].AccountingEntryId)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeItemStatusRejected, rsp.Item.Items[1].Status)
+	assert.Empty(suite.T(), rsp.Item.Items[1].AccountingEntryId)
+
+	report, err = suite.service.royaltyReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.RoyaltyReportStatusPending, report.Status)
+	assert.NotNil(suite.T(), report.DisputeClosedAt)
+	assert.Len(suite.T(), report.Summary.Corrections, 1)
+	assert.Equal(suite.T(), rsp.Item.Items[0].AccountingEntryId, report.Summary.Corrections[0].AccountingEntryId)
+	assert.Equal(suite.T(), float64(12), report.Totals.CorrectionAmount)
+
+	changes, err := suite.service.royaltyReportRepository.GetRoyaltyHistoryById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), changes, 3)
+	assert.Equal(suite.T(), pkg.RoyaltyReportChangeSourceAdmin, changes[2].Source)
+
+	err = suite.service.ResolveRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportErrorReportStatusChangeDenied, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_ResolveRoyaltyReportDispute_SkipItemWithCorrection() {
+	report, order := suite.createRoyaltyReportForDispute()
+	dispute := suite.openRoyaltyReportDispute(report, order)
+
+	report, err := suite.service.royaltyReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+
+	// correction created by the previous attempt of resolution which failed afterwards
+	entry, err := suite.service.createRoyaltyReportCorrection(context.TODO(), report, 12, "unit-test")
+	assert.NoError(suite.T(), err)
+
+	dispute.Items[0].Status = pkg.RoyaltyReportDisputeItemStatusAccepted
+	dispute.Items[0].AcceptedAmount = 12
+	dispute.Items[0].AccountingEntryId = entry.Id
+	err = suite.service.royaltyDisputeRepository.Update(context.TODO(), dispute)
+	assert.NoError(suite.T(), err)
+
+	req := &pkg.ResolveRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		Items: []*pkg.RoyaltyReportDisputeItemResolution{
+			{ItemId: dispute.Items[0].Id, IsAccepted: true, Amount: 12},
+		},
+		Ip: "127.0.0.2",
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err = suite.service.ResolveRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeItemStatusAccepted, rsp.Item.Items[0].Status)
+	assert.Equal(suite.T(), entry.Id, rsp.Item.Items[0].AccountingEntryId)
+
+	report, err = suite.service.royaltyReportRepository.GetById(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), report.Summary.Corrections, 1)
+	assert.Equal(suite.T(), float64(12), report.Totals.CorrectionAmount)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_ResolveRoyaltyReportDispute_ItemNotFound() {
+	report, order := suite.createRoyaltyReportForDispute()
+	suite.openRoyaltyReportDispute(report, order)
+
+	req := &pkg.ResolveRoyaltyReportDisputeRequest{
+		ReportId:   report.Id,
+		MerchantId: report.MerchantId,
+		Items: []*pkg.RoyaltyReportDisputeItemResolution{
+			{ItemId: primitive.NewObjectID().Hex(), IsAccepted: true},
+		},
+	}
+	rsp := &pkg.RoyaltyReportDisputeResponse{}
+	err := suite.service.ResolveRoyaltyReportDispute(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyDisputeErrorItemNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportTestSuite) TestRoyaltyReport_ChangeRoyaltyReport_ClosesRoyaltyReportDispute() {
+	report, _ := suite.createRoyaltyReportForDispute()
+
+	req := &billingpb.MerchantReviewRoyaltyReportRequest{
+		ReportId:      report.Id,
+		MerchantId:    report.MerchantId,
+		IsAccepted:    false,
+		DisputeReason: "unit-test",
+		Ip:            "127.0.0.1",
+	}
+	rsp := &billingpb.ResponseError{}
+	err := suite.service.MerchantReviewRoyaltyReport(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	dispute, err := suite.service.royaltyDisputeRepository.GetLastByRoyaltyReportId(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeStatusOpened, dispute.Status)
+	assert.Equal(suite.T(), req.DisputeReason, dispute.Messages[0].Message)
+
+	req1 := &billingpb.ChangeRoyaltyReportRequest{
+		ReportId:   report.Id,
+		Status:     billingpb.RoyaltyReportStatusPending,
+		MerchantId: report.MerchantId,
+		Ip:         "127.0.0.1",
+	}
+	rsp1 := &billingpb.ResponseError{}
+	err = suite.service.ChangeRoyaltyReport(context.TODO(), req1, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+
+	dispute, err = suite.service.royaltyDisputeRepository.GetLastByRoyaltyReportId(context.TODO(), report.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.RoyaltyReportDisputeStatusResolved, dispute.Status)
+	assert.NotNil(suite.T(), dispute.ResolvedAt)
+}
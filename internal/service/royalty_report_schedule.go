@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+const (
+	royaltyScheduleMaxMonthlyAnchorDay = 28
+	royaltyScheduleMaxEndHour          = 23
+)
+
+var (
+	royaltyScheduleErrorPeriodInvalid    = newBillingServerErrorMsg("rr00013", "royalty report schedule period is invalid")
+	royaltyScheduleErrorAnchorDayInvalid = newBillingServerErrorMsg("rr00014", "royalty report schedule anchor day is invalid")
+	royaltyScheduleErrorEndHourInvalid   = newBillingServerErrorMsg("rr00015", "royalty report schedule end hour is invalid")
+	royaltyScheduleErrorNotFound         = newBillingServerErrorMsg("rr00016", "royalty report schedule for merchant not found")
+)
+
+type royaltyReportPeriod struct {
+	from time.Time
+	to   time.Time
+}
+
+func (s *Service) SetMerchantRoyaltySchedule(
+	ctx context.Context,
+	req *pkg.SetMerchantRoyaltyScheduleRequest,
+	rsp *pkg.MerchantRoyaltyScheduleResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		rsp.Status = billingpb.ResponseStatusNotFound
+		rsp.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = s.cfg.RoyaltyReportTimeZone
+	}
+
+	if _, err = time.LoadLocation(req.Timezone); err != nil {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyReportErrorTimezoneIncorrect
+		return nil
+	}
+
+	switch req.Period {
+	case pkg.RoyaltyReportSchedulePeriodWeekly, pkg.RoyaltyReportSchedulePeriodBiWeekly:
+		if req.AnchorDay < int32(time.Sunday) || req.AnchorDay > int32(time.Saturday) {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = royaltyScheduleErrorAnchorDayInvalid
+			return nil
+		}
+	case pkg.RoyaltyReportSchedulePeriodMonthly:
+		if req.AnchorDay < 1 || req.AnchorDay > royaltyScheduleMaxMonthlyAnchorDay {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = royaltyScheduleErrorAnchorDayInvalid
+			return nil
+		}
+	default:
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyScheduleErrorPeriodInvalid
+		return nil
+	}
+
+	if req.EndHour < 0 || req.EndHour > royaltyScheduleMaxEndHour {
+		rsp.Status = billingpb.ResponseStatusBadData
+		rsp.Message = royaltyScheduleErrorEndHourInvalid
+		return nil
+	}
+
+	schedule, err := s.royaltyScheduleRepository.GetByMerchantId(ctx, merchant.Id)
+
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusSystemError
+			rsp.Message = royaltyReportEntryErrorUnknown
+			return nil
+		}
+
+		schedule = &pkg.MerchantRoyaltySchedule{
+			MerchantId: merchant.Id,
+			CreatedAt:  ptypes.TimestampNow(),
+		}
+	}
+
+	// new schedule starts right after the last closed royalty report period,
+	// so the next report covers everything that was not reported yet
+	schedule.StartsAt = ptypes.TimestampNow()
+	lastReport, err := s.royaltyReportRepository.GetLastByMerchant(ctx, merchant.Id)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	if lastReport != nil {
+		schedule.StartsAt = lastReport.PeriodTo
+	}
+
+	schedule.Period = req.Period
+	schedule.AnchorDay = req.AnchorDay
+	schedule.EndHour = req.EndHour
+	schedule.Timezone = req.Timezone
+	schedule.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.royaltyScheduleRepository.Upsert(ctx, schedule); err != nil {
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = schedule
+
+	return nil
+}
+
+func (s *Service) GetMerchantRoyaltySchedule(
+	ctx context.Context,
+	req *pkg.GetMerchantRoyaltyScheduleRequest,
+	rsp *pkg.MerchantRoyaltyScheduleResponse,
+) error {
+	schedule, err := s.royaltyScheduleRepository.GetByMerchantId(ctx, req.MerchantId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			rsp.Status = billingpb.ResponseStatusNotFound
+			rsp.Message = royaltyScheduleErrorNotFound
+			return nil
+		}
+
+		rsp.Status = billingpb.ResponseStatusSystemError
+		rsp.Message = royaltyReportEntryErrorUnknown
+		return nil
+	}
+
+	rsp.Status = billingpb.ResponseStatusOk
+	rsp.Item = schedule
+
+	return nil
+}
+
+// CreateScheduledRoyaltyReports creates royalty reports for merchants with own royalty report schedule
+// which periods are already finished.
+func (s *Service) CreateScheduledRoyaltyReports(ctx context.Context) error {
+	schedules, err := s.royaltyScheduleRepository.GetAll(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		s.processScheduledRoyaltyReport(ctx, schedule, false)
+	}
+
+	return nil
+}
+
+// processScheduledRoyaltyReport creates or updates royalty report of merchant for the last finished period of his
+// schedule. Without force flag the report will be created only if merchant has transactions in the period.
+func (s *Service) processScheduledRoyaltyReport(
+	ctx context.Context,
+	schedule *pkg.MerchantRoyaltySchedule,
+	force bool,
+) bool {
+	period, err := s.getScheduledRoyaltyReportPeriod(ctx, schedule, time.Now())
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorRoyaltyReportGenerationFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorRoyaltyReportFieldMerchantId, schedule.MerchantId),
+		)
+		return false
+	}
+
+	if period == nil {
+		return false
+	}
+
+	if !force {
+		merchants, err := s.orderViewRepository.GetRoyaltyForMerchants(
+			ctx,
+			orderStatusForRoyaltyReports,
+			period.from,
+			period.to,
+			[]string{schedule.MerchantId},
+			nil,
+		)
+
+		if err != nil || len(merchants) <= 0 {
+			return false
+		}
+	}
+
+	handler := &royaltyHandler{
+		Service: s,
+		from:    period.from,
+		to:      period.to,
+	}
+
+	oid, _ := primitive.ObjectIDFromHex(schedule.MerchantId)
+	err = handler.createMerchantRoyaltyReport(ctx, oid)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorRoyaltyReportGenerationFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorRoyaltyReportFieldMerchantId, schedule.MerchantId),
+			zap.Any(pkg.ErrorRoyaltyReportFieldFrom, period.from),
+			zap.Any(pkg.ErrorRoyaltyReportFieldTo, period.to),
+		)
+		return false
+	}
+
+	return true
+}
+
+// getScheduledRoyaltyReportPeriod returns the last finished royalty report period of the merchant schedule.
+// Beginning of the period is adjusted to the end of the last merchant royalty report, so the periods are gap-free
+// after schedule changes. Nil is returned when the last finished period is already covered by existing reports
+// which can't be updated.
+func (s *Service) getScheduledRoyaltyReportPeriod(
+	ctx context.Context,
+	schedule *pkg.MerchantRoyaltySchedule,
+	current time.Time,
+) (*royaltyReportPeriod, error) {
+	period, err := getRoyaltySchedulePeriod(schedule, current)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lastReport, err := s.royaltyReportRepository.GetLastByMerchant(ctx, schedule.MerchantId)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return period, nil
+		}
+
+		return nil, err
+	}
+
+	lastFrom, err := ptypes.Timestamp(lastReport.PeriodFrom)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lastTo, err := ptypes.Timestamp(lastReport.PeriodTo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case lastTo.Equal(period.to):
+		// report for this period already exists, it can be updated only until the merchant reviews it
+		if lastReport.Status != billingpb.RoyaltyReportStatusPending {
+			return nil, nil
+		}
+
+		period.from = lastFrom.In(period.to.Location())
+	case lastTo.After(period.to):
+		return nil, nil
+	default:
+		period.from = lastTo.Add(1 * time.Millisecond).In(period.to.Location())
+	}
+
+	return period, nil
+}
+
+// getRoyaltySchedulePeriod calculates boundaries of the last finished period of schedule without respect
+// of the existing royalty reports.
+func getRoyaltySchedulePeriod(schedule *pkg.MerchantRoyaltySchedule, current time.Time) (*royaltyReportPeriod, error) {
+	loc, err := time.LoadLocation(schedule.Timezone)
+
+	if err != nil {
+		return nil, royaltyReportErrorTimezoneIncorrect
+	}
+
+	current = current.In(loc)
+
+	var to, from time.Time
+
+	switch schedule.Period {
+	case pkg.RoyaltyReportSchedulePeriodWeekly:
+		to = getRoyaltyScheduleWeeklyEnd(schedule, current)
+		from = to.AddDate(0, 0, -7)
+	case pkg.RoyaltyReportSchedulePeriodBiWeekly:
+		to = getRoyaltyScheduleWeeklyEnd(schedule, current)
+
+		if schedule.StartsAt != nil {
+			startsAt, err := ptypes.Timestamp(schedule.StartsAt)
+
+			if err != nil {
+				return nil, err
+			}
+
+			reference := getRoyaltyScheduleWeeklyEnd(schedule, startsAt.In(loc))
+			weeks := int(math.Round(to.Sub(reference).Hours()/24)) / 7
+
+			if weeks%2 != 0 {
+				to = to.AddDate(0, 0, -7)
+			}
+		}
+
+		from = to.AddDate(0, 0, -14)
+	case pkg.RoyaltyReportSchedulePeriodMonthly:
+		to = time.Date(current.Year(), current.Month(), int(schedule.AnchorDay), int(schedule.EndHour), 0, 0, 0, loc)
+
+		if to.After(current) {
+			to = to.AddDate(0, -1, 0)
+		}
+
+		from = to.AddDate(0, -1, 0)
+	default:
+		return nil, royaltyScheduleErrorPeriodInvalid
+	}
+
+	return &royaltyReportPeriod{from: from.Add(1 * time.Millisecond), to: to}, nil
+}
+
+func getRoyaltyScheduleWeeklyEnd(schedule *pkg.MerchantRoyaltySchedule, current time.Time) time.Time {
+	to := time.Date(current.Year(), current.Month(), current.Day(), int(schedule.EndHour), 0, 0, 0, current.Location())
+	to = to.AddDate(0, 0, -((int(to.Weekday()) - int(schedule.AnchorDay) + 7) % 7))
+
+	if to.After(current) {
+		to = to.AddDate(0, 0, -7)
+	}
+
+	return to
+}
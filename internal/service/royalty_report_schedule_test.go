@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+type RoyaltyReportScheduleTestSuite struct {
+	suite.Suite
+	service  *Service
+	merchant *billingpb.Merchant
+}
+
+func Test_RoyaltyReportSchedule(t *testing.T) {
+	suite.Run(t, new(RoyaltyReportScheduleTestSuite))
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant = &billingpb.Merchant{
+		Id:      primitive.NewObjectID().Hex(),
+		Company: &billingpb.MerchantCompanyInfo{Name: "Unit test", Country: "RU"},
+		Status:  billingpb.MerchantStatusAgreementSigned,
+	}
+
+	if err := suite.service.merchantRepository.Insert(context.TODO(), suite.merchant); err != nil {
+		suite.FailNow("Insert merchant test data failed", "%v", err)
+	}
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_SetMerchantRoyaltySchedule_Ok() {
+	req := &pkg.SetMerchantRoyaltyScheduleRequest{
+		MerchantId: suite.merchant.Id,
+		Period:     pkg.RoyaltyReportSchedulePeriodMonthly,
+		AnchorDay:  1,
+		EndHour:    18,
+		Timezone:   "Europe/Berlin",
+	}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err := suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+	assert.NotNil(suite.T(), rsp.Item)
+	assert.NotNil(suite.T(), rsp.Item.StartsAt)
+
+	req1 := &pkg.GetMerchantRoyaltyScheduleRequest{MerchantId: suite.merchant.Id}
+	rsp1 := &pkg.MerchantRoyaltyScheduleResponse{}
+	err = suite.service.GetMerchantRoyaltySchedule(context.TODO(), req1, rsp1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp1.Status)
+	assert.Equal(suite.T(), req.Period, rsp1.Item.Period)
+	assert.Equal(suite.T(), req.AnchorDay, rsp1.Item.AnchorDay)
+	assert.Equal(suite.T(), req.EndHour, rsp1.Item.EndHour)
+	assert.Equal(suite.T(), req.Timezone, rsp1.Item.Timezone)
+
+	req.Period = pkg.RoyaltyReportSchedulePeriodWeekly
+	req.AnchorDay = int32(time.Friday)
+	req.Timezone = ""
+	err = suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	schedules, err := suite.service.royaltyScheduleRepository.GetAll(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), schedules, 1)
+	assert.Equal(suite.T(), pkg.RoyaltyReportSchedulePeriodWeekly, schedules[0].Period)
+	assert.Equal(suite.T(), suite.service.cfg.RoyaltyReportTimeZone, schedules[0].Timezone)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_SetMerchantRoyaltySchedule_StartsAtLastReport() {
+	periodTo := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	report := &billingpb.RoyaltyReport{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: suite.merchant.Id,
+		Currency:   "RUB",
+		Status:     billingpb.RoyaltyReportStatusPending,
+		Totals:     &billingpb.RoyaltyReportTotals{},
+		Summary:    &billingpb.RoyaltyReportSummary{},
+	}
+	report.PeriodFrom, _ = ptypes.TimestampProto(periodTo.AddDate(0, 0, -7))
+	report.PeriodTo, _ = ptypes.TimestampProto(periodTo)
+	report.CreatedAt = ptypes.TimestampNow()
+	err := suite.service.royaltyReportRepository.Insert(context.TODO(), report, "", pkg.RoyaltyReportChangeSourceAuto)
+	assert.NoError(suite.T(), err)
+
+	req := &pkg.SetMerchantRoyaltyScheduleRequest{
+		MerchantId: suite.merchant.Id,
+		Period:     pkg.RoyaltyReportSchedulePeriodBiWeekly,
+		AnchorDay:  int32(time.Monday),
+	}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err = suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusOk, rsp.Status)
+
+	startsAt, err := ptypes.Timestamp(rsp.Item.StartsAt)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), periodTo.Equal(startsAt))
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_SetMerchantRoyaltySchedule_MerchantNotFound() {
+	req := &pkg.SetMerchantRoyaltyScheduleRequest{
+		MerchantId: primitive.NewObjectID().Hex(),
+		Period:     pkg.RoyaltyReportSchedulePeriodWeekly,
+	}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err := suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), merchantErrorNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_SetMerchantRoyaltySchedule_TimezoneIncorrect() {
+	req := &pkg.SetMerchantRoyaltyScheduleRequest{
+		MerchantId: suite.merchant.Id,
+		Period:     pkg.RoyaltyReportSchedulePeriodWeekly,
+		Timezone:   "Unknown/Timezone",
+	}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err := suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyReportErrorTimezoneIncorrect, rsp.Message)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_SetMerchantRoyaltySchedule_PeriodInvalid() {
+	req := &pkg.SetMerchantRoyaltyScheduleRequest{
+		MerchantId: suite.merchant.Id,
+		Period:     "daily",
+	}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err := suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyScheduleErrorPeriodInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_SetMerchantRoyaltySchedule_AnchorDayInvalid() {
+	req := &pkg.SetMerchantRoyaltyScheduleRequest{
+		MerchantId: suite.merchant.Id,
+		Period:     pkg.RoyaltyReportSchedulePeriodWeekly,
+		AnchorDay:  7,
+	}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err := suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyScheduleErrorAnchorDayInvalid, rsp.Message)
+
+	req.Period = pkg.RoyaltyReportSchedulePeriodMonthly
+	req.AnchorDay = 31
+	err = suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyScheduleErrorAnchorDayInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_SetMerchantRoyaltySchedule_EndHourInvalid() {
+	req := &pkg.SetMerchantRoyaltyScheduleRequest{
+		MerchantId: suite.merchant.Id,
+		Period:     pkg.RoyaltyReportSchedulePeriodWeekly,
+		EndHour:    24,
+	}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err := suite.service.SetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusBadData, rsp.Status)
+	assert.Equal(suite.T(), royaltyScheduleErrorEndHourInvalid, rsp.Message)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_GetMerchantRoyaltySchedule_NotFound() {
+	req := &pkg.GetMerchantRoyaltyScheduleRequest{MerchantId: suite.merchant.Id}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err := suite.service.GetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), royaltyScheduleErrorNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_ExtendedBillingService_GetMerchantRoyaltySchedule() {
+	req := &pkg.GetMerchantRoyaltyScheduleRequest{MerchantId: suite.merchant.Id}
+	rsp := &pkg.MerchantRoyaltyScheduleResponse{}
+	err := NewExtendedBillingService(suite.service).GetMerchantRoyaltySchedule(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), billingpb.ResponseStatusNotFound, rsp.Status)
+	assert.Equal(suite.T(), royaltyScheduleErrorNotFound, rsp.Message)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_GetRoyaltySchedulePeriod_Weekly() {
+	schedule := &pkg.MerchantRoyaltySchedule{
+		Period:    pkg.RoyaltyReportSchedulePeriodWeekly,
+		AnchorDay: int32(time.Monday),
+		EndHour:   18,
+		Timezone:  "UTC",
+	}
+	// Wednesday
+	current := time.Date(2020, 3, 11, 12, 0, 0, 0, time.UTC)
+	period, err := getRoyaltySchedulePeriod(schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), time.Date(2020, 3, 9, 18, 0, 0, 0, time.UTC), period.to)
+	assert.Equal(suite.T(), time.Date(2020, 3, 2, 18, 0, 0, int(time.Millisecond), time.UTC), period.from)
+
+	// Monday before end hour - previous period is the last finished one
+	current = time.Date(2020, 3, 9, 17, 0, 0, 0, time.UTC)
+	period, err = getRoyaltySchedulePeriod(schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), time.Date(2020, 3, 2, 18, 0, 0, 0, time.UTC), period.to)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_GetRoyaltySchedulePeriod_BiWeekly() {
+	startsAt, _ := ptypes.TimestampProto(time.Date(2020, 3, 2, 18, 0, 0, 0, time.UTC))
+	schedule := &pkg.MerchantRoyaltySchedule{
+		Period:    pkg.RoyaltyReportSchedulePeriodBiWeekly,
+		AnchorDay: int32(time.Monday),
+		EndHour:   18,
+		Timezone:  "UTC",
+		StartsAt:  startsAt,
+	}
+	current := time.Date(2020, 3, 11, 12, 0, 0, 0, time.UTC)
+	period, err := getRoyaltySchedulePeriod(schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), time.Date(2020, 3, 2, 18, 0, 0, 0, time.UTC), period.to)
+	assert.Equal(suite.T(), time.Date(2020, 2, 17, 18, 0, 0, int(time.Millisecond), time.UTC), period.from)
+
+	current = time.Date(2020, 3, 18, 12, 0, 0, 0, time.UTC)
+	period, err = getRoyaltySchedulePeriod(schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), time.Date(2020, 3, 16, 18, 0, 0, 0, time.UTC), period.to)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_GetRoyaltySchedulePeriod_Monthly() {
+	schedule := &pkg.MerchantRoyaltySchedule{
+		Period:    pkg.RoyaltyReportSchedulePeriodMonthly,
+		AnchorDay: 1,
+		EndHour:   0,
+		Timezone:  "Europe/Moscow",
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	assert.NoError(suite.T(), err)
+
+	current := time.Date(2020, 3, 11, 12, 0, 0, 0, loc)
+	period, err := getRoyaltySchedulePeriod(schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), time.Date(2020, 3, 1, 0, 0, 0, 0, loc).Equal(period.to))
+	assert.True(suite.T(), time.Date(2020, 2, 1, 0, 0, 0, int(time.Millisecond), loc).Equal(period.from))
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_GetRoyaltySchedulePeriod_Error() {
+	schedule := &pkg.MerchantRoyaltySchedule{
+		Period:   pkg.RoyaltyReportSchedulePeriodWeekly,
+		Timezone: "Unknown/Timezone",
+	}
+	_, err := getRoyaltySchedulePeriod(schedule, time.Now())
+	assert.Equal(suite.T(), royaltyReportErrorTimezoneIncorrect, err)
+
+	schedule.Timezone = "UTC"
+	schedule.Period = "daily"
+	_, err = getRoyaltySchedulePeriod(schedule, time.Now())
+	assert.Equal(suite.T(), royaltyScheduleErrorPeriodInvalid, err)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_GetScheduledRoyaltyReportPeriod_GapFree() {
+	schedule := &pkg.MerchantRoyaltySchedule{
+		MerchantId: suite.merchant.Id,
+		Period:     pkg.RoyaltyReportSchedulePeriodMonthly,
+		AnchorDay:  1,
+		EndHour:    0,
+		Timezone:   "UTC",
+	}
+	current := time.Date(2020, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	period, err := suite.service.getScheduledRoyaltyReportPeriod(context.TODO(), schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), time.Date(2020, 2, 1, 0, 0, 0, int(time.Millisecond), time.UTC), period.from)
+
+	// the last weekly report was closed in the middle of month, so next monthly period starts right after it
+	lastTo := time.Date(2020, 2, 17, 0, 0, 0, 0, time.UTC)
+	report := &billingpb.RoyaltyReport{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: suite.merchant.Id,
+		Currency:   "RUB",
+		Status:     billingpb.RoyaltyReportStatusPending,
+		Totals:     &billingpb.RoyaltyReportTotals{},
+		Summary:    &billingpb.RoyaltyReportSummary{},
+		CreatedAt:  ptypes.TimestampNow(),
+	}
+	report.PeriodFrom, _ = ptypes.TimestampProto(lastTo.AddDate(0, 0, -7))
+	report.PeriodTo, _ = ptypes.TimestampProto(lastTo)
+	err = suite.service.royaltyReportRepository.Insert(context.TODO(), report, "", pkg.RoyaltyReportChangeSourceAuto)
+	assert.NoError(suite.T(), err)
+
+	period, err = suite.service.getScheduledRoyaltyReportPeriod(context.TODO(), schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), lastTo.Add(time.Millisecond).Equal(period.from))
+	assert.True(suite.T(), time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC).Equal(period.to))
+
+	// the period is already covered by existing report
+	report.Id = primitive.NewObjectID().Hex()
+	report.PeriodFrom, _ = ptypes.TimestampProto(lastTo)
+	report.PeriodTo, _ = ptypes.TimestampProto(time.Date(2020, 3, 5, 0, 0, 0, 0, time.UTC))
+	err = suite.service.royaltyReportRepository.Insert(context.TODO(), report, "", pkg.RoyaltyReportChangeSourceAuto)
+	assert.NoError(suite.T(), err)
+
+	period, err = suite.service.getScheduledRoyaltyReportPeriod(context.TODO(), schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), period)
+}
+
+func (suite *RoyaltyReportScheduleTestSuite) TestRoyaltyReportSchedule_GetScheduledRoyaltyReportPeriod_ExistingReport() {
+	schedule := &pkg.MerchantRoyaltySchedule{
+		MerchantId: suite.merchant.Id,
+		Period:     pkg.RoyaltyReportSchedulePeriodMonthly,
+		AnchorDay:  1,
+		EndHour:    0,
+		Timezone:   "UTC",
+	}
+	current := time.Date(2020, 3, 11, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2020, 2, 1, 0, 0, 0, int(time.Millisecond), time.UTC)
+	to := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	report := &billingpb.RoyaltyReport{
+		Id:         primitive.NewObjectID().Hex(),
+		MerchantId: suite.merchant.Id,
+		Currency:   "RUB",
+		Status:     billingpb.RoyaltyReportStatusPending,
+		Totals:     &billingpb.RoyaltyReportTotals{},
+		Summary:    &billingpb.RoyaltyReportSummary{},
+		CreatedAt:  ptypes.TimestampNow(),
+	}
+	report.PeriodFrom, _ = ptypes.TimestampProto(from)
+	report.PeriodTo, _ = ptypes.TimestampProto(to)
+	err := suite.service.royaltyReportRepository.Insert(context.TODO(), report, "", pkg.RoyaltyReportChangeSourceAuto)
+	assert.NoError(suite.T(), err)
+
+	// pending report of the period is updated
+	period, err := suite.service.getScheduledRoyaltyReportPeriod(context.TODO(), schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), period)
+	assert.True(suite.T(), from.Equal(period.from))
+	assert.True(suite.T(), to.Equal(period.to))
+
+	// report of the period was reviewed by the merchant and can't be updated anymore
+	report.Status = billingpb.RoyaltyReportStatusAccepted
+	err = suite.service.royaltyReportRepository.Update(context.TODO(), report, "", pkg.RoyaltyReportChangeSourceAuto)
+	assert.NoError(suite.T(), err)
+
+	period, err = suite.service.getScheduledRoyaltyReportPeriod(context.TODO(), schedule, current)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), period)
+}
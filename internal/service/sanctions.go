@@ -0,0 +1,748 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	sanctionsListFileExtension      = ".csv"
+	sanctionsListInsertBatchSize    = 1000
+	sanctionsListValuesSeparator    = ";"
+	sanctionsNameKeyLength          = 3
+	sanctionsCountryMismatchPenalty = 0.1
+	sanctionsJaroWinklerPrefixScale = 0.1
+	sanctionsJaroWinklerPrefixMax   = 4
+)
+
+var (
+	errorSanctionsScreeningNotFound        = newBillingServerErrorMsg("sn000001", "sanctions screening not found")
+	errorSanctionsScreeningAlreadyResolved = newBillingServerErrorMsg("sn000002", "sanctions screening is already resolved")
+	errorSanctionsScreeningStatusInvalid   = newBillingServerErrorMsg("sn000003", "resolution status of sanctions screening is invalid")
+	errorSanctionsScreeningCommentRequired = newBillingServerErrorMsg("sn000004", "comment is required to resolve sanctions screening")
+	errorSanctionsScreeningHold            = newBillingServerErrorMsg("sn000005", "merchant has unresolved potential matches of sanctions screening")
+	errorSanctionsScreeningUnknown         = newBillingServerErrorMsg("sn000006", "unknown error with sanctions screening")
+
+	sanctionsListSources = map[string]bool{
+		pkg.SanctionsListSourceOfac: true,
+		pkg.SanctionsListSourceEu:   true,
+		pkg.SanctionsListSourceUn:   true,
+		pkg.SanctionsListSourcePep:  true,
+	}
+
+	// screenings with these statuses hold status changes of the merchant and payouts
+	sanctionsScreeningHoldStatuses = []string{
+		pkg.SanctionsScreeningStatusPotentialMatch,
+		pkg.SanctionsScreeningStatusConfirmed,
+	}
+
+	// legal forms and common words are ignored in names of companies
+	sanctionsNameStopWords = map[string]bool{
+		"the": true, "of": true, "and": true, "co": true, "company": true, "corp": true, "corporation": true,
+		"inc": true, "incorporated": true, "llc": true, "llp": true, "ltd": true, "limited": true, "plc": true,
+		"gmbh": true, "ag": true, "sa": true, "srl": true, "bv": true, "ooo": true, "oao": true, "zao": true,
+		"pao": true, "jsc": true, "pjsc": true,
+	}
+)
+
+// ListSanctionsScreenings returns sanctions screenings of merchants, the latest screening is the first.
+func (s *Service) ListSanctionsScreenings(
+	ctx context.Context,
+	req *pkg.ListSanctionsScreeningsRequest,
+	res *pkg.ListSanctionsScreeningsResponse,
+) error {
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	var statuses []string
+
+	if req.Status != "" {
+		statuses = []string{req.Status}
+	}
+
+	count, err := s.sanctionsScreeningRepository.FindCount(ctx, req.MerchantId, statuses)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorSanctionsScreeningUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count <= 0 {
+		return nil
+	}
+
+	res.Items, err = s.sanctionsScreeningRepository.Find(ctx, req.MerchantId, statuses, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorSanctionsScreeningUnknown
+		res.Count = 0
+		return nil
+	}
+
+	return nil
+}
+
+// ScreenMerchant screens the merchant again, for example after the update of sanctions lists.
+func (s *Service) ScreenMerchant(
+	ctx context.Context,
+	req *pkg.ScreenMerchantRequest,
+	res *pkg.SanctionsScreeningResponse,
+) error {
+	merchant, err := s.merchantRepository.GetById(ctx, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = merchantErrorNotFound
+		return nil
+	}
+
+	screening, err := s.screenMerchant(ctx, merchant, pkg.SanctionsScreeningTriggerManual, req.UserId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorSanctionsScreeningUnknown
+		return nil
+	}
+
+	if screening == nil {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorSanctionsScreeningNotFound
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = screening
+
+	return nil
+}
+
+// ResolveSanctionsScreening clears false positive matches of the screening or confirms true matches.
+// Status changes of the merchant and payouts are released when all screenings of the merchant are cleared.
+func (s *Service) ResolveSanctionsScreening(
+	ctx context.Context,
+	req *pkg.ResolveSanctionsScreeningRequest,
+	res *pkg.SanctionsScreeningResponse,
+) error {
+	if req.Status != pkg.SanctionsScreeningStatusCleared && req.Status != pkg.SanctionsScreeningStatusConfirmed {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorSanctionsScreeningStatusInvalid
+		return nil
+	}
+
+	if strings.TrimSpace(req.Comment) == "" {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorSanctionsScreeningCommentRequired
+		return nil
+	}
+
+	screening, err := s.sanctionsScreeningRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorSanctionsScreeningNotFound
+		return nil
+	}
+
+	if screening.Status != pkg.SanctionsScreeningStatusPotentialMatch {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorSanctionsScreeningAlreadyResolved
+		return nil
+	}
+
+	screening.Status = req.Status
+	screening.ResolvedBy = req.UserId
+	screening.ResolvedAt = ptypes.TimestampNow()
+	screening.UpdatedAt = ptypes.TimestampNow()
+	screening.History = append(screening.History, &pkg.SanctionsScreeningEvent{
+		Status:    req.Status,
+		UserId:    req.UserId,
+		Comment:   req.Comment,
+		CreatedAt: ptypes.TimestampNow(),
+	})
+
+	if err = s.sanctionsScreeningRepository.Upsert(ctx, screening); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorSanctionsScreeningUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = screening
+
+	return nil
+}
+
+// LoadSanctionsLists loads files of sanctions lists from the local directory. Entries of the list replace entries
+// of the previous load of the same source.
+func (s *Service) LoadSanctionsLists(ctx context.Context) (int, error) {
+	files, err := ioutil.ReadDir(s.cfg.SanctionsListsDir)
+
+	if err != nil {
+		zap.L().Error("Sanctions lists directory reading failed", zap.Error(err), zap.String("dir", s.cfg.SanctionsListsDir))
+		return 0, err
+	}
+
+	count := 0
+
+	for _, file := range files {
+		if file.IsDir() || strings.ToLower(filepath.Ext(file.Name())) != sanctionsListFileExtension {
+			continue
+		}
+
+		source := strings.ToLower(strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())))
+
+		if !sanctionsListSources[source] {
+			zap.L().Warn("Unknown source of sanctions list", zap.String("file", file.Name()))
+			continue
+		}
+
+		n, err := s.loadSanctionsListFile(ctx, source, filepath.Join(s.cfg.SanctionsListsDir, file.Name()))
+
+		if err != nil {
+			return count, err
+		}
+
+		count += n
+	}
+
+	return count, nil
+}
+
+func (s *Service) loadSanctionsListFile(ctx context.Context, source, path string) (int, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		zap.L().Error("Sanctions list file opening failed", zap.Error(err), zap.String("file", path))
+		return 0, err
+	}
+
+	defer f.Close()
+
+	version := time.Now().UnixNano()
+	entries, err := parseSanctionsList(source, f, version)
+
+	if err != nil {
+		zap.L().Error("Sanctions list file parsing failed", zap.Error(err), zap.String("file", path))
+		return 0, err
+	}
+
+	for i := 0; i < len(entries); i += sanctionsListInsertBatchSize {
+		j := i + sanctionsListInsertBatchSize
+
+		if j > len(entries) {
+			j = len(entries)
+		}
+
+		if err = s.sanctionsListEntryRepository.MultipleInsert(ctx, entries[i:j]); err != nil {
+			return 0, err
+		}
+	}
+
+	// entries of the previous load are removed only when the new version is loaded completely
+	if err = s.sanctionsListEntryRepository.DeletePreviousVersions(ctx, source, version); err != nil {
+		return 0, err
+	}
+
+	zap.L().Info("Sanctions list loaded", zap.String("source", source), zap.Int("count", len(entries)))
+
+	return len(entries), nil
+}
+
+// parseSanctionsList reads entries from CSV with columns id, type, name, aliases and countries,
+// the order of columns is defined by the header.
+func parseSanctionsList(source string, r io.Reader, version int64) ([]*pkg.SanctionsListEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+
+	for i, v := range header {
+		columns[strings.ToLower(strings.TrimSpace(v))] = i
+	}
+
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	var entries []*pkg.SanctionsListEntry
+
+	for {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &pkg.SanctionsListEntry{
+			Source:     source,
+			ExternalId: value(record, "id"),
+			Type:       strings.ToLower(value(record, "type")),
+			Name:       value(record, "name"),
+			Aliases:    splitSanctionsListValues(value(record, "aliases")),
+			Countries:  splitSanctionsListValues(strings.ToUpper(value(record, "countries"))),
+			Version:    version,
+			CreatedAt:  ptypes.TimestampNow(),
+		}
+
+		if entry.Name == "" {
+			continue
+		}
+
+		if entry.Type != pkg.SanctionsListEntryTypeIndividual {
+			entry.Type = pkg.SanctionsListEntryTypeEntity
+		}
+
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			entry.Keys = appendSanctionsNameKeys(entry.Keys, normalizeSanctionsName(name))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func splitSanctionsListValues(value string) []string {
+	var values []string
+
+	for _, v := range strings.Split(value, sanctionsListValuesSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// isMerchantSanctionsScreeningOnHold checks that the merchant has unresolved potential matches or confirmed matches
+// of the screening. The merchant is screened when rescreen is set or the merchant isn't screened yet.
+func (s *Service) isMerchantSanctionsScreeningOnHold(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	trigger, userId string,
+	rescreen bool,
+) (bool, error) {
+	count, err := s.sanctionsScreeningRepository.FindCount(ctx, merchant.Id, sanctionsScreeningHoldStatuses)
+
+	if err != nil {
+		return false, err
+	}
+
+	if count > 0 {
+		return true, nil
+	}
+
+	if !rescreen {
+		count, err = s.sanctionsScreeningRepository.FindCount(ctx, merchant.Id, nil)
+
+		if err != nil {
+			return false, err
+		}
+
+		if count > 0 {
+			return false, nil
+		}
+	}
+
+	screening, err := s.screenMerchant(ctx, merchant, trigger, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return screening != nil && screening.Status == pkg.SanctionsScreeningStatusPotentialMatch, nil
+}
+
+// screenMerchant matches names of the company, beneficial owners and the bank of the merchant with sanctions lists.
+// Matches cleared by compliance users before aren't reported again. Nothing is screened when the merchant
+// doesn't have names to screen.
+func (s *Service) screenMerchant(
+	ctx context.Context,
+	merchant *billingpb.Merchant,
+	trigger, userId string,
+) (*pkg.SanctionsScreening, error) {
+	subjects := getSanctionsScreeningSubjects(merchant)
+
+	if len(subjects) <= 0 {
+		return nil, nil
+	}
+
+	var keys []string
+	names := make([][]string, len(subjects))
+
+	for i, subject := range subjects {
+		names[i] = normalizeSanctionsName(subject.Name)
+		keys = appendSanctionsNameKeys(keys, names[i])
+	}
+
+	entries, err := s.sanctionsListEntryRepository.FindByKeys(ctx, keys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cleared, err := s.getClearedSanctionsMatches(ctx, merchant.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	screening := &pkg.SanctionsScreening{
+		MerchantId: merchant.Id,
+		Trigger:    trigger,
+		Subjects:   subjects,
+		Status:     pkg.SanctionsScreeningStatusClear,
+		CreatedAt:  ptypes.TimestampNow(),
+		UpdatedAt:  ptypes.TimestampNow(),
+	}
+
+	for i, subject := range subjects {
+		for _, entry := range entries {
+			match := s.matchSanctionsListEntry(subject, names[i], entry)
+
+			if match == nil || cleared[getSanctionsMatchKey(match)] {
+				continue
+			}
+
+			screening.Matches = append(screening.Matches, match)
+		}
+	}
+
+	if len(screening.Matches) > 0 {
+		screening.Status = pkg.SanctionsScreeningStatusPotentialMatch
+
+		sort.Slice(screening.Matches, func(i, j int) bool {
+			return screening.Matches[i].Score > screening.Matches[j].Score
+		})
+	}
+
+	screening.History = []*pkg.SanctionsScreeningEvent{
+		{Status: screening.Status, UserId: userId, CreatedAt: ptypes.TimestampNow()},
+	}
+
+	if err = s.sanctionsScreeningRepository.Upsert(ctx, screening); err != nil {
+		return nil, err
+	}
+
+	if screening.Status == pkg.SanctionsScreeningStatusPotentialMatch {
+		zap.L().Warn(
+			"Potential match of sanctions screening",
+			zap.String("merchant_id", merchant.Id),
+			zap.String("screening_id", screening.Id),
+			zap.String("trigger", trigger),
+		)
+	}
+
+	return screening, nil
+}
+
+// matchSanctionsListEntry returns the best match of the subject with the name or aliases of the entry
+// if the similarity of names reaches the threshold.
+func (s *Service) matchSanctionsListEntry(
+	subject *pkg.SanctionsScreeningSubject,
+	tokens []string,
+	entry *pkg.SanctionsListEntry,
+) *pkg.SanctionsScreeningMatch {
+	var match *pkg.SanctionsScreeningMatch
+
+	for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+		score := getSanctionsNameSimilarity(tokens, normalizeSanctionsName(name))
+
+		if subject.Country != "" && len(entry.Countries) > 0 && !helper.Contains(entry.Countries, subject.Country) {
+			score -= sanctionsCountryMismatchPenalty
+		}
+
+		if score < s.cfg.SanctionsScreeningThreshold || (match != nil && match.Score >= score) {
+			continue
+		}
+
+		match = &pkg.SanctionsScreeningMatch{
+			SubjectRole:    subject.Role,
+			SubjectName:    subject.Name,
+			Source:         entry.Source,
+			ExternalId:     entry.ExternalId,
+			EntryName:      name,
+			EntryCountries: entry.Countries,
+			Score:          score,
+		}
+	}
+
+	return match
+}
+
+func (s *Service) getClearedSanctionsMatches(ctx context.Context, merchantId string) (map[string]bool, error) {
+	statuses := []string{pkg.SanctionsScreeningStatusCleared}
+	screenings, err := s.sanctionsScreeningRepository.Find(ctx, merchantId, statuses, 0, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cleared := make(map[string]bool)
+
+	for _, screening := range screenings {
+		for _, match := range screening.Matches {
+			cleared[getSanctionsMatchKey(match)] = true
+		}
+	}
+
+	return cleared, nil
+}
+
+func getSanctionsMatchKey(match *pkg.SanctionsScreeningMatch) string {
+	name := strings.Join(normalizeSanctionsName(match.SubjectName), " ")
+	return strings.Join([]string{match.Source, match.ExternalId, match.SubjectRole, name}, ":")
+}
+
+// getSanctionsScreeningSubjects returns names of the merchant to screen. Payouts are paid to the company,
+// so the company is the beneficiary of the bank account.
+func getSanctionsScreeningSubjects(merchant *billingpb.Merchant) []*pkg.SanctionsScreeningSubject {
+	var subjects []*pkg.SanctionsScreeningSubject
+	country := merchant.GetCompany().GetCountry()
+
+	add := func(role, name, country string) {
+		name = strings.Join(strings.Fields(name), " ")
+
+		if name == "" {
+			return
+		}
+
+		for _, v := range subjects {
+			if v.Role == role && strings.EqualFold(v.Name, name) {
+				return
+			}
+		}
+
+		subjects = append(subjects, &pkg.SanctionsScreeningSubject{Role: role, Name: name, Country: country})
+	}
+
+	add(pkg.SanctionsScreeningSubjectCompany, merchant.GetCompany().GetName(), country)
+	add(pkg.SanctionsScreeningSubjectCompany, merchant.GetCompany().GetAlternativeName(), country)
+	add(pkg.SanctionsScreeningSubjectBeneficialOwner, merchant.GetContacts().GetAuthorized().GetName(), country)
+	add(
+		pkg.SanctionsScreeningSubjectBeneficialOwner,
+		merchant.GetUser().GetFirstName()+" "+merchant.GetUser().GetLastName(),
+		country,
+	)
+	add(pkg.SanctionsScreeningSubjectBank, merchant.GetBanking().GetName(), "")
+
+	return subjects
+}
+
+func isSanctionsScreeningSubjectsChanged(a, b []*pkg.SanctionsScreeningSubject) bool {
+	if len(a) != len(b) {
+		return true
+	}
+
+	for i := range a {
+		if *a[i] != *b[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeSanctionsName splits the name to lower case tokens without punctuation, legal forms of companies
+// are removed when the name has other tokens.
+func normalizeSanctionsName(name string) []string {
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var filtered []string
+
+	for _, v := range tokens {
+		if !sanctionsNameStopWords[v] {
+			filtered = append(filtered, v)
+		}
+	}
+
+	if len(filtered) <= 0 {
+		return tokens
+	}
+
+	return filtered
+}
+
+// appendSanctionsNameKeys adds prefixes of tokens used to find candidates for fuzzy matching,
+// names with typos in the first letters of all tokens aren't found.
+func appendSanctionsNameKeys(keys []string, tokens []string) []string {
+	for _, v := range tokens {
+		r := []rune(v)
+
+		if len(r) > sanctionsNameKeyLength {
+			r = r[:sanctionsNameKeyLength]
+		}
+
+		if key := string(r); !helper.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// getSanctionsNameSimilarity compares names with the Jaro-Winkler similarity. Names with different order
+// of tokens or with additional middle names are compared token by token.
+func getSanctionsNameSimilarity(a, b []string) float64 {
+	if len(a) <= 0 || len(b) <= 0 {
+		return 0
+	}
+
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+
+	score := getJaroWinklerSimilarity(strings.Join(sortedA, " "), strings.Join(sortedB, " "))
+
+	short, long := a, b
+
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+
+	if len(short) < 2 {
+		return score
+	}
+
+	sum := float64(0)
+
+	for _, v := range short {
+		best := float64(0)
+
+		for _, u := range long {
+			if similarity := getJaroWinklerSimilarity(v, u); similarity > best {
+				best = similarity
+			}
+		}
+
+		sum += best
+	}
+
+	if tokensScore := sum / float64(len(short)); tokensScore > score {
+		score = tokensScore
+	}
+
+	return score
+}
+
+func getJaroWinklerSimilarity(a, b string) float64 {
+	r1, r2 := []rune(a), []rune(b)
+
+	if len(r1) == 0 && len(r2) == 0 {
+		return 1
+	}
+
+	if len(r1) == 0 || len(r2) == 0 {
+		return 0
+	}
+
+	window := len(r1)
+
+	if len(r2) > window {
+		window = len(r2)
+	}
+
+	window = window/2 - 1
+
+	if window < 0 {
+		window = 0
+	}
+
+	matched1 := make([]bool, len(r1))
+	matched2 := make([]bool, len(r2))
+	matches := 0
+
+	for i := range r1 {
+		from, to := i-window, i+window+1
+
+		if from < 0 {
+			from = 0
+		}
+
+		if to > len(r2) {
+			to = len(r2)
+		}
+
+		for j := from; j < to; j++ {
+			if matched2[j] || r1[i] != r2[j] {
+				continue
+			}
+
+			matched1[i], matched2[j] = true, true
+			matches++
+			break
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+
+	for i := range r1 {
+		if !matched1[i] {
+			continue
+		}
+
+		for !matched2[j] {
+			j++
+		}
+
+		if r1[i] != r2[j] {
+			transpositions++
+		}
+
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(r1)) + m/float64(len(r2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+
+	for prefix < len(r1) && prefix < len(r2) && prefix < sanctionsJaroWinklerPrefixMax && r1[prefix] == r2[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*sanctionsJaroWinklerPrefixScale*(1-jaro)
+}
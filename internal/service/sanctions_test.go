@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type SanctionsTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant *billingpb.Merchant
+}
+
+func Test_Sanctions(t *testing.T) {
+	suite.Run(t, new(SanctionsTestSuite))
+}
+
+func (suite *SanctionsTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, _, _, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *SanctionsTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *SanctionsTestSuite) helperLoadSanctionsList(source, content string) {
+	entries, err := parseSanctionsList(source, strings.NewReader(content), time.Now().UnixNano())
+	assert.NoError(suite.T(), err)
+
+	err = suite.service.sanctionsListEntryRepository.MultipleInsert(context.TODO(), entries)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_ParseSanctionsList_Ok() {
+	content := "name,id,type,aliases,countries\n" +
+		"\"Unit Tests Ltd\",100,entity,\"Unit Testing; UT Group\",ru;by\n" +
+		"\"John Smith\",101,Individual,,\n" +
+		",102,entity,,\n"
+
+	entries, err := parseSanctionsList(pkg.SanctionsListSourceOfac, strings.NewReader(content), 1)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 2)
+
+	assert.Equal(suite.T(), pkg.SanctionsListSourceOfac, entries[0].Source)
+	assert.Equal(suite.T(), "100", entries[0].ExternalId)
+	assert.Equal(suite.T(), pkg.SanctionsListEntryTypeEntity, entries[0].Type)
+	assert.Equal(suite.T(), "Unit Tests Ltd", entries[0].Name)
+	assert.Equal(suite.T(), []string{"Unit Testing", "UT Group"}, entries[0].Aliases)
+	assert.Equal(suite.T(), []string{"RU", "BY"}, entries[0].Countries)
+	assert.Equal(suite.T(), []string{"uni", "tes", "ut", "gro"}, entries[0].Keys)
+	assert.EqualValues(suite.T(), 1, entries[0].Version)
+
+	assert.Equal(suite.T(), pkg.SanctionsListEntryTypeIndividual, entries[1].Type)
+	assert.Empty(suite.T(), entries[1].Aliases)
+	assert.Empty(suite.T(), entries[1].Countries)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_LoadSanctionsLists_Ok() {
+	dir, err := ioutil.TempDir("", "sanctions")
+	assert.NoError(suite.T(), err)
+	defer os.RemoveAll(dir)
+
+	suite.service.cfg.SanctionsListsDir = dir
+
+	err = ioutil.WriteFile(filepath.Join(dir, "un.csv"), []byte("id,name\n1,Unit Tests\n2,Other Name\n"), 0644)
+	assert.NoError(suite.T(), err)
+	err = ioutil.WriteFile(filepath.Join(dir, "unknown.csv"), []byte("id,name\n1,Unit Tests\n"), 0644)
+	assert.NoError(suite.T(), err)
+
+	count, err := suite.service.LoadSanctionsLists(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, count)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "un.csv"), []byte("id,name\n1,Unit Tests\n"), 0644)
+	assert.NoError(suite.T(), err)
+
+	count, err = suite.service.LoadSanctionsLists(context.TODO())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	// entries of the previous load are removed
+	entries, err := suite.service.sanctionsListEntryRepository.FindByKeys(context.TODO(), []string{"uni", "oth"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), pkg.SanctionsListSourceUn, entries[0].Source)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_LoadSanctionsLists_DirNotFound() {
+	suite.service.cfg.SanctionsListsDir = "/unknown/sanctions/dir"
+	_, err := suite.service.LoadSanctionsLists(context.TODO())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_GetSanctionsNameSimilarity() {
+	similarity := func(a, b string) float64 {
+		return getSanctionsNameSimilarity(normalizeSanctionsName(a), normalizeSanctionsName(b))
+	}
+
+	assert.EqualValues(suite.T(), 1, similarity("Unit Test LLC", "unit-test"))
+	assert.EqualValues(suite.T(), 1, similarity("Smith, John", "John Smith"))
+	assert.EqualValues(suite.T(), 1, similarity("John Smith", "John Michael Smith"))
+	assert.True(suite.T(), similarity("Jon Smyth", "John Smith") >= 0.88)
+	assert.True(suite.T(), similarity("Unit Test", "Global Trading") < 0.88)
+	assert.EqualValues(suite.T(), 0, similarity("", "John Smith"))
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_ScreenMerchant_Clear() {
+	suite.helperLoadSanctionsList(pkg.SanctionsListSourceOfac, "id,name\n1,Global Trading\n")
+
+	screening, err := suite.service.screenMerchant(
+		context.TODO(),
+		suite.merchant,
+		pkg.SanctionsScreeningTriggerOnboarding,
+		"user",
+	)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), screening)
+	assert.NotEmpty(suite.T(), screening.Id)
+	assert.Equal(suite.T(), pkg.SanctionsScreeningStatusClear, screening.Status)
+	assert.Empty(suite.T(), screening.Matches)
+	assert.NotEmpty(suite.T(), screening.Subjects)
+	assert.Len(suite.T(), screening.History, 1)
+	assert.Equal(suite.T(), "user", screening.History[0].UserId)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_ScreenMerchant_NoSubjects() {
+	screening, err := suite.service.screenMerchant(
+		context.TODO(),
+		&billingpb.Merchant{Id: primitive.NewObjectID().Hex()},
+		pkg.SanctionsScreeningTriggerOnboarding,
+		"",
+	)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), screening)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_ScreenMerchant_PotentialMatch() {
+	suite.helperLoadSanctionsList(
+		pkg.SanctionsListSourceEu,
+		"id,type,name,countries\n1,entity,Unit Tests,RU\n2,individual,Unit Test,IR\n3,entity,Bank Name,\n",
+	)
+
+	screening, err := suite.service.screenMerchant(
+		context.TODO(),
+		suite.merchant,
+		pkg.SanctionsScreeningTriggerPayout,
+		"",
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pkg.SanctionsScreeningStatusPotentialMatch, screening.Status)
+	assert.NotEmpty(suite.T(), screening.Matches)
+
+	roles := make(map[string]bool)
+
+	for i, match := range screening.Matches {
+		roles[match.SubjectRole] = true
+
+		if i > 0 {
+			assert.True(suite.T(), screening.Matches[i-1].Score >= match.Score)
+		}
+	}
+
+	assert.True(suite.T(), roles[pkg.SanctionsScreeningSubjectCompany])
+	assert.True(suite.T(), roles[pkg.SanctionsScreeningSubjectBank])
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_ResolveSanctionsScreening_Cleared() {
+	suite.helperLoadSanctionsList(pkg.SanctionsListSourcePep, "id,name\n1,Unit Test\n")
+
+	isOnHold, err := suite.service.isMerchantSanctionsScreeningOnHold(
+		context.TODO(),
+		suite.merchant,
+		pkg.SanctionsScreeningTriggerPayout,
+		"",
+		true,
+	)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), isOnHold)
+
+	list := &pkg.ListSanctionsScreeningsResponse{}
+	err = suite.service.ListSanctionsScreenings(
+		context.TODO(),
+		&pkg.ListSanctionsScreeningsRequest{
+			MerchantId: suite.merchant.Id,
+			Status:     pkg.SanctionsScreeningStatusPotentialMatch,
+		},
+		list,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, list.Status)
+	assert.EqualValues(suite.T(), 1, list.Count)
+
+	res := &pkg.SanctionsScreeningResponse{}
+	err = suite.service.ResolveSanctionsScreening(
+		context.TODO(),
+		&pkg.ResolveSanctionsScreeningRequest{
+			Id:      list.Items[0].Id,
+			Status:  pkg.SanctionsScreeningStatusCleared,
+			UserId:  "compliance",
+			Comment: "another person",
+		},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.SanctionsScreeningStatusCleared, res.Item.Status)
+	assert.Equal(suite.T(), "compliance", res.Item.ResolvedBy)
+	assert.NotNil(suite.T(), res.Item.ResolvedAt)
+	assert.Len(suite.T(), res.Item.History, 2)
+	assert.Equal(suite.T(), "another person", res.Item.History[1].Comment)
+
+	// cleared matches aren't reported by the next screening
+	isOnHold, err = suite.service.isMerchantSanctionsScreeningOnHold(
+		context.TODO(),
+		suite.merchant,
+		pkg.SanctionsScreeningTriggerPayout,
+		"",
+		true,
+	)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), isOnHold)
+
+	res = &pkg.SanctionsScreeningResponse{}
+	err = suite.service.ResolveSanctionsScreening(
+		context.TODO(),
+		&pkg.ResolveSanctionsScreeningRequest{
+			Id:      list.Items[0].Id,
+			Status:  pkg.SanctionsScreeningStatusConfirmed,
+			UserId:  "compliance",
+			Comment: "comment",
+		},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorSanctionsScreeningAlreadyResolved, res.Message)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_ResolveSanctionsScreening_Confirmed() {
+	suite.helperLoadSanctionsList(pkg.SanctionsListSourceUn, "id,name\n1,Unit Test\n")
+
+	screening, err := suite.service.screenMerchant(context.TODO(), suite.merchant, pkg.SanctionsScreeningTriggerManual, "")
+	assert.NoError(suite.T(), err)
+
+	res := &pkg.SanctionsScreeningResponse{}
+	err = suite.service.ResolveSanctionsScreening(
+		context.TODO(),
+		&pkg.ResolveSanctionsScreeningRequest{
+			Id:      screening.Id,
+			Status:  pkg.SanctionsScreeningStatusConfirmed,
+			UserId:  "compliance",
+			Comment: "true match",
+		},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	// confirmed matches hold the merchant permanently
+	isOnHold, err := suite.service.isMerchantSanctionsScreeningOnHold(
+		context.TODO(),
+		suite.merchant,
+		pkg.SanctionsScreeningTriggerPayout,
+		"",
+		false,
+	)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), isOnHold)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_ResolveSanctionsScreening_Error() {
+	res := &pkg.SanctionsScreeningResponse{}
+	err := suite.service.ResolveSanctionsScreening(
+		context.TODO(),
+		&pkg.ResolveSanctionsScreeningRequest{Id: primitive.NewObjectID().Hex(), Status: "unknown", Comment: "comment"},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorSanctionsScreeningStatusInvalid, res.Message)
+
+	res = &pkg.SanctionsScreeningResponse{}
+	err = suite.service.ResolveSanctionsScreening(
+		context.TODO(),
+		&pkg.ResolveSanctionsScreeningRequest{Id: primitive.NewObjectID().Hex(), Status: pkg.SanctionsScreeningStatusCleared},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorSanctionsScreeningCommentRequired, res.Message)
+
+	res = &pkg.SanctionsScreeningResponse{}
+	err = suite.service.ResolveSanctionsScreening(
+		context.TODO(),
+		&pkg.ResolveSanctionsScreeningRequest{
+			Id:      primitive.NewObjectID().Hex(),
+			Status:  pkg.SanctionsScreeningStatusCleared,
+			Comment: "comment",
+		},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorSanctionsScreeningNotFound, res.Message)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_IsMerchantSanctionsScreeningOnHold_ScreenedOnce() {
+	isOnHold, err := suite.service.isMerchantSanctionsScreeningOnHold(
+		context.TODO(),
+		suite.merchant,
+		pkg.SanctionsScreeningTriggerOnboarding,
+		"",
+		false,
+	)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), isOnHold)
+
+	// the list is loaded after the screening, the merchant isn't screened again without rescreen
+	suite.helperLoadSanctionsList(pkg.SanctionsListSourceOfac, "id,name\n1,Unit Test\n")
+
+	isOnHold, err = suite.service.isMerchantSanctionsScreeningOnHold(
+		context.TODO(),
+		suite.merchant,
+		pkg.SanctionsScreeningTriggerOnboarding,
+		"",
+		false,
+	)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), isOnHold)
+
+	res := &pkg.SanctionsScreeningResponse{}
+	err = suite.service.ScreenMerchant(context.TODO(), &pkg.ScreenMerchantRequest{MerchantId: suite.merchant.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), pkg.SanctionsScreeningTriggerManual, res.Item.Trigger)
+	assert.Equal(suite.T(), pkg.SanctionsScreeningStatusPotentialMatch, res.Item.Status)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_ChangeMerchantData_OnHold() {
+	suite.helperLoadSanctionsList(pkg.SanctionsListSourceOfac, "id,name\n1,Unit Test\n")
+
+	req := &billingpb.ChangeMerchantDataRequest{
+		MerchantId:           suite.merchant.Id,
+		HasMerchantSignature: true,
+	}
+	rsp := &billingpb.ChangeMerchantDataResponse{}
+	err := suite.service.ChangeMerchantData(context.TODO(), req, rsp)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusForbidden, rsp.Status)
+	assert.Equal(suite.T(), errorSanctionsScreeningHold, rsp.Message)
+
+	merchant, err := suite.service.merchantRepository.GetById(context.TODO(), suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), merchant.HasMerchantSignature)
+}
+
+func (suite *SanctionsTestSuite) TestSanctions_GetSanctionsScreeningSubjects() {
+	merchant := &billingpb.Merchant{
+		User:    &billingpb.MerchantUser{FirstName: "John", LastName: "Smith"},
+		Company: &billingpb.MerchantCompanyInfo{Name: "Unit  Test", AlternativeName: "unit test", Country: "RU"},
+		Banking: &billingpb.MerchantBanking{Name: "Bank"},
+		Contacts: &billingpb.MerchantContact{
+			Authorized: &billingpb.MerchantContactAuthorized{Name: "John Smith"},
+		},
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	subjects := getSanctionsScreeningSubjects(merchant)
+	assert.Equal(suite.T(), []*pkg.SanctionsScreeningSubject{
+		{Role: pkg.SanctionsScreeningSubjectCompany, Name: "Unit Test", Country: "RU"},
+		{Role: pkg.SanctionsScreeningSubjectBeneficialOwner, Name: "John Smith", Country: "RU"},
+		{Role: pkg.SanctionsScreeningSubjectBank, Name: "Bank"},
+	}, subjects)
+
+	assert.False(suite.T(), isSanctionsScreeningSubjectsChanged(subjects, getSanctionsScreeningSubjects(merchant)))
+
+	merchant.Banking.Name = "Another bank"
+	assert.True(suite.T(), isSanctionsScreeningSubjectsChanged(subjects, getSanctionsScreeningSubjects(merchant)))
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/internal/helper"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/currenciespb"
+	"go.uber.org/zap"
+)
+
+const (
+	scaExemptionCurrency = "EUR"
+)
+
+// setOrderScaDecision decides whether the bank card payment requires 3-D Secure challenge or frictionless
+// authentication, or an exemption from strong customer authentication is claimed. The decision is recorded
+// in private metadata of the order and passed to the payment system with requisites of the payment.
+func (s *Service) setOrderScaDecision(
+	ctx context.Context,
+	order *billingpb.Order,
+	data map[string]string,
+	assessment *pkg.RiskAssessment,
+	allowed bool,
+) {
+	if order.PaymentMethod == nil || !order.PaymentMethod.IsBankCard() {
+		return
+	}
+
+	decision := s.getScaDecision(ctx, order, data, assessment, allowed)
+	data[pkg.PaymentCreateFieldScaDecision] = decision
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataScaDecision] = decision
+}
+
+func (s *Service) getScaDecision(
+	ctx context.Context,
+	order *billingpb.Order,
+	data map[string]string,
+	assessment *pkg.RiskAssessment,
+	allowed bool,
+) string {
+	// decisions of the risk engine aren't applied to payments matched by the allow list
+	if !allowed && assessment != nil && assessment.Decision == pkg.RiskDecisionThreeDs {
+		return pkg.ScaDecisionChallenge
+	}
+
+	// payments initiated by the merchant with the stored card are out of scope of strong customer authentication
+	if data[pkg.PaymentCreateFieldMerchantInitiated] == "1" && data[billingpb.PaymentCreateFieldRecurringId] != "" {
+		return pkg.ScaDecisionExemptionMit
+	}
+
+	if !s.isScaRequired(order) {
+		return pkg.ScaDecisionOutOfScope
+	}
+
+	amount, err := s.getScaExemptionAmount(ctx, order)
+
+	if err != nil {
+		return pkg.ScaDecisionFrictionless
+	}
+
+	if amount <= s.cfg.ScaLowValueLimit {
+		return pkg.ScaDecisionExemptionLowValue
+	}
+
+	if assessment != nil && assessment.Score <= s.cfg.ScaTraMaxRiskScore && amount <= s.cfg.ScaTraLimit {
+		return pkg.ScaDecisionExemptionTra
+	}
+
+	return pkg.ScaDecisionFrictionless
+}
+
+// isScaRequired checks PSD2 applicability by the country of the card issuer from BIN data,
+// the country of the payment is used when the issuer is unknown.
+func (s *Service) isScaRequired(order *billingpb.Order) bool {
+	country := order.PaymentRequisites[billingpb.PaymentCreateBankCardFieldIssuerCountryIsoCode]
+
+	if country == "" {
+		country = order.GetCountry()
+	}
+
+	return helper.Contains(s.cfg.ScaCountries, country)
+}
+
+func (s *Service) getScaExemptionAmount(ctx context.Context, order *billingpb.Order) (float64, error) {
+	if order.ChargeCurrency == scaExemptionCurrency {
+		return order.ChargeAmount, nil
+	}
+
+	req := &currenciespb.ExchangeCurrencyCurrentCommonRequest{
+		From:              order.ChargeCurrency,
+		To:                scaExemptionCurrency,
+		RateType:          currenciespb.RateTypeOxr,
+		ExchangeDirection: currenciespb.ExchangeDirectionSell,
+		Amount:            order.ChargeAmount,
+	}
+	rsp, err := s.curService.ExchangeCurrencyCurrentCommon(ctx, req)
+
+	if err != nil {
+		zap.L().Error(
+			pkg.ErrorGrpcServiceCallFailed,
+			zap.Error(err),
+			zap.String(errorFieldService, "CurrencyRatesService"),
+			zap.String(errorFieldMethod, "ExchangeCurrencyCurrentCommon"),
+			zap.Any(errorFieldRequest, req),
+		)
+		return 0, err
+	}
+
+	return rsp.ExchangedAmount, nil
+}
+
+// setOrderLiabilityShift records whether the liability for the fraudulent payment is shifted to the issuer,
+// it's shifted when the bank card payment was authenticated by 3-D Secure.
+func (s *Service) setOrderLiabilityShift(order *billingpb.Order) {
+	if order.PaymentMethod == nil || !order.PaymentMethod.IsBankCard() {
+		return
+	}
+
+	if order.PrivateMetadata == nil {
+		order.PrivateMetadata = make(map[string]string)
+	}
+
+	order.PrivateMetadata[pkg.OrderPrivateMetadataLiabilityShift] = "0"
+
+	if order.PaymentMethodTxnParams[billingpb.TxnParamsFieldBankCardIs3DS] == "1" {
+		order.PrivateMetadata[pkg.OrderPrivateMetadataLiabilityShift] = "1"
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type ScaTestSuite struct {
+	suite.Suite
+	service *Service
+
+	project    *billingpb.Project
+	pmBankCard *billingpb.PaymentMethod
+}
+
+func Test_Sca(t *testing.T) {
+	suite.Run(t, new(ScaTestSuite))
+}
+
+func (suite *ScaTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	_, suite.project, suite.pmBankCard, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *ScaTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *ScaTestSuite) helperGetOrder(issuerCountry string, amount float64, currency string) *billingpb.Order {
+	return &billingpb.Order{
+		ChargeAmount:   amount,
+		ChargeCurrency: currency,
+		User: &billingpb.OrderUser{
+			Address: &billingpb.OrderBillingAddress{Country: "FR"},
+		},
+		PaymentMethod: &billingpb.PaymentMethodOrder{
+			Group:      "BANKCARD",
+			ExternalId: "BANKCARD",
+		},
+		PaymentRequisites: map[string]string{
+			billingpb.PaymentCreateBankCardFieldIssuerCountryIsoCode: issuerCountry,
+		},
+	}
+}
+
+func (suite *ScaTestSuite) TestSca_GetScaDecision_Challenge() {
+	order := suite.helperGetOrder("DE", 10, "EUR")
+	assessment := &pkg.RiskAssessment{Score: 60, Decision: pkg.RiskDecisionThreeDs}
+
+	decision := suite.service.getScaDecision(context.TODO(), order, map[string]string{}, assessment, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionChallenge, decision)
+
+	// risk decisions aren't applied to payments matched by the allow list
+	decision = suite.service.getScaDecision(context.TODO(), order, map[string]string{}, assessment, true)
+	assert.Equal(suite.T(), pkg.ScaDecisionExemptionLowValue, decision)
+}
+
+func (suite *ScaTestSuite) TestSca_GetScaDecision_ExemptionMit() {
+	order := suite.helperGetOrder("DE", 500, "EUR")
+	data := map[string]string{
+		pkg.PaymentCreateFieldMerchantInitiated: "1",
+		billingpb.PaymentCreateFieldRecurringId: "0987654321",
+	}
+
+	decision := suite.service.getScaDecision(context.TODO(), order, data, nil, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionExemptionMit, decision)
+
+	// merchant initiated payment requires the stored card
+	delete(data, billingpb.PaymentCreateFieldRecurringId)
+	decision = suite.service.getScaDecision(context.TODO(), order, data, nil, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionFrictionless, decision)
+}
+
+func (suite *ScaTestSuite) TestSca_GetScaDecision_OutOfScope() {
+	order := suite.helperGetOrder("US", 500, "EUR")
+	decision := suite.service.getScaDecision(context.TODO(), order, map[string]string{}, nil, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionOutOfScope, decision)
+
+	// country of the payment is used when the issuer is unknown
+	order = suite.helperGetOrder("", 500, "EUR")
+	decision = suite.service.getScaDecision(context.TODO(), order, map[string]string{}, nil, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionFrictionless, decision)
+}
+
+func (suite *ScaTestSuite) TestSca_GetScaDecision_ExemptionLowValue() {
+	order := suite.helperGetOrder("DE", 30, "EUR")
+	decision := suite.service.getScaDecision(context.TODO(), order, map[string]string{}, nil, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionExemptionLowValue, decision)
+
+	// amount is converted to EUR, 1440 RUB is 20 EUR by rates of the currency service mock
+	order = suite.helperGetOrder("DE", 1440, "RUB")
+	decision = suite.service.getScaDecision(context.TODO(), order, map[string]string{}, nil, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionExemptionLowValue, decision)
+}
+
+func (suite *ScaTestSuite) TestSca_GetScaDecision_ExemptionTra() {
+	order := suite.helperGetOrder("DE", 80, "EUR")
+	assessment := &pkg.RiskAssessment{Score: 5, Decision: pkg.RiskDecisionAllow}
+
+	decision := suite.service.getScaDecision(context.TODO(), order, map[string]string{}, assessment, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionExemptionTra, decision)
+
+	assessment.Score = 30
+	decision = suite.service.getScaDecision(context.TODO(), order, map[string]string{}, assessment, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionFrictionless, decision)
+
+	// risk score is unknown without the assessment
+	decision = suite.service.getScaDecision(context.TODO(), order, map[string]string{}, nil, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionFrictionless, decision)
+
+	assessment.Score = 5
+	order.ChargeAmount = 150
+	decision = suite.service.getScaDecision(context.TODO(), order, map[string]string{}, assessment, false)
+	assert.Equal(suite.T(), pkg.ScaDecisionFrictionless, decision)
+}
+
+func (suite *ScaTestSuite) TestSca_SetOrderScaDecision_NotBankCard() {
+	order := suite.helperGetOrder("DE", 500, "EUR")
+	order.PaymentMethod.Group = "QIWI"
+	order.PaymentMethod.ExternalId = "QIWI"
+	data := map[string]string{}
+
+	suite.service.setOrderScaDecision(context.TODO(), order, data, nil, false)
+	assert.Empty(suite.T(), data[pkg.PaymentCreateFieldScaDecision])
+	assert.Empty(suite.T(), order.PrivateMetadata[pkg.OrderPrivateMetadataScaDecision])
+}
+
+func (suite *ScaTestSuite) TestSca_PaymentFlow_Ok() {
+	order := HelperCreateAndPayOrder(suite.Suite, suite.service, 100, "RUB", "RU", suite.project, suite.pmBankCard)
+	assert.NotEmpty(suite.T(), order.PrivateMetadata[pkg.OrderPrivateMetadataScaDecision])
+
+	// payment is authenticated by 3-D Secure in the payment system mock
+	assert.Equal(suite.T(), "1", order.PrivateMetadata[pkg.OrderPrivateMetadataLiabilityShift])
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/go-redis/redis"
 	"github.com/paysuper/paysuper-billing-server/internal/config"
 	"github.com/paysuper/paysuper-billing-server/internal/database"
+	"github.com/paysuper/paysuper-billing-server/internal/kms"
 	"github.com/paysuper/paysuper-billing-server/internal/repository"
+	"github.com/paysuper/paysuper-billing-server/internal/repository/models"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-i18n"
 	"github.com/paysuper/paysuper-proto/go/billingpb"
@@ -97,12 +99,45 @@ type Service struct {
 	paymentMinLimitSystemRepository        repository.PaymentMinLimitSystemRepositoryInterface
 	keyRepository                          repository.KeyRepositoryInterface
 	keyProductRepository                   repository.KeyProductRepositoryInterface
+	merchantDataKeyRepository              repository.MerchantDataKeyRepositoryInterface
+	keyAuditRepository                     repository.KeyAuditRepositoryInterface
+	keyStockThresholdRepository            repository.KeyStockThresholdRepositoryInterface
+	keyImportJobRepository                 repository.KeyImportJobRepositoryInterface
+	keyImportChunkRepository               repository.KeyImportChunkRepositoryInterface
+	keyReservationPolicyRepository         repository.KeyReservationPolicyRepositoryInterface
+	keyProductReleaseRepository            repository.KeyProductReleaseRepositoryInterface
+	keyPreOrderRepository                  repository.KeyPreOrderRepositoryInterface
+	bundleRepository                       repository.BundleRepositoryInterface
+	promoRepository                        repository.PromoRepositoryInterface
+	promoUsageRepository                   repository.PromoUsageRepositoryInterface
+	priceScheduleRepository                repository.PriceScheduleRepositoryInterface
+	priceHistoryRepository                 repository.PriceHistoryRepositoryInterface
+	priceTableRuleRepository               repository.PriceTableRuleRepositoryInterface
+	priceTableVersionRepository            repository.PriceTableVersionRepositoryInterface
+	paylinkVariantRepository               repository.PaylinkVariantRepositoryInterface
+	paylinkFunnelEventRepository           repository.PaylinkFunnelEventRepositoryInterface
+	riskRuleRepository                     repository.RiskRuleRepositoryInterface
+	riskAssessmentRepository               repository.RiskAssessmentRepositoryInterface
+	velocityLimitRepository                repository.VelocityLimitRepositoryInterface
+	velocityLimitEventRepository           repository.VelocityLimitEventRepositoryInterface
+	blockListRepository                    repository.BlockListRepositoryInterface
+	orderReviewRepository                  repository.OrderReviewRepositoryInterface
+	sanctionsListEntryRepository           repository.SanctionsListEntryRepositoryInterface
+	sanctionsScreeningRepository           repository.SanctionsScreeningRepositoryInterface
+	cardVaultTokenRepository               repository.CardVaultTokenRepositoryInterface
+	cardUpdaterFileRepository              repository.CardUpdaterFileRepositoryInterface
+	merchantRiskReviewRepository           repository.MerchantRiskReviewRepositoryInterface
+	kms                                    kms.KmsInterface
 	productRepository                      repository.ProductRepositoryInterface
 	paylinkRepository                      repository.PaylinkRepositoryInterface
 	paylinkVisitsRepository                repository.PaylinkVisitRepositoryInterface
 	royaltyReportRepository                repository.RoyaltyReportRepositoryInterface
+	royaltyScheduleRepository              repository.MerchantRoyaltyScheduleRepositoryInterface
+	royaltyDisputeRepository               repository.RoyaltyReportDisputeRepositoryInterface
 	vatReportRepository                    repository.VatReportRepositoryInterface
 	payoutRepository                       repository.PayoutRepositoryInterface
+	payoutBatchRepository                  repository.PayoutBatchRepositoryInterface
+	merchantBankingChangeRepository        repository.MerchantBankingChangeRepositoryInterface
 	customerRepository                     repository.CustomerRepositoryInterface
 	accountingRepository                   repository.AccountingEntryRepositoryInterface
 	merchantTariffsSettingsRepository      repository.MerchantTariffsSettingsInterface
@@ -171,6 +206,43 @@ func (s *Service) Init() (err error) {
 	s.centrifugoDashboard = newCentrifugo(s.cfg.CentrifugoDashboard, httpTools.NewLoggedHttpClient(zap.S()))
 	s.paymentSystemGateway = s.newPaymentSystemGateway()
 
+	s.kms = nil
+
+	if len(s.cfg.KeyCodeEncryption.MasterKeys) > 0 {
+		localKms, err := kms.NewLocalKms(s.cfg.KeyCodeEncryption.MasterKeys, s.cfg.KeyCodeEncryption.MasterKeyId)
+
+		if err != nil {
+			zap.L().Error("Key management service initialization failed", zap.Error(err))
+			return err
+		}
+
+		s.kms = localKms
+	} else {
+		zap.L().Warn(
+			"Key codes encryption is disabled, master keys are not set, game activation keys are stored in plaintext",
+			zap.String("environment", s.cfg.Environment),
+		)
+	}
+
+	models.SetFieldCipher(nil, nil)
+
+	if len(s.cfg.PersonalDataEncryption.Keys) > 0 {
+		fieldCipher, err := kms.NewFieldCipher(
+			s.cfg.PersonalDataEncryption.Keys,
+			s.cfg.PersonalDataEncryption.KeyId,
+			s.cfg.PersonalDataEncryption.IndexSecret,
+		)
+
+		if err != nil {
+			zap.L().Error("Personal data encryption initialization failed", zap.Error(err))
+			return err
+		}
+
+		models.SetFieldCipher(fieldCipher, s.cfg.PersonalDataEncryption.Fields)
+	} else {
+		zap.L().Warn("Personal data encryption is disabled, keys are not set, bank cards aren't saved to the card vault")
+	}
+
 	s.refundRepository = repository.NewRefundRepository(s.db)
 	s.orderRepository = repository.NewOrderRepository(s.db)
 	s.country = repository.NewCountryRepository(s.db, s.cacher)
@@ -197,12 +269,44 @@ func (s *Service) Init() (err error) {
 	s.paymentMinLimitSystemRepository = repository.NewPaymentMinLimitSystemRepository(s.db, s.cacher)
 	s.keyRepository = repository.NewKeyRepository(s.db)
 	s.keyProductRepository = repository.NewKeyProductRepository(s.db)
+	s.merchantDataKeyRepository = repository.NewMerchantDataKeyRepository(s.db)
+	s.keyAuditRepository = repository.NewKeyAuditRepository(s.db)
+	s.keyStockThresholdRepository = repository.NewKeyStockThresholdRepository(s.db)
+	s.keyImportJobRepository = repository.NewKeyImportJobRepository(s.db)
+	s.keyImportChunkRepository = repository.NewKeyImportChunkRepository(s.db)
+	s.keyReservationPolicyRepository = repository.NewKeyReservationPolicyRepository(s.db)
+	s.keyProductReleaseRepository = repository.NewKeyProductReleaseRepository(s.db)
+	s.keyPreOrderRepository = repository.NewKeyPreOrderRepository(s.db)
+	s.bundleRepository = repository.NewBundleRepository(s.db)
+	s.promoRepository = repository.NewPromoRepository(s.db)
+	s.promoUsageRepository = repository.NewPromoUsageRepository(s.db)
+	s.priceScheduleRepository = repository.NewPriceScheduleRepository(s.db)
+	s.priceHistoryRepository = repository.NewPriceHistoryRepository(s.db)
+	s.priceTableRuleRepository = repository.NewPriceTableRuleRepository(s.db)
+	s.priceTableVersionRepository = repository.NewPriceTableVersionRepository(s.db)
+	s.paylinkVariantRepository = repository.NewPaylinkVariantRepository(s.db)
+	s.paylinkFunnelEventRepository = repository.NewPaylinkFunnelEventRepository(s.db)
+	s.riskRuleRepository = repository.NewRiskRuleRepository(s.db)
+	s.riskAssessmentRepository = repository.NewRiskAssessmentRepository(s.db)
+	s.velocityLimitRepository = repository.NewVelocityLimitRepository(s.db)
+	s.velocityLimitEventRepository = repository.NewVelocityLimitEventRepository(s.db)
+	s.blockListRepository = repository.NewBlockListRepository(s.db)
+	s.orderReviewRepository = repository.NewOrderReviewRepository(s.db)
+	s.sanctionsListEntryRepository = repository.NewSanctionsListEntryRepository(s.db)
+	s.sanctionsScreeningRepository = repository.NewSanctionsScreeningRepository(s.db)
+	s.cardVaultTokenRepository = repository.NewCardVaultTokenRepository(s.db)
+	s.cardUpdaterFileRepository = repository.NewCardUpdaterFileRepository(s.db)
+	s.merchantRiskReviewRepository = repository.NewMerchantRiskReviewRepository(s.db)
 	s.productRepository = repository.NewProductRepository(s.db, s.cacher)
 	s.paylinkRepository = repository.NewPaylinkRepository(s.db, s.cacher)
 	s.paylinkVisitsRepository = repository.NewPaylinkVisitRepository(s.db)
 	s.royaltyReportRepository = repository.NewRoyaltyReportRepository(s.db, s.cacher)
+	s.royaltyScheduleRepository = repository.NewMerchantRoyaltyScheduleRepository(s.db)
+	s.royaltyDisputeRepository = repository.NewRoyaltyReportDisputeRepository(s.db)
 	s.vatReportRepository = repository.NewVatReportRepository(s.db)
 	s.payoutRepository = repository.NewPayoutRepository(s.db, s.cacher)
+	s.payoutBatchRepository = repository.NewPayoutBatchRepository(s.db)
+	s.merchantBankingChangeRepository = repository.NewMerchantBankingChangeRepository(s.db)
 	s.customerRepository = repository.NewCustomerRepository(s.db)
 	s.accountingRepository = repository.NewAccountingEntryRepository(s.db)
 	s.merchantTariffsSettingsRepository = repository.NewMerchantTariffsSettingsRepository(s.db, s.cacher)
@@ -113,7 +113,8 @@ func (s *Service) CreateToken(
 		return nil
 	}
 
-	if req.Settings.Type == pkg.OrderType_product || req.Settings.Type == pkg.OrderType_key {
+	if req.Settings.Type == pkg.OrderType_product || req.Settings.Type == pkg.OrderType_key ||
+		req.Settings.Type == pkg.OrderTypeMixed {
 		if req.Settings.Amount > 0 || req.Settings.Currency != "" {
 			rsp.Status = billingpb.ResponseStatusBadData
 			rsp.Message = tokenErrorSettingsAmountAndCurrencyParamNotAllowedForType
@@ -196,6 +197,22 @@ func (s *Service) CreateToken(
 	case pkg.OrderType_key:
 		err = processor.processPaylinkKeyProducts()
 
+		if err != nil {
+			rsp.Status = billingpb.ResponseStatusBadData
+			rsp.Message = tokenErrorUnknown
+
+			e, ok := err.(*billingpb.ResponseErrorMessage)
+
+			if ok {
+				rsp.Message = e
+			}
+
+			return nil
+		}
+		break
+	case pkg.OrderTypeMixed:
+		err = processor.processPaylinkCartItems()
+
 		if err != nil {
 			rsp.Status = billingpb.ResponseStatusBadData
 			rsp.Message = tokenErrorUnknown
@@ -372,6 +389,7 @@ func (s *Service) updateCustomer(
 	customer *billingpb.Customer,
 ) (*billingpb.Customer, error) {
 	s.processCustomer(req, project, customer)
+	customer.UpdatedAt = ptypes.TimestampNow()
 
 	if err := s.customerRepository.Update(ctx, customer); err != nil {
 		return nil, tokenErrorUnknown
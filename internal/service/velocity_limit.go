@@ -0,0 +1,486 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/go-redis/redis"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/paysuper/paysuper-proto/go/currenciespb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// velocityLimitStorageKeyPrefix is a prefix of sorted sets of payment attempts scored by the time of the attempt.
+	// Members of sets are in format order_id|merchant_id|payment_method_id|amount|currency.
+	velocityLimitStorageKeyPrefix  = "velocity:limit:"
+	velocityLimitMemberSeparator   = "|"
+	velocityLimitMemberPartsLength = 5
+)
+
+var (
+	errorVelocityLimitNotFound         = newBillingServerErrorMsg("vl000001", "velocity limit not found")
+	errorVelocityLimitKeyInvalid       = newBillingServerErrorMsg("vl000002", "velocity limit key is invalid")
+	errorVelocityLimitWindowInvalid    = newBillingServerErrorMsg("vl000003", "velocity limit window must be positive and not greater than maximal window")
+	errorVelocityLimitValuesInvalid    = newBillingServerErrorMsg("vl000004", "velocity limit must have positive maximal count or amount")
+	errorVelocityLimitCurrencyInvalid  = newBillingServerErrorMsg("vl000005", "velocity limit currency is required for maximal amount")
+	errorVelocityLimitUnknown          = newBillingServerErrorMsg("vl000006", "unknown error with velocity limit")
+	errorVelocityLimitCardExceeded     = newBillingServerErrorMsg("vl000007", "payments limit for the bank card is exceeded")
+	errorVelocityLimitCustomerExceeded = newBillingServerErrorMsg("vl000008", "payments limit for the customer is exceeded")
+	errorVelocityLimitIpExceeded       = newBillingServerErrorMsg("vl000009", "payments limit for the ip address is exceeded")
+	errorVelocityLimitEmailExceeded    = newBillingServerErrorMsg("vl000010", "payments limit for the email is exceeded")
+	errorVelocityLimitProjectExceeded  = newBillingServerErrorMsg("vl000011", "payments limit for the project is exceeded")
+
+	velocityLimitExceededErrors = map[string]*billingpb.ResponseErrorMessage{
+		pkg.VelocityLimitKeyCard:     errorVelocityLimitCardExceeded,
+		pkg.VelocityLimitKeyCustomer: errorVelocityLimitCustomerExceeded,
+		pkg.VelocityLimitKeyIp:       errorVelocityLimitIpExceeded,
+		pkg.VelocityLimitKeyEmail:    errorVelocityLimitEmailExceeded,
+		pkg.VelocityLimitKeyProject:  errorVelocityLimitProjectExceeded,
+	}
+)
+
+type velocityLimitAttempt struct {
+	orderId         string
+	merchantId      string
+	paymentMethodId string
+	amount          float64
+	currency        string
+	time            int64
+}
+
+// CreateOrUpdateVelocityLimit creates or modifies the velocity limit of payments
+func (s *Service) CreateOrUpdateVelocityLimit(
+	ctx context.Context,
+	req *pkg.CreateOrUpdateVelocityLimitRequest,
+	res *pkg.VelocityLimitResponse,
+) error {
+	limit := &pkg.VelocityLimit{
+		Id:        primitive.NewObjectID().Hex(),
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	if req.Id != "" {
+		var err error
+		limit, err = s.velocityLimitRepository.GetById(ctx, req.Id)
+
+		if err != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = errorVelocityLimitNotFound
+			return nil
+		}
+	}
+
+	if _, ok := velocityLimitExceededErrors[req.Key]; !ok {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorVelocityLimitKeyInvalid
+		return nil
+	}
+
+	if req.Window <= 0 || req.Window > s.cfg.VelocityLimitMaxWindow {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorVelocityLimitWindowInvalid
+		return nil
+	}
+
+	if req.MaxCount < 0 || req.MaxAmount < 0 || (req.MaxCount == 0 && req.MaxAmount == 0) {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorVelocityLimitValuesInvalid
+		return nil
+	}
+
+	if req.MaxAmount > 0 && req.Currency == "" {
+		res.Status = billingpb.ResponseStatusBadData
+		res.Message = errorVelocityLimitCurrencyInvalid
+		return nil
+	}
+
+	if req.MerchantId != "" {
+		if _, err := s.merchantRepository.GetById(ctx, req.MerchantId); err != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = merchantErrorNotFound
+			return nil
+		}
+	}
+
+	if req.PaymentMethodId != "" {
+		if _, err := s.paymentMethodRepository.GetById(ctx, req.PaymentMethodId); err != nil {
+			res.Status = billingpb.ResponseStatusNotFound
+			res.Message = orderErrorPaymentMethodNotFound
+			return nil
+		}
+	}
+
+	limit.MerchantId = req.MerchantId
+	limit.PaymentMethodId = req.PaymentMethodId
+	limit.Key = req.Key
+	limit.Window = req.Window
+	limit.MaxCount = req.MaxCount
+	limit.MaxAmount = req.MaxAmount
+	limit.Currency = strings.ToUpper(req.Currency)
+	limit.Enabled = req.Enabled
+	limit.UpdatedAt = ptypes.TimestampNow()
+
+	if err := s.velocityLimitRepository.Upsert(ctx, limit); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorVelocityLimitUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Item = limit
+
+	return nil
+}
+
+// ListVelocityLimits returns velocity limits of payments, filtered by the merchant if it's passed
+func (s *Service) ListVelocityLimits(
+	ctx context.Context,
+	req *pkg.ListVelocityLimitsRequest,
+	res *pkg.ListVelocityLimitsResponse,
+) error {
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	count, err := s.velocityLimitRepository.FindCount(ctx, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorVelocityLimitUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count <= 0 {
+		return nil
+	}
+
+	res.Items, err = s.velocityLimitRepository.Find(ctx, req.MerchantId, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorVelocityLimitUnknown
+		res.Count = 0
+		return nil
+	}
+
+	return nil
+}
+
+// DeleteVelocityLimit marks the velocity limit as deleted
+func (s *Service) DeleteVelocityLimit(
+	ctx context.Context,
+	req *pkg.VelocityLimitRequest,
+	res *billingpb.EmptyResponseWithStatus,
+) error {
+	limit, err := s.velocityLimitRepository.GetById(ctx, req.Id)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = errorVelocityLimitNotFound
+		return nil
+	}
+
+	limit.Deleted = true
+	limit.UpdatedAt = ptypes.TimestampNow()
+
+	if err = s.velocityLimitRepository.Upsert(ctx, limit); err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorVelocityLimitUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+
+	return nil
+}
+
+// ListVelocityLimitEvents returns breaches of velocity limits by orders of the merchant
+func (s *Service) ListVelocityLimitEvents(
+	ctx context.Context,
+	req *pkg.ListVelocityLimitEventsRequest,
+	res *pkg.ListVelocityLimitEventsResponse,
+) error {
+	if _, err := s.merchantRepository.GetById(ctx, req.MerchantId); err != nil {
+		res.Status = billingpb.ResponseStatusNotFound
+		res.Message = merchantErrorNotFound
+		return nil
+	}
+
+	if req.Limit <= 0 || req.Limit > pkg.DatabaseRequestDefaultLimit {
+		req.Limit = pkg.DatabaseRequestDefaultLimit
+	}
+
+	count, err := s.velocityLimitEventRepository.FindCountByMerchantId(ctx, req.MerchantId)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorVelocityLimitUnknown
+		return nil
+	}
+
+	res.Status = billingpb.ResponseStatusOk
+	res.Count = count
+
+	if count <= 0 {
+		return nil
+	}
+
+	res.Items, err = s.velocityLimitEventRepository.FindByMerchantId(ctx, req.MerchantId, req.Offset, req.Limit)
+
+	if err != nil {
+		res.Status = billingpb.ResponseStatusSystemError
+		res.Message = errorVelocityLimitUnknown
+		res.Count = 0
+		return nil
+	}
+
+	return nil
+}
+
+// checkVelocityLimits checks the order against velocity limits as the next payment attempt and records
+// the event of the first breached limit. Amounts of attempts are converted to the currency of the limit,
+// attempts which amounts failed to convert aren't summed. Limits aren't enforced when the storage is unavailable.
+func (s *Service) checkVelocityLimits(
+	ctx context.Context,
+	order *billingpb.Order,
+	cardFingerprint, stage string,
+) *billingpb.ResponseErrorMessage {
+	paymentMethodId := ""
+
+	if order.PaymentMethod != nil {
+		paymentMethodId = order.PaymentMethod.Id
+	}
+
+	limits, err := s.velocityLimitRepository.FindEnabled(ctx, order.GetMerchantId(), paymentMethodId)
+
+	if err != nil || len(limits) <= 0 {
+		return nil
+	}
+
+	values := s.getVelocityLimitValues(order, cardFingerprint)
+	attempts := make(map[string][]*velocityLimitAttempt)
+	rates := make(map[string]float64)
+	now := time.Now().Unix()
+
+	for _, limit := range limits {
+		value := values[limit.Key]
+
+		if value == "" {
+			continue
+		}
+
+		if _, ok := attempts[limit.Key]; !ok {
+			attempts[limit.Key], err = s.getVelocityLimitAttempts(limit.Key, value, now)
+
+			if err != nil {
+				zap.L().Error(
+					"Failed to get payment attempts for velocity limit",
+					zap.Error(err),
+					zap.String("order_id", order.Id),
+					zap.String("key", limit.Key),
+				)
+				continue
+			}
+		}
+
+		count := int64(1)
+		amount := s.getVelocityLimitAmount(ctx, order.OrderAmount, order.Currency, limit.Currency, rates)
+
+		counted := make(map[string]bool)
+
+		for _, attempt := range attempts[limit.Key] {
+			if attempt.orderId == order.Id || counted[attempt.orderId] || attempt.time < now-limit.Window ||
+				(limit.MerchantId != "" && attempt.merchantId != limit.MerchantId) ||
+				(limit.PaymentMethodId != "" && attempt.paymentMethodId != limit.PaymentMethodId) {
+				continue
+			}
+
+			counted[attempt.orderId] = true
+			count++
+
+			amount += s.getVelocityLimitAmount(ctx, attempt.amount, attempt.currency, limit.Currency, rates)
+		}
+
+		if (limit.MaxCount <= 0 || count <= limit.MaxCount) && (limit.MaxAmount <= 0 || amount <= limit.MaxAmount) {
+			continue
+		}
+
+		event := &pkg.VelocityLimitEvent{
+			LimitId:         limit.Id,
+			MerchantId:      order.GetMerchantId(),
+			ProjectId:       order.GetProjectId(),
+			OrderId:         order.Id,
+			PaymentMethodId: paymentMethodId,
+			Key:             limit.Key,
+			Stage:           stage,
+			Window:          limit.Window,
+			Count:           count,
+			Amount:          amount,
+			MaxCount:        limit.MaxCount,
+			MaxAmount:       limit.MaxAmount,
+			Currency:        limit.Currency,
+			CreatedAt:       ptypes.TimestampNow(),
+		}
+
+		if err = s.velocityLimitEventRepository.Insert(ctx, event); err != nil {
+			zap.L().Error("Failed to record velocity limit event", zap.Error(err), zap.Any("event", event))
+		}
+
+		return velocityLimitExceededErrors[limit.Key]
+	}
+
+	return nil
+}
+
+// getVelocityLimitAmount converts the amount of the payment attempt to the currency of the velocity limit by
+// the payment rate, rates are cached for the single check of limits.
+func (s *Service) getVelocityLimitAmount(
+	ctx context.Context,
+	amount float64,
+	from, to string,
+	rates map[string]float64,
+) float64 {
+	if amount <= 0 || from == to {
+		return amount
+	}
+
+	key := from + velocityLimitMemberSeparator + to
+	rate, ok := rates[key]
+
+	if !ok {
+		req := &currenciespb.ExchangeCurrencyCurrentCommonRequest{
+			From:              from,
+			To:                to,
+			RateType:          currenciespb.RateTypePaysuper,
+			ExchangeDirection: currenciespb.ExchangeDirectionSell,
+			Amount:            1,
+		}
+		rsp, err := s.curService.ExchangeCurrencyCurrentCommon(ctx, req)
+
+		if err != nil {
+			zap.L().Error(
+				pkg.ErrorGrpcServiceCallFailed,
+				zap.Error(err),
+				zap.String(errorFieldService, "CurrencyRatesService"),
+				zap.String(errorFieldMethod, "ExchangeCurrencyCurrentCommon"),
+				zap.Any(errorFieldRequest, req),
+			)
+		} else {
+			rate = rsp.ExchangeRate
+		}
+
+		rates[key] = rate
+	}
+
+	return amount * rate
+}
+
+// recordVelocityLimitAttempt adds the payment attempt of the order to the sliding windows of velocity limits.
+func (s *Service) recordVelocityLimitAttempt(order *billingpb.Order, cardFingerprint string) {
+	paymentMethodId := ""
+
+	if order.PaymentMethod != nil {
+		paymentMethodId = order.PaymentMethod.Id
+	}
+
+	member := strings.Join([]string{
+		order.Id,
+		order.GetMerchantId(),
+		paymentMethodId,
+		strconv.FormatFloat(order.OrderAmount, 'f', -1, 64),
+		order.Currency,
+	}, velocityLimitMemberSeparator)
+
+	now := time.Now().Unix()
+	window := time.Duration(s.cfg.VelocityLimitMaxWindow) * time.Second
+	pipe := s.redis.Pipeline()
+
+	for key, value := range s.getVelocityLimitValues(order, cardFingerprint) {
+		if value == "" {
+			continue
+		}
+
+		storageKey := s.getVelocityLimitStorageKey(key, value)
+		pipe.ZAdd(storageKey, redis.Z{Score: float64(now), Member: member})
+		pipe.ZRemRangeByScore(storageKey, "-inf", "("+strconv.FormatInt(now-s.cfg.VelocityLimitMaxWindow, 10))
+		pipe.Expire(storageKey, window)
+	}
+
+	if _, err := pipe.Exec(); err != nil {
+		zap.L().Error("Failed to record payment attempt for velocity limits", zap.Error(err), zap.String("order_id", order.Id))
+	}
+}
+
+func (s *Service) getVelocityLimitAttempts(key, value string, now int64) ([]*velocityLimitAttempt, error) {
+	opt := redis.ZRangeBy{
+		Min: strconv.FormatInt(now-s.cfg.VelocityLimitMaxWindow, 10),
+		Max: "+inf",
+	}
+	members, err := s.redis.ZRangeByScoreWithScores(s.getVelocityLimitStorageKey(key, value), opt).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts []*velocityLimitAttempt
+
+	for _, member := range members {
+		str, ok := member.Member.(string)
+
+		if !ok {
+			continue
+		}
+
+		parts := strings.Split(str, velocityLimitMemberSeparator)
+
+		if len(parts) != velocityLimitMemberPartsLength {
+			continue
+		}
+
+		amount, _ := strconv.ParseFloat(parts[3], 64)
+		attempts = append(attempts, &velocityLimitAttempt{
+			orderId:         parts[0],
+			merchantId:      parts[1],
+			paymentMethodId: parts[2],
+			amount:          amount,
+			currency:        parts[4],
+			time:            int64(member.Score),
+		})
+	}
+
+	return attempts, nil
+}
+
+// getVelocityLimitValues returns values of velocity limit keys of the order, the bank card is known
+// on the payment only.
+func (s *Service) getVelocityLimitValues(order *billingpb.Order, cardFingerprint string) map[string]string {
+	values := map[string]string{
+		pkg.VelocityLimitKeyCard:    cardFingerprint,
+		pkg.VelocityLimitKeyProject: order.GetProjectId(),
+	}
+
+	if order.User != nil {
+		values[pkg.VelocityLimitKeyCustomer] = order.User.Id
+		values[pkg.VelocityLimitKeyIp] = order.User.Ip
+		values[pkg.VelocityLimitKeyEmail] = strings.ToLower(order.User.Email)
+	}
+
+	return values
+}
+
+// getVelocityLimitStorageKey returns the key of the sorted set of payment attempts, values are hashed
+// to keep personal data out of the storage.
+func (s *Service) getVelocityLimitStorageKey(key, value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return velocityLimitStorageKeyPrefix + key + ":" + hex.EncodeToString(hash[:])
+}
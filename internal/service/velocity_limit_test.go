@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+type VelocityLimitTestSuite struct {
+	suite.Suite
+	service *Service
+
+	merchant      *billingpb.Merchant
+	project       *billingpb.Project
+	paymentMethod *billingpb.PaymentMethod
+}
+
+func Test_VelocityLimit(t *testing.T) {
+	suite.Run(t, new(VelocityLimitTestSuite))
+}
+
+func (suite *VelocityLimitTestSuite) SetupTest() {
+	suite.service = HelperNewBillingService(suite.Suite)
+
+	suite.merchant, suite.project, suite.paymentMethod, _ = HelperCreateEntitiesForTests(suite.Suite, suite.service)
+}
+
+func (suite *VelocityLimitTestSuite) TearDownTest() {
+	HelperDropBillingService(suite.Suite, suite.service)
+}
+
+func (suite *VelocityLimitTestSuite) helperCreateVelocityLimit(req *pkg.CreateOrUpdateVelocityLimitRequest) *pkg.VelocityLimit {
+	res := &pkg.VelocityLimitResponse{}
+	err := suite.service.CreateOrUpdateVelocityLimit(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+
+	return res.Item
+}
+
+func (suite *VelocityLimitTestSuite) getOrder(amount float64) *billingpb.Order {
+	return &billingpb.Order{
+		Id:          primitive.NewObjectID().Hex(),
+		Project:     &billingpb.ProjectOrder{Id: suite.project.Id, MerchantId: suite.merchant.Id},
+		OrderAmount: amount,
+		Currency:    "USD",
+		User: &billingpb.OrderUser{
+			Id:    "customer",
+			Email: "Test@Unit.Test",
+			Ip:    "127.0.0.1",
+		},
+		PaymentMethod: &billingpb.PaymentMethodOrder{Id: "payment_method"},
+	}
+}
+
+func (suite *VelocityLimitTestSuite) TestVelocityLimit_CreateOrUpdateVelocityLimit_Ok() {
+	limit := suite.helperCreateVelocityLimit(&pkg.CreateOrUpdateVelocityLimitRequest{
+		Key:      pkg.VelocityLimitKeyCard,
+		Window:   3600,
+		MaxCount: 3,
+		Enabled:  true,
+	})
+	assert.NotEmpty(suite.T(), limit.Id)
+	assert.Empty(suite.T(), limit.MerchantId)
+
+	res := &pkg.VelocityLimitResponse{}
+	err := suite.service.CreateOrUpdateVelocityLimit(context.TODO(), &pkg.CreateOrUpdateVelocityLimitRequest{
+		Id:         limit.Id,
+		MerchantId: suite.merchant.Id,
+		Key:        pkg.VelocityLimitKeyCard,
+		Window:     86400,
+		MaxAmount:  1000,
+		Currency:   "usd",
+	}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.Equal(suite.T(), limit.Id, res.Item.Id)
+	assert.Equal(suite.T(), suite.merchant.Id, res.Item.MerchantId)
+	assert.EqualValues(suite.T(), 0, res.Item.MaxCount)
+	assert.Equal(suite.T(), "USD", res.Item.Currency)
+	assert.False(suite.T(), res.Item.Enabled)
+}
+
+func (suite *VelocityLimitTestSuite) TestVelocityLimit_CreateOrUpdateVelocityLimit_Error() {
+	req := &pkg.CreateOrUpdateVelocityLimitRequest{
+		Key:      "unknown",
+		Window:   3600,
+		MaxCount: 1,
+	}
+	res := &pkg.VelocityLimitResponse{}
+	err := suite.service.CreateOrUpdateVelocityLimit(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusBadData, res.Status)
+	assert.Equal(suite.T(), errorVelocityLimitKeyInvalid, res.Message)
+
+	req.Key = pkg.VelocityLimitKeyIp
+	req.Window = suite.service.cfg.VelocityLimitMaxWindow + 1
+	res = &pkg.VelocityLimitResponse{}
+	err = suite.service.CreateOrUpdateVelocityLimit(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), errorVelocityLimitWindowInvalid, res.Message)
+
+	req.Window = 3600
+	req.MaxCount = 0
+	res = &pkg.VelocityLimitResponse{}
+	err = suite.service.CreateOrUpdateVelocityLimit(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), errorVelocityLimitValuesInvalid, res.Message)
+
+	req.MaxAmount = 100
+	res = &pkg.VelocityLimitResponse{}
+	err = suite.service.CreateOrUpdateVelocityLimit(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), errorVelocityLimitCurrencyInvalid, res.Message)
+
+	req.Currency = "USD"
+	req.MerchantId = primitive.NewObjectID().Hex()
+	res = &pkg.VelocityLimitResponse{}
+	err = suite.service.CreateOrUpdateVelocityLimit(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), merchantErrorNotFound, res.Message)
+
+	req.MerchantId = ""
+	req.PaymentMethodId = primitive.NewObjectID().Hex()
+	res = &pkg.VelocityLimitResponse{}
+	err = suite.service.CreateOrUpdateVelocityLimit(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), orderErrorPaymentMethodNotFound, res.Message)
+
+	req.PaymentMethodId = ""
+	req.Id = primitive.NewObjectID().Hex()
+	res = &pkg.VelocityLimitResponse{}
+	err = suite.service.CreateOrUpdateVelocityLimit(context.TODO(), req, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), errorVelocityLimitNotFound, res.Message)
+}
+
+func (suite *VelocityLimitTestSuite) TestVelocityLimit_ListAndDeleteVelocityLimits_Ok() {
+	suite.helperCreateVelocityLimit(&pkg.CreateOrUpdateVelocityLimitRequest{
+		Key:      pkg.VelocityLimitKeyIp,
+		Window:   3600,
+		MaxCount: 10,
+	})
+	limit := suite.helperCreateVelocityLimit(&pkg.CreateOrUpdateVelocityLimitRequest{
+		MerchantId: suite.merchant.Id,
+		Key:        pkg.VelocityLimitKeyProject,
+		Window:     3600,
+		MaxCount:   1000,
+	})
+
+	res := &pkg.ListVelocityLimitsResponse{}
+	err := suite.service.ListVelocityLimits(context.TODO(), &pkg.ListVelocityLimitsRequest{}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.EqualValues(suite.T(), 2, res.Count)
+
+	res = &pkg.ListVelocityLimitsResponse{}
+	err = suite.service.ListVelocityLimits(context.TODO(), &pkg.ListVelocityLimitsRequest{MerchantId: suite.merchant.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, res.Count)
+	assert.Equal(suite.T(), limit.Id, res.Items[0].Id)
+
+	res2 := &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.DeleteVelocityLimit(context.TODO(), &pkg.VelocityLimitRequest{Id: limit.Id}, res2)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res2.Status)
+
+	res = &pkg.ListVelocityLimitsResponse{}
+	err = suite.service.ListVelocityLimits(context.TODO(), &pkg.ListVelocityLimitsRequest{MerchantId: suite.merchant.Id}, res)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0, res.Count)
+
+	res2 = &billingpb.EmptyResponseWithStatus{}
+	err = suite.service.DeleteVelocityLimit(context.TODO(), &pkg.VelocityLimitRequest{Id: limit.Id}, res2)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res2.Status)
+}
+
+func (suite *VelocityLimitTestSuite) TestVelocityLimit_CheckVelocityLimits_Count() {
+	limit := suite.helperCreateVelocityLimit(&pkg.CreateOrUpdateVelocityLimitRequest{
+		Key:      pkg.VelocityLimitKeyEmail,
+		Window:   3600,
+		MaxCount: 2,
+		Enabled:  true,
+	})
+
+	for i := 0; i < 2; i++ {
+		order := suite.getOrder(10)
+		msg := suite.service.checkVelocityLimits(context.TODO(), order, "", pkg.VelocityLimitStagePayment)
+		assert.Nil(suite.T(), msg)
+		suite.service.recordVelocityLimitAttempt(order, "")
+
+		// next attempt of the same order isn't counted twice
+		msg = suite.service.checkVelocityLimits(context.TODO(), order, "", pkg.VelocityLimitStagePayment)
+		assert.Nil(suite.T(), msg)
+	}
+
+	order := suite.getOrder(10)
+	order.User.Email = "test@unit.test"
+	msg := suite.service.checkVelocityLimits(context.TODO(), order, "", pkg.VelocityLimitStageOrderCreate)
+	assert.Equal(suite.T(), errorVelocityLimitEmailExceeded, msg)
+
+	order.User.Email = "other@unit.test"
+	msg = suite.service.checkVelocityLimits(context.TODO(), order, "", pkg.VelocityLimitStageOrderCreate)
+	assert.Nil(suite.T(), msg)
+
+	res := &pkg.ListVelocityLimitEventsResponse{}
+	err := suite.service.ListVelocityLimitEvents(
+		context.TODO(),
+		&pkg.ListVelocityLimitEventsRequest{MerchantId: suite.merchant.Id},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusOk, res.Status)
+	assert.EqualValues(suite.T(), 1, res.Count)
+	assert.Equal(suite.T(), limit.Id, res.Items[0].LimitId)
+	assert.Equal(suite.T(), order.Id, res.Items[0].OrderId)
+	assert.Equal(suite.T(), pkg.VelocityLimitStageOrderCreate, res.Items[0].Stage)
+	assert.EqualValues(suite.T(), 3, res.Items[0].Count)
+}
+
+func (suite *VelocityLimitTestSuite) TestVelocityLimit_CheckVelocityLimits_Amount() {
+	suite.helperCreateVelocityLimit(&pkg.CreateOrUpdateVelocityLimitRequest{
+		MerchantId: suite.merchant.Id,
+		Key:        pkg.VelocityLimitKeyCard,
+		Window:     3600,
+		MaxAmount:  100,
+		Currency:   "USD",
+		Enabled:    true,
+	})
+
+	order := suite.getOrder(60)
+	assert.Nil(suite.T(), suite.service.checkVelocityLimits(context.TODO(), order, "card", pkg.VelocityLimitStagePayment))
+	suite.service.recordVelocityLimitAttempt(order, "card")
+
+	// the bank card is unknown on the order creation
+	order = suite.getOrder(60)
+	assert.Nil(suite.T(), suite.service.checkVelocityLimits(context.TODO(), order, "", pkg.VelocityLimitStageOrderCreate))
+	assert.Equal(
+		suite.T(),
+		errorVelocityLimitCardExceeded,
+		suite.service.checkVelocityLimits(context.TODO(), order, "card", pkg.VelocityLimitStagePayment),
+	)
+	assert.Nil(suite.T(), suite.service.checkVelocityLimits(context.TODO(), order, "other", pkg.VelocityLimitStagePayment))
+}
+
+func (suite *VelocityLimitTestSuite) TestVelocityLimit_CheckVelocityLimits_AmountInOtherCurrency() {
+	suite.helperCreateVelocityLimit(&pkg.CreateOrUpdateVelocityLimitRequest{
+		MerchantId: suite.merchant.Id,
+		Key:        pkg.VelocityLimitKeyCard,
+		Window:     3600,
+		MaxAmount:  100,
+		Currency:   "USD",
+		Enabled:    true,
+	})
+
+	order := suite.getOrder(50)
+	assert.Nil(suite.T(), suite.service.checkVelocityLimits(context.TODO(), order, "card", pkg.VelocityLimitStagePayment))
+	suite.service.recordVelocityLimitAttempt(order, "card")
+
+	// 40 EUR are about 44.3 USD
+	order = suite.getOrder(40)
+	order.Currency = "EUR"
+	assert.Nil(suite.T(), suite.service.checkVelocityLimits(context.TODO(), order, "card", pkg.VelocityLimitStagePayment))
+	suite.service.recordVelocityLimitAttempt(order, "card")
+
+	order = suite.getOrder(10)
+	assert.Equal(
+		suite.T(),
+		errorVelocityLimitCardExceeded,
+		suite.service.checkVelocityLimits(context.TODO(), order, "card", pkg.VelocityLimitStagePayment),
+	)
+
+	events, err := suite.service.velocityLimitEventRepository.FindByMerchantId(context.TODO(), suite.merchant.Id, 0, 10)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), events, 1)
+	assert.InDelta(suite.T(), 104.31, events[0].Amount, 0.1)
+	assert.Equal(suite.T(), "USD", events[0].Currency)
+}
+
+func (suite *VelocityLimitTestSuite) TestVelocityLimit_CheckVelocityLimits_PaymentMethod() {
+	suite.helperCreateVelocityLimit(&pkg.CreateOrUpdateVelocityLimitRequest{
+		PaymentMethodId: suite.paymentMethod.Id,
+		Key:             pkg.VelocityLimitKeyIp,
+		Window:          3600,
+		MaxCount:        1,
+		Enabled:         true,
+	})
+
+	order := suite.getOrder(10)
+	order.PaymentMethod.Id = suite.paymentMethod.Id
+	assert.Nil(suite.T(), suite.service.checkVelocityLimits(context.TODO(), order, "", pkg.VelocityLimitStagePayment))
+	suite.service.recordVelocityLimitAttempt(order, "")
+
+	order = suite.getOrder(10)
+	assert.Nil(suite.T(), suite.service.checkVelocityLimits(context.TODO(), order, "", pkg.VelocityLimitStagePayment))
+	suite.service.recordVelocityLimitAttempt(order, "")
+
+	order = suite.getOrder(10)
+	order.PaymentMethod.Id = suite.paymentMethod.Id
+	assert.Equal(
+		suite.T(),
+		errorVelocityLimitIpExceeded,
+		suite.service.checkVelocityLimits(context.TODO(), order, "", pkg.VelocityLimitStagePayment),
+	)
+}
+
+func (suite *VelocityLimitTestSuite) TestVelocityLimit_ListVelocityLimitEvents_MerchantNotFound() {
+	res := &pkg.ListVelocityLimitEventsResponse{}
+	err := suite.service.ListVelocityLimitEvents(
+		context.TODO(),
+		&pkg.ListVelocityLimitEventsRequest{MerchantId: primitive.NewObjectID().Hex()},
+		res,
+	)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), billingpb.ResponseStatusNotFound, res.Status)
+	assert.Equal(suite.T(), merchantErrorNotFound, res.Message)
+}
@@ -26,6 +26,9 @@ func main() {
 		case "royalty_reports":
 			err = app.TaskCreateRoyaltyReport()
 
+		case "royalty_reports_scheduled":
+			err = app.TaskCreateScheduledRoyaltyReports()
+
 		case "royalty_reports_accept":
 			err = app.TaskAutoAcceptRoyaltyReports()
 
@@ -40,6 +43,33 @@ func main() {
 
 		case "fix_taxes":
 			err = app.TaskFixTaxes()
+
+		case "rotate_key_codes":
+			err = app.TaskRotateKeyCodes()
+
+		case "release_expired_keys":
+			err = app.TaskReleaseExpiredKeys()
+
+		case "key_pre_orders":
+			err = app.TaskDeliverKeyPreOrders()
+
+		case "refresh_price_tables":
+			err = app.TaskRefreshPriceTables()
+
+		case "load_sanctions_lists":
+			err = app.TaskLoadSanctionsLists()
+
+		case "purge_customer_history":
+			err = app.TaskPurgeCustomerHistory()
+
+		case "encrypt_personal_data":
+			err = app.TaskEncryptPersonalData()
+
+		case "import_card_updates":
+			err = app.TaskImportCardUpdates()
+
+		case "monitor_merchant_risk":
+			err = app.TaskMonitorMerchantRisk()
 		}
 
 		if err != nil {
@@ -54,6 +84,8 @@ func main() {
 	}
 
 	app.KeyDaemonStart()
+	app.KeyImportDaemonStart()
+	app.KeyMetricsDaemonStart()
 
 	app.Run()
 }
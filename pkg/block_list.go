@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// BlockListEntry is an entry of the block list or the allow list. Payments matched by the block list entry
+// are rejected, payments matched by the allow list entry skip block lists and velocity limits.
+type BlockListEntry struct {
+	Id string `json:"id"`
+	// MerchantId limits the entry to payments of the merchant, the entry is applied to all payments if it's empty.
+	MerchantId string `json:"merchant_id,omitempty"`
+	// Type is BlockListTypeBlock or BlockListTypeAllow.
+	Type string `json:"type"`
+	// Field is one of BlockListField* constants.
+	Field string `json:"field"`
+	// Value is a fingerprint of the bank card, a BIN or a range of BINs separated by dash, an email,
+	// a domain of emails, an IP address or a CIDR, an identifier of the customer or the browser cookie.
+	Value  string `json:"value"`
+	Reason string `json:"reason,omitempty"`
+	// ExpiresAt is a time after which the entry isn't applied, the entry never expires if it's empty.
+	ExpiresAt *timestamp.Timestamp `json:"expires_at,omitempty"`
+	CreatedBy string               `json:"created_by,omitempty"`
+	HitCount  int64                `json:"hit_count"`
+	LastHitAt *timestamp.Timestamp `json:"last_hit_at,omitempty"`
+	Deleted   bool                 `json:"deleted"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt *timestamp.Timestamp `json:"updated_at"`
+}
+
+type CreateOrUpdateBlockListEntryRequest struct {
+	Id         string `json:"id"`
+	MerchantId string `json:"merchant_id"`
+	Type       string `json:"type"`
+	Field      string `json:"field"`
+	// Value of the bank card may be a full card number, it's replaced by the fingerprint.
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+	// ExpiresAt is a unix time of expiry of the entry, zero means the entry never expires.
+	ExpiresAt int64  `json:"expires_at"`
+	UserId    string `json:"user_id"`
+}
+
+type BlockListEntryResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *BlockListEntry                 `json:"item,omitempty"`
+}
+
+type ListBlockListEntriesRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Type       string `json:"type"`
+	Field      string `json:"field"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+}
+
+type ListBlockListEntriesResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*BlockListEntry               `json:"items,omitempty"`
+}
+
+// DeleteBlockListEntryRequest deletes the entry of the merchant, global entries are deleted
+// when the merchant isn't passed.
+type DeleteBlockListEntryRequest struct {
+	Id         string `json:"id"`
+	MerchantId string `json:"merchant_id"`
+}
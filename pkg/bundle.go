@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// Bundle groups products and key products of the project which are sold together at the bundle price.
+type Bundle struct {
+	Id          string            `json:"id"`
+	Object      string            `json:"object"`
+	Sku         string            `json:"sku"`
+	Name        map[string]string `json:"name"`
+	Description map[string]string `json:"description"`
+	MerchantId  string            `json:"merchant_id"`
+	ProjectId   string            `json:"project_id"`
+	Products    []string          `json:"products"`
+	KeyProducts []string          `json:"key_products"`
+	// Prices contains prices of the bundle in price regions, the price in the default region of the merchant
+	// is required.
+	Prices    []*billingpb.ProductPrice `json:"prices"`
+	Images    []string                  `json:"images,omitempty"`
+	Enabled   bool                      `json:"enabled"`
+	Deleted   bool                      `json:"deleted"`
+	CreatedAt *timestamp.Timestamp      `json:"created_at"`
+	UpdatedAt *timestamp.Timestamp      `json:"updated_at"`
+}
+
+// OrderItemBreakdown is a share of the order item in amounts of the order, the share is proportional
+// to the item amount.
+type OrderItemBreakdown struct {
+	ItemId string `json:"item_id"`
+	Name   string `json:"name"`
+	// Type is one of OrderItemTypeProduct or OrderItemTypeKeyProduct.
+	Type       string  `json:"type"`
+	BundleId   string  `json:"bundle_id,omitempty"`
+	BundleName string  `json:"bundle_name,omitempty"`
+	Amount     float64 `json:"amount"`
+	Share      float64 `json:"share"`
+	// Currency is the payout currency of the merchant, amounts below are in this currency.
+	Currency     string  `json:"currency"`
+	GrossRevenue float64 `json:"gross_revenue"`
+	TaxFee       float64 `json:"tax_fee"`
+	FeesTotal    float64 `json:"fees_total"`
+	NetRevenue   float64 `json:"net_revenue"`
+}
+
+type CreateOrUpdateBundleRequest struct {
+	Id          string                    `json:"id"`
+	MerchantId  string                    `json:"merchant_id"`
+	ProjectId   string                    `json:"project_id"`
+	Sku         string                    `json:"sku"`
+	Name        map[string]string         `json:"name"`
+	Description map[string]string         `json:"description"`
+	Products    []string                  `json:"products"`
+	KeyProducts []string                  `json:"key_products"`
+	Prices      []*billingpb.ProductPrice `json:"prices"`
+	Images      []string                  `json:"images"`
+	Enabled     bool                      `json:"enabled"`
+}
+
+type GetBundleRequest struct {
+	Id         string `json:"id"`
+	MerchantId string `json:"merchant_id"`
+}
+
+type BundleResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *Bundle                         `json:"item,omitempty"`
+}
+
+type ListBundlesRequest struct {
+	MerchantId string `json:"merchant_id"`
+	ProjectId  string `json:"project_id"`
+	Offset     int64  `json:"offset"`
+	Limit      int64  `json:"limit"`
+}
+
+type ListBundlesResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*Bundle                       `json:"items,omitempty"`
+}
+
+type GetOrderItemsBreakdownRequest struct {
+	MerchantId string `json:"merchant_id"`
+	OrderId    string `json:"order_id"`
+}
+
+type GetOrderItemsBreakdownResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*OrderItemBreakdown           `json:"items,omitempty"`
+}
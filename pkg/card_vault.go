@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// CardVaultToken is a bank card saved by the customer for one-click payments. The card number isn't stored,
+// payments are made by the reference of the card in the payment system which is stored encrypted.
+type CardVaultToken struct {
+	Id string `json:"id"`
+	// CustomerId is an identifier of the customer or the virtual customer from the browser cookie.
+	CustomerId string `json:"customer_id"`
+	ProjectId  string `json:"project_id"`
+	MerchantId string `json:"merchant_id"`
+	// Fingerprint is a hash of the card number used to find duplicates of the card of the customer
+	// and cards updated by the account updater.
+	Fingerprint string `json:"-"`
+	// PanReference is a recurring identifier of the card in the payment system.
+	PanReference string `json:"-"`
+	MaskedPan    string `json:"masked_pan"`
+	CardHolder   string `json:"card_holder"`
+	ExpireMonth  string `json:"expire_month"`
+	ExpireYear   string `json:"expire_year"`
+	IsDefault    bool   `json:"is_default"`
+	// ExpireUpdatedAt is a time of the last update of the expiry by the account updater.
+	ExpireUpdatedAt *timestamp.Timestamp `json:"expire_updated_at,omitempty"`
+	CreatedAt       *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt       *timestamp.Timestamp `json:"updated_at"`
+}
+
+// CardUpdaterFile is a file of the account updater imported to saved bank cards, files with the same name
+// aren't imported again.
+type CardUpdaterFile struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// Count is a number of saved cards updated by the file.
+	Count     int64                `json:"count"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+type ListCardVaultTokensRequest struct {
+	Cookie string `json:"cookie"`
+}
+
+type ListCardVaultTokensResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*CardVaultToken               `json:"items,omitempty"`
+}
+
+type CardVaultTokenRequest struct {
+	Id     string `json:"id"`
+	Cookie string `json:"cookie"`
+}
+
+type CardVaultTokenResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *CardVaultToken                 `json:"item,omitempty"`
+}
@@ -47,6 +47,13 @@ const (
 
 	ErrorGrpcServiceCallFailed       = "gRPC call failed"
 	ErrorVatReportDateCantBeInFuture = "vat report date cant be in future"
+	ErrorKeyCodeMasterKeyNotFound    = "master key for key codes encryption not found"
+	ErrorKeyCodeNoIndexSecret        = "secret for hashes of key codes is required"
+	ErrorKeyCodeNoMasterKeys         = "master keys for key codes encryption are required outside the development environment"
+	ErrorPersonalDataKeyNotFound     = "key for personal data encryption not found"
+	ErrorPersonalDataNoIndexSecret   = "secret for blind indexes of personal data is required"
+	ErrorCardFingerprintNoSecret     = "secret for fingerprints of bank cards is required"
+	ErrorKeyStateUnknown             = "unknown state of key"
 	MethodFinishedWithError          = "method finished with error"
 	LogFieldRequest                  = "request"
 	LogFieldResponse                 = "response"
@@ -153,6 +160,25 @@ const (
 	RoyaltyReportChangeSourceMerchant = "merchant"
 	RoyaltyReportChangeSourceAdmin    = "admin"
 
+	RoyaltyReportSchedulePeriodWeekly   = "weekly"
+	RoyaltyReportSchedulePeriodBiWeekly = "biweekly"
+	RoyaltyReportSchedulePeriodMonthly  = "monthly"
+
+	RoyaltyReportDisputeStatusOpened   = "opened"
+	RoyaltyReportDisputeStatusResolved = "resolved"
+
+	RoyaltyReportDisputeItemStatusPending  = "pending"
+	RoyaltyReportDisputeItemStatusAccepted = "accepted"
+	RoyaltyReportDisputeItemStatusRejected = "rejected"
+
+	MerchantStatementItemTypeRoyaltyReport            = "royalty_report"
+	MerchantStatementItemTypeCorrection               = "correction"
+	MerchantStatementItemTypeRollingReserve           = "rolling_reserve"
+	MerchantStatementItemTypeRollingReserveSettlement = "rolling_reserve_settlement"
+	MerchantStatementItemTypePayout                   = "payout"
+
+	ReportTypeMerchantStatement = "merchant_statement"
+
 	VatCurrencyRatesPolicyOnDay    = "on-day"
 	VatCurrencyRatesPolicyLastDay  = "last-day"
 	VatCurrencyRatesPolicyAvgMonth = "avg-month"
@@ -189,6 +215,49 @@ const (
 	PayoutDocumentStatusPaid     = "paid"
 	PayoutDocumentStatusCanceled = "canceled"
 	PayoutDocumentStatusFailed   = "failed"
+	PayoutDocumentStatusInBatch  = "in_batch"
+
+	PayoutBatchFormatSepa  = "sepa"
+	PayoutBatchFormatSwift = "swift"
+
+	PayoutBatchStatusCreated   = "created"
+	PayoutBatchStatusProcessed = "processed"
+
+	MerchantBankingChangeStatusPending  = "pending"
+	MerchantBankingChangeStatusVerified = "verified"
+	MerchantBankingChangeStatusRejected = "rejected"
+
+	MerchantBankingVerificationMicroDeposit = "micro_deposit"
+	MerchantBankingVerificationAdmin        = "admin"
+
+	KeyStateAvailable = "available"
+	KeyStateReserved  = "reserved"
+	KeyStateRedeemed  = "redeemed"
+	KeyStateRevoked   = "revoked"
+	KeyStateExpired   = "expired"
+
+	KeyAuditActionUploaded = "uploaded"
+	KeyAuditActionReserved = "reserved"
+	KeyAuditActionReleased = "released"
+	KeyAuditActionRedeemed = "redeemed"
+	KeyAuditActionRevoked  = "revoked"
+	KeyAuditActionExpired  = "expired"
+
+	KeyUploadRejectReasonEmpty            = "empty"
+	KeyUploadRejectReasonTooLong          = "too_long"
+	KeyUploadRejectReasonDuplicateInFile  = "duplicate_in_file"
+	KeyUploadRejectReasonDuplicateInStock = "duplicate_in_stock"
+	KeyUploadRejectReasonInsertFailed     = "insert_failed"
+
+	KeyImportJobStatusUploading  = "uploading"
+	KeyImportJobStatusQueued     = "queued"
+	KeyImportJobStatusProcessing = "processing"
+	KeyImportJobStatusCompleted  = "completed"
+	KeyImportJobStatusFailed     = "failed"
+
+	KeyPreOrderStatusPending   = "pending"
+	KeyPreOrderStatusDelivered = "delivered"
+	KeyPreOrderStatusRefunded  = "refunded"
 
 	OrderIssuerReferenceTypePaylink = "paylink"
 
@@ -204,6 +273,7 @@ const (
 	PaymentSystemActionCreatePayment    = "create_payment"
 	PaymentSystemActionRecurringPayment = "recurring_payment"
 	PaymentSystemActionRefund           = "refund"
+	PaymentSystemActionChangeStatus     = "change_status"
 
 	MerchantOperationTypeLowRisk  = "low-risk"
 	MerchantOperationTypeHighRisk = "high-risk"
@@ -240,6 +310,171 @@ const (
 	OrderType_key            = "key"
 	OrderType_product        = "product"
 	OrderTypeVirtualCurrency = "virtual_currency"
+	OrderTypeMixed           = "mixed"
+
+	OrderItemTypeProduct    = "product"
+	OrderItemTypeKeyProduct = "key_product"
+
+	OrderItemMetadataType       = "paysuper_item_type"
+	OrderItemMetadataBundleId   = "paysuper_bundle_id"
+	OrderItemMetadataBundleName = "paysuper_bundle_name"
+
+	BundleObject = "bundle"
+
+	OrderItemMetadataOriginalAmount = "paysuper_original_amount"
+	OrderItemMetadataDiscount       = "paysuper_discount"
+	OrderItemMetadataPromoId        = "paysuper_promo_id"
+	OrderItemMetadataPriceSchedule  = "paysuper_price_schedule_id"
+
+	OrderPrivateMetadataPromoCode = "PromoCode"
+	OrderPrivateMetadataPromoId   = "PromoId"
+
+	OrderPrivateMetadataPaylinkId        = "PaylinkId"
+	OrderPrivateMetadataPaylinkVariantId = "PaylinkVariantId"
+
+	PaylinkFunnelStepVisit          = "visit"
+	PaylinkFunnelStepFormOpen       = "form_open"
+	PaylinkFunnelStepPaymentAttempt = "payment_attempt"
+	PaylinkFunnelStepSuccess        = "success"
+
+	OrderPrivateMetadataSessionCount     = "SessionCount"
+	OrderPrivateMetadataRiskAssessmentId = "RiskAssessmentId"
+	OrderPrivateMetadataRiskScore        = "RiskScore"
+	OrderPrivateMetadataRiskDecision     = "RiskDecision"
+
+	// PaymentCreateFieldScaDecision is a field of payment requisites with the decision of strong customer
+	// authentication of the payment passed to the payment system, one of ScaDecision* constants.
+	PaymentCreateFieldScaDecision = "sca_decision"
+	// PaymentCreateFieldMerchantInitiated is a field of payment requisites which marks the recurring payment
+	// initiated by the merchant without participation of the customer.
+	PaymentCreateFieldMerchantInitiated = "merchant_initiated"
+	// PaymentCreateFieldPreauth is a field of payment requisites which requires authorization of the payment without
+	// the capture, the payment is captured or voided later.
+	PaymentCreateFieldPreauth = "preauth"
+
+	RiskSignalBinIpCountryMismatch = "bin_ip_country_mismatch"
+	RiskSignalCardVelocity         = "card_velocity"
+	RiskSignalCustomerVelocity     = "customer_velocity"
+	RiskSignalIpVelocity           = "ip_velocity"
+	RiskSignalEmailVelocity        = "email_velocity"
+	RiskSignalAmountRatio          = "amount_ratio"
+	RiskSignalChargebacks          = "chargebacks"
+	RiskSignalSessionCount         = "session_count"
+
+	RiskOperatorGt  = "gt"
+	RiskOperatorGte = "gte"
+	RiskOperatorLt  = "lt"
+	RiskOperatorLte = "lte"
+	RiskOperatorEq  = "eq"
+
+	RiskDecisionAllow   = "allow"
+	RiskDecisionThreeDs = "three_ds"
+	RiskDecisionReview  = "review"
+	RiskDecisionDecline = "decline"
+
+	VelocityLimitKeyCard     = "card"
+	VelocityLimitKeyCustomer = "customer"
+	VelocityLimitKeyIp       = "ip"
+	VelocityLimitKeyEmail    = "email"
+	VelocityLimitKeyProject  = "project"
+
+	VelocityLimitStageOrderCreate = "order_create"
+	VelocityLimitStagePayment     = "payment"
+
+	OrderPrivateMetadataBrowserCookieId = "BrowserCookieId"
+	OrderPrivateMetadataBlockListHits   = "BlockListHits"
+
+	BlockListTypeBlock = "block"
+	BlockListTypeAllow = "allow"
+
+	BlockListFieldCard        = "card"
+	BlockListFieldBin         = "bin"
+	BlockListFieldEmail       = "email"
+	BlockListFieldEmailDomain = "email_domain"
+	BlockListFieldIp          = "ip"
+	BlockListFieldCustomer    = "customer"
+	BlockListFieldCookie      = "cookie"
+
+	OrderPrivateMetadataReviewId     = "ReviewId"
+	OrderPrivateMetadataReviewStatus = "ReviewStatus"
+
+	OrderReviewStatusPending  = "pending"
+	OrderReviewStatusApproved = "approved"
+	OrderReviewStatusDeclined = "declined"
+	OrderReviewStatusCanceled = "canceled"
+
+	OrderPrivateMetadataScaDecision    = "ScaDecision"
+	OrderPrivateMetadataLiabilityShift = "LiabilityShift"
+
+	OrderPrivateMetadataCardFingerprint  = "CardFingerprint"
+	OrderPrivateMetadataCardVaultTokenId = "CardVaultTokenId"
+
+	ScaDecisionChallenge         = "challenge"
+	ScaDecisionFrictionless      = "frictionless"
+	ScaDecisionExemptionLowValue = "exemption_low_value"
+	ScaDecisionExemptionTra      = "exemption_tra"
+	ScaDecisionExemptionMit      = "exemption_mit"
+	ScaDecisionOutOfScope        = "out_of_scope"
+
+	// AccountingEntryReasonLiabilityShift is a reason of accounting entries of the chargeback when the liability
+	// for the fraudulent payment was shifted to the issuer by 3-D Secure authentication.
+	AccountingEntryReasonLiabilityShift = "liability_shift"
+
+	SanctionsListSourceOfac = "ofac"
+	SanctionsListSourceEu   = "eu"
+	SanctionsListSourceUn   = "un"
+	SanctionsListSourcePep  = "pep"
+
+	SanctionsListEntryTypeIndividual = "individual"
+	SanctionsListEntryTypeEntity     = "entity"
+
+	SanctionsScreeningTriggerOnboarding = "onboarding"
+	SanctionsScreeningTriggerBanking    = "banking"
+	SanctionsScreeningTriggerPayout     = "payout"
+	SanctionsScreeningTriggerManual     = "manual"
+
+	SanctionsScreeningSubjectCompany         = "company"
+	SanctionsScreeningSubjectBeneficialOwner = "beneficial_owner"
+	SanctionsScreeningSubjectBank            = "bank"
+
+	SanctionsScreeningStatusClear          = "clear"
+	SanctionsScreeningStatusPotentialMatch = "potential_match"
+	SanctionsScreeningStatusCleared        = "cleared"
+	SanctionsScreeningStatusConfirmed      = "confirmed"
+
+	MerchantRiskReviewStatusOpen      = "open"
+	MerchantRiskReviewStatusSuspended = "suspended"
+	MerchantRiskReviewStatusResolved  = "resolved"
+
+	MerchantRiskMetricChargebackRatio = "chargeback_ratio"
+	MerchantRiskMetricRefundRatio     = "refund_ratio"
+	MerchantRiskMetricDeclineRate     = "decline_rate"
+	MerchantRiskMetricSalesSpike      = "sales_spike"
+
+	MerchantRiskActionHighRisk          = "high_risk"
+	MerchantRiskActionRollingReserve    = "rolling_reserve"
+	MerchantRiskActionManualPayouts     = "manual_payouts"
+	MerchantRiskActionSuspendProcessing = "suspend_processing"
+
+	PersonalDataFieldEmail      = "email"
+	PersonalDataFieldPhone      = "phone"
+	PersonalDataFieldName       = "name"
+	PersonalDataFieldIp         = "ip"
+	PersonalDataFieldUserAgent  = "user_agent"
+	PersonalDataFieldAddress    = "address"
+	PersonalDataFieldCardHolder = "card_holder"
+
+	PromoObject       = "promo"
+	PromoTypePercent  = "percent"
+	PromoTypeFixed    = "fixed"
+	PromoTypeBuyXGetY = "buy_x_get_y"
+
+	PromoUsageStatusReserved = "reserved"
+	PromoUsageStatusUsed     = "used"
+
+	PriceTableBaseRegion           = "USD"
+	PriceTableVersionStatusPending = "pending"
+	PriceTableVersionStatusApplied = "applied"
 
 	DefaultPaymentMethodFee               = float64(5)
 	DefaultPaymentMethodPerTransactionFee = float64(0)
@@ -314,5 +549,9 @@ var (
 			Path:   "/api/refunds",
 			Method: http.MethodPost,
 		},
+		PaymentSystemActionChangeStatus: {
+			Path:   "/api/payments/%s",
+			Method: http.MethodPatch,
+		},
 	}
 )
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// CustomerDataRequest is a data subject request of the customer. The customer is found by one of identifier,
+// email or phone, the request with few values finds all customers and orders linked to any of them.
+type CustomerDataRequest struct {
+	CustomerId string `json:"customer_id"`
+	Email      string `json:"email"`
+	Phone      string `json:"phone"`
+	// UserId is an identifier of the user of the system who processes the request.
+	UserId string `json:"user_id"`
+}
+
+// CustomerDataExport is a set of all personal data linked to the customer.
+type CustomerDataExport struct {
+	Customers        []*billingpb.Customer            `json:"customers"`
+	Orders           []*billingpb.Order               `json:"orders"`
+	Refunds          []*billingpb.Refund              `json:"refunds"`
+	PaylinkVisits    []*PaylinkFunnelEvent            `json:"paylink_visits"`
+	NotifySales      []*billingpb.NotifyUserSales     `json:"notify_sales"`
+	NotifyNewRegions []*billingpb.NotifyUserNewRegion `json:"notify_new_regions"`
+}
+
+type ExportCustomerDataResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *CustomerDataExport             `json:"item,omitempty"`
+}
+
+// CustomerDataErasure is a result of the erasure of personal data of the customer.
+type CustomerDataErasure struct {
+	CustomerIds []string `json:"customer_ids"`
+	// OrderIds are identifiers of orders where personal data of the user were pseudonymised,
+	// amounts, taxes and the country of the billing address of orders are kept for accounting.
+	OrderIds            []string `json:"order_ids"`
+	PaylinkVisitors     int64    `json:"paylink_visitors"`
+	NotifySubscriptions int64    `json:"notify_subscriptions"`
+}
+
+type EraseCustomerDataResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *CustomerDataErasure            `json:"item,omitempty"`
+}
@@ -17,10 +17,24 @@ func newBillingServerErrorMsg(code, msg string, details ...string) *billingpb.Re
 }
 
 var (
-	KeyErrorFileProcess    = newBillingServerErrorMsg("ks000001", "failed to process file")
-	KeyErrorNotFound       = newBillingServerErrorMsg("ks000002", "key not found")
-	KeyErrorFailedToInsert = newBillingServerErrorMsg("ks000003", "failed to insert key")
-	KeyErrorCanceled       = newBillingServerErrorMsg("ks000004", "unable to cancel key")
-	KeyErrorFinish         = newBillingServerErrorMsg("ks000005", "unable to finish key")
-	KeyErrorReserve        = newBillingServerErrorMsg("ks000006", "unable to reserve key")
+	KeyErrorFileProcess         = newBillingServerErrorMsg("ks000001", "failed to process file")
+	KeyErrorNotFound            = newBillingServerErrorMsg("ks000002", "key not found")
+	KeyErrorFailedToInsert      = newBillingServerErrorMsg("ks000003", "failed to insert key")
+	KeyErrorCanceled            = newBillingServerErrorMsg("ks000004", "unable to cancel key")
+	KeyErrorFinish              = newBillingServerErrorMsg("ks000005", "unable to finish key")
+	KeyErrorReserve             = newBillingServerErrorMsg("ks000006", "unable to reserve key")
+	KeyErrorEncrypt             = newBillingServerErrorMsg("ks000007", "failed to encrypt key")
+	KeyErrorDecrypt             = newBillingServerErrorMsg("ks000008", "failed to decrypt key")
+	KeyErrorStateInvalid        = newBillingServerErrorMsg("ks000009", "key state is invalid")
+	KeyErrorNotSpecified        = newBillingServerErrorMsg("ks000010", "platform or list of keys must be specified")
+	KeyErrorChangeState         = newBillingServerErrorMsg("ks000011", "unable to change state of keys")
+	KeyErrorThreshold           = newBillingServerErrorMsg("ks000012", "threshold of keys stock must not be negative")
+	KeyErrorPlatform            = newBillingServerErrorMsg("ks000013", "platform is required")
+	KeyErrorUnknown             = newBillingServerErrorMsg("ks000014", "unknown error with keys")
+	KeyErrorImportJobNotFound   = newBillingServerErrorMsg("ks000015", "key import job not found")
+	KeyErrorImportJobStatus     = newBillingServerErrorMsg("ks000016", "operation is not allowed in the current status of key import job")
+	KeyErrorImportChunkNumber   = newBillingServerErrorMsg("ks000017", "chunk number of key import is invalid")
+	KeyErrorImportChunkSize     = newBillingServerErrorMsg("ks000018", "chunk of key import is empty or too large")
+	KeyErrorImportChunksMissing = newBillingServerErrorMsg("ks000019", "not all chunks of key import were uploaded")
+	KeyErrorReservationTtl      = newBillingServerErrorMsg("ks000020", "time of key reservation must not be negative")
 )
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"strings"
+)
+
+const (
+	// KeyCodeEncryptedPrefix marks the key code stored encrypted, key codes without the prefix are stored as plaintext.
+	KeyCodeEncryptedPrefix = "enc:v1:"
+
+	keyCodeEnvelopeMask  = KeyCodeEncryptedPrefix + "%s:%s:%s"
+	keyCodeEnvelopeParts = 3
+)
+
+// MerchantDataKey is a data key for encrypting game activation keys of the merchant.
+// The data key is stored encrypted by the master key of the key management service.
+type MerchantDataKey struct {
+	Id           string               `json:"id"`
+	MerchantId   string               `json:"merchant_id"`
+	MasterKeyId  string               `json:"master_key_id"`
+	EncryptedKey []byte               `json:"-"`
+	CreatedAt    *timestamp.Timestamp `json:"created_at"`
+}
+
+// KeyCodeEnvelope is a representation of the encrypted game activation key code
+// stored in the code field of the key.
+type KeyCodeEnvelope struct {
+	// DataKeyId is an identifier of the merchant data key used to encrypt the code.
+	DataKeyId string
+	// Index is a keyed hash of the plaintext code used to find duplicate codes.
+	Index string
+	// Ciphertext is a base64 encoded encrypted code.
+	Ciphertext string
+}
+
+func (e *KeyCodeEnvelope) String() string {
+	return fmt.Sprintf(keyCodeEnvelopeMask, e.DataKeyId, e.Index, e.Ciphertext)
+}
+
+// ParseKeyCodeEnvelope returns the envelope of the encrypted key code
+// or false if the code is stored as plaintext.
+func ParseKeyCodeEnvelope(code string) (*KeyCodeEnvelope, bool) {
+	if !strings.HasPrefix(code, KeyCodeEncryptedPrefix) {
+		return nil, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(code, KeyCodeEncryptedPrefix), ":")
+
+	if len(parts) != keyCodeEnvelopeParts {
+		return nil, false
+	}
+
+	return &KeyCodeEnvelope{DataKeyId: parts[0], Index: parts[1], Ciphertext: parts[2]}, true
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// KeyImportJob is an asynchronous import of the file with keys uploaded by chunks.
+type KeyImportJob struct {
+	Id           string `json:"id"`
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	PlatformId   string `json:"platform_id"`
+	// Status is one of KeyImportJobStatusUploading, KeyImportJobStatusQueued, KeyImportJobStatusProcessing,
+	// KeyImportJobStatusCompleted or KeyImportJobStatusFailed.
+	Status string `json:"status"`
+	// ChunksCount is a total count of chunks of the file, it's known after the upload was committed.
+	ChunksCount    int32 `json:"chunks_count"`
+	ChunksUploaded int32 `json:"chunks_uploaded"`
+	// ChunksProcessed is a count of chunks processed in order, processing is resumed from the next chunk.
+	ChunksProcessed int32 `json:"chunks_processed"`
+	// LinesCount is a count of processed non-empty lines of the file.
+	LinesCount    int32 `json:"lines_count"`
+	KeysProcessed int32 `json:"keys_processed"`
+	// DuplicatesCount is a count of codes repeated in the file or already uploaded for the platform.
+	// Keys inserted before the processing was interrupted are counted as duplicates after resume.
+	DuplicatesCount int32 `json:"duplicates_count"`
+	// FailedCount is a count of too long codes and codes failed to encrypt or insert.
+	FailedCount int32                `json:"failed_count"`
+	Error       string               `json:"error,omitempty"`
+	LockedUntil *timestamp.Timestamp `json:"-"`
+	CreatedAt   *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt   *timestamp.Timestamp `json:"updated_at"`
+	StartedAt   *timestamp.Timestamp `json:"started_at,omitempty"`
+	FinishedAt  *timestamp.Timestamp `json:"finished_at,omitempty"`
+}
+
+// KeyImportChunk is a part of the file with keys, data of the chunk is encrypted by the data key of the merchant.
+type KeyImportChunk struct {
+	Id        string               `json:"id"`
+	JobId     string               `json:"job_id"`
+	Number    int32                `json:"number"`
+	DataKeyId string               `json:"data_key_id"`
+	Data      []byte               `json:"data"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+type CreateKeyImportJobRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	PlatformId   string `json:"platform_id"`
+}
+
+type UploadKeyImportChunkRequest struct {
+	MerchantId string `json:"merchant_id"`
+	JobId      string `json:"job_id"`
+	// Number is a sequence number of the chunk in the file starting from 1, repeated upload replaces the chunk.
+	Number int32  `json:"number"`
+	Data   []byte `json:"data"`
+}
+
+type CommitKeyImportJobRequest struct {
+	MerchantId  string `json:"merchant_id"`
+	JobId       string `json:"job_id"`
+	ChunksCount int32  `json:"chunks_count"`
+}
+
+type GetKeyImportJobRequest struct {
+	MerchantId string `json:"merchant_id"`
+	JobId      string `json:"job_id"`
+}
+
+type KeyImportJobResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *KeyImportJob                   `json:"item,omitempty"`
+}
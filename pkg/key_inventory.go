@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// KeyAudit is a record about the change of state of the game activation key.
+type KeyAudit struct {
+	Id           string `json:"id"`
+	KeyId        string `json:"key_id"`
+	KeyProductId string `json:"key_product_id"`
+	PlatformId   string `json:"platform_id"`
+	OrderId      string `json:"order_id,omitempty"`
+	// Action is one of KeyAuditActionUploaded, KeyAuditActionReserved, KeyAuditActionReleased,
+	// KeyAuditActionRedeemed, KeyAuditActionRevoked or KeyAuditActionExpired.
+	Action    string               `json:"action"`
+	UserId    string               `json:"user_id,omitempty"`
+	Reason    string               `json:"reason,omitempty"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+// KeyStockThreshold is a minimal count of available keys of the key product on the platform.
+// Merchant gets notification once when the stock falls to the threshold, notification is repeated
+// only after the stock was replenished above the threshold.
+type KeyStockThreshold struct {
+	Id           string `json:"id"`
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	PlatformId   string `json:"platform_id"`
+	// Threshold equal to zero disables notifications.
+	Threshold  int32                `json:"threshold"`
+	IsNotified bool                 `json:"is_notified"`
+	NotifiedAt *timestamp.Timestamp `json:"notified_at,omitempty"`
+	CreatedAt  *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt  *timestamp.Timestamp `json:"updated_at"`
+}
+
+// KeysUploadSummary is a result of processing of the uploaded file with keys.
+type KeysUploadSummary struct {
+	// TotalCount is a count of available keys of the key product on the platform after upload.
+	TotalCount    int32                     `json:"total_count"`
+	LinesCount    int32                     `json:"lines_count"`
+	KeysProcessed int32                     `json:"keys_processed"`
+	Rejected      []*KeysUploadRejectedLine `json:"rejected"`
+}
+
+type KeysUploadRejectedLine struct {
+	// Line is a number of line in the file starting from 1.
+	Line int32 `json:"line"`
+	// Reason is one of KeyUploadRejectReasonEmpty, KeyUploadRejectReasonTooLong, KeyUploadRejectReasonDuplicateInFile,
+	// KeyUploadRejectReasonDuplicateInStock or KeyUploadRejectReasonInsertFailed.
+	Reason string `json:"reason"`
+}
+
+type UploadKeysFileResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *KeysUploadSummary              `json:"item,omitempty"`
+}
+
+type GetKeysRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	PlatformId   string `json:"platform_id"`
+	// State is one of KeyStateAvailable, KeyStateReserved, KeyStateRedeemed, KeyStateRevoked or KeyStateExpired.
+	State  string `json:"state"`
+	Limit  int64  `json:"limit"`
+	Offset int64  `json:"offset"`
+}
+
+type GetKeysResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*billingpb.Key                `json:"items,omitempty"`
+}
+
+type ChangeKeysStateRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	// PlatformId is required if list of keys is empty, in this case all available keys of the platform are changed.
+	PlatformId string   `json:"platform_id"`
+	KeyIds     []string `json:"key_ids"`
+	UserId     string   `json:"user_id"`
+	Reason     string   `json:"reason"`
+}
+
+type ChangeKeysStateResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int32                           `json:"count"`
+	// Skipped is a list of keys which are not available (reserved, redeemed, revoked or not found).
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+type SetKeyStockThresholdRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	PlatformId   string `json:"platform_id"`
+	Threshold    int32  `json:"threshold"`
+}
+
+type GetKeyStockThresholdsRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+}
+
+type KeyStockThresholdResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *KeyStockThreshold              `json:"item,omitempty"`
+}
+
+type GetKeyStockThresholdsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*KeyStockThreshold            `json:"items,omitempty"`
+}
+
+type GetKeyAuditRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	KeyId        string `json:"key_id"`
+	Limit        int64  `json:"limit"`
+	Offset       int64  `json:"offset"`
+}
+
+type GetKeyAuditResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*KeyAudit                     `json:"items,omitempty"`
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// KeyProductRelease is a release date of the key product, keys of orders paid before the release are delivered
+// at the release.
+type KeyProductRelease struct {
+	Id           string               `json:"id"`
+	MerchantId   string               `json:"merchant_id"`
+	KeyProductId string               `json:"key_product_id"`
+	ReleaseAt    *timestamp.Timestamp `json:"release_at"`
+	// Regions contains release dates in price regions which differ from the default release date.
+	Regions   map[string]*timestamp.Timestamp `json:"regions,omitempty"`
+	CreatedAt *timestamp.Timestamp            `json:"created_at"`
+	UpdatedAt *timestamp.Timestamp            `json:"updated_at"`
+}
+
+// KeyPreOrder is a key allocated for the order paid before the release of the key product.
+type KeyPreOrder struct {
+	Id           string `json:"id"`
+	OrderId      string `json:"order_id"`
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	KeyId        string `json:"key_id"`
+	PlatformId   string `json:"platform_id"`
+	// Region is a price region of the customer country, the key is delivered at the release date in the region.
+	Region string `json:"region"`
+	// Status is one of KeyPreOrderStatusPending, KeyPreOrderStatusDelivered or KeyPreOrderStatusRefunded.
+	Status      string               `json:"status"`
+	ReleaseAt   *timestamp.Timestamp `json:"release_at"`
+	DeliveredAt *timestamp.Timestamp `json:"delivered_at,omitempty"`
+	CreatedAt   *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt   *timestamp.Timestamp `json:"updated_at"`
+}
+
+type SetKeyProductReleaseRequest struct {
+	MerchantId   string                          `json:"merchant_id"`
+	KeyProductId string                          `json:"key_product_id"`
+	ReleaseAt    *timestamp.Timestamp            `json:"release_at"`
+	Regions      map[string]*timestamp.Timestamp `json:"regions"`
+}
+
+type GetKeyProductReleaseRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+}
+
+type KeyProductReleaseResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *KeyProductRelease              `json:"item,omitempty"`
+}
+
+type GetKeyPreOrdersRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	// Status filters pre-orders by status, pre-orders in all statuses are returned if it's empty.
+	Status string `json:"status"`
+	Offset int64  `json:"offset"`
+	Limit  int64  `json:"limit"`
+}
+
+type GetKeyPreOrdersResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*KeyPreOrder                  `json:"items,omitempty"`
+}
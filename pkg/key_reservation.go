@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// KeyReservationPolicy is a lifecycle of reservations of keys of the key product for orders.
+type KeyReservationPolicy struct {
+	Id           string `json:"id"`
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+	// Ttl is a time in seconds the key is reserved for the new order, zero means the default time.
+	Ttl int32 `json:"ttl"`
+	// FailedOrderTtl is a time in seconds the key stays reserved after the order was cancelled or failed,
+	// zero means the key is released immediately.
+	FailedOrderTtl int32                `json:"failed_order_ttl"`
+	CreatedAt      *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt      *timestamp.Timestamp `json:"updated_at"`
+}
+
+// KeyStateCount is a count of keys of the key product on the platform in the state.
+type KeyStateCount struct {
+	KeyProductId string `json:"key_product_id"`
+	PlatformId   string `json:"platform_id"`
+	State        string `json:"state"`
+	Count        int64  `json:"count"`
+}
+
+type SetKeyReservationPolicyRequest struct {
+	MerchantId     string `json:"merchant_id"`
+	KeyProductId   string `json:"key_product_id"`
+	Ttl            int32  `json:"ttl"`
+	FailedOrderTtl int32  `json:"failed_order_ttl"`
+}
+
+type GetKeyReservationPolicyRequest struct {
+	MerchantId   string `json:"merchant_id"`
+	KeyProductId string `json:"key_product_id"`
+}
+
+type KeyReservationPolicyResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *KeyReservationPolicy           `json:"item,omitempty"`
+}
@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// MerchantBankingChange is a record of the history of merchant bank account changes.
+// New bank account must be confirmed by the merchant owner and verified by micro-deposits or by admin,
+// payouts of the merchant are held until verification and cooling-off period end.
+type MerchantBankingChange struct {
+	Id         string                     `json:"id,omitempty"`
+	MerchantId string                     `json:"merchant_id,omitempty"`
+	Previous   *billingpb.MerchantBanking `json:"previous,omitempty"`
+	Banking    *billingpb.MerchantBanking `json:"banking,omitempty"`
+	// Status is one of MerchantBankingChangeStatusPending, MerchantBankingChangeStatusVerified
+	// or MerchantBankingChangeStatusRejected.
+	Status string `json:"status,omitempty"`
+	// VerificationMethod is one of MerchantBankingVerificationMicroDeposit or MerchantBankingVerificationAdmin.
+	VerificationMethod string `json:"verification_method,omitempty"`
+	// MicroDeposits are amounts of test transfers to the new bank account which merchant must confirm.
+	MicroDeposits        []float64 `json:"micro_deposits,omitempty"`
+	MicroDepositAttempts int32     `json:"micro_deposit_attempts,omitempty"`
+	// ConfirmationCodeHash is a salted hash of the code sent to the merchant owner to confirm the change.
+	ConfirmationCodeHash string `json:"-"`
+	ConfirmationCodeSalt string `json:"-"`
+	// ConfirmationCodeAttempts is a count of failed attempts to confirm the change, the change is rejected
+	// after too many failed attempts.
+	ConfirmationCodeAttempts  int32                `json:"confirmation_code_attempts,omitempty"`
+	ConfirmationCodeExpiresAt *timestamp.Timestamp `json:"confirmation_code_expires_at,omitempty"`
+	IsOwnerConfirmed          bool                 `json:"is_owner_confirmed,omitempty"`
+	IsAccountVerified         bool                 `json:"is_account_verified,omitempty"`
+	ReviewerId                string               `json:"reviewer_id,omitempty"`
+	RejectReason              string               `json:"reject_reason,omitempty"`
+	UserId                    string               `json:"user_id,omitempty"`
+	Ip                        string               `json:"ip,omitempty"`
+	HoldUntil                 *timestamp.Timestamp `json:"hold_until,omitempty"`
+	VerifiedAt                *timestamp.Timestamp `json:"verified_at,omitempty"`
+	CreatedAt                 *timestamp.Timestamp `json:"created_at,omitempty"`
+	UpdatedAt                 *timestamp.Timestamp `json:"updated_at,omitempty"`
+}
+
+type ChangeMerchantBankingRequest struct {
+	MerchantId string                     `json:"merchant_id"`
+	UserId     string                     `json:"user_id"`
+	Banking    *billingpb.MerchantBanking `json:"banking"`
+	// VerificationMethod is MerchantBankingVerificationMicroDeposit by default.
+	VerificationMethod string `json:"verification_method"`
+	Ip                 string `json:"ip"`
+}
+
+type ConfirmMerchantBankingChangeRequest struct {
+	MerchantId string `json:"merchant_id"`
+	ChangeId   string `json:"change_id"`
+	UserId     string `json:"user_id"`
+	Code       string `json:"code"`
+}
+
+type VerifyMerchantBankingMicroDepositsRequest struct {
+	MerchantId string    `json:"merchant_id"`
+	ChangeId   string    `json:"change_id"`
+	Amounts    []float64 `json:"amounts"`
+}
+
+type ReviewMerchantBankingChangeRequest struct {
+	MerchantId string `json:"merchant_id"`
+	ChangeId   string `json:"change_id"`
+	UserId     string `json:"user_id"`
+	IsApproved bool   `json:"is_approved"`
+	Reason     string `json:"reason"`
+}
+
+type GetMerchantBankingChangesRequest struct {
+	MerchantId string `json:"merchant_id"`
+}
+
+type MerchantBankingChangeResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *MerchantBankingChange          `json:"item,omitempty"`
+}
+
+type GetMerchantBankingChangesResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*MerchantBankingChange        `json:"items,omitempty"`
+}
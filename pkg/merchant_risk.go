@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// MerchantRiskMetrics are counters and ratios of payments of the merchant during the period of the risk monitoring.
+// Amounts are in the payout currency of the merchant.
+type MerchantRiskMetrics struct {
+	SalesCount      int64 `json:"sales_count"`
+	RefundCount     int64 `json:"refund_count"`
+	ChargebackCount int64 `json:"chargeback_count"`
+	// AttemptCount is a count of completed payment attempts, both successful and declined.
+	AttemptCount int64   `json:"attempt_count"`
+	DeclineCount int64   `json:"decline_count"`
+	SalesAmount  float64 `json:"sales_amount"`
+	// DailySalesAmount is an amount of sales of the last day of the period, AverageDailySalesAmount is an average
+	// amount of sales of previous days of the period.
+	DailySalesAmount        float64 `json:"daily_sales_amount"`
+	AverageDailySalesAmount float64 `json:"average_daily_sales_amount"`
+	Currency                string  `json:"currency"`
+	ChargebackRatio         float64 `json:"chargeback_ratio"`
+	RefundRatio             float64 `json:"refund_ratio"`
+	DeclineRate             float64 `json:"decline_rate"`
+	// SalesSpike is a ratio of the daily amount of sales to the average daily amount of sales.
+	SalesSpike float64 `json:"sales_spike"`
+}
+
+// MerchantRiskAlert is a metric of the merchant exceeding the threshold.
+type MerchantRiskAlert struct {
+	// Metric is one of MerchantRiskMetric* constants.
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+}
+
+// MerchantRiskReviewEvent is a record of the audit trail of the review.
+type MerchantRiskReviewEvent struct {
+	Status string `json:"status"`
+	// Actions are MerchantRiskAction* constants applied to the merchant on this event.
+	Actions   []string             `json:"actions,omitempty"`
+	UserId    string               `json:"user_id,omitempty"`
+	Comment   string               `json:"comment,omitempty"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+// MerchantRiskReview is an alert of the daily risk monitoring of the merchant. The merchant has one unresolved
+// review at most, it's updated by following runs of the monitoring while metrics exceed thresholds. Processing
+// of payments of the merchant is suspended while the review has the suspended status.
+type MerchantRiskReview struct {
+	Id         string               `json:"id"`
+	MerchantId string               `json:"merchant_id"`
+	PeriodFrom *timestamp.Timestamp `json:"period_from"`
+	PeriodTo   *timestamp.Timestamp `json:"period_to"`
+	Metrics    *MerchantRiskMetrics `json:"metrics"`
+	Alerts     []*MerchantRiskAlert `json:"alerts"`
+	// Actions are MerchantRiskAction* constants applied to the merchant by the monitoring.
+	Actions []string `json:"actions,omitempty"`
+	// OperationsType is the operations type of the merchant before the review.
+	OperationsType string `json:"operations_type"`
+	// Status is one of MerchantRiskReviewStatus* constants.
+	Status     string                     `json:"status"`
+	ResolvedBy string                     `json:"resolved_by,omitempty"`
+	ResolvedAt *timestamp.Timestamp       `json:"resolved_at,omitempty"`
+	History    []*MerchantRiskReviewEvent `json:"history"`
+	CreatedAt  *timestamp.Timestamp       `json:"created_at"`
+	UpdatedAt  *timestamp.Timestamp       `json:"updated_at"`
+}
+
+type ListMerchantRiskReviewsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Status     string `json:"status"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+}
+
+type ListMerchantRiskReviewsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*MerchantRiskReview           `json:"items,omitempty"`
+}
+
+// ResolveMerchantRiskReviewRequest resolves the review, processing of payments of the merchant is resumed
+// if it was suspended by the review. Other actions of the review are kept and can be reverted by merchant settings.
+type ResolveMerchantRiskReviewRequest struct {
+	Id      string `json:"id"`
+	UserId  string `json:"user_id"`
+	Comment string `json:"comment"`
+}
+
+type MerchantRiskReviewResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *MerchantRiskReview             `json:"item,omitempty"`
+}
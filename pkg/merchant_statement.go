@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// MerchantStatement is a statement of account of the merchant for the period in the payout currency.
+// Closing balance of the statement for the current moment equals to the total of merchant balance.
+type MerchantStatement struct {
+	MerchantId     string                   `json:"merchant_id"`
+	Currency       string                   `json:"currency"`
+	From           *timestamp.Timestamp     `json:"from"`
+	To             *timestamp.Timestamp     `json:"to"`
+	OpeningBalance float64                  `json:"opening_balance"`
+	ClosingBalance float64                  `json:"closing_balance"`
+	TotalDebit     float64                  `json:"total_debit"`
+	TotalCredit    float64                  `json:"total_credit"`
+	Items          []*MerchantStatementItem `json:"items"`
+	CreatedAt      *timestamp.Timestamp     `json:"created_at"`
+}
+
+// MerchantStatementItem is a single movement of the merchant statement of account.
+type MerchantStatementItem struct {
+	// Type is one of MerchantStatementItemType* constants.
+	Type string `json:"type"`
+	// SourceId is identifier of royalty report, accounting entry or payout document.
+	SourceId    string               `json:"source_id"`
+	Description string               `json:"description"`
+	Date        *timestamp.Timestamp `json:"date"`
+	Amount      float64              `json:"amount"`
+	Balance     float64              `json:"balance"`
+}
+
+type GetMerchantStatementRequest struct {
+	MerchantId string `json:"merchant_id"`
+	// From and To are the period boundaries as unix timestamps. Empty To means the current moment.
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+type GetMerchantStatementResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *MerchantStatement              `json:"item,omitempty"`
+}
+
+type CreateMerchantStatementFileRequest struct {
+	MerchantId string `json:"merchant_id"`
+	UserId     string `json:"user_id"`
+	From       int64  `json:"from"`
+	To         int64  `json:"to"`
+	// FileType is one of reporter output extensions, pdf and csv are supported.
+	FileType string `json:"file_type"`
+}
@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// OrderReview is a manual review of the payment held by the risk engine. Delivery of the order and notifications
+// of the merchant wait until the review is resolved by the risk manager.
+type OrderReview struct {
+	Id               string  `json:"id"`
+	OrderId          string  `json:"order_id"`
+	OrderUuid        string  `json:"order_uuid"`
+	MerchantId       string  `json:"merchant_id"`
+	ProjectId        string  `json:"project_id"`
+	RiskAssessmentId string  `json:"risk_assessment_id,omitempty"`
+	Score            int32   `json:"score"`
+	Amount           float64 `json:"amount"`
+	Currency         string  `json:"currency"`
+	// Status is one of OrderReviewStatus* constants.
+	Status string `json:"status"`
+	// Captured is set when the payment system has captured the payment instead of the authorization only,
+	// such payments are refunded when the review is declined.
+	Captured bool `json:"captured"`
+	// ClaimedBy is an identifier of the risk manager who works with the review.
+	ClaimedBy      string               `json:"claimed_by,omitempty"`
+	ClaimExpiresAt *timestamp.Timestamp `json:"claim_expires_at,omitempty"`
+	ResolvedBy     string               `json:"resolved_by,omitempty"`
+	ResolvedAt     *timestamp.Timestamp `json:"resolved_at,omitempty"`
+	Comment        string               `json:"comment,omitempty"`
+	// SlaDueAt is a time until which the review should be resolved.
+	SlaDueAt  *timestamp.Timestamp `json:"sla_due_at"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt *timestamp.Timestamp `json:"updated_at"`
+}
+
+type ListOrderReviewsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Status     string `json:"status"`
+	// Overdue returns pending reviews which aren't resolved in time only.
+	Overdue bool  `json:"overdue"`
+	Limit   int64 `json:"limit"`
+	Offset  int64 `json:"offset"`
+}
+
+type ListOrderReviewsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*OrderReview                  `json:"items,omitempty"`
+}
+
+type OrderReviewRequest struct {
+	Id      string `json:"id"`
+	UserId  string `json:"user_id"`
+	Comment string `json:"comment"`
+}
+
+type OrderReviewResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *OrderReview                    `json:"item,omitempty"`
+}
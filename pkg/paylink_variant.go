@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// PaylinkVariant is a variant of the paylink used for A/B testing. Traffic of the paylink is split between
+// its variants in proportion to their weights, the paylink without variants works as usual.
+type PaylinkVariant struct {
+	Id         string `json:"id"`
+	PaylinkId  string `json:"paylink_id"`
+	MerchantId string `json:"merchant_id"`
+	Name       string `json:"name"`
+	// Weight is a share of traffic of the paylink, the variant with zero weight doesn't get new visitors.
+	Weight int32 `json:"weight"`
+	// Products replaces products of the paylink for the variant, products of the paylink are used if it's empty.
+	Products []string `json:"products,omitempty"`
+	// PromoCode is a code of the promo of the project which is applied to all orders of the variant.
+	PromoCode string               `json:"promo_code,omitempty"`
+	Deleted   bool                 `json:"deleted"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt *timestamp.Timestamp `json:"updated_at"`
+}
+
+// PaylinkFunnelEvent is a fact of passing the funnel step by the visitor of the paylink.
+type PaylinkFunnelEvent struct {
+	Id        string `json:"id"`
+	PaylinkId string `json:"paylink_id"`
+	// VariantId is empty for paylinks without variants.
+	VariantId string `json:"variant_id,omitempty"`
+	// Step is one of PaylinkFunnelStepVisit, PaylinkFunnelStepFormOpen, PaylinkFunnelStepPaymentAttempt
+	// or PaylinkFunnelStepSuccess.
+	Step      string               `json:"step"`
+	VisitorId string               `json:"visitor_id,omitempty"`
+	OrderId   string               `json:"order_id,omitempty"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+// PaylinkFunnelStepStat is a statistics of the funnel step of the paylink variant.
+type PaylinkFunnelStepStat struct {
+	Step string `json:"step"`
+	// Count is a count of unique visitors for the visit step and a count of unique orders for other steps.
+	Count int64 `json:"count"`
+	// Conversion is a conversion from the previous step in percents, it is zero for the visit step.
+	Conversion float64 `json:"conversion"`
+	// PValue is a p-value of the two-proportion z-test of the step conversion against the control variant.
+	PValue        float64 `json:"p_value"`
+	IsSignificant bool    `json:"is_significant"`
+}
+
+// PaylinkVariantFunnelStat is a funnel statistics of the paylink variant.
+type PaylinkVariantFunnelStat struct {
+	VariantId string `json:"variant_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Weight    int32  `json:"weight"`
+	// IsControl is true for the oldest variant, other variants are compared with it.
+	IsControl bool                     `json:"is_control"`
+	Steps     []*PaylinkFunnelStepStat `json:"steps"`
+	// Conversion is a conversion from the visit to the successful payment in percents.
+	Conversion float64 `json:"conversion"`
+	// Uplift is a relative change of the conversion against the control variant in percents.
+	Uplift        float64 `json:"uplift"`
+	PValue        float64 `json:"p_value"`
+	IsSignificant bool    `json:"is_significant"`
+}
+
+type CreateOrUpdatePaylinkVariantRequest struct {
+	Id         string   `json:"id"`
+	PaylinkId  string   `json:"paylink_id"`
+	MerchantId string   `json:"merchant_id"`
+	Name       string   `json:"name"`
+	Weight     int32    `json:"weight"`
+	Products   []string `json:"products"`
+	PromoCode  string   `json:"promo_code"`
+}
+
+type PaylinkVariantResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *PaylinkVariant                 `json:"item,omitempty"`
+}
+
+type GetPaylinkVariantsRequest struct {
+	PaylinkId  string `json:"paylink_id"`
+	MerchantId string `json:"merchant_id"`
+}
+
+type GetPaylinkVariantsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*PaylinkVariant               `json:"items,omitempty"`
+}
+
+type DeletePaylinkVariantRequest struct {
+	Id         string `json:"id"`
+	PaylinkId  string `json:"paylink_id"`
+	MerchantId string `json:"merchant_id"`
+}
+
+// AssignPaylinkVariantRequest is sent by the paylink page on the visit. VisitorId must be equal
+// to the cookie passed to OrderCreateByPaylink to keep the visitor in the same variant.
+type AssignPaylinkVariantRequest struct {
+	PaylinkId string `json:"paylink_id"`
+	VisitorId string `json:"visitor_id"`
+}
+
+type GetPaylinkFunnelStatResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*PaylinkVariantFunnelStat     `json:"items,omitempty"`
+}
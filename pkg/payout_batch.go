@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// PayoutBatch is a group of pending payout documents of the operating company in the same currency
+// which are transferred to merchants by the single bank file.
+type PayoutBatch struct {
+	Id                 string `json:"id,omitempty"`
+	OperatingCompanyId string `json:"operating_company_id,omitempty"`
+	Currency           string `json:"currency,omitempty"`
+	// Format is one of PayoutBatchFormatSepa (ISO 20022 pain.001 credit transfer XML) or PayoutBatchFormatSwift (CSV).
+	Format string `json:"format,omitempty"`
+	// Status is one of PayoutBatchStatusCreated or PayoutBatchStatusProcessed.
+	Status            string               `json:"status,omitempty"`
+	PayoutDocumentIds []string             `json:"payout_document_ids,omitempty"`
+	TotalAmount       float64              `json:"total_amount,omitempty"`
+	DebtorName        string               `json:"debtor_name,omitempty"`
+	DebtorAccount     string               `json:"debtor_account,omitempty"`
+	DebtorSwift       string               `json:"debtor_swift,omitempty"`
+	ExecutionDate     *timestamp.Timestamp `json:"execution_date,omitempty"`
+	CreatedAt         *timestamp.Timestamp `json:"created_at,omitempty"`
+	UpdatedAt         *timestamp.Timestamp `json:"updated_at,omitempty"`
+}
+
+type CreatePayoutBatchesRequest struct {
+	OperatingCompanyId string `json:"operating_company_id"`
+	// Currency limits batches to the payout documents in the currency. All currencies are used by default,
+	// SEPA batches are created for EUR payout documents only.
+	Currency      string `json:"currency"`
+	Format        string `json:"format"`
+	DebtorName    string `json:"debtor_name"`
+	DebtorAccount string `json:"debtor_account"`
+	DebtorSwift   string `json:"debtor_swift"`
+	// ExecutionDate is unix timestamp of the requested execution date of transfers. Current date is used by default.
+	ExecutionDate int64  `json:"execution_date"`
+	Ip            string `json:"ip"`
+}
+
+type CreatePayoutBatchesResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*PayoutBatch                  `json:"items,omitempty"`
+	// SkippedPayoutDocumentIds contains payout documents left in pending status because of incomplete banking details,
+	// holds of the merchant or the failure of the batch creation.
+	SkippedPayoutDocumentIds []string `json:"skipped_payout_document_ids,omitempty"`
+}
+
+type GetPayoutBatchRequest struct {
+	Id string `json:"id"`
+}
+
+type PayoutBatchResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *PayoutBatch                    `json:"item,omitempty"`
+}
+
+type PayoutBatchFileResponse struct {
+	Status      int32                           `json:"status"`
+	Message     *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Filename    string                          `json:"filename,omitempty"`
+	ContentType string                          `json:"content_type,omitempty"`
+	Content     []byte                          `json:"content,omitempty"`
+}
+
+type ImportPayoutBatchStatusRequest struct {
+	// Content is a bank status file in ISO 20022 pain.002 or camt.053/camt.054 format.
+	Content []byte `json:"content"`
+	Ip      string `json:"ip"`
+}
+
+// PayoutBatchStatusImportItem is a result of the payout document status update from the bank status file.
+type PayoutBatchStatusImportItem struct {
+	PayoutDocumentId string `json:"payout_document_id"`
+	Status           string `json:"status"`
+	FailureCode      string `json:"failure_code,omitempty"`
+	FailureMessage   string `json:"failure_message,omitempty"`
+	// Error contains the reason if the payout document status was not updated.
+	Error string `json:"error,omitempty"`
+}
+
+type ImportPayoutBatchStatusResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*PayoutBatchStatusImportItem  `json:"items,omitempty"`
+}
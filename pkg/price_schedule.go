@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// PriceSchedule is a temporary change of prices of the product or key product. Prices of the schedule replace
+// prices of the product in the same regions from StartAt till EndAt, after EndAt prices of the product are
+// used again.
+type PriceSchedule struct {
+	Id         string `json:"id"`
+	MerchantId string `json:"merchant_id"`
+	ProductId  string `json:"product_id"`
+	// ProductType is one of OrderItemTypeProduct or OrderItemTypeKeyProduct.
+	ProductType string `json:"product_type"`
+	// PlatformId is a platform of the key product the prices are changed for.
+	PlatformId string                    `json:"platform_id,omitempty"`
+	Name       string                    `json:"name"`
+	Prices     []*billingpb.ProductPrice `json:"prices"`
+	StartAt    *timestamp.Timestamp      `json:"start_at"`
+	EndAt      *timestamp.Timestamp      `json:"end_at"`
+	Cancelled  bool                      `json:"cancelled"`
+	CreatedAt  *timestamp.Timestamp      `json:"created_at"`
+	UpdatedAt  *timestamp.Timestamp      `json:"updated_at"`
+}
+
+// PriceHistory is a snapshot of prices of the product or key product saved on each change of prices.
+type PriceHistory struct {
+	Id          string `json:"id"`
+	MerchantId  string `json:"merchant_id"`
+	ProductId   string `json:"product_id"`
+	ProductType string `json:"product_type"`
+	// Prices contains prices of the product, Platforms contains prices of the key product.
+	Prices    []*billingpb.ProductPrice  `json:"prices,omitempty"`
+	Platforms []*billingpb.PlatformPrice `json:"platforms,omitempty"`
+	CreatedAt *timestamp.Timestamp       `json:"created_at"`
+}
+
+type CreatePriceScheduleRequest struct {
+	MerchantId  string                    `json:"merchant_id"`
+	ProductId   string                    `json:"product_id"`
+	ProductType string                    `json:"product_type"`
+	PlatformId  string                    `json:"platform_id"`
+	Name        string                    `json:"name"`
+	Prices      []*billingpb.ProductPrice `json:"prices"`
+	StartAt     *timestamp.Timestamp      `json:"start_at"`
+	EndAt       *timestamp.Timestamp      `json:"end_at"`
+}
+
+type CancelPriceScheduleRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Id         string `json:"id"`
+}
+
+type PriceScheduleResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *PriceSchedule                  `json:"item,omitempty"`
+}
+
+type GetProductPriceHistoryRequest struct {
+	MerchantId  string `json:"merchant_id"`
+	ProductId   string `json:"product_id"`
+	ProductType string `json:"product_type"`
+}
+
+type GetProductPriceHistoryResponse struct {
+	Status    int32                           `json:"status"`
+	Message   *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	History   []*PriceHistory                 `json:"history,omitempty"`
+	Schedules []*PriceSchedule                `json:"schedules,omitempty"`
+}
+
+// GetEffectivePricesRequest requests prices of the product which were effective at the time, for example
+// at the time of creation of the order.
+type GetEffectivePricesRequest struct {
+	MerchantId  string               `json:"merchant_id"`
+	ProductId   string               `json:"product_id"`
+	ProductType string               `json:"product_type"`
+	At          *timestamp.Timestamp `json:"at"`
+}
+
+type GetEffectivePricesResponse struct {
+	Status    int32                           `json:"status"`
+	Message   *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Prices    []*billingpb.ProductPrice       `json:"prices,omitempty"`
+	Platforms []*billingpb.PlatformPrice      `json:"platforms,omitempty"`
+	// ScheduleIds contains identities of schedules which changed prices at the time.
+	ScheduleIds []string `json:"schedule_ids,omitempty"`
+}
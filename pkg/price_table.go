@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// PriceTableRule contains settings used to calculate the recommended price table of the region.
+type PriceTableRule struct {
+	Id     string `json:"id"`
+	Region string `json:"region"`
+	// PurchasingPowerIndex is a multiplier of prices converted from the base region, 1 if prices must be
+	// converted by exchange rate only.
+	PurchasingPowerIndex float64 `json:"purchasing_power_index"`
+	// CharmStep and CharmEnding define the charm price rounding, the price is rounded up to the nearest value
+	// which ends with CharmEnding on each CharmStep, for example 0.99 with step 1 or 9 with step 10.
+	CharmStep   float64              `json:"charm_step"`
+	CharmEnding float64              `json:"charm_ending"`
+	CreatedAt   *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt   *timestamp.Timestamp `json:"updated_at"`
+}
+
+// PriceTableVersionRate is a rate used to calculate the price table of the region in the version.
+type PriceTableVersionRate struct {
+	Region               string  `json:"region"`
+	Currency             string  `json:"currency"`
+	ExchangeRate         float64 `json:"exchange_rate"`
+	PurchasingPowerIndex float64 `json:"purchasing_power_index"`
+}
+
+// PriceTableVersion is a set of recommended price tables of all regions calculated at one time. The version
+// is pending until it applied to the price tables used for recommendations.
+type PriceTableVersion struct {
+	Id        string                   `json:"id"`
+	Version   int32                    `json:"version"`
+	Status    string                   `json:"status"`
+	Tables    []*billingpb.PriceTable  `json:"tables"`
+	Rates     []*PriceTableVersionRate `json:"rates"`
+	CreatedAt *timestamp.Timestamp     `json:"created_at"`
+	AppliedAt *timestamp.Timestamp     `json:"applied_at,omitempty"`
+}
+
+type SetPriceTableRuleRequest struct {
+	Region               string  `json:"region"`
+	PurchasingPowerIndex float64 `json:"purchasing_power_index"`
+	CharmStep            float64 `json:"charm_step"`
+	CharmEnding          float64 `json:"charm_ending"`
+}
+
+type PriceTableRuleResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *PriceTableRule                 `json:"item,omitempty"`
+}
+
+type GetPriceTableVersionsRequest struct {
+	Status string `json:"status"`
+	Limit  int64  `json:"limit"`
+	Offset int64  `json:"offset"`
+}
+
+type GetPriceTableVersionsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*PriceTableVersion            `json:"items,omitempty"`
+}
+
+type ApplyPriceTableVersionRequest struct {
+	Id string `json:"id"`
+}
+
+type PriceTableVersionResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *PriceTableVersion              `json:"item,omitempty"`
+}
+
+// GetRecommendedPriceDiffRequest requests changes of recommended prices of products of the merchant which will
+// be made by the price table version, the last pending version is used if identity of version is not set.
+type GetRecommendedPriceDiffRequest struct {
+	MerchantId  string `json:"merchant_id"`
+	ProjectId   string `json:"project_id"`
+	VersionId   string `json:"version_id"`
+	ProductType string `json:"product_type"`
+	Limit       int64  `json:"limit"`
+	Offset      int64  `json:"offset"`
+}
+
+// RecommendedPriceDiff is a change of the recommended price of the product in the region.
+type RecommendedPriceDiff struct {
+	ProductId   string `json:"product_id"`
+	ProductType string `json:"product_type"`
+	PlatformId  string `json:"platform_id,omitempty"`
+	Region      string `json:"region"`
+	Currency    string `json:"currency"`
+	// Amount is the current price of the product in the region, zero if the product has no price in the region.
+	Amount             float64 `json:"amount"`
+	CurrentRecommended float64 `json:"current_recommended"`
+	NewRecommended     float64 `json:"new_recommended"`
+	// Change is a change of the recommended price in percents.
+	Change float64 `json:"change"`
+}
+
+type GetRecommendedPriceDiffResponse struct {
+	Status    int32                           `json:"status"`
+	Message   *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	VersionId string                          `json:"version_id,omitempty"`
+	Items     []*RecommendedPriceDiff         `json:"items,omitempty"`
+}
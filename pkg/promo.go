@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// Promo is a discount of the merchant. The promo with a code is applied when the customer enters the code
+// in the payment form, the promo without a code is an automatic campaign applied to all matching orders.
+type Promo struct {
+	Id         string `json:"id"`
+	Object     string `json:"object"`
+	MerchantId string `json:"merchant_id"`
+	ProjectId  string `json:"project_id"`
+	// Code is stored in upper case, the promo is an automatic campaign if it's empty.
+	Code string `json:"code,omitempty"`
+	Name string `json:"name"`
+	// Type is one of PromoTypePercent, PromoTypeFixed or PromoTypeBuyXGetY.
+	Type string `json:"type"`
+	// Percent is a discount in percents of the item price for PromoTypePercent.
+	Percent float64 `json:"percent,omitempty"`
+	// Amounts contains discounts of the order for PromoTypeFixed, the promo isn't applied to orders
+	// in other currencies.
+	Amounts []*PromoAmount `json:"amounts,omitempty"`
+	// BuyQuantity and FreeQuantity are used for PromoTypeBuyXGetY, the cheapest FreeQuantity items
+	// of each BuyQuantity + FreeQuantity items are free.
+	BuyQuantity  int32 `json:"buy_quantity,omitempty"`
+	FreeQuantity int32 `json:"free_quantity,omitempty"`
+	// Products contains identities of products, key products and bundles the promo is applied to,
+	// the promo is applied to all items of the order if it's empty.
+	Products []string `json:"products,omitempty"`
+	// Regions contains price regions the promo is applied in, the promo is applied in all regions if it's empty.
+	Regions   []string             `json:"regions,omitempty"`
+	ValidFrom *timestamp.Timestamp `json:"valid_from,omitempty"`
+	ValidTo   *timestamp.Timestamp `json:"valid_to,omitempty"`
+	// MaxUses and MaxUsesPerCustomer limit count of paid orders with the promo, zero means unlimited.
+	MaxUses            int64                `json:"max_uses,omitempty"`
+	MaxUsesPerCustomer int64                `json:"max_uses_per_customer,omitempty"`
+	Enabled            bool                 `json:"enabled"`
+	Deleted            bool                 `json:"deleted"`
+	CreatedAt          *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt          *timestamp.Timestamp `json:"updated_at"`
+}
+
+type PromoAmount struct {
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+}
+
+// PromoUsage is a fact of usage of the promo in the paid order. The usage of the promo with usage limits
+// is reserved when the promo is applied to the order and is confirmed when the order is paid.
+type PromoUsage struct {
+	Id         string `json:"id"`
+	PromoId    string `json:"promo_id"`
+	MerchantId string `json:"merchant_id"`
+	OrderId    string `json:"order_id"`
+	CustomerId string `json:"customer_id"`
+	// Discount is a total discount of the order in the order currency.
+	Discount float64 `json:"discount"`
+	Currency string  `json:"currency"`
+	// Status is one of PromoUsageStatusReserved or PromoUsageStatusUsed.
+	Status string `json:"status"`
+	// ExpiresAt is a time after which the reservation of the unpaid order may be released.
+	ExpiresAt *timestamp.Timestamp `json:"expires_at,omitempty"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+// PromoDiscountSummaryItem is a total of discounts of the promo in the currency.
+type PromoDiscountSummaryItem struct {
+	PromoId     string  `json:"promo_id"`
+	Code        string  `json:"code,omitempty"`
+	Name        string  `json:"name"`
+	Currency    string  `json:"currency"`
+	OrdersCount int64   `json:"orders_count"`
+	Discount    float64 `json:"discount"`
+}
+
+type CreateOrUpdatePromoRequest struct {
+	Id                 string               `json:"id"`
+	MerchantId         string               `json:"merchant_id"`
+	ProjectId          string               `json:"project_id"`
+	Code               string               `json:"code"`
+	Name               string               `json:"name"`
+	Type               string               `json:"type"`
+	Percent            float64              `json:"percent"`
+	Amounts            []*PromoAmount       `json:"amounts"`
+	BuyQuantity        int32                `json:"buy_quantity"`
+	FreeQuantity       int32                `json:"free_quantity"`
+	Products           []string             `json:"products"`
+	Regions            []string             `json:"regions"`
+	ValidFrom          *timestamp.Timestamp `json:"valid_from"`
+	ValidTo            *timestamp.Timestamp `json:"valid_to"`
+	MaxUses            int64                `json:"max_uses"`
+	MaxUsesPerCustomer int64                `json:"max_uses_per_customer"`
+	Enabled            bool                 `json:"enabled"`
+}
+
+type GetPromoRequest struct {
+	Id         string `json:"id"`
+	MerchantId string `json:"merchant_id"`
+}
+
+type PromoResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *Promo                          `json:"item,omitempty"`
+}
+
+type ListPromosRequest struct {
+	MerchantId string `json:"merchant_id"`
+	ProjectId  string `json:"project_id"`
+	Offset     int64  `json:"offset"`
+	Limit      int64  `json:"limit"`
+}
+
+type ListPromosResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*Promo                        `json:"items,omitempty"`
+}
+
+// PaymentFormPromoCodeRequest applies the promo code to the order, the applied code is removed if it's empty.
+type PaymentFormPromoCodeRequest struct {
+	OrderId string `json:"order_id"`
+	Code    string `json:"code"`
+}
+
+type GetRoyaltyReportDiscountsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	ReportId   string `json:"report_id"`
+}
+
+type GetRoyaltyReportDiscountsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*PromoDiscountSummaryItem     `json:"items,omitempty"`
+}
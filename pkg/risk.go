@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// RiskRule is a rule of the payment risk engine. The score of the rule is added to the score of the payment
+// when the value of the signal matches the condition of the rule.
+type RiskRule struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// MerchantId limits the rule to payments of the merchant, the rule is applied to all payments if it's empty.
+	MerchantId string `json:"merchant_id,omitempty"`
+	// Signal is one of RiskSignal* constants.
+	Signal string `json:"signal"`
+	// Operator is one of RiskOperator* constants, the value of the signal is compared with Value.
+	Operator string  `json:"operator"`
+	Value    float64 `json:"value"`
+	// Period in seconds is used by velocity signals and the amount ratio signal.
+	Period int64 `json:"period,omitempty"`
+	// Score is added to the score of the payment, negative scores decrease the risk.
+	Score int32 `json:"score"`
+	// Decision is applied to the payment when the rule matches regardless of the score, it's optional.
+	Decision  string               `json:"decision,omitempty"`
+	Enabled   bool                 `json:"enabled"`
+	Deleted   bool                 `json:"deleted"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt *timestamp.Timestamp `json:"updated_at"`
+}
+
+// RiskAssessmentSignal is a value of the signal calculated for the payment.
+type RiskAssessmentSignal struct {
+	Signal string  `json:"signal"`
+	Period int64   `json:"period,omitempty"`
+	Value  float64 `json:"value"`
+}
+
+// RiskAssessmentRule is a rule matched by the payment.
+type RiskAssessmentRule struct {
+	RuleId   string `json:"rule_id"`
+	Name     string `json:"name"`
+	Score    int32  `json:"score"`
+	Decision string `json:"decision,omitempty"`
+}
+
+// RiskAssessment is a result of the risk evaluation of the payment attempt. Assessments are also the history
+// used to calculate velocity signals of next payments.
+type RiskAssessment struct {
+	Id         string `json:"id"`
+	OrderId    string `json:"order_id"`
+	MerchantId string `json:"merchant_id"`
+	ProjectId  string `json:"project_id"`
+	CustomerId string `json:"customer_id,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Ip         string `json:"ip,omitempty"`
+	// CardFingerprint is a hash of the bank card, it's empty for other payment methods.
+	CardFingerprint string                  `json:"card_fingerprint,omitempty"`
+	Amount          float64                 `json:"amount"`
+	Currency        string                  `json:"currency"`
+	Signals         []*RiskAssessmentSignal `json:"signals,omitempty"`
+	Rules           []*RiskAssessmentRule   `json:"rules,omitempty"`
+	Score           int32                   `json:"score"`
+	// Decision is one of RiskDecision* constants.
+	Decision string `json:"decision"`
+	// Chargeback is set when the chargeback is received for the order.
+	Chargeback bool                 `json:"chargeback"`
+	CreatedAt  *timestamp.Timestamp `json:"created_at"`
+}
+
+type CreateOrUpdateRiskRuleRequest struct {
+	Id         string  `json:"id"`
+	Name       string  `json:"name"`
+	MerchantId string  `json:"merchant_id"`
+	Signal     string  `json:"signal"`
+	Operator   string  `json:"operator"`
+	Value      float64 `json:"value"`
+	Period     int64   `json:"period"`
+	Score      int32   `json:"score"`
+	Decision   string  `json:"decision"`
+	Enabled    bool    `json:"enabled"`
+}
+
+type RiskRuleResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *RiskRule                       `json:"item,omitempty"`
+}
+
+type ListRiskRulesRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+}
+
+type ListRiskRulesResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*RiskRule                     `json:"items,omitempty"`
+}
+
+type RiskRuleRequest struct {
+	Id string `json:"id"`
+}
+
+type GetOrderRiskAssessmentsRequest struct {
+	OrderId string `json:"order_id"`
+}
+
+type GetOrderRiskAssessmentsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Items   []*RiskAssessment               `json:"items,omitempty"`
+}
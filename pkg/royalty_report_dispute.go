@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// RoyaltyReportDispute is a structured dispute of the merchant on the royalty report.
+// Dispute contains line items referencing specific orders or product summary lines of the report
+// and a discussion thread between merchant and admin.
+type RoyaltyReportDispute struct {
+	Id              string `json:"id,omitempty"`
+	RoyaltyReportId string `json:"royalty_report_id,omitempty"`
+	MerchantId      string `json:"merchant_id,omitempty"`
+	// Status is one of RoyaltyReportDisputeStatusOpened or RoyaltyReportDisputeStatusResolved.
+	Status     string                         `json:"status,omitempty"`
+	Reason     string                         `json:"reason,omitempty"`
+	Items      []*RoyaltyReportDisputeItem    `json:"items,omitempty"`
+	Messages   []*RoyaltyReportDisputeMessage `json:"messages,omitempty"`
+	CreatedAt  *timestamp.Timestamp           `json:"created_at,omitempty"`
+	UpdatedAt  *timestamp.Timestamp           `json:"updated_at,omitempty"`
+	ResolvedAt *timestamp.Timestamp           `json:"resolved_at,omitempty"`
+}
+
+// RoyaltyReportDisputeItem is a line item of the dispute. Item references an order (by public order identifier)
+// or a product summary line of the report (by product name and region) and contains proposed adjustment
+// of the royalty in the report currency.
+type RoyaltyReportDisputeItem struct {
+	Id        string  `json:"id,omitempty"`
+	OrderUuid string  `json:"order_uuid,omitempty"`
+	Product   string  `json:"product,omitempty"`
+	Region    string  `json:"region,omitempty"`
+	Amount    float64 `json:"amount,omitempty"`
+	Reason    string  `json:"reason,omitempty"`
+	// Status is one of RoyaltyReportDisputeItemStatusPending, RoyaltyReportDisputeItemStatusAccepted
+	// or RoyaltyReportDisputeItemStatusRejected.
+	Status string `json:"status,omitempty"`
+	// AcceptedAmount is the amount of merchant_royalty_correction accounting entry created on the dispute resolution.
+	AcceptedAmount    float64 `json:"accepted_amount,omitempty"`
+	AccountingEntryId string  `json:"accounting_entry_id,omitempty"`
+}
+
+// RoyaltyReportDisputeMessage is a message of the dispute discussion thread.
+type RoyaltyReportDisputeMessage struct {
+	Id string `json:"id,omitempty"`
+	// Source is one of RoyaltyReportChangeSourceMerchant or RoyaltyReportChangeSourceAdmin.
+	Source    string               `json:"source,omitempty"`
+	UserId    string               `json:"user_id,omitempty"`
+	Message   string               `json:"message,omitempty"`
+	CreatedAt *timestamp.Timestamp `json:"created_at,omitempty"`
+}
+
+type OpenRoyaltyReportDisputeRequest struct {
+	ReportId   string                      `json:"report_id"`
+	MerchantId string                      `json:"merchant_id"`
+	UserId     string                      `json:"user_id"`
+	Reason     string                      `json:"reason"`
+	Items      []*RoyaltyReportDisputeItem `json:"items"`
+	Ip         string                      `json:"ip"`
+}
+
+type AddRoyaltyReportDisputeMessageRequest struct {
+	ReportId   string `json:"report_id"`
+	MerchantId string `json:"merchant_id"`
+	UserId     string `json:"user_id"`
+	Source     string `json:"source"`
+	Message    string `json:"message"`
+	Ip         string `json:"ip"`
+}
+
+type RoyaltyReportDisputeItemResolution struct {
+	ItemId     string `json:"item_id"`
+	IsAccepted bool   `json:"is_accepted"`
+	// Amount overrides the proposed adjustment of the item. Zero value means the proposed amount is accepted.
+	Amount float64 `json:"amount"`
+}
+
+type ResolveRoyaltyReportDisputeRequest struct {
+	ReportId   string                                `json:"report_id"`
+	MerchantId string                                `json:"merchant_id"`
+	UserId     string                                `json:"user_id"`
+	Items      []*RoyaltyReportDisputeItemResolution `json:"items"`
+	Message    string                                `json:"message"`
+	// Status is the new status of the royalty report after resolution. Pending status is used by default.
+	Status string `json:"status"`
+	Ip     string `json:"ip"`
+}
+
+type GetRoyaltyReportDisputeRequest struct {
+	ReportId   string `json:"report_id"`
+	MerchantId string `json:"merchant_id"`
+}
+
+type RoyaltyReportDisputeResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *RoyaltyReportDispute           `json:"item,omitempty"`
+}
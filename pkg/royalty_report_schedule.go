@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// MerchantRoyaltySchedule describes when royalty report periods of the merchant are closed.
+// Merchants without own schedule use the default weekly schedule from the service configuration.
+type MerchantRoyaltySchedule struct {
+	Id         string `json:"id,omitempty"`
+	MerchantId string `json:"merchant_id,omitempty"`
+	// Period is one of RoyaltyReportSchedulePeriodWeekly, RoyaltyReportSchedulePeriodBiWeekly or RoyaltyReportSchedulePeriodMonthly.
+	Period string `json:"period,omitempty"`
+	// AnchorDay is a day of week (0 - Sunday, 6 - Saturday) for weekly and bi-weekly periods
+	// and a day of month (1 - 28) for monthly periods.
+	AnchorDay int32 `json:"anchor_day,omitempty"`
+	// EndHour is an hour of the anchor day when the period ends.
+	EndHour  int32  `json:"end_hour,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// StartsAt is the moment when the schedule takes effect. Usually it equals to the end of the last royalty report
+	// period created before the schedule was changed, it is also used as a reference point for bi-weekly periods.
+	StartsAt  *timestamp.Timestamp `json:"starts_at,omitempty"`
+	CreatedAt *timestamp.Timestamp `json:"created_at,omitempty"`
+	UpdatedAt *timestamp.Timestamp `json:"updated_at,omitempty"`
+}
+
+type SetMerchantRoyaltyScheduleRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Period     string `json:"period"`
+	AnchorDay  int32  `json:"anchor_day"`
+	EndHour    int32  `json:"end_hour"`
+	Timezone   string `json:"timezone"`
+}
+
+type GetMerchantRoyaltyScheduleRequest struct {
+	MerchantId string `json:"merchant_id"`
+}
+
+type MerchantRoyaltyScheduleResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *MerchantRoyaltySchedule        `json:"item,omitempty"`
+}
@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// SanctionsListEntry is a person or an organisation from the sanctions list or the list of politically
+// exposed persons loaded from local files.
+type SanctionsListEntry struct {
+	Id string `json:"id"`
+	// Source is one of SanctionsListSource* constants.
+	Source string `json:"source"`
+	// ExternalId is an identifier of the entry in the source list, it's stable between loads of the list.
+	ExternalId string `json:"external_id"`
+	// Type is SanctionsListEntryTypeIndividual or SanctionsListEntryTypeEntity.
+	Type      string   `json:"type"`
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+	// Keys are prefixes of tokens of normalized names and aliases, they are used to find candidates for fuzzy matching.
+	Keys []string `json:"-"`
+	// Version is a time of the load of the list, entries of previous loads are removed.
+	Version   int64                `json:"version"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+// SanctionsScreeningSubject is a name checked by the screening.
+type SanctionsScreeningSubject struct {
+	// Role is one of SanctionsScreeningSubject* constants.
+	Role    string `json:"role"`
+	Name    string `json:"name"`
+	Country string `json:"country,omitempty"`
+}
+
+// SanctionsScreeningMatch is a potential match of the subject with the entry of the sanctions list.
+type SanctionsScreeningMatch struct {
+	SubjectRole string `json:"subject_role"`
+	SubjectName string `json:"subject_name"`
+	Source      string `json:"source"`
+	ExternalId  string `json:"external_id"`
+	// EntryName is a name or an alias of the entry matched by the subject.
+	EntryName      string   `json:"entry_name"`
+	EntryCountries []string `json:"entry_countries,omitempty"`
+	// Score is a similarity of names from 0 to 1.
+	Score float64 `json:"score"`
+}
+
+// SanctionsScreeningEvent is a record of the audit trail of the screening.
+type SanctionsScreeningEvent struct {
+	Status    string               `json:"status"`
+	UserId    string               `json:"user_id,omitempty"`
+	Comment   string               `json:"comment,omitempty"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+// SanctionsScreening is a result of the screening of the merchant. Screenings with potential matches hold
+// status changes of the merchant and payouts until the compliance user clears them.
+type SanctionsScreening struct {
+	Id         string `json:"id"`
+	MerchantId string `json:"merchant_id"`
+	// Trigger is one of SanctionsScreeningTrigger* constants.
+	Trigger  string                       `json:"trigger"`
+	Subjects []*SanctionsScreeningSubject `json:"subjects"`
+	Matches  []*SanctionsScreeningMatch   `json:"matches,omitempty"`
+	// Status is one of SanctionsScreeningStatus* constants.
+	Status     string                     `json:"status"`
+	ResolvedBy string                     `json:"resolved_by,omitempty"`
+	ResolvedAt *timestamp.Timestamp       `json:"resolved_at,omitempty"`
+	History    []*SanctionsScreeningEvent `json:"history"`
+	CreatedAt  *timestamp.Timestamp       `json:"created_at"`
+	UpdatedAt  *timestamp.Timestamp       `json:"updated_at"`
+}
+
+type ListSanctionsScreeningsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Status     string `json:"status"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+}
+
+type ListSanctionsScreeningsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*SanctionsScreening           `json:"items,omitempty"`
+}
+
+type ResolveSanctionsScreeningRequest struct {
+	Id string `json:"id"`
+	// Status is SanctionsScreeningStatusCleared for false positive matches
+	// or SanctionsScreeningStatusConfirmed for true matches.
+	Status  string `json:"status"`
+	UserId  string `json:"user_id"`
+	Comment string `json:"comment"`
+}
+
+type ScreenMerchantRequest struct {
+	MerchantId string `json:"merchant_id"`
+	UserId     string `json:"user_id"`
+}
+
+type SanctionsScreeningResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *SanctionsScreening             `json:"item,omitempty"`
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/paysuper/paysuper-proto/go/billingpb"
+)
+
+// VelocityLimit is a limit of count and amount of payment attempts with the same bank card, customer,
+// IP address, email or project during the sliding window.
+type VelocityLimit struct {
+	Id string `json:"id"`
+	// MerchantId limits the rule to payments of the merchant, the limit is applied to all payments if it's empty.
+	MerchantId string `json:"merchant_id,omitempty"`
+	// PaymentMethodId limits the rule to payments with the method, the limit is applied to all methods if it's empty.
+	PaymentMethodId string `json:"payment_method_id,omitempty"`
+	// Key is one of VelocityLimitKey* constants.
+	Key string `json:"key"`
+	// Window is a length of the sliding window in seconds.
+	Window int64 `json:"window"`
+	// MaxCount is a maximal count of payment attempts during the window, zero means no limit.
+	MaxCount int64 `json:"max_count"`
+	// MaxAmount is a maximal amount of payment attempts in Currency during the window, zero means no limit.
+	// Payments in other currencies aren't counted.
+	MaxAmount float64              `json:"max_amount"`
+	Currency  string               `json:"currency,omitempty"`
+	Enabled   bool                 `json:"enabled"`
+	Deleted   bool                 `json:"deleted"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+	UpdatedAt *timestamp.Timestamp `json:"updated_at"`
+}
+
+// VelocityLimitEvent is a fact of breach of the velocity limit by the order.
+type VelocityLimitEvent struct {
+	Id              string `json:"id"`
+	LimitId         string `json:"limit_id"`
+	MerchantId      string `json:"merchant_id"`
+	ProjectId       string `json:"project_id"`
+	OrderId         string `json:"order_id"`
+	PaymentMethodId string `json:"payment_method_id,omitempty"`
+	Key             string `json:"key"`
+	// Stage is VelocityLimitStageOrderCreate or VelocityLimitStagePayment.
+	Stage     string               `json:"stage"`
+	Window    int64                `json:"window"`
+	Count     int64                `json:"count"`
+	Amount    float64              `json:"amount"`
+	MaxCount  int64                `json:"max_count"`
+	MaxAmount float64              `json:"max_amount"`
+	Currency  string               `json:"currency,omitempty"`
+	CreatedAt *timestamp.Timestamp `json:"created_at"`
+}
+
+type CreateOrUpdateVelocityLimitRequest struct {
+	Id              string  `json:"id"`
+	MerchantId      string  `json:"merchant_id"`
+	PaymentMethodId string  `json:"payment_method_id"`
+	Key             string  `json:"key"`
+	Window          int64   `json:"window"`
+	MaxCount        int64   `json:"max_count"`
+	MaxAmount       float64 `json:"max_amount"`
+	Currency        string  `json:"currency"`
+	Enabled         bool    `json:"enabled"`
+}
+
+type VelocityLimitResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Item    *VelocityLimit                  `json:"item,omitempty"`
+}
+
+type ListVelocityLimitsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+}
+
+type ListVelocityLimitsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*VelocityLimit                `json:"items,omitempty"`
+}
+
+type VelocityLimitRequest struct {
+	Id string `json:"id"`
+}
+
+type ListVelocityLimitEventsRequest struct {
+	MerchantId string `json:"merchant_id"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+}
+
+type ListVelocityLimitEventsResponse struct {
+	Status  int32                           `json:"status"`
+	Message *billingpb.ResponseErrorMessage `json:"message,omitempty"`
+	Count   int64                           `json:"count"`
+	Items   []*VelocityLimitEvent           `json:"items,omitempty"`
+}